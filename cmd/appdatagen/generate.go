@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type schema struct {
+	Types  []schemaType `json:"types"`
+	Events []schemaType `json:"events"`
+}
+
+type schemaType struct {
+	Name   string        `json:"name"`
+	Doc    string        `json:"doc"`
+	Fields []schemaField `json:"fields"`
+}
+
+type schemaField struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Doc      string   `json:"doc"`
+	Optional bool     `json:"optional"`
+	Enum     []string `json:"enum"`
+}
+
+var (
+	identifierRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	goTypes          = map[string]string{
+		"string":  "string",
+		"integer": "int64",
+		"number":  "float64",
+		"boolean": "bool",
+	}
+	tsTypes = map[string]string{
+		"string":  "string",
+		"integer": "number",
+		"number":  "number",
+		"boolean": "boolean",
+	}
+)
+
+const generatedHeader = "Code generated by appdatagen. DO NOT EDIT."
+
+func parseSchema(data []byte) (s schema, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(&s); err != nil {
+		return
+	}
+
+	names := make(map[string]bool)
+
+	for _, types := range [][]schemaType{s.Types, s.Events} {
+		for _, t := range types {
+			if !identifierRegexp.MatchString(t.Name) {
+				return s, fmt.Errorf(`invalid name "%s"`, t.Name)
+			}
+			if names[exported(t.Name)] {
+				return s, fmt.Errorf(`duplicated name "%s"`, t.Name)
+			}
+			names[exported(t.Name)] = true
+
+			fields := make(map[string]bool)
+
+			for _, field := range t.Fields {
+				if !identifierRegexp.MatchString(field.Name) {
+					return s, fmt.Errorf(`%s: invalid field name "%s"`, t.Name, field.Name)
+				}
+				if fields[field.Name] {
+					return s, fmt.Errorf(`%s: duplicated field "%s"`, t.Name, field.Name)
+				}
+				fields[field.Name] = true
+
+				if _, ok := goTypes[strings.TrimPrefix(field.Type, "[]")]; !ok {
+					return s, fmt.Errorf(`%s.%s: invalid type "%s"`, t.Name, field.Name, field.Type)
+				}
+				if len(field.Enum) > 0 && field.Type != "string" {
+					return s, fmt.Errorf(`%s.%s: enum just for strings`, t.Name, field.Name)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// exported returns the Go exported name of the given schema name
+// ("peerId" -> "PeerId", "active_speaker" -> "ActiveSpeaker").
+func exported(name string) string {
+	var b strings.Builder
+
+	for _, part := range strings.Split(name, "_") {
+		if len(part) > 0 {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+
+	return b.String()
+}
+
+// appDataKeys returns the field names of the appData types, sorted.
+func (s schema) appDataKeys() (keys []string) {
+	seen := make(map[string]bool)
+
+	for _, t := range s.Types {
+		for _, field := range t.Fields {
+			if !seen[field.Name] {
+				seen[field.Name] = true
+				keys = append(keys, field.Name)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return
+}
+
+func writeGoDoc(b *bytes.Buffer, indent, doc string) {
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		if len(line) > 0 {
+			fmt.Fprintf(b, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+func generateGo(s schema, pkg string) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// %s\n\npackage %s\n\n", generatedHeader, pkg)
+
+	if keys := s.appDataKeys(); len(keys) > 0 {
+		b.WriteString("// appData keys.\nconst (\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "\tAppDataKey%s = %q\n", exported(key), key)
+		}
+		b.WriteString(")\n\n")
+	}
+
+	if len(s.Events) > 0 {
+		b.WriteString("// Event names.\nconst (\n")
+		for _, event := range s.Events {
+			fmt.Fprintf(&b, "\tEvent%s = %q\n", exported(event.Name), event.Name)
+		}
+		b.WriteString(")\n\n")
+	}
+
+	writeStruct := func(name string, t schemaType) {
+		writeGoDoc(&b, "", t.Doc)
+		fmt.Fprintf(&b, "type %s struct {\n", name)
+
+		for _, field := range t.Fields {
+			goType := goTypes[strings.TrimPrefix(field.Type, "[]")]
+			if strings.HasPrefix(field.Type, "[]") {
+				goType = "[]" + goType
+			}
+			tag := field.Name
+			if field.Optional {
+				tag += ",omitempty"
+			}
+
+			writeGoDoc(&b, "\t", field.Doc)
+			fmt.Fprintf(&b, "\t%s %s `json:\"%s\"`\n", exported(field.Name), goType, tag)
+		}
+
+		b.WriteString("}\n\n")
+
+		for _, field := range t.Fields {
+			if len(field.Enum) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(&b, "// Values of %s.%s.\nconst (\n", name, exported(field.Name))
+			for _, value := range field.Enum {
+				fmt.Fprintf(&b, "\t%s%s%s = %q\n", name, exported(field.Name), exported(value), value)
+			}
+			b.WriteString(")\n\n")
+		}
+	}
+
+	for _, t := range s.Types {
+		writeStruct(exported(t.Name), t)
+	}
+	for _, event := range s.Events {
+		writeStruct(exported(event.Name)+"Event", event)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func writeTsDoc(b *bytes.Buffer, indent, doc string) {
+	if doc = strings.TrimSpace(doc); len(doc) > 0 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.Replace(doc, "\n", " ", -1))
+	}
+}
+
+func generateTypeScript(s schema) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// %s\n\n", generatedHeader)
+
+	if keys := s.appDataKeys(); len(keys) > 0 {
+		b.WriteString("export const AppDataKeys = {\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "\t%s: '%s',\n", key, key)
+		}
+		b.WriteString("} as const;\n\n")
+	}
+
+	if len(s.Events) > 0 {
+		b.WriteString("export const Events = {\n")
+		for _, event := range s.Events {
+			fmt.Fprintf(&b, "\t%s: '%s',\n", event.Name, event.Name)
+		}
+		b.WriteString("} as const;\n\n")
+	}
+
+	writeInterface := func(name string, t schemaType) {
+		writeTsDoc(&b, "", t.Doc)
+		fmt.Fprintf(&b, "export interface %s {\n", name)
+
+		for _, field := range t.Fields {
+			tsType := tsTypes[strings.TrimPrefix(field.Type, "[]")]
+			if len(field.Enum) > 0 {
+				values := make([]string, len(field.Enum))
+				for i, value := range field.Enum {
+					values[i] = fmt.Sprintf("'%s'", value)
+				}
+				tsType = strings.Join(values, " | ")
+			}
+			if strings.HasPrefix(field.Type, "[]") {
+				tsType += "[]"
+			}
+			optional := ""
+			if field.Optional {
+				optional = "?"
+			}
+
+			writeTsDoc(&b, "\t", field.Doc)
+			fmt.Fprintf(&b, "\t%s%s: %s;\n", field.Name, optional, tsType)
+		}
+
+		b.WriteString("}\n\n")
+	}
+
+	for _, t := range s.Types {
+		writeInterface(exported(t.Name), t)
+	}
+	for _, event := range s.Events {
+		writeInterface(exported(event.Name)+"Event", event)
+	}
+
+	return append(bytes.TrimRight(b.Bytes(), "\n"), '\n')
+}
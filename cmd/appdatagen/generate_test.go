@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"types": [
+		{"name": "ProducerAppData", "doc": "appData of the Producers.", "fields": [
+			{"name": "peerId", "type": "string", "doc": "Id of the peer."},
+			{"name": "source", "type": "string", "enum": ["mic", "screen"]},
+			{"name": "tags", "type": "[]string", "optional": true}
+		]}
+	],
+	"events": [
+		{"name": "activeSpeaker", "fields": [
+			{"name": "peerId", "type": "string"},
+			{"name": "volume", "type": "number"}
+		]}
+	]
+}`
+
+func TestGenerateGo(t *testing.T) {
+	s, err := parseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	code, err := generateGo(s, "appdata")
+	require.NoError(t, err)
+
+	assert.Equal(t, `// Code generated by appdatagen. DO NOT EDIT.
+
+package appdata
+
+// appData keys.
+const (
+	AppDataKeyPeerId = "peerId"
+	AppDataKeySource = "source"
+	AppDataKeyTags   = "tags"
+)
+
+// Event names.
+const (
+	EventActiveSpeaker = "activeSpeaker"
+)
+
+// appData of the Producers.
+type ProducerAppData struct {
+	// Id of the peer.
+	PeerId string   `+"`json:\"peerId\"`"+`
+	Source string   `+"`json:\"source\"`"+`
+	Tags   []string `+"`json:\"tags,omitempty\"`"+`
+}
+
+// Values of ProducerAppData.Source.
+const (
+	ProducerAppDataSourceMic    = "mic"
+	ProducerAppDataSourceScreen = "screen"
+)
+
+type ActiveSpeakerEvent struct {
+	PeerId string  `+"`json:\"peerId\"`"+`
+	Volume float64 `+"`json:\"volume\"`"+`
+}
+`, string(code))
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	s, err := parseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	assert.Equal(t, `// Code generated by appdatagen. DO NOT EDIT.
+
+export const AppDataKeys = {
+	peerId: 'peerId',
+	source: 'source',
+	tags: 'tags',
+} as const;
+
+export const Events = {
+	activeSpeaker: 'activeSpeaker',
+} as const;
+
+/** appData of the Producers. */
+export interface ProducerAppData {
+	/** Id of the peer. */
+	peerId: string;
+	source: 'mic' | 'screen';
+	tags?: string[];
+}
+
+export interface ActiveSpeakerEvent {
+	peerId: string;
+	volume: number;
+}
+`, string(generateTypeScript(s)))
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"types": [{"name": "A", "fields": [{"name": "a", "type": "date"}]}]}`,
+		`{"types": [{"name": "A", "fields": [{"name": "a", "type": "integer", "enum": ["1"]}]}]}`,
+		`{"types": [{"name": "A", "fields": [{"name": "a", "type": "string"}, {"name": "a", "type": "string"}]}]}`,
+		`{"types": [{"name": "A"}], "events": [{"name": "a"}]}`,
+		`{"types": [{"name": "A-B"}]}`,
+		`{"typs": []}`,
+	} {
+		_, err := parseSchema([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
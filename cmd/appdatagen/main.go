@@ -0,0 +1,70 @@
+// Command appdatagen generates, from a JSON schema of the appData of the
+// mediasoup entities and of the application events, the Go constants and
+// structs of the SFU and the TypeScript definitions of the browser client,
+// so that both sides share the same contract:
+//
+//	appdatagen -schema appdata.json -go appdata/appdata.go -package appdata -ts client/appdata.ts
+//
+// The schema lists the appData types and the events with their fields:
+//
+//	{
+//	  "types": [
+//	    {"name": "ProducerAppData", "doc": "appData of the Producers.", "fields": [
+//	      {"name": "peerId", "type": "string"},
+//	      {"name": "source", "type": "string", "enum": ["mic", "webcam", "screen"]},
+//	      {"name": "tags", "type": "[]string", "optional": true}
+//	    ]}
+//	  ],
+//	  "events": [
+//	    {"name": "activeSpeaker", "fields": [{"name": "peerId", "type": "string"}]}
+//	  ]
+//	}
+//
+// Field types are "string", "integer", "number", "boolean" and their "[]"
+// arrays.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "path of the JSON schema")
+	goPath := flag.String("go", "", "path of the generated Go file")
+	goPackage := flag.String("package", "appdata", "package of the generated Go file")
+	tsPath := flag.String("ts", "", "path of the generated TypeScript file")
+	flag.Parse()
+
+	if len(*schemaPath) == 0 || (len(*goPath) == 0 && len(*tsPath) == 0) {
+		flag.Usage()
+		log.Fatal("-schema and -go or -ts are required")
+	}
+
+	data, err := ioutil.ReadFile(*schemaPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	schema, err := parseSchema(data)
+	if err != nil {
+		log.Fatalf("invalid schema: %s", err)
+	}
+
+	if len(*goPath) > 0 {
+		code, err := generateGo(schema, *goPackage)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = ioutil.WriteFile(*goPath, code, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(*tsPath) > 0 {
+		if err = ioutil.WriteFile(*tsPath, generateTypeScript(schema), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Command workermatrix runs the test suite against several mediasoup-worker
+// versions, so that regressions in version compatibility are caught before
+// releases:
+//
+//	go run ./cmd/workermatrix -versions 3.10.13,3.12.16,3.14.6 -dir .workers
+//
+// The worker of each version is taken from <dir>/<version>/mediasoup-worker
+// (the versions without prebuilt worker, or for another platform, must be
+// built and copied there), else acquired from the prebuilt workers of the
+// mediasoup releases with workerbin.Acquire, cached in dir and verified
+// against the checksum given for the version:
+//
+//	go run ./cmd/workermatrix -versions 3.14.6 -checksums 3.14.6=<sha256>
+//
+// The suite runs with MEDIASOUP_WORKER_BIN and MEDIASOUP_WORKER_VERSION set,
+// the tests of the features not supported by a version being skipped (see
+// WorkerFeatureMatrix).
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+)
+
+func main() {
+	versions := flag.String("versions", strings.Join(defaultVersions, ","), "comma separated worker versions")
+	dir := flag.String("dir", ".workers", "directory of the workers, by version")
+	baseUrl := flag.String("url", defaultBaseUrl, "URL of the mediasoup releases")
+	checksums := flag.String("checksums", "",
+		"comma separated <version>=<sha256> of the prebuilt worker archives")
+	run := flag.String("run", "", "run only the tests matching the regexp")
+	packages := flag.String("packages", "./mediasoup/", "packages to test")
+	flag.Parse()
+
+	parsedChecksums, err := parseChecksums(*checksums)
+	if err != nil {
+		flag.Usage()
+		log.Fatal(err)
+	}
+
+	// The worker of each version is given to the suite, not this one.
+	os.Unsetenv("MEDIASOUP_WORKER_BIN")
+
+	matrix := matrix{
+		versions:  splitVersions(*versions),
+		dir:       *dir,
+		baseUrl:   *baseUrl,
+		checksums: parsedChecksums,
+		testArgs:  append([]string{"test", "-count=1"}, testRunArgs(*run, *packages)...),
+		output:    os.Stdout,
+		backoff:   mediasoup.DefaultBackoff,
+	}
+
+	if len(matrix.versions) == 0 {
+		flag.Usage()
+		log.Fatal("-versions is required")
+	}
+
+	results := matrix.run()
+
+	printResults(os.Stdout, results)
+
+	for _, result := range results {
+		if result.err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func testRunArgs(run, packages string) (args []string) {
+	if len(run) > 0 {
+		args = append(args, "-run", run)
+	}
+
+	return append(args, strings.Fields(packages)...)
+}
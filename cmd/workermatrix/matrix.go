@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/jiyeyuran/mediasoup-go/mediasoup/workerbin"
+)
+
+// Worker versions tested by default: the oldest supported one and the
+// versions introducing features of WorkerFeatureMatrix.
+var defaultVersions = []string{"3.10.13", "3.12.16", "3.14.6"}
+
+const defaultBaseUrl = "https://github.com/versatica/mediasoup/releases/download"
+
+const workerBinName = "mediasoup-worker"
+
+type matrix struct {
+	versions []string
+	dir      string
+	// Url of the releases (see workerbin.Options).
+	baseUrl string
+	// SHA-256 of the prebuilt worker archives, by version.
+	checksums map[string]string
+	// Arguments of the go command running the suite.
+	testArgs []string
+	output   io.Writer
+	// Backoff of the downloads.
+	backoff mediasoup.Backoff
+	// Runs the suite, exec by default.
+	runSuite func(workerBin, version string) error
+}
+
+type result struct {
+	version  string
+	duration time.Duration
+	// Whether the worker couldn't be fetched.
+	unavailable bool
+	err         error
+}
+
+func (m *matrix) run() (results []result) {
+	runSuite := m.runSuite
+	if runSuite == nil {
+		runSuite = m.execSuite
+	}
+
+	for _, version := range m.versions {
+		fmt.Fprintf(m.output, "=== worker %s\n", version)
+
+		started := time.Now()
+
+		workerBin, err := m.fetchWorker(version)
+		if err != nil {
+			results = append(results, result{version: version, unavailable: true, err: err})
+			continue
+		}
+
+		err = runSuite(workerBin, version)
+
+		results = append(results, result{
+			version:  version,
+			duration: time.Since(started),
+			err:      err,
+		})
+	}
+
+	return
+}
+
+func (m *matrix) execSuite(workerBin, version string) error {
+	cmd := exec.Command("go", m.testArgs...)
+	cmd.Env = append(os.Environ(),
+		"MEDIASOUP_WORKER_BIN="+workerBin,
+		"MEDIASOUP_WORKER_VERSION="+version,
+	)
+	cmd.Stdout = m.output
+	cmd.Stderr = m.output
+
+	return cmd.Run()
+}
+
+// fetchWorker returns the absolute path of the worker of the given version:
+// the one copied to <dir>/<version> if any, else the prebuilt one acquired in
+// dir.
+func (m *matrix) fetchWorker(version string) (workerBin string, err error) {
+	dir, err := filepath.Abs(m.dir)
+	if err != nil {
+		return
+	}
+
+	workerBin = filepath.Join(dir, version, workerBinName)
+
+	if _, err = os.Stat(workerBin); err == nil {
+		return
+	}
+
+	fmt.Fprintf(m.output, "acquiring the prebuilt worker %s\n", version)
+
+	backoff := m.backoff
+	backoff.OnRetry = func(attempt int, delay time.Duration, err error) {
+		fmt.Fprintf(m.output, "download failed, retrying in %s: %s\n", delay.Round(time.Millisecond), err)
+	}
+
+	workerBin, err = workerbin.Acquire(context.Background(), version,
+		workerbin.WithCacheDir(dir),
+		workerbin.WithBaseUrl(m.baseUrl),
+		workerbin.WithChecksum(m.checksums[version]),
+		workerbin.WithBackoff(backoff),
+	)
+	if err != nil {
+		return "", fmt.Errorf("download worker %s: %w", version, err)
+	}
+
+	return
+}
+
+func splitVersions(versions string) (split []string) {
+	for _, version := range strings.Split(versions, ",") {
+		if version = strings.TrimSpace(version); len(version) > 0 {
+			split = append(split, version)
+		}
+	}
+
+	return
+}
+
+// parseChecksums parses comma separated "<version>=<sha256>" pairs.
+func parseChecksums(checksums string) (map[string]string, error) {
+	parsed := make(map[string]string)
+
+	for _, pair := range splitVersions(checksums) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf(`invalid checksum "%s", expected <version>=<sha256>`, pair)
+		}
+
+		parsed[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return parsed, nil
+}
+
+func printResults(w io.Writer, results []result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "WORKER\tRESULT\tDURATION")
+
+	for _, result := range results {
+		status := "ok"
+
+		switch {
+		case result.unavailable:
+			status = "unavailable: " + result.err.Error()
+		case result.err != nil:
+			status = "FAIL: " + result.err.Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.version, status, result.duration.Round(time.Second))
+	}
+
+	tw.Flush()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func workerArchive(t *testing.T, name, content string) []byte {
+	buf := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buf)
+	archive := tar.NewWriter(gz)
+
+	require.NoError(t, archive.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0755,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err := archive.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func TestSplitVersions(t *testing.T) {
+	assert.Equal(t, []string{"3.10.13", "3.14.6"}, splitVersions(" 3.10.13, ,3.14.6,"))
+	assert.Empty(t, splitVersions(""))
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestMatrix_Run(t *testing.T) {
+	// Not to use the worker of the environment.
+	t.Setenv("MEDIASOUP_WORKER_BIN", "")
+
+	archive := workerArchive(t, "mediasoup-worker-3.14.6/mediasoup-worker", "worker 3.14.6")
+
+	requested := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested++
+		if !strings.HasPrefix(r.URL.Path, "/3.14.6/mediasoup-worker-3.14.6-") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	// 3.10.13 is already there, as e.g. built from source.
+	cached := filepath.Join(dir, "3.10.13", workerBinName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(cached), 0755))
+	require.NoError(t, os.WriteFile(cached, []byte("worker 3.10.13"), 0755))
+
+	suites := map[string]string{}
+
+	m := matrix{
+		versions: []string{"3.10.13", "3.12.16", "3.14.6", "3.14.7"},
+		dir:      dir,
+		baseUrl:  server.URL,
+		checksums: map[string]string{
+			"3.12.16": checksum(archive),
+			"3.14.6":  checksum(archive),
+		},
+		output: io.Discard,
+		runSuite: func(workerBin, version string) error {
+			suites[version] = workerBin
+			if version == "3.10.13" {
+				return errors.New("exit status 1")
+			}
+			return nil
+		},
+	}
+
+	results := m.run()
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "3.10.13", results[0].version)
+	assert.EqualError(t, results[0].err, "exit status 1")
+	assert.Equal(t, "3.12.16", results[1].version)
+	assert.True(t, results[1].unavailable)
+	assert.Error(t, results[1].err)
+	assert.Equal(t, "3.14.6", results[2].version)
+	assert.NoError(t, results[2].err)
+	// No checksum to verify the archive.
+	assert.Equal(t, "3.14.7", results[3].version)
+	assert.True(t, results[3].unavailable)
+
+	// Only the missing workers having a checksum are downloaded.
+	assert.Equal(t, 2, requested)
+	assert.Equal(t, cached, suites["3.10.13"])
+	assert.Equal(t, filepath.Join(dir, "3.14.6"), filepath.Dir(filepath.Dir(suites["3.14.6"])))
+
+	data, err := os.ReadFile(suites["3.14.6"])
+	require.NoError(t, err)
+	assert.Equal(t, "worker 3.14.6", string(data))
+
+	info, err := os.Stat(suites["3.14.6"])
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100)
+
+	// Acquired from the cache afterwards.
+	workerBin, err := m.fetchWorker("3.14.6")
+	require.NoError(t, err)
+	assert.Equal(t, suites["3.14.6"], workerBin)
+	assert.Equal(t, 2, requested)
+
+	output := bytes.NewBuffer(nil)
+	printResults(output, results)
+	assert.Contains(t, output.String(), "FAIL: exit status 1")
+	assert.Contains(t, output.String(), "unavailable: download worker 3.12.16")
+}
+
+func TestMatrix_MissingBinary(t *testing.T) {
+	t.Setenv("MEDIASOUP_WORKER_BIN", "")
+
+	archive := workerArchive(t, "README.md", "readme")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	m := matrix{
+		dir:       t.TempDir(),
+		baseUrl:   server.URL,
+		checksums: map[string]string{"3.14.6": checksum(archive)},
+		output:    io.Discard,
+	}
+
+	_, err := m.fetchWorker("3.14.6")
+	assert.Error(t, err)
+}
+
+func TestMatrix_RetriesDownload(t *testing.T) {
+	t.Setenv("MEDIASOUP_WORKER_BIN", "")
+
+	archive := workerArchive(t, "mediasoup-worker", "worker")
+
+	requested := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested++
+		if requested == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	m := matrix{
+		dir:       t.TempDir(),
+		baseUrl:   server.URL,
+		checksums: map[string]string{"3.14.6": checksum(archive)},
+		output:    io.Discard,
+		backoff:   mediasoup.Backoff{Initial: time.Millisecond, MaxRetries: 2},
+	}
+
+	_, err := m.fetchWorker("3.14.6")
+	require.NoError(t, err)
+	assert.Equal(t, 2, requested)
+}
+
+func TestParseChecksums(t *testing.T) {
+	checksums, err := parseChecksums("3.12.16=abc, 3.14.6=def")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"3.12.16": "abc", "3.14.6": "def"}, checksums)
+
+	_, err = parseChecksums("3.12.16")
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+// Command broadcast serves one-to-many rooms through the broadcaster REST API:
+// a broadcaster (e.g. the ffmpeg script of mediasoup-demo, over plain RTP)
+// produces, and any number of viewers create a WebRtcTransport and consume.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+)
+
+func main() {
+	addr := flag.String("addr", ":4443", "HTTP listen address")
+	ip := flag.String("ip", "127.0.0.1", "listen IP of the transports")
+	announcedIp := flag.String("announced-ip", "", "announced IP of the transports")
+	flag.Parse()
+
+	worker, err := mediasoup.CreateWorker("", mediasoup.WithLogLevel("warn"))
+	if err != nil {
+		log.Fatalf("create worker: %s", err)
+	}
+	defer worker.Close()
+
+	handler := newHandler(worker, mediasoup.ListenIp{Ip: *ip, AnnouncedIp: *announcedIp})
+
+	log.Printf("serving broadcasts on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
+
+func newHandler(worker *mediasoup.Worker, listenIp mediasoup.ListenIp) http.Handler {
+	var mu sync.Mutex
+	routers := make(map[string]*mediasoup.Router)
+
+	getRouter := func(roomId string) (*mediasoup.Router, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if router, ok := routers[roomId]; ok && !router.Closed() {
+			return router, nil
+		}
+
+		router, err := worker.CreateRouterWithProfile(mediasoup.RouterProfileWebinar)
+		if err != nil {
+			return nil, err
+		}
+		routers[roomId] = router
+
+		logProducers(roomId, router)
+
+		return router, nil
+	}
+
+	return mediasoup.NewBroadcasterHandler(mediasoup.BroadcasterHandlerOptions{
+		GetRouter: getRouter,
+		WebRtcTransportParams: mediasoup.CreateWebRtcTransportParams{
+			ListenIps: []mediasoup.ListenIp{listenIp},
+			EnableUdp: true,
+			EnableTcp: true,
+			PreferUdp: true,
+		},
+		PlainRtpListenIp: listenIp,
+	})
+}
+
+// logProducers logs the streams published in the room and their viewers.
+func logProducers(roomId string, router *mediasoup.Router) {
+	router.Observer().On("newtransport", func(transport mediasoup.Transport) {
+		transport.Observer().On("newproducer", func(producer *mediasoup.Producer) {
+			log.Printf("room %s: %s stream published [producerId:%s]", roomId, producer.Kind(), producer.Id())
+
+			producer.Observer().On("close", func() {
+				log.Printf("room %s: stream unpublished [producerId:%s]", roomId, producer.Id())
+			})
+		})
+		transport.Observer().On("newconsumer", func(consumer *mediasoup.Consumer) {
+			log.Printf("room %s: new viewer [producerId:%s]", roomId, consumer.ProducerId())
+		})
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	if len(os.Getenv("MEDIASOUP_WORKER_BIN")) == 0 {
+		os.Setenv("MEDIASOUP_WORKER_BIN", "../../mediasoup-worker")
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	worker, err := mediasoup.CreateWorker("")
+	require.NoError(t, err)
+	defer worker.Close()
+
+	server := httptest.NewServer(newHandler(worker, mediasoup.ListenIp{Ip: "127.0.0.1"}))
+	defer server.Close()
+
+	rsp, err := http.Get(server.URL + "/rooms/live")
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	var rtpCapabilities mediasoup.RtpCapabilities
+	require.NoError(t, json.NewDecoder(rsp.Body).Decode(&rtpCapabilities))
+	assert.NotEmpty(t, rtpCapabilities.Codecs)
+}
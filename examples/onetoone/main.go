@@ -0,0 +1,144 @@
+// Command onetoone serves 1:1 calls: rooms of at most two peers, each
+// producing its media and consuming the media of the other through the
+// broadcaster REST API.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+)
+
+func main() {
+	addr := flag.String("addr", ":4443", "HTTP listen address")
+	ip := flag.String("ip", "127.0.0.1", "listen IP of the transports")
+	announcedIp := flag.String("announced-ip", "", "announced IP of the transports")
+	flag.Parse()
+
+	worker, err := mediasoup.CreateWorker("", mediasoup.WithLogLevel("warn"))
+	if err != nil {
+		log.Fatalf("create worker: %s", err)
+	}
+	defer worker.Close()
+
+	handler := newHandler(worker, mediasoup.ListenIp{Ip: *ip, AnnouncedIp: *announcedIp})
+
+	log.Printf("serving 1:1 calls on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
+
+// callHandler serves the broadcaster API, rejecting a third peer in a room.
+type callHandler struct {
+	mu    sync.Mutex
+	next  http.Handler
+	peers map[string]map[string]bool
+}
+
+func newHandler(worker *mediasoup.Worker, listenIp mediasoup.ListenIp) http.Handler {
+	var mu sync.Mutex
+	routers := make(map[string]*mediasoup.Router)
+
+	getRouter := func(roomId string) (*mediasoup.Router, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if router, ok := routers[roomId]; ok && !router.Closed() {
+			return router, nil
+		}
+
+		router, err := worker.CreateRouterWithProfile(mediasoup.RouterProfileCall)
+		if err != nil {
+			return nil, err
+		}
+		routers[roomId] = router
+
+		return router, nil
+	}
+
+	return &callHandler{
+		next: mediasoup.NewBroadcasterHandler(mediasoup.BroadcasterHandlerOptions{
+			GetRouter: getRouter,
+			WebRtcTransportParams: mediasoup.CreateWebRtcTransportParams{
+				ListenIps: []mediasoup.ListenIp{listenIp},
+				EnableUdp: true,
+				EnableTcp: true,
+				PreferUdp: true,
+			},
+			PlainRtpListenIp: listenIp,
+		}),
+		peers: make(map[string]map[string]bool),
+	}
+}
+
+func (h *callHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	// POST /rooms/:roomId/broadcasters
+	case len(parts) == 3 && parts[0] == "rooms" && parts[2] == "broadcasters" && r.Method == http.MethodPost:
+		h.join(w, r, parts[1])
+
+	// DELETE /rooms/:roomId/broadcasters/:broadcasterId
+	case len(parts) == 4 && parts[0] == "rooms" && parts[2] == "broadcasters" && r.Method == http.MethodDelete:
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.next.ServeHTTP(recorder, r)
+
+		if recorder.status < 300 {
+			h.mu.Lock()
+			delete(h.peers[parts[1]], parts[3])
+			h.mu.Unlock()
+		}
+
+	default:
+		h.next.ServeHTTP(w, r)
+	}
+}
+
+func (h *callHandler) join(w http.ResponseWriter, r *http.Request, roomId string) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var peer struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(body, &peer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.peers[roomId]) >= 2 {
+		http.Error(w, "room is full", http.StatusConflict)
+		return
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(recorder, r)
+
+	if recorder.status < 300 {
+		if h.peers[roomId] == nil {
+			h.peers[roomId] = make(map[string]bool)
+		}
+		h.peers[roomId][peer.Id] = true
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
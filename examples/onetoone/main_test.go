@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	if len(os.Getenv("MEDIASOUP_WORKER_BIN")) == 0 {
+		os.Setenv("MEDIASOUP_WORKER_BIN", "../../mediasoup-worker")
+	}
+}
+
+func TestOneToOne(t *testing.T) {
+	worker, err := mediasoup.CreateWorker("")
+	require.NoError(t, err)
+	defer worker.Close()
+
+	server := httptest.NewServer(newHandler(worker, mediasoup.ListenIp{Ip: "127.0.0.1"}))
+	defer server.Close()
+
+	rsp, err := http.Get(server.URL + "/rooms/call")
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	join := func(peerId string) int {
+		body := fmt.Sprintf(`{"id":"%s","displayName":"%s","device":{"name":"test"}}`, peerId, peerId)
+		rsp, err := http.Post(server.URL+"/rooms/call/broadcasters", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, join("alice"))
+	assert.Equal(t, http.StatusOK, join("bob"))
+	assert.Equal(t, http.StatusConflict, join("carol"))
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/rooms/call/broadcasters/bob", nil)
+	rsp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	assert.Equal(t, http.StatusOK, join("carol"))
+}
@@ -0,0 +1,173 @@
+// Command recordingbot serves rooms through the broadcaster REST API and
+// records each of them into a composite file with ffmpeg, with its manifest
+// next to it, until interrupted.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/jiyeyuran/mediasoup-go/mediasoup/recorder"
+)
+
+func main() {
+	addr := flag.String("addr", ":4443", "HTTP listen address")
+	ip := flag.String("ip", "127.0.0.1", "listen IP of the transports")
+	announcedIp := flag.String("announced-ip", "", "announced IP of the transports")
+	outputDir := flag.String("output-dir", ".", "directory of the recordings")
+	flag.Parse()
+
+	worker, err := mediasoup.CreateWorker("", mediasoup.WithLogLevel("warn"))
+	if err != nil {
+		log.Fatalf("create worker: %s", err)
+	}
+	defer worker.Close()
+
+	bot := newBot(worker, mediasoup.ListenIp{Ip: *ip, AnnouncedIp: *announcedIp}, *outputDir)
+
+	go func() {
+		log.Printf("serving recorded rooms on %s", *addr)
+		log.Fatal(http.ListenAndServe(*addr, bot.handler))
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	<-signals
+
+	bot.stop()
+}
+
+type bot struct {
+	mu         sync.Mutex
+	worker     *mediasoup.Worker
+	listenIp   mediasoup.ListenIp
+	outputDir  string
+	routers    map[string]*mediasoup.Router
+	recordings map[string]*recording
+	handler    http.Handler
+}
+
+// recording of a room, its Producers being added and removed in order.
+type recording struct {
+	mu       sync.Mutex
+	recorder *recorder.CompositeRecorder
+	started  bool
+}
+
+func newBot(worker *mediasoup.Worker, listenIp mediasoup.ListenIp, outputDir string) *bot {
+	b := &bot{
+		worker:     worker,
+		listenIp:   listenIp,
+		outputDir:  outputDir,
+		routers:    make(map[string]*mediasoup.Router),
+		recordings: make(map[string]*recording),
+	}
+
+	b.handler = mediasoup.NewBroadcasterHandler(mediasoup.BroadcasterHandlerOptions{
+		GetRouter: b.getRouter,
+		WebRtcTransportParams: mediasoup.CreateWebRtcTransportParams{
+			ListenIps: []mediasoup.ListenIp{listenIp},
+			EnableUdp: true,
+			EnableTcp: true,
+			PreferUdp: true,
+		},
+		PlainRtpListenIp: listenIp,
+	})
+
+	return b
+}
+
+func (b *bot) getRouter(roomId string) (*mediasoup.Router, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if router, ok := b.routers[roomId]; ok && !router.Closed() {
+		return router, nil
+	}
+
+	router, err := b.worker.CreateRouterWithProfile(mediasoup.RouterProfileCall)
+	if err != nil {
+		return nil, err
+	}
+	b.routers[roomId] = router
+
+	rec := &recording{
+		recorder: recorder.NewCompositeRecorder(router, recorder.CompositeRecorderOptions{
+			ListenIp:   b.listenIp,
+			OutputPath: filepath.Join(b.outputDir, roomId+".mkv"),
+			Layout:     recorder.RecordingLayoutSpeaker,
+			PeerKey:    "broadcasterId",
+		}),
+	}
+	rec.recorder.On("segmentstart", func(segment string) {
+		log.Printf("room %s: recording %s", roomId, segment)
+	})
+	rec.recorder.On("failure", func(err error) {
+		log.Printf("room %s: recording failed: %s", roomId, err)
+	})
+	b.recordings[roomId] = rec
+
+	router.Observer().On("newtransport", func(transport mediasoup.Transport) {
+		transport.Observer().On("newproducer", func(producer *mediasoup.Producer) {
+			// Not to delay the answer to the broadcaster.
+			go rec.add(producer)
+
+			producer.Observer().On("close", func() {
+				go rec.remove(producer.Id())
+			})
+		})
+	})
+
+	return router, nil
+}
+
+func (r *recording) add(producer *mediasoup.Producer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+
+	if r.started {
+		err = r.recorder.AddProducer(producer)
+	} else if err = r.recorder.Start(producer); err == nil {
+		r.started = true
+	}
+
+	if err != nil {
+		log.Printf("record producer %s: %s", producer.Id(), err)
+	}
+}
+
+func (r *recording) remove(producerId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started {
+		return
+	}
+
+	if err := r.recorder.RemoveProducer(producerId); err != nil {
+		log.Printf("stop recording producer %s: %s", producerId, err)
+	}
+}
+
+// stop stops the recordings, writing their manifests.
+func (b *bot) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for roomId, rec := range b.recordings {
+		rec.mu.Lock()
+		if rec.started {
+			rec.recorder.Stop()
+			log.Printf("room %s: recorded %d segments", roomId, len(rec.recorder.Manifest().Segments))
+		}
+		rec.mu.Unlock()
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	if len(os.Getenv("MEDIASOUP_WORKER_BIN")) == 0 {
+		os.Setenv("MEDIASOUP_WORKER_BIN", "../../mediasoup-worker")
+	}
+}
+
+func TestRecordingBot(t *testing.T) {
+	worker, err := mediasoup.CreateWorker("")
+	require.NoError(t, err)
+	defer worker.Close()
+
+	bot := newBot(worker, mediasoup.ListenIp{Ip: "127.0.0.1"}, os.TempDir())
+	defer bot.stop()
+
+	server := httptest.NewServer(bot.handler)
+	defer server.Close()
+
+	rsp, err := http.Get(server.URL + "/rooms/meeting")
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	bot.mu.Lock()
+	assert.Contains(t, bot.recordings, "meeting")
+	bot.mu.Unlock()
+}
@@ -0,0 +1,201 @@
+package mediasoup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RedactedAppDataValue replaces the values of sensitive appData keys when no
+// encryption key is set.
+const RedactedAppDataValue = "[REDACTED]"
+
+const encryptedAppDataPrefix = "enc:"
+
+// AppDataRedactor redacts or encrypts the values of the sensitive appData keys
+// (at any depth) where appData is serialized: the redacted snapshots (see
+// RouterSnapshot.Redacted), the TopologyStore, the StatsExporter labels and
+// the log entries having an "appData" field (see SetLoggerAppDataRedactor). A
+// nil AppDataRedactor leaves appData untouched.
+type AppDataRedactor struct {
+	keys map[string]bool
+	aead cipher.AEAD
+}
+
+type appDataRedactorOptions struct {
+	keys []string
+	key  []byte
+}
+
+type AppDataRedactorOption func(o *appDataRedactorOptions)
+
+// WithSensitiveAppDataKeys sets the appData keys whose values must not be
+// serialized.
+func WithSensitiveAppDataKeys(keys ...string) AppDataRedactorOption {
+	return func(o *appDataRedactorOptions) {
+		o.keys = keys
+	}
+}
+
+// WithAppDataEncryptionKey makes the sensitive values be encrypted with
+// AES-GCM instead of redacted. The key must be 16, 24 or 32 bytes long.
+func WithAppDataEncryptionKey(key []byte) AppDataRedactorOption {
+	return func(o *appDataRedactorOptions) {
+		o.key = key
+	}
+}
+
+func NewAppDataRedactor(options ...AppDataRedactorOption) (redactor *AppDataRedactor, err error) {
+	o := appDataRedactorOptions{}
+
+	for _, option := range options {
+		option(&o)
+	}
+
+	redactor = &AppDataRedactor{
+		keys: make(map[string]bool, len(o.keys)),
+	}
+
+	for _, key := range o.keys {
+		redactor.keys[key] = true
+	}
+
+	if o.key != nil {
+		if redactor.aead, err = newAppDataAEAD(o.key); err != nil {
+			return nil, NewValidationError("AppDataRedactorOptions.EncryptionKey", "%s", err)
+		}
+	}
+
+	return
+}
+
+// IsSensitive returns whether the values of the given appData key are
+// redacted.
+func (r *AppDataRedactor) IsSensitive(key string) bool {
+	return r != nil && r.keys[key]
+}
+
+// Redact returns a copy of the given appData with the values of the sensitive
+// keys redacted or encrypted. The given appData is not modified.
+func (r *AppDataRedactor) Redact(appData interface{}) interface{} {
+	if r == nil || appData == nil || len(r.keys) == 0 {
+		return appData
+	}
+
+	var value interface{}
+
+	// Work on a generic copy so that structs are handled as well.
+	data, err := json.Marshal(appData)
+	if err != nil || json.Unmarshal(data, &value) != nil {
+		return RedactedAppDataValue
+	}
+
+	return r.redactValue(value)
+}
+
+// DecryptAppDataValue decrypts a sensitive appData value encrypted by an
+// AppDataRedactor with the given key.
+func DecryptAppDataValue(key []byte, value string) (plaintext string, err error) {
+	if !strings.HasPrefix(value, encryptedAppDataPrefix) {
+		err = NewTypeError("value is not encrypted")
+		return
+	}
+
+	aead, err := newAppDataAEAD(key)
+	if err != nil {
+		return
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedAppDataPrefix))
+	if err != nil {
+		return
+	}
+	if len(data) < aead.NonceSize() {
+		err = errors.New("encrypted value too short")
+		return
+	}
+
+	result, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return
+	}
+
+	return string(result), nil
+}
+
+func (r *AppDataRedactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.keys[key] {
+				v[key] = r.protectValue(item)
+			} else {
+				v[key] = r.redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+	}
+
+	return value
+}
+
+func (r *AppDataRedactor) protectValue(value interface{}) interface{} {
+	if r.aead == nil {
+		return RedactedAppDataValue
+	}
+
+	plaintext, ok := value.(string)
+	if !ok {
+		plaintext = fmt.Sprint(value)
+	}
+
+	nonce := make([]byte, r.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return RedactedAppDataValue
+	}
+
+	data := r.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedAppDataPrefix + base64.StdEncoding.EncodeToString(data)
+}
+
+func newAppDataAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// AppDataValue returns the value of the given top level appData key, appData
+// being a map or a struct (through its JSON representation).
+func AppDataValue(appData interface{}, key string) (value interface{}, ok bool) {
+	switch data := appData.(type) {
+	case nil:
+		return
+	case H:
+		value, ok = data[key]
+		return
+	case map[string]interface{}:
+		value, ok = data[key]
+		return
+	}
+
+	var values map[string]interface{}
+
+	if raw, err := json.Marshal(appData); err == nil && json.Unmarshal(raw, &values) == nil {
+		value, ok = values[key]
+	}
+
+	return
+}
@@ -0,0 +1,83 @@
+package mediasoup
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppDataRedactor(t *testing.T) {
+	redactor, err := NewAppDataRedactor(WithSensitiveAppDataKeys("userId", "email"))
+	require.NoError(t, err)
+
+	appData := H{
+		"userId": "u1",
+		"role":   "viewer",
+		"peer":   H{"email": "foo@bar.com"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"userId": RedactedAppDataValue,
+		"role":   "viewer",
+		"peer":   map[string]interface{}{"email": RedactedAppDataValue},
+	}, redactor.Redact(appData))
+
+	// The given appData is not modified.
+	assert.Equal(t, "u1", appData["userId"])
+
+	assert.True(t, redactor.IsSensitive("email"))
+	assert.False(t, redactor.IsSensitive("role"))
+
+	// A nil redactor leaves appData as is.
+	var nilRedactor *AppDataRedactor
+	assert.Equal(t, appData, nilRedactor.Redact(appData))
+	assert.False(t, nilRedactor.IsSensitive("email"))
+}
+
+func TestAppDataRedactor_Encrypted(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	redactor, err := NewAppDataRedactor(
+		WithSensitiveAppDataKeys("userId"),
+		WithAppDataEncryptionKey(key),
+	)
+	require.NoError(t, err)
+
+	redacted := redactor.Redact(H{"userId": "u1"}).(map[string]interface{})
+	value := redacted["userId"].(string)
+
+	assert.True(t, strings.HasPrefix(value, "enc:"))
+
+	plaintext, err := DecryptAppDataValue(key, value)
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", plaintext)
+
+	_, err = NewAppDataRedactor(WithAppDataEncryptionKey([]byte("short")))
+	assert.IsType(t, ValidationError{}, err)
+}
+
+func TestAppDataRedactor_Logger(t *testing.T) {
+	redactor, err := NewAppDataRedactor(WithSensitiveAppDataKeys("userId"))
+	require.NoError(t, err)
+
+	SetLoggerAppDataRedactor(redactor)
+	defer SetLoggerAppDataRedactor(nil)
+
+	buf := &bytes.Buffer{}
+	out, level := Logger().Out, Logger().Level
+	Logger().SetOutput(buf)
+	Logger().SetLevel(logrus.InfoLevel)
+	defer func() {
+		Logger().SetOutput(out)
+		Logger().SetLevel(level)
+	}()
+
+	AppLogger().WithField("appData", H{"userId": "u1"}).Info("peer joined")
+
+	assert.Contains(t, buf.String(), RedactedAppDataValue)
+	assert.NotContains(t, buf.String(), "u1")
+}
@@ -9,17 +9,32 @@ import (
 type AudioLevelObserver struct {
 	*baseRtpObserver
 	logger logrus.FieldLogger
+	// Aggregates the volumes, nil if not rate limited.
+	volumes *volumesAggregator
+	// Parameters given at creation.
+	params CreateAudioLevelObserverParams
 }
 
 func NewAudioLevelObserver(
 	internal internalData,
 	channel *Channel,
 	getProducerById fetchProducerFunc,
+) *AudioLevelObserver {
+	return newAudioLevelObserver(internal, channel, getProducerById, NotificationRateLimits{}, nil)
+}
+
+func newAudioLevelObserver(
+	internal internalData,
+	channel *Channel,
+	getProducerById fetchProducerFunc,
+	limits NotificationRateLimits,
+	appLogger logrus.FieldLogger,
 ) *AudioLevelObserver {
 	o := &AudioLevelObserver{
-		baseRtpObserver: newRtpObserver(internal, channel),
-		logger:          TypeLogger("AudioLevelObserver"),
+		baseRtpObserver: newRtpObserver(internal, channel, getProducerById, appLogger),
+		logger:          entityAppLogger(appLogger).WithField("type", "AudioLevelObserver"),
 	}
+	o.volumes = newVolumesAggregator(limits, o.emitVolumes)
 
 	o.handleWorkerNotifications(internal.RtpObserverId, getProducerById)
 
@@ -30,7 +45,9 @@ func (o *AudioLevelObserver) handleWorkerNotifications(
 	rtpObserverId string,
 	getProducerById fetchProducerFunc,
 ) {
-	o.baseRtpObserver.channel.On(rtpObserverId,
+	channel := o.baseRtpObserver.channel
+
+	o.baseRtpObserver.subscription = channel.subscribe(rtpObserverId, channel.profiledListener(o.baseRtpObserver.internal.RouterId,
 		func(event string, data json.RawMessage) {
 			switch event {
 			case "volumes":
@@ -56,13 +73,32 @@ func (o *AudioLevelObserver) handleWorkerNotifications(
 				}
 
 				if len(volumes) > 0 {
-					o.SafeEmit("volumes", volumes)
+					if o.volumes != nil {
+						o.volumes.add(volumes)
+					} else {
+						o.emitVolumes(volumes)
+					}
 				}
 			case "silence":
+				// The volumes heard before go first.
+				if o.volumes != nil {
+					o.volumes.flush()
+				}
+
 				o.SafeEmit("silence")
+
+				// Emit observer event.
+				o.observer.SafeEmit("silence")
 			default:
 				o.logger.Errorf(`ignoring unknown event "%s"`, event)
 			}
 		},
-	)
+	))
+}
+
+func (o *AudioLevelObserver) emitVolumes(volumes []VolumeInfo) {
+	o.SafeEmit("volumes", volumes)
+
+	// Emit observer event.
+	o.observer.SafeEmit("volumes", volumes)
 }
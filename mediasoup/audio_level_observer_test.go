@@ -1,9 +1,13 @@
 package mediasoup
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -23,19 +27,21 @@ var (
 func TestCreateAudioLevelObserver_Succeeds(t *testing.T) {
 	worker := CreateTestWorker()
 	router, _ := worker.CreateRouter(audioLevelMediaCodecs)
+
+	onObserverNewRtpObserver := NewMockFunc(t)
+	router.Observer().Once("newrtpobserver", onObserverNewRtpObserver.Fn())
+
 	audioLevelObserver, err := router.CreateAudioLevelObserver(nil)
 
 	assert.NoError(t, err)
+	onObserverNewRtpObserver.ExpectCalledTimes(1)
+	onObserverNewRtpObserver.ExpectCalledWith(audioLevelObserver)
 	assert.False(t, audioLevelObserver.Closed())
 	assert.False(t, audioLevelObserver.Paused())
 
-	dump := router.Dump()
-
-	var result struct {
-		RtpObserverIds []string
-	}
-	assert.NoError(t, dump.Unmarshal(&result))
-	assert.Equal(t, []string{audioLevelObserver.Id()}, result.RtpObserverIds)
+	dump, err := router.Dump()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{audioLevelObserver.Id()}, dump.RtpObserverIds)
 }
 
 func TestCreateAudioLevelObserver_TypeError(t *testing.T) {
@@ -44,7 +50,8 @@ func TestCreateAudioLevelObserver_TypeError(t *testing.T) {
 	_, err := router.CreateAudioLevelObserver(&CreateAudioLevelObserverParams{
 		MaxEntries: 0,
 	})
-	assert.IsType(t, err, NewTypeError(""))
+	assert.IsType(t, ValidationError{}, err)
+	assert.Equal(t, "CreateAudioLevelObserverParams.MaxEntries", err.(ValidationError).Field)
 }
 
 func TestCreateAudioLevelObserver_Pause_Resume(t *testing.T) {
@@ -71,22 +78,19 @@ func TestCreateAudioLevelObserver_Close(t *testing.T) {
 	audioLevelObserver2, err := router.CreateAudioLevelObserver(nil)
 	assert.NoError(t, err)
 
-	dump := router.Dump()
-	var result struct {
-		RtpObserverIds []string
-	}
-	assert.NoError(t, dump.Unmarshal(&result))
+	dump, err := router.Dump()
+	assert.NoError(t, err)
 
-	assert.Equal(t, 2, len(result.RtpObserverIds))
+	assert.Equal(t, 2, len(dump.RtpObserverIds))
 
 	audioLevelObserver2.Close()
 
 	assert.True(t, audioLevelObserver2.Closed())
 
-	dump = router.Dump()
-	assert.NoError(t, dump.Unmarshal(&result))
+	dump, err = router.Dump()
+	assert.NoError(t, err)
 
-	assert.Equal(t, 1, len(result.RtpObserverIds))
+	assert.Equal(t, 1, len(dump.RtpObserverIds))
 }
 
 func TestCreateAudioLevelObserver_Router_Close(t *testing.T) {
@@ -122,3 +126,65 @@ func TestCreateAudioLevelObserver_Worker_Close(t *testing.T) {
 	assert.True(t, audioLevelObserver.Closed())
 	assert.True(t, routerclose)
 }
+
+func TestAudioLevelObserver_Observer(t *testing.T) {
+	fake := newFakeWorker(t)
+	channel := fake.channel
+
+	producer := &Producer{internal: internalData{ProducerId: "producer"}}
+	audioLevelObserver := NewAudioLevelObserver(
+		internalData{RtpObserverId: "observer"},
+		channel,
+		func(producerId string) *Producer {
+			if producerId == producer.Id() {
+				return producer
+			}
+			return nil
+		},
+	)
+
+	events := make(chan string, 10)
+	for _, event := range []string{"close", "pause", "resume", "silence"} {
+		event := event
+		audioLevelObserver.Observer().On(event, func() { events <- event })
+	}
+	audioLevelObserver.Observer().On("addproducer", func(p *Producer) {
+		events <- "addproducer " + p.Id()
+	})
+	audioLevelObserver.Observer().On("removeproducer", func(p *Producer) {
+		events <- "removeproducer " + p.Id()
+	})
+	audioLevelObserver.Observer().On("volumes", func(volumes []VolumeInfo) {
+		events <- fmt.Sprintf("volumes %s %d", volumes[0].Producer.Id(), volumes[0].Volume)
+	})
+	receive := func() string {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			require.FailNow(t, "no observer event")
+		}
+		return ""
+	}
+
+	audioLevelObserver.Pause()
+	assert.Equal(t, "pause", receive())
+	audioLevelObserver.Resume()
+	assert.Equal(t, "resume", receive())
+
+	audioLevelObserver.AddProducer("unknown")
+	audioLevelObserver.AddProducer("producer")
+	assert.Equal(t, "addproducer producer", receive())
+
+	fake.notify("observer", "volumes", json.RawMessage(`[{"producerId":"producer","volume":0}]`))
+	assert.Equal(t, "volumes producer 0", receive())
+	fake.notify("observer", "silence", nil)
+	assert.Equal(t, "silence", receive())
+
+	audioLevelObserver.RemoveProducer("producer")
+	assert.Equal(t, "removeproducer producer", receive())
+
+	audioLevelObserver.Close()
+	assert.Equal(t, "close", receive())
+	assert.Empty(t, events)
+}
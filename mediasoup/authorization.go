@@ -0,0 +1,44 @@
+package mediasoup
+
+// Actions of the AuthorizationRequests.
+const (
+	AuthorizeCreateTransport = "createTransport"
+	AuthorizeProduce         = "produce"
+	AuthorizeConsume         = "consume"
+)
+
+// AuthorizationRequest describes an entity about to be created.
+type AuthorizationRequest struct {
+	// AuthorizeCreateTransport, AuthorizeProduce or AuthorizeConsume.
+	Action   string
+	RouterId string
+	// Transport of the Producer or Consumer.
+	TransportId string
+	// "webrtc", "plain" or "pipe", the type of the Transport created or of
+	// the one of the Producer or Consumer.
+	TransportType string
+	// CreateWebRtcTransportParams, CreatePlainRtpTransportParams or
+	// CreatePipeTransportParams of a Transport.
+	Options interface{}
+	// Kind of the Producer or Consumer.
+	Kind string
+	// RTP parameters of the Producer.
+	RtpParameters RtpParameters
+	// Producer of the Consumer.
+	ProducerId string
+	// AppData of the entity.
+	AppData interface{}
+}
+
+// Authorizer is called before creating a Transport, a Producer or a Consumer,
+// which fails with the returned error if not nil. Transports, Producers and
+// Consumers created to pipe Producers between Routers are authorized too.
+type Authorizer func(request AuthorizationRequest) error
+
+func authorize(authorizer Authorizer, request AuthorizationRequest) error {
+	if authorizer == nil {
+		return nil
+	}
+
+	return authorizer(request)
+}
@@ -0,0 +1,89 @@
+package mediasoup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer(t *testing.T) {
+	errDenied := errors.New("denied")
+
+	var requests []AuthorizationRequest
+
+	worker := newPoolTestWorker(t, 1)
+	worker.authorizer = func(request AuthorizationRequest) error {
+		requests = append(requests, request)
+
+		if request.AppData.(H)["role"] == "viewer" && request.Action != AuthorizeConsume {
+			return errDenied
+		}
+		return nil
+	}
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+		AppData:   H{"role": "viewer"},
+	})
+	assert.Equal(t, errDenied, err)
+	assert.Empty(t, router.getTransports())
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+		AppData:   H{"role": "publisher"},
+	})
+	require.NoError(t, err)
+
+	rtpParameters := RtpParameters{
+		Codecs: []RtpCodecCapability{
+			{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+		},
+		Encodings: []RtpEncoding{{Ssrc: 11111111}},
+	}
+
+	_, err = transport.Produce(TransportProduceParams{
+		Kind:          "audio",
+		RtpParameters: rtpParameters,
+		AppData:       H{"role": "viewer"},
+	})
+	assert.Equal(t, errDenied, err)
+	assert.Empty(t, transport.getProducers())
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind:          "audio",
+		RtpParameters: rtpParameters,
+		AppData:       H{"role": "publisher"},
+	})
+	require.NoError(t, err)
+
+	_, err = transport.Consume(TransportConsumeParams{
+		ProducerId:      producer.Id(),
+		RtpCapabilities: router.RtpCapabilities(),
+		AppData:         H{"role": "viewer"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 5)
+
+	assert.Equal(t, AuthorizeCreateTransport, requests[1].Action)
+	assert.Equal(t, router.Id(), requests[1].RouterId)
+	assert.Equal(t, "webrtc", requests[1].TransportType)
+	assert.IsType(t, CreateWebRtcTransportParams{}, requests[1].Options)
+
+	assert.Equal(t, AuthorizeProduce, requests[3].Action)
+	assert.Equal(t, transport.Id(), requests[3].TransportId)
+	assert.Equal(t, "audio", requests[3].Kind)
+	assert.Equal(t, uint32(11111111), requests[3].RtpParameters.Encodings[0].Ssrc)
+
+	assert.Equal(t, AuthorizeConsume, requests[4].Action)
+	assert.Equal(t, "webrtc", requests[4].TransportType)
+	assert.Equal(t, producer.Id(), requests[4].ProducerId)
+	assert.Equal(t, H{"role": "viewer"}, requests[4].AppData)
+}
@@ -0,0 +1,122 @@
+package mediasoup
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff retries failing operations with exponentially growing delays, so
+// that the subsystems (webhook delivery, worker spawning, channel requests)
+// and the applications retry consistently.
+type Backoff struct {
+	// Delay before the first retry.
+	Initial time.Duration
+	// Upper bound of the delays, unbounded if zero.
+	Max time.Duration
+	// Growth factor of the delays, 2 if zero.
+	Multiplier float64
+	// Randomization of the delays, from 0 to 1: a delay d is picked in
+	// [d*(1-Jitter), d*(1+Jitter)], so that failing clients don't retry in
+	// lockstep.
+	Jitter float64
+	// Number of retries after the first attempt, unlimited if negative.
+	MaxRetries int
+	// Called before waiting for a retry, e.g. to log the error.
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// DefaultBackoff retries 3 times, after about 1s, 2s and 4s.
+var DefaultBackoff = Backoff{
+	Initial:    time.Second,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+	MaxRetries: 3,
+}
+
+// PermanentError stops the retries of Backoff.Retry.
+type PermanentError struct {
+	Err error
+}
+
+func (e PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps the given error to stop the retries, nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return PermanentError{Err: err}
+}
+
+// Delay returns the delay before the given retry (from 1), capped to Max then
+// jittered.
+func (b Backoff) Delay(retry int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	if retry < 1 {
+		retry = 1
+	}
+
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(retry-1))
+
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	// Unbounded delays overflow time.Duration after enough retries.
+	if delay >= math.MaxInt64 || math.IsNaN(delay) {
+		return math.MaxInt64
+	}
+
+	return time.Duration(delay)
+}
+
+// Retry calls fn until it succeeds, returns a PermanentError (unwrapped), the
+// retries are exhausted (returning the last error), or ctx is done (returning
+// ctx.Err()). fn is given the attempt number, from 0.
+func (b Backoff) Retry(ctx context.Context, fn func(attempt int) error) (err error) {
+	for attempt := 0; ; attempt++ {
+		if err = fn(attempt); err == nil {
+			return
+		}
+
+		var permanent PermanentError
+
+		if errors.As(err, &permanent) {
+			return permanent.Err
+		}
+		if b.MaxRetries >= 0 && attempt >= b.MaxRetries {
+			return
+		}
+
+		delay := b.Delay(attempt + 1)
+
+		if b.OnRetry != nil {
+			b.OnRetry(attempt+1, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package mediasoup
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, backoff.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, backoff.Delay(2))
+	assert.Equal(t, 800*time.Millisecond, backoff.Delay(4))
+	assert.Equal(t, time.Second, backoff.Delay(10))
+
+	backoff.Multiplier = 3
+	assert.Equal(t, 900*time.Millisecond, backoff.Delay(3))
+
+	backoff = Backoff{Initial: time.Second, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := backoff.Delay(1)
+		assert.True(t, delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond, delay)
+	}
+
+	// The jitter applies to the capped delay, so that capped retries are
+	// still spread.
+	backoff = Backoff{Initial: time.Second, Max: 2 * time.Second, Jitter: 0.5}
+
+	spread := false
+	for i := 0; i < 100; i++ {
+		delay := backoff.Delay(10)
+		assert.True(t, delay >= time.Second && delay <= 3*time.Second, delay)
+		spread = spread || delay != 2*time.Second
+	}
+	assert.True(t, spread)
+
+	// Unbounded delays don't overflow.
+	backoff = Backoff{Initial: time.Second}
+	assert.Equal(t, time.Duration(math.MaxInt64), backoff.Delay(1000))
+	assert.Equal(t, time.Duration(math.MaxInt64), backoff.Delay(100000))
+}
+
+func TestBackoff_Retry(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var retries []int
+
+	backoff := Backoff{
+		Initial:    time.Millisecond,
+		MaxRetries: 2,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			retries = append(retries, attempt)
+			assert.Equal(t, errFailed, err)
+		},
+	}
+
+	// Retries exhausted.
+	attempts := 0
+	err := backoff.Retry(context.Background(), func(attempt int) error {
+		assert.Equal(t, attempts, attempt)
+		attempts++
+		return errFailed
+	})
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []int{1, 2}, retries)
+
+	// Succeeding.
+	attempts = 0
+	err = backoff.Retry(context.Background(), func(attempt int) error {
+		attempts++
+		if attempt == 0 {
+			return errFailed
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// Permanent error.
+	attempts = 0
+	err = backoff.Retry(context.Background(), func(attempt int) error {
+		attempts++
+		return Permanent(errFailed)
+	})
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, 1, attempts)
+	assert.Nil(t, Permanent(nil))
+
+	// Context done while waiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	backoff = Backoff{Initial: time.Hour, MaxRetries: -1}
+	err = backoff.Retry(ctx, func(attempt int) error {
+		return errFailed
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
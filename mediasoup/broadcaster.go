@@ -0,0 +1,421 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BroadcasterHandlerOptions to serve the broadcaster HTTP API.
+type BroadcasterHandlerOptions struct {
+	// GetRouter returns the Router of the given room, creating it if needed.
+	GetRouter func(roomId string) (*Router, error)
+	// Options of the WebRtcTransports, its appData is ignored.
+	WebRtcTransportParams CreateWebRtcTransportParams
+	// Listen IP of the PlainRtpTransports.
+	PlainRtpListenIp ListenIp
+}
+
+// BroadcasterHandler serves the broadcaster REST API of mediasoup-demo, so
+// that tools made for it (e.g. the broadcaster scripts with ffmpeg or
+// gstreamer) work unchanged. The handler must be mounted at the root:
+//
+//	GET    /rooms/:roomId
+//	POST   /rooms/:roomId/broadcasters
+//	DELETE /rooms/:roomId/broadcasters/:broadcasterId
+//	POST   /rooms/:roomId/broadcasters/:broadcasterId/transports
+//	POST   /rooms/:roomId/broadcasters/:broadcasterId/transports/:transportId/connect
+//	POST   /rooms/:roomId/broadcasters/:broadcasterId/transports/:transportId/plain/connect
+//	POST   /rooms/:roomId/broadcasters/:broadcasterId/transports/:transportId/producers
+//	POST   /rooms/:roomId/broadcasters/:broadcasterId/transports/:transportId/consume?producerId=
+type BroadcasterHandler struct {
+	mu      sync.Mutex
+	logger  logrus.FieldLogger
+	options BroadcasterHandlerOptions
+	// Broadcasters by room id and broadcaster id.
+	rooms map[string]map[string]*broadcaster
+}
+
+type broadcaster struct {
+	Id              string          `json:"id"`
+	DisplayName     string          `json:"displayName"`
+	Device          H               `json:"device"`
+	RtpCapabilities RtpCapabilities `json:"-"`
+	transports      map[string]Transport
+	producers       map[string]*Producer
+}
+
+type broadcasterPeerInfo struct {
+	Id          string                    `json:"id"`
+	DisplayName string                    `json:"displayName"`
+	Device      H                         `json:"device"`
+	Producers   []broadcasterProducerInfo `json:"producers"`
+}
+
+type broadcasterProducerInfo struct {
+	Id   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+type broadcasterHttpError struct {
+	status int
+	err    error
+}
+
+func (e broadcasterHttpError) Error() string {
+	return e.err.Error()
+}
+
+func NewBroadcasterHandler(options BroadcasterHandlerOptions) *BroadcasterHandler {
+	logger := TypeLogger("BroadcasterHandler")
+
+	logger.Debug("constructor()")
+
+	return &BroadcasterHandler{
+		logger:  logger,
+		options: options,
+		rooms:   make(map[string]map[string]*broadcaster),
+	}
+}
+
+func (h *BroadcasterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	result, err := h.route(r)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if httpErr, ok := err.(broadcasterHttpError); ok {
+			status = httpErr.status
+		}
+
+		h.logger.Warnf("%s %s failed: %s", r.Method, r.URL.Path, err)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprint(w, err)
+
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *BroadcasterHandler) route(r *http.Request) (result interface{}, err error) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	if len(parts) < 2 || parts[0] != "rooms" {
+		return nil, broadcasterNotFound("not found")
+	}
+
+	roomId := parts[1]
+	parts = parts[2:]
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		router, err := h.options.GetRouter(roomId)
+		if err != nil {
+			return nil, err
+		}
+		return router.RtpCapabilities(), nil
+
+	case len(parts) == 1 && parts[0] == "broadcasters" && r.Method == http.MethodPost:
+		return h.createBroadcaster(roomId, r)
+	}
+
+	if len(parts) < 2 || parts[0] != "broadcasters" {
+		return nil, broadcasterNotFound("not found")
+	}
+
+	h.mu.Lock()
+	b := h.rooms[roomId][parts[1]]
+	h.mu.Unlock()
+
+	if b == nil {
+		return nil, broadcasterNotFound(`broadcaster with id "%s" does not exist`, parts[1])
+	}
+
+	parts = parts[2:]
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodDelete:
+		h.deleteBroadcaster(roomId, b)
+		return H{}, nil
+
+	case len(parts) == 1 && parts[0] == "transports" && r.Method == http.MethodPost:
+		return h.createTransport(roomId, b, r)
+	}
+
+	if len(parts) < 3 || parts[0] != "transports" || r.Method != http.MethodPost {
+		return nil, broadcasterNotFound("not found")
+	}
+
+	h.mu.Lock()
+	transport := b.transports[parts[1]]
+	h.mu.Unlock()
+
+	if transport == nil {
+		return nil, broadcasterNotFound(`transport with id "%s" does not exist`, parts[1])
+	}
+
+	switch strings.Join(parts[2:], "/") {
+	case "connect", "plain/connect":
+		var params TransportConnectParams
+		if err = decodeBroadcasterBody(r, &params); err != nil {
+			return
+		}
+		return H{}, transport.Connect(params)
+
+	case "producers":
+		return h.createProducer(b, transport, r)
+
+	case "consume":
+		return h.createConsumer(roomId, b, transport, r.URL.Query().Get("producerId"))
+	}
+
+	return nil, broadcasterNotFound("not found")
+}
+
+func (h *BroadcasterHandler) createBroadcaster(roomId string, r *http.Request) (result interface{}, err error) {
+	var params struct {
+		Id              string          `json:"id"`
+		DisplayName     string          `json:"displayName"`
+		Device          H               `json:"device"`
+		RtpCapabilities RtpCapabilities `json:"rtpCapabilities"`
+	}
+	if err = decodeBroadcasterBody(r, &params); err != nil {
+		return
+	}
+	if len(params.Id) == 0 {
+		return nil, broadcasterBadRequest("missing body.id")
+	}
+	if len(params.DisplayName) == 0 {
+		return nil, broadcasterBadRequest("missing body.displayName")
+	}
+	if params.Device == nil || params.Device["name"] == nil {
+		return nil, broadcasterBadRequest("missing body.device.name")
+	}
+	if _, err = h.options.GetRouter(roomId); err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rooms[roomId][params.Id] != nil {
+		return nil, broadcasterBadRequest(`broadcaster with id "%s" already exists`, params.Id)
+	}
+
+	peers := []broadcasterPeerInfo{}
+
+	for _, other := range h.rooms[roomId] {
+		peer := broadcasterPeerInfo{
+			Id:          other.Id,
+			DisplayName: other.DisplayName,
+			Device:      other.Device,
+			Producers:   []broadcasterProducerInfo{},
+		}
+		for _, producer := range other.producers {
+			peer.Producers = append(peer.Producers, broadcasterProducerInfo{
+				Id:   producer.Id(),
+				Kind: producer.Kind(),
+			})
+		}
+		peers = append(peers, peer)
+	}
+
+	if h.rooms[roomId] == nil {
+		h.rooms[roomId] = make(map[string]*broadcaster)
+	}
+
+	h.rooms[roomId][params.Id] = &broadcaster{
+		Id:              params.Id,
+		DisplayName:     params.DisplayName,
+		Device:          params.Device,
+		RtpCapabilities: params.RtpCapabilities,
+		transports:      make(map[string]Transport),
+		producers:       make(map[string]*Producer),
+	}
+
+	return H{"peers": peers}, nil
+}
+
+func (h *BroadcasterHandler) deleteBroadcaster(roomId string, b *broadcaster) {
+	h.mu.Lock()
+
+	delete(h.rooms[roomId], b.Id)
+
+	if len(h.rooms[roomId]) == 0 {
+		delete(h.rooms, roomId)
+	}
+
+	transports := make([]Transport, 0, len(b.transports))
+	for _, transport := range b.transports {
+		transports = append(transports, transport)
+	}
+
+	h.mu.Unlock()
+
+	for _, transport := range transports {
+		transport.Close()
+	}
+}
+
+func (h *BroadcasterHandler) createTransport(roomId string, b *broadcaster, r *http.Request) (result interface{}, err error) {
+	var params struct {
+		Type    string `json:"type"`
+		RtcpMux *bool  `json:"rtcpMux"`
+		Comedia *bool  `json:"comedia"`
+	}
+	if err = decodeBroadcasterBody(r, &params); err != nil {
+		return
+	}
+
+	router, err := h.options.GetRouter(roomId)
+	if err != nil {
+		return
+	}
+
+	appData := H{"broadcasterId": b.Id}
+
+	switch params.Type {
+	case "webrtc":
+		transportParams := h.options.WebRtcTransportParams
+		transportParams.AppData = appData
+
+		transport, err := router.CreateWebRtcTransport(transportParams)
+		if err != nil {
+			return nil, err
+		}
+		h.addTransport(b, transport)
+
+		return H{
+			"id":             transport.Id(),
+			"iceParameters":  transport.IceParameters(),
+			"iceCandidates":  transport.IceCandidates(),
+			"dtlsParameters": transport.DtlsParameters(),
+		}, nil
+
+	case "plain":
+		transportParams := CreatePlainRtpTransportParams{
+			ListenIp: h.options.PlainRtpListenIp,
+			Comedia:  params.Comedia != nil && *params.Comedia,
+			RtcpMux:  params.RtcpMux != nil && *params.RtcpMux,
+			AppData:  appData,
+		}
+
+		transport, err := router.CreatePlainRtpTransport(transportParams)
+		if err != nil {
+			return nil, err
+		}
+		h.addTransport(b, transport)
+
+		result := H{
+			"id":   transport.Id(),
+			"ip":   transport.Tuple().LocalIp,
+			"port": transport.Tuple().LocalPort,
+		}
+		if rtcpTuple := transport.RtcpTuple(); rtcpTuple != nil {
+			result["rtcpPort"] = rtcpTuple.LocalPort
+		}
+
+		return result, nil
+
+	default:
+		return nil, broadcasterBadRequest("invalid type")
+	}
+}
+
+func (h *BroadcasterHandler) addTransport(b *broadcaster, transport Transport) {
+	h.mu.Lock()
+	b.transports[transport.Id()] = transport
+	h.mu.Unlock()
+
+	transport.Observer().On("close", func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(b.transports, transport.Id())
+	})
+}
+
+func (h *BroadcasterHandler) createProducer(b *broadcaster, transport Transport, r *http.Request) (result interface{}, err error) {
+	var params struct {
+		Kind          string        `json:"kind"`
+		RtpParameters RtpParameters `json:"rtpParameters"`
+	}
+	if err = decodeBroadcasterBody(r, &params); err != nil {
+		return
+	}
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind:          params.Kind,
+		RtpParameters: params.RtpParameters,
+		AppData:       H{"broadcasterId": b.Id},
+	})
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	b.producers[producer.Id()] = producer
+	h.mu.Unlock()
+
+	producer.Observer().On("close", func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(b.producers, producer.Id())
+	})
+
+	return H{"id": producer.Id()}, nil
+}
+
+func (h *BroadcasterHandler) createConsumer(
+	roomId string, b *broadcaster, transport Transport, producerId string,
+) (result interface{}, err error) {
+	router, err := h.options.GetRouter(roomId)
+	if err != nil {
+		return
+	}
+	if !router.CanConsume(producerId, b.RtpCapabilities) {
+		return nil, broadcasterBadRequest(`cannot consume producer with id "%s"`, producerId)
+	}
+
+	consumer, err := transport.Consume(TransportConsumeParams{
+		ProducerId:      producerId,
+		RtpCapabilities: b.RtpCapabilities,
+		AppData:         H{"broadcasterId": b.Id},
+	})
+	if err != nil {
+		return
+	}
+
+	return H{
+		"id":            consumer.Id(),
+		"producerId":    producerId,
+		"kind":          consumer.Kind(),
+		"rtpParameters": consumer.RtpParameters(),
+		"type":          consumer.Type(),
+	}, nil
+}
+
+func decodeBroadcasterBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return broadcasterBadRequest("invalid body: %s", err)
+	}
+
+	return nil
+}
+
+func broadcasterBadRequest(format string, args ...interface{}) error {
+	return broadcasterHttpError{status: http.StatusBadRequest, err: NewTypeError(format, args...)}
+}
+
+func broadcasterNotFound(format string, args ...interface{}) error {
+	return broadcasterHttpError{status: http.StatusNotFound, err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,115 @@
+package mediasoup
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcasterHandler_ProduceAndConsume(t *testing.T) {
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+	defer router.Close()
+
+	server := httptest.NewServer(NewBroadcasterHandler(BroadcasterHandlerOptions{
+		GetRouter: func(roomId string) (*Router, error) {
+			return router, nil
+		},
+		WebRtcTransportParams: CreateWebRtcTransportParams{
+			ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+			EnableUdp: true,
+		},
+		PlainRtpListenIp: ListenIp{Ip: "127.0.0.1"},
+	}))
+	defer server.Close()
+
+	request := func(method, path string, body interface{}, result interface{}) int {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewReader(data))
+
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		if result != nil && rsp.StatusCode == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(rsp.Body).Decode(result))
+		}
+
+		return rsp.StatusCode
+	}
+
+	var rtpCapabilities RtpCapabilities
+	assert.Equal(t, http.StatusOK, request("GET", "/rooms/room1", nil, &rtpCapabilities))
+	assert.Equal(t, router.RtpCapabilities(), rtpCapabilities)
+
+	// Sending broadcaster.
+	assert.Equal(t, http.StatusOK, request("POST", "/rooms/room1/broadcasters", H{
+		"id":          "sender",
+		"displayName": "Sender",
+		"device":      H{"name": "ffmpeg"},
+	}, nil))
+
+	var plainTransport struct {
+		Id   string
+		Ip   string
+		Port uint16
+	}
+	assert.Equal(t, http.StatusOK, request("POST", "/rooms/room1/broadcasters/sender/transports",
+		H{"type": "plain", "comedia": true}, &plainTransport))
+	assert.Equal(t, "127.0.0.1", plainTransport.Ip)
+	assert.NotZero(t, plainTransport.Port)
+
+	var producer struct{ Id string }
+	assert.Equal(t, http.StatusOK, request("POST",
+		"/rooms/room1/broadcasters/sender/transports/"+plainTransport.Id+"/producers", H{
+			"kind":          "audio",
+			"rtpParameters": audioProducerParameters.RtpParameters,
+		}, &producer))
+	assert.NotEmpty(t, producer.Id)
+
+	// Receiving broadcaster.
+	var joined struct {
+		Peers []struct {
+			Id        string
+			Producers []struct{ Id, Kind string }
+		}
+	}
+	assert.Equal(t, http.StatusOK, request("POST", "/rooms/room1/broadcasters", H{
+		"id":              "receiver",
+		"displayName":     "Receiver",
+		"device":          H{"name": "gstreamer"},
+		"rtpCapabilities": consumerDeviceCapabilities,
+	}, &joined))
+	require.Len(t, joined.Peers, 1)
+	assert.Equal(t, "sender", joined.Peers[0].Id)
+	assert.Equal(t, producer.Id, joined.Peers[0].Producers[0].Id)
+
+	var webRtcTransport struct{ Id string }
+	assert.Equal(t, http.StatusOK, request("POST", "/rooms/room1/broadcasters/receiver/transports",
+		H{"type": "webrtc"}, &webRtcTransport))
+
+	var consumer struct {
+		Id            string
+		ProducerId    string
+		Kind          string
+		RtpParameters RtpParameters
+	}
+	assert.Equal(t, http.StatusOK, request("POST",
+		"/rooms/room1/broadcasters/receiver/transports/"+webRtcTransport.Id+"/consume?producerId="+producer.Id,
+		nil, &consumer))
+	assert.Equal(t, producer.Id, consumer.ProducerId)
+	assert.Equal(t, "audio", consumer.Kind)
+	assert.NotEmpty(t, consumer.RtpParameters.Codecs)
+
+	assert.Equal(t, http.StatusNotFound, request("DELETE", "/rooms/room1/broadcasters/unknown", nil, nil))
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/rooms/room1/broadcasters/sender/transports",
+		H{"type": "chicken"}, nil))
+
+	assert.Equal(t, http.StatusOK, request("DELETE", "/rooms/room1/broadcasters/sender", nil, nil))
+	assert.NotContains(t, router.transports, plainTransport.Id)
+}
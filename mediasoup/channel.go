@@ -1,10 +1,14 @@
 package mediasoup
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jiyeyuran/mediasoup-go/mediasoup/netstring"
@@ -16,37 +20,131 @@ const (
 	NS_PAYLOAD_MAX_LEN = 65536
 )
 
+// ErrChannelRequestTimeout is returned by the requests not answered by the
+// worker in time.
+var ErrChannelRequestTimeout = errors.New("Channel request timeout")
+
+// Maximum number of low priority requests (dumps and stats) waiting for
+// their response at a time, so that heavy stats polling can't delay latency
+// sensitive requests in the worker.
+const channelLowPriorityConcurrency = 2
+
+// ChannelPriority of a request method. Pending high priority requests are
+// written to the worker before normal ones, and normal ones before low ones.
+type ChannelPriority int
+
+const (
+	ChannelPriorityLow ChannelPriority = iota
+	ChannelPriorityNormal
+	ChannelPriorityHigh
+)
+
+var channelMethodPriorities = struct {
+	sync.RWMutex
+	priorities map[string]ChannelPriority
+}{
+	priorities: map[string]ChannelPriority{
+		"consumer.requestKeyFrame": ChannelPriorityHigh,
+		"consumer.pause":           ChannelPriorityHigh,
+		"consumer.resume":          ChannelPriorityHigh,
+		"producer.pause":           ChannelPriorityHigh,
+		"producer.resume":          ChannelPriorityHigh,
+	},
+}
+
+// SetChannelMethodPriority sets the priority of the given request method
+// (e.g. "consumer.requestKeyFrame"). Methods are normal priority by default,
+// except the latency sensitive ones (high) and dumps and stats (low).
+func SetChannelMethodPriority(method string, priority ChannelPriority) {
+	channelMethodPriorities.Lock()
+	defer channelMethodPriorities.Unlock()
+
+	channelMethodPriorities.priorities[method] = priority
+}
+
+func channelMethodPriority(method string) ChannelPriority {
+	channelMethodPriorities.RLock()
+	defer channelMethodPriorities.RUnlock()
+
+	if priority, ok := channelMethodPriorities.priorities[method]; ok {
+		return priority
+	}
+	if strings.HasSuffix(method, ".dump") || strings.HasSuffix(method, ".getStats") {
+		return ChannelPriorityLow
+	}
+
+	return ChannelPriorityNormal
+}
+
 type sentInfo struct {
 	id         int64
 	method     string
 	responseCh chan Response
 }
 
+type writeRequest struct {
+	data  []byte
+	errCh chan error
+}
+
 type Channel struct {
 	EventEmitter
-	socket       net.Conn
-	logger       logrus.FieldLogger
-	workerLogger logrus.FieldLogger
-	closed       bool
-	nextId       int64
-	sents        map[int64]sentInfo
-	closeCh      chan struct{}
+	socket      net.Conn
+	logger      logrus.FieldLogger
+	workerLogs  *workerLogForwarder
+	pid         string
+	closed      closeFlag
+	nextId      int64
+	sents       map[int64]sentInfo
+	sentsLocker sync.Mutex
+	closeCh     chan struct{}
+	// Write queues by priority.
+	writeQueues [ChannelPriorityHigh + 1]chan writeRequest
+	// Slots of the low priority requests.
+	lowPrioritySlots chan struct{}
+	// Timers of the worker entities.
+	timers *timerWheel
+	// Errors of the requests.
+	errorStats errorStats
+	// I/O statistics.
+	stats *channelStats
+	// Handlers of the notifications by targetId.
+	routes *notificationRoutes
+	// Time after which the requests time out, plus 100ms per pending one.
+	timeout time.Duration
+	// Backoff of the idempotent requests timing out, not retried if nil.
+	requestBackoff *Backoff
 }
 
 func NewChannel(socket net.Conn, pid int) *Channel {
+	return newChannel(socket, pid, newWorkerLogForwarder(pid, nil, nil))
+}
+
+func newChannel(socket net.Conn, pid int, workerLogs *workerLogForwarder) *Channel {
 	logger := TypeLogger(fmt.Sprintf("Channel[pid:%d]", pid))
-	workerLogger := TypeLogger(fmt.Sprintf("worker[pid:%d]", pid))
 
 	channel := &Channel{
 		EventEmitter: NewEventEmitter(logger),
 		socket:       socket,
 		logger:       logger,
-		workerLogger: workerLogger,
+		workerLogs:   workerLogs,
+		pid:          strconv.Itoa(pid),
 		sents:        make(map[int64]sentInfo),
 		closeCh:      make(chan struct{}),
+
+		lowPrioritySlots: make(chan struct{}, channelLowPriorityConcurrency),
+		timers:           newTimerWheel(timerWheelTick, timerWheelSlots),
+		stats:            newChannelStats(),
+		routes:           newNotificationRoutes(),
+		timeout:          15 * time.Second,
+	}
+
+	for i := range channel.writeQueues {
+		channel.writeQueues[i] = make(chan writeRequest)
 	}
 
-	go channel.runReadLoop()
+	spawn("channel.readLoop", channel.runReadLoop, "worker", channel.pid)
+	spawn("channel.writeLoop", channel.runWriteLoop, "worker", channel.pid)
 
 	logger.Debugln("constructor()")
 
@@ -54,15 +152,13 @@ func NewChannel(socket net.Conn, pid int) *Channel {
 }
 
 func (c *Channel) Close() {
-	if c.closed {
+	if !c.closed.set() {
 		return
 	}
 
 	c.logger.Debugln("close()")
 
 	c.socket.Close()
-
-	c.closed = true
 }
 
 func (c *Channel) Request(
@@ -70,6 +166,8 @@ func (c *Channel) Request(
 	internal interface{},
 	data ...interface{},
 ) (rsp Response) {
+	c.sentsLocker.Lock()
+
 	if c.nextId < 4294967295 {
 		c.nextId++
 	} else {
@@ -78,21 +176,49 @@ func (c *Channel) Request(
 
 	id := c.nextId
 
+	c.sentsLocker.Unlock()
+
 	c.logger.Debugf("request() [method:%s, id:%d]", method, id)
 
-	if c.closed {
+	defer func() {
+		if rsp.err != nil {
+			c.errorStats.record(method, rsp.err)
+		}
+	}()
+
+	if c.closed.isSet() {
 		rsp.err = NewInvalidStateError("Channel closed")
 		return
 	}
 
+	priority := channelMethodPriority(method)
+
+	if priority == ChannelPriorityLow {
+		select {
+		case c.lowPrioritySlots <- struct{}{}:
+			defer func() { <-c.lowPrioritySlots }()
+		case <-c.closeCh:
+			rsp.err = errors.New("Channel closed")
+			return
+		}
+	}
+
 	sent := sentInfo{
 		id:         id,
 		method:     method,
-		responseCh: make(chan Response),
+		responseCh: make(chan Response, 1),
 	}
+
+	c.sentsLocker.Lock()
 	c.sents[id] = sent
+	pending := len(c.sents)
+	c.sentsLocker.Unlock()
 
-	defer delete(c.sents, id)
+	defer func() {
+		c.sentsLocker.Lock()
+		delete(c.sents, id)
+		c.sentsLocker.Unlock()
+	}()
 
 	req := struct {
 		Id       int64       `json:"id"`
@@ -115,20 +241,25 @@ func (c *Channel) Request(
 		return
 	}
 
-	_, rsp.err = c.socket.Write(ns)
-	if rsp.err != nil {
+	if rsp.err = c.write(priority, ns); rsp.err != nil {
 		return
 	}
 
-	timeout := 1000 * (15 + (0.1 * float64(len(c.sents))))
-	timer := time.NewTimer(time.Duration(timeout) * time.Millisecond)
+	sentAt := time.Now()
+
+	timeout := c.timeout + time.Duration(pending)*100*time.Millisecond
+	timeoutCh := make(chan struct{})
+	timer := c.timers.AfterFunc(timeout, func() {
+		close(timeoutCh)
+	})
 	defer timer.Stop()
 
 	select {
 	case rsp = <-sent.responseCh:
+		c.stats.latency(method, time.Since(sentAt))
 		return
-	case <-timer.C:
-		rsp.err = errors.New("Channel request timeout")
+	case <-timeoutCh:
+		rsp.err = ErrChannelRequestTimeout
 	case <-c.closeCh:
 		rsp.err = errors.New("Channel closed")
 	}
@@ -136,10 +267,98 @@ func (c *Channel) Request(
 	return
 }
 
+// RequestWithRetry sends the request, retried with the given backoff while
+// it times out. Only idempotent requests (dump, getStats...) are to be
+// retried, a timed out request having possibly been processed.
+func (c *Channel) RequestWithRetry(
+	ctx context.Context,
+	backoff Backoff,
+	method string,
+	internal interface{},
+	data ...interface{},
+) (rsp Response) {
+	err := backoff.Retry(ctx, func(attempt int) error {
+		rsp = c.Request(method, internal, data...)
+
+		if errors.Is(rsp.err, ErrChannelRequestTimeout) {
+			return rsp.err
+		}
+
+		return nil
+	})
+	if err != nil {
+		rsp.err = err
+	}
+
+	return
+}
+
+// requestIdempotent sends the idempotent request, retried with the backoff of
+// the Channel if any.
+func (c *Channel) requestIdempotent(ctx context.Context, method string, internal interface{}, data ...interface{}) Response {
+	if c.requestBackoff == nil {
+		return c.Request(method, internal, data...)
+	}
+
+	return c.RequestWithRetry(ctx, *c.requestBackoff, method, internal, data...)
+}
+
+// write queues the given data to be written by the write loop, according to
+// its priority.
+func (c *Channel) write(priority ChannelPriority, data []byte) error {
+	req := writeRequest{
+		data:  data,
+		errCh: make(chan error, 1),
+	}
+
+	select {
+	case c.writeQueues[priority] <- req:
+	case <-c.closeCh:
+		return errors.New("Channel closed")
+	}
+
+	return <-req.errCh
+}
+
+func (c *Channel) runWriteLoop() {
+	high := c.writeQueues[ChannelPriorityHigh]
+	normal := c.writeQueues[ChannelPriorityNormal]
+	low := c.writeQueues[ChannelPriorityLow]
+
+	for {
+		var req writeRequest
+
+		// Take the pending request with the highest priority.
+		select {
+		case req = <-high:
+		default:
+			select {
+			case req = <-high:
+			case req = <-normal:
+			default:
+				select {
+				case req = <-high:
+				case req = <-normal:
+				case req = <-low:
+				case <-c.closeCh:
+					return
+				}
+			}
+		}
+
+		_, err := c.socket.Write(req.data)
+		if err == nil {
+			c.stats.sent(len(req.data))
+		}
+
+		req.errCh <- err
+	}
+}
+
 func (c *Channel) runReadLoop() {
 	decoder := netstring.NewDecoder()
 
-	go func() {
+	spawn("channel.processLoop", func() {
 		for {
 			select {
 			case nsPayload := <-decoder.Result():
@@ -148,7 +367,7 @@ func (c *Channel) runReadLoop() {
 				return
 			}
 		}
-	}()
+	}, "worker", c.pid)
 
 	buf := make([]byte, NS_PAYLOAD_MAX_LEN)
 
@@ -160,6 +379,8 @@ func (c *Channel) runReadLoop() {
 		}
 		data := buf[:n]
 
+		c.stats.receivedBytes(n)
+
 		decoder.Feed(data)
 
 		if decoder.Length() > NS_PAYLOAD_MAX_LEN {
@@ -168,22 +389,17 @@ func (c *Channel) runReadLoop() {
 		}
 	}
 
-	c.closed = true
+	c.closed.set()
 	close(c.closeCh)
 }
 
 func (c *Channel) processNSPayload(nsPayload []byte) {
-	switch nsPayload[0] {
-	case '{':
+	c.stats.receivedMessage()
+
+	if nsPayload[0] == '{' {
 		c.processMessage(nsPayload)
-	case 'D':
-		c.workerLogger.Debugf("%s", nsPayload)
-	case 'W':
-		c.workerLogger.Warnf("%s", nsPayload)
-	case 'E':
-		c.workerLogger.Errorf("%s", nsPayload)
-	default:
-		c.workerLogger.Errorf("unexpected data: %s", nsPayload)
+	} else if !c.workerLogs.forwardPayload(nsPayload) {
+		c.logger.Errorf("unexpected data: %s", nsPayload)
 	}
 }
 
@@ -195,7 +411,10 @@ func (c *Channel) processMessage(nsPayload []byte) {
 	json.Unmarshal(nsPayload, &msg)
 
 	if msg.Id > 0 {
+		c.sentsLocker.Lock()
 		sent, ok := c.sents[msg.Id]
+		c.sentsLocker.Unlock()
+
 		if !ok {
 			c.logger.Errorf("received response does not match any sent request [id:%d]", msg.Id)
 			return
@@ -227,7 +446,15 @@ func (c *Channel) processMessage(nsPayload []byte) {
 		}
 		json.Unmarshal(nsPayload, &notification)
 
-		go c.SafeEmit(notification.TargetId, notification.Event, notification.Data)
+		if len(c.routes.get(notification.TargetId)) == 0 {
+			c.logger.Debugf("notification without handler [targetId:%s, event:%s]",
+				notification.TargetId, notification.Event)
+			return
+		}
+
+		spawn("channel.notification", func() {
+			c.dispatch(notification.TargetId, notification.Event, notification.Data)
+		})
 	} else {
 		c.logger.Errorln("received message is not a response nor a notification")
 	}
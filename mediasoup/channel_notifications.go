@@ -0,0 +1,129 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// notificationHandler handles the notifications of a worker entity.
+type notificationHandler func(event string, data json.RawMessage)
+
+// notificationSubscription identifies a handler added by Channel.subscribe.
+// Several entities can share a targetId (e.g. a Producer piped to a Router of
+// the same Worker and its pipe Producer), so each one removes just its own.
+type notificationSubscription struct {
+	targetId string
+	id       uint64
+}
+
+type notificationRoute struct {
+	id      uint64
+	handler notificationHandler
+}
+
+// notificationRoutes routes the worker notifications to the handlers
+// registered for their targetId, calling them directly instead of through the
+// reflection of the EventEmitter. The routes of a targetId are replaced, never
+// modified, so that they are called without holding the lock.
+type notificationRoutes struct {
+	mu     sync.RWMutex
+	lastId uint64
+	routes map[string][]notificationRoute
+}
+
+func newNotificationRoutes() *notificationRoutes {
+	return &notificationRoutes{
+		routes: make(map[string][]notificationRoute),
+	}
+}
+
+func (r *notificationRoutes) get(targetId string) []notificationRoute {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.routes[targetId]
+}
+
+// subscribe routes the notifications of targetId to handler, along with the
+// other handlers of targetId.
+func (c *Channel) subscribe(targetId string, handler notificationHandler) notificationSubscription {
+	c.routes.mu.Lock()
+	defer c.routes.mu.Unlock()
+
+	c.routes.lastId++
+
+	routes := c.routes.routes[targetId]
+	newRoutes := make([]notificationRoute, len(routes), len(routes)+1)
+	copy(newRoutes, routes)
+
+	c.routes.routes[targetId] = append(newRoutes, notificationRoute{
+		id:      c.routes.lastId,
+		handler: handler,
+	})
+
+	return notificationSubscription{targetId: targetId, id: c.routes.lastId}
+}
+
+// unsubscribe removes the handler of the given subscription, keeping the other
+// handlers of its targetId.
+func (c *Channel) unsubscribe(subscription notificationSubscription) {
+	c.routes.mu.Lock()
+	defer c.routes.mu.Unlock()
+
+	routes := c.routes.routes[subscription.targetId]
+	newRoutes := make([]notificationRoute, 0, len(routes))
+
+	for _, route := range routes {
+		if route.id != subscription.id {
+			newRoutes = append(newRoutes, route)
+		}
+	}
+
+	if len(newRoutes) == len(routes) {
+		return
+	}
+	if len(newRoutes) == 0 {
+		delete(c.routes.routes, subscription.targetId)
+	} else {
+		c.routes.routes[subscription.targetId] = newRoutes
+	}
+}
+
+// subscribeOnce routes the first notification of targetId to handler, then
+// removes it.
+func (c *Channel) subscribeOnce(targetId string, handler notificationHandler) {
+	var once sync.Once
+
+	// Passes the subscription to the handler, which may be called before
+	// subscribe returns.
+	subscription := make(chan notificationSubscription, 1)
+
+	subscription <- c.subscribe(targetId, func(event string, data json.RawMessage) {
+		once.Do(func() {
+			c.unsubscribe(<-subscription)
+			handler(event, data)
+		})
+	})
+}
+
+// dispatch calls the handlers of targetId with the notification, returning
+// whether there are some.
+func (c *Channel) dispatch(targetId, event string, data json.RawMessage) (ok bool) {
+	routes := c.routes.get(targetId)
+
+	for _, route := range routes {
+		c.callHandler(route.handler, targetId, event, data)
+	}
+
+	return len(routes) > 0
+}
+
+func (c *Channel) callHandler(handler notificationHandler, targetId, event string, data json.RawMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Errorf("notification handler panic [targetId:%s, event:%s]: %v", targetId, event, r)
+		}
+	}()
+
+	handler(event, data)
+}
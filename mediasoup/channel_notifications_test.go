@@ -0,0 +1,126 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannel_NotificationRoutes(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	assert.False(t, channel.dispatch("id1", "score", nil))
+
+	var events []string
+
+	subscription := channel.subscribe("id1", func(event string, data json.RawMessage) {
+		events = append(events, event+":"+string(data))
+	})
+	assert.True(t, channel.dispatch("id1", "score", json.RawMessage(`1`)))
+	assert.False(t, channel.dispatch("id2", "score", json.RawMessage(`2`)))
+	assert.Equal(t, []string{"score:1"}, events)
+
+	// A panicking handler does not break the dispatch.
+	channel.subscribe("id2", func(event string, data json.RawMessage) {
+		panic("oops")
+	})
+	assert.True(t, channel.dispatch("id2", "score", nil))
+
+	channel.unsubscribe(subscription)
+	assert.False(t, channel.dispatch("id1", "score", nil))
+	assert.Equal(t, []string{"score:1"}, events)
+}
+
+func TestChannel_NotificationRoutesShareTargetId(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	var events []string
+
+	subscription1 := channel.subscribe("id1", func(event string, data json.RawMessage) {
+		events = append(events, "handler1:"+event)
+	})
+	subscription2 := channel.subscribe("id1", func(event string, data json.RawMessage) {
+		events = append(events, "handler2:"+event)
+	})
+
+	assert.True(t, channel.dispatch("id1", "score", nil))
+	assert.Equal(t, []string{"handler1:score", "handler2:score"}, events)
+
+	// Just the given handler is removed.
+	channel.unsubscribe(subscription1)
+	channel.unsubscribe(subscription1)
+	assert.True(t, channel.dispatch("id1", "trace", nil))
+	assert.Equal(t, []string{"handler1:score", "handler2:score", "handler2:trace"}, events)
+
+	channel.unsubscribe(subscription2)
+	assert.False(t, channel.dispatch("id1", "score", nil))
+}
+
+func TestChannel_SubscribeOnce(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	var events []string
+
+	channel.subscribeOnce("id1", func(event string, data json.RawMessage) {
+		events = append(events, event)
+	})
+
+	assert.True(t, channel.dispatch("id1", "running", nil))
+	assert.False(t, channel.dispatch("id1", "running", nil))
+	assert.Equal(t, []string{"running"}, events)
+}
+
+func TestChannel_RoutesWorkerNotifications(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	type notification struct {
+		event string
+		data  string
+	}
+	notifications := make(chan notification, 1)
+
+	channel.subscribe("id1", func(event string, data json.RawMessage) {
+		notifications <- notification{event, string(data)}
+	})
+
+	channel.processNSPayload([]byte(`{"targetId":"id2","event":"score","data":2}`))
+	channel.processNSPayload([]byte(`{"targetId":"id1","event":"score","data":{"score":1}}`))
+
+	select {
+	case n := <-notifications:
+		assert.Equal(t, notification{"score", `{"score":1}`}, n)
+	case <-time.After(time.Second):
+		assert.FailNow(t, "timeout")
+	}
+}
+
+func BenchmarkChannel_DispatchEmitter(b *testing.B) {
+	emitter := NewEventEmitter(AppLogger())
+	emitter.On("id1", func(event string, data json.RawMessage) {})
+
+	data := json.RawMessage(`{"score":10}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		emitter.SafeEmit("id1", "score", data)
+	}
+}
+
+func BenchmarkChannel_DispatchRoutes(b *testing.B) {
+	channel := newFakeWorker(b).channel
+
+	channel.subscribe("id1", func(event string, data json.RawMessage) {})
+
+	data := json.RawMessage(`{"score":10}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		channel.dispatch("id1", "score", data)
+	}
+}
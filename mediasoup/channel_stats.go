@@ -0,0 +1,241 @@
+package mediasoup
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Seconds over which the channel rates are computed.
+	channelStatsWindow = 10
+	// Number of latest request latencies the percentiles are computed from.
+	channelStatsLatencySamples = 1024
+	// Same, by request method.
+	channelStatsMethodLatencySamples = 256
+)
+
+// ChannelStats are the I/O statistics of the channel to a worker, rates being
+// over the last 10 seconds. There is no payload channel yet, so they cover
+// all the traffic with the worker.
+type ChannelStats struct {
+	BytesSent                 uint64  `json:"bytesSent"`
+	BytesReceived             uint64  `json:"bytesReceived"`
+	MessagesSent              uint64  `json:"messagesSent"`
+	MessagesReceived          uint64  `json:"messagesReceived"`
+	BytesSentPerSecond        float64 `json:"bytesSentPerSecond"`
+	BytesReceivedPerSecond    float64 `json:"bytesReceivedPerSecond"`
+	MessagesSentPerSecond     float64 `json:"messagesSentPerSecond"`
+	MessagesReceivedPerSecond float64 `json:"messagesReceivedPerSecond"`
+	// Requests waiting for their response.
+	PendingRequests int `json:"pendingRequests"`
+	// Latencies of the latest answered requests.
+	RequestLatency ChannelLatencyStats `json:"requestLatency"`
+	// Same, by request method (e.g. "transport.consume").
+	RequestLatencyByMethod map[string]ChannelLatencyStats `json:"requestLatencyByMethod,omitempty"`
+}
+
+// ChannelLatencyStats are request latency percentiles, in milliseconds.
+type ChannelLatencyStats struct {
+	// Number of answered requests.
+	Count uint64 `json:"count"`
+	// Number of latest ones the percentiles are computed from.
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+}
+
+// latencyRing keeps the latest request latencies, in ms.
+type latencyRing struct {
+	count     uint64
+	latencies []float64
+	// Index of the oldest latency once full.
+	start int
+}
+
+func newLatencyRing(size int) *latencyRing {
+	return &latencyRing{latencies: make([]float64, 0, size)}
+}
+
+func (r *latencyRing) add(ms float64) {
+	r.count++
+
+	if len(r.latencies) < cap(r.latencies) {
+		r.latencies = append(r.latencies, ms)
+		return
+	}
+
+	r.latencies[r.start] = ms
+	r.start = (r.start + 1) % len(r.latencies)
+}
+
+func (r *latencyRing) stats() ChannelLatencyStats {
+	n := len(r.latencies)
+	if n == 0 {
+		return ChannelLatencyStats{}
+	}
+
+	latencies := append([]float64(nil), r.latencies...)
+	sort.Float64s(latencies)
+
+	percentile := func(p int) float64 {
+		return latencies[(n-1)*p/100]
+	}
+
+	return ChannelLatencyStats{
+		Count:   r.count,
+		Samples: n,
+		P50:     percentile(50),
+		P90:     percentile(90),
+		P95:     percentile(95),
+		P99:     percentile(99),
+		Max:     latencies[n-1],
+	}
+}
+
+type channelStatsBucket struct {
+	second           int64
+	bytesSent        uint64
+	bytesReceived    uint64
+	messagesSent     uint64
+	messagesReceived uint64
+}
+
+// channelStats tracks the I/O of a Channel.
+type channelStats struct {
+	mu     sync.Mutex
+	totals channelStatsBucket
+	// Per second counters of the window and the current second, by second
+	// modulo their number.
+	buckets [channelStatsWindow + 1]channelStatsBucket
+	// Latest request latencies, overall and by method.
+	latencies       *latencyRing
+	methodLatencies map[string]*latencyRing
+	now             func() time.Time
+}
+
+func newChannelStats() *channelStats {
+	return &channelStats{
+		latencies:       newLatencyRing(channelStatsLatencySamples),
+		methodLatencies: make(map[string]*latencyRing),
+		now:             time.Now,
+	}
+}
+
+// bucket returns the counters of the current second. It must be called with
+// the lock held.
+func (s *channelStats) bucket() *channelStatsBucket {
+	second := s.now().Unix()
+	bucket := &s.buckets[second%int64(len(s.buckets))]
+
+	if bucket.second != second {
+		*bucket = channelStatsBucket{second: second}
+	}
+
+	return bucket
+}
+
+func (s *channelStats) sent(bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket := s.bucket()
+	bucket.bytesSent += uint64(bytes)
+	bucket.messagesSent++
+	s.totals.bytesSent += uint64(bytes)
+	s.totals.messagesSent++
+}
+
+func (s *channelStats) receivedBytes(bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bucket().bytesReceived += uint64(bytes)
+	s.totals.bytesReceived += uint64(bytes)
+}
+
+func (s *channelStats) receivedMessage() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bucket().messagesReceived++
+	s.totals.messagesReceived++
+}
+
+func (s *channelStats) latency(method string, latency time.Duration) {
+	ms := float64(latency) / float64(time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies.add(ms)
+
+	methodLatencies := s.methodLatencies[method]
+	if methodLatencies == nil {
+		methodLatencies = newLatencyRing(channelStatsMethodLatencySamples)
+		s.methodLatencies[method] = methodLatencies
+	}
+	methodLatencies.add(ms)
+}
+
+func (s *channelStats) snapshot(pendingRequests int) ChannelStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ChannelStats{
+		BytesSent:        s.totals.bytesSent,
+		BytesReceived:    s.totals.bytesReceived,
+		MessagesSent:     s.totals.messagesSent,
+		MessagesReceived: s.totals.messagesReceived,
+		PendingRequests:  pendingRequests,
+	}
+
+	// The current second is not over, so the rates are over the previous ones.
+	second := s.now().Unix()
+
+	for _, bucket := range s.buckets {
+		if bucket.second < second && bucket.second >= second-channelStatsWindow {
+			stats.BytesSentPerSecond += float64(bucket.bytesSent)
+			stats.BytesReceivedPerSecond += float64(bucket.bytesReceived)
+			stats.MessagesSentPerSecond += float64(bucket.messagesSent)
+			stats.MessagesReceivedPerSecond += float64(bucket.messagesReceived)
+		}
+	}
+
+	stats.BytesSentPerSecond /= channelStatsWindow
+	stats.BytesReceivedPerSecond /= channelStatsWindow
+	stats.MessagesSentPerSecond /= channelStatsWindow
+	stats.MessagesReceivedPerSecond /= channelStatsWindow
+
+	stats.RequestLatency = s.latencies.stats()
+
+	if len(s.methodLatencies) > 0 {
+		stats.RequestLatencyByMethod = make(map[string]ChannelLatencyStats, len(s.methodLatencies))
+
+		for method, latencies := range s.methodLatencies {
+			stats.RequestLatencyByMethod[method] = latencies.stats()
+		}
+	}
+
+	return stats
+}
+
+// ChannelStats returns the I/O statistics of the channel to the worker:
+// traffic in both directions and request latencies, overall and by method.
+func (w *Worker) ChannelStats() ChannelStats {
+	c := w.channel
+
+	return c.stats.snapshot(c.pendingRequests())
+}
+
+// pendingRequests returns the number of the requests waiting for their
+// response.
+func (c *Channel) pendingRequests() int {
+	c.sentsLocker.Lock()
+	defer c.sentsLocker.Unlock()
+
+	return len(c.sents)
+}
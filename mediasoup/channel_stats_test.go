@@ -0,0 +1,84 @@
+package mediasoup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelStats(t *testing.T) {
+	stats := newChannelStats()
+
+	var clock int64 = 1000
+	stats.now = func() time.Time {
+		return time.Unix(clock, 0)
+	}
+
+	for i := 0; i < 10; i++ {
+		stats.sent(100)
+		stats.receivedBytes(50)
+		stats.receivedMessage()
+		clock++
+	}
+	// Current second, not counted in the rates.
+	stats.sent(1000)
+
+	for i := 1; i <= 100; i++ {
+		method := "transport.consume"
+		if i%2 == 0 {
+			method = "consumer.resume"
+		}
+		stats.latency(method, time.Duration(i)*time.Millisecond)
+	}
+
+	snapshot := stats.snapshot(3)
+
+	assert.EqualValues(t, 2000, snapshot.BytesSent)
+	assert.EqualValues(t, 11, snapshot.MessagesSent)
+	assert.EqualValues(t, 500, snapshot.BytesReceived)
+	assert.EqualValues(t, 10, snapshot.MessagesReceived)
+	assert.EqualValues(t, 100, snapshot.BytesSentPerSecond)
+	assert.EqualValues(t, 1, snapshot.MessagesSentPerSecond)
+	assert.EqualValues(t, 50, snapshot.BytesReceivedPerSecond)
+	assert.EqualValues(t, 1, snapshot.MessagesReceivedPerSecond)
+	assert.Equal(t, 3, snapshot.PendingRequests)
+	assert.Equal(t, ChannelLatencyStats{Count: 100, Samples: 100, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}, snapshot.RequestLatency)
+	assert.Equal(t, map[string]ChannelLatencyStats{
+		"transport.consume": {Count: 50, Samples: 50, P50: 49, P90: 89, P95: 93, P99: 97, Max: 99},
+		"consumer.resume":   {Count: 50, Samples: 50, P50: 50, P90: 90, P95: 94, P99: 98, Max: 100},
+	}, snapshot.RequestLatencyByMethod)
+
+	// Old seconds leave the window.
+	clock += 20
+	snapshot = stats.snapshot(0)
+	assert.Zero(t, snapshot.BytesSentPerSecond)
+	assert.EqualValues(t, 2000, snapshot.BytesSent)
+
+	// Just the latest latencies are kept.
+	for i := 0; i < channelStatsLatencySamples; i++ {
+		stats.latency("consumer.resume", time.Second)
+	}
+	snapshot = stats.snapshot(0)
+	assert.Equal(t, channelStatsLatencySamples, snapshot.RequestLatency.Samples)
+	assert.EqualValues(t, 100+channelStatsLatencySamples, snapshot.RequestLatency.Count)
+	assert.EqualValues(t, 1000, snapshot.RequestLatency.P50)
+	assert.Equal(t, channelStatsMethodLatencySamples, snapshot.RequestLatencyByMethod["consumer.resume"].Samples)
+	assert.EqualValues(t, 50+channelStatsLatencySamples, snapshot.RequestLatencyByMethod["consumer.resume"].Count)
+	assert.EqualValues(t, 1000, snapshot.RequestLatencyByMethod["consumer.resume"].P50)
+}
+
+func TestChannelStats_Channel(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	require.NoError(t, channel.Request("worker.dump", nil).Err())
+
+	stats := channel.stats.snapshot(0)
+	assert.EqualValues(t, 1, stats.MessagesSent)
+	assert.NotZero(t, stats.BytesSent)
+	assert.EqualValues(t, 1, stats.MessagesReceived)
+	assert.NotZero(t, stats.BytesReceived)
+	assert.Equal(t, 1, stats.RequestLatency.Samples)
+	assert.EqualValues(t, 1, stats.RequestLatencyByMethod["worker.dump"].Count)
+}
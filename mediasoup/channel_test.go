@@ -0,0 +1,78 @@
+package mediasoup
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelRequest_WritesHigherPriorityFirst(t *testing.T) {
+	// The first request blocks the write loop until the worker reads.
+	fake := newFakeWorker(t, withFakeWorkerHeld())
+
+	wg := sync.WaitGroup{}
+	request := func(method string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, fake.channel.Request(method, nil).Err())
+		}()
+	}
+
+	request("router.createWebRtcTransport")
+	time.Sleep(50 * time.Millisecond)
+	request("transport.getStats")
+	time.Sleep(50 * time.Millisecond)
+	request("consumer.requestKeyFrame")
+	time.Sleep(50 * time.Millisecond)
+
+	fake.release()
+	wg.Wait()
+
+	assert.Equal(t, []string{
+		"router.createWebRtcTransport",
+		"consumer.requestKeyFrame",
+		"transport.getStats",
+	}, fake.takeMethods())
+}
+
+func TestChannelRequest_RetriesIdempotentRequests(t *testing.T) {
+	fake := newFakeWorker(t)
+	fake.channel.timeout = 20 * time.Millisecond
+
+	// The first request of each method times out.
+	var answered sync.Map
+	fake.reply("*", func(request fakeWorkerRequest) (interface{}, error) {
+		if _, ok := answered.LoadOrStore(request.Method, true); !ok {
+			return nil, errFakeWorkerNoResponse
+		}
+		return H{}, nil
+	})
+	worker := fake.worker(1)
+
+	// Not retried without backoff.
+	_, err := worker.Dump()
+	assert.ErrorIs(t, err, ErrChannelRequestTimeout)
+	answered.Delete("worker.dump")
+	fake.takeRequests()
+
+	fake.channel.requestBackoff = &Backoff{Initial: time.Millisecond, MaxRetries: 2}
+
+	_, err = worker.Dump()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker.dump", "worker.dump"}, fake.takeMethods())
+
+	_, err = worker.Ping(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker.getResourceUsage", "worker.getResourceUsage"}, fake.takeMethods())
+
+	// The other requests are not retried.
+	answered.Delete("router.createWebRtcTransport")
+	err = fake.channel.Request("router.createWebRtcTransport", nil).Err()
+	assert.ErrorIs(t, err, ErrChannelRequestTimeout)
+	assert.Equal(t, []string{"router.createWebRtcTransport"}, fake.takeMethods())
+}
@@ -0,0 +1,208 @@
+package mediasoup
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const concurrentCloses = 2000
+
+// closeCounter counts the "close" events emitted by an observer.
+type closeCounter struct {
+	counts map[string]*int32
+}
+
+func newCloseCounter() *closeCounter {
+	return &closeCounter{counts: make(map[string]*int32)}
+}
+
+func (c *closeCounter) watch(id string, observer EventEmitter) {
+	count := new(int32)
+	c.counts[id] = count
+
+	observer.On("close", func() {
+		atomic.AddInt32(count, 1)
+	})
+}
+
+func (c *closeCounter) assertClosedOnce(t *testing.T) {
+	for id, count := range c.counts {
+		assert.EqualValues(t, 1, atomic.LoadInt32(count), "close events of %s", id)
+	}
+}
+
+// runConcurrently runs concurrentCloses calls of the given functions, picked
+// randomly, all at once.
+func runConcurrently(closes ...func()) {
+	var (
+		start = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+
+	for i := 0; i < concurrentCloses; i++ {
+		closeFn := closes[rand.Intn(len(closes))]
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+			closeFn()
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+}
+
+func setupCloseTest(t *testing.T, worker *Worker, counter *closeCounter) (
+	router *Router, transports []Transport, producers []*Producer, consumers []*Consumer,
+) {
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+	counter.watch(router.Id(), router.Observer())
+
+	for i := 0; i < 4; i++ {
+		transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+			ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+		})
+		require.NoError(t, err)
+		counter.watch(transport.Id(), transport.Observer())
+		transports = append(transports, transport)
+	}
+
+	for i, params := range []TransportProduceParams{audioProducerParameters, videoProducerParameters} {
+		producer, err := transports[i].Produce(params)
+		require.NoError(t, err)
+		counter.watch(producer.Id(), producer.Observer())
+		producers = append(producers, producer)
+	}
+
+	for _, transport := range transports[2:] {
+		for _, producer := range producers {
+			consumer, err := transport.Consume(TransportConsumeParams{
+				ProducerId:      producer.Id(),
+				RtpCapabilities: router.RtpCapabilities(),
+			})
+			require.NoError(t, err)
+			counter.watch(consumer.Id(), consumer.Observer())
+			consumers = append(consumers, consumer)
+		}
+	}
+
+	return
+}
+
+func TestConcurrentClose_RouterAndChildren(t *testing.T) {
+	counter := newCloseCounter()
+	router, transports, producers, consumers := setupCloseTest(t, worker, counter)
+
+	closes := []func(){func() { router.Close() }}
+
+	for _, transport := range transports {
+		transport := transport
+		closes = append(closes, func() { transport.Close() })
+	}
+	for _, producer := range producers {
+		producer := producer
+		closes = append(closes, func() { producer.Close() })
+	}
+	for _, consumer := range consumers {
+		consumer := consumer
+		closes = append(closes, func() { consumer.Close() })
+	}
+
+	runConcurrently(closes...)
+
+	// The Router may not have been picked.
+	router.Close()
+
+	assert.True(t, router.Closed())
+	assert.Empty(t, router.getTransports())
+	assert.Empty(t, router.getProducers())
+
+	for _, transport := range transports {
+		assert.True(t, transport.Closed())
+	}
+	for _, producer := range producers {
+		assert.True(t, producer.Closed())
+	}
+	for _, consumer := range consumers {
+		assert.True(t, consumer.Closed())
+	}
+
+	counter.assertClosedOnce(t)
+}
+
+func TestConcurrentClose_WorkerAndChildren(t *testing.T) {
+	worker := CreateTestWorker()
+	counter := newCloseCounter()
+	counter.watch("worker", worker.Observer())
+
+	closes := []func(){func() { worker.Close() }}
+
+	for i := 0; i < 3; i++ {
+		router, transports, _, consumers := setupCloseTest(t, worker, counter)
+
+		closes = append(closes, func() { router.Close() })
+
+		for _, transport := range transports {
+			transport := transport
+			closes = append(closes, func() { transport.Close() })
+		}
+		for _, consumer := range consumers {
+			consumer := consumer
+			closes = append(closes, func() { consumer.Close() })
+		}
+	}
+
+	runConcurrently(closes...)
+
+	// The Worker may not have been picked.
+	worker.Close()
+
+	assert.True(t, worker.Closed())
+
+	counter.assertClosedOnce(t)
+}
+
+func TestConcurrentClose_CreateWhileClosing(t *testing.T) {
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+
+	var (
+		wg         sync.WaitGroup
+		transports = make(chan Transport, 100)
+	)
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+				ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+			})
+			if err == nil {
+				transports <- transport
+			}
+		}()
+	}
+
+	router.Close()
+	wg.Wait()
+	close(transports)
+
+	// No Transport survives its Router.
+	for transport := range transports {
+		assert.True(t, transport.Closed())
+	}
+	assert.Empty(t, router.getTransports())
+}
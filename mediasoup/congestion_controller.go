@@ -0,0 +1,116 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// CongestionFeedback is the congestion control feedback of a Transport, from
+// its "bwe" trace events.
+type CongestionFeedback struct {
+	TransportId string `json:"transportId"`
+	// "in" or "out".
+	Direction string    `json:"direction,omitempty"`
+	Sample    BweSample `json:"sample"`
+	// Raw feedback, as given by the worker.
+	Info json.RawMessage `json:"info,omitempty"`
+}
+
+// CongestionController is an external congestion controller (e.g. a custom
+// ABR algorithm), given the congestion control feedback of a WebRtcTransport
+// and applying its decisions with the given control.
+type CongestionController interface {
+	// OnFeedback is called with the feedbacks in order, from a goroutine of
+	// the controller, so that it doesn't block the worker channel.
+	OnFeedback(control CongestionControl, feedback CongestionFeedback)
+}
+
+// CongestionControllerFunc is a function used as CongestionController.
+type CongestionControllerFunc func(control CongestionControl, feedback CongestionFeedback)
+
+func (fn CongestionControllerFunc) OnFeedback(control CongestionControl, feedback CongestionFeedback) {
+	fn(control, feedback)
+}
+
+// CongestionControl applies the commands of a CongestionController to its
+// WebRtcTransport. It may be kept to send commands at any time.
+type CongestionControl struct {
+	transport *WebRtcTransport
+}
+
+// SetTargetBitrate sets the maximum outgoing bitrate of the Transport, in bps.
+func (c CongestionControl) SetTargetBitrate(bitrate int) error {
+	return c.transport.SetMaxOutgoingBitrate(bitrate)
+}
+
+// SetPreferredLayers sets the preferred layers of the given Consumer of the
+// Transport.
+func (c CongestionControl) SetPreferredLayers(consumerId string, spatialLayer, temporalLayer uint8) error {
+	for _, consumer := range c.transport.getConsumers() {
+		if consumer.Id() == consumerId {
+			return consumer.SetPreferredLayers(spatialLayer, temporalLayer)
+		}
+	}
+
+	return fmt.Errorf(`Consumer with id "%s" not found`, consumerId)
+}
+
+// Consumers returns the Consumers of the Transport.
+func (c CongestionControl) Consumers() []*Consumer {
+	return c.transport.getConsumers()
+}
+
+/**
+ * Stream the congestion control feedback of the Transport to the given
+ * controller until detached (or the Transport closed). The "bwe" trace
+ * events are enabled, replacing the enabled trace types, and disabled on
+ * detach. The feedbacks not handled in time by the controller are dropped.
+ */
+func (t *WebRtcTransport) AttachCongestionController(controller CongestionController) (detach func(), err error) {
+	t.logger.Debug("attachCongestionController()")
+
+	ch, unsubscribe := t.Subscribe("trace")
+
+	if err = t.EnableTraceEvent("bwe"); err != nil {
+		unsubscribe()
+		return
+	}
+
+	control := CongestionControl{transport: t}
+
+	spawn("transport.congestionController", func() {
+		for argv := range ch {
+			trace, ok := argv[0].(TransportTraceEventData)
+			if !ok || trace.Type != "bwe" {
+				continue
+			}
+
+			feedback := CongestionFeedback{
+				TransportId: t.Id(),
+				Direction:   trace.Direction,
+				Sample:      BweSample{Timestamp: trace.Timestamp},
+				Info:        trace.Info,
+			}
+			json.Unmarshal([]byte(trace.Info), &feedback.Sample)
+
+			controller.OnFeedback(control, feedback)
+		}
+	})
+
+	var once sync.Once
+
+	detach = func() {
+		once.Do(func() {
+			unsubscribe()
+
+			if !t.Closed() {
+				t.EnableTraceEvent()
+			}
+		})
+	}
+
+	t.observer.Once("close", detach)
+
+	return detach, nil
+}
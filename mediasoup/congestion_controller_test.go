@@ -0,0 +1,62 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebRtcTransport_AttachCongestionController(t *testing.T) {
+	fake := newFakeWorker(t)
+	channel := fake.channel
+
+	receive := func() string {
+		request := fake.nextRequest(t)
+		return fmt.Sprintf("%s %s", request.Method, request.Data)
+	}
+
+	transport := NewWebRtcTransport(WebRtcTransportData{}, createTransportParams{
+		Internal: internalData{TransportId: "transport"},
+		Channel:  channel,
+	})
+
+	feedbacks := make(chan CongestionFeedback, 10)
+
+	detach, err := transport.AttachCongestionController(CongestionControllerFunc(
+		func(control CongestionControl, feedback CongestionFeedback) {
+			feedbacks <- feedback
+
+			control.SetTargetBitrate(int(feedback.Sample.AvailableBitrate) / 2)
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, `transport.enableTraceEvent {"types":["bwe"]}`, receive())
+
+	fake.notify("transport", "trace", json.RawMessage(
+		`{"type":"probation","timestamp":1,"direction":"out"}`))
+	fake.notify("transport", "trace", json.RawMessage(
+		`{"type":"bwe","timestamp":2,"direction":"out","info":{"type":"transport-cc","availableBitrate":800000}}`))
+
+	select {
+	case feedback := <-feedbacks:
+		assert.Equal(t, "transport", feedback.TransportId)
+		assert.Equal(t, "out", feedback.Direction)
+		assert.Equal(t, BweSample{Timestamp: 2, Type: "transport-cc", AvailableBitrate: 800000}, feedback.Sample)
+		assert.JSONEq(t, `{"type":"transport-cc","availableBitrate":800000}`, string(feedback.Info))
+	case <-time.After(time.Second):
+		t.Fatal("no feedback")
+	}
+	assert.Equal(t, `transport.setMaxOutgoingBitrate {"bitrate":400000}`, receive())
+
+	err = CongestionControl{transport: transport}.SetPreferredLayers("unknown", 1, 1)
+	assert.Error(t, err)
+
+	detach()
+	detach()
+	assert.Equal(t, `transport.enableTraceEvent {"types":[]}`, receive())
+	assert.Equal(t, 0, transport.ListenerCount("trace"))
+	assert.Empty(t, feedbacks)
+}
@@ -1,7 +1,9 @@
 package mediasoup
 
 import (
+	"context"
 	"encoding/json"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -14,23 +16,52 @@ type Consumer struct {
 	channel        *Channel
 	appData        interface{}
 	paused         bool
-	closed         bool
+	closed         closeFlag
 	producerPaused bool
 	score          *ConsumerScore
 	// Current video layers (just for video with simulcast or SVC).
 	currentLayers *VideoLayer
-	observer      EventEmitter
+	// Preferred video layers, set by SetPreferredLayers.
+	preferredLayers *VideoLayer
+	observer        EventEmitter
+	closeCh         chan struct{}
+	// Closed once the first media is traced, the "rtp" trace event being
+	// enabled once.
+	firstMediaCh    chan struct{}
+	firstMediaWatch sync.Once
+	firstMediaOnce  sync.Once
+	// Policy on Producer close, and what is needed to replace the Producer.
+	onProducerClose ProducerCloseHook
+	getProducerById fetchProducerFunc
+	// Guards producerClosed and the Producer replacement.
+	producerLocker sync.Mutex
+	producerClosed bool
+	// Limits the "score" events, nil if not rate limited.
+	scoreThrottle *notificationThrottle
+	// Guards preferredLayers, requestedLayers and bitrateCap, serializing the
+	// changes of the preferred layers.
+	layersLocker sync.Mutex
+	// Layers given to SetPreferredLayers, restored when the cap is removed.
+	requestedLayers *consumerRequestedLayers
+	// Bitrate cap, set by SetMaxBitrate.
+	bitrateCap *ConsumerBitrateCap
+	// Route of the worker notifications.
+	subscription notificationSubscription
 }
 
 /**
  * New Consumer
  *
  * @emits transportclose
+ * @emits producerclose
+ * @emits {producerId: String} producerreplace
  * @emits consumerclose
  * @emits consumerpause
  * @emits consumerresume
  * @emits {consumer: Number, consumer: Number} score
  * @emits {spatialLayer: Number|Null} layerschange
+ * @emits {layersStatus: ConsumerLayersStatus} layersstatuschange
+ * @emits firstmedia
  * @emits @close
  * @emits @consumerclose
  */
@@ -43,12 +74,16 @@ func NewConsumer(
 	producerPaused bool,
 	score *ConsumerScore,
 ) *Consumer {
-	logger := TypeLogger("Consumer")
+	appLogger := entityAppLogger(data.AppLogger)
+	logger := appLogger.WithField("type", "Consumer")
 
 	logger.Debug("constructor()")
 
+	closed, ctx := newCloseFlag()
+
 	consumer := &Consumer{
-		EventEmitter: NewEventEmitter(logger),
+		EventEmitter: NewEventEmitter(logger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		closed:       closed,
 		logger:       logger,
 		// - .routerId
 		// - .transportId
@@ -61,7 +96,10 @@ func NewConsumer(
 		paused:         paused,
 		producerPaused: producerPaused,
 		score:          score,
-		observer:       NewEventEmitter(AppLogger()),
+		observer:       NewEventEmitter(appLogger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		closeCh:        make(chan struct{}),
+		firstMediaCh:   make(chan struct{}),
+		scoreThrottle:  newNotificationThrottle(data.ScoreInterval),
 	}
 
 	consumer.handleWorkerNotifications()
@@ -86,7 +124,7 @@ func (consumer *Consumer) ProducerId() string {
 
 // Whether the Consumer is closed.
 func (consumer *Consumer) Closed() bool {
-	return consumer.closed
+	return consumer.closed.isSet()
 }
 
 // Media kind.
@@ -138,6 +176,7 @@ func (consumer *Consumer) AppData() interface{} {
  * @emits resume
  * @emits {consumer: Number, consumer: Number} score
  * @emits {spatialLayer: Number|Null} layerschange
+ * @emits firstmedia
  */
 func (consumer *Consumer) Observer() EventEmitter {
 	return consumer.observer
@@ -145,20 +184,21 @@ func (consumer *Consumer) Observer() EventEmitter {
 
 // Close the Consumer.
 func (consumer *Consumer) Close() (err error) {
-	if consumer.closed {
+	if !consumer.closed.set() {
 		return
 	}
-
-	consumer.closed = true
+	defer consumer.closed.cancelContext()
+	close(consumer.closeCh)
 
 	consumer.logger.Debug("close()")
 
-	consumer.channel.RemoveAllListeners(consumer.internal.ConsumerId)
+	consumer.channel.unsubscribe(consumer.subscription)
 
-	response := consumer.channel.Request("consumer.close", consumer.internal, nil)
+	// Not in the worker anymore if its Producer closed.
+	if !consumer.ProducerClosed() {
+		response := consumer.channel.Request("consumer.close", consumer.internal, nil)
 
-	if err = response.Err(); err != nil {
-		return
+		err = response.Err()
 	}
 
 	consumer.Emit("@close")
@@ -171,11 +211,11 @@ func (consumer *Consumer) Close() (err error) {
 
 // Transport was closed.
 func (consumer *Consumer) TransportClosed() {
-	if consumer.closed {
+	if !consumer.closed.set() {
 		return
 	}
-
-	consumer.closed = true
+	defer consumer.closed.cancelContext()
+	close(consumer.closeCh)
 
 	consumer.logger.Debug("transportClosed()")
 
@@ -189,14 +229,14 @@ func (consumer *Consumer) TransportClosed() {
 func (consumer *Consumer) Dump() Response {
 	consumer.logger.Debug("dump()")
 
-	return consumer.channel.Request("consumer.dump", consumer.internal, nil)
+	return consumer.channel.requestIdempotent(context.Background(), "consumer.dump", consumer.internal, nil)
 }
 
 // Get Consumer stats.
 func (consumer *Consumer) GetStats() Response {
 	consumer.logger.Debug("getStats()")
 
-	return consumer.channel.Request("consumer.getStats", consumer.internal, nil)
+	return consumer.channel.requestIdempotent(context.Background(), "consumer.getStats", consumer.internal, nil)
 }
 
 // Pause the Consumer.
@@ -243,10 +283,35 @@ func (consumer *Consumer) Resume() (err error) {
 	return
 }
 
-// Set preferred video layers.
+// Set preferred video layers, lowered to the layers enforced by the bitrate
+// cap if any.
 func (consumer *Consumer) SetPreferredLayers(spatialLayer, temporalLayer uint8) (err error) {
 	consumer.logger.Debug("setPreferredLayers()")
 
+	consumer.layersLocker.Lock()
+	defer consumer.layersLocker.Unlock()
+
+	appliedSpatialLayer, appliedTemporalLayer := spatialLayer, temporalLayer
+
+	if bitrateCap := consumer.bitrateCap; bitrateCap != nil {
+		appliedSpatialLayer, appliedTemporalLayer = bitrateCap.clampLayers(spatialLayer, temporalLayer)
+	}
+
+	if err = consumer.setPreferredLayers(appliedSpatialLayer, appliedTemporalLayer); err != nil {
+		return
+	}
+
+	consumer.requestedLayers = &consumerRequestedLayers{
+		spatialLayer:  spatialLayer,
+		temporalLayer: temporalLayer,
+	}
+
+	return
+}
+
+// setPreferredLayers sets the preferred layers in the worker, layersLocker
+// being held.
+func (consumer *Consumer) setPreferredLayers(spatialLayer, temporalLayer uint8) (err error) {
 	response := consumer.channel.Request(
 		"consumer.setPreferredLayers",
 		consumer.internal,
@@ -256,7 +321,13 @@ func (consumer *Consumer) SetPreferredLayers(spatialLayer, temporalLayer uint8)
 		},
 	)
 
-	return response.Err()
+	if err = response.Err(); err != nil {
+		return
+	}
+
+	consumer.preferredLayers = &VideoLayer{SpatialLayer: spatialLayer}
+
+	return
 }
 
 // Request a key frame to the Producer.
@@ -268,17 +339,97 @@ func (consumer *Consumer) RequestKeyFrame() error {
 	return response.Err()
 }
 
+// AwaitFirstMedia waits until the worker has sent the first RTP packet of the
+// Consumer, so that the client can be told to reveal the media only once it
+// flows. See OnFirstMedia for how it is detected.
+func (consumer *Consumer) AwaitFirstMedia(ctx context.Context) error {
+	consumer.logger.Debug("awaitFirstMedia()")
+
+	consumer.watchFirstMedia()
+
+	select {
+	case <-consumer.firstMediaCh:
+		return nil
+	default:
+	}
+
+	select {
+	case <-consumer.firstMediaCh:
+		return nil
+	case <-consumer.closeCh:
+		return NewInvalidStateError("Consumer closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnFirstMedia adds a listener of "firstmedia", emitted once the worker has
+// sent the first RTP packet of the Consumer. The worker not notifying it, the
+// "rtp" trace event of the Consumer is enabled by the first OnFirstMedia or
+// AwaitFirstMedia call, and disabled once the first packet is traced. The
+// listeners added with On("firstmedia") don't enable it.
+func (consumer *Consumer) OnFirstMedia(listener func()) (off func()) {
+	off = EventConsumerFirstMedia.On(consumer, listener)
+
+	consumer.watchFirstMedia()
+
+	return
+}
+
+// watchFirstMedia enables the "rtp" trace event of the Consumer, once.
+func (consumer *Consumer) watchFirstMedia() {
+	consumer.firstMediaWatch.Do(func() {
+		if consumer.Closed() {
+			return
+		}
+
+		response := consumer.channel.Request("consumer.enableTraceEvent", consumer.internal, H{"types": []string{"rtp"}})
+
+		if err := response.Err(); err != nil {
+			consumer.logger.Warnf("enableTraceEvent() failed, first media not detected: %s", err)
+		}
+	})
+}
+
+// handleTrace handles the "trace" notification of the worker, only enabled to
+// detect the first media.
+func (consumer *Consumer) handleTrace(data json.RawMessage) {
+	var trace struct {
+		Type string `json:"type"`
+	}
+
+	if json.Unmarshal(data, &trace); trace.Type != "rtp" {
+		return
+	}
+
+	consumer.firstMediaOnce.Do(func() {
+		close(consumer.firstMediaCh)
+
+		if !consumer.Closed() {
+			consumer.channel.Request("consumer.enableTraceEvent", consumer.internal, H{"types": []string{}})
+		}
+
+		consumer.SafeEmit("firstmedia")
+
+		// Emit observer event.
+		consumer.observer.SafeEmit("firstmedia")
+	})
+}
+
 func (consumer *Consumer) handleWorkerNotifications() {
-	consumer.channel.On(consumer.internal.ConsumerId, func(event string, data json.RawMessage) {
+	consumer.subscription = consumer.channel.subscribe(consumer.internal.ConsumerId, consumer.channel.profiledListener(consumer.internal.RouterId, func(event string, data json.RawMessage) {
 		switch event {
 		case "producerclose":
-			if consumer.closed {
+			if consumer.closed.isSet() || consumer.handleProducerClose() {
 				break
 			}
+			if !consumer.closed.set() {
+				break
+			}
+			defer consumer.closed.cancelContext()
+			close(consumer.closeCh)
 
-			consumer.closed = true
-
-			consumer.channel.RemoveAllListeners(consumer.internal.ConsumerId)
+			consumer.channel.unsubscribe(consumer.subscription)
 
 			consumer.Emit("@producerclose")
 			consumer.SafeEmit("producerclose")
@@ -318,6 +469,9 @@ func (consumer *Consumer) handleWorkerNotifications() {
 				consumer.observer.SafeEmit("resume")
 			}
 
+		case "trace":
+			consumer.handleTrace(data)
+
 		case "score":
 			var score ConsumerScore
 
@@ -325,25 +479,38 @@ func (consumer *Consumer) handleWorkerNotifications() {
 
 			consumer.score = &score
 
-			consumer.SafeEmit("score", score)
+			consumer.scoreThrottle.emit(func() {
+				if consumer.Closed() {
+					return
+				}
 
-			// Emit observer event.
-			consumer.observer.SafeEmit("score", score)
+				consumer.SafeEmit("score", score)
+
+				// Emit observer event.
+				consumer.observer.SafeEmit("score", score)
+			})
 
 		case "layerschange":
 			var layer VideoLayer
 
 			json.Unmarshal([]byte(data), &layer)
 
-			consumer.currentLayers = &layer
+			// No layer is forwarded on null.
+			if string(data) == "null" {
+				consumer.currentLayers = nil
+			} else {
+				consumer.currentLayers = &layer
+			}
 
 			consumer.SafeEmit("layerschange", layer)
 
 			// Emit observer event.
 			consumer.observer.SafeEmit("layerschange", layer)
 
+			consumer.SafeEmit("layersstatuschange", consumer.LayersStatus())
+
 		default:
 			consumer.logger.Errorf(`ignoring unknown event "%s"`, event)
 		}
-	})
+	}))
 }
@@ -0,0 +1,169 @@
+package mediasoup
+
+// ConsumerBitrateCap is the cap of the bitrate forwarded by a simulcast or SVC
+// Consumer. The worker having no per-Consumer bitrate limit, the cap is
+// enforced through the preferred layers: the layers of highest estimated
+// bitrate fitting in it, the highest spatial layer on equal bitrates.
+type ConsumerBitrateCap struct {
+	// Requested cap, in bps.
+	MaxBitrate uint32 `json:"maxBitrate"`
+	// Highest layers forwarded.
+	SpatialLayer  uint8 `json:"spatialLayer"`
+	TemporalLayer uint8 `json:"temporalLayer"`
+	// Estimated bitrate of the highest layers forwarded, above MaxBitrate if
+	// even the lowest layers exceed it.
+	EffectiveBitrate uint32 `json:"effectiveBitrate"`
+}
+
+// clampLayers lowers the given layers to the enforced ones.
+func (c ConsumerBitrateCap) clampLayers(spatialLayer, temporalLayer uint8) (uint8, uint8) {
+	if spatialLayer > c.SpatialLayer ||
+		(spatialLayer == c.SpatialLayer && temporalLayer > c.TemporalLayer) {
+		return c.SpatialLayer, c.TemporalLayer
+	}
+
+	return spatialLayer, temporalLayer
+}
+
+// consumerRequestedLayers are the layers given to SetPreferredLayers.
+type consumerRequestedLayers struct {
+	spatialLayer  uint8
+	temporalLayer uint8
+}
+
+// requestedLayersAndCap returns the layers given to SetPreferredLayers, nil if
+// none, and the bitrate cap given to SetMaxBitrate, zero if none.
+func (consumer *Consumer) requestedLayersAndCap() (layers *ConsumerPreferredLayers, maxBitrate uint32) {
+	consumer.layersLocker.Lock()
+	defer consumer.layersLocker.Unlock()
+
+	if requested := consumer.requestedLayers; requested != nil {
+		layers = &ConsumerPreferredLayers{
+			SpatialLayer:  requested.spatialLayer,
+			TemporalLayer: requested.temporalLayer,
+		}
+	}
+	if consumer.bitrateCap != nil {
+		maxBitrate = consumer.bitrateCap.MaxBitrate
+	}
+
+	return
+}
+
+// SetMaxBitrate caps the bitrate forwarded by the Consumer (e.g. for tiered
+// subscriptions), removing the cap if zero. The bitrates of the layers are
+// estimated from the maxBitrate of the Producer encodings, each temporal layer
+// doubling the bitrate of the lower one, each SVC spatial layer quadrupling
+// it. The layers given to SetPreferredLayers are lowered to the cap, and
+// restored when it is removed.
+func (consumer *Consumer) SetMaxBitrate(maxBitrate uint32) (bitrateCap *ConsumerBitrateCap, err error) {
+	consumer.logger.Debug("setMaxBitrate()")
+
+	bitrates, err := consumer.layerBitrates()
+	if err != nil {
+		return
+	}
+
+	consumer.layersLocker.Lock()
+	defer consumer.layersLocker.Unlock()
+
+	// The highest layers unless others were requested.
+	spatialLayer, temporalLayer := uint8(len(bitrates)-1), uint8(len(bitrates[0])-1)
+
+	if requested := consumer.requestedLayers; requested != nil {
+		spatialLayer, temporalLayer = requested.spatialLayer, requested.temporalLayer
+	}
+
+	if maxBitrate == 0 {
+		if err = consumer.setPreferredLayers(spatialLayer, temporalLayer); err != nil {
+			return
+		}
+		consumer.bitrateCap = nil
+
+		return
+	}
+
+	// The lowest layers are forwarded whatever the cap.
+	bitrateCap = &ConsumerBitrateCap{
+		MaxBitrate:       maxBitrate,
+		EffectiveBitrate: bitrates[0][0],
+	}
+
+	for spatialLayer := range bitrates {
+		for temporalLayer, bitrate := range bitrates[spatialLayer] {
+			if bitrate <= maxBitrate && bitrate >= bitrateCap.EffectiveBitrate {
+				bitrateCap.SpatialLayer = uint8(spatialLayer)
+				bitrateCap.TemporalLayer = uint8(temporalLayer)
+				bitrateCap.EffectiveBitrate = bitrate
+			}
+		}
+	}
+
+	spatialLayer, temporalLayer = bitrateCap.clampLayers(spatialLayer, temporalLayer)
+
+	if err = consumer.setPreferredLayers(spatialLayer, temporalLayer); err != nil {
+		return nil, err
+	}
+
+	consumer.bitrateCap = bitrateCap
+
+	return
+}
+
+// BitrateCap returns the bitrate cap of the Consumer, nil if not capped.
+func (consumer *Consumer) BitrateCap() *ConsumerBitrateCap {
+	consumer.layersLocker.Lock()
+	defer consumer.layersLocker.Unlock()
+
+	return consumer.bitrateCap
+}
+
+// layerBitrates returns the estimated bitrates of the layers of the Consumer,
+// by spatial then temporal layer.
+func (consumer *Consumer) layerBitrates() ([][]uint32, error) {
+	if consumer.Type() != "simulcast" && consumer.Type() != "svc" {
+		return nil, NewUnsupportedError("bitrate cap of a %s Consumer", consumer.Type())
+	}
+
+	var producer *Producer
+
+	if consumer.getProducerById != nil {
+		producer = consumer.getProducerById(consumer.ProducerId())
+	}
+	if producer == nil {
+		return nil, NewInvalidStateError("Producer closed")
+	}
+
+	encodings := producer.RtpParameters().Encodings
+	if len(encodings) == 0 {
+		return nil, NewUnsupportedError("Producer without encodings")
+	}
+
+	var spatialBitrates []uint32
+
+	spatialLayers, temporalLayers, _ := parseScalabilityMode(encodings[0].ScalabilityMode)
+
+	if consumer.Type() == "simulcast" {
+		// A spatial layer by encoding, from the lowest one.
+		for _, encoding := range encodings {
+			spatialBitrates = append(spatialBitrates, encoding.MaxBitrate)
+		}
+	} else {
+		for i := 0; i < spatialLayers; i++ {
+			spatialBitrates = append(spatialBitrates, encodings[0].MaxBitrate>>(2*uint(spatialLayers-1-i)))
+		}
+	}
+
+	bitrates := make([][]uint32, len(spatialBitrates))
+
+	for i, spatialBitrate := range spatialBitrates {
+		if spatialBitrate == 0 {
+			return nil, NewUnsupportedError("Producer encodings without maxBitrate")
+		}
+		for j := 0; j < temporalLayers; j++ {
+			bitrates[i] = append(bitrates[i], spatialBitrate>>uint(temporalLayers-1-j))
+		}
+	}
+
+	return bitrates, nil
+}
@@ -0,0 +1,132 @@
+package mediasoup
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerSetMaxBitrate(t *testing.T) {
+	fake := newFakeWorker(t)
+	worker := fake.worker(1)
+
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+
+	transport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind: "video",
+		RtpParameters: RtpParameters{
+			Mid: "VIDEO",
+			Codecs: []RtpCodecCapability{
+				{MimeType: "video/VP8", PayloadType: 112, ClockRate: 90000},
+			},
+			Encodings: []RtpEncoding{
+				{Ssrc: 22222222, MaxBitrate: 100000, ScalabilityMode: "L1T3"},
+				{Ssrc: 22222223, MaxBitrate: 400000, ScalabilityMode: "L1T3"},
+				{Ssrc: 22222224, MaxBitrate: 1600000, ScalabilityMode: "L1T3"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	newConsumer := func(producer *Producer, typ string) *Consumer {
+		consumer := NewConsumer(
+			internalData{ConsumerId: "consumer", ProducerId: producer.Id()},
+			consumerData{Kind: producer.Kind(), Type: typ},
+			worker.channel, nil, false, false, nil,
+		)
+		consumer.getProducerById = router.getProducer
+
+		return consumer
+	}
+
+	consumer := newConsumer(producer, "simulcast")
+	assert.Nil(t, consumer.BitrateCap())
+
+	fake.takeMethods()
+
+	// Temporal layer 0 of spatial layer 2 at 400 kbps preferred to temporal
+	// layer 2 of spatial layer 1 at 400 kbps, temporal layer 1 of spatial
+	// layer 2 at 800 kbps exceeding the cap.
+	bitrateCap, err := consumer.SetMaxBitrate(500000)
+	require.NoError(t, err)
+	assert.Equal(t, &ConsumerBitrateCap{
+		MaxBitrate:       500000,
+		SpatialLayer:     2,
+		TemporalLayer:    0,
+		EffectiveBitrate: 400000,
+	}, bitrateCap)
+	assert.Equal(t, bitrateCap, consumer.BitrateCap())
+	assert.Equal(t, &VideoLayer{SpatialLayer: 2}, consumer.PreferredLayers())
+	assert.Equal(t, []string{"consumer.setPreferredLayers"}, fake.takeMethods())
+
+	bitrateCap, err = consumer.SetMaxBitrate(900000)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, bitrateCap.SpatialLayer)
+	assert.EqualValues(t, 1, bitrateCap.TemporalLayer)
+	assert.EqualValues(t, 800000, bitrateCap.EffectiveBitrate)
+
+	// The preferred layers don't exceed the cap.
+	require.NoError(t, consumer.SetPreferredLayers(2, 2))
+	assert.Equal(t, &VideoLayer{SpatialLayer: 2}, consumer.PreferredLayers())
+	require.NoError(t, consumer.SetPreferredLayers(0, 2))
+	assert.Equal(t, &VideoLayer{SpatialLayer: 0}, consumer.PreferredLayers())
+
+	// The lowest layers exceed the cap.
+	bitrateCap, err = consumer.SetMaxBitrate(10000)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, bitrateCap.SpatialLayer)
+	assert.EqualValues(t, 0, bitrateCap.TemporalLayer)
+	assert.EqualValues(t, 25000, bitrateCap.EffectiveBitrate)
+
+	require.NoError(t, consumer.SetPreferredLayers(1, 2))
+	assert.Equal(t, &VideoLayer{SpatialLayer: 0}, consumer.PreferredLayers())
+
+	// The requested layers are restored without cap.
+	bitrateCap, err = consumer.SetMaxBitrate(0)
+	require.NoError(t, err)
+	assert.Nil(t, bitrateCap)
+	assert.Nil(t, consumer.BitrateCap())
+	assert.Equal(t, &VideoLayer{SpatialLayer: 1}, consumer.PreferredLayers())
+
+	// The highest layers if none were requested.
+	consumer = newConsumer(producer, "simulcast")
+
+	_, err = consumer.SetMaxBitrate(500000)
+	require.NoError(t, err)
+	_, err = consumer.SetMaxBitrate(0)
+	require.NoError(t, err)
+	assert.Equal(t, &VideoLayer{SpatialLayer: 2}, consumer.PreferredLayers())
+
+	// Concurrent changes of the cap and of the preferred layers.
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			consumer.SetMaxBitrate(uint32(i * 100000))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			consumer.SetPreferredLayers(uint8(i%3), 2)
+			consumer.BitrateCap()
+		}(i)
+	}
+	wg.Wait()
+
+	audioProducer, err := transport.Produce(audioProducerParameters)
+	require.NoError(t, err)
+
+	audioConsumer := newConsumer(audioProducer, "simple")
+
+	_, err = audioConsumer.SetMaxBitrate(500000)
+	assert.IsType(t, NewUnsupportedError(""), err)
+}
@@ -0,0 +1,87 @@
+package mediasoup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerFirstMedia(t *testing.T) {
+	fake := newFakeWorker(t)
+
+	receive := func() string {
+		request := fake.nextRequest(t)
+		return fmt.Sprintf("%s %s", request.Method, request.Data)
+	}
+
+	consumer := NewConsumer(
+		internalData{ConsumerId: "consumer", ProducerId: "producer"},
+		consumerData{Kind: "audio", Type: "simple"},
+		fake.channel, nil, false, false, nil,
+	)
+
+	// Not traced for the plain listeners.
+	emitted := make(chan struct{}, 2)
+	consumer.On("firstmedia", func() { emitted <- struct{}{} })
+	assert.Empty(t, fake.takeMethods())
+
+	consumer.OnFirstMedia(func() { emitted <- struct{}{} })
+	assert.Equal(t, `consumer.enableTraceEvent {"types":["rtp"]}`, receive())
+
+	// The trace event is enabled once for every waiter.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, consumer.AwaitFirstMedia(context.Background()))
+		}()
+	}
+
+	fake.notify("consumer", "trace", json.RawMessage(
+		`{"type":"keyframe","timestamp":1,"direction":"out"}`))
+	fake.notify("consumer", "trace", json.RawMessage(
+		`{"type":"rtp","timestamp":2,"direction":"out","info":{}}`))
+	fake.notify("consumer", "trace", json.RawMessage(
+		`{"type":"rtp","timestamp":3,"direction":"out","info":{}}`))
+
+	assert.Equal(t, `consumer.enableTraceEvent {"types":[]}`, receive())
+	wg.Wait()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-emitted:
+		case <-time.After(time.Second):
+			require.FailNow(t, "firstmedia not emitted")
+		}
+	}
+
+	fake.takeMethods()
+	assert.NoError(t, consumer.AwaitFirstMedia(context.Background()))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, fake.takeMethods())
+	assert.Empty(t, emitted)
+}
+
+func TestConsumerFirstMedia_Closed(t *testing.T) {
+	fake := newFakeWorker(t)
+
+	consumer := NewConsumer(
+		internalData{ConsumerId: "consumer", ProducerId: "producer"},
+		consumerData{Kind: "audio", Type: "simple"},
+		fake.channel, nil, false, false, nil,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, consumer.AwaitFirstMedia(ctx))
+
+	consumer.Close()
+	assert.IsType(t, NewInvalidStateError(""), consumer.AwaitFirstMedia(context.Background()))
+}
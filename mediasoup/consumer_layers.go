@@ -0,0 +1,143 @@
+package mediasoup
+
+// ConsumerLayerReason tells why a spatial layer is not forwarded by a
+// Consumer.
+type ConsumerLayerReason string
+
+const (
+	// The Consumer is paused.
+	ConsumerLayerReasonPaused ConsumerLayerReason = "paused"
+	// The Producer is paused or closed.
+	ConsumerLayerReasonProducerPaused ConsumerLayerReason = "producerPaused"
+	// The Producer doesn't send media for the layer (score 0, the stream
+	// being e.g. disabled by the sender for lack of uplink bandwidth or CPU).
+	ConsumerLayerReasonNoMedia ConsumerLayerReason = "noMedia"
+	// The layer is above the preferred layers of the Consumer.
+	ConsumerLayerReasonPreferredLayers ConsumerLayerReason = "preferredLayers"
+	// The layer is received and allowed, but the worker selected a lower one
+	// for lack of downlink bandwidth (or of the consumer score).
+	ConsumerLayerReasonBandwidth ConsumerLayerReason = "bandwidth"
+)
+
+// ConsumerLayerStatus is the status of a spatial layer of a Consumer.
+type ConsumerLayerStatus struct {
+	SpatialLayer uint8 `json:"spatialLayer"`
+	// Whether the layer is the one forwarded.
+	Active bool `json:"active"`
+	// Whether the Producer sends media for the layer.
+	Available bool `json:"available"`
+	// Why a layer above the forwarded one is not forwarded, empty for the
+	// forwarded layer and the lower ones.
+	Reason ConsumerLayerReason `json:"reason,omitempty"`
+}
+
+// ConsumerLayersStatus is the status of the spatial layers of a simulcast or
+// SVC Consumer, so that UIs can tell e.g. "HD unavailable due to bandwidth".
+type ConsumerLayersStatus struct {
+	CurrentLayers   *VideoLayer           `json:"currentLayers,omitempty"`
+	PreferredLayers *VideoLayer           `json:"preferredLayers,omitempty"`
+	Layers          []ConsumerLayerStatus `json:"layers,omitempty"`
+}
+
+// Preferred video layers, nil if not set.
+func (consumer *Consumer) PreferredLayers() *VideoLayer {
+	consumer.layersLocker.Lock()
+	defer consumer.layersLocker.Unlock()
+
+	return consumer.preferredLayers
+}
+
+// LayersStatus returns the status of the spatial layers, without layer for a
+// "simple" Consumer. It is emitted with "layersstatuschange" on
+// "layerschange".
+func (consumer *Consumer) LayersStatus() ConsumerLayersStatus {
+	status := ConsumerLayersStatus{
+		CurrentLayers:   consumer.currentLayers,
+		PreferredLayers: consumer.PreferredLayers(),
+	}
+
+	if consumer.Type() != "simulcast" && consumer.Type() != "svc" {
+		return status
+	}
+
+	encodings := consumer.RtpParameters().Encodings
+	if len(encodings) == 0 {
+		return status
+	}
+
+	spatialLayers, _, _ := parseScalabilityMode(encodings[0].ScalabilityMode)
+	available := consumer.availableLayers(spatialLayers)
+
+	for i := 0; i < spatialLayers; i++ {
+		layer := ConsumerLayerStatus{
+			SpatialLayer: uint8(i),
+			Available:    available[i],
+		}
+
+		switch {
+		case status.CurrentLayers != nil && int(status.CurrentLayers.SpatialLayer) == i:
+			layer.Active = true
+		case status.CurrentLayers != nil && int(status.CurrentLayers.SpatialLayer) > i:
+		case consumer.paused:
+			layer.Reason = ConsumerLayerReasonPaused
+		case consumer.producerPaused || consumer.ProducerClosed():
+			layer.Reason = ConsumerLayerReasonProducerPaused
+		case !layer.Available:
+			layer.Reason = ConsumerLayerReasonNoMedia
+		case status.PreferredLayers != nil && int(status.PreferredLayers.SpatialLayer) < i:
+			layer.Reason = ConsumerLayerReasonPreferredLayers
+		default:
+			layer.Reason = ConsumerLayerReasonBandwidth
+		}
+
+		status.Layers = append(status.Layers, layer)
+	}
+
+	return status
+}
+
+// availableLayers returns whether the Producer sends media for each spatial
+// layer, according to the scores of its streams: a simulcast layer is an
+// encoding of the Producer, the SVC layers share the stream of the Producer.
+// The layers are deemed available until the Producer has scores.
+func (consumer *Consumer) availableLayers(spatialLayers int) []bool {
+	available := make([]bool, spatialLayers)
+
+	for i := range available {
+		available[i] = true
+	}
+
+	var producer *Producer
+
+	if consumer.getProducerById != nil {
+		producer = consumer.getProducerById(consumer.ProducerId())
+	}
+	if producer == nil || len(producer.Score()) == 0 {
+		return available
+	}
+
+	encodingScore := func(encoding RtpEncoding) uint8 {
+		for _, score := range producer.Score() {
+			if (encoding.Ssrc != 0 && score.Ssrc == encoding.Ssrc) ||
+				(encoding.Rid != "" && score.Rid == encoding.Rid) {
+				return score.Score
+			}
+		}
+		return 0
+	}
+
+	encodings := producer.RtpParameters().Encodings
+
+	for i := range available {
+		switch {
+		case consumer.Type() == "svc" && len(encodings) > 0:
+			available[i] = encodingScore(encodings[0]) > 0
+		case i < len(encodings):
+			available[i] = encodingScore(encodings[i]) > 0
+		default:
+			available[i] = false
+		}
+	}
+
+	return available
+}
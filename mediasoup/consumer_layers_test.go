@@ -0,0 +1,141 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerLayersStatus(t *testing.T) {
+	producer := &Producer{
+		data: producerData{
+			Kind: "video",
+			Type: "simulcast",
+			RtpParameters: RtpParameters{
+				Encodings: []RtpEncoding{{Ssrc: 1}, {Ssrc: 2}, {Rid: "h"}},
+			},
+		},
+	}
+	newConsumer := func(typ, scalabilityMode string) *Consumer {
+		return &Consumer{
+			internal: internalData{ProducerId: "producer"},
+			data: consumerData{
+				Kind: "video",
+				Type: typ,
+				RtpParameters: RtpParameters{
+					Encodings: []RtpEncoding{{Ssrc: 10, ScalabilityMode: scalabilityMode}},
+				},
+			},
+			getProducerById: func(producerId string) *Producer {
+				if producerId == producer.Id() {
+					return producer
+				}
+				return nil
+			},
+		}
+	}
+	reasons := func(status ConsumerLayersStatus) (reasons []ConsumerLayerReason) {
+		for _, layer := range status.Layers {
+			reasons = append(reasons, layer.Reason)
+		}
+		return
+	}
+	producer.internal.ProducerId = "producer"
+
+	simple := newConsumer("simple", "")
+	assert.Empty(t, simple.LayersStatus().Layers)
+
+	// No layer forwarded yet, nor scores.
+	consumer := newConsumer("simulcast", "S3T3")
+	assert.Equal(t, []ConsumerLayerStatus{
+		{SpatialLayer: 0, Available: true, Reason: ConsumerLayerReasonBandwidth},
+		{SpatialLayer: 1, Available: true, Reason: ConsumerLayerReasonBandwidth},
+		{SpatialLayer: 2, Available: true, Reason: ConsumerLayerReasonBandwidth},
+	}, consumer.LayersStatus().Layers)
+
+	consumer.currentLayers = &VideoLayer{SpatialLayer: 1}
+	producer.score = []ProducerScore{{Ssrc: 1, Score: 10}, {Ssrc: 2, Score: 9}, {Rid: "h", Score: 8}}
+	status := consumer.LayersStatus()
+	assert.Equal(t, &VideoLayer{SpatialLayer: 1}, status.CurrentLayers)
+	assert.Equal(t, []ConsumerLayerStatus{
+		{SpatialLayer: 0, Available: true},
+		{SpatialLayer: 1, Active: true, Available: true},
+		{SpatialLayer: 2, Available: true, Reason: ConsumerLayerReasonBandwidth},
+	}, status.Layers)
+
+	consumer.preferredLayers = &VideoLayer{SpatialLayer: 1}
+	assert.Equal(t, []ConsumerLayerReason{"", "", ConsumerLayerReasonPreferredLayers}, reasons(consumer.LayersStatus()))
+
+	// The highest stream is not received anymore.
+	producer.score = []ProducerScore{{Ssrc: 1, Score: 10}, {Ssrc: 2, Score: 9}, {Rid: "h", Score: 0}}
+	status = consumer.LayersStatus()
+	assert.False(t, status.Layers[2].Available)
+	assert.Equal(t, ConsumerLayerReasonNoMedia, status.Layers[2].Reason)
+
+	consumer.currentLayers = nil
+	consumer.producerPaused = true
+	assert.Equal(t, []ConsumerLayerReason{
+		ConsumerLayerReasonProducerPaused,
+		ConsumerLayerReasonProducerPaused,
+		ConsumerLayerReasonProducerPaused,
+	}, reasons(consumer.LayersStatus()))
+
+	consumer.paused = true
+	assert.Equal(t, ConsumerLayerReasonPaused, consumer.LayersStatus().Layers[0].Reason)
+
+	// The SVC layers share the stream of the Producer.
+	producer.data.RtpParameters.Encodings = []RtpEncoding{{Ssrc: 1, ScalabilityMode: "L2T3"}}
+	svc := newConsumer("svc", "L2T3")
+	svc.currentLayers = &VideoLayer{SpatialLayer: 0}
+	status = svc.LayersStatus()
+	assert.Len(t, status.Layers, 2)
+	assert.True(t, status.Layers[1].Available)
+	assert.Equal(t, ConsumerLayerReasonBandwidth, status.Layers[1].Reason)
+}
+
+func TestConsumerLayersStatusChange(t *testing.T) {
+	fake := newFakeWorker(t)
+	channel := fake.channel
+
+	consumer := NewConsumer(
+		internalData{ConsumerId: "consumer", ProducerId: "producer"},
+		consumerData{
+			Kind: "video",
+			Type: "simulcast",
+			RtpParameters: RtpParameters{
+				Encodings: []RtpEncoding{{Ssrc: 10, ScalabilityMode: "S2T3"}},
+			},
+		},
+		channel, H{}, false, false, nil,
+	)
+
+	statuses := make(chan ConsumerLayersStatus, 2)
+	consumer.OnLayersStatusChange(func(status ConsumerLayersStatus) {
+		statuses <- status
+	})
+	receive := func() ConsumerLayersStatus {
+		select {
+		case status := <-statuses:
+			return status
+		case <-time.After(time.Second):
+			require.FailNow(t, "layersstatuschange not emitted")
+		}
+		return ConsumerLayersStatus{}
+	}
+
+	fake.notify("consumer", "layerschange", json.RawMessage(`{"spatialLayer":1}`))
+
+	status := receive()
+	assert.Equal(t, &VideoLayer{SpatialLayer: 1}, status.CurrentLayers)
+	assert.True(t, status.Layers[1].Active)
+
+	fake.notify("consumer", "layerschange", json.RawMessage(`null`))
+
+	status = receive()
+	assert.Nil(t, status.CurrentLayers)
+	assert.Nil(t, consumer.CurrentLayers())
+	assert.Equal(t, ConsumerLayerReasonBandwidth, status.Layers[1].Reason)
+}
@@ -0,0 +1,130 @@
+package mediasoup
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrWorkerFull is returned (wrapped) when creating a Consumer on a Worker
+// having reached its hard limit of Consumers, see WithConsumerLimits.
+var ErrWorkerFull = errors.New("worker full")
+
+// consumerLimiter counts the Consumers of a Worker against its limits, zero
+// meaning unlimited. A nil limiter counts nothing.
+type consumerLimiter struct {
+	soft  int64
+	hard  int64
+	count int64
+	// Called when the soft limit is reached.
+	onSoftLimit func(count int)
+	// Called when a Consumer is refused above the hard limit.
+	onHardLimit func(count int)
+}
+
+func newConsumerLimiter(soft, hard int, onSoftLimit, onHardLimit func(count int)) *consumerLimiter {
+	return &consumerLimiter{
+		soft:        int64(soft),
+		hard:        int64(hard),
+		onSoftLimit: onSoftLimit,
+		onHardLimit: onHardLimit,
+	}
+}
+
+// acquire counts a Consumer, returning the function to call on its close.
+func (l *consumerLimiter) acquire() (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	count := atomic.AddInt64(&l.count, 1)
+
+	if l.hard > 0 && count > l.hard {
+		atomic.AddInt64(&l.count, -1)
+
+		if l.onHardLimit != nil {
+			l.onHardLimit(int(l.hard))
+		}
+
+		return nil, fmt.Errorf("%w: %d consumers", ErrWorkerFull, l.hard)
+	}
+
+	if l.soft > 0 && count == l.soft && l.onSoftLimit != nil {
+		l.onSoftLimit(int(count))
+	}
+
+	var once sync.Once
+
+	release = func() {
+		once.Do(func() {
+			atomic.AddInt64(&l.count, -1)
+		})
+	}
+
+	return release, nil
+}
+
+func (l *consumerLimiter) load() int {
+	if l == nil {
+		return 0
+	}
+
+	return int(atomic.LoadInt64(&l.count))
+}
+
+// Number of Consumers of the Worker, including the pipe ones.
+func (w *Worker) ConsumerCount() int {
+	return w.consumerLimiter.load()
+}
+
+// Soft and hard limits of Consumers of the Worker, zero if unlimited.
+func (w *Worker) ConsumerLimits() (soft, hard int) {
+	return int(w.consumerLimiter.soft), int(w.consumerLimiter.hard)
+}
+
+// Whether the Worker reached its soft limit of Consumers, new Consumers being
+// better created on other Workers.
+func (w *Worker) ConsumersAboveSoftLimit() bool {
+	soft, _ := w.ConsumerLimits()
+
+	return soft > 0 && w.ConsumerCount() >= soft
+}
+
+// Whether the Worker reached its hard limit of Consumers, the creation of new
+// Consumers failing with ErrWorkerFull.
+func (w *Worker) ConsumersFull() bool {
+	_, hard := w.ConsumerLimits()
+
+	return hard > 0 && w.ConsumerCount() >= hard
+}
+
+// SelectWorker returns the Worker of the given pool to create new Consumers
+// on: the one with the fewest Consumers among the Workers below their soft
+// limit, else among the Workers below their hard limit. ErrWorkerFull is
+// returned if every Worker is full (or closed).
+func SelectWorker(workers []*Worker) (*Worker, error) {
+	var selected *Worker
+	var selectedAboveSoftLimit bool
+
+	for _, worker := range workers {
+		if worker.Closed() || worker.ConsumersFull() {
+			continue
+		}
+
+		aboveSoftLimit := worker.ConsumersAboveSoftLimit()
+
+		if selected == nil ||
+			(selectedAboveSoftLimit && !aboveSoftLimit) ||
+			(selectedAboveSoftLimit == aboveSoftLimit && worker.ConsumerCount() < selected.ConsumerCount()) {
+			selected = worker
+			selectedAboveSoftLimit = aboveSoftLimit
+		}
+	}
+
+	if selected == nil {
+		return nil, ErrWorkerFull
+	}
+
+	return selected, nil
+}
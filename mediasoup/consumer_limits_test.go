@@ -0,0 +1,145 @@
+package mediasoup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerLimiter(t *testing.T) {
+	var softLimits, hardLimits []int
+	limiter := newConsumerLimiter(2, 3, func(count int) {
+		softLimits = append(softLimits, count)
+	}, func(count int) {
+		hardLimits = append(hardLimits, count)
+	})
+
+	release1, err := limiter.acquire()
+	require.NoError(t, err)
+	assert.Empty(t, softLimits)
+
+	_, err = limiter.acquire()
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, softLimits)
+
+	_, err = limiter.acquire()
+	require.NoError(t, err)
+	assert.Empty(t, hardLimits)
+
+	_, err = limiter.acquire()
+	assert.True(t, errors.Is(err, ErrWorkerFull))
+	assert.Equal(t, 3, limiter.load())
+	assert.Equal(t, []int{3}, hardLimits)
+
+	release1()
+	release1()
+	assert.Equal(t, 2, limiter.load())
+
+	_, err = limiter.acquire()
+	assert.NoError(t, err)
+
+	// Unlimited.
+	var unlimited *consumerLimiter
+	release, err := unlimited.acquire()
+	require.NoError(t, err)
+	release()
+	assert.Equal(t, 0, unlimited.load())
+}
+
+func TestSelectWorker(t *testing.T) {
+	newWorker := func(soft, hard, count int) *Worker {
+		worker := &Worker{consumerLimiter: newConsumerLimiter(soft, hard, nil, nil)}
+		worker.consumerLimiter.count = int64(count)
+		return worker
+	}
+
+	_, err := SelectWorker(nil)
+	assert.Equal(t, ErrWorkerFull, err)
+
+	full := newWorker(5, 10, 10)
+	aboveSoftLimit := newWorker(5, 10, 6)
+	busy := newWorker(5, 10, 6)
+	busy.consumerLimiter.soft = 0
+	idle := newWorker(5, 10, 1)
+
+	selected, err := SelectWorker([]*Worker{full, aboveSoftLimit})
+	require.NoError(t, err)
+	assert.Equal(t, aboveSoftLimit, selected)
+
+	selected, err = SelectWorker([]*Worker{full, aboveSoftLimit, busy})
+	require.NoError(t, err)
+	assert.Equal(t, busy, selected)
+
+	selected, err = SelectWorker([]*Worker{busy, full, idle, aboveSoftLimit})
+	require.NoError(t, err)
+	assert.Equal(t, idle, selected)
+
+	idle.closed.set()
+	_, err = SelectWorker([]*Worker{full, idle})
+	assert.Equal(t, ErrWorkerFull, err)
+}
+
+func TestTransportConsumeLimits(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	routerRtpCapabilities, err := GenerateRouterRtpCapabilities(testRouterMediaCodecs)
+	require.NoError(t, err)
+
+	var producer *Producer
+
+	limiter := newConsumerLimiter(0, 1, nil, nil)
+	transport := newTransport(createTransportParams{
+		Channel: channel,
+		GetRouterRtpCapabilities: func() RtpCapabilities {
+			return routerRtpCapabilities
+		},
+		GetProducerById: func(producerId string) *Producer {
+			return producer
+		},
+		GetProducerBySsrc: func(ssrc uint32) *Producer {
+			return nil
+		},
+		ConsumerLimiter: limiter,
+	})
+
+	result, err := transport.ProduceDryRun(TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	})
+	require.NoError(t, err)
+
+	producer = &Producer{
+		internal: internalData{ProducerId: "producer"},
+		data: producerData{
+			Kind:                    "audio",
+			RtpParameters:           result.RtpParameters,
+			Type:                    "simple",
+			ConsumableRtpParameters: result.ConsumableRtpParameters,
+		},
+	}
+	params := TransportConsumeParams{
+		ProducerId:      "producer",
+		RtpCapabilities: routerRtpCapabilities,
+	}
+
+	consumer, err := transport.Consume(params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, limiter.load())
+
+	_, err = transport.Consume(params)
+	assert.True(t, errors.Is(err, ErrWorkerFull))
+	assert.Equal(t, 1, limiter.load())
+
+	consumer.TransportClosed()
+	assert.Equal(t, 0, limiter.load())
+
+	_, err = transport.Consume(params)
+	assert.NoError(t, err)
+}
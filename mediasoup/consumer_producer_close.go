@@ -0,0 +1,134 @@
+package mediasoup
+
+import "strings"
+
+// ProducerCloseAction tells what to do with a Consumer whose Producer closed.
+type ProducerCloseAction int
+
+const (
+	// Close the Consumer, the default.
+	ProducerCloseActionClose ProducerCloseAction = iota
+	// Keep the Consumer as a placeholder, without media, until it is given
+	// another Producer with ReplaceProducer (or closed), so that the client
+	// doesn't have to recreate its consumer.
+	ProducerCloseActionKeep
+)
+
+// ProducerCloseHook decides the action on a Consumer whose Producer closed.
+// It is called from the channel goroutine and must not block.
+type ProducerCloseHook func(consumer *Consumer) ProducerCloseAction
+
+// Whether the Producer of the Consumer closed, the Consumer being kept as a
+// placeholder (see ProducerCloseActionKeep).
+func (consumer *Consumer) ProducerClosed() bool {
+	consumer.producerLocker.Lock()
+	defer consumer.producerLocker.Unlock()
+
+	return consumer.producerClosed
+}
+
+// handleProducerClose returns whether the Consumer is kept.
+func (consumer *Consumer) handleProducerClose() bool {
+	if consumer.onProducerClose == nil || consumer.onProducerClose(consumer) != ProducerCloseActionKeep {
+		return false
+	}
+
+	consumer.logger.Debug("producer closed, consumer kept")
+
+	consumer.producerLocker.Lock()
+	consumer.producerClosed = true
+	consumer.producerLocker.Unlock()
+
+	consumer.SafeEmit("producerclose")
+
+	return true
+}
+
+/**
+ * Give another Producer to a Consumer kept after the close of its Producer.
+ * The Consumer keeps its RTP parameters, so the new Producer must be of the
+ * same kind and type, and have the codec of the Consumer. The client just
+ * sees the RTP sequence numbers and timestamps jump.
+ *
+ * @param producerId
+ */
+func (consumer *Consumer) ReplaceProducer(producerId string) (err error) {
+	consumer.logger.Debugf("replaceProducer() [producerId:%s]", producerId)
+
+	consumer.producerLocker.Lock()
+	defer consumer.producerLocker.Unlock()
+
+	if consumer.closed.isSet() {
+		return NewInvalidStateError("Consumer closed")
+	}
+	if !consumer.producerClosed {
+		return NewInvalidStateError("Producer not closed")
+	}
+
+	var producer *Producer
+	if consumer.getProducerById != nil {
+		producer = consumer.getProducerById(producerId)
+	}
+	if producer == nil {
+		return NewTypeError(`Producer with id "%s" not found`, producerId)
+	}
+
+	if producer.Kind() != consumer.Kind() || producer.Type() != consumer.Type() {
+		return NewTypeError(`Producer with id "%s" is not a %s %s Producer`, producerId, consumer.Type(), consumer.Kind())
+	}
+	if !hasConsumerCodec(producer.ConsumableRtpParameters(), consumer.RtpParameters()) {
+		return NewTypeError(`Producer with id "%s" doesn't have the codec of the Consumer`, producerId)
+	}
+
+	internal := consumer.internal
+	internal.ProducerId = producerId
+
+	reqData := H{
+		"kind":                   consumer.Kind(),
+		"rtpParameters":          consumer.RtpParameters(),
+		"type":                   consumer.Type(),
+		"paused":                 consumer.paused,
+		"consumableRtpEncodings": producer.ConsumableRtpParameters().Encodings,
+	}
+
+	// The worker deleted the Consumer, it is created again with the same id.
+	resp := consumer.channel.Request("transport.consume", internal, reqData)
+
+	var status struct {
+		ProducerPaused bool
+		Score          *ConsumerScore
+	}
+	if err = resp.Unmarshal(&status); err != nil {
+		return
+	}
+
+	consumer.internal = internal
+	consumer.producerClosed = false
+	consumer.producerPaused = status.ProducerPaused
+	consumer.score = status.Score
+
+	producer.addConsumer(consumer)
+
+	consumer.SafeEmit("producerreplace", producerId)
+
+	return
+}
+
+// hasConsumerCodec returns whether the given consumable RTP parameters have
+// the media codec of the given Consumer RTP parameters (the first one).
+func hasConsumerCodec(consumableParams, rtpParameters RtpParameters) bool {
+	if len(rtpParameters.Codecs) == 0 {
+		return false
+	}
+
+	codec := rtpParameters.Codecs[0]
+
+	for _, consumableCodec := range consumableParams.Codecs {
+		if consumableCodec.PayloadType == codec.PayloadType &&
+			strings.EqualFold(consumableCodec.MimeType, codec.MimeType) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package mediasoup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerProducerClose(t *testing.T) {
+	fake := newFakeWorker(t)
+	fake.reply("*", func(request fakeWorkerRequest) (interface{}, error) {
+		return H{"producerPaused": true}, nil
+	})
+	channel := fake.channel
+
+	nextRequest := func() string {
+		request := fake.nextRequest(t)
+		return request.Method + " " + request.Internal.ProducerId
+	}
+
+	codecs := []RtpCodecCapability{{MimeType: "audio/opus", PayloadType: 100, ClockRate: 48000, Channels: 2}}
+	newProducer := func(id, kind string) *Producer {
+		return &Producer{
+			internal: internalData{ProducerId: id},
+			data: producerData{
+				Kind:                    kind,
+				Type:                    "simple",
+				ConsumableRtpParameters: RtpParameters{Codecs: codecs},
+			},
+		}
+	}
+	producers := map[string]*Producer{
+		"video":    newProducer("video", "video"),
+		"producer": newProducer("producer", "audio"),
+	}
+
+	newConsumer := func(id string, hook ProducerCloseHook) *Consumer {
+		consumer := NewConsumer(
+			internalData{ConsumerId: id, ProducerId: "old"},
+			consumerData{Kind: "audio", Type: "simple", RtpParameters: RtpParameters{Codecs: codecs}},
+			channel, H{}, false, false, nil,
+		)
+		consumer.onProducerClose = hook
+		consumer.getProducerById = func(producerId string) *Producer {
+			return producers[producerId]
+		}
+		return consumer
+	}
+
+	// Default policy.
+	closed := newConsumer("closed", nil)
+	closedCh := make(chan struct{})
+	closed.On("producerclose", func() { close(closedCh) })
+
+	fake.notify("closed", "producerclose", nil)
+
+	select {
+	case <-closedCh:
+	case <-time.After(time.Second):
+		t.Fatal("producerclose not emitted")
+	}
+	assert.True(t, closed.Closed())
+
+	// Kept Consumer.
+	kept := newConsumer("kept", func(consumer *Consumer) ProducerCloseAction {
+		return ProducerCloseActionKeep
+	})
+	keptCh := make(chan struct{})
+	kept.On("producerclose", func() { close(keptCh) })
+
+	assert.Error(t, kept.ReplaceProducer("producer"))
+
+	fake.notify("kept", "producerclose", nil)
+
+	select {
+	case <-keptCh:
+	case <-time.After(time.Second):
+		t.Fatal("producerclose not emitted")
+	}
+	assert.False(t, kept.Closed())
+	assert.True(t, kept.ProducerClosed())
+
+	assert.Error(t, kept.ReplaceProducer("unknown"))
+	assert.Error(t, kept.ReplaceProducer("video"))
+
+	require.NoError(t, kept.ReplaceProducer("producer"))
+	assert.Equal(t, "transport.consume producer", nextRequest())
+	assert.Equal(t, "producer", kept.ProducerId())
+	assert.False(t, kept.ProducerClosed())
+	assert.True(t, kept.ProducerPaused())
+	assert.Equal(t, 1, producers["producer"].ConsumerCount())
+
+	require.NoError(t, kept.Close())
+	assert.Equal(t, "consumer.close producer", nextRequest())
+	assert.Equal(t, 0, producers["producer"].ConsumerCount())
+}
@@ -1,8 +1,10 @@
 package mediasoup
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/jiyeyuran/mediasoup-go/mediasoup/h264profile"
 
@@ -248,8 +250,8 @@ func (suite *ConsumerTestSuite) SetupTest() {
 
 	var (
 		mediaCodecs             []RtpCodecCapability
-		audioProducerParameters transportProduceParams
-		videoProducerParameters transportProduceParams
+		audioProducerParameters TransportProduceParams
+		videoProducerParameters TransportProduceParams
 	)
 
 	err := json.Unmarshal([]byte(mediaCodecsJSON), &mediaCodecs)
@@ -303,7 +305,7 @@ func (suite *ConsumerTestSuite) TestTransportConsume_Succeeds() {
 
 	suite.True(router.CanConsume(suite.audioProducer.Id(), suite.consumerDeviceCapabilities))
 
-	audioConsumer, err := transport2.Consume(transportConsumeParams{
+	audioConsumer, err := transport2.Consume(TransportConsumeParams{
 		ProducerId:      suite.audioProducer.Id(),
 		RtpCapabilities: suite.consumerDeviceCapabilities,
 		AppData:         H{"baz": "LOL"},
@@ -341,23 +343,12 @@ func (suite *ConsumerTestSuite) TestTransportConsume_Succeeds() {
 	suite.JSONEq(`{ "producer": 0, "consumer": 10 }`, string(data))
 	suite.Equal(H{"baz": "LOL"}, audioConsumer.AppData())
 
-	type RouterDump struct {
-		MapProducerIdConsumerIds map[string][]string `json:"mapProducerIdConsumerIds"`
-		MapConsumerIdProducerId  map[string]string   `json:"mapConsumerIdProducerId"`
-	}
-	var routerDump RouterDump
-	router.Dump().Unmarshal(&routerDump)
+	routerDump, _ := router.Dump()
 
 	suite.Equal([]string{audioConsumer.Id()}, routerDump.MapProducerIdConsumerIds[suite.audioProducer.Id()])
 	suite.Equal(suite.audioProducer.Id(), routerDump.MapConsumerIdProducerId[audioConsumer.Id()])
 
-	type TransportDump struct {
-		Id          string
-		ProducerIds []string
-		ConsumerIds []string
-	}
-	var transportDump TransportDump
-	transport2.Dump().Unmarshal(&transportDump)
+	transportDump, _ := transport2.Dump()
 
 	suite.Equal(transport2.Id(), transportDump.Id)
 	suite.Equal([]string{}, transportDump.ProducerIds)
@@ -368,7 +359,7 @@ func (suite *ConsumerTestSuite) TestTransportConsume_Succeeds() {
 
 	suite.True(router.CanConsume(suite.videoProducer.Id(), suite.consumerDeviceCapabilities))
 
-	videoConsumer, err := transport2.Consume(transportConsumeParams{
+	videoConsumer, err := transport2.Consume(TransportConsumeParams{
 		ProducerId:      suite.videoProducer.Id(),
 		RtpCapabilities: suite.consumerDeviceCapabilities,
 		Paused:          true,
@@ -424,7 +415,7 @@ func (suite *ConsumerTestSuite) TestTransportConsume_Succeeds() {
 	suite.Equal(H{"baz": "LOL"}, videoConsumer.AppData())
 
 	routerDump = RouterDump{}
-	router.Dump().Unmarshal(&routerDump)
+	routerDump, _ = router.Dump()
 
 	suite.Equal([]string{audioConsumer.Id()}, routerDump.MapProducerIdConsumerIds[suite.audioProducer.Id()])
 	suite.Equal([]string{videoConsumer.Id()}, routerDump.MapProducerIdConsumerIds[suite.videoProducer.Id()])
@@ -432,13 +423,46 @@ func (suite *ConsumerTestSuite) TestTransportConsume_Succeeds() {
 	suite.Equal(suite.videoProducer.Id(), routerDump.MapConsumerIdProducerId[videoConsumer.Id()])
 
 	transportDump = TransportDump{}
-	transport2.Dump().Unmarshal(&transportDump)
+	transportDump, _ = transport2.Dump()
 
 	suite.Equal(transport2.Id(), transportDump.Id)
 	suite.Equal([]string{}, transportDump.ProducerIds)
 	suite.ElementsMatch([]string{audioConsumer.Id(), videoConsumer.Id()}, transportDump.ConsumerIds)
 }
 
+func (suite *ConsumerTestSuite) TestTransportConsume_EmitsDegradedConsumer() {
+	transport2 := suite.transport2
+
+	rtpCapabilities := suite.consumerDeviceCapabilities
+	rtpCapabilities.Codecs = nil
+
+	for _, codec := range suite.consumerDeviceCapabilities.Codecs {
+		if codec.MimeType != "video/rtx" {
+			rtpCapabilities.Codecs = append(rtpCapabilities.Codecs, codec)
+		}
+	}
+
+	onDegradedConsumer := NewMockFunc(suite.T())
+	onObserverDegradedConsumer := NewMockFunc(suite.T())
+
+	transport2.On("degradedconsumer", onDegradedConsumer.Fn())
+	transport2.Observer().On("degradedconsumer", onObserverDegradedConsumer.Fn())
+
+	videoConsumer, err := transport2.Consume(TransportConsumeParams{
+		ProducerId:      suite.videoProducer.Id(),
+		RtpCapabilities: rtpCapabilities,
+	})
+	suite.NoError(err)
+
+	report := suite.videoProducer.CheckRtpCapabilities(rtpCapabilities)
+
+	suite.True(report.NoRtx)
+	onDegradedConsumer.ExpectCalledTimes(1)
+	onDegradedConsumer.ExpectCalledWith(videoConsumer, report)
+	onObserverDegradedConsumer.ExpectCalledTimes(1)
+	onObserverDegradedConsumer.ExpectCalledWith(videoConsumer, report)
+}
+
 func (suite *ConsumerTestSuite) TestTransportConsume_UnsupportedError() {
 	router, transport2, audioProducer := suite.router, suite.transport2, suite.audioProducer
 	invalidDeviceCapabilitiesJSON := `
@@ -461,7 +485,7 @@ func (suite *ConsumerTestSuite) TestTransportConsume_UnsupportedError() {
 
 	suite.False(router.CanConsume(audioProducer.Id(), invalidDeviceCapabilities))
 
-	_, err := transport2.Consume(transportConsumeParams{
+	_, err := transport2.Consume(TransportConsumeParams{
 		ProducerId:      audioProducer.Id(),
 		RtpCapabilities: invalidDeviceCapabilities,
 	})
@@ -471,7 +495,7 @@ func (suite *ConsumerTestSuite) TestTransportConsume_UnsupportedError() {
 
 	suite.False(router.CanConsume(audioProducer.Id(), invalidDeviceCapabilities))
 
-	_, err = transport2.Consume(transportConsumeParams{
+	_, err = transport2.Consume(TransportConsumeParams{
 		ProducerId:      audioProducer.Id(),
 		RtpCapabilities: invalidDeviceCapabilities,
 	})
@@ -682,14 +706,36 @@ func (suite *ConsumerTestSuite) TestConsumerEmitsScore() {
 
 	channel := audioConsumer.channel
 
-	channel.Emit(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 9}`))
-	channel.Emit(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 9, "consumer": 9}`))
-	channel.Emit(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 8, "consumer": 8}`))
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 9}`))
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 9, "consumer": 9}`))
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 8, "consumer": 8}`))
 
 	onScore.ExpectCalledTimes(3)
 	suite.Equal(&ConsumerScore{Producer: 8, Consumer: 8}, audioConsumer.Score())
 }
 
+func (suite *ConsumerTestSuite) TestConsumerAwaitFirstMedia() {
+	audioConsumer := suite.audioConsumer()
+
+	onFirstMedia := NewMockFunc(suite.T())
+	audioConsumer.On("firstmedia", onFirstMedia.Fn())
+
+	// No media is sent to the Consumer.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	suite.Equal(context.DeadlineExceeded, audioConsumer.AwaitFirstMedia(ctx))
+	onFirstMedia.ExpectCalledTimes(0)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		audioConsumer.Close()
+	}()
+
+	err := audioConsumer.AwaitFirstMedia(context.Background())
+	suite.IsType(NewInvalidStateError(""), err)
+}
+
 func (suite *ConsumerTestSuite) TestConsumerClose() {
 	audioConsumer := suite.audioConsumer()
 	videoConsumer := suite.videoConsumer(true)
@@ -702,21 +748,12 @@ func (suite *ConsumerTestSuite) TestConsumerClose() {
 	onObserverClose.ExpectCalledTimes(1)
 	suite.True(audioConsumer.Closed())
 
-	var routerDump struct {
-		MapProducerIdConsumerIds map[string][]string
-		MapConsumerIdProducerId  map[string]string
-	}
-	suite.router.Dump().Unmarshal(&routerDump)
+	routerDump, _ := suite.router.Dump()
 
 	suite.Empty(routerDump.MapProducerIdConsumerIds[suite.audioProducer.Id()])
 	suite.Equal(suite.videoProducer.Id(), routerDump.MapConsumerIdProducerId[videoConsumer.Id()])
 
-	var transportDump struct {
-		Id          string
-		ProducerIds []string
-		ConsumerIds []string
-	}
-	suite.transport2.Dump().Unmarshal(&transportDump)
+	transportDump, _ := suite.transport2.Dump()
 
 	suite.Equal(suite.transport2.Id(), transportDump.Id)
 	suite.Empty(transportDump.ProducerIds)
@@ -768,18 +805,14 @@ func (suite *ConsumerTestSuite) TestConsumerEmitsTransportClosed() {
 	onObserverClose.ExpectCalledTimes(1)
 	suite.True(videoConsumer.Closed())
 
-	var routerDump struct {
-		MapProducerIdConsumerIds map[string][]string
-		MapConsumerIdProducerId  map[string]string
-	}
-	suite.router.Dump().Unmarshal(&routerDump)
+	routerDump, _ := suite.router.Dump()
 
 	suite.Empty(routerDump.MapProducerIdConsumerIds[suite.audioProducer.Id()])
 	suite.Empty(routerDump.MapConsumerIdProducerId)
 }
 
 func (suite *ConsumerTestSuite) audioConsumer() *Consumer {
-	audioConsumer, _ := suite.transport2.Consume(transportConsumeParams{
+	audioConsumer, _ := suite.transport2.Consume(TransportConsumeParams{
 		ProducerId:      suite.audioProducer.Id(),
 		RtpCapabilities: suite.consumerDeviceCapabilities,
 		AppData:         H{"baz": "LOL"},
@@ -789,7 +822,7 @@ func (suite *ConsumerTestSuite) audioConsumer() *Consumer {
 }
 
 func (suite *ConsumerTestSuite) videoConsumer(paused bool) *Consumer {
-	videoConsumer, _ := suite.transport2.Consume(transportConsumeParams{
+	videoConsumer, _ := suite.transport2.Consume(TransportConsumeParams{
 		ProducerId:      suite.videoProducer.Id(),
 		RtpCapabilities: suite.consumerDeviceCapabilities,
 		Paused:          paused,
@@ -0,0 +1,292 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DiagnosticSnapshot is the state of a Consumer captured when its score
+// stayed low, so that transient quality problems can be investigated later.
+type DiagnosticSnapshot struct {
+	Timestamp   int64          `json:"timestamp"` // unix time in milliseconds
+	RouterId    string         `json:"routerId"`
+	TransportId string         `json:"transportId"`
+	ConsumerId  string         `json:"consumerId"`
+	ProducerId  string         `json:"producerId"`
+	Score       *ConsumerScore `json:"score,omitempty"`
+	// Consumer dump at capture time.
+	Dump json.RawMessage `json:"dump,omitempty"`
+	// Consumer stats sampled on each interval the score was low, oldest
+	// first.
+	Stats []json.RawMessage `json:"stats,omitempty"`
+	// Recent events of the Consumer, oldest first.
+	Events []DiagnosticEvent `json:"events"`
+}
+
+// DiagnosticEvent is an event of a Consumer recorded for diagnostics.
+type DiagnosticEvent struct {
+	Timestamp int64       `json:"timestamp"` // unix time in milliseconds
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// DiagnosticsOptions to capture snapshots.
+type DiagnosticsOptions struct {
+	// Consumer score (from 0 to 10) below which the Consumer is unhealthy.
+	ScoreThreshold uint8
+	// The score is checked every Interval, a snapshot being captured once it
+	// stayed below the threshold for Intervals consecutive checks.
+	Interval  time.Duration
+	Intervals int
+	// Minimum delay between two snapshots of the same Consumer.
+	MinSnapshotInterval time.Duration
+	// Number of recent events kept per Consumer.
+	MaxEvents int
+}
+
+type DiagnosticsOption func(o *DiagnosticsOptions)
+
+func WithDiagnosticsScore(scoreThreshold uint8, interval time.Duration, intervals int) DiagnosticsOption {
+	return func(o *DiagnosticsOptions) {
+		o.ScoreThreshold = scoreThreshold
+		o.Interval = interval
+		o.Intervals = intervals
+	}
+}
+
+func WithDiagnosticsRateLimit(minSnapshotInterval time.Duration) DiagnosticsOption {
+	return func(o *DiagnosticsOptions) {
+		o.MinSnapshotInterval = minSnapshotInterval
+	}
+}
+
+func WithDiagnosticsMaxEvents(maxEvents int) DiagnosticsOption {
+	return func(o *DiagnosticsOptions) {
+		o.MaxEvents = maxEvents
+	}
+}
+
+// DiagnosticsRecorder captures a DiagnosticSnapshot when the score of a
+// watched Consumer stays low, at most once per low score period and per
+// MinSnapshotInterval.
+type DiagnosticsRecorder struct {
+	EventEmitter
+	logger  logrus.FieldLogger
+	options DiagnosticsOptions
+}
+
+/**
+ * NewDiagnosticsRecorder
+ *
+ * @emits {snapshot: DiagnosticSnapshot} snapshot
+ */
+func NewDiagnosticsRecorder(options ...DiagnosticsOption) *DiagnosticsRecorder {
+	logger := TypeLogger("DiagnosticsRecorder")
+
+	logger.Debug("constructor()")
+
+	opts := DiagnosticsOptions{
+		ScoreThreshold:      5,
+		Interval:            time.Second,
+		Intervals:           5,
+		MinSnapshotInterval: time.Minute,
+		MaxEvents:           50,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &DiagnosticsRecorder{
+		EventEmitter: NewEventEmitter(logger),
+		logger:       logger,
+		options:      opts,
+	}
+}
+
+// WatchWorker watches the Consumers of the given Worker.
+func (r *DiagnosticsRecorder) WatchWorker(worker *Worker) {
+	r.visitor().WalkWorker(worker)
+}
+
+// WatchRouter watches the Consumers of the given Router.
+func (r *DiagnosticsRecorder) WatchRouter(router *Router) {
+	r.visitor().WalkRouter(router)
+}
+
+func (r *DiagnosticsRecorder) visitor() EntityVisitor {
+	return EntityVisitor{
+		Consumer: func(router *Router, transport Transport, consumer *Consumer) {
+			r.WatchConsumer(consumer)
+		},
+	}
+}
+
+// consumerDiagnostics is the diagnostics state of a Consumer.
+type consumerDiagnostics struct {
+	mu           sync.Mutex
+	recorder     *DiagnosticsRecorder
+	consumer     *Consumer
+	score        *ConsumerScore
+	events       []DiagnosticEvent
+	stats        []json.RawMessage
+	lowIntervals int
+	timer        *wheelTimer
+	timerSeq     int
+	captured     bool
+	lastSnapshot time.Time
+	closed       bool
+}
+
+// WatchConsumer watches the given Consumer.
+func (r *DiagnosticsRecorder) WatchConsumer(consumer *Consumer) {
+	d := &consumerDiagnostics{
+		recorder: r,
+		consumer: consumer,
+		score:    consumer.Score(),
+	}
+
+	consumer.Observer().On("score", func(score ConsumerScore) {
+		d.record("score", score)
+		d.setScore(&score)
+	})
+	consumer.Observer().On("layerschange", func(layers VideoLayer) {
+		d.record("layerschange", layers)
+	})
+	consumer.Observer().On("pause", func() {
+		d.record("pause", nil)
+	})
+	consumer.Observer().On("resume", func() {
+		d.record("resume", nil)
+	})
+	consumer.Observer().On("close", d.close)
+
+	d.setScore(consumer.Score())
+}
+
+func (d *consumerDiagnostics) record(eventType string, data interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.events = append(d.events, DiagnosticEvent{
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+		Type:      eventType,
+		Data:      data,
+	})
+
+	if maxEvents := d.recorder.options.MaxEvents; len(d.events) > maxEvents {
+		d.events = d.events[len(d.events)-maxEvents:]
+	}
+}
+
+func (d *consumerDiagnostics) setScore(score *ConsumerScore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.score = score
+
+	if d.closed || score == nil {
+		return
+	}
+
+	if score.Consumer >= d.recorder.options.ScoreThreshold {
+		// Recovered, a new low score period may be captured.
+		d.stopTimer()
+		d.lowIntervals = 0
+		d.stats = nil
+		d.captured = false
+		return
+	}
+
+	if d.timer == nil && !d.captured {
+		seq := d.timerSeq
+
+		d.timer = d.consumer.channel.timers.AfterFunc(d.recorder.options.Interval, func() {
+			d.check(seq)
+		})
+	}
+}
+
+// check runs on each interval while the score is low.
+func (d *consumerDiagnostics) check(seq int) {
+	// Requested without holding the lock, the worker may be slow to respond.
+	stats := d.consumer.GetStats()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Stopped in the meantime.
+	if d.closed || seq != d.timerSeq {
+		return
+	}
+
+	options := d.recorder.options
+
+	if stats.Err() == nil {
+		d.stats = append(d.stats, json.RawMessage(stats.Data()))
+
+		if len(d.stats) > options.Intervals {
+			d.stats = d.stats[len(d.stats)-options.Intervals:]
+		}
+	}
+
+	d.lowIntervals++
+
+	if d.lowIntervals < options.Intervals {
+		d.timer.Reset(options.Interval)
+		return
+	}
+
+	d.stopTimer()
+	d.captured = true
+
+	if !d.lastSnapshot.IsZero() && time.Since(d.lastSnapshot) < options.MinSnapshotInterval {
+		d.recorder.logger.Debugf("snapshot rate limited [consumerId:%s]", d.consumer.Id())
+		return
+	}
+
+	d.lastSnapshot = time.Now()
+
+	snapshot := DiagnosticSnapshot{
+		Timestamp:   d.lastSnapshot.UnixNano() / int64(time.Millisecond),
+		RouterId:    d.consumer.internal.RouterId,
+		TransportId: d.consumer.internal.TransportId,
+		ConsumerId:  d.consumer.Id(),
+		ProducerId:  d.consumer.ProducerId(),
+		Score:       d.score,
+		Stats:       d.stats,
+		Events:      make([]DiagnosticEvent, len(d.events)),
+	}
+	copy(snapshot.Events, d.events)
+
+	d.stats = nil
+
+	spawn("diagnostics.snapshot", func() {
+		if dump := d.consumer.Dump(); dump.Err() == nil {
+			snapshot.Dump = json.RawMessage(dump.Data())
+		}
+
+		d.recorder.logger.Warnf("consumer score stayed low, snapshot captured [consumerId:%s]", snapshot.ConsumerId)
+
+		d.recorder.SafeEmit("snapshot", snapshot)
+	})
+}
+
+func (d *consumerDiagnostics) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	d.stopTimer()
+}
+
+func (d *consumerDiagnostics) stopTimer() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+		d.timerSeq++
+	}
+}
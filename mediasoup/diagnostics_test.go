@@ -0,0 +1,61 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"time"
+)
+
+func (suite *ConsumerTestSuite) TestDiagnosticsRecorder_CapturesSnapshot() {
+	recorder := NewDiagnosticsRecorder(
+		WithDiagnosticsScore(5, 20*time.Millisecond, 3),
+		WithDiagnosticsRateLimit(time.Hour),
+	)
+
+	snapshots := make(chan DiagnosticSnapshot, 2)
+	recorder.On("snapshot", func(snapshot DiagnosticSnapshot) {
+		snapshots <- snapshot
+	})
+
+	audioConsumer := suite.audioConsumer()
+	recorder.WatchConsumer(audioConsumer)
+
+	channel := audioConsumer.channel
+
+	// A short low score period is not captured.
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 2}`))
+	time.Sleep(30 * time.Millisecond)
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 10}`))
+
+	select {
+	case <-snapshots:
+		suite.Fail("unexpected snapshot")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 3}`))
+
+	select {
+	case snapshot := <-snapshots:
+		suite.Equal(audioConsumer.Id(), snapshot.ConsumerId)
+		suite.Equal(suite.audioProducer.Id(), snapshot.ProducerId)
+		suite.Equal(suite.transport2.Id(), snapshot.TransportId)
+		suite.Equal(suite.router.Id(), snapshot.RouterId)
+		suite.Equal(&ConsumerScore{Producer: 10, Consumer: 3}, snapshot.Score)
+		suite.Len(snapshot.Stats, 3)
+		suite.NotEmpty(snapshot.Dump)
+		suite.Len(snapshot.Events, 3)
+		suite.Equal("score", snapshot.Events[2].Type)
+	case <-time.After(time.Second):
+		suite.Fail("snapshot not captured")
+	}
+
+	// Rate limited.
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 10}`))
+	channel.dispatch(audioConsumer.Id(), "score", json.RawMessage(`{"producer": 10, "consumer": 1}`))
+
+	select {
+	case <-snapshots:
+		suite.Fail("unexpected snapshot")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
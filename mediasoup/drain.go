@@ -0,0 +1,62 @@
+package mediasoup
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrWorkerDraining is returned when creating a Router, WebRtcServer or
+// Transport on a Worker being drained, see Worker.Drain.
+var ErrWorkerDraining = errors.New("worker draining")
+
+// Interval at which Drain checks whether the Consumers are gone.
+var drainCheckInterval = 100 * time.Millisecond
+
+/**
+ * Drain stops the creation of Routers, WebRtcServers and Transports in the
+ * worker, waits until all its Consumers are closed and then closes it, e.g.
+ * before a rolling deploy so that the ongoing calls aren't dropped. The worker
+ * is closed anyway once the context is done, its error being returned.
+ *
+ * @emits draining
+ */
+func (w *Worker) Drain(ctx context.Context) (err error) {
+	w.logger.Debugln("drain()")
+
+	if w.draining.set() {
+		w.SafeEmit("draining")
+	}
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for err == nil && !w.Closed() && w.ConsumerCount() > 0 {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+
+			w.logger.Warnf("drain() | closing worker with %d consumers: %s", w.ConsumerCount(), err)
+		case <-ticker.C:
+		}
+	}
+
+	w.Close()
+
+	return
+}
+
+// Draining returns whether the worker is being drained, see Drain.
+func (w *Worker) Draining() bool {
+	return w.draining.isSet()
+}
+
+// checkWorkerDraining returns ErrWorkerDraining if the worker of the Router is
+// being drained.
+func (router *Router) checkWorkerDraining() error {
+	if router.workerDraining != nil && router.workerDraining.isSet() {
+		return ErrWorkerDraining
+	}
+
+	return nil
+}
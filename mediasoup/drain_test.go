@@ -0,0 +1,88 @@
+package mediasoup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerDrain(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+	worker.consumerLimiter = newConsumerLimiter(0, 0, nil, nil)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	release, err := worker.consumerLimiter.acquire()
+	require.NoError(t, err)
+
+	draining := make(chan struct{}, 1)
+	worker.On("draining", func() { draining <- struct{}{} })
+
+	done := make(chan error)
+	go func() {
+		done <- worker.Drain(context.Background())
+	}()
+
+	select {
+	case <-draining:
+	case <-time.After(time.Second):
+		assert.FailNow(t, "timeout")
+	}
+	assert.True(t, worker.Draining())
+
+	_, err = worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	assert.Equal(t, ErrWorkerDraining, err)
+
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+	})
+	assert.Equal(t, ErrWorkerDraining, err)
+	assert.False(t, worker.Closed())
+
+	// The last Consumer is closed.
+	release()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.FailNow(t, "timeout")
+	}
+	assert.True(t, worker.Closed())
+}
+
+func TestWorkerDrain_Timeout(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+	worker.consumerLimiter = newConsumerLimiter(0, 0, nil, nil)
+
+	_, err := worker.consumerLimiter.acquire()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, worker.Drain(ctx))
+	assert.True(t, worker.Closed())
+}
+
+func TestWorkerPool_SkipsDrainingWorkers(t *testing.T) {
+	pool := newWorkerPool("", WithWorkerPoolCpuLoad(0, 0))
+
+	worker1, worker2 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2)
+	pool.addWorker(worker1)
+	pool.addWorker(worker2)
+
+	worker1.draining.set()
+
+	worker, err := pool.GetLeastLoadedWorker()
+	require.NoError(t, err)
+	assert.Equal(t, worker2, worker)
+}
@@ -0,0 +1,61 @@
+package mediasoup
+
+// WorkerDump is the content of a Worker as reported by the worker process.
+type WorkerDump struct {
+	Pid             int      `json:"pid"`
+	WebRtcServerIds []string `json:"webRtcServerIds"`
+	RouterIds       []string `json:"routerIds"`
+}
+
+// RouterDump is the content of a Router as reported by the worker process.
+type RouterDump struct {
+	Id                       string              `json:"id"`
+	TransportIds             []string            `json:"transportIds"`
+	RtpObserverIds           []string            `json:"rtpObserverIds"`
+	MapProducerIdConsumerIds map[string][]string `json:"mapProducerIdConsumerIds"`
+	MapConsumerIdProducerId  map[string]string   `json:"mapConsumerIdProducerId"`
+	MapProducerIdObserverIds map[string][]string `json:"mapProducerIdObserverIds"`
+}
+
+// TransportDump is the content of a Transport as reported by the worker
+// process, the fields of the other types of Transport being empty.
+type TransportDump struct {
+	Id          string   `json:"id"`
+	Direct      bool     `json:"direct,omitempty"`
+	ProducerIds []string `json:"producerIds"`
+	ConsumerIds []string `json:"consumerIds"`
+	// Consumer ids by SSRC of their RTP and RTX streams.
+	MapSsrcConsumerId    map[string]string `json:"mapSsrcConsumerId,omitempty"`
+	MapRtxSsrcConsumerId map[string]string `json:"mapRtxSsrcConsumerId,omitempty"`
+	// Ids of the header extensions read from the received RTP packets, in
+	// RecvRtpHeaderExtensions with recent workers.
+	RtpHeaderExtensions     map[string]int        `json:"rtpHeaderExtensions,omitempty"`
+	RecvRtpHeaderExtensions map[string]int        `json:"recvRtpHeaderExtensions,omitempty"`
+	RtpListener             *TransportRtpListener `json:"rtpListener,omitempty"`
+	// Comma separated trace event types enabled.
+	TraceEventTypes string `json:"traceEventTypes,omitempty"`
+
+	// WebRtcTransport.
+	IceRole          string          `json:"iceRole,omitempty"`
+	IceParameters    IceParameters   `json:"iceParameters"`
+	IceCandidates    []IceCandidate  `json:"iceCandidates,omitempty"`
+	IceState         string          `json:"iceState,omitempty"`
+	IceSelectedTuple *TransportTuple `json:"iceSelectedTuple,omitempty"`
+	DtlsParameters   DtlsParameters  `json:"dtlsParameters"`
+	DtlsState        string          `json:"dtlsState,omitempty"`
+
+	// PlainRtpTransport and PipeTransport.
+	Tuple     *TransportTuple `json:"tuple,omitempty"`
+	RtcpTuple *TransportTuple `json:"rtcpTuple,omitempty"`
+	RtcpMux   bool            `json:"rtcpMux,omitempty"`
+	Comedia   bool            `json:"comedia,omitempty"`
+	Rtx       bool            `json:"rtx,omitempty"`
+}
+
+// TransportRtpListener routes the received RTP packets to the Producers, by
+// SSRC, MID or RID.
+type TransportRtpListener struct {
+	SsrcTable map[string]string `json:"ssrcTable"`
+	MidTable  map[string]string `json:"midTable"`
+	RidTable  map[string]string `json:"ridTable"`
+}
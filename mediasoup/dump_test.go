@@ -0,0 +1,36 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportDump_Unmarshal(t *testing.T) {
+	var dump TransportDump
+
+	err := json.Unmarshal([]byte(`{
+		"id": "t1",
+		"producerIds": ["p1"],
+		"consumerIds": [],
+		"mapSsrcConsumerId": { "1111": "c1" },
+		"recvRtpHeaderExtensions": { "mid": 1 },
+		"rtpListener": { "ssrcTable": { "2222": "p1" }, "midTable": {}, "ridTable": {} },
+		"iceRole": "controlled",
+		"iceState": "new",
+		"iceSelectedTuple": { "localIp": "127.0.0.1", "localPort": 10000, "protocol": "udp" },
+		"dtlsState": "new"
+	}`), &dump)
+	require.NoError(t, err)
+
+	assert.Equal(t, "t1", dump.Id)
+	assert.Equal(t, []string{"p1"}, dump.ProducerIds)
+	assert.Equal(t, map[string]string{"1111": "c1"}, dump.MapSsrcConsumerId)
+	assert.Equal(t, map[string]int{"mid": 1}, dump.RecvRtpHeaderExtensions)
+	assert.Equal(t, "p1", dump.RtpListener.SsrcTable["2222"])
+	assert.Equal(t, "controlled", dump.IceRole)
+	assert.EqualValues(t, 10000, dump.IceSelectedTuple.LocalPort)
+	assert.Nil(t, dump.Tuple)
+}
@@ -0,0 +1,50 @@
+package mediasoup
+
+// Features disabled in end-to-end encrypted Routers (see RouterSettings.E2ee).
+const (
+	// Simulcast and SVC video Producers, whose layers are switched by the
+	// worker on key frames detected in the payloads.
+	E2eeFeatureSimulcast = "simulcast"
+	// Server side recordings (e.g. recorder.CompositeRecorder), decoding the
+	// payloads.
+	E2eeFeatureRecording = "recording"
+)
+
+// E2ee returns whether the media of the Router is end-to-end encrypted.
+func (router *Router) E2ee() bool {
+	return router.data.Settings.E2ee
+}
+
+// BlockE2eeFeature fails with an UnsupportedError if the Router is end-to-end
+// encrypted, emitting "e2eefeatureblocked" on its observer. The features built
+// on top of the Router (e.g. recordings) call it before starting.
+func (router *Router) BlockE2eeFeature(feature string) error {
+	if !router.E2ee() {
+		return nil
+	}
+
+	router.logger.Warnf(`feature "%s" blocked in end-to-end encrypted router`, feature)
+
+	router.observer.SafeEmit("e2eefeatureblocked", feature)
+
+	return NewUnsupportedError(`%s is not available in end-to-end encrypted Routers`, feature)
+}
+
+// isLayeredVideo returns whether the RTP parameters of a Producer of the
+// given kind have several spatial or temporal layers.
+func isLayeredVideo(kind string, rtpParameters RtpParameters) bool {
+	if kind != "video" {
+		return false
+	}
+	if len(rtpParameters.Encodings) > 1 {
+		return true
+	}
+
+	for _, encoding := range rtpParameters.Encodings {
+		if spatialLayers, temporalLayers, _ := parseScalabilityMode(encoding.ScalabilityMode); spatialLayers > 1 || temporalLayers > 1 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,60 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLayeredVideo(t *testing.T) {
+	simulcast := RtpParameters{Encodings: []RtpEncoding{{Ssrc: 1}, {Ssrc: 2}}}
+	svc := RtpParameters{Encodings: []RtpEncoding{{Ssrc: 1, ScalabilityMode: "L1T3"}}}
+	single := RtpParameters{Encodings: []RtpEncoding{{Ssrc: 1, ScalabilityMode: "L1T1"}}}
+
+	assert.True(t, isLayeredVideo("video", simulcast))
+	assert.True(t, isLayeredVideo("video", svc))
+	assert.False(t, isLayeredVideo("video", single))
+	assert.False(t, isLayeredVideo("audio", simulcast))
+}
+
+func TestRouterE2ee(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "video", MimeType: "video/VP8", ClockRate: 90000},
+	}, WithRouterE2ee())
+	require.NoError(t, err)
+	assert.True(t, router.E2ee())
+
+	var blocked []string
+	router.Observer().On("e2eefeatureblocked", func(feature string) {
+		blocked = append(blocked, feature)
+	})
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+	})
+	require.NoError(t, err)
+
+	rtpParameters := RtpParameters{
+		Codecs: []RtpCodecCapability{
+			{MimeType: "video/VP8", PayloadType: 96, ClockRate: 90000},
+		},
+		Encodings: []RtpEncoding{{Ssrc: 11111111}, {Ssrc: 22222222}},
+	}
+
+	_, err = transport.Produce(TransportProduceParams{Kind: "video", RtpParameters: rtpParameters})
+	assert.IsType(t, NewUnsupportedError(""), err)
+	assert.Empty(t, transport.getProducers())
+
+	rtpParameters.Encodings = rtpParameters.Encodings[:1]
+
+	_, err = transport.Produce(TransportProduceParams{Kind: "video", RtpParameters: rtpParameters})
+	require.NoError(t, err)
+
+	err = router.BlockE2eeFeature(E2eeFeatureRecording)
+	assert.IsType(t, NewUnsupportedError(""), err)
+
+	assert.Equal(t, []string{E2eeFeatureSimulcast, E2eeFeatureRecording}, blocked)
+}
@@ -0,0 +1,162 @@
+package mediasoup
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EgressConsumerManager shares the Consumers of a Transport among the
+// subsystems tapping the same Producers (recording, HLS, analysis...), so that
+// each Producer is consumed once: the Consumer is created on its first
+// acquisition and closed once released by all of them. Since the Consumer is
+// shared, pausing it or changing its layers affects all its users.
+type EgressConsumerManager struct {
+	logger          logrus.FieldLogger
+	transport       Transport
+	rtpCapabilities RtpCapabilities
+	mu              sync.Mutex
+	// Shared Consumers by Producer id.
+	consumers map[string]*egressConsumer
+}
+
+type egressConsumer struct {
+	consumer *Consumer
+	refs     int
+	// Closed once the Consumer is created, or failed with err.
+	ready chan struct{}
+	err   error
+}
+
+// NewEgressConsumerManager returns a manager creating the Consumers on the
+// given Transport with the given RTP capabilities.
+func NewEgressConsumerManager(transport Transport, rtpCapabilities RtpCapabilities) *EgressConsumerManager {
+	logger := TypeLogger("EgressConsumerManager")
+
+	logger.Debug("constructor()")
+
+	return &EgressConsumerManager{
+		logger:          logger,
+		transport:       transport,
+		rtpCapabilities: rtpCapabilities,
+		consumers:       make(map[string]*egressConsumer),
+	}
+}
+
+// Acquire returns the Consumer of the given Producer, creating it if not
+// acquired yet, and the function releasing it. The Consumer is closed once
+// all its acquisitions are released.
+func (m *EgressConsumerManager) Acquire(producerId string) (consumer *Consumer, release func(), err error) {
+	m.logger.Debugf("acquire() [producerId:%s]", producerId)
+
+	m.mu.Lock()
+
+	entry, ok := m.consumers[producerId]
+	if !ok {
+		entry = &egressConsumer{ready: make(chan struct{})}
+		m.consumers[producerId] = entry
+	}
+	entry.refs++
+
+	m.mu.Unlock()
+
+	if ok {
+		<-entry.ready
+	} else {
+		m.create(producerId, entry)
+	}
+
+	if entry.err != nil {
+		m.release(producerId, entry)
+
+		return nil, nil, entry.err
+	}
+
+	var once sync.Once
+
+	release = func() {
+		once.Do(func() {
+			m.release(producerId, entry)
+		})
+	}
+
+	return entry.consumer, release, nil
+}
+
+// RefCount returns the number of the acquisitions of the Consumer of the given
+// Producer not released yet.
+func (m *EgressConsumerManager) RefCount(producerId string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.consumers[producerId]; ok {
+		return entry.refs
+	}
+
+	return 0
+}
+
+// Close closes all the Consumers, whether released or not.
+func (m *EgressConsumerManager) Close() {
+	m.logger.Debug("close()")
+
+	m.mu.Lock()
+	consumers := m.consumers
+	m.consumers = make(map[string]*egressConsumer)
+	m.mu.Unlock()
+
+	for _, entry := range consumers {
+		<-entry.ready
+
+		if entry.consumer != nil {
+			entry.consumer.Close()
+		}
+	}
+}
+
+func (m *EgressConsumerManager) create(producerId string, entry *egressConsumer) {
+	defer close(entry.ready)
+
+	entry.consumer, entry.err = m.transport.Consume(TransportConsumeParams{
+		ProducerId:      producerId,
+		RtpCapabilities: m.rtpCapabilities,
+		AppData:         H{"egress": true},
+	})
+	if entry.err != nil {
+		return
+	}
+
+	// Closed with its Producer or Transport, a new one being created on the
+	// next acquisition.
+	entry.consumer.Observer().On("close", func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if m.consumers[producerId] == entry {
+			delete(m.consumers, producerId)
+		}
+	})
+}
+
+func (m *EgressConsumerManager) release(producerId string, entry *egressConsumer) {
+	m.mu.Lock()
+
+	entry.refs--
+
+	if entry.refs > 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	if m.consumers[producerId] == entry {
+		delete(m.consumers, producerId)
+	}
+
+	m.mu.Unlock()
+
+	if entry.consumer != nil {
+		m.logger.Debugf("release() | closing unused Consumer [producerId:%s]", producerId)
+
+		entry.consumer.Close()
+	}
+}
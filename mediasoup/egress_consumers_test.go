@@ -0,0 +1,110 @@
+package mediasoup
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEgressTestProducer(t *testing.T) (*Router, Transport, *Producer) {
+	worker := newPoolTestWorker(t, 1)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	transport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	})
+	require.NoError(t, err)
+
+	return router, transport, producer
+}
+
+func TestEgressConsumerManager_Acquire(t *testing.T) {
+	router, transport, producer := newEgressTestProducer(t)
+
+	manager := NewEgressConsumerManager(transport, router.RtpCapabilities())
+
+	consumer1, release1, err := manager.Acquire(producer.Id())
+	require.NoError(t, err)
+	consumer2, release2, err := manager.Acquire(producer.Id())
+	require.NoError(t, err)
+
+	assert.Equal(t, consumer1, consumer2)
+	assert.Equal(t, 2, manager.RefCount(producer.Id()))
+
+	release1()
+	// Released once only.
+	release1()
+	assert.Equal(t, 1, manager.RefCount(producer.Id()))
+	assert.False(t, consumer1.Closed())
+
+	release2()
+	assert.Equal(t, 0, manager.RefCount(producer.Id()))
+	assert.True(t, consumer1.Closed())
+
+	consumer3, release3, err := manager.Acquire(producer.Id())
+	require.NoError(t, err)
+	assert.NotEqual(t, consumer1, consumer3)
+
+	// Closed elsewhere, e.g. with its Transport.
+	consumer3.Close()
+	assert.Equal(t, 0, manager.RefCount(producer.Id()))
+	release3()
+
+	consumer4, release4, err := manager.Acquire(producer.Id())
+	require.NoError(t, err)
+	assert.NotEqual(t, consumer3, consumer4)
+	release4()
+
+	producer.Close()
+
+	_, _, err = manager.Acquire(producer.Id())
+	assert.Error(t, err)
+	assert.Equal(t, 0, manager.RefCount(producer.Id()))
+}
+
+func TestEgressConsumerManager_Concurrent(t *testing.T) {
+	router, transport, producer := newEgressTestProducer(t)
+
+	manager := NewEgressConsumerManager(transport, router.RtpCapabilities())
+
+	consumers := make([]*Consumer, 8)
+	wg := sync.WaitGroup{}
+
+	for i := range consumers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			consumer, _, err := manager.Acquire(producer.Id())
+			assert.NoError(t, err)
+			consumers[i] = consumer
+		}(i)
+	}
+	wg.Wait()
+
+	for _, consumer := range consumers {
+		assert.Equal(t, consumers[0], consumer)
+	}
+	assert.Equal(t, len(consumers), manager.RefCount(producer.Id()))
+
+	manager.Close()
+	assert.True(t, consumers[0].Closed())
+	assert.Equal(t, 0, manager.RefCount(producer.Id()))
+}
@@ -0,0 +1,145 @@
+package mediasoup
+
+import "sync"
+
+// EntityVisitor is called for every entity of the walked Workers, Routers or
+// Transports, the existing ones first, then those created afterwards, so that
+// the watchers of the entities (stats, topology, webhooks...) miss none. The
+// children of the entities are walked whether their callback is set or not.
+// The Router is nil for the entities of a Transport walked alone.
+type EntityVisitor struct {
+	WebRtcServer func(server *WebRtcServer)
+	Router       func(router *Router)
+	Transport    func(router *Router, transport Transport)
+	Producer     func(router *Router, transport Transport, producer *Producer)
+	Consumer     func(router *Router, transport Transport, consumer *Consumer)
+}
+
+// entityWalk visits every entity once, even if created while listing the
+// existing ones.
+type entityWalk struct {
+	visitor EntityVisitor
+	mu      sync.Mutex
+	// Visited entities, until closed.
+	visited map[string]bool
+}
+
+// WalkWorker visits the WebRtcServers and Routers of the given Worker, and
+// their entities.
+func (v EntityVisitor) WalkWorker(worker *Worker) {
+	v.newWalk().walkWorker(worker)
+}
+
+// WalkRouter visits the given Router and its entities.
+func (v EntityVisitor) WalkRouter(router *Router) {
+	v.newWalk().walkRouter(router)
+}
+
+// WalkTransport visits the given Transport, of the given Router if known, and
+// its Producers and Consumers.
+func (v EntityVisitor) WalkTransport(router *Router, transport Transport) {
+	v.newWalk().walkTransport(router, transport)
+}
+
+func (v EntityVisitor) newWalk() *entityWalk {
+	return &entityWalk{
+		visitor: v,
+		visited: make(map[string]bool),
+	}
+}
+
+func (w *entityWalk) walkWorker(worker *Worker) {
+	// Listening before listing not to miss the entities created meanwhile.
+	worker.Observer().On("newwebrtcserver", w.visitWebRtcServer)
+	worker.Observer().On("newrouter", w.walkRouter)
+
+	for _, server := range worker.getWebRtcServers() {
+		w.visitWebRtcServer(server)
+	}
+	for _, router := range worker.getRouters() {
+		w.walkRouter(router)
+	}
+}
+
+func (w *entityWalk) visitWebRtcServer(server *WebRtcServer) {
+	if !w.visit(server.Id(), server.Observer()) {
+		return
+	}
+
+	if w.visitor.WebRtcServer != nil {
+		w.visitor.WebRtcServer(server)
+	}
+}
+
+func (w *entityWalk) walkRouter(router *Router) {
+	if !w.visit(router.Id(), router.Observer()) {
+		return
+	}
+
+	if w.visitor.Router != nil {
+		w.visitor.Router(router)
+	}
+
+	router.Observer().On("newtransport", func(transport Transport) {
+		w.walkTransport(router, transport)
+	})
+
+	for _, transport := range router.getTransports() {
+		w.walkTransport(router, transport)
+	}
+}
+
+func (w *entityWalk) walkTransport(router *Router, transport Transport) {
+	if !w.visit(transport.Id(), transport.Observer()) {
+		return
+	}
+
+	if w.visitor.Transport != nil {
+		w.visitor.Transport(router, transport)
+	}
+
+	visitProducer := func(producer *Producer) {
+		if w.visit(producer.Id(), producer.Observer()) && w.visitor.Producer != nil {
+			w.visitor.Producer(router, transport, producer)
+		}
+	}
+	visitConsumer := func(consumer *Consumer) {
+		if w.visit(consumer.Id(), consumer.Observer()) && w.visitor.Consumer != nil {
+			w.visitor.Consumer(router, transport, consumer)
+		}
+	}
+
+	transport.Observer().On("newproducer", visitProducer)
+	transport.Observer().On("newconsumer", visitConsumer)
+
+	if base := baseTransportOf(transport); base != nil {
+		for _, producer := range base.getProducers() {
+			visitProducer(producer)
+		}
+		for _, consumer := range base.getConsumers() {
+			visitConsumer(consumer)
+		}
+	}
+}
+
+// visit returns whether the entity with the given id is visited for the
+// first time.
+func (w *entityWalk) visit(id string, observer EventEmitter) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.visited[id] {
+		return false
+	}
+
+	w.visited[id] = true
+
+	observer.On("close", func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		delete(w.visited, id)
+	})
+
+	return true
+}
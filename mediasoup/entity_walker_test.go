@@ -0,0 +1,66 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityVisitor_WalkWorker(t *testing.T) {
+	fake := newFakeWorker(t)
+	worker := fake.worker(1)
+
+	var visited []string
+
+	visitor := EntityVisitor{
+		Router: func(router *Router) {
+			visited = append(visited, "router")
+		},
+		Transport: func(router *Router, transport Transport) {
+			visited = append(visited, "transport")
+		},
+		Producer: func(router *Router, transport Transport, producer *Producer) {
+			visited = append(visited, "producer")
+		},
+	}
+
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+
+	transport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	produce := func(ssrc uint32) {
+		_, err := transport.Produce(TransportProduceParams{
+			Kind: "audio",
+			RtpParameters: RtpParameters{
+				Codecs: []RtpCodecCapability{
+					{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+				},
+				Encodings: []RtpEncoding{{Ssrc: ssrc}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	produce(11111111)
+
+	// The existing entities first.
+	visitor.WalkWorker(worker)
+	assert.Equal(t, []string{"router", "transport", "producer"}, visited)
+
+	// Then those created afterwards, once.
+	visited = nil
+	produce(11111112)
+	assert.Equal(t, []string{"producer"}, visited)
+
+	visited = nil
+	_, err = router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"transport"}, visited)
+}
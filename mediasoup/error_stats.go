@@ -0,0 +1,103 @@
+package mediasoup
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Maximum number of distinct (method, reason) pairs counted by a worker, the
+// errors of the next ones being counted together with the "<other>" method
+// and reason.
+const maxErrorStats = 1000
+
+// WorkerErrorStat counts the errors of the requests to a worker having the
+// same method and reason. The ids and numbers of the reasons are replaced by
+// placeholders, so that e.g. all the "Producer not found" errors are counted
+// together.
+type WorkerErrorStat struct {
+	Method string `json:"method"`
+	Reason string `json:"reason"`
+	Count  uint64 `json:"count"`
+	// Unix time in ms of the last error.
+	LastAt int64 `json:"lastAt"`
+}
+
+type errorStatKey struct {
+	method string
+	reason string
+}
+
+// errorStats counts the request errors of a Channel.
+type errorStats struct {
+	mu    sync.Mutex
+	stats map[errorStatKey]*WorkerErrorStat
+}
+
+var (
+	errorReasonIdRegexp     = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	errorReasonNumberRegexp = regexp.MustCompile(`\d+`)
+)
+
+// categorizeErrorReason removes the ids and numbers of the given reason.
+func categorizeErrorReason(reason string) string {
+	reason = errorReasonIdRegexp.ReplaceAllString(reason, "<id>")
+
+	return errorReasonNumberRegexp.ReplaceAllString(reason, "<n>")
+}
+
+func (s *errorStats) record(method string, err error) {
+	key := errorStatKey{method: method, reason: categorizeErrorReason(err.Error())}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil {
+		s.stats = make(map[errorStatKey]*WorkerErrorStat)
+	}
+
+	stat, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= maxErrorStats {
+			key = errorStatKey{method: "<other>", reason: "<other>"}
+		}
+		if stat, ok = s.stats[key]; !ok {
+			stat = &WorkerErrorStat{Method: key.method, Reason: key.reason}
+			s.stats[key] = stat
+		}
+	}
+
+	stat.Count++
+	stat.LastAt = time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// list returns the counters, the most frequent errors first.
+func (s *errorStats) list() []WorkerErrorStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]WorkerErrorStat, 0, len(s.stats))
+
+	for _, stat := range s.stats {
+		stats = append(stats, *stat)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		if stats[i].Method != stats[j].Method {
+			return stats[i].Method < stats[j].Method
+		}
+		return stats[i].Reason < stats[j].Reason
+	})
+
+	return stats
+}
+
+// ErrorStats returns the counters of the errors of the requests to the
+// worker (rejections, timeouts...), by method and reason.
+func (w *Worker) ErrorStats() []WorkerErrorStat {
+	return w.channel.errorStats.list()
+}
@@ -0,0 +1,72 @@
+package mediasoup
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorStats(t *testing.T) {
+	var stats errorStats
+
+	stats.record("transport.consume", NewTypeError(`Producer with id "a9a0d6d5-4f7b-4d8c-9c52-1a2b3c4d5e6f" not found`))
+	stats.record("transport.consume", NewTypeError(`Producer with id "0c9e1b7e-8c62-4a3e-b7f4-6d5e4c3b2a19" not found`))
+	stats.record("transport.consume", NewTypeError("unsupported codec"))
+	stats.record("router.dump", errors.New("Channel request timeout"))
+
+	list := stats.list()
+	require.Len(t, list, 3)
+	assert.Equal(t, "transport.consume", list[0].Method)
+	assert.Equal(t, `Producer with id "<id>" not found`, list[0].Reason)
+	assert.EqualValues(t, 2, list[0].Count)
+	assert.NotZero(t, list[0].LastAt)
+	assert.Equal(t, "router.dump", list[1].Method)
+	assert.Equal(t, "unsupported codec", list[2].Reason)
+
+	assert.Equal(t, "invalid port <n>", categorizeErrorReason("invalid port 40000"))
+}
+
+func TestErrorStats_Overflow(t *testing.T) {
+	var stats errorStats
+
+	for i := 0; i < maxErrorStats+10; i++ {
+		stats.record(fmt.Sprintf("method%d", i), errors.New("failure"))
+	}
+
+	list := stats.list()
+	assert.Len(t, list, maxErrorStats+1)
+	assert.Equal(t, WorkerErrorStat{Method: "<other>", Reason: "<other>", Count: 10, LastAt: list[0].LastAt}, list[0])
+}
+
+func TestWorkerErrorStats(t *testing.T) {
+	worker := CreateTestWorker()
+	defer worker.Close()
+
+	router, err := worker.CreateRouter(testRouterMediaCodecs)
+	require.NoError(t, err)
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+	})
+	require.NoError(t, err)
+
+	_, err = transport.Produce(TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs:    []RtpCodecCapability{{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2}},
+			Encodings: []RtpEncoding{{Ssrc: 1111}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, worker.ErrorStats())
+
+	err = transport.Connect(TransportConnectParams{})
+	require.Error(t, err)
+
+	stats := worker.ErrorStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "transport.connect", stats[0].Method)
+	assert.EqualValues(t, 1, stats[0].Count)
+}
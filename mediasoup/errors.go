@@ -27,6 +27,21 @@ func (e UnsupportedError) Error() string {
 	return fmt.Sprintf("%s:%s", e.name, e.message)
 }
 
+// SsrcCollisionError produced when a Producer announces an SSRC already used
+// by another Producer in the same Router (or twice).
+type SsrcCollisionError struct {
+	Ssrc       uint32
+	ProducerId string // empty if announced twice
+}
+
+func (e SsrcCollisionError) Error() string {
+	if len(e.ProducerId) == 0 {
+		return fmt.Sprintf("SsrcCollisionError:ssrc %d announced twice", e.Ssrc)
+	}
+
+	return fmt.Sprintf(`SsrcCollisionError:ssrc %d already used by Producer "%s"`, e.Ssrc, e.ProducerId)
+}
+
 // InvalidStateError produced when calling a method in an invalid state.
 type InvalidStateError struct {
 	name    string
@@ -43,3 +58,39 @@ func NewInvalidStateError(format string, args ...interface{}) error {
 func (e InvalidStateError) Error() string {
 	return fmt.Sprintf("%s:%s", e.name, e.message)
 }
+
+// TimeoutError produced when something expected didn't happen in time.
+type TimeoutError struct {
+	name    string
+	message string
+}
+
+func NewTimeoutError(format string, args ...interface{}) error {
+	return TimeoutError{
+		name:    "TimeoutError",
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("%s:%s", e.name, e.message)
+}
+
+// ValidationError produced when options are invalid, before any request is
+// sent to the worker. Field is the path of the invalid field (e.g.
+// "CreateWebRtcTransportParams.ListenIps[0].Ip").
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func NewValidationError(field string, format string, args ...interface{}) error {
+	return ValidationError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
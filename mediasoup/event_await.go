@@ -0,0 +1,81 @@
+package mediasoup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/**
+ * Add a listener called at most once, removed if the event is not emitted
+ * within the given timeout. The returned channel receives nil once the
+ * listener is called, or a TimeoutError, e.g.:
+ *
+ *	done := transport.OnceWithTimeout("dtlsstatechange", 10*time.Second, func(dtlsState string) {
+ *		...
+ *	})
+ *	if err := <-done; err != nil {
+ *		...
+ *	}
+ */
+func (e *eventEmitter) OnceWithTimeout(evt string, timeout time.Duration, listener interface{}) (done <-chan error) {
+	doneCh := make(chan error, 1)
+
+	target := newIntervalListener(listener)
+	if target == nil {
+		doneCh <- NewTypeError("listener must be a function")
+		return doneCh
+	}
+
+	var (
+		once    sync.Once
+		timer   *time.Timer
+		wrapper *intervalListener
+	)
+
+	wrapper = newIntervalListener(func(argv ...interface{}) {
+		once.Do(func() {
+			timer.Stop()
+			e.removeItem(evt, wrapper)
+
+			defer func() {
+				doneCh <- nil
+			}()
+
+			target.call(evt, &eventArgs{argv: argv})
+		})
+	})
+
+	timer = time.AfterFunc(timeout, func() {
+		once.Do(func() {
+			e.removeItem(evt, wrapper)
+
+			doneCh <- NewTimeoutError(`event "%s" not emitted within %s`, evt, timeout)
+		})
+	})
+
+	e.addListeners(evt, wrapper)
+
+	return doneCh
+}
+
+/**
+ * Block until the given event is emitted, returning its arguments, or until
+ * the context is done. The event must be emitted after the call, e.g.:
+ *
+ *	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+ *	defer cancel()
+ *
+ *	argv, err := transport.Await(ctx, "dtlsstatechange")
+ */
+func (e *eventEmitter) Await(ctx context.Context, evt string) (argv []interface{}, err error) {
+	ch, unsubscribe := e.Subscribe(evt)
+	defer unsubscribe()
+
+	select {
+	case argv = <-ch:
+		return argv, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+package mediasoup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventEmitter_OnceWithTimeout(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var states []string
+	done := emitter.OnceWithTimeout("dtlsstatechange", time.Second, func(dtlsState string) {
+		states = append(states, dtlsState)
+	})
+	emitter.Emit("dtlsstatechange", "connected")
+	emitter.Emit("dtlsstatechange", "closed")
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"connected"}, states)
+	assert.Equal(t, 0, emitter.ListenerCount("dtlsstatechange"))
+
+	called := false
+	done = emitter.OnceWithTimeout("dtlsstatechange", 10*time.Millisecond, func() {
+		called = true
+	})
+
+	err := <-done
+	assert.IsType(t, TimeoutError{}, err)
+	assert.Equal(t, 0, emitter.ListenerCount("dtlsstatechange"))
+	emitter.Emit("dtlsstatechange", "connected")
+	assert.False(t, called)
+
+	assert.Error(t, <-emitter.OnceWithTimeout("dtlsstatechange", time.Second, "listener"))
+}
+
+func TestEventEmitter_Await(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	go func() {
+		for emitter.ListenerCount("@success") == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		emitter.Emit("@success", 1, "a")
+	}()
+
+	argv, err := emitter.Await(context.Background(), "@success")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, "a"}, argv)
+	assert.Equal(t, 0, emitter.ListenerCount("@success"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = emitter.Await(ctx, "@success")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, emitter.ListenerCount("@success"))
+}
+
+func TestEvent_Await(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	go func() {
+		for emitter.ListenerCount("dtlsstatechange") == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		emitter.Emit("dtlsstatechange", "connecting")
+		emitter.Emit("dtlsstatechange", "connected")
+	}()
+
+	dtlsState, err := EventWebRtcTransportDtlsStateChange.Await(context.Background(), emitter,
+		func(dtlsState string) bool {
+			return dtlsState == "connected"
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "connected", dtlsState)
+}
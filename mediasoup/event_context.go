@@ -0,0 +1,63 @@
+package mediasoup
+
+import "context"
+
+// WithEmitterContext sets the context of the emitter. The emitters of the
+// entities have a context canceled once the entity is closed and its close
+// events emitted.
+func WithEmitterContext(ctx context.Context) EventEmitterOption {
+	return func(e *eventEmitter) {
+		e.ctx = ctx
+	}
+}
+
+// Context returns the context of the emitter, never done if not set.
+func (e *eventEmitter) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+
+	return e.ctx
+}
+
+/**
+ * Add a listener removed once the given context is done, e.g. bound to the
+ * context of a connection, or to the one of the entity to get its events up
+ * to the close ones:
+ *
+ *	producer.OnContext(conn.Context(), "score", func(score []ProducerScore) {
+ *		...
+ *	})
+ *
+ *	ctx := consumer.Context()
+ *	consumer.OnContext(ctx, "layerschange", func(layers VideoLayer) {
+ *		go notifyLayers(ctx, peer, layers)
+ *	})
+ */
+func (e *eventEmitter) OnContext(ctx context.Context, evt string, listener interface{}) {
+	inner := newIntervalListener(listener)
+	if inner == nil || ctx.Err() != nil {
+		return
+	}
+
+	// Not called anymore once the context is done, the removal being
+	// asynchronous.
+	item := &intervalListener{
+		id:      inner.id,
+		handler: inner.handler,
+		pointer: inner.pointer,
+		value:   listener,
+		fast: func(evt string, argv []interface{}) error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return inner.call(evt, &eventArgs{argv: argv})
+		},
+	}
+
+	e.addListeners(evt, item)
+
+	context.AfterFunc(ctx, func() {
+		e.removeItem(evt, item)
+	})
+}
@@ -0,0 +1,101 @@
+package mediasoup
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventEmitter_OnContext(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var values []int
+	emitter.OnContext(ctx, "test", func(value int) {
+		values = append(values, value)
+	})
+	emitter.Emit("test", 1)
+
+	// Not called anymore once canceled, even if not removed yet.
+	cancel()
+	emitter.Emit("test", 2)
+	assert.Equal(t, []int{1}, values)
+	waitListenerCount(t, emitter, "test", 0)
+
+	// Not added with a done context.
+	emitter.OnContext(ctx, "test", func(value int) {})
+	assert.Equal(t, 0, emitter.ListenerCount("test"))
+
+	// Other listeners of the same function are kept.
+	listener := func() {}
+	ctx, cancel = context.WithCancel(context.Background())
+	emitter.On("other", listener)
+	emitter.OnContext(ctx, "other", listener)
+	assert.Equal(t, 2, emitter.ListenerCount("other"))
+
+	cancel()
+	waitListenerCount(t, emitter, "other", 1)
+}
+
+func TestEventEmitter_Context(t *testing.T) {
+	assert.Equal(t, context.Background(), NewEventEmitter(AppLogger()).Context())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.Equal(t, ctx, NewEventEmitter(AppLogger(), WithEmitterContext(ctx)).Context())
+}
+
+func TestEntityContext_CanceledOnClose(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+	})
+	require.NoError(t, err)
+
+	routerCtx, transportCtx := router.Context(), transport.Context()
+	assert.NoError(t, routerCtx.Err())
+
+	// The listeners bound to the context of the entity get its close events.
+	var events []string
+	transport.OnContext(transportCtx, "routerclose", func() {
+		events = append(events, "routerclose")
+	})
+	transport.Observer().OnContext(transportCtx, "close", func() {
+		events = append(events, "close")
+		assert.NoError(t, transportCtx.Err())
+	})
+
+	router.Close()
+	assert.Equal(t, []string{"routerclose", "close"}, events)
+	assert.Error(t, transportCtx.Err())
+	assert.Error(t, routerCtx.Err())
+	assert.Error(t, router.Observer().Context().Err())
+
+	waitListenerCount(t, transport, "routerclose", 0)
+	waitListenerCount(t, transport.Observer(), "close", 0)
+}
+
+// waitListenerCount waits for the listeners removed once their context is
+// done, asynchronously.
+func waitListenerCount(t *testing.T, emitter EventEmitter, evt string, count int) {
+	deadline := time.Now().Add(time.Second)
+
+	for emitter.ListenerCount(evt) != count {
+		if time.Now().After(deadline) {
+			assert.FailNow(t, "listeners not removed", "%d listeners of %q", emitter.ListenerCount(evt), evt)
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,181 @@
+package mediasoup
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Number of the events queued by the asynchronous emitters, not dispatched
+// yet.
+var queuedEventDispatches int64
+
+// QueuedEventDispatches returns the number of the events queued by the
+// asynchronous emitters (see WithEmitterAsync) waiting for their dispatch.
+func QueuedEventDispatches() int {
+	return int(atomic.LoadInt64(&queuedEventDispatches))
+}
+
+// EmitterDispatchOptions configure the asynchronous dispatch of the events of
+// an EventEmitter (see WithEmitterAsync).
+type EmitterDispatchOptions struct {
+	// Maximum number of events dispatched concurrently, 4 if not positive.
+	PoolSize int
+	// Maximum number of concurrent dispatches per event name (e.g. "rtp": 4),
+	// the events with a limit of 1 being dispatched serially, in emit order.
+	EventConcurrency map[string]int
+	// Limit of the events not in EventConcurrency, 1 if not positive.
+	DefaultConcurrency int
+	// Whether to dispatch the events to each listener separately, so that a
+	// slow listener doesn't delay the others. Each listener gets the events
+	// in emit order, one at a time, EventConcurrency and DefaultConcurrency
+	// being ignored.
+	PerListener bool
+}
+
+// WithEmitterAsync makes Emit and SafeEmit queue the events, their listeners
+// being called from other goroutines according to the given options, so that
+// slow listeners don't block the emitter (e.g. the channel of the worker).
+// Listener panics are recovered as with SafeEmit. The private events (with the
+// "@" prefix) are still emitted synchronously.
+func WithEmitterAsync(options EmitterDispatchOptions) EventEmitterOption {
+	return func(e *eventEmitter) {
+		e.dispatcher = newEventDispatcher(options)
+	}
+}
+
+// eventDispatcher runs the dispatches of the events, within the limits. It
+// has no goroutine while idle.
+type eventDispatcher struct {
+	mu      sync.Mutex
+	options EmitterDispatchOptions
+	queues  map[dispatchKey]*eventQueue
+	// Keys of the queues having dispatches, in arrival order.
+	waiting []dispatchKey
+	active  int
+}
+
+// dispatchKey identifies the dispatches of an event, or of an event to one
+// of its listeners.
+type dispatchKey struct {
+	evt      string
+	listener *intervalListener
+}
+
+type eventQueue struct {
+	dispatches []func()
+	active     int
+	waiting    bool
+}
+
+func newEventDispatcher(options EmitterDispatchOptions) *eventDispatcher {
+	if options.PoolSize <= 0 {
+		options.PoolSize = 4
+	}
+	if options.DefaultConcurrency <= 0 {
+		options.DefaultConcurrency = 1
+	}
+
+	return &eventDispatcher{
+		options: options,
+		queues:  make(map[dispatchKey]*eventQueue),
+	}
+}
+
+func isPrivateEvent(evt string) bool {
+	return strings.HasPrefix(evt, "@")
+}
+
+func (d *eventDispatcher) limit(key dispatchKey) int {
+	// The events to a listener are dispatched in order.
+	if key.listener != nil {
+		return 1
+	}
+	if limit := d.options.EventConcurrency[key.evt]; limit > 0 {
+		return limit
+	}
+
+	return d.options.DefaultConcurrency
+}
+
+// dispatch queues the given dispatch of the given event.
+func (d *eventDispatcher) dispatch(evt string, fn func()) {
+	d.enqueue(dispatchKey{evt: evt}, fn)
+}
+
+// dispatchListener queues the given dispatch of the given event to the given
+// listener.
+func (d *eventDispatcher) dispatchListener(evt string, listener *intervalListener, fn func()) {
+	d.enqueue(dispatchKey{evt: evt, listener: listener}, fn)
+}
+
+func (d *eventDispatcher) enqueue(key dispatchKey, fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	queue, ok := d.queues[key]
+	if !ok {
+		queue = &eventQueue{}
+		d.queues[key] = queue
+	}
+
+	queue.dispatches = append(queue.dispatches, fn)
+	atomic.AddInt64(&queuedEventDispatches, 1)
+
+	if !queue.waiting {
+		queue.waiting = true
+		d.waiting = append(d.waiting, key)
+	}
+
+	d.run()
+}
+
+// run starts the queued dispatches allowed by the limits, the events being
+// served in turn. It must be called with the lock held.
+func (d *eventDispatcher) run() {
+	for i := 0; i < len(d.waiting) && d.active < d.options.PoolSize; {
+		key := d.waiting[i]
+		queue := d.queues[key]
+
+		if queue.active >= d.limit(key) {
+			i++
+			continue
+		}
+
+		fn := queue.dispatches[0]
+		queue.dispatches[0] = nil
+		queue.dispatches = queue.dispatches[1:]
+		atomic.AddInt64(&queuedEventDispatches, -1)
+		queue.active++
+		d.active++
+
+		if len(queue.dispatches) == 0 {
+			queue.waiting = false
+			d.waiting = append(d.waiting[:i], d.waiting[i+1:]...)
+		} else {
+			// To the back of the line, for the other events to be served.
+			d.waiting = append(append(d.waiting[:i], d.waiting[i+1:]...), key)
+		}
+
+		spawn("emitter.dispatch", func() {
+			defer d.done(key)
+
+			fn()
+		})
+	}
+}
+
+func (d *eventDispatcher) done(key dispatchKey) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	queue := d.queues[key]
+	queue.active--
+	d.active--
+
+	if queue.active == 0 && !queue.waiting {
+		delete(d.queues, key)
+	}
+
+	d.run()
+}
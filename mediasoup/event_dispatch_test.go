@@ -0,0 +1,221 @@
+package mediasoup
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEmitter_Async(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger(), WithEmitterAsync(EmitterDispatchOptions{
+		PoolSize:         6,
+		EventConcurrency: map[string]int{"rtp": 4},
+	}))
+
+	var (
+		mu                   sync.Mutex
+		rtpActive, rtpMax    int
+		closeActive          int
+		closeOrder           []int
+		closeSerialViolation bool
+		wg                   sync.WaitGroup
+	)
+
+	emitter.On("rtp", func(i int) {
+		defer wg.Done()
+
+		mu.Lock()
+		rtpActive++
+		if rtpActive > rtpMax {
+			rtpMax = rtpActive
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		rtpActive--
+		mu.Unlock()
+	})
+	emitter.On("close", func(i int) {
+		defer wg.Done()
+
+		mu.Lock()
+		closeActive++
+		if closeActive > 1 {
+			closeSerialViolation = true
+		}
+		closeOrder = append(closeOrder, i)
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		closeActive--
+		mu.Unlock()
+	})
+	emitter.On("panic", func() {
+		defer wg.Done()
+		panic("listener panic")
+	})
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		emitter.Emit("rtp", i)
+		emitter.SafeEmit("close", i)
+	}
+	wg.Add(1)
+	assert.NoError(t, emitter.Emit("panic"))
+
+	wg.Wait()
+
+	assert.Equal(t, 4, rtpMax)
+	assert.False(t, closeSerialViolation)
+	for i, value := range closeOrder {
+		assert.Equal(t, i, value)
+	}
+
+	// Private events are synchronous.
+	called := false
+	emitter.On("@close", func() { called = true })
+	emitter.Emit("@close")
+	assert.True(t, called)
+}
+
+func TestEventDispatcher_PoolSize(t *testing.T) {
+	dispatcher := newEventDispatcher(EmitterDispatchOptions{
+		PoolSize:           2,
+		DefaultConcurrency: 10,
+	})
+
+	var (
+		mu             sync.Mutex
+		active, maxAct int
+		wg             sync.WaitGroup
+	)
+
+	for _, evt := range []string{"a", "b", "c", "a", "b", "c"} {
+		wg.Add(1)
+		dispatcher.dispatch(evt, func() {
+			defer wg.Done()
+
+			mu.Lock()
+			active++
+			if active > maxAct {
+				maxAct = active
+			}
+			mu.Unlock()
+
+			time.Sleep(2 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 2, maxAct)
+
+	dispatcher.mu.Lock()
+	assert.Empty(t, dispatcher.queues)
+	assert.Empty(t, dispatcher.waiting)
+	dispatcher.mu.Unlock()
+}
+
+func TestEventEmitter_AsyncPerListener(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger(), WithEmitterAsync(EmitterDispatchOptions{
+		PoolSize:    4,
+		PerListener: true,
+	}))
+
+	var (
+		mu              sync.Mutex
+		slowOrder       []int
+		fastOrder       []int
+		onceCalls       int
+		slowActive      int
+		serialViolation bool
+		wg              sync.WaitGroup
+	)
+
+	release := make(chan struct{})
+	fastDone := make(chan struct{})
+
+	emitter.On("score", func(i int) {
+		defer wg.Done()
+
+		mu.Lock()
+		slowActive++
+		if slowActive > 1 {
+			serialViolation = true
+		}
+		mu.Unlock()
+
+		if i == 0 {
+			<-release
+		}
+
+		mu.Lock()
+		slowOrder = append(slowOrder, i)
+		slowActive--
+		mu.Unlock()
+	})
+	emitter.On("score", func(i int) {
+		defer wg.Done()
+
+		mu.Lock()
+		fastOrder = append(fastOrder, i)
+		if len(fastOrder) == 10 {
+			close(fastDone)
+		}
+		mu.Unlock()
+	})
+	emitter.Once("score", func(i int) {
+		defer wg.Done()
+
+		mu.Lock()
+		onceCalls++
+		mu.Unlock()
+	})
+
+	wg.Add(1)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		emitter.SafeEmit("score", i)
+	}
+
+	// The slow listener doesn't delay the other ones.
+	select {
+	case <-fastDone:
+	case <-time.After(time.Second):
+		t.Fatal("fast listener blocked")
+	}
+	close(release)
+	wg.Wait()
+
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	assert.Equal(t, expected, slowOrder)
+	assert.Equal(t, expected, fastOrder)
+	assert.Equal(t, 1, onceCalls)
+	assert.False(t, serialViolation)
+	assert.Equal(t, 2, emitter.ListenerCount("score"))
+
+	// Panics are recovered per listener.
+	var called bool
+	wg.Add(2)
+	emitter.On("panic", func() {
+		defer wg.Done()
+		panic("listener panic")
+	})
+	emitter.On("panic", func() {
+		defer wg.Done()
+		called = true
+	})
+	assert.NoError(t, emitter.Emit("panic"))
+	wg.Wait()
+	assert.True(t, called)
+}
@@ -1,9 +1,12 @@
 package mediasoup
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -13,12 +16,42 @@ type EventEmitter interface {
 	Once(evt string, listener interface{})
 	Emit(evt string, argv ...interface{}) (err error)
 	SafeEmit(evt string, argv ...interface{})
+	// RemoveListener removes the listener matching the given function by
+	// pointer, or the given ListenerHandle.
+	//
+	// Deprecated: distinct closures of the same function literal having the
+	// same pointer, they can't be told apart. Use the ListenerHandle
+	// returned by Listen.
 	RemoveListener(evt string, listener interface{}) (ok bool)
 	RemoveAllListeners(evt string)
 	On(evt string, listener ...interface{})
+	// Off removes a listener, see RemoveListener.
+	//
+	// Deprecated: use the ListenerHandle returned by Listen.
 	Off(evt string, listener interface{})
 	ListenerCount(evt string) int
 	Len() int
+	// Subscribe returns a channel receiving the arguments of the emitted
+	// events, closed by calling unsubscribe.
+	Subscribe(evt string) (ch <-chan []interface{}, unsubscribe func())
+	// OnceWithTimeout adds a listener called at most once, removed if the
+	// event is not emitted within the timeout.
+	OnceWithTimeout(evt string, timeout time.Duration, listener interface{}) (done <-chan error)
+	// Await blocks until the event is emitted, returning its arguments.
+	Await(ctx context.Context, evt string) (argv []interface{}, err error)
+	// OnAny adds a listener of every event, called with the name of the
+	// event followed by its arguments.
+	OnAny(listener interface{})
+	OffAny(listener interface{})
+	// OnContext adds a listener removed once the context is done.
+	OnContext(ctx context.Context, evt string, listener interface{})
+	// Listen adds a listener, returning the handle removing exactly it.
+	Listen(evt string, listener interface{}) ListenerHandle
+	// Context returns the context of the emitter, canceled once its entity
+	// is closed.
+	Context() context.Context
+	// Use adds middlewares wrapping every listener call.
+	Use(middlewares ...EventMiddleware)
 }
 
 type (
@@ -26,19 +59,93 @@ type (
 		FuncValue reflect.Value
 		ArgTypes  []reflect.Type
 		Once      bool
+		id        uint64
+		// Calls the listener without reflection, if of a known type.
+		fast func(evt string, argv []interface{}) error
+		// Whether the listener is an EventHandler, given the event name.
+		handler bool
+		// Identity of the listener, see matches.
+		pointer uintptr
+		value   interface{}
 	}
 
 	eventEmitter struct {
 		logger       logrus.FieldLogger
+		quiet        bool
+		panicHook    func(evt string, r interface{})
 		evtListeners map[string][]*intervalListener
-		mu           sync.Mutex
+		// Whether pattern listeners were added.
+		hasPatterns bool
+		mu          sync.Mutex
+		// Asynchronous dispatch, if enabled.
+		dispatcher  *eventDispatcher
+		middlewares []EventMiddleware
+		// Canceled once the entity is closed.
+		ctx context.Context
 	}
 )
 
-func NewEventEmitter(logger logrus.FieldLogger) EventEmitter {
-	return &eventEmitter{
+// EventEmitterOption configures an EventEmitter created by NewEventEmitter.
+type EventEmitterOption func(e *eventEmitter)
+
+var defaultEventEmitterOptions = struct {
+	sync.RWMutex
+	options []EventEmitterOption
+}{}
+
+// SetDefaultEventEmitterOptions sets the options applied to every EventEmitter
+// created afterwards, including the ones owned by workers, routers, transports,
+// producers and consumers. Options given to NewEventEmitter take precedence.
+func SetDefaultEventEmitterOptions(options ...EventEmitterOption) {
+	defaultEventEmitterOptions.Lock()
+	defer defaultEventEmitterOptions.Unlock()
+
+	defaultEventEmitterOptions.options = options
+}
+
+// WithEmitterLogFields adds fields (e.g. router id, transport id) to every log
+// entry written by the emitter.
+func WithEmitterLogFields(fields logrus.Fields) EventEmitterOption {
+	return func(e *eventEmitter) {
+		if len(fields) > 0 {
+			e.logger = e.logger.WithFields(fields)
+		}
+	}
+}
+
+// WithEmitterQuiet suppresses the error log written by SafeEmit when a listener
+// panics or returns an error. The panic hook, if any, is still called.
+func WithEmitterQuiet(quiet bool) EventEmitterOption {
+	return func(e *eventEmitter) {
+		e.quiet = quiet
+	}
+}
+
+// WithEmitterPanicHook sets the function called with the recovered value when a
+// listener panics within SafeEmit.
+func WithEmitterPanicHook(hook func(evt string, r interface{})) EventEmitterOption {
+	return func(e *eventEmitter) {
+		e.panicHook = hook
+	}
+}
+
+func NewEventEmitter(logger logrus.FieldLogger, options ...EventEmitterOption) EventEmitter {
+	e := &eventEmitter{
 		logger: logger,
 	}
+
+	defaultEventEmitterOptions.RLock()
+	defaults := defaultEventEmitterOptions.options
+	defaultEventEmitterOptions.RUnlock()
+
+	for _, option := range defaults {
+		option(e)
+	}
+	for _, option := range options {
+		option(e)
+	}
+
+	return e
 }
 
 func (e *eventEmitter) AddListener(evt string, listeners ...interface{}) {
@@ -49,24 +156,47 @@ func (e *eventEmitter) AddListener(evt string, listeners ...interface{}) {
 	var listenerValues []*intervalListener
 
 	for _, listener := range listeners {
-		listenerValue := reflect.ValueOf(listener)
-		listenerType := listenerValue.Type()
-
-		if listenerType.Kind() != reflect.Func {
-			continue
+		if listenerValue := newIntervalListener(listener); listenerValue != nil {
+			listenerValues = append(listenerValues, listenerValue)
 		}
-		var argTypes []reflect.Type
+	}
 
-		for i := 0; i < listenerType.NumIn(); i++ {
-			argTypes = append(argTypes, listenerType.In(i))
-		}
+	e.addListeners(evt, listenerValues...)
+}
 
-		listenerValues = append(listenerValues, &intervalListener{
-			FuncValue: listenerValue,
-			ArgTypes:  argTypes,
-		})
+func newIntervalListener(listener interface{}) *intervalListener {
+	item := &intervalListener{
+		id:      nextListenerId(),
+		fast:    fastCall(listener),
+		pointer: listenerPointer(listener),
+		value:   listener,
 	}
 
+	if item.fast != nil {
+		_, item.handler = listener.(EventHandler)
+		return item
+	}
+
+	listenerValue := reflect.ValueOf(listener)
+
+	if listenerValue.Kind() != reflect.Func {
+		return nil
+	}
+	listenerType := listenerValue.Type()
+
+	var argTypes []reflect.Type
+
+	for i := 0; i < listenerType.NumIn(); i++ {
+		argTypes = append(argTypes, listenerType.In(i))
+	}
+
+	item.FuncValue = listenerValue
+	item.ArgTypes = argTypes
+
+	return item
+}
+
+func (e *eventEmitter) addListeners(evt string, listeners ...*intervalListener) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -74,7 +204,11 @@ func (e *eventEmitter) AddListener(evt string, listeners ...interface{}) {
 		e.evtListeners = make(map[string][]*intervalListener)
 	}
 
-	e.evtListeners[evt] = append(e.evtListeners[evt], listenerValues...)
+	e.evtListeners[evt] = append(e.evtListeners[evt], listeners...)
+
+	if isEventPattern(evt) {
+		e.hasPatterns = true
+	}
 }
 
 func (e *eventEmitter) Once(evt string, listener interface{}) {
@@ -83,21 +217,32 @@ func (e *eventEmitter) Once(evt string, listener interface{}) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	listenerPointer := reflect.ValueOf(listener).Pointer()
 	listeners := e.evtListeners[evt]
 
 	for i := len(listeners) - 1; i >= 0; i-- {
 		item := listeners[i]
 
-		if item.FuncValue.Pointer() == listenerPointer {
+		if item.matches(listener) {
 			item.Once = true
 			break
 		}
 	}
 }
 
-// Emit fires a particular event
+// Emit fires a particular event, returning the errors returned by the
+// listeners (the ones with an error as last result), joined. The events
+// dispatched asynchronously return no error.
 func (e *eventEmitter) Emit(evt string, argv ...interface{}) (err error) {
+	// Asynchronous events are dispatched as with SafeEmit.
+	if e.dispatcher != nil && !isPrivateEvent(evt) {
+		e.SafeEmit(evt, argv...)
+		return
+	}
+
+	return e.emit(evt, argv...)
+}
+
+func (e *eventEmitter) emit(evt string, argv ...interface{}) (err error) {
 	e.mu.Lock()
 
 	if e.evtListeners == nil {
@@ -105,59 +250,183 @@ func (e *eventEmitter) Emit(evt string, argv ...interface{}) (err error) {
 		return // has no listeners to emit yet
 	}
 
-	listeners := e.evtListeners[evt][:]
+	listeners := e.eventListeners(evt)
+	middlewares := e.middlewares
 
 	e.mu.Unlock()
 
-	var callArgs []reflect.Value
+	args := &eventArgs{argv: argv}
+
+	var errs []error
+
+	for _, l := range listeners {
+		if err := e.invoke(middlewares, evt, argv, l, args); err != nil {
+			errs = append(errs, err)
+		}
 
-	for _, a := range argv {
-		callArgs = append(callArgs, reflect.ValueOf(a))
+		if l.listener.Once {
+			e.removeItem(l.key, l.listener)
+		}
 	}
 
-	for _, listener := range listeners {
-		var actualCallArgs []reflect.Value
+	return errors.Join(errs...)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// call calls the listener with the given arguments, adapted to its arity,
+// returning the error it returned, if any.
+func (listener *intervalListener) call(evt string, args *eventArgs) error {
+	if listener.fast != nil {
+		return listener.fast(evt, args.argv)
+	}
 
+	callArgs := args.reflectValues()
+
+	var actualCallArgs []reflect.Value
+
+	argc := len(listener.ArgTypes)
+	isVariadic := listener.FuncValue.Type().IsVariadic()
+
+	if isVariadic && len(callArgs) >= argc-1 {
+		// pass all arguments to variadic listeners
+		actualCallArgs = callArgs[:]
+	} else if len(callArgs) >= argc {
 		// delete unwanted arguments
-		if argc := len(listener.ArgTypes); len(callArgs) >= argc {
-			actualCallArgs = callArgs[0:argc]
-		} else {
-			actualCallArgs = callArgs[:]
-			isVariadic := listener.FuncValue.Type().IsVariadic()
-
-			// append missing arguments with zero value
-			for i, a := range listener.ArgTypes[len(callArgs):] {
-				// ignore the last variadic argument
-				if isVariadic && len(callArgs)+i == argc-1 {
-					break
-				}
-				actualCallArgs = append(actualCallArgs, reflect.Zero(a))
+		actualCallArgs = callArgs[0:argc]
+	} else {
+		actualCallArgs = callArgs[:]
+
+		// append missing arguments with zero value
+		for i, a := range listener.ArgTypes[len(callArgs):] {
+			// ignore the last variadic argument
+			if isVariadic && len(callArgs)+i == argc-1 {
+				break
 			}
+			actualCallArgs = append(actualCallArgs, reflect.Zero(a))
 		}
+	}
 
-		listener.FuncValue.Call(actualCallArgs)
-
-		if listener.Once {
-			e.RemoveListener(evt, listener)
+	// untyped nil arguments are given as zero values
+	for i, arg := range actualCallArgs {
+		if arg.IsValid() {
+			continue
+		}
+		var argType reflect.Type
+		if isVariadic && i >= argc-1 {
+			argType = listener.ArgTypes[argc-1].Elem()
+		} else {
+			argType = listener.ArgTypes[i]
 		}
+		actualCallArgs = append([]reflect.Value(nil), actualCallArgs...)
+		actualCallArgs[i] = reflect.Zero(argType)
+	}
+
+	results := listener.FuncValue.Call(actualCallArgs)
+
+	if len(results) == 0 {
+		return nil
+	}
+	if last := results[len(results)-1]; last.Type() == errorType && !last.IsNil() {
+		return last.Interface().(error)
 	}
 
-	return
+	return nil
 }
 
 // SafaEmit fires a particular event and ignore panic.
 func (e *eventEmitter) SafeEmit(evt string, argv ...interface{}) {
+	if e.dispatcher != nil && !isPrivateEvent(evt) {
+		if e.dispatcher.options.PerListener {
+			e.dispatchListeners(evt, argv...)
+			return
+		}
+		e.dispatcher.dispatch(evt, func() {
+			e.safeEmit(evt, argv...)
+		})
+		return
+	}
+
+	e.safeEmit(evt, argv...)
+}
+
+func (e *eventEmitter) safeEmit(evt string, argv ...interface{}) {
 	defer func() {
 		if r := recover(); r != nil {
-			if logger, ok := e.logger.(*logrus.Logger); ok &&
-				logger.IsLevelEnabled(logrus.DebugLevel) {
-				debug.PrintStack()
-			}
-			e.logger.WithField("event", evt).Errorln(r)
+			e.handlePanic(evt, r)
 		}
 	}()
 
-	e.Emit(evt, argv...)
+	e.handleError(evt, e.emit(evt, argv...))
+}
+
+// dispatchListeners queues the call of each listener of the event, the Once
+// listeners being removed right away.
+func (e *eventEmitter) dispatchListeners(evt string, argv ...interface{}) {
+	e.mu.Lock()
+
+	listeners := e.eventListeners(evt)
+	middlewares := e.middlewares
+
+	for _, l := range listeners {
+		if l.listener.Once {
+			e.removeListener(l.key, l.listener)
+		}
+	}
+
+	e.mu.Unlock()
+
+	for _, l := range listeners {
+		l := l
+
+		e.dispatcher.dispatchListener(evt, l.listener, func() {
+			defer func() {
+				if r := recover(); r != nil {
+					e.handlePanic(evt, r)
+				}
+			}()
+
+			// Not shared, the listeners being called concurrently.
+			args := &eventArgs{argv: argv}
+
+			e.handleError(evt, e.invoke(middlewares, evt, argv, l, args))
+		})
+	}
+}
+
+// handleError logs the error returned by listeners of the event emitted with
+// SafeEmit, having no caller to return it to.
+func (e *eventEmitter) handleError(evt string, err error) {
+	if err != nil && !e.quiet {
+		e.logger.WithField("event", evt).Warnln(err)
+	}
+}
+
+// handlePanic reports the panic of a listener of the event.
+func (e *eventEmitter) handlePanic(evt string, r interface{}) {
+	if e.panicHook != nil {
+		e.panicHook(evt, r)
+	}
+	if e.quiet {
+		return
+	}
+	if loggerLevelEnabled(e.logger, logrus.DebugLevel) {
+		debug.PrintStack()
+	}
+	e.logger.WithField("event", evt).Errorln(r)
+}
+
+// loggerLevelEnabled tells whether the given logger, possibly an entry with
+// fields, logs at the given level.
+func loggerLevelEnabled(logger logrus.FieldLogger, level logrus.Level) bool {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return l.IsLevelEnabled(level)
+	case *logrus.Entry:
+		return l.Logger.IsLevelEnabled(level)
+	}
+
+	return false
 }
 
 func (e *eventEmitter) RemoveListener(evt string, listener interface{}) (ok bool) {
@@ -172,13 +441,16 @@ func (e *eventEmitter) RemoveListener(evt string, listener interface{}) (ok bool
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	return e.removeListener(evt, listener)
+}
+
+// removeListener removes the listener, with the lock held.
+func (e *eventEmitter) removeListener(evt string, listener interface{}) (ok bool) {
 	idx := -1
-	listenerPointer := reflect.ValueOf(listener).Pointer()
 	listeners := e.evtListeners[evt]
 
 	for index, item := range listeners {
-		if listener == item ||
-			item.FuncValue.Pointer() == listenerPointer {
+		if item.matches(listener) {
 			idx = index
 			break
 		}
@@ -1,8 +1,12 @@
 package mediasoup
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,6 +45,42 @@ func TestEventEmitter_Emit(t *testing.T) {
 	assert.Equal(t, 2, onObserver.CalledTimes())
 }
 
+func TestEventEmitter_EmitErrors(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	errVeto := errors.New("veto")
+	calls := 0
+
+	emitter.On("test", func() error {
+		calls++
+		return errVeto
+	})
+	emitter.On("test", func(value int) error {
+		calls++
+		return nil
+	})
+	emitter.On("test", func(value int) (int, error) {
+		calls++
+		return value, fmt.Errorf("invalid value %d", value)
+	})
+	emitter.On("test", func(value int) {
+		calls++
+	})
+
+	err := emitter.Emit("test", 1)
+	assert.Equal(t, 4, calls)
+	assert.True(t, errors.Is(err, errVeto))
+	assert.EqualError(t, err, "veto\ninvalid value 1")
+
+	assert.NoError(t, emitter.Emit("other"))
+
+	// Logged by SafeEmit.
+	assert.NotPanics(t, func() {
+		emitter.SafeEmit("test", 2)
+	})
+	assert.Equal(t, 8, calls)
+}
+
 func TestEventEmitter_SafeEmit(t *testing.T) {
 	evName := "test"
 	logger := TypeLogger("eventEmitter")
@@ -84,3 +124,56 @@ func TestEventEmitter_RemoveAllListeners(t *testing.T) {
 	assert.Equal(t, 0, onObserver.CalledTimes())
 	assert.Equal(t, 0, emitter.ListenerCount(evName))
 }
+
+func TestEventEmitter_SafeEmitQuiet(t *testing.T) {
+	evName := "test"
+	logger := TypeLogger("eventEmitter")
+
+	var hookEvt string
+	var hookValue interface{}
+
+	emitter := NewEventEmitter(logger,
+		WithEmitterLogFields(logrus.Fields{"routerId": "r1"}),
+		WithEmitterQuiet(true),
+		WithEmitterPanicHook(func(evt string, r interface{}) {
+			hookEvt, hookValue = evt, r
+		}),
+	)
+
+	emitter.On(evName, func() { panic("boom") })
+	emitter.SafeEmit(evName)
+
+	assert.Equal(t, evName, hookEvt)
+	assert.Equal(t, "boom", hookValue)
+}
+
+func TestLoggerLevelEnabled(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+
+	assert.True(t, loggerLevelEnabled(logger, logrus.DebugLevel))
+	assert.True(t, loggerLevelEnabled(logger.WithField("routerId", "r1"), logrus.DebugLevel))
+
+	logger.SetLevel(logrus.InfoLevel)
+
+	assert.False(t, loggerLevelEnabled(logger.WithField("routerId", "r1"), logrus.DebugLevel))
+}
+
+func TestSetDefaultEventEmitterOptions_Concurrent(t *testing.T) {
+	defer SetDefaultEventEmitterOptions()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefaultEventEmitterOptions(WithEmitterQuiet(true))
+		}()
+		go func() {
+			defer wg.Done()
+			NewEventEmitter(AppLogger())
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,116 @@
+package mediasoup
+
+import "reflect"
+
+// EventHandler is a listener called without reflection, for the events
+// emitted at high frequency (e.g. "rtp" of the DirectTransport Consumers).
+// The arguments must not be retained after Handle returns.
+//
+// Listeners of type func(), func(...interface{}), func(interface{}) and
+// func(interface{}, interface{}) are called without reflection too.
+type EventHandler interface {
+	Handle(evt string, argv ...interface{})
+}
+
+// EventHandlerFunc is an EventHandler function.
+type EventHandlerFunc func(evt string, argv ...interface{})
+
+func (fn EventHandlerFunc) Handle(evt string, argv ...interface{}) {
+	fn(evt, argv...)
+}
+
+// eventArgs are the arguments of an emitted event, converted to reflect
+// values for the first listener called through reflection.
+type eventArgs struct {
+	argv   []interface{}
+	values []reflect.Value
+}
+
+func (args *eventArgs) reflectValues() []reflect.Value {
+	if args.values == nil && len(args.argv) > 0 {
+		args.values = make([]reflect.Value, len(args.argv))
+
+		for i, a := range args.argv {
+			args.values[i] = reflect.ValueOf(a)
+		}
+	}
+
+	return args.values
+}
+
+// fastCall returns the function calling the listener without reflection, nil
+// if the listener is not of a known type.
+func fastCall(listener interface{}) func(evt string, argv []interface{}) error {
+	arg := func(argv []interface{}, i int) interface{} {
+		if i < len(argv) {
+			return argv[i]
+		}
+		return nil
+	}
+
+	switch fn := listener.(type) {
+	case EventHandler:
+		return func(evt string, argv []interface{}) error {
+			fn.Handle(evt, argv...)
+			return nil
+		}
+	case func():
+		return func(evt string, argv []interface{}) error {
+			fn()
+			return nil
+		}
+	case func(...interface{}):
+		return func(evt string, argv []interface{}) error {
+			fn(argv...)
+			return nil
+		}
+	case func(interface{}):
+		return func(evt string, argv []interface{}) error {
+			fn(arg(argv, 0))
+			return nil
+		}
+	case func(interface{}, interface{}):
+		return func(evt string, argv []interface{}) error {
+			fn(arg(argv, 0), arg(argv, 1))
+			return nil
+		}
+	case func() error:
+		return func(evt string, argv []interface{}) error {
+			return fn()
+		}
+	case func(...interface{}) error:
+		return func(evt string, argv []interface{}) error {
+			return fn(argv...)
+		}
+	}
+
+	return nil
+}
+
+// listenerPointer returns the pointer identifying the listener, zero if it
+// is not a pointer like value (e.g. an EventHandler struct).
+func listenerPointer(listener interface{}) uintptr {
+	value := reflect.ValueOf(listener)
+
+	switch value.Kind() {
+	case reflect.Func, reflect.Ptr, reflect.Map, reflect.Chan, reflect.Slice, reflect.UnsafePointer:
+		return value.Pointer()
+	}
+
+	return 0
+}
+
+// matches returns whether the listener was added as the given one.
+func (item *intervalListener) matches(listener interface{}) bool {
+	switch other := listener.(type) {
+	case *intervalListener:
+		return other == item
+	case ListenerHandle:
+		return other.id == item.id
+	}
+	if pointer := listenerPointer(listener); pointer != 0 {
+		return pointer == item.pointer
+	}
+
+	return reflect.TypeOf(listener).Comparable() && item.value == listener
+}
@@ -0,0 +1,92 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingHandler struct {
+	counts map[string]int
+}
+
+func (h *countingHandler) Handle(evt string, argv ...interface{}) {
+	h.counts[evt] += len(argv)
+}
+
+func TestEventEmitter_EventHandler(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	handler := &countingHandler{counts: map[string]int{}}
+	emitter.On("rtp", handler)
+	emitter.OnAny(handler)
+
+	var received []interface{}
+	handlerFunc := EventHandlerFunc(func(evt string, argv ...interface{}) {
+		received = append(received, evt)
+		received = append(received, argv...)
+	})
+	emitter.On("rtp", handlerFunc)
+
+	emitter.Emit("rtp", []byte{1}, 2)
+	emitter.Emit("rtcp", []byte{1})
+
+	// EventHandlers of patterns get the event name, not prefixed arguments.
+	assert.Equal(t, map[string]int{"rtp": 4, "rtcp": 1}, handler.counts)
+	assert.Equal(t, []interface{}{"rtp", []byte{1}, 2}, received)
+
+	assert.True(t, emitter.RemoveListener("rtp", handler))
+	assert.True(t, emitter.RemoveListener("rtp", handlerFunc))
+	assert.Equal(t, 0, emitter.ListenerCount("rtp"))
+
+	emitter.OffAny(handler)
+	emitter.Once("rtp", handler)
+	emitter.Emit("rtp", 1)
+	emitter.Emit("rtp", 1)
+	assert.Equal(t, 5, handler.counts["rtp"])
+}
+
+func TestEventEmitter_FastListeners(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	var calls []interface{}
+
+	emitter.On("test", func() { calls = append(calls, "none") })
+	emitter.On("test", func(a interface{}) { calls = append(calls, a) })
+	emitter.On("test", func(a, b interface{}) { calls = append(calls, a, b) })
+	emitter.On("test", func(argv ...interface{}) { calls = append(calls, len(argv)) })
+
+	emitter.Emit("test", 1)
+
+	assert.Equal(t, []interface{}{"none", 1, 1, nil, 1}, calls)
+}
+
+func benchmarkEmit(b *testing.B, listener interface{}) {
+	emitter := NewEventEmitter(AppLogger())
+	emitter.On("rtp", listener)
+
+	packet := make([]byte, 1200)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		emitter.Emit("rtp", packet)
+	}
+}
+
+func BenchmarkEventEmitter_EmitReflect(b *testing.B) {
+	benchmarkEmit(b, func(packet []byte) {})
+}
+
+func BenchmarkEventEmitter_EmitFastFunc(b *testing.B) {
+	benchmarkEmit(b, func(packet interface{}) {
+		_ = packet.([]byte)
+	})
+}
+
+func BenchmarkEventEmitter_EmitEventHandler(b *testing.B) {
+	benchmarkEmit(b, EventHandlerFunc(func(evt string, argv ...interface{}) {
+		_ = argv[0].([]byte)
+	}))
+}
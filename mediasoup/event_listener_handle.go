@@ -0,0 +1,61 @@
+package mediasoup
+
+import "sync/atomic"
+
+// Ids of the listeners, identifying them in the ListenerHandles.
+var listenerIds uint64
+
+func nextListenerId() uint64 {
+	return atomic.AddUint64(&listenerIds, 1)
+}
+
+// ListenerHandle identifies a listener added with Listen. RemoveListener
+// matches the listeners by function pointer, and thus also removes the other
+// closures of the same function literal (e.g. created in a loop). Remove
+// removes exactly the added listener.
+type ListenerHandle struct {
+	emitter *eventEmitter
+	evt     string
+	id      uint64
+}
+
+// Remove removes the listener, returning whether it was still added.
+func (h ListenerHandle) Remove() bool {
+	if h.emitter == nil {
+		return false
+	}
+
+	h.emitter.mu.Lock()
+	defer h.emitter.mu.Unlock()
+
+	return h.emitter.removeListener(h.evt, h)
+}
+
+/**
+ * Add a listener, returning the handle removing exactly it, e.g.:
+ *
+ *	for _, peer := range peers {
+ *		handle := producer.Listen("score", func(score []ProducerScore) {
+ *			peer.notify(score)
+ *		})
+ *		peer.onLeave(handle.Remove)
+ *	}
+ */
+func (e *eventEmitter) Listen(evt string, listener interface{}) ListenerHandle {
+	item := newIntervalListener(listener)
+	if item == nil {
+		return ListenerHandle{}
+	}
+
+	e.addListeners(evt, item)
+
+	return ListenerHandle{emitter: e, evt: evt, id: item.id}
+}
+
+// removeItem removes the given listener, with its identity.
+func (e *eventEmitter) removeItem(evt string, item *intervalListener) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.removeListener(evt, item)
+}
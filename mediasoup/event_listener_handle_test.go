@@ -0,0 +1,44 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEmitter_Listen(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	var calls []int
+	var handles []ListenerHandle
+	var listeners []func()
+
+	for i := 0; i < 3; i++ {
+		i := i
+		listener := func() { calls = append(calls, i) }
+
+		listeners = append(listeners, listener)
+		handles = append(handles, emitter.Listen("test", listener))
+	}
+
+	// The handles remove exactly their listener.
+	assert.True(t, handles[1].Remove())
+	assert.False(t, handles[1].Remove())
+
+	emitter.Emit("test")
+	assert.Equal(t, []int{0, 2}, calls)
+
+	// RemoveListener matches by function pointer: the closures of the same
+	// function literal are not told apart, the first added is removed.
+	assert.True(t, emitter.RemoveListener("test", listeners[2]))
+
+	calls = nil
+	emitter.Emit("test")
+	assert.Equal(t, []int{2}, calls)
+
+	assert.True(t, emitter.RemoveListener("test", handles[2]))
+	assert.Equal(t, 0, emitter.ListenerCount("test"))
+
+	assert.False(t, ListenerHandle{}.Remove())
+	assert.False(t, emitter.Listen("test", "not a function").Remove())
+}
@@ -0,0 +1,54 @@
+package mediasoup
+
+// EventMiddleware wraps every listener call of an emitter, including the
+// ones of the private ("@") events, e.g. to add tracing spans or count the
+// events. The listener is called by next, not calling it drops the event for
+// the listener:
+//
+//	emitter.Use(func(evt string, argv []interface{}, next func()) {
+//		start := time.Now()
+//		next()
+//		listenerDuration.WithLabelValues(evt).Observe(time.Since(start).Seconds())
+//	})
+type EventMiddleware func(evt string, argv []interface{}, next func())
+
+// WithEmitterMiddleware adds middlewares to the emitter, see
+// EventEmitter.Use.
+func WithEmitterMiddleware(middlewares ...EventMiddleware) EventEmitterOption {
+	return func(e *eventEmitter) {
+		e.middlewares = append(e.middlewares, middlewares...)
+	}
+}
+
+// Use adds middlewares, the first added one being the outermost.
+func (e *eventEmitter) Use(middlewares ...EventMiddleware) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Copied, the emitting goroutines iterating over the current ones.
+	e.middlewares = append(append([]EventMiddleware(nil), e.middlewares...), middlewares...)
+}
+
+// invoke calls the listener through the middlewares, with the emitted
+// arguments.
+func (e *eventEmitter) invoke(middlewares []EventMiddleware, evt string, argv []interface{}, l eventListener, args *eventArgs) (err error) {
+	if len(middlewares) == 0 {
+		return l.listener.call(evt, l.args(evt, args))
+	}
+
+	call := func() {
+		err = l.listener.call(evt, l.args(evt, args))
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		middleware, next := middlewares[i], call
+
+		call = func() {
+			middleware(evt, argv, next)
+		}
+	}
+
+	call()
+
+	return
+}
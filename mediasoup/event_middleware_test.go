@@ -0,0 +1,67 @@
+package mediasoup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEmitter_Use(t *testing.T) {
+	var trace []string
+
+	emitter := NewEventEmitter(AppLogger(), WithEmitterMiddleware(
+		func(evt string, argv []interface{}, next func()) {
+			trace = append(trace, "outer:"+evt)
+			next()
+			trace = append(trace, "outer done")
+		},
+	))
+
+	emitter.Use(func(evt string, argv []interface{}, next func()) {
+		// Drops the "secret" events.
+		if evt == "secret" {
+			return
+		}
+		trace = append(trace, "inner")
+		next()
+	})
+
+	emitter.On("test", func(value int) error {
+		trace = append(trace, "listener")
+		return errors.New("failed")
+	})
+	emitter.On("secret", func() {
+		trace = append(trace, "secret listener")
+	})
+
+	err := emitter.Emit("test", 1)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, []string{"outer:test", "inner", "listener", "outer done"}, trace)
+
+	trace = nil
+	emitter.Emit("secret")
+	assert.Equal(t, []string{"outer:secret", "outer done"}, trace)
+}
+
+func TestEventEmitter_UseArguments(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	var counts = map[string]int{}
+	var args [][]interface{}
+
+	emitter.Use(func(evt string, argv []interface{}, next func()) {
+		counts[evt]++
+		args = append(args, argv)
+		next()
+	})
+
+	emitter.On("score", func(score int) {})
+	emitter.OnAny(func(evt string, score int) {})
+
+	emitter.Emit("score", 10)
+
+	// Per listener call, with the emitted arguments.
+	assert.Equal(t, map[string]int{"score": 2}, counts)
+	assert.Equal(t, [][]interface{}{{10}, {10}}, args)
+}
@@ -0,0 +1,104 @@
+package mediasoup
+
+import (
+	"sort"
+	"strings"
+)
+
+// Pattern listeners are added with an event name containing "*", which
+// matches any sequence of characters (e.g. "producer.*" or "*"), and are
+// called with the name of the emitted event followed by its arguments:
+//
+//	emitter.On("*", func(evt string, argv ...interface{}) {
+//		logger.Debugf("%s %v", evt, argv)
+//	})
+//
+// The private events ("@close"...) are only matched by the patterns starting
+// with "@".
+
+// eventListener is a listener of an emitted event, added with the given
+// event name or pattern.
+type eventListener struct {
+	key      string
+	listener *intervalListener
+}
+
+func isEventPattern(evt string) bool {
+	return strings.Contains(evt, "*")
+}
+
+// matchEventPattern returns whether the event name matches the pattern.
+func matchEventPattern(pattern, evt string) bool {
+	if isPrivateEvent(evt) && !isPrivateEvent(pattern) {
+		return false
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(evt, parts[0]) {
+		return false
+	}
+	evt = evt[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	middle := parts[1 : len(parts)-1]
+
+	for _, part := range middle {
+		idx := strings.Index(evt, part)
+		if idx < 0 {
+			return false
+		}
+		evt = evt[idx+len(part):]
+	}
+
+	return strings.HasSuffix(evt, last)
+}
+
+// OnAny adds a listener of every event, called with the name of the event
+// followed by its arguments. It is removed with OffAny.
+func (e *eventEmitter) OnAny(listener interface{}) {
+	e.AddListener("*", listener)
+}
+
+func (e *eventEmitter) OffAny(listener interface{}) {
+	e.RemoveListener("*", listener)
+}
+
+// eventListeners returns the listeners of the event, the ones of the
+// matching patterns following the ones of the event, with the lock held.
+func (e *eventEmitter) eventListeners(evt string) (listeners []eventListener) {
+	for _, listener := range e.evtListeners[evt] {
+		listeners = append(listeners, eventListener{key: evt, listener: listener})
+	}
+
+	if !e.hasPatterns || isEventPattern(evt) {
+		return
+	}
+
+	var patterns []string
+
+	for pattern, items := range e.evtListeners {
+		if len(items) > 0 && isEventPattern(pattern) && matchEventPattern(pattern, evt) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		for _, listener := range e.evtListeners[pattern] {
+			listeners = append(listeners, eventListener{key: pattern, listener: listener})
+		}
+	}
+
+	return
+}
+
+// args returns the arguments of the listener, prefixed with the name of the
+// event for pattern listeners (but EventHandlers, given the name).
+func (l eventListener) args(evt string, args *eventArgs) *eventArgs {
+	if l.key == evt || l.listener.handler {
+		return args
+	}
+
+	return &eventArgs{argv: append([]interface{}{evt}, args.argv...)}
+}
@@ -0,0 +1,72 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEventPattern(t *testing.T) {
+	assert.True(t, matchEventPattern("*", "newproducer"))
+	assert.True(t, matchEventPattern("producer.*", "producer.score"))
+	assert.True(t, matchEventPattern("*state*", "icestatechange"))
+	assert.True(t, matchEventPattern("ice*change", "icestatechange"))
+	assert.False(t, matchEventPattern("producer.*", "consumer.score"))
+	assert.False(t, matchEventPattern("*change", "changes"))
+	assert.False(t, matchEventPattern("*", "@close"))
+	assert.True(t, matchEventPattern("@*", "@close"))
+}
+
+func TestEventEmitter_PatternListeners(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	var exact []int
+	var events []string
+	var args [][]interface{}
+
+	emitter.On("producer.score", func(score int) {
+		exact = append(exact, score)
+	})
+	emitter.On("producer.*", func(evt string, argv ...interface{}) {
+		events = append(events, evt)
+		args = append(args, argv)
+	})
+
+	anyListener := func(evt string) {
+		events = append(events, "any:"+evt)
+	}
+	emitter.OnAny(anyListener)
+
+	emitter.Emit("producer.score", 7)
+	emitter.Emit("consumer.score", 3)
+	emitter.Emit("@close")
+
+	assert.Equal(t, []int{7}, exact)
+	assert.Equal(t, []string{"any:producer.score", "producer.score", "any:consumer.score"}, events)
+	assert.Equal(t, [][]interface{}{{7}}, args)
+
+	emitter.OffAny(anyListener)
+	events = nil
+
+	emitter.Emit("producer.pause")
+	assert.Equal(t, []string{"producer.pause"}, events)
+
+	// Once pattern listeners are removed from their pattern.
+	calls := 0
+	emitter.Once("*.close", func(evt string) { calls++ })
+	emitter.Emit("producer.close")
+	emitter.Emit("consumer.close")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, emitter.ListenerCount("*.close"))
+}
+
+func TestEventEmitter_SubscribePattern(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger())
+
+	ch, unsubscribe := emitter.Subscribe("*")
+	defer unsubscribe()
+
+	emitter.Emit("score", 10)
+
+	assert.Equal(t, []interface{}{"score", 10}, <-ch)
+}
@@ -0,0 +1,90 @@
+package mediasoup
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Number of events buffered by a subscription, the next ones being dropped
+// until the subscriber catches up.
+const eventSubscriptionBufferSize = 128
+
+// eventSubscription forwards the events to a channel, so that they can be
+// consumed in select loops (e.g. with the cancellation of a context).
+type eventSubscription struct {
+	evt    string
+	ch     chan []interface{}
+	mu     sync.Mutex
+	closed bool
+}
+
+func (s *eventSubscription) send(logger logrus.FieldLogger, argv []interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	// Copied, and not nil unlike what is received from the closed channel.
+	argv = append([]interface{}{}, argv...)
+
+	select {
+	case s.ch <- argv:
+	default:
+		logger.Warnf(`subscription of event "%s" is full, event dropped`, s.evt)
+	}
+}
+
+func (s *eventSubscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+/**
+ * Subscribe to the given event, e.g.:
+ *
+ *	ch, unsubscribe := producer.Subscribe("score")
+ *	defer unsubscribe()
+ *
+ *	for {
+ *		select {
+ *		case argv := <-ch:
+ *			score := argv[0].([]ProducerScore)
+ *		case <-ctx.Done():
+ *			return
+ *		}
+ *	}
+ *
+ * The channel buffers up to 128 events, the next ones being dropped (with a
+ * warning) until the subscriber catches up. It is closed by unsubscribe, not
+ * on the close of the entity, whose "close" events can be subscribed too.
+ */
+func (e *eventEmitter) Subscribe(evt string) (ch <-chan []interface{}, unsubscribe func()) {
+	subscription := &eventSubscription{
+		evt: evt,
+		ch:  make(chan []interface{}, eventSubscriptionBufferSize),
+	}
+
+	listener := newIntervalListener(func(argv ...interface{}) {
+		subscription.send(e.logger, argv)
+	})
+	e.addListeners(evt, listener)
+
+	var once sync.Once
+
+	unsubscribe = func() {
+		once.Do(func() {
+			e.removeItem(evt, listener)
+			subscription.close()
+		})
+	}
+
+	return subscription.ch, unsubscribe
+}
@@ -0,0 +1,61 @@
+package mediasoup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEmitter_Subscribe(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	ch, unsubscribe := emitter.Subscribe("test")
+	assert.Equal(t, 1, emitter.ListenerCount("test"))
+
+	emitter.Emit("test")
+	emitter.Emit("test", 1, "a")
+	emitter.Emit("test", nil, errors.New("error"))
+	emitter.Emit("other", 2)
+
+	assert.Equal(t, []interface{}{}, <-ch)
+	assert.Equal(t, []interface{}{1, "a"}, <-ch)
+	assert.Equal(t, []interface{}{nil, errors.New("error")}, <-ch)
+	assert.Len(t, ch, 0)
+
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 0, emitter.ListenerCount("test"))
+
+	emitter.Emit("test", 3)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestEventEmitter_SubscribeFull(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	ch, unsubscribe := emitter.Subscribe("test")
+	defer unsubscribe()
+
+	for i := 0; i < eventSubscriptionBufferSize+10; i++ {
+		emitter.Emit("test", i)
+	}
+	assert.Len(t, ch, eventSubscriptionBufferSize)
+	assert.Equal(t, []interface{}{0}, <-ch)
+}
+
+func TestEventEmitter_EmitVariadic(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var calls [][]interface{}
+	emitter.On("test", func(first int, rest ...interface{}) {
+		calls = append(calls, append([]interface{}{first}, rest...))
+	})
+	emitter.Emit("test")
+	emitter.Emit("test", 1)
+	emitter.Emit("test", 1, "a", nil)
+
+	assert.Equal(t, [][]interface{}{{0}, {1}, {1, "a", nil}}, calls)
+}
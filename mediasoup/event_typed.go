@@ -0,0 +1,202 @@
+package mediasoup
+
+import "context"
+
+// Event is an event whose listeners take a value of type T. Unlike the
+// listeners given to EventEmitter.On, whose arguments are checked when the
+// event is emitted, the typed ones are checked at compile time:
+//
+//	EventWebRtcTransportDtlsStateChange.On(transport, func(dtlsState string) {})
+type Event[T any] string
+
+// Event2 is an event whose listeners take two values.
+type Event2[A, B any] string
+
+// Signal is an event without value.
+type Signal string
+
+// Typed events of the entities, emitted by them and not by their observer.
+var (
+	EventWorkerDied = Event[error]("died")
+	// The same event, for the listeners taking its WorkerDiedInfo.
+	EventWorkerDiedInfo = Event[WorkerDiedInfo]("died")
+
+	EventRouterWorkerClose = Signal("workerclose")
+
+	EventTransportRouterClose      = Signal("routerclose")
+	EventTransportTrace            = Event[TransportTraceEventData]("trace")
+	EventTransportDegradedConsumer = Event2[*Consumer, RtpCapabilitiesReport]("degradedconsumer")
+
+	EventWebRtcTransportIceStateChange         = Event[string]("icestatechange")
+	EventWebRtcTransportIceSelectedTupleChange = Event[TransportTuple]("iceselectedtuplechange")
+	EventWebRtcTransportIceSelectedTupleLoss   = Event[TransportTuple]("iceselectedtupleloss")
+	EventWebRtcTransportIceConsentExpired      = Event[TransportTuple]("iceconsentexpired")
+	EventWebRtcTransportDtlsStateChange        = Event[string]("dtlsstatechange")
+
+	EventProducerTransportClose         = Signal("transportclose")
+	EventProducerScore                  = Event[[]ProducerScore]("score")
+	EventProducerVideoOrientationChange = Event[VideoOrientation]("videoorientationchange")
+
+	EventConsumerTransportClose     = Signal("transportclose")
+	EventConsumerProducerClose      = Signal("producerclose")
+	EventConsumerProducerPause      = Signal("producerpause")
+	EventConsumerProducerResume     = Signal("producerresume")
+	EventConsumerProducerReplace    = Event[string]("producerreplace")
+	EventConsumerScore              = Event[ConsumerScore]("score")
+	EventConsumerLayersChange       = Event[VideoLayer]("layerschange")
+	EventConsumerLayersStatusChange = Event[ConsumerLayersStatus]("layersstatuschange")
+	EventConsumerFirstMedia         = Signal("firstmedia")
+)
+
+// On adds the given listener, removed by calling off.
+func (evt Event[T]) On(emitter EventEmitter, listener func(value T)) (off func()) {
+	return addTypedListener(emitter, string(evt), false, func(value interface{}) {
+		v, _ := value.(T)
+		listener(v)
+	})
+}
+
+// Once adds the given listener, called at most once.
+func (evt Event[T]) Once(emitter EventEmitter, listener func(value T)) (off func()) {
+	return addTypedListener(emitter, string(evt), true, func(value interface{}) {
+		v, _ := value.(T)
+		listener(v)
+	})
+}
+
+// Emit emits the event safely (see EventEmitter.SafeEmit).
+func (evt Event[T]) Emit(emitter EventEmitter, value T) {
+	emitter.SafeEmit(string(evt), value)
+}
+
+// Await blocks until the event is emitted with a value matching the given
+// function (any value if nil), or until the context is done, e.g.:
+//
+//	EventWebRtcTransportDtlsStateChange.Await(ctx, transport, func(dtlsState string) bool {
+//		return dtlsState == "connected"
+//	})
+func (evt Event[T]) Await(ctx context.Context, emitter EventEmitter, match func(value T) bool) (value T, err error) {
+	ch, unsubscribe := emitter.Subscribe(string(evt))
+	defer unsubscribe()
+
+	for {
+		select {
+		case argv := <-ch:
+			value, _ = typedArg(argv).(T)
+
+			if match == nil || match(value) {
+				return value, nil
+			}
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// On adds the given listener, removed by calling off.
+func (evt Event2[A, B]) On(emitter EventEmitter, listener func(a A, b B)) (off func()) {
+	return addTypedListener(emitter, string(evt), false, func(a, b interface{}) {
+		va, _ := a.(A)
+		vb, _ := b.(B)
+		listener(va, vb)
+	})
+}
+
+// Once adds the given listener, called at most once.
+func (evt Event2[A, B]) Once(emitter EventEmitter, listener func(a A, b B)) (off func()) {
+	return addTypedListener(emitter, string(evt), true, func(a, b interface{}) {
+		va, _ := a.(A)
+		vb, _ := b.(B)
+		listener(va, vb)
+	})
+}
+
+// Emit emits the event safely (see EventEmitter.SafeEmit).
+func (evt Event2[A, B]) Emit(emitter EventEmitter, a A, b B) {
+	emitter.SafeEmit(string(evt), a, b)
+}
+
+// On adds the given listener, removed by calling off.
+func (evt Signal) On(emitter EventEmitter, listener func()) (off func()) {
+	return addTypedListener(emitter, string(evt), false, listener)
+}
+
+// Once adds the given listener, called at most once.
+func (evt Signal) Once(emitter EventEmitter, listener func()) (off func()) {
+	return addTypedListener(emitter, string(evt), true, listener)
+}
+
+// Emit emits the event safely (see EventEmitter.SafeEmit).
+func (evt Signal) Emit(emitter EventEmitter) {
+	emitter.SafeEmit(string(evt))
+}
+
+// addTypedListener adds the given wrapper of a typed listener, taking
+// interface{} arguments so that the values of the emitted event are converted
+// by the type assertions of the wrapper and not rejected by reflection.
+func addTypedListener(emitter EventEmitter, evt string, once bool, fn interface{}) (off func()) {
+	e, ok := emitter.(*eventEmitter)
+	if !ok {
+		if once {
+			emitter.Once(evt, fn)
+		} else {
+			emitter.On(evt, fn)
+		}
+		return func() { emitter.Off(evt, fn) }
+	}
+
+	// The wrappers sharing their code, they are removed by identity.
+	item := newIntervalListener(fn)
+	item.Once = once
+	e.addListeners(evt, item)
+
+	return func() { e.removeItem(evt, item) }
+}
+
+// Typed listeners of the entities, see the typed events.
+
+func (w *Worker) OnDied(listener func(err error)) (off func()) {
+	return EventWorkerDied.On(w, listener)
+}
+
+func (t *WebRtcTransport) OnIceStateChange(listener func(iceState string)) (off func()) {
+	return EventWebRtcTransportIceStateChange.On(t, listener)
+}
+
+func (t *WebRtcTransport) OnIceSelectedTupleChange(listener func(tuple TransportTuple)) (off func()) {
+	return EventWebRtcTransportIceSelectedTupleChange.On(t, listener)
+}
+
+func (t *WebRtcTransport) OnDtlsStateChange(listener func(dtlsState string)) (off func()) {
+	return EventWebRtcTransportDtlsStateChange.On(t, listener)
+}
+
+func (producer *Producer) OnScore(listener func(score []ProducerScore)) (off func()) {
+	return EventProducerScore.On(producer, listener)
+}
+
+func (consumer *Consumer) OnScore(listener func(score ConsumerScore)) (off func()) {
+	return EventConsumerScore.On(consumer, listener)
+}
+
+func (consumer *Consumer) OnLayersChange(listener func(layers VideoLayer)) (off func()) {
+	return EventConsumerLayersChange.On(consumer, listener)
+}
+
+func (consumer *Consumer) OnProducerClose(listener func()) (off func()) {
+	return EventConsumerProducerClose.On(consumer, listener)
+}
+
+func (consumer *Consumer) OnLayersStatusChange(listener func(status ConsumerLayersStatus)) (off func()) {
+	return EventConsumerLayersStatusChange.On(consumer, listener)
+}
+
+// typedArg returns the first argument of the event, nil if none.
+func typedArg(argv []interface{}) interface{} {
+	if len(argv) > 0 {
+		return argv[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package mediasoup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_On(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var states []string
+	off := EventWebRtcTransportDtlsStateChange.On(emitter, func(dtlsState string) {
+		states = append(states, dtlsState)
+	})
+	emitter.Emit("dtlsstatechange", "connecting")
+	EventWebRtcTransportDtlsStateChange.Emit(emitter, "connected")
+	assert.Equal(t, []string{"connecting", "connected"}, states)
+
+	off()
+	emitter.Emit("dtlsstatechange", "closed")
+	assert.Equal(t, []string{"connecting", "connected"}, states)
+	assert.Equal(t, 0, emitter.ListenerCount("dtlsstatechange"))
+}
+
+func TestEvent_Off(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var first, second int
+	off := EventConsumerScore.On(emitter, func(ConsumerScore) { first++ })
+	EventConsumerScore.On(emitter, func(ConsumerScore) { second++ })
+
+	// only the listener of off is removed, the wrappers sharing their code
+	off()
+	off()
+	EventConsumerScore.Emit(emitter, ConsumerScore{Producer: 10})
+	assert.Equal(t, 0, first)
+	assert.Equal(t, 1, second)
+}
+
+func TestEvent_Once(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var scores []ConsumerScore
+	EventConsumerScore.Once(emitter, func(score ConsumerScore) {
+		scores = append(scores, score)
+	})
+	emitter.Emit("score", ConsumerScore{Producer: 10})
+	emitter.Emit("score", ConsumerScore{Producer: 9})
+	assert.Equal(t, []ConsumerScore{{Producer: 10}}, scores)
+}
+
+func TestEvent_MismatchedValue(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var errs []error
+	EventWorkerDied.On(emitter, func(err error) {
+		errs = append(errs, err)
+	})
+	emitter.Emit("died")
+	emitter.Emit("died", nil)
+	emitter.Emit("died", "not an error")
+	emitter.Emit("died", errors.New("killed"))
+	assert.Equal(t, []error{nil, nil, nil, errors.New("killed")}, errs)
+}
+
+func TestEvent2_On(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	var consumers []*Consumer
+	var reports []RtpCapabilitiesReport
+	EventTransportDegradedConsumer.On(emitter, func(consumer *Consumer, report RtpCapabilitiesReport) {
+		consumers = append(consumers, consumer)
+		reports = append(reports, report)
+	})
+	consumer := &Consumer{}
+	EventTransportDegradedConsumer.Emit(emitter, consumer, RtpCapabilitiesReport{})
+	assert.Equal(t, []*Consumer{consumer}, consumers)
+	assert.Len(t, reports, 1)
+}
+
+func TestSignal_On(t *testing.T) {
+	emitter := NewEventEmitter(TypeLogger("eventEmitter"))
+
+	called := 0
+	off := EventConsumerProducerClose.On(emitter, func() { called++ })
+	EventConsumerProducerClose.Once(emitter, func() { called++ })
+	EventConsumerProducerClose.Emit(emitter)
+	emitter.Emit("producerclose")
+	off()
+	emitter.Emit("producerclose")
+	assert.Equal(t, 3, called)
+}
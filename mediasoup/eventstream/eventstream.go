@@ -0,0 +1,316 @@
+// Package eventstream streams the events of a mediasoup.TopologyStore to
+// WebSocket clients as JSON-RPC 2.0 notifications, so that external observers
+// (ops dashboards, recording controllers...) can react to them without
+// linking the library.
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/sirupsen/logrus"
+)
+
+// Notification is a JSON-RPC 2.0 notification streamed by the Handler, whose
+// method is "<kind>.<action>" (e.g. "producer.create",
+// "transport.dtlsstatechange").
+type Notification struct {
+	JsonRpc string       `json:"jsonrpc"`
+	Method  string       `json:"method"`
+	Params  NotifyParams `json:"params"`
+}
+
+type NotifyParams struct {
+	Seq       uint64      `json:"seq"`
+	Timestamp int64       `json:"timestamp"` // unix time in milliseconds
+	Id        string      `json:"id"`
+	ParentId  string      `json:"parentId,omitempty"`
+	RouterId  string      `json:"routerId,omitempty"`
+	Data      mediasoup.H `json:"data,omitempty"`
+}
+
+// Store is the source of the streamed events, a *mediasoup.TopologyStore.
+type Store interface {
+	State() mediasoup.TopologyState
+	Subscribe(seq uint64, bufferSize int) (events <-chan mediasoup.TopologyEvent, cancel func(), err error)
+}
+
+type Options struct {
+	// Events buffered per client, 1000 if not positive.
+	BufferSize int
+	// Maximum duration of a write to a client, 10s if not positive. Clients
+	// not reading in time are disconnected.
+	WriteTimeout time.Duration
+	// Whether the WebSocket handshake of the given request is accepted, the
+	// same origin ones only if nil.
+	CheckOrigin func(r *http.Request) bool
+}
+
+type Option func(o *Options)
+
+func WithBufferSize(bufferSize int) Option {
+	return func(o *Options) {
+		o.BufferSize = bufferSize
+	}
+}
+
+func WithWriteTimeout(writeTimeout time.Duration) Option {
+	return func(o *Options) {
+		o.WriteTimeout = writeTimeout
+	}
+}
+
+func WithCheckOrigin(checkOrigin func(r *http.Request) bool) Option {
+	return func(o *Options) {
+		o.CheckOrigin = checkOrigin
+	}
+}
+
+// Handler streams the events of a Store to WebSocket clients. Clients select
+// the events with query parameters, every given one having to match:
+//
+//	routerId=<id>            events of the given Router and of its entities
+//	kind=producer,consumer   events of the given entity kinds
+//	appData.<key>=<value>    events of the entities having the given top
+//	                         level appData value
+//	seq=<seq>                events following the given sequence number,
+//	                         to resume after a reconnection (events of the
+//	                         entities closed meanwhile are skipped)
+//
+// Clients not keeping up are disconnected with the 1013 (try again later)
+// close code, and should reconnect from the last received sequence number.
+type Handler struct {
+	logger   logrus.FieldLogger
+	store    Store
+	options  Options
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(store Store, options ...Option) *Handler {
+	logger := mediasoup.TypeLogger("EventStreamHandler")
+
+	logger.Debug("constructor()")
+
+	o := Options{}
+
+	for _, option := range options {
+		option(&o)
+	}
+
+	if o.BufferSize <= 0 {
+		o.BufferSize = 1000
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 10 * time.Second
+	}
+
+	return &Handler{
+		logger:  logger,
+		store:   store,
+		options: o,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: o.CheckOrigin,
+		},
+	}
+}
+
+// filter selects the streamed events.
+type filter struct {
+	routerId string
+	kinds    map[string]bool
+	appData  map[string]string
+}
+
+// entity is what a client stream remembers of an entity, to filter its later
+// events which don't repeat it.
+type entity struct {
+	routerId string
+	appData  interface{}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, seq, err := parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !websocket.IsWebSocketUpgrade(r) {
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		return
+	}
+
+	// Known entities, to filter the events which don't repeat their Router
+	// and appData.
+	state := h.store.State()
+	entities := make(map[string]entity, len(state.Entities))
+	byId := make(map[string]mediasoup.TopologyEntity, len(state.Entities))
+
+	for _, topologyEntity := range state.Entities {
+		byId[topologyEntity.Id] = topologyEntity
+	}
+	for _, topologyEntity := range state.Entities {
+		entities[topologyEntity.Id] = entity{
+			routerId: routerIdOf(byId, topologyEntity),
+			appData:  topologyEntity.Data["appData"],
+		}
+	}
+
+	if seq == nil {
+		seq = &state.Seq
+	}
+
+	events, cancel, err := h.store.Subscribe(*seq, h.options.BufferSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		cancel()
+		h.logger.Warnf("upgrade failed: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	h.logger.Debugf("client connected [addr:%s]", r.RemoteAddr)
+
+	// Reading the client messages, discarded, to handle the pings and the
+	// close.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	for event := range events {
+		current, known := entities[event.Id]
+
+		switch event.Action {
+		case "create":
+			current = entity{appData: event.Data["appData"]}
+			if event.Kind == "router" {
+				current.routerId = event.Id
+			} else if event.Kind != "worker" {
+				current.routerId = entities[event.ParentId].routerId
+			}
+			entities[event.Id] = current
+
+		case "close":
+			delete(entities, event.Id)
+		}
+
+		if !known && event.Action != "create" {
+			continue
+		}
+		if !filter.match(event.Kind, current) {
+			continue
+		}
+
+		data, _ := json.Marshal(Notification{
+			JsonRpc: "2.0",
+			Method:  event.Kind + "." + event.Action,
+			Params: NotifyParams{
+				Seq:       event.Seq,
+				Timestamp: event.Timestamp,
+				Id:        event.Id,
+				ParentId:  event.ParentId,
+				RouterId:  current.routerId,
+				Data:      event.Data,
+			},
+		})
+
+		conn.SetWriteDeadline(time.Now().Add(h.options.WriteTimeout))
+
+		if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			h.logger.Debugf("client disconnected [addr:%s]: %s", r.RemoteAddr, err)
+			cancel()
+			return
+		}
+	}
+
+	// Unsubscribed by the store because the client didn't keep up, or
+	// disconnected.
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "resubscribe"),
+		time.Now().Add(h.options.WriteTimeout))
+}
+
+func parseQuery(r *http.Request) (filter filter, seq *uint64, err error) {
+	query := r.URL.Query()
+
+	filter.routerId = query.Get("routerId")
+	filter.appData = make(map[string]string)
+
+	if kinds := query.Get("kind"); len(kinds) > 0 {
+		filter.kinds = make(map[string]bool)
+
+		for _, kind := range strings.Split(kinds, ",") {
+			filter.kinds[strings.TrimSpace(kind)] = true
+		}
+	}
+
+	for name, values := range query {
+		if strings.HasPrefix(name, "appData.") && len(values) > 0 {
+			filter.appData[strings.TrimPrefix(name, "appData.")] = values[0]
+		}
+	}
+
+	if value := query.Get("seq"); len(value) > 0 {
+		var s uint64
+		if _, err = fmt.Sscan(value, &s); err != nil {
+			err = mediasoup.NewTypeError("invalid seq %q", value)
+			return
+		}
+		seq = &s
+	}
+
+	return
+}
+
+func (filter filter) match(kind string, entity entity) bool {
+	if filter.kinds != nil && !filter.kinds[kind] {
+		return false
+	}
+	if len(filter.routerId) > 0 && filter.routerId != entity.routerId {
+		return false
+	}
+
+	for key, expected := range filter.appData {
+		value, ok := mediasoup.AppDataValue(entity.appData, key)
+		if !ok || fmt.Sprint(value) != expected {
+			return false
+		}
+	}
+
+	return true
+}
+
+// routerIdOf returns the id of the Router of the given entity, following its
+// parents.
+func routerIdOf(byId map[string]mediasoup.TopologyEntity, entity mediasoup.TopologyEntity) string {
+	for {
+		switch entity.Kind {
+		case "router":
+			return entity.Id
+		case "worker":
+			return ""
+		}
+
+		parent, ok := byId[entity.ParentId]
+		if !ok {
+			return ""
+		}
+
+		entity = parent
+	}
+}
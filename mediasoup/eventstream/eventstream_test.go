@@ -0,0 +1,218 @@
+package eventstream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore records the given events like a mediasoup.TopologyStore.
+type fakeStore struct {
+	mu          sync.Mutex
+	events      []mediasoup.TopologyEvent
+	entities    map[string]mediasoup.TopologyEntity
+	subscribers map[chan mediasoup.TopologyEvent]bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		entities:    make(map[string]mediasoup.TopologyEntity),
+		subscribers: make(map[chan mediasoup.TopologyEvent]bool),
+	}
+}
+
+func (s *fakeStore) record(kind, action, id, parentId string, data mediasoup.H) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := mediasoup.TopologyEvent{
+		Seq:      uint64(len(s.events) + 1),
+		Kind:     kind,
+		Action:   action,
+		Id:       id,
+		ParentId: parentId,
+		Data:     data,
+	}
+	s.events = append(s.events, event)
+
+	switch action {
+	case "create":
+		s.entities[id] = mediasoup.TopologyEntity{Kind: kind, Id: id, ParentId: parentId, Data: data}
+	case "close":
+		delete(s.entities, id)
+	}
+
+	// Unsubscribing the subscribers not keeping up.
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *fakeStore) State() (state mediasoup.TopologyState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.Seq = uint64(len(s.events))
+
+	for _, entity := range s.entities {
+		state.Entities = append(state.Entities, entity)
+	}
+
+	return
+}
+
+func (s *fakeStore) Subscribe(seq uint64, bufferSize int) (<-chan mediasoup.TopologyEvent, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan mediasoup.TopologyEvent, bufferSize+len(s.events))
+
+	for _, event := range s.events[seq:] {
+		ch <- event
+	}
+
+	s.subscribers[ch] = true
+
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.subscribers[ch] {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, cancel, nil
+}
+
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?" + query
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+
+	return conn
+}
+
+func readNotification(t *testing.T, conn *websocket.Conn) (notification Notification) {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, conn.ReadJSON(&notification))
+
+	return
+}
+
+func TestHandler_StreamsFilteredEvents(t *testing.T) {
+	store := newFakeStore()
+	store.record("worker", "create", "w", "", nil)
+	store.record("router", "create", "r1", "w", nil)
+	store.record("router", "create", "r2", "w", nil)
+	store.record("transport", "create", "t1", "r1", mediasoup.H{"appData": mediasoup.H{"peerId": "alice"}})
+	store.record("transport", "create", "t2", "r2", mediasoup.H{"appData": mediasoup.H{"peerId": "alice"}})
+
+	server := httptest.NewServer(NewHandler(store))
+	defer server.Close()
+
+	conn := dial(t, server, "routerId=r1&kind=producer&appData.peerId=alice")
+	defer conn.Close()
+
+	store.record("producer", "create", "p1", "t1", mediasoup.H{"appData": mediasoup.H{"peerId": "alice"}})
+	store.record("producer", "create", "p2", "t2", mediasoup.H{"appData": mediasoup.H{"peerId": "alice"}})
+	store.record("producer", "create", "p3", "t1", mediasoup.H{"appData": mediasoup.H{"peerId": "bob"}})
+	store.record("transport", "icestatechange", "t1", "", mediasoup.H{"iceState": "connected"})
+	store.record("producer", "pause", "p1", "", mediasoup.H{"paused": true})
+	store.record("producer", "close", "p1", "", nil)
+
+	notification := readNotification(t, conn)
+	assert.Equal(t, "2.0", notification.JsonRpc)
+	assert.Equal(t, "producer.create", notification.Method)
+	assert.Equal(t, "p1", notification.Params.Id)
+	assert.Equal(t, "t1", notification.Params.ParentId)
+	assert.Equal(t, "r1", notification.Params.RouterId)
+	assert.EqualValues(t, 6, notification.Params.Seq)
+
+	notification = readNotification(t, conn)
+	assert.Equal(t, "producer.pause", notification.Method)
+	assert.Equal(t, "p1", notification.Params.Id)
+	assert.Equal(t, "r1", notification.Params.RouterId)
+	assert.Equal(t, mediasoup.H{"paused": true}, notification.Params.Data)
+
+	notification = readNotification(t, conn)
+	assert.Equal(t, "producer.close", notification.Method)
+	assert.EqualValues(t, 11, notification.Params.Seq)
+
+	// Resuming, the events of p1 which is closed now are skipped.
+	resumed := dial(t, server, "seq=7&kind=producer")
+	defer resumed.Close()
+
+	store.record("producer", "create", "p4", "t2", nil)
+
+	notification = readNotification(t, resumed)
+	assert.Equal(t, "producer.create", notification.Method)
+	assert.Equal(t, "p3", notification.Params.Id)
+	assert.EqualValues(t, 8, notification.Params.Seq)
+
+	notification = readNotification(t, resumed)
+	assert.Equal(t, "p4", notification.Params.Id)
+	assert.Equal(t, "r2", notification.Params.RouterId)
+}
+
+func (s *fakeStore) subscribed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers) > 0
+}
+
+func TestHandler_WriteTimeout(t *testing.T) {
+	store := newFakeStore()
+
+	server := httptest.NewServer(NewHandler(store, WithWriteTimeout(50*time.Millisecond)))
+	defer server.Close()
+
+	// Never reading, the writes time out once the socket buffers are full,
+	// before the events buffer is.
+	conn := dial(t, server, "")
+	defer conn.Close()
+
+	payload := mediasoup.H{"payload": strings.Repeat("x", 64*1024)}
+	deadline := time.Now().Add(5 * time.Second)
+
+	for i := 0; store.subscribed(); i++ {
+		require.True(t, time.Now().Before(deadline), "client not disconnected")
+
+		if i < 500 {
+			store.record("producer", "create", "p", "", payload)
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	server := httptest.NewServer(NewHandler(newFakeStore()))
+	defer server.Close()
+
+	rsp, err := http.Get(server.URL + "?seq=abc")
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+
+	rsp, err = http.Get(server.URL)
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusUpgradeRequired, rsp.StatusCode)
+}
@@ -0,0 +1,252 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup/netstring"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorkerRequest is a request received by a fakeWorker.
+type fakeWorkerRequest struct {
+	Id       int64
+	Method   string
+	Internal internalData
+	Data     json.RawMessage
+}
+
+// fakeWorkerReply returns the data to accept the given request with, or the
+// error to reject it with.
+type fakeWorkerReply func(request fakeWorkerRequest) (interface{}, error)
+
+// errFakeWorkerNoResponse is returned by a fakeWorkerReply to leave the request
+// unanswered.
+var errFakeWorkerNoResponse = errors.New("no response")
+
+// fakeWorker is the worker side of a Channel. It records the requests and
+// answers them with the reply set for their method, or for "*", accepting them
+// with empty data if none.
+type fakeWorker struct {
+	channel    *Channel
+	newChannel func(conn net.Conn) *Channel
+	conn       net.Conn
+	released   chan struct{}
+	received   chan fakeWorkerRequest
+	writeMu    sync.Mutex
+	mu         sync.Mutex
+	replies    map[string]fakeWorkerReply
+	requests   []fakeWorkerRequest
+	// Index in requests of the first request not taken by takeRequests.
+	taken int
+}
+
+type fakeWorkerOption func(w *fakeWorker)
+
+// withFakeWorkerHeld makes the worker read no request until release is
+// called, blocking the writes of the Channel.
+func withFakeWorkerHeld() fakeWorkerOption {
+	return func(w *fakeWorker) {
+		w.released = make(chan struct{})
+	}
+}
+
+// withFakeWorkerChannel makes the worker use the Channel created by the given
+// function on the socket.
+func withFakeWorkerChannel(newChannel func(conn net.Conn) *Channel) fakeWorkerOption {
+	return func(w *fakeWorker) {
+		w.newChannel = newChannel
+	}
+}
+
+func newFakeWorker(t testing.TB, options ...fakeWorkerOption) *fakeWorker {
+	conn, workerConn := net.Pipe()
+
+	released := make(chan struct{})
+	close(released)
+
+	w := &fakeWorker{
+		newChannel: func(conn net.Conn) *Channel {
+			return NewChannel(conn, 0)
+		},
+		conn:     workerConn,
+		released: released,
+		received: make(chan fakeWorkerRequest, 1024),
+		replies:  make(map[string]fakeWorkerReply),
+	}
+
+	for _, option := range options {
+		option(w)
+	}
+
+	w.channel = w.newChannel(conn)
+	t.Cleanup(w.channel.Close)
+
+	go w.run()
+
+	return w
+}
+
+// newPoolTestWorker returns a Worker whose channel accepts every request.
+func newPoolTestWorker(t *testing.T, pid int) *Worker {
+	return newFakeWorker(t).worker(pid)
+}
+
+// worker returns a Worker using the Channel.
+func (w *fakeWorker) worker(pid int) *Worker {
+	return &Worker{
+		EventEmitter:  NewEventEmitter(AppLogger()),
+		pid:           pid,
+		logger:        TypeLogger("Worker"),
+		channel:       w.channel,
+		observer:      NewEventEmitter(AppLogger()),
+		routers:       make(map[string]*Router),
+		webRtcServers: make(map[string]*WebRtcServer),
+	}
+}
+
+// reply sets the reply to the requests of the given method, "*" for the
+// methods without one.
+func (w *fakeWorker) reply(method string, reply fakeWorkerReply) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.replies[method] = reply
+}
+
+// release makes a held worker read the requests.
+func (w *fakeWorker) release() {
+	close(w.released)
+}
+
+// requested returns the requests received so far.
+func (w *fakeWorker) requested() []fakeWorkerRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]fakeWorkerRequest(nil), w.requests...)
+}
+
+// takeRequests returns the requests received since the previous call.
+func (w *fakeWorker) takeRequests() []fakeWorkerRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	requests := append([]fakeWorkerRequest(nil), w.requests[w.taken:]...)
+	w.taken = len(w.requests)
+
+	return requests
+}
+
+// takeMethods returns the methods requested since the previous call.
+func (w *fakeWorker) takeMethods() []string {
+	var methods []string
+
+	for _, request := range w.takeRequests() {
+		methods = append(methods, request.Method)
+	}
+
+	return methods
+}
+
+// nextRequest returns the next received request, failing the test if none
+// within a second.
+func (w *fakeWorker) nextRequest(t testing.TB) fakeWorkerRequest {
+	select {
+	case request := <-w.received:
+		return request
+	case <-time.After(time.Second):
+		require.FailNow(t, "no request")
+		return fakeWorkerRequest{}
+	}
+}
+
+// notify sends the notification of the given event to the Channel.
+func (w *fakeWorker) notify(targetId, event string, data interface{}) {
+	notification := H{"targetId": targetId, "event": event}
+
+	if data != nil {
+		notification["data"] = data
+	}
+
+	payload, _ := json.Marshal(notification)
+
+	w.send(payload)
+}
+
+// send sends the given payload to the Channel.
+func (w *fakeWorker) send(payload []byte) {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	w.conn.Write(netstring.Encode(payload))
+}
+
+func (w *fakeWorker) run() {
+	<-w.released
+
+	decoder := netstring.NewDecoder()
+	buf := make([]byte, NS_PAYLOAD_MAX_LEN)
+
+	for {
+		n, err := w.conn.Read(buf)
+		if err != nil {
+			return
+		}
+		decoder.Feed(buf[:n])
+
+		for len(decoder.Result()) > 0 {
+			w.handle(<-decoder.Result())
+		}
+	}
+}
+
+func (w *fakeWorker) handle(payload []byte) {
+	var request fakeWorkerRequest
+
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	w.requests = append(w.requests, request)
+	reply, ok := w.replies[request.Method]
+	if !ok {
+		reply = w.replies["*"]
+	}
+	w.mu.Unlock()
+
+	// Not blocking the tests not waiting for the requests.
+	select {
+	case w.received <- request:
+	default:
+	}
+
+	var (
+		data interface{} = H{}
+		err  error
+	)
+
+	if reply != nil {
+		data, err = reply(request)
+	}
+
+	var response H
+
+	switch {
+	case err == errFakeWorkerNoResponse:
+		return
+	case err != nil:
+		response = H{"id": request.Id, "error": "Error", "reason": err.Error()}
+	default:
+		response = H{"id": request.Id, "accepted": true, "data": data}
+	}
+
+	payload, _ = json.Marshal(response)
+
+	w.send(payload)
+}
@@ -0,0 +1,98 @@
+package mediasoup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Time given to the library goroutines to exit before VerifyNoLeaks reports
+// them.
+const verifyNoLeaksTimeout = 5 * time.Second
+
+// Counters (*int64) of the running goroutines spawned by the library, by
+// name. The names being a few constants, the counters are never deleted, so
+// that spawning only takes an atomic increment on the notification path.
+var goroutineRegistry sync.Map
+
+// spawn runs fn in a goroutine accounted under the given name, with the given
+// pprof labels if enabled.
+func spawn(name string, fn func(), labels ...string) {
+	counter, ok := goroutineRegistry.Load(name)
+	if !ok {
+		counter, _ = goroutineRegistry.LoadOrStore(name, new(int64))
+	}
+	running := counter.(*int64)
+
+	atomic.AddInt64(running, 1)
+
+	go func() {
+		defer atomic.AddInt64(running, -1)
+
+		doWithProfileLabels(fn, append([]string{"mediasoup", name}, labels...)...)
+	}()
+}
+
+// Spawn runs fn in a goroutine accounted under the given name, like the
+// library ones, so that the packages built on the library (recorder...) are
+// checked by VerifyNoLeaks too.
+func Spawn(name string, fn func()) {
+	spawn(name, fn)
+}
+
+// LibraryGoroutines returns the number of goroutines spawned by the library
+// (channel readers, worker output readers, notifiers...) still running, by
+// name.
+func LibraryGoroutines() map[string]int {
+	running := make(map[string]int)
+
+	goroutineRegistry.Range(func(name, counter interface{}) bool {
+		if count := atomic.LoadInt64(counter.(*int64)); count > 0 {
+			running[name.(string)] = int(count)
+		}
+		return true
+	})
+
+	return running
+}
+
+// LeakTestingT is the part of testing.TB used by VerifyNoLeaks.
+type LeakTestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// VerifyNoLeaks fails the test if goroutines spawned by the library are still
+// running a few seconds after being called, which happens when Workers (or
+// notifiers, recorders...) are not closed. Typically deferred in tests or
+// called at the end of TestMain.
+func VerifyNoLeaks(t LeakTestingT) {
+	t.Helper()
+
+	deadline := time.Now().Add(verifyNoLeaksTimeout)
+
+	for {
+		running := LibraryGoroutines()
+		if len(running) == 0 {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			leaks := make([]string, 0, len(running))
+
+			for name, count := range running {
+				leaks = append(leaks, fmt.Sprintf("%s (%d)", name, count))
+			}
+			sort.Strings(leaks)
+
+			t.Errorf("leaked mediasoup goroutines: %s", strings.Join(leaks, ", "))
+
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
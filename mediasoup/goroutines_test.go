@@ -0,0 +1,31 @@
+package mediasoup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpawn_AccountsGoroutines(t *testing.T) {
+	release := make(chan struct{})
+
+	spawn("test.blocked", func() {
+		<-release
+	})
+	Spawn("test.blocked", func() {
+		<-release
+	})
+
+	assert.Equal(t, 2, LibraryGoroutines()["test.blocked"])
+
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+
+	for LibraryGoroutines()["test.blocked"] > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.NotContains(t, LibraryGoroutines(), "test.blocked")
+}
@@ -0,0 +1,147 @@
+package mediasoup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Valid header extension ids (two-byte header form).
+const (
+	minHeaderExtensionId = 1
+	maxHeaderExtensionId = 255
+	// Reserved in the one-byte header form, not given when remapping.
+	reservedHeaderExtensionId = 15
+)
+
+// RtpHeaderExtensionConflict is a header extension whose id conflicted with
+// another one, and how the conflict was resolved.
+type RtpHeaderExtensionConflict struct {
+	Uri string `json:"uri"`
+	// Id given by the endpoint.
+	Id int `json:"id"`
+	// Id used instead, zero if the header extension was dropped.
+	MappedId int    `json:"mappedId"`
+	Reason   string `json:"reason"`
+}
+
+func (c RtpHeaderExtensionConflict) String() string {
+	if c.MappedId == 0 {
+		return fmt.Sprintf(`header extension "%s" with id %d dropped: %s`, c.Uri, c.Id, c.Reason)
+	}
+
+	return fmt.Sprintf(`header extension "%s" remapped from id %d to %d: %s`, c.Uri, c.Id, c.MappedId, c.Reason)
+}
+
+// resolveProducerHeaderExtensions drops the header extensions of a Producer
+// which can't be told apart: invalid ids, URIs given twice and ids already
+// given to another URI. The ids of a Producer are chosen by the sender, so
+// they can't be remapped, and the first header extension wins.
+func resolveProducerHeaderExtensions(
+	exts []RtpHeaderExtension,
+) (resolved []RtpHeaderExtension, conflicts []RtpHeaderExtensionConflict) {
+	uris := make(map[int]string)
+	ids := make(map[string]int)
+
+	for _, ext := range exts {
+		conflict := RtpHeaderExtensionConflict{Uri: ext.Uri, Id: ext.Id}
+
+		if ext.Id < minHeaderExtensionId || ext.Id > maxHeaderExtensionId {
+			conflict.Reason = fmt.Sprintf("id not in [%d, %d]", minHeaderExtensionId, maxHeaderExtensionId)
+		} else if id, ok := ids[ext.Uri]; ok {
+			conflict.Reason = fmt.Sprintf("uri already given with id %d", id)
+		} else if uri, ok := uris[ext.Id]; ok {
+			conflict.Reason = fmt.Sprintf(`id already given to "%s"`, uri)
+		}
+
+		if len(conflict.Reason) > 0 {
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+
+		uris[ext.Id] = ext.Uri
+		ids[ext.Uri] = ext.Id
+		resolved = append(resolved, ext)
+	}
+
+	return
+}
+
+// resolveConsumerHeaderExtensions returns the header extensions of a Consumer
+// of the given kind supported by the remote capabilities, matched by URI, with
+// the ids preferred by the remote endpoint. When the remote endpoint prefers
+// the same id for several of them, the later ones keep the Router id if free,
+// else get the lowest free id.
+func resolveConsumerHeaderExtensions(
+	kind string, exts []RtpHeaderExtension, caps RtpCapabilities,
+) (resolved []RtpHeaderExtension, conflicts []RtpHeaderExtensionConflict) {
+	resolved = []RtpHeaderExtension{}
+	uris := make(map[int]string)
+
+	for _, ext := range exts {
+		var capExt *RtpHeaderExtension
+
+		for i := range caps.HeaderExtensions {
+			if matchHeaderExtensions(RtpHeaderExtension{Kind: kind, Uri: ext.Uri}, caps.HeaderExtensions[i]) {
+				capExt = &caps.HeaderExtensions[i]
+				break
+			}
+		}
+		if capExt == nil {
+			continue
+		}
+
+		id := capExt.PreferredId
+		if id == 0 {
+			id = ext.Id
+		}
+
+		if uri, ok := uris[id]; ok {
+			conflict := RtpHeaderExtensionConflict{
+				Uri:    ext.Uri,
+				Id:     id,
+				Reason: fmt.Sprintf(`id already given to "%s"`, uri),
+			}
+
+			if _, ok := uris[ext.Id]; !ok {
+				id = ext.Id
+			} else {
+				id = freeHeaderExtensionId(uris)
+			}
+
+			if id == 0 {
+				conflicts = append(conflicts, conflict)
+				continue
+			}
+
+			conflict.MappedId = id
+			conflicts = append(conflicts, conflict)
+		}
+
+		uris[id] = ext.Uri
+		ext.Id = id
+		resolved = append(resolved, ext)
+	}
+
+	return
+}
+
+// freeHeaderExtensionId returns the lowest id not in the given ones, zero if
+// none.
+func freeHeaderExtensionId(uris map[int]string) int {
+	for id := minHeaderExtensionId; id <= maxHeaderExtensionId; id++ {
+		if _, ok := uris[id]; !ok && id != reservedHeaderExtensionId {
+			return id
+		}
+	}
+
+	return 0
+}
+
+// codecsKind returns the kind of the given codecs, from their mime type.
+func codecsKind(codecs []RtpCodecCapability) string {
+	if len(codecs) == 0 {
+		return ""
+	}
+
+	return strings.ToLower(strings.SplitN(codecs[0].MimeType, "/", 2)[0])
+}
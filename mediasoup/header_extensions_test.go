@@ -0,0 +1,91 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveProducerHeaderExtensions(t *testing.T) {
+	resolved, conflicts := resolveProducerHeaderExtensions([]RtpHeaderExtension{
+		{Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", Id: 1},
+		{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 1},
+		{Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", Id: 2},
+		{Uri: "urn:3gpp:video-orientation", Id: 0},
+		{Uri: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time", Id: 3},
+	})
+
+	assert.Equal(t, []RtpHeaderExtension{
+		{Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", Id: 1},
+		{Uri: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time", Id: 3},
+	}, resolved)
+
+	if assert.Len(t, conflicts, 3) {
+		assert.Equal(t, "urn:ietf:params:rtp-hdrext:toffset", conflicts[0].Uri)
+		assert.Equal(t, 0, conflicts[0].MappedId)
+		assert.Equal(t, "urn:ietf:params:rtp-hdrext:sdes:mid", conflicts[1].Uri)
+		assert.Equal(t, 2, conflicts[1].Id)
+		assert.Equal(t, "urn:3gpp:video-orientation", conflicts[2].Uri)
+	}
+}
+
+func TestResolveConsumerHeaderExtensions(t *testing.T) {
+	exts := []RtpHeaderExtension{
+		{Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", Id: 1},
+		{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 2},
+		{Uri: "urn:3gpp:video-orientation", Id: 4},
+		{Uri: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time", Id: 5},
+	}
+
+	caps := RtpCapabilities{
+		HeaderExtensions: []RtpHeaderExtension{
+			// Matched by URI, not by id.
+			{Kind: "video", Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", PreferredId: 9},
+			{Kind: "video", Uri: "urn:ietf:params:rtp-hdrext:toffset", PreferredId: 9},
+			{Kind: "video", Uri: "urn:3gpp:video-orientation", PreferredId: 9},
+			{Kind: "audio", Uri: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time", PreferredId: 3},
+		},
+	}
+
+	resolved, conflicts := resolveConsumerHeaderExtensions("video", exts, caps)
+
+	assert.Equal(t, []RtpHeaderExtension{
+		{Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", Id: 9},
+		{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 2},
+		{Uri: "urn:3gpp:video-orientation", Id: 4},
+	}, resolved)
+
+	assert.Equal(t, []RtpHeaderExtensionConflict{
+		{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 9, MappedId: 2, Reason: `id already given to "urn:ietf:params:rtp-hdrext:sdes:mid"`},
+		{Uri: "urn:3gpp:video-orientation", Id: 9, MappedId: 4, Reason: `id already given to "urn:ietf:params:rtp-hdrext:sdes:mid"`},
+	}, conflicts)
+
+	// The Router id is taken too.
+	exts = []RtpHeaderExtension{
+		{Uri: "urn:ietf:params:rtp-hdrext:sdes:mid", Id: 1},
+		{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 1},
+	}
+	caps.HeaderExtensions[0].PreferredId = 1
+	caps.HeaderExtensions[1].PreferredId = 1
+
+	resolved, conflicts = resolveConsumerHeaderExtensions("video", exts, caps)
+
+	assert.Equal(t, 2, resolved[1].Id)
+	if assert.Len(t, conflicts, 1) {
+		assert.Equal(t, 2, conflicts[0].MappedId)
+	}
+}
+
+func TestFreeHeaderExtensionId(t *testing.T) {
+	uris := make(map[int]string)
+
+	for id := minHeaderExtensionId; id < reservedHeaderExtensionId; id++ {
+		uris[id] = "uri"
+	}
+	assert.Equal(t, reservedHeaderExtensionId+1, freeHeaderExtensionId(uris))
+
+	for id := minHeaderExtensionId; id <= maxHeaderExtensionId; id++ {
+		uris[id] = "uri"
+	}
+	assert.Equal(t, 0, freeHeaderExtensionId(uris))
+}
@@ -0,0 +1,74 @@
+package mediasoup
+
+import (
+	"runtime"
+	"time"
+)
+
+// Health is a snapshot of the health of a worker and of the Go side driving
+// it, e.g. served by a health endpoint or sampled by a dashboard.
+type Health struct {
+	Timestamp int64 `json:"timestamp"` // unix time in milliseconds
+	// All the goroutines of the process, and the ones spawned by the library
+	// by name.
+	Goroutines        int            `json:"goroutines"`
+	LibraryGoroutines map[string]int `json:"libraryGoroutines"`
+	// Requests to the worker waiting for their response.
+	PendingRequests int `json:"pendingRequests"`
+	// Events queued by the asynchronous emitters, not dispatched yet.
+	EventQueueDepth int `json:"eventQueueDepth"`
+	Pid             int `json:"pid"`
+	// Whether the worker is running and answering requests.
+	Alive       bool `json:"alive"`
+	RouterCount int  `json:"routerCount"`
+	// Resource usage of the worker process, nil if not alive or not supported
+	// by the worker.
+	ResourceUsage *WorkerResourceUsage `json:"resourceUsage,omitempty"`
+	// Why the worker is not alive.
+	Error string `json:"error,omitempty"`
+}
+
+// Healthz returns the health of the worker, requesting its resource usage
+// (or a dump if not supported by the worker) to check it is alive.
+func (w *Worker) Healthz() Health {
+	w.logger.Debugln("healthz()")
+
+	w.routersLocker.Lock()
+	routerCount := len(w.routers)
+	w.routersLocker.Unlock()
+
+	health := Health{
+		Timestamp:         time.Now().UnixNano() / int64(time.Millisecond),
+		Goroutines:        runtime.NumGoroutine(),
+		LibraryGoroutines: LibraryGoroutines(),
+		PendingRequests:   w.channel.pendingRequests(),
+		EventQueueDepth:   QueuedEventDispatches(),
+		Pid:               w.pid,
+		RouterCount:       routerCount,
+	}
+
+	if w.Closed() {
+		health.Error = "worker closed"
+		return health
+	}
+
+	var err error
+
+	if workerSupports(w.version, WorkerFeatureResourceUsage) {
+		var usage WorkerResourceUsage
+
+		if usage, err = w.GetResourceUsage(); err == nil {
+			health.ResourceUsage = &usage
+		}
+	} else {
+		_, err = w.Dump()
+	}
+
+	if err != nil {
+		health.Error = err.Error()
+	} else {
+		health.Alive = true
+	}
+
+	return health
+}
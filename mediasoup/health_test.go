@@ -0,0 +1,74 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerHealthz(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+
+	_, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	health := worker.Healthz()
+	assert.True(t, health.Alive)
+	assert.Empty(t, health.Error)
+	assert.Equal(t, 1, health.Pid)
+	assert.Equal(t, 1, health.RouterCount)
+	assert.NotNil(t, health.ResourceUsage)
+	assert.True(t, health.Goroutines > 0)
+	assert.Contains(t, health.LibraryGoroutines, "channel.readLoop")
+	assert.Equal(t, 0, health.PendingRequests)
+
+	data, err := json.Marshal(health)
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Contains(t, fields, "eventQueueDepth")
+	assert.Contains(t, fields, "resourceUsage")
+
+	// Resource usage not supported by the worker.
+	worker.version = "3.5.0"
+
+	health = worker.Healthz()
+	assert.True(t, health.Alive)
+	assert.Nil(t, health.ResourceUsage)
+
+	worker.Close()
+
+	health = worker.Healthz()
+	assert.False(t, health.Alive)
+	assert.Equal(t, "worker closed", health.Error)
+}
+
+func TestQueuedEventDispatches(t *testing.T) {
+	emitter := NewEventEmitter(AppLogger(), WithEmitterAsync(EmitterDispatchOptions{PoolSize: 1}))
+
+	release := make(chan struct{})
+	done := make(chan struct{}, 3)
+	emitter.On("test", func() {
+		<-release
+		done <- struct{}{}
+	})
+
+	queued := QueuedEventDispatches()
+
+	for i := 0; i < 3; i++ {
+		emitter.Emit("test")
+	}
+	// The first one is dispatched, the others are waiting.
+	assert.Equal(t, queued+2, QueuedEventDispatches())
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+	assert.Equal(t, queued, QueuedEventDispatches())
+}
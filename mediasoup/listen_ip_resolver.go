@@ -0,0 +1,110 @@
+package mediasoup
+
+import (
+	"fmt"
+	"net"
+)
+
+// ListenIpResolver converts the host of a listen IP, being an IP, a network
+// interface name ("eth0", "ens5") or a hostname, into an IP.
+type ListenIpResolver func(host string) (ip string, err error)
+
+// ResolveListenIp is the default ListenIpResolver. An IP is returned as is, a
+// network interface resolves to its first IPv4 address (else IPv6) and any
+// other host is looked up in DNS. Link-local addresses are ignored.
+func ResolveListenIp(host string) (ip string, err error) {
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+
+	if iface, ifaceErr := net.InterfaceByName(host); ifaceErr == nil {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", err
+		}
+
+		ips := make([]net.IP, 0, len(addrs))
+
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+
+		if ip := preferredListenIp(ips); ip != nil {
+			return ip.String(), nil
+		}
+
+		return "", NewTypeError(`network interface "%s" has no usable address`, host)
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return "", NewTypeError(`cannot resolve listen host "%s": %s`, host, err)
+	}
+
+	if ip := preferredListenIp(ips); ip != nil {
+		return ip.String(), nil
+	}
+
+	return "", NewTypeError(`listen host "%s" has no usable address`, host)
+}
+
+func preferredListenIp(ips []net.IP) net.IP {
+	var ipv6 net.IP
+
+	for _, ip := range ips {
+		if ip.IsLinkLocalUnicast() {
+			continue
+		}
+		if ip.To4() != nil {
+			return ip
+		}
+		if ipv6 == nil {
+			ipv6 = ip
+		}
+	}
+
+	return ipv6
+}
+
+// resolveListenIps returns a copy of the given listen IPs with their hosts
+// converted into IPs by the given resolver, the listen IPs being named field
+// in the errors.
+func resolveListenIps(
+	resolver ListenIpResolver, field string, listenIps ...ListenIp,
+) (resolved []ListenIp, err error) {
+	resolved = make([]ListenIp, len(listenIps))
+
+	for i, listenIp := range listenIps {
+		if resolved[i], err = resolveListenIp(resolver, fmt.Sprintf("%s[%d]", field, i), listenIp); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// resolveListenIp returns the given listen IP with its host converted into an
+// IP by the given resolver, the listen IP being named field in the errors.
+func resolveListenIp(resolver ListenIpResolver, field string, listenIp ListenIp) (ListenIp, error) {
+	if len(listenIp.Ip) == 0 || net.ParseIP(listenIp.Ip) != nil {
+		return listenIp, nil
+	}
+
+	if resolver == nil {
+		resolver = ResolveListenIp
+	}
+
+	ip, err := resolver(listenIp.Ip)
+	if err != nil {
+		return listenIp, NewValidationError(field+".Ip", "invalid address: %s", err)
+	}
+	if net.ParseIP(ip) == nil {
+		return listenIp, NewValidationError(field+".Ip", `invalid address "%s" resolved from "%s"`, ip, listenIp.Ip)
+	}
+
+	listenIp.Ip = ip
+
+	return listenIp, nil
+}
@@ -0,0 +1,56 @@
+package mediasoup
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveListenIp(t *testing.T) {
+	ip, err := ResolveListenIp("10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", ip)
+
+	ip, err = ResolveListenIp("::1")
+	assert.NoError(t, err)
+	assert.Equal(t, "::1", ip)
+
+	if iface, err := net.InterfaceByName("lo"); err == nil && iface.Flags&net.FlagUp != 0 {
+		ip, err = ResolveListenIp("lo")
+		assert.NoError(t, err)
+		assert.Equal(t, "127.0.0.1", ip)
+	}
+
+	_, err = ResolveListenIp("invalid.invalid")
+	assert.Error(t, err)
+}
+
+func TestResolveListenIps(t *testing.T) {
+	resolver := func(host string) (string, error) {
+		if host == "media0" {
+			return "192.168.1.10", nil
+		}
+		return "", errors.New("unknown host")
+	}
+
+	listenIps := []ListenIp{
+		{Ip: "media0", AnnouncedIp: "1.2.3.4"},
+		{Ip: "127.0.0.1"},
+	}
+
+	resolved, err := resolveListenIps(resolver, "RouterSettings.ListenIps", listenIps...)
+	require.NoError(t, err)
+	assert.Equal(t, []ListenIp{
+		{Ip: "192.168.1.10", AnnouncedIp: "1.2.3.4"},
+		{Ip: "127.0.0.1"},
+	}, resolved)
+	// The given listen IPs are left untouched.
+	assert.Equal(t, "media0", listenIps[0].Ip)
+
+	_, err = resolveListenIps(resolver, "RouterSettings.ListenIps", listenIps[1], ListenIp{Ip: "media1"})
+	require.IsType(t, ValidationError{}, err)
+	assert.Equal(t, "RouterSettings.ListenIps[1].Ip", err.(ValidationError).Field)
+}
@@ -0,0 +1,170 @@
+package mediasoup
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// ListFilter selects the entities returned by listings.
+type ListFilter struct {
+	// Media kind ("audio" or "video"), any if empty. Ignored by
+	// ListTransports.
+	Kind string
+	// Top level appData values the entities must have, compared through
+	// their JSON representation.
+	AppData H
+}
+
+// ListPage selects a page of a listing, whose entities are ordered by id so
+// that pages are stable while entities come and go.
+type ListPage struct {
+	// Id of the last entity of the previous page, from the start if empty.
+	After string
+	// Maximum number of entities, 100 if not positive.
+	Limit int
+}
+
+// ListProducers returns the Producers of the Router matching the given
+// filter, and the After value of the next page, empty if it is the last one.
+func (router *Router) ListProducers(filter ListFilter, page ListPage) (producers []*Producer, next string) {
+	router.logger.Debug("listProducers()")
+
+	matched := make(map[string]*Producer)
+	ids := []string{}
+
+	for _, producer := range router.getProducers() {
+		if filter.match(producer.Kind(), producer.AppData()) {
+			matched[producer.Id()] = producer
+			ids = append(ids, producer.Id())
+		}
+	}
+
+	ids, next = paginateIds(ids, page)
+
+	for _, id := range ids {
+		producers = append(producers, matched[id])
+	}
+
+	return
+}
+
+// ListConsumers returns the Consumers of the Router matching the given
+// filter, and the After value of the next page, empty if it is the last one.
+func (router *Router) ListConsumers(filter ListFilter, page ListPage) (consumers []*Consumer, next string) {
+	router.logger.Debug("listConsumers()")
+
+	matched := make(map[string]*Consumer)
+	ids := []string{}
+
+	for _, transport := range router.getTransports() {
+		base := baseTransportOf(transport)
+		if base == nil {
+			continue
+		}
+
+		for _, consumer := range base.getConsumers() {
+			if filter.match(consumer.Kind(), consumer.AppData()) {
+				matched[consumer.Id()] = consumer
+				ids = append(ids, consumer.Id())
+			}
+		}
+	}
+
+	ids, next = paginateIds(ids, page)
+
+	for _, id := range ids {
+		consumers = append(consumers, matched[id])
+	}
+
+	return
+}
+
+// ListTransports returns the Transports of the Router matching the given
+// filter, and the After value of the next page, empty if it is the last one.
+func (router *Router) ListTransports(filter ListFilter, page ListPage) (transports []Transport, next string) {
+	router.logger.Debug("listTransports()")
+
+	// Transports have no kind.
+	filter.Kind = ""
+
+	matched := make(map[string]Transport)
+	ids := []string{}
+
+	for _, transport := range router.getTransports() {
+		if filter.match("", transport.AppData()) {
+			matched[transport.Id()] = transport
+			ids = append(ids, transport.Id())
+		}
+	}
+
+	ids, next = paginateIds(ids, page)
+
+	for _, id := range ids {
+		transports = append(transports, matched[id])
+	}
+
+	return
+}
+
+func (filter ListFilter) match(kind string, appData interface{}) bool {
+	if len(filter.Kind) > 0 && filter.Kind != kind {
+		return false
+	}
+
+	for key, expected := range filter.AppData {
+		value, ok := AppDataValue(appData, key)
+		if !ok {
+			return false
+		}
+
+		expectedJSON, err1 := json.Marshal(expected)
+		valueJSON, err2 := json.Marshal(value)
+
+		if err1 != nil || err2 != nil || !bytes.Equal(expectedJSON, valueJSON) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// paginateIds sorts the given ids and returns the ones within the given page.
+func paginateIds(ids []string, page ListPage) ([]string, string) {
+	var next string
+
+	sort.Strings(ids)
+
+	if len(page.After) > 0 {
+		ids = ids[sort.SearchStrings(ids, page.After):]
+
+		if len(ids) > 0 && ids[0] == page.After {
+			ids = ids[1:]
+		}
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+		next = ids[limit-1]
+	}
+
+	return ids, next
+}
+
+func baseTransportOf(transport Transport) *baseTransport {
+	switch t := transport.(type) {
+	case *WebRtcTransport:
+		return t.baseTransport
+	case *PlainRtpTransport:
+		return t.baseTransport
+	case *PipeTransport:
+		return t.baseTransport
+	}
+
+	return nil
+}
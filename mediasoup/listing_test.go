@@ -0,0 +1,71 @@
+package mediasoup
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterListProducers_Succeeds(t *testing.T) {
+	router, transport := setupWebRtcTest(t)
+
+	var ids []string
+
+	for i, name := range []string{"alice", "bob", "carol"} {
+		params := audioProducerParameters
+		params.RtpParameters.Mid = name
+		params.RtpParameters.Encodings = []RtpEncoding{{Ssrc: uint32(1000 + i)}}
+		params.AppData = H{"peer": name, "room": "A"}
+
+		producer, err := transport.Produce(params)
+		require.NoError(t, err)
+
+		ids = append(ids, producer.Id())
+	}
+
+	video, err := transport.Produce(videoProducerParameters)
+	require.NoError(t, err)
+
+	producers, next := router.ListProducers(ListFilter{Kind: "audio"}, ListPage{Limit: 2})
+	require.Len(t, producers, 2)
+	assert.Equal(t, producers[1].Id(), next)
+
+	remaining, next := router.ListProducers(ListFilter{Kind: "audio"}, ListPage{After: next, Limit: 2})
+	require.Len(t, remaining, 1)
+	assert.Empty(t, next)
+
+	listed := []string{}
+	for _, producer := range append(producers, remaining...) {
+		listed = append(listed, producer.Id())
+	}
+	assert.ElementsMatch(t, ids, listed)
+	assert.True(t, sort.StringsAreSorted(listed))
+
+	producers, _ = router.ListProducers(ListFilter{AppData: H{"peer": "bob"}}, ListPage{})
+	require.Len(t, producers, 1)
+	assert.Equal(t, ids[1], producers[0].Id())
+
+	producers, _ = router.ListProducers(ListFilter{Kind: "video"}, ListPage{})
+	require.Len(t, producers, 1)
+	assert.Equal(t, video.Id(), producers[0].Id())
+
+	transports, _ := router.ListTransports(ListFilter{Kind: "audio"}, ListPage{})
+	assert.Len(t, transports, 1)
+}
+
+func TestPaginateIds(t *testing.T) {
+	ids, next := paginateIds([]string{"d", "b", "a", "c"}, ListPage{Limit: 2})
+	assert.Equal(t, []string{"a", "b"}, ids)
+	assert.Equal(t, "b", next)
+
+	ids, next = paginateIds([]string{"d", "b", "a", "c"}, ListPage{After: next, Limit: 2})
+	assert.Equal(t, []string{"c", "d"}, ids)
+	assert.Empty(t, next)
+
+	// The entity of the previous page may be gone.
+	ids, next = paginateIds([]string{"d", "a", "c"}, ListPage{After: "b"})
+	assert.Equal(t, []string{"c", "d"}, ids)
+	assert.Empty(t, next)
+}
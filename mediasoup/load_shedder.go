@@ -0,0 +1,423 @@
+package mediasoup
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LoadShedAction is how a Consumer is shed by the LoadShedder.
+type LoadShedAction string
+
+const (
+	// Pause the Consumer.
+	LoadShedPause LoadShedAction = "pause"
+	// Prefer the lowest spatial layer of simulcast and SVC Consumers, the
+	// other ones being paused.
+	LoadShedDowngrade LoadShedAction = "downgrade"
+)
+
+// LoadShedderOptions to shed the Consumers of the Workers on CPU pressure.
+type LoadShedderOptions struct {
+	// Interval of the periodic check started by Start().
+	Interval time.Duration
+	// CPU usage above which Consumers are shed, and below which they are
+	// restored, the gap preventing oscillations.
+	MaxCpuPercent     float64
+	RecoverCpuPercent float64
+	Action            LoadShedAction
+	// Number of Consumers shed (or restored) per Worker and per check.
+	Step int
+	// Top level appData key of the priority of the Consumers, the lowest
+	// priorities being shed first. Consumers without priority have 0.
+	PriorityKey string
+	// CPU usage of the worker process (100 for a whole core), negative if
+	// unknown. Sampled from the resource usage of the worker by default.
+	CpuPercent func(worker *Worker) float64
+}
+
+type LoadShedderOption func(o *LoadShedderOptions)
+
+func WithLoadShedInterval(interval time.Duration) LoadShedderOption {
+	return func(o *LoadShedderOptions) {
+		o.Interval = interval
+	}
+}
+
+func WithLoadShedThresholds(maxCpuPercent, recoverCpuPercent float64) LoadShedderOption {
+	return func(o *LoadShedderOptions) {
+		o.MaxCpuPercent = maxCpuPercent
+		o.RecoverCpuPercent = recoverCpuPercent
+	}
+}
+
+func WithLoadShedAction(action LoadShedAction, step int) LoadShedderOption {
+	return func(o *LoadShedderOptions) {
+		o.Action = action
+		o.Step = step
+	}
+}
+
+func WithLoadShedPriorityKey(key string) LoadShedderOption {
+	return func(o *LoadShedderOptions) {
+		o.PriorityKey = key
+	}
+}
+
+func WithLoadShedCpuPercent(cpuPercent func(worker *Worker) float64) LoadShedderOption {
+	return func(o *LoadShedderOptions) {
+		o.CpuPercent = cpuPercent
+	}
+}
+
+// ShedConsumer is a Consumer shed by the LoadShedder.
+type ShedConsumer struct {
+	Consumer *Consumer
+	Priority float64
+	// The action applied, LoadShedPause for the "simple" Consumers with
+	// LoadShedDowngrade.
+	Action LoadShedAction
+	// Preferred layers before the downgrade, restored with the Consumer (the
+	// highest layers if nil).
+	PreviousLayers *ConsumerPreferredLayers
+}
+
+// LoadShed is emitted with "shed" and "restore".
+type LoadShed struct {
+	Worker     *Worker
+	CpuPercent float64
+	Consumers  []ShedConsumer
+}
+
+// LoadShedder degrades the lowest priority video Consumers of the Workers
+// whose CPU usage is too high, instead of letting the quality of every
+// Consumer collapse, and restores them once the CPU usage is back to normal.
+// Audio Consumers are never shed.
+type LoadShedder struct {
+	EventEmitter
+	mu      sync.Mutex
+	logger  logrus.FieldLogger
+	options LoadShedderOptions
+	workers []*Worker
+	// Shed Consumers by Worker, in shedding order.
+	shed   map[*Worker][]ShedConsumer
+	stopCh chan struct{}
+}
+
+/**
+ * NewLoadShedder
+ *
+ * @emits {shed: LoadShed} shed
+ * @emits {shed: LoadShed} restore
+ */
+func NewLoadShedder(options ...LoadShedderOption) (*LoadShedder, error) {
+	logger := TypeLogger("LoadShedder")
+
+	logger.Debug("constructor()")
+
+	opts := LoadShedderOptions{
+		Interval:          5 * time.Second,
+		MaxCpuPercent:     85,
+		RecoverCpuPercent: 60,
+		Action:            LoadShedDowngrade,
+		Step:              5,
+		PriorityKey:       "priority",
+		CpuPercent:        newCpuSampler().cpuPercent,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.Interval <= 0 {
+		return nil, NewValidationError("LoadShedderOptions.Interval", "must be positive")
+	}
+	if opts.RecoverCpuPercent >= opts.MaxCpuPercent {
+		return nil, NewValidationError("LoadShedderOptions.RecoverCpuPercent",
+			"must be less than MaxCpuPercent (%v)", opts.MaxCpuPercent)
+	}
+	if opts.Action != LoadShedPause && opts.Action != LoadShedDowngrade {
+		return nil, NewValidationError("LoadShedderOptions.Action", `unknown action "%s"`, opts.Action)
+	}
+	if opts.Step <= 0 {
+		return nil, NewValidationError("LoadShedderOptions.Step", "must be positive")
+	}
+
+	return &LoadShedder{
+		EventEmitter: NewEventEmitter(logger),
+		logger:       logger,
+		options:      opts,
+		shed:         make(map[*Worker][]ShedConsumer),
+	}, nil
+}
+
+// AddWorker adds the given Worker to the checked ones, until it is closed.
+func (s *LoadShedder) AddWorker(worker *Worker) {
+	s.mu.Lock()
+	s.workers = append(s.workers, worker)
+	s.mu.Unlock()
+
+	worker.Observer().On("close", func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i, w := range s.workers {
+			if w == worker {
+				s.workers = append(s.workers[:i], s.workers[i+1:]...)
+				break
+			}
+		}
+		delete(s.shed, worker)
+	})
+}
+
+// Start checking the Workers periodically.
+func (s *LoadShedder) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopCh != nil {
+		return
+	}
+
+	stopCh := make(chan struct{})
+	s.stopCh = stopCh
+
+	spawn("loadShedder", func() {
+		ticker := time.NewTicker(s.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				s.Run()
+			}
+		}
+	})
+}
+
+// Stop the periodic check, the shed Consumers staying shed.
+func (s *LoadShedder) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopCh != nil {
+		close(s.stopCh)
+		s.stopCh = nil
+	}
+}
+
+// Run checks the Workers once, shedding or restoring Consumers.
+func (s *LoadShedder) Run() {
+	s.mu.Lock()
+	workers := make([]*Worker, len(s.workers))
+	copy(workers, s.workers)
+	s.mu.Unlock()
+
+	for _, worker := range workers {
+		cpuPercent := s.options.CpuPercent(worker)
+
+		switch {
+		case cpuPercent < 0:
+		case cpuPercent > s.options.MaxCpuPercent:
+			s.shedConsumers(worker, cpuPercent, workerConsumers(worker))
+		case cpuPercent < s.options.RecoverCpuPercent:
+			s.restoreConsumers(worker, cpuPercent)
+		}
+	}
+}
+
+// Shed returns the Consumers of the given Worker currently shed.
+func (s *LoadShedder) Shed(worker *Worker) []ShedConsumer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]ShedConsumer(nil), s.shed[worker]...)
+}
+
+// shedConsumers sheds the Step lowest priority Consumers among the given
+// ones, skipping the paused and already shed ones.
+func (s *LoadShedder) shedConsumers(worker *Worker, cpuPercent float64, consumers []*Consumer) {
+	s.mu.Lock()
+	shed := make(map[*Consumer]bool, len(s.shed[worker]))
+	for _, item := range s.shed[worker] {
+		shed[item.Consumer] = true
+	}
+	s.mu.Unlock()
+
+	var candidates []ShedConsumer
+
+	for _, consumer := range consumers {
+		if consumer.Kind() != "video" || consumer.Closed() || consumer.Paused() || shed[consumer] {
+			continue
+		}
+
+		candidates = append(candidates, ShedConsumer{
+			Consumer: consumer,
+			Priority: s.priority(consumer),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority < candidates[j].Priority
+		}
+		return candidates[i].Consumer.Id() < candidates[j].Consumer.Id()
+	})
+
+	event := LoadShed{Worker: worker, CpuPercent: cpuPercent}
+
+	for _, candidate := range candidates {
+		if len(event.Consumers) == s.options.Step {
+			break
+		}
+		if err := s.shedConsumer(&candidate); err != nil {
+			s.logger.Warnf("shedding consumer %s failed: %s", candidate.Consumer.Id(), err)
+			continue
+		}
+		event.Consumers = append(event.Consumers, candidate)
+	}
+
+	if len(event.Consumers) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.shed[worker] = append(s.shed[worker], event.Consumers...)
+	s.mu.Unlock()
+
+	s.logger.Warnf("worker %d cpu %.0f%% above %.0f%%, %d consumers shed",
+		worker.Pid(), cpuPercent, s.options.MaxCpuPercent, len(event.Consumers))
+
+	s.SafeEmit("shed", event)
+}
+
+func (s *LoadShedder) shedConsumer(item *ShedConsumer) error {
+	consumer := item.Consumer
+
+	if s.options.Action == LoadShedPause || (consumer.Type() != "simulcast" && consumer.Type() != "svc") {
+		item.Action = LoadShedPause
+
+		return consumer.Pause()
+	}
+
+	item.Action = LoadShedDowngrade
+	item.PreviousLayers, _ = consumer.requestedLayersAndCap()
+
+	_, temporalLayers := consumerLayers(consumer)
+
+	return consumer.SetPreferredLayers(0, temporalLayers-1)
+}
+
+// restoreConsumers restores the Step last shed Consumers, the highest
+// priority ones first.
+func (s *LoadShedder) restoreConsumers(worker *Worker, cpuPercent float64) {
+	s.mu.Lock()
+	shed := s.shed[worker]
+	count := s.options.Step
+	if count > len(shed) {
+		count = len(shed)
+	}
+	restored := append([]ShedConsumer(nil), shed[len(shed)-count:]...)
+	s.shed[worker] = shed[:len(shed)-count]
+	s.mu.Unlock()
+
+	event := LoadShed{Worker: worker, CpuPercent: cpuPercent}
+
+	for i := len(restored) - 1; i >= 0; i-- {
+		item := restored[i]
+
+		if item.Consumer.Closed() {
+			continue
+		}
+		if err := s.restoreConsumer(item); err != nil {
+			s.logger.Warnf("restoring consumer %s failed: %s", item.Consumer.Id(), err)
+			continue
+		}
+		event.Consumers = append(event.Consumers, item)
+	}
+
+	if len(event.Consumers) == 0 {
+		return
+	}
+
+	s.logger.Infof("worker %d cpu %.0f%% below %.0f%%, %d consumers restored",
+		worker.Pid(), cpuPercent, s.options.RecoverCpuPercent, len(event.Consumers))
+
+	s.SafeEmit("restore", event)
+}
+
+func (s *LoadShedder) restoreConsumer(item ShedConsumer) error {
+	consumer := item.Consumer
+
+	if item.Action == LoadShedPause {
+		return consumer.Resume()
+	}
+
+	if item.PreviousLayers != nil {
+		return consumer.SetPreferredLayers(item.PreviousLayers.SpatialLayer, item.PreviousLayers.TemporalLayer)
+	}
+
+	spatialLayers, temporalLayers := consumerLayers(consumer)
+
+	return consumer.SetPreferredLayers(spatialLayers-1, temporalLayers-1)
+}
+
+// priority returns the priority of the Consumer, from its appData.
+func (s *LoadShedder) priority(consumer *Consumer) float64 {
+	value, ok := AppDataValue(consumer.AppData(), s.options.PriorityKey)
+	if !ok {
+		return 0
+	}
+
+	switch priority := value.(type) {
+	case int:
+		return float64(priority)
+	case float64:
+		return priority
+	case string:
+		f, _ := strconv.ParseFloat(priority, 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+// consumerLayers returns the numbers of spatial and temporal layers of the
+// Consumer.
+func consumerLayers(consumer *Consumer) (spatialLayers, temporalLayers uint8) {
+	var scalabilityMode string
+
+	if encodings := consumer.RtpParameters().Encodings; len(encodings) > 0 {
+		scalabilityMode = encodings[0].ScalabilityMode
+	}
+
+	spatial, temporal, _ := parseScalabilityMode(scalabilityMode)
+
+	return uint8(spatial), uint8(temporal)
+}
+
+// workerConsumers returns the Consumers of the Routers of the Worker.
+func workerConsumers(worker *Worker) (consumers []*Consumer) {
+	worker.routersLocker.Lock()
+	routers := make([]*Router, 0, len(worker.routers))
+	for _, router := range worker.routers {
+		routers = append(routers, router)
+	}
+	worker.routersLocker.Unlock()
+
+	for _, router := range routers {
+		for _, transport := range router.getTransports() {
+			if base := baseTransportOf(transport); base != nil {
+				consumers = append(consumers, base.getConsumers()...)
+			}
+		}
+	}
+
+	return
+}
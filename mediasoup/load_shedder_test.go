@@ -0,0 +1,119 @@
+package mediasoup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadShedder(t *testing.T) {
+	_, err := NewLoadShedder(WithLoadShedThresholds(50, 60))
+	assertValidationError(t, "LoadShedderOptions.RecoverCpuPercent", err)
+
+	_, err = NewLoadShedder(WithLoadShedAction("close", 1))
+	assertValidationError(t, "LoadShedderOptions.Action", err)
+
+	fake := newFakeWorker(t)
+	channel := fake.channel
+
+	takeRequests := func() []string {
+		var requests []string
+
+		for _, request := range fake.takeRequests() {
+			requests = append(requests, fmt.Sprintf("%s %s %s",
+				request.Internal.ConsumerId, request.Method, request.Data))
+		}
+
+		return requests
+	}
+
+	newConsumer := func(id, kind, typ string, appData H) *Consumer {
+		return NewConsumer(
+			internalData{ConsumerId: id, ProducerId: "producer"},
+			consumerData{
+				Kind: kind,
+				Type: typ,
+				RtpParameters: RtpParameters{
+					Encodings: []RtpEncoding{{Ssrc: 10, ScalabilityMode: "S3T3"}},
+				},
+			},
+			channel, appData, false, false, nil,
+		)
+	}
+
+	consumers := []*Consumer{
+		newConsumer("audio", "audio", "simple", H{"priority": 0}),
+		newConsumer("screen", "video", "simulcast", H{"priority": 10}),
+		newConsumer("camera1", "video", "simulcast", H{"priority": 1}),
+		newConsumer("camera2", "video", "simple", H{"priority": "2"}),
+		newConsumer("camera3", "video", "simulcast", nil),
+	}
+
+	cpuPercent := 50.0
+	worker := &Worker{observer: NewEventEmitter(AppLogger())}
+
+	shedder, err := NewLoadShedder(
+		WithLoadShedAction(LoadShedDowngrade, 3),
+		WithLoadShedCpuPercent(func(w *Worker) float64 {
+			return cpuPercent
+		}),
+	)
+	require.NoError(t, err)
+	shedder.AddWorker(worker)
+
+	var sheds, restores []LoadShed
+	shedder.On("shed", func(shed LoadShed) {
+		sheds = append(sheds, shed)
+	})
+	shedder.On("restore", func(shed LoadShed) {
+		restores = append(restores, shed)
+	})
+
+	require.NoError(t, consumers[2].SetPreferredLayers(1, 1))
+	takeRequests()
+
+	shedder.shedConsumers(worker, 95, consumers)
+
+	require.Len(t, sheds, 1)
+	assert.Equal(t, 95.0, sheds[0].CpuPercent)
+	assert.Equal(t, []ShedConsumer{
+		{Consumer: consumers[4], Priority: 0, Action: LoadShedDowngrade},
+		{Consumer: consumers[2], Priority: 1, Action: LoadShedDowngrade, PreviousLayers: &ConsumerPreferredLayers{SpatialLayer: 1, TemporalLayer: 1}},
+		{Consumer: consumers[3], Priority: 2, Action: LoadShedPause},
+	}, sheds[0].Consumers)
+	assert.Equal(t, []string{
+		`camera3 consumer.setPreferredLayers {"spatialLayer":0,"temporalLayer":2}`,
+		`camera1 consumer.setPreferredLayers {"spatialLayer":0,"temporalLayer":2}`,
+		`camera2 consumer.pause null`,
+	}, takeRequests())
+
+	// The shed Consumers are skipped.
+	shedder.shedConsumers(worker, 95, consumers)
+	require.Len(t, sheds, 2)
+	assert.Equal(t, consumers[1], sheds[1].Consumers[0].Consumer)
+	assert.Len(t, shedder.Shed(worker), 4)
+	takeRequests()
+
+	// Between the thresholds, nothing changes.
+	cpuPercent = 70
+	shedder.Run()
+	assert.Empty(t, restores)
+
+	// Restored by highest priority first.
+	cpuPercent = 30
+	shedder.Run()
+	require.Len(t, restores, 1)
+	assert.Len(t, restores[0].Consumers, 3)
+	assert.Equal(t, []string{
+		`screen consumer.setPreferredLayers {"spatialLayer":2,"temporalLayer":2}`,
+		`camera2 consumer.resume null`,
+		`camera1 consumer.setPreferredLayers {"spatialLayer":1,"temporalLayer":1}`,
+	}, takeRequests())
+
+	shedder.Run()
+	require.Len(t, restores, 2)
+	assert.Equal(t, consumers[4], restores[1].Consumers[0].Consumer)
+	assert.Empty(t, shedder.Shed(worker))
+}
@@ -0,0 +1,182 @@
+package mediasoup
+
+import "sort"
+
+// Worker log tags. Most of the logs they enable are at "debug" level, so the
+// worker log level must be "debug" for them to show up.
+const (
+	WorkerLogTagInfo      = "info"
+	WorkerLogTagIce       = "ice"
+	WorkerLogTagDtls      = "dtls"
+	WorkerLogTagRtp       = "rtp"
+	WorkerLogTagSrtp      = "srtp"
+	WorkerLogTagRtcp      = "rtcp"
+	WorkerLogTagRtx       = "rtx"
+	WorkerLogTagBwe       = "bwe"
+	WorkerLogTagScore     = "score"
+	WorkerLogTagSimulcast = "simulcast"
+	WorkerLogTagSvc       = "svc"
+	WorkerLogTagSctp      = "sctp"
+	WorkerLogTagMessage   = "message"
+)
+
+// Named presets of worker log tags, for the usual investigations.
+var WorkerLogTagPresets = map[string][]string{
+	// Transports not connecting.
+	"connectivity": {WorkerLogTagIce, WorkerLogTagDtls, WorkerLogTagSrtp},
+	// Media not flowing or not decodable.
+	"media": {WorkerLogTagRtp, WorkerLogTagRtcp, WorkerLogTagRtx},
+	// Poor quality, layers switching.
+	"quality": {WorkerLogTagBwe, WorkerLogTagScore, WorkerLogTagSimulcast, WorkerLogTagSvc},
+	// DataChannels.
+	"data": {WorkerLogTagSctp, WorkerLogTagMessage},
+}
+
+// EnableLogTags enables the given log tags (or presets names of
+// WorkerLogTagPresets) in the worker, in addition to the enabled ones.
+func (w *Worker) EnableLogTags(tags ...string) error {
+	w.logger.Debugln("enableLogTags()")
+
+	w.logSettingsLocker.Lock()
+	defer w.logSettingsLocker.Unlock()
+
+	enabled := make(map[string]bool)
+
+	for _, tag := range w.logTags {
+		enabled[tag] = true
+	}
+	for _, tag := range expandLogTags(tags) {
+		enabled[tag] = true
+	}
+
+	return w.setLogTags(enabled)
+}
+
+// DisableLogTags disables the given log tags (or presets names of
+// WorkerLogTagPresets) in the worker.
+func (w *Worker) DisableLogTags(tags ...string) error {
+	w.logger.Debugln("disableLogTags()")
+
+	w.logSettingsLocker.Lock()
+	defer w.logSettingsLocker.Unlock()
+
+	enabled := make(map[string]bool)
+
+	for _, tag := range w.logTags {
+		enabled[tag] = true
+	}
+	for _, tag := range expandLogTags(tags) {
+		delete(enabled, tag)
+	}
+
+	return w.setLogTags(enabled)
+}
+
+// LogTags returns the log tags enabled in the worker.
+func (w *Worker) LogTags() []string {
+	w.logSettingsLocker.Lock()
+	defer w.logSettingsLocker.Unlock()
+
+	return append([]string{}, w.logTags...)
+}
+
+// SetLogLevel changes the log level of the running worker.
+func (w *Worker) SetLogLevel(logLevel string) error {
+	w.logger.Debugln("setLogLevel()")
+
+	if err := validateLogSettings(logLevel, nil); err != nil {
+		return err
+	}
+
+	w.logSettingsLocker.Lock()
+	defer w.logSettingsLocker.Unlock()
+
+	return w.setLogSettings(logLevel, w.logTags)
+}
+
+// LogLevel returns the log level of the worker.
+func (w *Worker) LogLevel() string {
+	w.logSettingsLocker.Lock()
+	defer w.logSettingsLocker.Unlock()
+
+	return w.logLevel
+}
+
+func (w *Worker) setLogTags(enabled map[string]bool) error {
+	logTags := []string{}
+
+	for tag := range enabled {
+		logTags = append(logTags, tag)
+	}
+
+	return w.setLogSettings(w.logLevel, logTags)
+}
+
+// setLogSettings sends the given log settings to the worker. It must be called
+// with logSettingsLocker held.
+func (w *Worker) setLogSettings(logLevel string, logTags []string) (err error) {
+	logTags = supportedLogTags(w.version, append([]string{}, logTags...))
+
+	sort.Strings(logTags)
+
+	// Not through Options since empty log tags would be omitted.
+	settings := H{"logTags": logTags}
+
+	if len(logLevel) > 0 {
+		settings["logLevel"] = logLevel
+	}
+
+	rsp := w.channel.Request("worker.updateSettings", nil, settings)
+	if err = rsp.Err(); err != nil {
+		return
+	}
+
+	w.logLevel = logLevel
+	w.logTags = logTags
+
+	return
+}
+
+func isWorkerLogTag(tag string) bool {
+	switch tag {
+	case WorkerLogTagInfo, WorkerLogTagIce, WorkerLogTagDtls, WorkerLogTagRtp,
+		WorkerLogTagSrtp, WorkerLogTagRtcp, WorkerLogTagRtx, WorkerLogTagBwe,
+		WorkerLogTagScore, WorkerLogTagSimulcast, WorkerLogTagSvc,
+		WorkerLogTagSctp, WorkerLogTagMessage:
+		return true
+	}
+
+	return false
+}
+
+// supportedLogTags returns the given log tags supported by the worker of the
+// given version.
+func supportedLogTags(version string, tags []string) []string {
+	if workerSupports(version, WorkerFeatureExtendedLogTags) {
+		return tags
+	}
+
+	supported := []string{}
+
+	for _, tag := range tags {
+		switch tag {
+		case WorkerLogTagBwe, WorkerLogTagSvc, WorkerLogTagSctp, WorkerLogTagMessage:
+		default:
+			supported = append(supported, tag)
+		}
+	}
+
+	return supported
+}
+
+func expandLogTags(tags []string) (expanded []string) {
+	for _, tag := range tags {
+		if preset, ok := WorkerLogTagPresets[tag]; ok {
+			expanded = append(expanded, preset...)
+		} else {
+			expanded = append(expanded, tag)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,70 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerEnableLogTags_Succeeds(t *testing.T) {
+	skipUnlessWorkerSupports(t, WorkerFeatureExtendedLogTags)
+
+	worker := CreateTestWorker()
+	defer worker.Close()
+
+	assert.Equal(t, []string{"info"}, worker.LogTags())
+
+	assert.NoError(t, worker.EnableLogTags("connectivity", WorkerLogTagBwe))
+	assert.Equal(t, []string{"bwe", "dtls", "ice", "info", "srtp"}, worker.LogTags())
+
+	assert.NoError(t, worker.DisableLogTags("connectivity"))
+	assert.Equal(t, []string{"bwe", "info"}, worker.LogTags())
+
+	assert.NoError(t, worker.DisableLogTags("bwe", "info"))
+	assert.Empty(t, worker.LogTags())
+}
+
+func TestWorkerEnableLogTags_InvalidStateError(t *testing.T) {
+	worker := CreateTestWorker()
+	worker.Close()
+
+	err := worker.EnableLogTags(WorkerLogTagIce)
+	assert.IsType(t, NewInvalidStateError(""), err)
+	assert.Equal(t, []string{"info"}, worker.LogTags())
+}
+
+func TestWorkerSetLogLevel(t *testing.T) {
+	worker := CreateTestWorker()
+	defer worker.Close()
+
+	assert.Equal(t, "debug", worker.LogLevel())
+
+	assert.NoError(t, worker.SetLogLevel("warn"))
+	assert.Equal(t, "warn", worker.LogLevel())
+	assert.Equal(t, []string{"info"}, worker.LogTags())
+
+	assertValidationError(t, "Options.LogLevel", worker.SetLogLevel("chicken"))
+	assert.Equal(t, "warn", worker.LogLevel())
+}
+
+func TestWorkerUpdateSettings_KeepsUnsetSettings(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+	worker.logLevel = "error"
+	worker.logTags = []string{WorkerLogTagIce}
+
+	assert.NoError(t, worker.UpdateSettings(Options{LogLevel: "debug"}).Err())
+	assert.Equal(t, "debug", worker.LogLevel())
+	assert.Equal(t, []string{WorkerLogTagIce}, worker.LogTags())
+
+	assert.NoError(t, worker.UpdateSettings(Options{LogTags: []string{WorkerLogTagRtp, WorkerLogTagDtls}}).Err())
+	assert.Equal(t, "debug", worker.LogLevel())
+	assert.Equal(t, []string{WorkerLogTagDtls, WorkerLogTagRtp}, worker.LogTags())
+
+	assertValidationError(t, "Options.LogTags[0]", worker.UpdateSettings(Options{LogTags: []string{"chicken"}}).Err())
+}
+
+func TestExpandLogTags(t *testing.T) {
+	assert.Equal(t,
+		[]string{"ice", "dtls", "srtp", "sctp"},
+		expandLogTags([]string{"connectivity", WorkerLogTagSctp}))
+}
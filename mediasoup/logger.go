@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"path"
 	"runtime"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
 
-var logger = logrus.New()
+var (
+	logger         = logrus.New()
+	loggerRedactor atomic.Value
+)
 
 func init() {
 	logger.AddHook(ContextHook{})
@@ -18,6 +22,18 @@ func Logger() *logrus.Logger {
 	return logger
 }
 
+// SetLoggerAppDataRedactor sets the redactor of the "appData" field of the log
+// entries, nil to log appData as is.
+func SetLoggerAppDataRedactor(redactor *AppDataRedactor) {
+	loggerRedactor.Store(redactor)
+}
+
+func loggerAppDataRedactor() *AppDataRedactor {
+	redactor, _ := loggerRedactor.Load().(*AppDataRedactor)
+
+	return redactor
+}
+
 func AppLogger() logrus.FieldLogger {
 	return logger.WithField("app", "mediasoup")
 }
@@ -26,6 +42,16 @@ func TypeLogger(value string) logrus.FieldLogger {
 	return AppLogger().WithField("type", value)
 }
 
+// entityAppLogger returns the given logger of the Router of an entity,
+// AppLogger() if nil.
+func entityAppLogger(appLogger logrus.FieldLogger) logrus.FieldLogger {
+	if appLogger == nil {
+		return AppLogger()
+	}
+
+	return appLogger
+}
+
 type ContextHook struct{}
 
 func (hook ContextHook) Levels() []logrus.Level {
@@ -33,6 +59,16 @@ func (hook ContextHook) Levels() []logrus.Level {
 }
 
 func (hook ContextHook) Fire(entry *logrus.Entry) error {
+	// Never log sensitive appData values.
+	if appData, ok := entry.Data["appData"]; ok {
+		data := make(logrus.Fields, len(entry.Data))
+		for key, value := range entry.Data {
+			data[key] = value
+		}
+		data["appData"] = loggerAppDataRedactor().Redact(appData)
+		entry.Data = data
+	}
+
 	if pc, file, line, ok := runtime.Caller(10); ok {
 		funcName := runtime.FuncForPC(pc).Name()
 
@@ -1,29 +1,45 @@
 package mediasoup
 
 import (
-	"sync"
+	"context"
+	"errors"
 )
 
 func CreateWorker(workerBin string, options ...Option) (worker *Worker, err error) {
-	worker, err = newWorker(workerBin, options...)
-	if err != nil {
-		return
+	opts := NewOptions()
+
+	for _, option := range options {
+		option(opts)
 	}
 
-	var wg sync.WaitGroup
+	for attempt := 0; ; attempt++ {
+		worker, err = startWorker(workerBin, opts.StartupTimeout, options...)
 
-	wg.Add(1)
+		if !errors.Is(err, ErrWorkerStartupTimeout) || attempt >= opts.StartupRetries {
+			return
+		}
 
-	worker.On("@failure", func(errr error) {
-		worker, err = nil, errr
-		wg.Done()
-	})
+		TypeLogger("Worker").Warnf("retrying worker startup [attempt:%d]: %s", attempt+1, err)
+	}
+}
 
-	worker.On("@success", func(worker *Worker) {
-		wg.Done()
-	})
+// CreateWorkerWithRetry creates a Worker, retrying with the given backoff if
+// the worker process fails to start, e.g. to respawn a died Worker:
+//
+//	worker.OnDied(func(err error) {
+//		worker, err = CreateWorkerWithRetry(ctx, DefaultBackoff, workerBin, options...)
+//	})
+func CreateWorkerWithRetry(ctx context.Context, backoff Backoff, workerBin string, options ...Option) (worker *Worker, err error) {
+	err = backoff.Retry(ctx, func(attempt int) (err error) {
+		worker, err = CreateWorker(workerBin, options...)
+
+		// Invalid options won't get valid.
+		if _, ok := err.(ValidationError); ok {
+			return Permanent(err)
+		}
 
-	wg.Wait()
+		return
+	})
 
 	return
 }
@@ -0,0 +1,163 @@
+package mediasoup
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NotificationRateLimits limit the high frequency events of the entities of a
+// Router, protecting the application from floods in large rooms. Zero values
+// disable the limits.
+type NotificationRateLimits struct {
+	// Minimum interval between two "score" events of a Producer or Consumer,
+	// the last score received meanwhile being emitted at the end of it.
+	ScoreInterval time.Duration
+	// Interval of the "volumes" events of the AudioLevelObservers, the
+	// volumes received meanwhile being aggregated, keeping the loudest one of
+	// each Producer.
+	VolumesInterval time.Duration
+	// Maximum number of entries of the aggregated "volumes" events, the
+	// loudest ones being kept, unlimited if not positive.
+	MaxVolumes int
+}
+
+// notificationThrottle emits at most once per interval: at once if the
+// previous emission is older than the interval, else the last notification
+// at the end of the interval.
+type notificationThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+	timer    *time.Timer
+	pending  func()
+}
+
+// newNotificationThrottle returns nil, emitting everything, if the interval
+// is not positive.
+func newNotificationThrottle(interval time.Duration) *notificationThrottle {
+	if interval <= 0 {
+		return nil
+	}
+
+	return &notificationThrottle{interval: interval}
+}
+
+func (t *notificationThrottle) emit(fn func()) {
+	if t == nil {
+		fn()
+		return
+	}
+
+	t.mu.Lock()
+
+	now := time.Now()
+
+	if t.timer == nil && now.Sub(t.last) >= t.interval {
+		t.last = now
+		t.mu.Unlock()
+
+		fn()
+		return
+	}
+
+	t.pending = fn
+
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.interval-now.Sub(t.last), t.flush)
+	}
+
+	t.mu.Unlock()
+}
+
+func (t *notificationThrottle) flush() {
+	t.mu.Lock()
+	fn := t.pending
+	t.pending, t.timer, t.last = nil, nil, time.Now()
+	t.mu.Unlock()
+
+	if fn != nil {
+		fn()
+	}
+}
+
+// volumesAggregator aggregates the "volumes" notifications of an
+// AudioLevelObserver, emitting the loudest ones every interval.
+type volumesAggregator struct {
+	mu         sync.Mutex
+	interval   time.Duration
+	maxEntries int
+	// Loudest volume of each Producer, in arrival order.
+	volumes []VolumeInfo
+	timer   *time.Timer
+	emit    func(volumes []VolumeInfo)
+}
+
+// newVolumesAggregator returns nil, emitting every notification, if the
+// interval is not positive.
+func newVolumesAggregator(limits NotificationRateLimits, emit func(volumes []VolumeInfo)) *volumesAggregator {
+	if limits.VolumesInterval <= 0 {
+		return nil
+	}
+
+	return &volumesAggregator{
+		interval:   limits.VolumesInterval,
+		maxEntries: limits.MaxVolumes,
+		emit:       emit,
+	}
+}
+
+func (a *volumesAggregator) add(volumes []VolumeInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+next:
+	for _, volume := range volumes {
+		for i, aggregated := range a.volumes {
+			if aggregated.Producer == volume.Producer {
+				if volume.Volume > aggregated.Volume {
+					a.volumes[i].Volume = volume.Volume
+				}
+				continue next
+			}
+		}
+
+		a.volumes = append(a.volumes, volume)
+	}
+
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.interval, a.flush)
+	}
+}
+
+// flush emits the aggregated volumes, the loudest first.
+func (a *volumesAggregator) flush() {
+	a.mu.Lock()
+	volumes := a.volumes
+	a.volumes = nil
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.mu.Unlock()
+
+	// Producers closed meanwhile are removed.
+	alive := volumes[:0]
+	for _, volume := range volumes {
+		if !volume.Producer.Closed() {
+			alive = append(alive, volume)
+		}
+	}
+
+	sort.SliceStable(alive, func(i, j int) bool {
+		return alive[i].Volume > alive[j].Volume
+	})
+
+	if a.maxEntries > 0 && len(alive) > a.maxEntries {
+		alive = alive[:a.maxEntries]
+	}
+
+	if len(alive) > 0 {
+		a.emit(alive)
+	}
+}
@@ -0,0 +1,99 @@
+package mediasoup
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationThrottle(t *testing.T) {
+	var mu sync.Mutex
+	var emitted []int
+
+	emit := func(n int) func() {
+		return func() {
+			mu.Lock()
+			emitted = append(emitted, n)
+			mu.Unlock()
+		}
+	}
+
+	throttle := newNotificationThrottle(50 * time.Millisecond)
+
+	throttle.emit(emit(1))
+	throttle.emit(emit(2))
+	throttle.emit(emit(3))
+
+	mu.Lock()
+	assert.Equal(t, []int{1}, emitted)
+	mu.Unlock()
+
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []int{1, 3}, emitted)
+	mu.Unlock()
+
+	// Not rate limited.
+	throttle = newNotificationThrottle(0)
+	assert.Nil(t, throttle)
+
+	throttle.emit(emit(4))
+	throttle.emit(emit(5))
+
+	mu.Lock()
+	assert.Equal(t, []int{1, 3, 4, 5}, emitted)
+	mu.Unlock()
+}
+
+func TestVolumesAggregator(t *testing.T) {
+	assert.Nil(t, newVolumesAggregator(NotificationRateLimits{}, nil))
+
+	var emitted [][]VolumeInfo
+
+	aggregator := newVolumesAggregator(NotificationRateLimits{
+		VolumesInterval: time.Hour,
+		MaxVolumes:      2,
+	}, func(volumes []VolumeInfo) {
+		emitted = append(emitted, volumes)
+	})
+
+	producer1, producer2, producer3, closed := &Producer{}, &Producer{}, &Producer{}, &Producer{}
+	closed.closed.set()
+
+	aggregator.add([]VolumeInfo{{Producer: producer1, Volume: 10}, {Producer: closed, Volume: 90}})
+	aggregator.add([]VolumeInfo{{Producer: producer2, Volume: 30}, {Producer: producer1, Volume: 50}})
+	aggregator.add([]VolumeInfo{{Producer: producer3, Volume: 20}, {Producer: producer1, Volume: 40}})
+	assert.Empty(t, emitted)
+
+	aggregator.flush()
+	assert.Equal(t, [][]VolumeInfo{
+		{{Producer: producer1, Volume: 50}, {Producer: producer2, Volume: 30}},
+	}, emitted)
+
+	// Nothing heard.
+	aggregator.flush()
+	assert.Len(t, emitted, 1)
+}
+
+func TestVolumesAggregator_Interval(t *testing.T) {
+	emitted := make(chan []VolumeInfo, 1)
+
+	aggregator := newVolumesAggregator(NotificationRateLimits{
+		VolumesInterval: 20 * time.Millisecond,
+	}, func(volumes []VolumeInfo) {
+		emitted <- volumes
+	})
+
+	producer := &Producer{}
+	aggregator.add([]VolumeInfo{{Producer: producer, Volume: 10}})
+
+	select {
+	case volumes := <-emitted:
+		assert.Equal(t, []VolumeInfo{{Producer: producer, Volume: 10}}, volumes)
+	case <-time.After(time.Second):
+		t.Fatal("volumes not emitted")
+	}
+}
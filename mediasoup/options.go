@@ -3,6 +3,7 @@ package mediasoup
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 // Options to start worker
@@ -14,6 +15,40 @@ type Options struct {
 	RTCMaxPort          uint16   `json:"rtcMaxPort,omitempty"`
 	DTLSCertificateFile string   `json:"dtlsCertificateFile,omitempty"`
 	DTLSPrivateKeyFile  string   `json:"dtlsPrivateKeyFile,omitempty"`
+	// Resolver of the listen hosts given to the Routers of the worker,
+	// ResolveListenIp if nil.
+	ListenIpResolver ListenIpResolver `json:"-"`
+	// Soft and hard limits of Consumers of the worker, zero if unlimited.
+	MaxConsumersSoft int `json:"-"`
+	MaxConsumersHard int `json:"-"`
+	// Called before creating Transports, Producers and Consumers in the
+	// worker.
+	Authorizer Authorizer `json:"-"`
+	// Path of the worker binary, used when CreateWorker is given none,
+	// MEDIASOUP_WORKER_BIN if empty.
+	WorkerBin string `json:"-"`
+	// Extra environment variables ("KEY=value") of the worker process.
+	Env []string `json:"-"`
+	// Versions of the worker supported by the application, checked against
+	// Version (the MEDIASOUP_VERSION given to the worker) on startup, not
+	// checked if empty.
+	MinVersion string `json:"-"`
+	MaxVersion string `json:"-"`
+	// Cores the worker process is pinned to (through taskset, on Linux only),
+	// not pinned if empty.
+	CPUAffinity []int `json:"-"`
+	// Handler of the logs of the worker, logged through logrus if nil.
+	WorkerLogger WorkerLogger `json:"-"`
+	// Tags (or presets names of WorkerLogTagPresets) of the worker debug logs
+	// forwarded to WorkerLogger, all if empty.
+	WorkerLogFilter []string `json:"-"`
+	// Time given to the worker process to be running, killed and respawned up
+	// to StartupRetries times if not, waited indefinitely if zero.
+	StartupTimeout time.Duration `json:"-"`
+	StartupRetries int           `json:"-"`
+	// Backoff of the idempotent requests (dumps, stats and pings) timing out,
+	// not retried if nil.
+	RequestBackoff *Backoff `json:"-"`
 }
 
 func NewOptions() *Options {
@@ -38,7 +73,7 @@ func (o *Options) WorkerArgs() []string {
 		workerArgs = append(workerArgs, "--logLevel="+o.LogLevel)
 	}
 
-	for _, logTag := range o.LogTags {
+	for _, logTag := range supportedLogTags(o.Version, o.LogTags) {
 		if len(logTag) > 0 {
 			workerArgs = append(workerArgs, "--logTags="+logTag)
 		}
@@ -93,3 +128,85 @@ func WithDTLSCert(dtlsCertificateFile, dtlsPrivateKeyFile string) Option {
 		o.DTLSPrivateKeyFile = dtlsPrivateKeyFile
 	}
 }
+
+func WithListenIpResolver(resolver ListenIpResolver) Option {
+	return func(o *Options) {
+		o.ListenIpResolver = resolver
+	}
+}
+
+// WithConsumerLimits limits the Consumers of the worker: a warning is logged
+// and "consumersoftlimit" emitted when the soft limit is reached, and the
+// creation of Consumers fails with ErrWorkerFull above the hard limit,
+// emitting "consumerhardlimit".
+func WithConsumerLimits(soft, hard int) Option {
+	return func(o *Options) {
+		o.MaxConsumersSoft = soft
+		o.MaxConsumersHard = hard
+	}
+}
+
+func WithWorkerBin(workerBin string) Option {
+	return func(o *Options) {
+		o.WorkerBin = workerBin
+	}
+}
+
+func WithEnv(env ...string) Option {
+	return func(o *Options) {
+		o.Env = append(o.Env, env...)
+	}
+}
+
+// WithVersionRange makes CreateWorker fail with a ValidationError if the
+// version of the worker is not in [minVersion, maxVersion], versions that are
+// not semver (e.g. "latest") included. Either bound may be empty.
+func WithVersionRange(minVersion, maxVersion string) Option {
+	return func(o *Options) {
+		o.MinVersion = minVersion
+		o.MaxVersion = maxVersion
+	}
+}
+
+// WithCPUAffinity pins the worker process to the given cores.
+func WithCPUAffinity(cpus ...int) Option {
+	return func(o *Options) {
+		o.CPUAffinity = cpus
+	}
+}
+
+// WithStartupTimeout kills the worker process if not running within timeout,
+// spawning it again up to retries times before failing with
+// ErrWorkerStartupTimeout.
+func WithStartupTimeout(timeout time.Duration, retries int) Option {
+	return func(o *Options) {
+		o.StartupTimeout = timeout
+		o.StartupRetries = retries
+	}
+}
+
+// WithWorkerLogger forwards the logs of the worker to the given logger, only
+// the debug logs of the given tags (or presets names of WorkerLogTagPresets)
+// if any.
+func WithWorkerLogger(logger WorkerLogger, tags ...string) Option {
+	return func(o *Options) {
+		o.WorkerLogger = logger
+		o.WorkerLogFilter = tags
+	}
+}
+
+// WithAuthorizer authorizes the creation of Transports, Producers and
+// Consumers in the worker, centralizing the permission checks.
+func WithAuthorizer(authorizer Authorizer) Option {
+	return func(o *Options) {
+		o.Authorizer = authorizer
+	}
+}
+
+// WithRequestBackoff retries the idempotent requests (dumps, stats and pings)
+// timing out with the given backoff.
+func WithRequestBackoff(backoff Backoff) Option {
+	return func(o *Options) {
+		o.RequestBackoff = &backoff
+	}
+}
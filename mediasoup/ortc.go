@@ -3,6 +3,8 @@ package mediasoup
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/imdario/mergo"
@@ -217,7 +219,11 @@ func GetProducerRtpParametersMapping(
 	}
 
 	// Generate header extensions mapping.
-	for _, ext := range params.HeaderExtensions {
+	headerExtensions, conflicts := resolveProducerHeaderExtensions(params.HeaderExtensions)
+
+	rtpMapping.HeaderExtensionConflicts = conflicts
+
+	for _, ext := range headerExtensions {
 		var matchedCapExt *RtpHeaderExtension
 
 		for _, capExt := range caps.HeaderExtensions {
@@ -413,7 +419,16 @@ func CanConsume(consumableParams RtpParameters, caps RtpCapabilities) bool {
 func GetConsumerRtpParameters(
 	consumableParams RtpParameters, caps RtpCapabilities,
 ) (consumerParams RtpParameters, err error) {
-	consumerParams.HeaderExtensions = []RtpHeaderExtension{}
+	consumerParams, _, err = getConsumerRtpParameters(consumableParams, caps)
+
+	return
+}
+
+// getConsumerRtpParameters is GetConsumerRtpParameters, also returning how
+// the conflicting header extension ids were resolved.
+func getConsumerRtpParameters(
+	consumableParams RtpParameters, caps RtpCapabilities,
+) (consumerParams RtpParameters, conflicts []RtpHeaderExtensionConflict, err error) {
 
 	for _, capCodec := range caps.Codecs {
 		if err = checkCodecCapability(&capCodec); err != nil {
@@ -448,20 +463,31 @@ func GetConsumerRtpParameters(
 		return
 	}
 
-	for _, ext := range consumableParams.HeaderExtensions {
-		for _, capExt := range caps.HeaderExtensions {
-			if capExt.PreferredId == ext.Id {
-				consumerParams.HeaderExtensions =
-					append(consumerParams.HeaderExtensions, ext)
-				break
-			}
-		}
-	}
+	consumerParams.HeaderExtensions, conflicts = resolveConsumerHeaderExtensions(
+		codecsKind(consumableParams.Codecs), consumableParams.HeaderExtensions, caps)
 
 	consumerEncoding := RtpEncoding{
 		Ssrc: generateRandomNumber(),
 	}
 
+	if len(consumableParams.Encodings) > 0 {
+		consumerEncoding.ScalabilityMode = consumableParams.Encodings[0].ScalabilityMode
+	}
+
+	// Simulcast, the Consumer gets a single stream whose spatial layers are
+	// the Producer streams.
+	if len(consumableParams.Encodings) > 1 {
+		maxTemporalLayers := 1
+
+		for _, encoding := range consumableParams.Encodings {
+			if _, temporalLayers, _ := parseScalabilityMode(encoding.ScalabilityMode); temporalLayers > maxTemporalLayers {
+				maxTemporalLayers = temporalLayers
+			}
+		}
+
+		consumerEncoding.ScalabilityMode = fmt.Sprintf("S%dT%d", len(consumableParams.Encodings), maxTemporalLayers)
+	}
+
 	if rtxSupported {
 		consumerEncoding.Rtx = &RtpEncoding{
 			Ssrc: generateRandomNumber(),
@@ -474,6 +500,54 @@ func GetConsumerRtpParameters(
 	return
 }
 
+/**
+ * Apply the Consumer specific overrides to the given Consumer RTP parameters.
+ *
+ * It sets the MID (if given) and reduces the header extensions to the enabled
+ * ones (if any are given) minus the disabled ones. Enabling a header extension
+ * not present in the RTP parameters is an error since the Consumer cannot send
+ * it.
+ */
+func ApplyConsumerRtpParametersOverrides(
+	params *RtpParameters, mid string, enabledHeaderExtensions, disabledHeaderExtensions []string,
+) (err error) {
+	if len(mid) > 0 {
+		params.Mid = mid
+	}
+
+	for _, uri := range enabledHeaderExtensions {
+		found := false
+
+		for _, ext := range params.HeaderExtensions {
+			if ext.Uri == uri {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return NewTypeError(`cannot enable unavailable header extension [uri:"%s"]`, uri)
+		}
+	}
+
+	headerExtensions := []RtpHeaderExtension{}
+
+	for _, ext := range params.HeaderExtensions {
+		if len(enabledHeaderExtensions) > 0 && !containsString(enabledHeaderExtensions, ext.Uri) {
+			continue
+		}
+		if containsString(disabledHeaderExtensions, ext.Uri) {
+			continue
+		}
+
+		headerExtensions = append(headerExtensions, ext)
+	}
+
+	params.HeaderExtensions = headerExtensions
+
+	return
+}
+
 /**
  * Generate RTP parameters for a pipe Consumer.
  *
@@ -608,6 +682,38 @@ func matchedCodecs(
 				aCodec.Parameters = aParameters
 			}
 		}
+
+	case "video/vp9":
+		if mode&codecMatchStrict > 0 {
+			var aProfileId, bProfileId uint8
+
+			if aCodec.Parameters != nil {
+				aProfileId = aCodec.Parameters.ProfileId
+			}
+			if bCodec.Parameters != nil {
+				bProfileId = bCodec.Parameters.ProfileId
+			}
+
+			if aProfileId != bProfileId {
+				return
+			}
+		}
+
+	case "video/av1":
+		if mode&codecMatchStrict > 0 {
+			var aProfile, bProfile uint8
+
+			if aCodec.Parameters != nil {
+				aProfile = aCodec.Parameters.Profile
+			}
+			if bCodec.Parameters != nil {
+				bProfile = bCodec.Parameters.Profile
+			}
+
+			if aProfile != bProfile {
+				return
+			}
+		}
 	}
 
 	return true
@@ -622,3 +728,20 @@ func matchHeaderExtensions(aExt, bExt RtpHeaderExtension) bool {
 
 	return aExt.Uri == bExt.Uri
 }
+
+var scalabilityModeRegex = regexp.MustCompile(`^[LS]([1-9][0-9]?)T([1-9][0-9]?)(_KEY)?`)
+
+// parseScalabilityMode returns the spatial and temporal layers of the given
+// scalability mode ("L3T3_KEY" for instance), a single layer of each if not
+// valid.
+func parseScalabilityMode(scalabilityMode string) (spatialLayers, temporalLayers int, ksvc bool) {
+	match := scalabilityModeRegex.FindStringSubmatch(scalabilityMode)
+	if match == nil {
+		return 1, 1, false
+	}
+
+	spatialLayers, _ = strconv.Atoi(match[1])
+	temporalLayers, _ = strconv.Atoi(match[2])
+
+	return spatialLayers, temporalLayers, len(match[3]) > 0
+}
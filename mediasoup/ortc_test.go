@@ -430,6 +430,7 @@ func TestProducerComsumerPipeRtpParameters_Succeed(t *testing.T) {
 	assert.NotEmpty(t, consumerRtpParameters.Encodings[0].Ssrc)
 	assert.NotEmpty(t, consumerRtpParameters.Encodings[0].Rtx)
 	assert.NotEmpty(t, consumerRtpParameters.Encodings[0].Rtx.Ssrc)
+	assert.Equal(t, "S3T1", consumerRtpParameters.Encodings[0].ScalabilityMode)
 
 	assert.ElementsMatch(t, []RtpHeaderExtension{
 		{
@@ -485,6 +486,115 @@ func TestProducerComsumerPipeRtpParameters_Succeed(t *testing.T) {
 	}, pipeConsumerRtpParameters.Rtcp)
 }
 
+func TestAV1AndVP9Profile2RtpParameters_Succeed(t *testing.T) {
+	mediaCodecs := []RtpCodecCapability{
+		{
+			Kind:      "video",
+			MimeType:  "video/VP9",
+			ClockRate: 90000,
+		},
+		{
+			Kind:      "video",
+			MimeType:  "video/VP9",
+			ClockRate: 90000,
+			Parameters: &RtpCodecParameter{
+				ProfileId: 2,
+			},
+		},
+		{
+			Kind:      "video",
+			MimeType:  "video/AV1",
+			ClockRate: 90000,
+		},
+	}
+
+	routerRtpCapabilities, err := GenerateRouterRtpCapabilities(mediaCodecs)
+	assert.NoError(t, err)
+	assert.Len(t, routerRtpCapabilities.Codecs, 6)
+	assert.EqualValues(t, 2, routerRtpCapabilities.Codecs[2].Parameters.ProfileId)
+	assert.Equal(t, "video/AV1", routerRtpCapabilities.Codecs[4].MimeType)
+
+	vp9Profile2PayloadType := routerRtpCapabilities.Codecs[2].PreferredPayloadType
+
+	// VP9 profile 2 maps to the router VP9 profile 2 codec.
+	rtpParameters := RtpParameters{
+		Codecs: []RtpCodecCapability{
+			{
+				MimeType:    "video/VP9",
+				ClockRate:   90000,
+				PayloadType: 98,
+				Parameters: &RtpCodecParameter{
+					ProfileId: 2,
+				},
+			},
+		},
+		Encodings: []RtpEncoding{
+			{Ssrc: 11111111, ScalabilityMode: "L3T3_KEY"},
+		},
+		Rtcp: RtcpConfiguation{
+			Cname: "qwerty1234",
+		},
+	}
+
+	rtpMapping, err := GetProducerRtpParametersMapping(rtpParameters, routerRtpCapabilities)
+	assert.NoError(t, err)
+	assert.Equal(t, vp9Profile2PayloadType, rtpMapping.Codecs[0].MappedPayloadType)
+
+	consumableRtpParameters, err := GetConsumableRtpParameters(
+		"video", rtpParameters, routerRtpCapabilities, rtpMapping)
+	assert.NoError(t, err)
+	assert.Equal(t, "L3T3_KEY", consumableRtpParameters.Encodings[0].ScalabilityMode)
+
+	// A client only supporting VP9 profile 0 cannot consume it.
+	assert.False(t, CanConsume(consumableRtpParameters, RtpCapabilities{
+		Codecs: []RtpCodecCapability{routerRtpCapabilities.Codecs[0], routerRtpCapabilities.Codecs[1]},
+	}))
+
+	consumerRtpParameters, err := GetConsumerRtpParameters(consumableRtpParameters, routerRtpCapabilities)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, consumerRtpParameters.Codecs[0].Parameters.ProfileId)
+	assert.Equal(t, "L3T3_KEY", consumerRtpParameters.Encodings[0].ScalabilityMode)
+
+	// AV1 SVC.
+	rtpParameters.Codecs = []RtpCodecCapability{
+		{
+			MimeType:    "video/AV1",
+			ClockRate:   90000,
+			PayloadType: 45,
+		},
+	}
+	rtpParameters.Encodings[0].ScalabilityMode = "L1T3"
+
+	rtpMapping, err = GetProducerRtpParametersMapping(rtpParameters, routerRtpCapabilities)
+	assert.NoError(t, err)
+	assert.Equal(t, routerRtpCapabilities.Codecs[4].PreferredPayloadType, rtpMapping.Codecs[0].MappedPayloadType)
+
+	consumableRtpParameters, err = GetConsumableRtpParameters(
+		"video", rtpParameters, routerRtpCapabilities, rtpMapping)
+	assert.NoError(t, err)
+
+	consumerRtpParameters, err = GetConsumerRtpParameters(consumableRtpParameters, routerRtpCapabilities)
+	assert.NoError(t, err)
+	assert.Equal(t, "video/AV1", consumerRtpParameters.Codecs[0].MimeType)
+	assert.Equal(t, "L1T3", consumerRtpParameters.Encodings[0].ScalabilityMode)
+}
+
+func TestParseScalabilityMode(t *testing.T) {
+	spatialLayers, temporalLayers, ksvc := parseScalabilityMode("L3T3_KEY")
+	assert.Equal(t, 3, spatialLayers)
+	assert.Equal(t, 3, temporalLayers)
+	assert.True(t, ksvc)
+
+	spatialLayers, temporalLayers, ksvc = parseScalabilityMode("S2T1")
+	assert.Equal(t, 2, spatialLayers)
+	assert.Equal(t, 1, temporalLayers)
+	assert.False(t, ksvc)
+
+	spatialLayers, temporalLayers, _ = parseScalabilityMode("foo")
+	assert.Equal(t, 1, spatialLayers)
+	assert.Equal(t, 1, temporalLayers)
+}
+
 func TestGetProducerRtpParametersMapping_UnsupportedError(t *testing.T) {
 	mediaCodecs := []RtpCodecCapability{
 		{
@@ -546,3 +656,37 @@ func assertJSONEq(t *testing.T, expected, actual interface{}) {
 
 	assert.JSONEq(t, string(expectedData), string(actualData))
 }
+
+func TestApplyConsumerRtpParametersOverrides(t *testing.T) {
+	newParams := func() RtpParameters {
+		return RtpParameters{
+			HeaderExtensions: []RtpHeaderExtension{
+				{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 2},
+				{Uri: "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time", Id: 3},
+				{Uri: "urn:3gpp:video-orientation", Id: 4},
+			},
+		}
+	}
+
+	params := newParams()
+	err := ApplyConsumerRtpParametersOverrides(&params, "1", nil,
+		[]string{"http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", params.Mid)
+	assert.Equal(t, []RtpHeaderExtension{
+		{Uri: "urn:ietf:params:rtp-hdrext:toffset", Id: 2},
+		{Uri: "urn:3gpp:video-orientation", Id: 4},
+	}, params.HeaderExtensions)
+
+	params = newParams()
+	err = ApplyConsumerRtpParametersOverrides(&params, "", []string{"urn:3gpp:video-orientation"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, params.Mid)
+	assert.Equal(t, []RtpHeaderExtension{
+		{Uri: "urn:3gpp:video-orientation", Id: 4},
+	}, params.HeaderExtensions)
+
+	params = newParams()
+	err = ApplyConsumerRtpParametersOverrides(&params, "", []string{"urn:ietf:params:rtp-hdrext:sdes:mid"}, nil)
+	assert.IsType(t, NewTypeError(""), err)
+}
@@ -0,0 +1,224 @@
+package mediasoup
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PeerRegistry tracks the Transports, Producers and Consumers of each peer of
+// the watched Workers, so that everything a peer left behind can be closed at
+// once when it disconnects abruptly.
+//
+// The peer of an entity is the value of the configured appData key. Producers
+// and Consumers without that key belong to the peer of their Transport.
+type PeerRegistry struct {
+	mu         sync.Mutex
+	logger     logrus.FieldLogger
+	appDataKey string
+	peers      map[string]*peerEntities
+}
+
+type peerEntities struct {
+	transports map[string]Transport
+	producers  map[string]*Producer
+	consumers  map[string]*Consumer
+}
+
+// NewPeerRegistry creates a registry identifying peers by the given appData
+// key.
+func NewPeerRegistry(appDataKey string) *PeerRegistry {
+	logger := TypeLogger("PeerRegistry")
+
+	logger.Debug("constructor()")
+
+	return &PeerRegistry{
+		logger:     logger,
+		appDataKey: appDataKey,
+		peers:      make(map[string]*peerEntities),
+	}
+}
+
+// WatchWorker tracks the entities of the given Worker.
+func (r *PeerRegistry) WatchWorker(worker *Worker) {
+	r.visitor().WalkWorker(worker)
+}
+
+// WatchRouter tracks the entities of the given Router.
+func (r *PeerRegistry) WatchRouter(router *Router) {
+	r.visitor().WalkRouter(router)
+}
+
+func (r *PeerRegistry) visitor() EntityVisitor {
+	return EntityVisitor{
+		Transport: func(router *Router, transport Transport) {
+			r.watchTransport(transport)
+		},
+		Producer: func(router *Router, transport Transport, producer *Producer) {
+			r.watchProducer(transport, producer)
+		},
+		Consumer: func(router *Router, transport Transport, consumer *Consumer) {
+			r.watchConsumer(transport, consumer)
+		},
+	}
+}
+
+// Peers returns the keys of the peers having open entities, sorted.
+func (r *PeerRegistry) Peers() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	peerKeys := make([]string, 0, len(r.peers))
+
+	for peerKey := range r.peers {
+		peerKeys = append(peerKeys, peerKey)
+	}
+
+	sort.Strings(peerKeys)
+
+	return peerKeys
+}
+
+// CloseAllForPeer closes the Consumers, then the Producers, then the
+// Transports of the given peer, whatever their Router. The first error is
+// returned, the remaining entities being closed anyway.
+func (r *PeerRegistry) CloseAllForPeer(peerKey string) (err error) {
+	r.logger.Debugf("closeAllForPeer() [peerKey:%s]", peerKey)
+
+	r.mu.Lock()
+	entities := r.peers[peerKey]
+	delete(r.peers, peerKey)
+	r.mu.Unlock()
+
+	if entities == nil {
+		return
+	}
+
+	keepFirst := func(closeErr error) {
+		if err == nil {
+			err = closeErr
+		}
+	}
+
+	// Closing the Consumers first so that the other peers are not notified
+	// of Producers closing under them for nothing.
+	for _, consumer := range entities.consumers {
+		keepFirst(consumer.Close())
+	}
+	for _, producer := range entities.producers {
+		keepFirst(producer.Close())
+	}
+	for _, transport := range entities.transports {
+		keepFirst(transport.Close())
+	}
+
+	return
+}
+
+func (r *PeerRegistry) watchTransport(transport Transport) {
+	peerKey := r.peerKeyOf(transport.AppData())
+	if len(peerKey) == 0 {
+		return
+	}
+
+	r.add(peerKey, func(entities *peerEntities) {
+		entities.transports[transport.Id()] = transport
+	})
+
+	transport.Observer().On("close", func() {
+		r.remove(peerKey, func(entities *peerEntities) {
+			delete(entities.transports, transport.Id())
+		})
+	})
+}
+
+func (r *PeerRegistry) watchProducer(transport Transport, producer *Producer) {
+	peerKey := r.entityPeerKey(transport, producer.AppData())
+	if len(peerKey) == 0 {
+		return
+	}
+
+	r.add(peerKey, func(entities *peerEntities) {
+		entities.producers[producer.Id()] = producer
+	})
+
+	producer.Observer().On("close", func() {
+		r.remove(peerKey, func(entities *peerEntities) {
+			delete(entities.producers, producer.Id())
+		})
+	})
+}
+
+func (r *PeerRegistry) watchConsumer(transport Transport, consumer *Consumer) {
+	peerKey := r.entityPeerKey(transport, consumer.AppData())
+	if len(peerKey) == 0 {
+		return
+	}
+
+	r.add(peerKey, func(entities *peerEntities) {
+		entities.consumers[consumer.Id()] = consumer
+	})
+
+	consumer.Observer().On("close", func() {
+		r.remove(peerKey, func(entities *peerEntities) {
+			delete(entities.consumers, consumer.Id())
+		})
+	})
+}
+
+// entityPeerKey returns the peer key of a Producer or Consumer, the one of
+// its Transport if none.
+func (r *PeerRegistry) entityPeerKey(transport Transport, appData interface{}) string {
+	if peerKey := r.peerKeyOf(appData); len(peerKey) > 0 {
+		return peerKey
+	}
+
+	return r.peerKeyOf(transport.AppData())
+}
+
+func (r *PeerRegistry) peerKeyOf(appData interface{}) string {
+	value, ok := AppDataValue(appData, r.appDataKey)
+	if !ok || value == nil {
+		return ""
+	}
+	if peerKey, ok := value.(string); ok {
+		return peerKey
+	}
+
+	return fmt.Sprint(value)
+}
+
+func (r *PeerRegistry) add(peerKey string, update func(entities *peerEntities)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entities, ok := r.peers[peerKey]
+	if !ok {
+		entities = &peerEntities{
+			transports: make(map[string]Transport),
+			producers:  make(map[string]*Producer),
+			consumers:  make(map[string]*Consumer),
+		}
+		r.peers[peerKey] = entities
+	}
+
+	update(entities)
+}
+
+func (r *PeerRegistry) remove(peerKey string, update func(entities *peerEntities)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entities, ok := r.peers[peerKey]
+	if !ok {
+		return
+	}
+
+	update(entities)
+
+	if len(entities.transports)+len(entities.producers)+len(entities.consumers) == 0 {
+		delete(r.peers, peerKey)
+	}
+}
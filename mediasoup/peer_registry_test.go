@@ -0,0 +1,79 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerRegistry_CloseAllForPeer(t *testing.T) {
+	registry := NewPeerRegistry("peerId")
+
+	router1, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+	router2, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+
+	registry.WatchRouter(router1)
+	registry.WatchRouter(router2)
+
+	createTransport := func(router *Router, appData H) Transport {
+		transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+			ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+			AppData:   appData,
+		})
+		require.NoError(t, err)
+
+		return transport
+	}
+
+	aliceTransport1 := createTransport(router1, H{"peerId": "alice"})
+	aliceTransport2 := createTransport(router2, H{"peerId": "alice"})
+	bobTransport := createTransport(router1, H{"peerId": "bob"})
+	sharedTransport := createTransport(router1, nil)
+
+	aliceProducer1, err := aliceTransport1.Produce(audioProducerParameters)
+	require.NoError(t, err)
+	aliceProducer2, err := aliceTransport2.Produce(audioProducerParameters)
+	require.NoError(t, err)
+	bobProducer, err := bobTransport.Produce(videoProducerParameters)
+	require.NoError(t, err)
+
+	// Consuming bob on alice's Transport, the Consumer belongs to alice.
+	aliceConsumer, err := aliceTransport1.Consume(TransportConsumeParams{
+		ProducerId:      bobProducer.Id(),
+		RtpCapabilities: router1.RtpCapabilities(),
+	})
+	require.NoError(t, err)
+
+	// Consuming alice on a Transport shared by several peers.
+	sharedConsumer, err := sharedTransport.Consume(TransportConsumeParams{
+		ProducerId:      aliceProducer1.Id(),
+		RtpCapabilities: router1.RtpCapabilities(),
+		AppData:         H{"peerId": 42},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"42", "alice", "bob"}, registry.Peers())
+
+	assert.NoError(t, registry.CloseAllForPeer("alice"))
+
+	assert.True(t, aliceTransport1.Closed())
+	assert.True(t, aliceTransport2.Closed())
+	assert.True(t, aliceProducer1.Closed())
+	assert.True(t, aliceProducer2.Closed())
+	assert.True(t, aliceConsumer.Closed())
+	assert.False(t, bobTransport.Closed())
+	assert.False(t, bobProducer.Closed())
+	assert.False(t, sharedTransport.Closed())
+
+	assert.NotContains(t, registry.Peers(), "alice")
+	assert.Contains(t, registry.Peers(), "bob")
+
+	sharedConsumer.Close()
+	assert.Equal(t, []string{"bob"}, registry.Peers())
+
+	// Nothing left to close.
+	assert.NoError(t, registry.CloseAllForPeer("alice"))
+}
@@ -1,6 +1,7 @@
 package mediasoup
 
 import (
+	"encoding/json"
 	"fmt"
 
 	uuid "github.com/satori/go.uuid"
@@ -16,15 +17,28 @@ type PipeTransport struct {
 }
 
 func NewPipeTransport(data PipeTransportData, params createTransportParams) *PipeTransport {
-	logger := TypeLogger("PipeTransport")
+	logger := entityAppLogger(params.AppLogger).WithField("type", "PipeTransport")
 
 	logger.Debug("constructor()")
 
-	return &PipeTransport{
+	transport := &PipeTransport{
 		baseTransport: newTransport(params),
 		logger:        logger,
 		data:          data,
 	}
+
+	transport.handleWorkerNotifications()
+
+	return transport
+}
+
+func (t *PipeTransport) handleWorkerNotifications() {
+	t.subscription = t.channel.subscribe(t.internal.TransportId, t.channel.profiledListener(t.internal.RouterId, func(event string, rawData json.RawMessage) {
+		switch event {
+		case "trace":
+			t.handleTrace(rawData)
+		}
+	}))
 }
 
 func (t PipeTransport) Tuple() TransportTuple {
@@ -39,7 +53,7 @@ func (t PipeTransport) Tuple() TransportTuple {
  *
  * @override
  */
-func (t *PipeTransport) Connect(params transportConnectParams) (err error) {
+func (t *PipeTransport) Connect(params TransportConnectParams) (err error) {
 	t.logger.Debug("connect()")
 
 	resp := t.channel.Request("transport.connect", t.internal, params)
@@ -58,7 +72,7 @@ func (t *PipeTransport) Connect(params transportConnectParams) (err error) {
  *
  * @override
  */
-func (transport *PipeTransport) Produce(params transportProduceParams) (producer *Producer, err error) {
+func (transport *PipeTransport) Produce(params TransportProduceParams) (producer *Producer, err error) {
 	return transport.baseTransport.Produce(params)
 }
 
@@ -70,7 +84,7 @@ func (transport *PipeTransport) Produce(params transportProduceParams) (producer
  *
  * @override
  */
-func (t *PipeTransport) Consume(params transportConsumeParams) (consumer *Consumer, err error) {
+func (t *PipeTransport) Consume(params TransportConsumeParams) (consumer *Consumer, err error) {
 	t.logger.Debug("consume()")
 
 	producerId, appData := params.ProducerId, params.AppData
@@ -87,6 +101,15 @@ func (t *PipeTransport) Consume(params transportConsumeParams) (consumer *Consum
 
 	if producer == nil {
 		err = fmt.Errorf(`Producer with id "%s" not found`, producerId)
+		return
+	}
+	if err = t.authorize(AuthorizationRequest{
+		Action:     AuthorizeConsume,
+		Kind:       producer.Kind(),
+		ProducerId: producerId,
+		AppData:    appData,
+	}); err != nil {
+		return
 	}
 
 	rtpParameters := GetPipeConsumerRtpParameters(producer.ConsumableRtpParameters())
@@ -102,6 +125,11 @@ func (t *PipeTransport) Consume(params transportConsumeParams) (consumer *Consum
 		"consumableRtpEncodings": producer.ConsumableRtpParameters().Encodings,
 	}
 
+	releaseConsumer, err := t.consumerLimiter.acquire()
+	if err != nil {
+		return
+	}
+
 	resp := t.channel.Request("transport.consume", internal, reqData)
 
 	var status struct {
@@ -109,6 +137,7 @@ func (t *PipeTransport) Consume(params transportConsumeParams) (consumer *Consum
 		ProducerPaused bool
 	}
 	if err = resp.Unmarshal(&status); err != nil {
+		releaseConsumer()
 		return
 	}
 
@@ -116,6 +145,7 @@ func (t *PipeTransport) Consume(params transportConsumeParams) (consumer *Consum
 		Kind:          producer.Kind(),
 		RtpParameters: rtpParameters,
 		Type:          "pipe",
+		AppLogger:     t.appLogger,
 	}
 
 	consumer = NewConsumer(
@@ -127,17 +157,39 @@ func (t *PipeTransport) Consume(params transportConsumeParams) (consumer *Consum
 		status.ProducerPaused,
 		nil,
 	)
+	consumer.observer.Once("close", releaseConsumer)
 
-	t.consumers[consumer.Id()] = consumer
-	consumer.On("@close", func() {
-		delete(t.consumers, consumer.Id())
-	})
-	consumer.On("@producerclose", func() {
-		delete(t.consumers, consumer.Id())
-	})
+	if err = t.addConsumer(consumer); err != nil {
+		return
+	}
+
+	producer.addConsumer(consumer)
 
 	// Emit observer event.
 	t.observer.SafeEmit("newconsumer", consumer)
 
 	return
 }
+
+/**
+ * Compute the RTP parameters of a pipe Consumer of the given Producer.
+ *
+ * @override
+ */
+func (t *PipeTransport) ConsumeDryRun(params TransportConsumeParams) (rtpParameters RtpParameters, err error) {
+	t.logger.Debug("consumeDryRun()")
+
+	if params.AppData != nil && !isObject(params.AppData) {
+		err = NewTypeError("if given, appData must be an object")
+		return
+	}
+
+	producer := t.getProducerById(params.ProducerId)
+
+	if producer == nil {
+		err = fmt.Errorf(`Producer with id "%s" not found`, params.ProducerId)
+		return
+	}
+
+	return GetPipeConsumerRtpParameters(producer.ConsumableRtpParameters()), nil
+}
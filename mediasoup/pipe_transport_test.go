@@ -2,8 +2,10 @@ package mediasoup
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -141,8 +143,8 @@ const consumerDeviceCapabilitiesJSON = `
 }`
 
 var (
-	audioProducerParameters    transportProduceParams
-	videoProducerParameters    transportProduceParams
+	audioProducerParameters    TransportProduceParams
+	videoProducerParameters    TransportProduceParams
 	consumerDeviceCapabilities RtpCapabilities
 )
 
@@ -217,10 +219,7 @@ func TestRouterPipeToRouter_SucceedsWithAudio(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
-	var dump struct {
-		TransportIds []string
-	}
-	ns.router1.Dump().Unmarshal(&dump)
+	dump, _ := ns.router1.Dump()
 
 	// There shoud should be two Transports in router1:
 	// - WebRtcTransport for audioProducer and videoProducer.
@@ -228,7 +227,7 @@ func TestRouterPipeToRouter_SucceedsWithAudio(t *testing.T) {
 	assert.Len(t, dump.TransportIds, 2)
 
 	dump.TransportIds = nil
-	ns.router2.Dump().Unmarshal(&dump)
+	dump, _ = ns.router2.Dump()
 
 	// There shoud should be two Transports in router2:
 	// - WebRtcTransport for audioConsumer and videoConsumer.
@@ -303,16 +302,13 @@ func TestRouterPipeToRouter_SucceedsWithVideo(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
-	var dump struct {
-		TransportIds []string
-	}
-	ns.router1.Dump().Unmarshal(&dump)
+	dump, _ := ns.router1.Dump()
 
 	// No new PipeTransport should has been created. The existing one is used.
 	assert.Len(t, dump.TransportIds, 2)
 
 	dump.TransportIds = nil
-	ns.router2.Dump().Unmarshal(&dump)
+	dump, _ = ns.router2.Dump()
 
 	// No new PipeTransport should has been created. The existing one is used.
 	assert.Len(t, dump.TransportIds, 2)
@@ -396,7 +392,7 @@ func TestTransportConsume_ForAPipeProducerSucceeds(t *testing.T) {
 		Router:     ns.router2,
 	})
 
-	videoConsumer, err := ns.transport2.Consume(transportConsumeParams{
+	videoConsumer, err := ns.transport2.Consume(TransportConsumeParams{
 		ProducerId:      ns.videoProducer.Id(),
 		RtpCapabilities: consumerDeviceCapabilities,
 	})
@@ -452,7 +448,7 @@ func TestTransportPauseAndResumeAreTransmittedToPipeConsumer(t *testing.T) {
 		Router:     ns.router2,
 	})
 
-	videoConsumer, err := ns.transport2.Consume(transportConsumeParams{
+	videoConsumer, err := ns.transport2.Consume(TransportConsumeParams{
 		ProducerId:      ns.videoProducer.Id(),
 		RtpCapabilities: consumerDeviceCapabilities,
 	})
@@ -501,7 +497,7 @@ func TestProducerCloseIsTransmittedToPipeConsumer(t *testing.T) {
 		Router:     ns.router2,
 	})
 
-	videoConsumer, _ := ns.transport2.Consume(transportConsumeParams{
+	videoConsumer, _ := ns.transport2.Consume(TransportConsumeParams{
 		ProducerId:      ns.videoProducer.Id(),
 		RtpCapabilities: consumerDeviceCapabilities,
 	})
@@ -521,3 +517,88 @@ func TestProducerCloseIsTransmittedToPipeConsumer(t *testing.T) {
 
 	assert.True(t, videoConsumer.Closed())
 }
+
+func TestRouterPipeToRouter_SameWorkerKeepsSourceProducerNotifications(t *testing.T) {
+	ns := setupPipeTest(t)
+
+	_, pipeProducer, err := ns.router1.PipeToRouter(PipeToRouterParams{
+		ProducerId: ns.audioProducer.Id(),
+		Router:     ns.router2,
+	})
+	assert.NoError(t, err)
+
+	// Both Routers belong to the same Worker, the pipe Producer having the id
+	// of its source Producer.
+	assert.Equal(t, ns.audioProducer.Id(), pipeProducer.Id())
+
+	emitScore := func(score uint8) {
+		worker.channel.processNSPayload([]byte(fmt.Sprintf(
+			`{"targetId":"%s","event":"score","data":[{"ssrc":11111111,"score":%d}]}`,
+			ns.audioProducer.Id(), score)))
+	}
+	awaitScore := func(producer *Producer, score uint8) {
+		assert.Eventually(t, func() bool {
+			scores := producer.Score()
+			return len(scores) == 1 && scores[0].Score == score
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	emitScore(7)
+	awaitScore(ns.audioProducer, 7)
+	awaitScore(pipeProducer, 7)
+
+	// Closing the pipe Producer keeps the notifications of the source one.
+	pipeProducer.Close()
+
+	emitScore(9)
+	awaitScore(ns.audioProducer, 9)
+	assert.Equal(t, uint8(7), pipeProducer.Score()[0].Score)
+}
+
+func TestRouterPipeToRouter_ConcurrentCallsReturnSamePipePair(t *testing.T) {
+	ns := setupPipeTest(t)
+
+	const count = 10
+
+	var wg sync.WaitGroup
+	pipeConsumers := make([]*Consumer, count)
+	pipeProducers := make([]*Producer, count)
+	errs := make([]error, count)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			pipeConsumers[i], pipeProducers[i], errs[i] = ns.router1.PipeToRouter(PipeToRouterParams{
+				ProducerId: ns.audioProducer.Id(),
+				Router:     ns.router2,
+			})
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := 0; i < count; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, pipeConsumers[0], pipeConsumers[i])
+		assert.Equal(t, pipeProducers[0], pipeProducers[i])
+	}
+
+	dump, _ := ns.router1.Dump()
+
+	// Just one PipeTransport must have been created between router1 and router2.
+	assert.Len(t, dump.TransportIds, 2)
+
+	// Once the pipe Consumer is closed, piping again creates a new pair.
+	pipeConsumers[0].Close()
+
+	pipeConsumer, pipeProducer, err := ns.router1.PipeToRouter(PipeToRouterParams{
+		ProducerId: ns.audioProducer.Id(),
+		Router:     ns.router2,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, pipeConsumers[0], pipeConsumer)
+	assert.False(t, pipeProducer.Closed())
+}
@@ -0,0 +1,89 @@
+package mediasoup
+
+import "sync/atomic"
+
+// PlacementStrategy selects the Worker a WorkerPool creates a Router on, given
+// the loads of its Workers not draining, in the pool order (never empty).
+type PlacementStrategy interface {
+	SelectWorker(loads []WorkerLoad) *Worker
+}
+
+// PlacementStrategyFunc is a function selecting the Worker of a Router.
+type PlacementStrategyFunc func(loads []WorkerLoad) *Worker
+
+func (fn PlacementStrategyFunc) SelectWorker(loads []WorkerLoad) *Worker {
+	return fn(loads)
+}
+
+// LeastLoadedPlacement selects the Worker of least load (see
+// WorkerPoolOptions), the first one on equal loads. It is the default.
+func LeastLoadedPlacement() PlacementStrategy {
+	return PlacementStrategyFunc(func(loads []WorkerLoad) *Worker {
+		return selectLeastWorker(loads, func(load WorkerLoad) float64 {
+			return load.Load
+		})
+	})
+}
+
+// RoundRobinPlacement selects the Workers in turn.
+func RoundRobinPlacement() PlacementStrategy {
+	var next uint64
+
+	return PlacementStrategyFunc(func(loads []WorkerLoad) *Worker {
+		i := atomic.AddUint64(&next, 1) - 1
+
+		return loads[i%uint64(len(loads))].Worker
+	})
+}
+
+// LeastConsumersPlacement selects the Worker having the fewest Consumers.
+func LeastConsumersPlacement() PlacementStrategy {
+	return PlacementStrategyFunc(func(loads []WorkerLoad) *Worker {
+		return selectLeastWorker(loads, func(load WorkerLoad) float64 {
+			return float64(load.Worker.ConsumerCount())
+		})
+	})
+}
+
+// CpuPlacement selects the Worker using the least CPU, the unknown usages
+// (see WorkerLoad.CpuPercent) counting as none, the least loaded one on equal
+// usages.
+func CpuPlacement() PlacementStrategy {
+	return PlacementStrategyFunc(func(loads []WorkerLoad) *Worker {
+		var least *WorkerLoad
+
+		for i, load := range loads {
+			if least == nil || cpuPercentOrZero(load) < cpuPercentOrZero(*least) ||
+				(cpuPercentOrZero(load) == cpuPercentOrZero(*least) && load.Load < least.Load) {
+				least = &loads[i]
+			}
+		}
+
+		return least.Worker
+	})
+}
+
+func cpuPercentOrZero(load WorkerLoad) float64 {
+	if load.CpuPercent < 0 {
+		return 0
+	}
+
+	return load.CpuPercent
+}
+
+// selectLeastWorker returns the Worker of least value, the first one on equal
+// values.
+func selectLeastWorker(loads []WorkerLoad, value func(load WorkerLoad) float64) *Worker {
+	var (
+		least      *Worker
+		leastValue float64
+	)
+
+	for _, load := range loads {
+		if v := value(load); least == nil || v < leastValue {
+			least, leastValue = load.Worker, v
+		}
+	}
+
+	return least
+}
@@ -0,0 +1,67 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacementStrategies(t *testing.T) {
+	worker1, worker2, worker3 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2), newPoolTestWorker(t, 3)
+
+	worker1.consumerLimiter = &consumerLimiter{count: 5}
+	worker2.consumerLimiter = &consumerLimiter{count: 2}
+
+	loads := []WorkerLoad{
+		{Worker: worker1, CpuPercent: 10, Load: 30},
+		{Worker: worker2, CpuPercent: 40, Load: 20},
+		{Worker: worker3, CpuPercent: -1, Load: 20},
+	}
+
+	assert.Equal(t, worker2, LeastLoadedPlacement().SelectWorker(loads))
+	assert.Equal(t, worker3, LeastConsumersPlacement().SelectWorker(loads))
+	// The unknown CPU usage counts as none.
+	assert.Equal(t, worker3, CpuPlacement().SelectWorker(loads))
+
+	loads[2].CpuPercent = 10
+	assert.Equal(t, worker3, CpuPlacement().SelectWorker(loads))
+
+	roundRobin := RoundRobinPlacement()
+
+	var selected []*Worker
+	for i := 0; i < 4; i++ {
+		selected = append(selected, roundRobin.SelectWorker(loads))
+	}
+	assert.Equal(t, []*Worker{worker1, worker2, worker3, worker1}, selected)
+}
+
+func TestWorkerPool_Placement(t *testing.T) {
+	pool := newWorkerPool("", WithWorkerPoolPlacement(CpuPlacement()))
+
+	worker1, worker2 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2)
+	pool.addWorker(worker1)
+	pool.addWorker(worker2)
+
+	pool.cpuPercents[worker1] = 50
+	pool.cpuPercents[worker2] = 20
+
+	worker, err := pool.SelectWorker()
+	require.NoError(t, err)
+	assert.Equal(t, worker2, worker)
+
+	// Routers created on the selected Worker only.
+	for i := 0; i < 2; i++ {
+		router, err := pool.CreateRouter([]RtpCodecCapability{
+			{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, worker2.routers, router.Id())
+	}
+
+	pool.options.Placement = PlacementStrategyFunc(func(loads []WorkerLoad) *Worker {
+		return nil
+	})
+	_, err = pool.SelectWorker()
+	assert.IsType(t, NewInvalidStateError(""), err)
+}
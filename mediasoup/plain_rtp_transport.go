@@ -1,6 +1,7 @@
 package mediasoup
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/sirupsen/logrus"
@@ -15,15 +16,19 @@ type PlainRtpTransport struct {
 }
 
 func NewPlainRtpTransport(data PlainTransportData, params createTransportParams) *PlainRtpTransport {
-	logger := TypeLogger("PlainRtpTransport")
+	logger := entityAppLogger(params.AppLogger).WithField("type", "PlainRtpTransport")
 
 	logger.Debug("constructor()")
 
-	return &PlainRtpTransport{
+	transport := &PlainRtpTransport{
 		baseTransport: newTransport(params),
 		logger:        logger,
 		data:          data,
 	}
+
+	transport.handleWorkerNotifications()
+
+	return transport
 }
 
 func (t PlainRtpTransport) Tuple() TransportTuple {
@@ -34,6 +39,15 @@ func (t PlainRtpTransport) RtcpTuple() *TransportTuple {
 	return t.data.RtcpTuple
 }
 
+func (t *PlainRtpTransport) handleWorkerNotifications() {
+	t.subscription = t.channel.subscribe(t.internal.TransportId, t.channel.profiledListener(t.internal.RouterId, func(event string, rawData json.RawMessage) {
+		switch event {
+		case "trace":
+			t.handleTrace(rawData)
+		}
+	}))
+}
+
 /**
  * Provide the PlainRtpTransport remote parameters.
  *
@@ -43,7 +57,7 @@ func (t PlainRtpTransport) RtcpTuple() *TransportTuple {
  *
  * @override
  */
-func (t *PlainRtpTransport) Connect(params transportConnectParams) (err error) {
+func (t *PlainRtpTransport) Connect(params TransportConnectParams) (err error) {
 	t.logger.Debug("connect()")
 
 	resp := t.channel.Request("transport.connect", t.internal, params)
@@ -58,7 +72,7 @@ func (t *PlainRtpTransport) Connect(params transportConnectParams) (err error) {
  * @override
  * @returns {Consumer}
  */
-func (t *PlainRtpTransport) Consume(params transportConsumeParams) (*Consumer, error) {
+func (t *PlainRtpTransport) Consume(params TransportConsumeParams) (*Consumer, error) {
 	if t.data.MultiSource {
 		return nil, errors.New("cannot call consume() with multiSource set")
 	}
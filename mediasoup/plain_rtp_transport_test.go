@@ -47,13 +47,9 @@ func TestCreatePlainRtpTransport_Succeeds(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	type Dump struct {
-		TransportIds []string
-	}
-	var data Dump
-	router.Dump().Unmarshal(&data)
+	data, _ := router.Dump()
 
-	assertJSONEq(t, Dump{TransportIds: []string{transport.Id()}}, data)
+	assert.Equal(t, []string{transport.Id()}, data.TransportIds)
 
 	var plainTransport *PlainRtpTransport
 	called := 0
@@ -79,16 +75,15 @@ func TestCreatePlainRtpTransport_Succeeds(t *testing.T) {
 	assert.Equal(t, transport1.Tuple().Protocol, "udp")
 	assert.Empty(t, transport1.RtcpTuple())
 
-	var data1 map[string]interface{}
-	transport1.Dump().Unmarshal(&data1)
+	data1, _ := transport1.Dump()
 
-	assert.Equal(t, data1["id"], transport1.Id())
-	assert.Empty(t, data1["producerIds"])
-	assert.Empty(t, data1["consumerIds"])
-	assertJSONEq(t, data1["tuple"], transport1.Tuple())
-	assertJSONEq(t, data1["rtcpTuple"], transport1.RtcpTuple())
-	assert.NotNil(t, data1["rtpHeaderExtensions"])
-	assert.NotNil(t, data1["rtpListener"])
+	assert.Equal(t, data1.Id, transport1.Id())
+	assert.Empty(t, data1.ProducerIds)
+	assert.Empty(t, data1.ConsumerIds)
+	assertJSONEq(t, data1.Tuple, transport1.Tuple())
+	assertJSONEq(t, data1.RtcpTuple, transport1.RtcpTuple())
+	assert.NotNil(t, data1.RtpHeaderExtensions)
+	assert.NotNil(t, data1.RtpListener)
 
 	transport1.Close()
 
@@ -118,24 +113,23 @@ func TestCreatePlainRtpTransport_Succeeds(t *testing.T) {
 	assert.NotEmpty(t, transport2.RtcpTuple().LocalPort)
 	assert.Equal(t, transport2.RtcpTuple().Protocol, "udp")
 
-	var data2 map[string]interface{}
-	transport2.Dump().Unmarshal(&data2)
+	data2, _ := transport2.Dump()
 
-	assert.Equal(t, data2["id"], transport2.Id())
-	assertJSONEq(t, data2["tuple"], transport2.Tuple())
-	assertJSONEq(t, data2["rtcpTuple"], transport2.RtcpTuple())
+	assert.Equal(t, data2.Id, transport2.Id())
+	assertJSONEq(t, data2.Tuple, transport2.Tuple())
+	assertJSONEq(t, data2.RtcpTuple, transport2.RtcpTuple())
 }
 
 func TestCreatePlainRtpTransport_TypeError(t *testing.T) {
 	router, _ := worker.CreateRouter(testPlainMediaCodecs)
 
 	_, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{})
-	assert.IsType(t, err, NewTypeError(""))
+	assert.Equal(t, NewValidationError("CreatePlainRtpTransportParams.ListenIp.Ip", "missing address"), err)
 
 	_, err = router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
 		ListenIp: ListenIp{Ip: "123"},
 	})
-	assert.IsType(t, err, NewTypeError(""))
+	assert.Error(t, err)
 }
 
 func TestCreatePlainRtpTransport_Error(t *testing.T) {
@@ -175,14 +169,14 @@ func TestPlaintRtpTransport_Connect_Succeeds(t *testing.T) {
 		RtcpMux:  false,
 	})
 
-	err := transport.Connect(transportConnectParams{
+	err := transport.Connect(TransportConnectParams{
 		Ip:       "1.2.3.4",
 		Port:     1234,
 		RtcpPort: 1235,
 	})
 	assert.NoError(t, err)
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		Ip:       "1.2.3.4",
 		Port:     1234,
 		RtcpPort: 1235,
@@ -205,15 +199,15 @@ func TestPlaintRtpTransport_Connect_TypeError(t *testing.T) {
 		ListenIp: ListenIp{Ip: "127.0.0.1", AnnouncedIp: "4.4.4.4"},
 		RtcpMux:  false,
 	})
-	err := transport.Connect(transportConnectParams{})
+	err := transport.Connect(TransportConnectParams{})
 	assert.IsType(t, err, NewTypeError(""))
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		Ip: "::::1234",
 	})
 	assert.IsType(t, err, NewTypeError(""))
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		Ip:   "127.0.0.1",
 		Port: 1234,
 	})
@@ -236,12 +230,13 @@ func TestPlainRtpTransport_Reject_If_Closed(t *testing.T) {
 	assert.Equal(t, called, 1)
 	assert.True(t, transport.Closed())
 
-	assert.Error(t, transport.Dump().Err())
+	_, err := transport.Dump()
+	assert.Error(t, err)
 
-	_, err := transport.GetStats()
+	_, err = transport.GetStats()
 	assert.Error(t, err)
 
-	assert.Error(t, transport.Connect(transportConnectParams{}))
+	assert.Error(t, transport.Connect(TransportConnectParams{}))
 }
 
 func TestPlaintRtpTransport_Emits_Routerclose_If_RouterClosed(t *testing.T) {
@@ -0,0 +1,369 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PortMapping is a port a Worker listens on.
+type PortMapping struct {
+	WorkerPid int `json:"workerPid"`
+	// "udp" or "tcp".
+	Protocol string `json:"protocol"`
+	// Listening IP, the announced one for the WebRtcTransports not using a
+	// WebRtcServer.
+	Ip          string `json:"ip"`
+	AnnouncedIp string `json:"announcedIp,omitempty"`
+	Port        uint16 `json:"port"`
+	// "webrtcserver", "webrtctransport", "plaintransport",
+	// "plaintransport-rtcp" or "pipetransport".
+	Purpose string `json:"purpose"`
+	// Id of the WebRtcServer or Transport listening on the port.
+	OwnerId string `json:"ownerId"`
+}
+
+// PortRange is the range of the ports a Worker picks those of its transports
+// not using a WebRtcServer from.
+type PortRange struct {
+	WorkerPid int    `json:"workerPid"`
+	MinPort   uint16 `json:"minPort"`
+	MaxPort   uint16 `json:"maxPort"`
+}
+
+// PortMapState is the ports listened on as of the change with sequence
+// number Seq.
+type PortMapState struct {
+	Seq      uint64        `json:"seq"`
+	Ranges   []PortRange   `json:"ranges"`
+	Mappings []PortMapping `json:"mappings"`
+}
+
+// PortMap maintains the ports the watched Workers listen on, for the
+// automation programming firewalls (cloud security groups, nftables...):
+// either open the port ranges once, or open exactly the mapped ports, applying
+// the state of each "change" event whose Seq is greater than the last
+// applied one. The WebRtcTransports using a WebRtcServer share its ports, so
+// add no mapping.
+//
+// @emits {state: PortMapState} change
+type PortMap struct {
+	EventEmitter
+	mu     sync.Mutex
+	logger logrus.FieldLogger
+	seq    uint64
+	ranges map[int]PortRange
+	// Mappings by owner id.
+	mappings map[string][]PortMapping
+	// Watched WebRtcServers by Worker pid.
+	webRtcServers map[int]map[string]*WebRtcServer
+}
+
+func NewPortMap() *PortMap {
+	logger := TypeLogger("PortMap")
+
+	logger.Debug("constructor()")
+
+	return &PortMap{
+		EventEmitter:  NewEventEmitter(AppLogger()),
+		logger:        logger,
+		ranges:        make(map[int]PortRange),
+		mappings:      make(map[string][]PortMapping),
+		webRtcServers: make(map[int]map[string]*WebRtcServer),
+	}
+}
+
+// State returns the current ports.
+func (m *PortMap) State() PortMapState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.state()
+}
+
+// ServeHTTP writes the current ports as JSON.
+func (m *PortMap) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.State())
+}
+
+// WatchWorker maps the ports of the given Worker and of its existing and
+// future WebRtcServers and transports.
+func (m *PortMap) WatchWorker(worker *Worker) {
+	pid := worker.Pid()
+
+	m.update(func() {
+		if worker.rtcMaxPort > 0 {
+			m.ranges[pid] = PortRange{
+				WorkerPid: pid,
+				MinPort:   worker.rtcMinPort,
+				MaxPort:   worker.rtcMaxPort,
+			}
+		}
+		m.webRtcServers[pid] = make(map[string]*WebRtcServer)
+	})
+
+	worker.Observer().On("close", func() {
+		m.update(func() {
+			delete(m.ranges, pid)
+			delete(m.webRtcServers, pid)
+
+			for ownerId, mappings := range m.mappings {
+				if mappings[0].WorkerPid == pid {
+					delete(m.mappings, ownerId)
+				}
+			}
+		})
+	})
+
+	// The WebRtcServers are visited first, their WebRtcTransports adding no
+	// mapping.
+	EntityVisitor{
+		WebRtcServer: func(server *WebRtcServer) {
+			m.watchWebRtcServer(pid, server)
+		},
+		Transport: func(router *Router, transport Transport) {
+			m.watchTransport(pid, transport)
+		},
+	}.WalkWorker(worker)
+}
+
+func (m *PortMap) watchWebRtcServer(pid int, server *WebRtcServer) {
+	m.mu.Lock()
+	if servers, ok := m.webRtcServers[pid]; ok {
+		servers[server.Id()] = server
+	}
+	m.mu.Unlock()
+
+	server.Observer().On("close", func() {
+		m.update(func() {
+			delete(m.webRtcServers[pid], server.Id())
+			delete(m.mappings, server.Id())
+		})
+	})
+
+	mappings, resolved := webRtcServerMappings(pid, server, nil)
+
+	if resolved {
+		m.setMappings(server, server.Id(), mappings)
+		return
+	}
+
+	// Free ports were picked by the worker, as given by the dump.
+	spawn("portMap.webRtcServer", func() {
+		var dump webRtcServerDump
+
+		if err := server.Dump().Unmarshal(&dump); err != nil {
+			m.logger.Warnf("free ports of WebRtcServer unknown [id:%s]: %s", server.Id(), err)
+		}
+
+		mappings, _ := webRtcServerMappings(pid, server, &dump)
+
+		m.setMappings(server, server.Id(), mappings)
+	})
+}
+
+func (m *PortMap) watchTransport(pid int, transport Transport) {
+	var mappings []PortMapping
+
+	add := func(purpose string, tuple TransportTuple) {
+		if tuple.LocalPort == 0 {
+			return
+		}
+		mappings = append(mappings, PortMapping{
+			WorkerPid: pid,
+			Protocol:  tuple.Protocol,
+			Ip:        tuple.LocalIp,
+			Port:      tuple.LocalPort,
+			Purpose:   purpose,
+			OwnerId:   transport.Id(),
+		})
+	}
+
+	switch t := transport.(type) {
+	case *WebRtcTransport:
+		if m.usesWebRtcServer(pid, t) {
+			return
+		}
+		for _, candidate := range t.IceCandidates() {
+			add("webrtctransport", TransportTuple{
+				LocalIp:   candidate.Ip,
+				LocalPort: candidate.Port,
+				Protocol:  candidate.Protocol,
+			})
+		}
+	case *PlainRtpTransport:
+		add("plaintransport", t.Tuple())
+
+		if rtcpTuple := t.RtcpTuple(); rtcpTuple != nil {
+			add("plaintransport-rtcp", *rtcpTuple)
+		}
+	case *PipeTransport:
+		add("pipetransport", t.Tuple())
+	}
+
+	if len(mappings) == 0 {
+		return
+	}
+
+	transport.Observer().On("close", func() {
+		m.update(func() {
+			delete(m.mappings, transport.Id())
+		})
+	})
+
+	m.setMappings(transport, transport.Id(), mappings)
+}
+
+func (m *PortMap) usesWebRtcServer(pid int, transport *WebRtcTransport) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, server := range m.webRtcServers[pid] {
+		if server.hasWebRtcTransport(transport.Id()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setMappings sets the mappings of the given owner, unless it was closed
+// meanwhile.
+func (m *PortMap) setMappings(owner interface{ Closed() bool }, ownerId string, mappings []PortMapping) {
+	if len(mappings) == 0 {
+		return
+	}
+
+	m.update(func() {
+		if !owner.Closed() {
+			m.mappings[ownerId] = mappings
+		}
+	})
+}
+
+// update applies the given change and emits the resulting state.
+func (m *PortMap) update(change func()) {
+	m.mu.Lock()
+	change()
+	m.seq++
+	state := m.state()
+	m.mu.Unlock()
+
+	m.SafeEmit("change", state)
+}
+
+func (m *PortMap) state() PortMapState {
+	state := PortMapState{
+		Seq:      m.seq,
+		Ranges:   make([]PortRange, 0, len(m.ranges)),
+		Mappings: []PortMapping{},
+	}
+
+	for _, portRange := range m.ranges {
+		state.Ranges = append(state.Ranges, portRange)
+	}
+	for _, mappings := range m.mappings {
+		state.Mappings = append(state.Mappings, mappings...)
+	}
+
+	sort.Slice(state.Ranges, func(i, j int) bool {
+		return state.Ranges[i].WorkerPid < state.Ranges[j].WorkerPid
+	})
+	sort.Slice(state.Mappings, func(i, j int) bool {
+		a, b := state.Mappings[i], state.Mappings[j]
+
+		if a.WorkerPid != b.WorkerPid {
+			return a.WorkerPid < b.WorkerPid
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.Ip != b.Ip {
+			return a.Ip < b.Ip
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.OwnerId < b.OwnerId
+	})
+
+	return state
+}
+
+// webRtcServerDump is the part of the WebRtcServer dump giving its sockets.
+type webRtcServerDump struct {
+	UdpSockets []webRtcServerSocket `json:"udpSockets"`
+	TcpServers []webRtcServerSocket `json:"tcpServers"`
+}
+
+type webRtcServerSocket struct {
+	Ip   string `json:"ip"`
+	Port uint16 `json:"port"`
+}
+
+// webRtcServerMappings returns the mappings of the listen infos of the given
+// WebRtcServer, the free ports being taken from the dump if given, and
+// whether no port was left unknown.
+func webRtcServerMappings(
+	pid int, server *WebRtcServer, dump *webRtcServerDump,
+) (mappings []PortMapping, resolved bool) {
+	resolved = true
+
+	// Sockets of the dump not matched yet, by protocol.
+	sockets := map[string][]webRtcServerSocket{}
+
+	if dump != nil {
+		sockets["udp"] = dump.UdpSockets
+		sockets["tcp"] = dump.TcpServers
+
+		for _, info := range server.listenInfos {
+			if info.Port > 0 {
+				sockets[info.Protocol] = removeWebRtcServerSocket(sockets[info.Protocol], info.Ip, info.Port)
+			}
+		}
+	}
+
+	for _, info := range server.listenInfos {
+		port := info.Port
+
+		if port == 0 {
+			for _, socket := range sockets[info.Protocol] {
+				if socket.Ip == info.Ip {
+					port = socket.Port
+					sockets[info.Protocol] = removeWebRtcServerSocket(sockets[info.Protocol], socket.Ip, socket.Port)
+					break
+				}
+			}
+		}
+		if port == 0 {
+			resolved = false
+			continue
+		}
+
+		mappings = append(mappings, PortMapping{
+			WorkerPid:   pid,
+			Protocol:    info.Protocol,
+			Ip:          info.Ip,
+			AnnouncedIp: info.AnnouncedIp,
+			Port:        port,
+			Purpose:     "webrtcserver",
+			OwnerId:     server.Id(),
+		})
+	}
+
+	return
+}
+
+func removeWebRtcServerSocket(sockets []webRtcServerSocket, ip string, port uint16) []webRtcServerSocket {
+	for i, socket := range sockets {
+		if socket.Ip == ip && socket.Port == port {
+			return append(sockets[:i:i], sockets[i+1:]...)
+		}
+	}
+
+	return sockets
+}
@@ -0,0 +1,191 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortMap_WatchWorker(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+	worker.rtcMinPort, worker.rtcMaxPort = 40000, 49999
+
+	portMap := NewPortMap()
+
+	var states []PortMapState
+
+	portMap.On("change", func(state PortMapState) {
+		states = append(states, state)
+	})
+
+	portMap.WatchWorker(worker)
+
+	assert.Equal(t, []PortRange{{WorkerPid: 1, MinPort: 40000, MaxPort: 49999}}, portMap.State().Ranges)
+
+	server, err := worker.CreateWebRtcServer(CreateWebRtcServerParams{
+		ListenInfos: []WebRtcServerListenInfo{
+			{Protocol: "udp", ListenIp: ListenIp{Ip: "127.0.0.1", AnnouncedIp: "1.2.3.4"}, Port: 44444},
+			{Protocol: "tcp", ListenIp: ListenIp{Ip: "127.0.0.1", AnnouncedIp: "1.2.3.4"}, Port: 44444},
+		},
+	})
+	require.NoError(t, err)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	onServer := NewWebRtcTransport(WebRtcTransportData{
+		IceCandidates: []IceCandidate{{Ip: "1.2.3.4", Port: 44444, Protocol: "udp"}},
+	}, createTransportParams{
+		Internal: internalData{RouterId: router.Id(), TransportId: "onserver"},
+		Channel:  worker.channel,
+	})
+	require.NoError(t, server.handleWebRtcTransport(onServer))
+	router.Observer().SafeEmit("newtransport", onServer)
+
+	webRtc := NewWebRtcTransport(WebRtcTransportData{
+		IceCandidates: []IceCandidate{
+			{Ip: "1.2.3.4", Port: 40000, Protocol: "udp"},
+			{Ip: "1.2.3.4", Port: 40001, Protocol: "tcp"},
+		},
+	}, createTransportParams{
+		Internal: internalData{RouterId: router.Id(), TransportId: "webrtc"},
+		Channel:  worker.channel,
+	})
+	router.Observer().SafeEmit("newtransport", webRtc)
+
+	plain := NewPlainRtpTransport(PlainTransportData{
+		Tuple:     TransportTuple{LocalIp: "127.0.0.1", LocalPort: 40002, Protocol: "udp"},
+		RtcpTuple: &TransportTuple{LocalIp: "127.0.0.1", LocalPort: 40003, Protocol: "udp"},
+	}, createTransportParams{
+		Internal: internalData{RouterId: router.Id(), TransportId: "plain"},
+		Channel:  worker.channel,
+	})
+	router.Observer().SafeEmit("newtransport", plain)
+
+	pipe := NewPipeTransport(PipeTransportData{
+		Tuple: TransportTuple{LocalIp: "127.0.0.1", LocalPort: 40004, Protocol: "udp"},
+	}, createTransportParams{
+		Internal: internalData{RouterId: router.Id(), TransportId: "pipe"},
+		Channel:  worker.channel,
+	})
+	router.Observer().SafeEmit("newtransport", pipe)
+
+	assert.Equal(t, []PortMapping{
+		{WorkerPid: 1, Protocol: "tcp", Ip: "1.2.3.4", Port: 40001, Purpose: "webrtctransport", OwnerId: "webrtc"},
+		{WorkerPid: 1, Protocol: "tcp", Ip: "127.0.0.1", AnnouncedIp: "1.2.3.4", Port: 44444, Purpose: "webrtcserver", OwnerId: server.Id()},
+		{WorkerPid: 1, Protocol: "udp", Ip: "1.2.3.4", Port: 40000, Purpose: "webrtctransport", OwnerId: "webrtc"},
+		{WorkerPid: 1, Protocol: "udp", Ip: "127.0.0.1", Port: 40002, Purpose: "plaintransport", OwnerId: "plain"},
+		{WorkerPid: 1, Protocol: "udp", Ip: "127.0.0.1", Port: 40003, Purpose: "plaintransport-rtcp", OwnerId: "plain"},
+		{WorkerPid: 1, Protocol: "udp", Ip: "127.0.0.1", Port: 40004, Purpose: "pipetransport", OwnerId: "pipe"},
+		{WorkerPid: 1, Protocol: "udp", Ip: "127.0.0.1", AnnouncedIp: "1.2.3.4", Port: 44444, Purpose: "webrtcserver", OwnerId: server.Id()},
+	}, portMap.State().Mappings)
+
+	// Transports and servers closing.
+	seq := portMap.State().Seq
+
+	plain.Close()
+	server.Close()
+
+	state := portMap.State()
+	assert.Equal(t, seq+2, state.Seq)
+	assert.Len(t, state.Mappings, 3)
+
+	for _, mapping := range state.Mappings {
+		assert.NotEqual(t, "plain", mapping.OwnerId)
+		assert.NotEqual(t, server.Id(), mapping.OwnerId)
+	}
+
+	require.NotEmpty(t, states)
+	assert.Equal(t, state, states[len(states)-1])
+
+	// Worker closing.
+	worker.observer.SafeEmit("close")
+
+	state = portMap.State()
+	assert.Empty(t, state.Ranges)
+	assert.Empty(t, state.Mappings)
+}
+
+func TestPortMap_WatchWorker_ExistingEntities(t *testing.T) {
+	fake := newFakeWorker(t)
+	fake.reply("router.createPlainRtpTransport", func(request fakeWorkerRequest) (interface{}, error) {
+		return H{"tuple": H{"localIp": "127.0.0.1", "localPort": 40002, "protocol": "udp"}}, nil
+	})
+	worker := fake.worker(1)
+
+	server, err := worker.CreateWebRtcServer(CreateWebRtcServerParams{
+		ListenInfos: []WebRtcServerListenInfo{
+			{Protocol: "udp", ListenIp: ListenIp{Ip: "127.0.0.1"}, Port: 44444},
+		},
+	})
+	require.NoError(t, err)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	plain, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	portMap := NewPortMap()
+	portMap.WatchWorker(worker)
+
+	assert.Equal(t, []PortMapping{
+		{WorkerPid: 1, Protocol: "udp", Ip: "127.0.0.1", Port: 40002, Purpose: "plaintransport", OwnerId: plain.Id()},
+		{WorkerPid: 1, Protocol: "udp", Ip: "127.0.0.1", Port: 44444, Purpose: "webrtcserver", OwnerId: server.Id()},
+	}, portMap.State().Mappings)
+
+	plain.Close()
+	assert.Len(t, portMap.State().Mappings, 1)
+}
+
+func TestPortMap_ServeHTTP(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+	worker.rtcMinPort, worker.rtcMaxPort = 40000, 49999
+
+	portMap := NewPortMap()
+	portMap.WatchWorker(worker)
+
+	w := httptest.NewRecorder()
+	portMap.ServeHTTP(w, httptest.NewRequest("GET", "/ports", nil))
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var state PortMapState
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	assert.Equal(t, portMap.State(), state)
+}
+
+func TestWebRtcServerMappings(t *testing.T) {
+	server := NewWebRtcServer(internalData{WebRtcServerId: "server"}, nil, nil)
+	server.listenInfos = []WebRtcServerListenInfo{
+		{Protocol: "udp", ListenIp: ListenIp{Ip: "10.0.0.1"}, Port: 44444},
+		{Protocol: "udp", ListenIp: ListenIp{Ip: "10.0.0.1"}},
+		{Protocol: "tcp", ListenIp: ListenIp{Ip: "10.0.0.1"}},
+	}
+
+	mappings, resolved := webRtcServerMappings(1, server, nil)
+	assert.False(t, resolved)
+	assert.Equal(t, []PortMapping{
+		{WorkerPid: 1, Protocol: "udp", Ip: "10.0.0.1", Port: 44444, Purpose: "webrtcserver", OwnerId: "server"},
+	}, mappings)
+
+	mappings, resolved = webRtcServerMappings(1, server, &webRtcServerDump{
+		UdpSockets: []webRtcServerSocket{{Ip: "10.0.0.1", Port: 44444}, {Ip: "10.0.0.1", Port: 45678}},
+		TcpServers: []webRtcServerSocket{{Ip: "10.0.0.1", Port: 45679}},
+	})
+	assert.True(t, resolved)
+	assert.Equal(t, []PortMapping{
+		{WorkerPid: 1, Protocol: "udp", Ip: "10.0.0.1", Port: 44444, Purpose: "webrtcserver", OwnerId: "server"},
+		{WorkerPid: 1, Protocol: "udp", Ip: "10.0.0.1", Port: 45678, Purpose: "webrtcserver", OwnerId: "server"},
+		{WorkerPid: 1, Protocol: "tcp", Ip: "10.0.0.1", Port: 45679, Purpose: "webrtcserver", OwnerId: "server"},
+	}, mappings)
+}
@@ -0,0 +1,108 @@
+package mediasoup
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+)
+
+var profileLabels = struct {
+	sync.RWMutex
+	// Read on every notification, accessed atomically.
+	enabled int32
+	// Label key/value pairs by Router id.
+	routers map[string][]string
+}{
+	routers: make(map[string][]string),
+}
+
+// EnableProfileLabels makes the goroutines of the library carry pprof labels,
+// so that CPU profiles can be attributed to specific workers and rooms:
+// "mediasoup" (goroutine name, e.g. "channel.readLoop"), "worker" (worker pid)
+// and, when handling notifications of Router entities, the labels set with
+// Router.SetProfileLabels.
+func EnableProfileLabels(enabled bool) {
+	var value int32
+
+	if enabled {
+		value = 1
+	}
+
+	atomic.StoreInt32(&profileLabels.enabled, value)
+}
+
+func profileLabelsEnabled() bool {
+	return atomic.LoadInt32(&profileLabels.enabled) == 1
+}
+
+// SetProfileLabels sets the pprof labels (key/value pairs, e.g. "room",
+// "room1") of the goroutines handling the notifications of the Router
+// entities. See EnableProfileLabels.
+func (router *Router) SetProfileLabels(labels ...string) {
+	if len(labels)%2 != 0 {
+		router.logger.Warn("setProfileLabels() | odd number of labels, ignoring the last one")
+		labels = labels[:len(labels)-1]
+	}
+
+	profileLabels.Lock()
+	defer profileLabels.Unlock()
+
+	if _, ok := profileLabels.routers[router.Id()]; !ok {
+		router.observer.Once("close", func() {
+			profileLabels.Lock()
+			defer profileLabels.Unlock()
+
+			delete(profileLabels.routers, router.Id())
+		})
+	}
+
+	profileLabels.routers[router.Id()] = labels
+}
+
+// DoWithProfileLabels calls fn with the Router pprof labels, e.g. in
+// application goroutines polling the stats of a room.
+func (router *Router) DoWithProfileLabels(fn func()) {
+	doWithProfileLabels(fn, routerProfileLabels(router.Id())...)
+}
+
+func routerProfileLabels(routerId string) []string {
+	profileLabels.RLock()
+	defer profileLabels.RUnlock()
+
+	return profileLabels.routers[routerId]
+}
+
+func doWithProfileLabels(fn func(), labels ...string) {
+	if !profileLabelsEnabled() || len(labels) == 0 {
+		fn()
+		return
+	}
+
+	pprof.Do(context.Background(), pprof.Labels(labels...), func(context.Context) {
+		fn()
+	})
+}
+
+// profiledListener wraps a listener of the notifications of a Router entity to
+// run it with the Router pprof labels, when enabled.
+func (c *Channel) profiledListener(
+	routerId string, listener func(event string, data json.RawMessage),
+) func(event string, data json.RawMessage) {
+	return func(event string, data json.RawMessage) {
+		if !profileLabelsEnabled() {
+			listener(event, data)
+			return
+		}
+
+		labels := append([]string{
+			"mediasoup", "channel.notification",
+			"worker", c.pid,
+		}, routerProfileLabels(routerId)...)
+
+		doWithProfileLabels(func() {
+			listener(event, data)
+		}, labels...)
+	}
+}
@@ -0,0 +1,43 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterSetProfileLabels(t *testing.T) {
+	EnableProfileLabels(true)
+	defer EnableProfileLabels(false)
+
+	router, err := worker.CreateRouter(testRouterMediaCodecs)
+	assert.NoError(t, err)
+
+	router.SetProfileLabels("room", "room1")
+	assert.Equal(t, []string{"room", "room1"}, routerProfileLabels(router.Id()))
+
+	called := false
+	router.DoWithProfileLabels(func() {
+		called = true
+	})
+	assert.True(t, called)
+
+	router.Close()
+	assert.Empty(t, routerProfileLabels(router.Id()))
+}
+
+func TestChannelProfiledListener_Disabled(t *testing.T) {
+	channel := newFakeWorker(t).channel
+
+	called := 0
+	listener := channel.profiledListener("router", func(event string, data json.RawMessage) {
+		called++
+	})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		listener("score", nil)
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, 101, called)
+}
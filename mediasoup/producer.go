@@ -1,8 +1,11 @@
 package mediasoup
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -16,17 +19,38 @@ type Producer struct {
 	channel  *Channel
 	appData  interface{}
 	paused   bool
-	closed   bool
+	closed   closeFlag
 	score    []ProducerScore
 	observer EventEmitter
+
+	// Limits the "score" events, nil if not rate limited.
+	scoreThrottle *notificationThrottle
+	// Consumers of the Producer, by id.
+	consumers       map[string]*Consumer
+	consumersLocker sync.Mutex
+	// Route of the worker notifications.
+	subscription notificationSubscription
+	// Announced simulcast encodings without a scored stream, see
+	// checkSimulcastMapping.
+	simulcastLocker       sync.Mutex
+	simulcastScore        []ProducerScore
+	simulcastMissingSince time.Time
+	simulcastTimer        *time.Timer
+	// Reason of the last emitted simulcast mismatch.
+	simulcastMismatch string
 }
 
+// How long announced simulcast encodings may stay without a scored stream
+// before "simulcastmismatch" is emitted.
+var simulcastMissingTimeout = 5 * time.Second
+
 /**
  * New Producer.
  *
  * @emits transportclose
  * @emits {Array<Object>} score
  * @emits {Object} videoorientationchange
+ * @emits {SimulcastMismatch} simulcastmismatch
  * @emits @close
  */
 func NewProducer(
@@ -36,12 +60,16 @@ func NewProducer(
 	appData interface{},
 	paused bool,
 ) *Producer {
-	logger := TypeLogger("Producer")
+	appLogger := entityAppLogger(data.AppLogger)
+	logger := appLogger.WithField("type", "Producer")
 
 	logger.Debug("constructor()")
 
+	closed, ctx := newCloseFlag()
+
 	producer := &Producer{
-		EventEmitter: NewEventEmitter(logger),
+		EventEmitter: NewEventEmitter(logger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		closed:       closed,
 		logger:       logger,
 		// - .routerId
 		// - .transportId
@@ -51,7 +79,10 @@ func NewProducer(
 		channel:  channel,
 		appData:  appData,
 		paused:   paused,
-		observer: NewEventEmitter(AppLogger()),
+		observer: NewEventEmitter(appLogger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+
+		scoreThrottle: newNotificationThrottle(data.ScoreInterval),
+		consumers:     make(map[string]*Consumer),
 	}
 
 	producer.handleWorkerNotifications()
@@ -66,7 +97,7 @@ func (producer *Producer) Id() string {
 
 // Whether the Producer is closed.
 func (producer *Producer) Closed() bool {
-	return producer.closed
+	return producer.closed.isSet()
 }
 
 // Media kind.
@@ -113,6 +144,7 @@ func (producer *Producer) AppData() interface{} {
  * @emits resume
  * @emits {[]ProducerScore} score
  * @emits {Object} videoorientationchange
+ * @emits {SimulcastMismatch} simulcastmismatch
  */
 func (producer *Producer) Observer() EventEmitter {
 	return producer.observer
@@ -120,21 +152,22 @@ func (producer *Producer) Observer() EventEmitter {
 
 // Close the Producer.
 func (producer *Producer) Close() (err error) {
-	if producer.closed {
+	if !producer.closed.set() {
 		return
 	}
-
-	producer.closed = true
+	defer producer.closed.cancelContext()
 
 	producer.logger.Debug("close()")
 
-	producer.channel.RemoveAllListeners(producer.internal.ProducerId)
+	producer.channel.unsubscribe(producer.subscription)
+
+	producer.simulcastLocker.Lock()
+	producer.stopSimulcastTimer()
+	producer.simulcastLocker.Unlock()
 
 	response := producer.channel.Request("producer.close", producer.internal, nil)
 
-	if err = response.Err(); err != nil {
-		return
-	}
+	err = response.Err()
 
 	producer.Emit("@close")
 
@@ -146,14 +179,17 @@ func (producer *Producer) Close() (err error) {
 
 // Transport was closed.
 func (producer *Producer) TransportClosed() {
-	if producer.closed {
+	if !producer.closed.set() {
 		return
 	}
-
-	producer.closed = true
+	defer producer.closed.cancelContext()
 
 	producer.logger.Debug("transportClosed()")
 
+	producer.simulcastLocker.Lock()
+	producer.stopSimulcastTimer()
+	producer.simulcastLocker.Unlock()
+
 	producer.SafeEmit("transportclose")
 
 	// Emit observer event.
@@ -164,14 +200,14 @@ func (producer *Producer) TransportClosed() {
 func (producer *Producer) Dump() Response {
 	producer.logger.Debug("dump()")
 
-	return producer.channel.Request("producer.dump", producer.internal, nil)
+	return producer.channel.requestIdempotent(context.Background(), "producer.dump", producer.internal, nil)
 }
 
 // Get Producer stats.
 func (producer *Producer) GetStats() Response {
 	producer.logger.Debug("getStats()")
 
-	return producer.channel.Request("producer.getStats", producer.internal, nil)
+	return producer.channel.requestIdempotent(context.Background(), "producer.getStats", producer.internal, nil)
 }
 
 // Pause the Producer.
@@ -213,8 +249,10 @@ func (producer *Producer) Resume() (err error) {
 
 	producer.paused = false
 
-	// Emit observer event.
 	if wasPaused {
+		producer.requestResumeKeyFrame()
+
+		// Emit observer event.
 		producer.observer.SafeEmit("resume")
 	}
 
@@ -222,17 +260,27 @@ func (producer *Producer) Resume() (err error) {
 }
 
 func (producer *Producer) handleWorkerNotifications() {
-	producer.channel.On(producer.internal.ProducerId, func(event string, data json.RawMessage) {
+	producer.subscription = producer.channel.subscribe(producer.internal.ProducerId, producer.channel.profiledListener(producer.internal.RouterId, func(event string, data json.RawMessage) {
 		switch event {
 		case "score":
 			producer.score = []ProducerScore{}
 
 			json.Unmarshal([]byte(data), &producer.score)
 
-			producer.SafeEmit("score", producer.score)
+			score := producer.score
 
-			// Emit observer event.
-			producer.observer.SafeEmit("score", producer.score)
+			producer.scoreThrottle.emit(func() {
+				if producer.Closed() {
+					return
+				}
+
+				producer.SafeEmit("score", score)
+
+				// Emit observer event.
+				producer.observer.SafeEmit("score", score)
+			})
+
+			producer.checkSimulcastMapping(score)
 
 		case "videoorientationchange":
 			orientation := VideoOrientation{}
@@ -247,5 +295,127 @@ func (producer *Producer) handleWorkerNotifications() {
 		default:
 			producer.logger.Errorf(`ignoring unknown event "%s"`, event)
 		}
-	})
+	}))
+}
+
+// checkSimulcastMapping records the streams in the current score and, when
+// announced simulcast encodings have no scored stream, schedules the
+// "simulcastmismatch" report.
+func (producer *Producer) checkSimulcastMapping(score []ProducerScore) {
+	if producer.data.Type != "simulcast" {
+		return
+	}
+
+	producer.simulcastLocker.Lock()
+
+	producer.simulcastScore = score
+
+	if _, missing := producer.mapSimulcastEncodings(score); len(missing) == 0 {
+		producer.stopSimulcastTimer()
+		producer.simulcastMissingSince = time.Time{}
+		producer.simulcastMismatch = ""
+		producer.simulcastLocker.Unlock()
+
+		return
+	}
+
+	if producer.simulcastMissingSince.IsZero() {
+		producer.simulcastMissingSince = time.Now()
+		producer.simulcastTimer = time.AfterFunc(simulcastMissingTimeout, producer.reportSimulcastMismatch)
+	}
+
+	// Encodings are missing for longer than the timeout, report at once.
+	pending := producer.simulcastTimer != nil
+
+	producer.simulcastLocker.Unlock()
+
+	if !pending {
+		producer.reportSimulcastMismatch()
+	}
+}
+
+func (producer *Producer) stopSimulcastTimer() {
+	if producer.simulcastTimer != nil {
+		producer.simulcastTimer.Stop()
+		producer.simulcastTimer = nil
+	}
+}
+
+// reportSimulcastMismatch emits "simulcastmismatch" once per distinct set of
+// announced encodings left without a scored stream.
+func (producer *Producer) reportSimulcastMismatch() {
+	const ridUri = "urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id"
+
+	producer.simulcastLocker.Lock()
+
+	producer.simulcastTimer = nil
+
+	mapped, missing := producer.mapSimulcastEncodings(producer.simulcastScore)
+
+	if producer.Closed() || len(missing) == 0 {
+		producer.simulcastLocker.Unlock()
+		return
+	}
+
+	mismatch := SimulcastMismatch{
+		Reason: fmt.Sprintf("%d of %d announced encodings have no received stream",
+			len(missing), len(producer.data.RtpParameters.Encodings)),
+		MappedEncodings:  mapped,
+		MissingEncodings: missing,
+		RidNegotiated:    producer.hasHeaderExtension(ridUri),
+	}
+
+	if len(missing[0].Rid) > 0 && !mismatch.RidNegotiated {
+		mismatch.Reason += fmt.Sprintf(`, "%s" header extension not negotiated`, ridUri)
+	}
+
+	if mismatch.Reason == producer.simulcastMismatch {
+		producer.simulcastLocker.Unlock()
+		return
+	}
+
+	producer.simulcastMismatch = mismatch.Reason
+
+	producer.simulcastLocker.Unlock()
+
+	producer.logger.Warnf("simulcast mismatch: %s", mismatch.Reason)
+
+	producer.SafeEmit("simulcastmismatch", mismatch)
+
+	// Emit observer event.
+	producer.observer.SafeEmit("simulcastmismatch", mismatch)
+}
+
+// mapSimulcastEncodings splits the announced encodings into the ones having a
+// stream in the given score and the ones without.
+func (producer *Producer) mapSimulcastEncodings(score []ProducerScore) (mapped, missing []RtpEncoding) {
+	for _, encoding := range producer.data.RtpParameters.Encodings {
+		found := false
+
+		for _, stream := range score {
+			if (len(encoding.Rid) > 0 && stream.Rid == encoding.Rid) ||
+				(len(encoding.Rid) == 0 && encoding.Ssrc > 0 && stream.Ssrc == encoding.Ssrc) {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			mapped = append(mapped, encoding)
+		} else {
+			missing = append(missing, encoding)
+		}
+	}
+
+	return
+}
+
+func (producer *Producer) hasHeaderExtension(uri string) bool {
+	for _, ext := range producer.data.RtpParameters.HeaderExtensions {
+		if ext.Uri == uri {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,56 @@
+package mediasoup
+
+// addConsumer tracks a Consumer of the Producer until closed.
+func (producer *Producer) addConsumer(consumer *Consumer) {
+	producer.consumersLocker.Lock()
+	if producer.consumers == nil {
+		producer.consumers = make(map[string]*Consumer)
+	}
+	producer.consumers[consumer.Id()] = consumer
+	producer.consumersLocker.Unlock()
+
+	consumer.observer.Once("close", func() {
+		producer.consumersLocker.Lock()
+		delete(producer.consumers, consumer.Id())
+		producer.consumersLocker.Unlock()
+	})
+}
+
+// ConsumerCount returns the number of Consumers of the Producer, pipe ones
+// included.
+func (producer *Producer) ConsumerCount() int {
+	producer.consumersLocker.Lock()
+	defer producer.consumersLocker.Unlock()
+
+	return len(producer.consumers)
+}
+
+// requestResumeKeyFrame requests a key frame to the resumed video Producer
+// (e.g. created paused) through one of its Consumers, so that they can decode
+// it right away. No key frame is requested without Consumers, the ones
+// created later requesting their own.
+func (producer *Producer) requestResumeKeyFrame() {
+	if producer.Kind() != "video" {
+		return
+	}
+
+	var consumer *Consumer
+
+	producer.consumersLocker.Lock()
+	for _, c := range producer.consumers {
+		if !c.Paused() {
+			consumer = c
+			break
+		}
+	}
+	producer.consumersLocker.Unlock()
+
+	if consumer == nil {
+		producer.logger.Debug("resume() | no active Consumer, key frame not requested")
+		return
+	}
+
+	if err := consumer.RequestKeyFrame(); err != nil {
+		producer.logger.Warnf("resume() | key frame request failed: %s", err)
+	}
+}
@@ -0,0 +1,61 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducerResume_RequestsKeyFrameWithConsumers(t *testing.T) {
+	fake := newFakeWorker(t)
+	worker := fake.worker(1)
+
+	router, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "video", MimeType: "video/VP8", ClockRate: 90000},
+	})
+	require.NoError(t, err)
+
+	transport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind: "video",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "video/VP8", PayloadType: 101, ClockRate: 90000},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 22222222}},
+		},
+		Paused: true,
+	})
+	require.NoError(t, err)
+	assert.True(t, producer.Paused())
+
+	// Nobody to send a key frame to.
+	fake.takeMethods()
+	require.NoError(t, producer.Resume())
+	assert.Equal(t, []string{"producer.resume"}, fake.takeMethods())
+
+	require.NoError(t, producer.Pause())
+
+	consumer, err := transport.Consume(TransportConsumeParams{
+		ProducerId:      producer.Id(),
+		RtpCapabilities: router.RtpCapabilities(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, producer.ConsumerCount())
+
+	fake.takeMethods()
+	require.NoError(t, producer.Resume())
+	assert.Equal(t, []string{"producer.resume", "consumer.requestKeyFrame"}, fake.takeMethods())
+
+	// Not requested when not resuming.
+	require.NoError(t, producer.Resume())
+	assert.Equal(t, []string{"producer.resume"}, fake.takeMethods())
+
+	consumer.Close()
+	assert.Equal(t, 0, producer.ConsumerCount())
+}
@@ -3,9 +3,12 @@ package mediasoup
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/jiyeyuran/mediasoup-go/mediasoup/h264profile"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -92,23 +95,14 @@ func (suite *ProducerTestSuite) TestWebRtcTransportProduce_Succeeds() {
 	suite.Empty(audioProducer.Score())
 	assertJSONEq(suite.T(), H{"foo": 1, "bar": "2"}, audioProducer.AppData())
 
-	var routerDump struct {
-		MapProducerIdConsumerIds map[string][]string
-		MapConsumerIdProducerId  map[string]string
-	}
-	suite.router.Dump().Unmarshal(&routerDump)
+	routerDump, _ := suite.router.Dump()
 
 	consumerIds, ok := routerDump.MapProducerIdConsumerIds[audioProducer.Id()]
 	suite.True(ok)
 	suite.Empty(consumerIds)
 	suite.Empty(routerDump.MapConsumerIdProducerId)
 
-	var transportDump struct {
-		Id          string
-		ProducerIds []string
-		ConsumerIds []string
-	}
-	suite.webRtcTransport.Dump().Unmarshal(&transportDump)
+	transportDump, _ := suite.webRtcTransport.Dump()
 
 	suite.Equal(suite.webRtcTransport.Id(), transportDump.Id)
 	suite.Equal([]string{audioProducer.Id()}, transportDump.ProducerIds)
@@ -135,23 +129,14 @@ func (suite *ProducerTestSuite) TestPlainRtpTransportProduce_Succeeds() {
 	suite.Empty(videoProducer.Score())
 	assertJSONEq(suite.T(), H{"foo": 1, "bar": "2"}, videoProducer.AppData())
 
-	var routerDump struct {
-		MapProducerIdConsumerIds map[string][]string
-		MapConsumerIdProducerId  map[string]string
-	}
-	suite.router.Dump().Unmarshal(&routerDump)
+	routerDump, _ := suite.router.Dump()
 
 	consumerIds, ok := routerDump.MapProducerIdConsumerIds[videoProducer.Id()]
 	suite.True(ok)
 	suite.Empty(consumerIds)
 	suite.Empty(routerDump.MapConsumerIdProducerId)
 
-	var transportDump struct {
-		Id          string
-		ProducerIds []string
-		ConsumerIds []string
-	}
-	suite.plainRtpTransport.Dump().Unmarshal(&transportDump)
+	transportDump, _ := suite.plainRtpTransport.Dump()
 
 	suite.Equal(suite.plainRtpTransport.Id(), transportDump.Id)
 	suite.Equal([]string{videoProducer.Id()}, transportDump.ProducerIds)
@@ -161,18 +146,18 @@ func (suite *ProducerTestSuite) TestPlainRtpTransportProduce_Succeeds() {
 func (suite *ProducerTestSuite) TestWebRtcTransportProduce_TypeError() {
 	webRtcTransport := suite.webRtcTransport
 
-	_, err := webRtcTransport.Produce(transportProduceParams{
+	_, err := webRtcTransport.Produce(TransportProduceParams{
 		Kind: "chicken",
 	})
 	suite.IsType(NewTypeError(""), err)
 
-	_, err = webRtcTransport.Produce(transportProduceParams{
+	_, err = webRtcTransport.Produce(TransportProduceParams{
 		Kind: "audio",
 	})
 	suite.IsType(NewTypeError(""), err)
 
 	// Missing or empty rtpParameters.codecs.
-	_, err = webRtcTransport.Produce(transportProduceParams{
+	_, err = webRtcTransport.Produce(TransportProduceParams{
 		Kind: "audio",
 		RtpParameters: RtpParameters{
 			Encodings: []RtpEncoding{
@@ -211,7 +196,7 @@ func (suite *ProducerTestSuite) TestWebRtcTransportProduce_TypeError() {
 		}
 	  }
 	`
-	var produceParams transportProduceParams
+	var produceParams TransportProduceParams
 	json.Unmarshal([]byte(produceParamsJSON), &produceParams)
 	_, err = webRtcTransport.Produce(produceParams)
 	suite.IsType(NewTypeError(""), err)
@@ -274,7 +259,7 @@ func (suite *ProducerTestSuite) TestWebRtcTransportProduce_UnsupportedError() {
 	}
   }
 `
-	var produceParams transportProduceParams
+	var produceParams TransportProduceParams
 	json.Unmarshal([]byte(produceParamsJSON), &produceParams)
 	_, err := webRtcTransport.Produce(produceParams)
 	suite.IsType(NewUnsupportedError(""), err)
@@ -337,7 +322,7 @@ func (suite *ProducerTestSuite) TestWebRtcTransportProduce_WithAlreadyUsedMIDOrS
 		}
 	  }
 `
-	var produceParams transportProduceParams
+	var produceParams TransportProduceParams
 	json.Unmarshal([]byte(produceParamsJSON), &produceParams)
 	_, err := webRtcTransport.Produce(produceParams)
 	suite.Error(err)
@@ -366,6 +351,37 @@ func (suite *ProducerTestSuite) TestWebRtcTransportProduce_WithAlreadyUsedMIDOrS
 	suite.Error(err)
 }
 
+func (suite *ProducerTestSuite) TestPlainRtpTransportProduce_SsrcCollision() {
+	audioProducer := suite.audioProducer()
+
+	produceParams := TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{
+					MimeType:    "audio/opus",
+					PayloadType: 111,
+					ClockRate:   48000,
+					Channels:    2,
+				},
+			},
+			Encodings: []RtpEncoding{
+				{Ssrc: 11111111},
+			},
+		},
+	}
+
+	_, err := suite.plainRtpTransport.Produce(produceParams)
+	suite.Equal(SsrcCollisionError{Ssrc: 11111111, ProducerId: audioProducer.Id()}, err)
+
+	produceParams.RemapSsrcOnCollision = true
+
+	producer, err := suite.plainRtpTransport.Produce(produceParams)
+	suite.NoError(err)
+	suite.NotEqual(uint32(11111111), producer.RtpParameters().Encodings[0].Ssrc)
+	suite.Equal(uint32(11111111), produceParams.RtpParameters.Encodings[0].Ssrc)
+}
+
 func (suite *ProducerTestSuite) TestProduerDump_Succeeds() {
 	audioProducer := suite.audioProducer()
 
@@ -509,11 +525,11 @@ func (suite *ProducerTestSuite) TestProducerEmitsScore() {
 
 	videoProducer.On("score", onScore.Fn())
 
-	channel.Emit(videoProducer.Id(), "score",
+	channel.dispatch(videoProducer.Id(), "score",
 		json.RawMessage(`[ { "ssrc": 11, "score": 10 } ]`))
-	channel.Emit(videoProducer.Id(), "score",
+	channel.dispatch(videoProducer.Id(), "score",
 		json.RawMessage(`[ { "ssrc": 11, "score": 9 }, { "ssrc": 22, "score": 8 } ]`))
-	channel.Emit(videoProducer.Id(), "score",
+	channel.dispatch(videoProducer.Id(), "score",
 		json.RawMessage(`[ { "ssrc": 11, "score": 9 }, { "ssrc": 22, "score": 9 } ]`))
 
 	suite.Equal(3, onScore.CalledTimes())
@@ -523,6 +539,51 @@ func (suite *ProducerTestSuite) TestProducerEmitsScore() {
 	}, videoProducer.Score())
 }
 
+func (suite *ProducerTestSuite) TestProducerEmitsSimulcastMismatch() {
+	defer func(timeout time.Duration) { simulcastMissingTimeout = timeout }(simulcastMissingTimeout)
+	simulcastMissingTimeout = 50 * time.Millisecond
+
+	videoProducer := suite.videoProducer()
+	channel := videoProducer.channel
+	encodings := videoProducer.RtpParameters().Encodings
+
+	mismatches := make(chan SimulcastMismatch, 4)
+
+	videoProducer.On("simulcastmismatch", func(mismatch SimulcastMismatch) {
+		mismatches <- mismatch
+	})
+
+	channel.dispatch(videoProducer.Id(), "score",
+		json.RawMessage(`[ { "ssrc": 22222222, "score": 10 } ]`))
+	// All layers show up before the timeout.
+	channel.dispatch(videoProducer.Id(), "score",
+		json.RawMessage(`[ { "ssrc": 22222222, "score": 10 }, { "ssrc": 22222224, "score": 10 }, { "ssrc": 22222226, "score": 10 }, { "ssrc": 22222228, "score": 10 } ]`))
+
+	time.Sleep(2 * simulcastMissingTimeout)
+	suite.Empty(mismatches)
+
+	// Only one layer works.
+	channel.dispatch(videoProducer.Id(), "score",
+		json.RawMessage(`[ { "ssrc": 22222222, "score": 9 } ]`))
+
+	select {
+	case mismatch := <-mismatches:
+		suite.Equal(encodings[:1], mismatch.MappedEncodings)
+		suite.Equal(encodings[1:], mismatch.MissingEncodings)
+		suite.False(mismatch.RidNegotiated)
+		suite.Equal("3 of 4 announced encodings have no received stream", mismatch.Reason)
+	case <-time.After(time.Second):
+		suite.FailNow("simulcastmismatch not emitted")
+	}
+
+	// The same mismatch is not emitted again.
+	channel.dispatch(videoProducer.Id(), "score",
+		json.RawMessage(`[ { "ssrc": 22222222, "score": 8 } ]`))
+
+	time.Sleep(2 * simulcastMissingTimeout)
+	suite.Empty(mismatches)
+}
+
 func (suite *ProducerTestSuite) TestProduceClose_Succeeds() {
 	onObserverClose := NewMockFunc(suite.T())
 
@@ -533,21 +594,12 @@ func (suite *ProducerTestSuite) TestProduceClose_Succeeds() {
 	suite.Equal(1, onObserverClose.CalledTimes())
 	suite.True(audioProducer.Closed())
 
-	var routerDump struct {
-		MapProducerIdConsumerIds map[string][]string
-		MapConsumerIdProducerId  map[string]string
-	}
-	suite.router.Dump().Unmarshal(&routerDump)
+	routerDump, _ := suite.router.Dump()
 
 	suite.Empty(routerDump.MapProducerIdConsumerIds)
 	suite.Empty(routerDump.MapConsumerIdProducerId)
 
-	var transportDump struct {
-		Id          string
-		ProducerIds []string
-		ConsumerIds []string
-	}
-	suite.webRtcTransport.Dump().Unmarshal(&transportDump)
+	transportDump, _ := suite.webRtcTransport.Dump()
 
 	suite.Equal(suite.webRtcTransport.Id(), transportDump.Id)
 	suite.Empty(transportDump.ProducerIds)
@@ -617,10 +669,10 @@ func (suite *ProducerTestSuite) audioProducer() *Producer {
 		"appData" : { "foo":1, "bar":"2" }
 	  }
 	`
-	var transportProduceParams transportProduceParams
-	json.Unmarshal([]byte(transportProduceParamsJSON), &transportProduceParams)
+	var TransportProduceParams TransportProduceParams
+	json.Unmarshal([]byte(transportProduceParamsJSON), &TransportProduceParams)
 
-	audioProducer, err := suite.webRtcTransport.Produce(transportProduceParams)
+	audioProducer, err := suite.webRtcTransport.Produce(TransportProduceParams)
 	suite.NoError(err)
 
 	return audioProducer
@@ -690,10 +742,10 @@ func (suite *ProducerTestSuite) videoProducer() *Producer {
 		"appData" : { "foo":1, "bar":"2" }
 	  }
 	`
-	var transportProduceParams transportProduceParams
-	json.Unmarshal([]byte(transportProduceParamsJSON), &transportProduceParams)
+	var TransportProduceParams TransportProduceParams
+	json.Unmarshal([]byte(transportProduceParamsJSON), &TransportProduceParams)
 
-	producer, err := suite.plainRtpTransport.Produce(transportProduceParams)
+	producer, err := suite.plainRtpTransport.Produce(TransportProduceParams)
 	suite.NoError(err)
 
 	return producer
@@ -702,3 +754,97 @@ func (suite *ProducerTestSuite) videoProducer() *Producer {
 func TestProducerTestSuite(t *testing.T) {
 	suite.Run(t, new(ProducerTestSuite))
 }
+
+func TestProduce_SsrcCollisionsJustBetweenInjectedStreams(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	require.NoError(t, err)
+
+	produceParams := TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	}
+
+	// WebRTC endpoints choose their SSRCs independently.
+	for i := 0; i < 2; i++ {
+		transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+			ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+		})
+		require.NoError(t, err)
+
+		_, err = transport.Produce(produceParams)
+		require.NoError(t, err)
+	}
+
+	plainTransport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	_, err = plainTransport.Produce(produceParams)
+	assert.IsType(t, SsrcCollisionError{}, err)
+}
+
+func TestProduce_ReservesSsrcsWhileCreating(t *testing.T) {
+	fake := newFakeWorker(t)
+
+	routerRtpCapabilities, err := GenerateRouterRtpCapabilities(testRouterMediaCodecs)
+	require.NoError(t, err)
+
+	// Two PlainRtpTransports of a Router.
+	reservations := newSsrcReservations()
+	newPlainTransport := func() *baseTransport {
+		return newTransport(createTransportParams{
+			Channel: fake.channel,
+			GetRouterRtpCapabilities: func() RtpCapabilities {
+				return routerRtpCapabilities
+			},
+			GetProducerBySsrc: func(ssrc uint32) *Producer {
+				return nil
+			},
+			SsrcReservations: reservations,
+		})
+	}
+	transport1, transport2 := newPlainTransport(), newPlainTransport()
+
+	producing, release := make(chan struct{}), make(chan struct{})
+	fake.reply("transport.produce", func(request fakeWorkerRequest) (interface{}, error) {
+		close(producing)
+		<-release
+		return H{"type": "simple"}, nil
+	})
+
+	params := TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	}
+
+	// The dry runs don't reserve the SSRCs.
+	_, err = transport1.ProduceDryRun(params)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := transport1.Produce(params)
+		errCh <- err
+	}()
+	<-producing
+
+	_, err = transport2.Produce(params)
+	assert.Equal(t, SsrcCollisionError{Ssrc: 11111111}, err)
+
+	close(release)
+	require.NoError(t, <-errCh)
+	assert.Empty(t, reservations.ssrcs)
+}
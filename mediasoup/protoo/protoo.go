@@ -0,0 +1,742 @@
+// Package protoo serves the protoo signaling protocol of mediasoup-demo on top
+// of a mediasoup Router.
+package protoo
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/sirupsen/logrus"
+)
+
+// Time the peers are given to answer the protoo requests (e.g. newConsumer).
+var requestTimeout = 20 * time.Second
+
+// ErrPeerClosed is returned by the protoo requests to a closed peer.
+var ErrPeerClosed = errors.New("protoo peer closed")
+
+// Message is a message of the protoo signaling protocol of
+// mediasoup-demo: a request, its response or a notification.
+type Message struct {
+	Request      bool            `json:"request,omitempty"`
+	Response     bool            `json:"response,omitempty"`
+	Notification bool            `json:"notification,omitempty"`
+	Id           uint32          `json:"id,omitempty"`
+	Method       string          `json:"method,omitempty"`
+	Ok           bool            `json:"ok,omitempty"`
+	Data         json.RawMessage `json:"data,omitempty"`
+	ErrorCode    int             `json:"errorCode,omitempty"`
+	ErrorReason  string          `json:"errorReason,omitempty"`
+}
+
+// RoomOptions to serve the protoo signaling of a room.
+type RoomOptions struct {
+	// Router of the room.
+	Router *mediasoup.Router
+	// Options of the WebRtcTransports, its appData is ignored.
+	WebRtcTransportParams mediasoup.CreateWebRtcTransportParams
+}
+
+// Room serves the protoo requests of the peers of a mediasoup-demo room,
+// so that its clients (mediasoup-client apps speaking the demo protocol) work
+// unchanged while migrating their Node.js server to Go. It is independent of
+// the WebSocket library, the peers being given the function sending their
+// messages:
+//
+//	peer, err := room.Join(peerId, func(data []byte) error {
+//		return conn.WriteMessage(websocket.TextMessage, data)
+//	})
+//	for {
+//		_, data, err := conn.ReadMessage()
+//		if err != nil {
+//			peer.Close()
+//			break
+//		}
+//		peer.HandleMessage(data)
+//	}
+//
+// The requests getRouterRtpCapabilities, join, createWebRtcTransport,
+// connectWebRtcTransport, restartIce, produce, closeProducer, pauseProducer,
+// resumeProducer, pauseConsumer, resumeConsumer, setConsumerPreferredLayers,
+// requestConsumerKeyFrame, getTransportStats, getProducerStats,
+// getConsumerStats and changeDisplayName are supported, the DataChannel ones
+// are not.
+type Room struct {
+	mu      sync.Mutex
+	logger  logrus.FieldLogger
+	options RoomOptions
+	peers   map[string]*Peer
+}
+
+// Peer is a peer of a Room.
+type Peer struct {
+	mu              sync.Mutex
+	id              string
+	room            *Room
+	send            func(data []byte) error
+	closed          bool
+	joined          bool
+	displayName     string
+	device          mediasoup.H
+	rtpCapabilities *mediasoup.RtpCapabilities
+	transports      map[string]*mediasoup.WebRtcTransport
+	producers       map[string]*mediasoup.Producer
+	consumers       map[string]*mediasoup.Consumer
+	nextId          uint32
+	sents           map[uint32]chan Message
+}
+
+type peerInfo struct {
+	Id          string      `json:"id"`
+	DisplayName string      `json:"displayName"`
+	Device      mediasoup.H `json:"device"`
+}
+
+type protooError struct {
+	code int
+	err  error
+}
+
+func (e protooError) Error() string {
+	return e.err.Error()
+}
+
+func NewRoom(options RoomOptions) *Room {
+	logger := mediasoup.TypeLogger("Room")
+
+	logger.Debug("constructor()")
+
+	return &Room{
+		logger:  logger,
+		options: options,
+		peers:   make(map[string]*Peer),
+	}
+}
+
+// Join adds a peer to the room, closing the one of the same id if any.
+func (room *Room) Join(peerId string, send func(data []byte) error) (*Peer, error) {
+	if len(peerId) == 0 {
+		return nil, mediasoup.NewTypeError("missing peerId")
+	}
+
+	peer := &Peer{
+		id:         peerId,
+		room:       room,
+		send:       send,
+		transports: make(map[string]*mediasoup.WebRtcTransport),
+		producers:  make(map[string]*mediasoup.Producer),
+		consumers:  make(map[string]*mediasoup.Consumer),
+		sents:      make(map[uint32]chan Message),
+	}
+
+	room.mu.Lock()
+	existing := room.peers[peerId]
+	room.peers[peerId] = peer
+	room.mu.Unlock()
+
+	if existing != nil {
+		room.logger.Warnf("there is already a peer with same peerId, closing it [peerId:%s]", peerId)
+
+		existing.close(false)
+	}
+
+	return peer, nil
+}
+
+// Peers returns the peers of the room.
+func (room *Room) Peers() []*Peer {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	peers := make([]*Peer, 0, len(room.peers))
+	for _, peer := range room.peers {
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+// Close closes the peers of the room.
+func (room *Room) Close() {
+	for _, peer := range room.Peers() {
+		peer.Close()
+	}
+}
+
+// joinedPeers returns the joined peers of the room but the excluded one.
+func (room *Room) joinedPeers(excluded *Peer) []*Peer {
+	peers := []*Peer{}
+
+	for _, peer := range room.Peers() {
+		if peer != excluded && peer.Joined() {
+			peers = append(peers, peer)
+		}
+	}
+
+	return peers
+}
+
+func (peer *Peer) Id() string {
+	return peer.id
+}
+
+func (peer *Peer) Joined() bool {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	return peer.joined
+}
+
+func (peer *Peer) Closed() bool {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	return peer.closed
+}
+
+// Close closes the transports of the peer and leaves the room, notifying the
+// other peers.
+func (peer *Peer) Close() {
+	peer.close(true)
+}
+
+func (peer *Peer) close(leave bool) {
+	peer.mu.Lock()
+	if peer.closed {
+		peer.mu.Unlock()
+		return
+	}
+	peer.closed = true
+
+	joined := peer.joined
+
+	transports := make([]*mediasoup.WebRtcTransport, 0, len(peer.transports))
+	for _, transport := range peer.transports {
+		transports = append(transports, transport)
+	}
+	for id, sent := range peer.sents {
+		close(sent)
+		delete(peer.sents, id)
+	}
+	peer.mu.Unlock()
+
+	room := peer.room
+
+	if leave {
+		room.mu.Lock()
+		if room.peers[peer.id] == peer {
+			delete(room.peers, peer.id)
+		}
+		room.mu.Unlock()
+	}
+
+	if joined {
+		for _, other := range room.joinedPeers(peer) {
+			other.Notify("peerClosed", mediasoup.H{"peerId": peer.id})
+		}
+	}
+
+	for _, transport := range transports {
+		transport.Close()
+	}
+}
+
+// HandleMessage handles a message received from the peer, answering its
+// requests.
+func (peer *Peer) HandleMessage(data []byte) error {
+	var msg Message
+
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return mediasoup.NewTypeError("invalid protoo message: %s", err)
+	}
+
+	switch {
+	case msg.Request:
+		result, err := peer.handleRequest(msg.Method, msg.Data)
+
+		return peer.respond(msg, result, err)
+
+	case msg.Response:
+		peer.mu.Lock()
+		sent := peer.sents[msg.Id]
+		delete(peer.sents, msg.Id)
+		peer.mu.Unlock()
+
+		if sent == nil {
+			peer.room.logger.Warnf("received response does not match any sent request [id:%d]", msg.Id)
+			return nil
+		}
+		sent <- msg
+
+		return nil
+
+	case msg.Notification:
+		peer.room.logger.Debugf("ignoring notification [method:%s]", msg.Method)
+
+		return nil
+	}
+
+	return mediasoup.NewTypeError("invalid protoo message")
+}
+
+// Notify sends a notification to the peer.
+func (peer *Peer) Notify(method string, data interface{}) error {
+	rawData, _ := json.Marshal(data)
+
+	return peer.write(Message{Notification: true, Method: method, Data: rawData})
+}
+
+// Request sends a request to the peer and waits for its response.
+func (peer *Peer) Request(method string, data interface{}) (json.RawMessage, error) {
+	rawData, _ := json.Marshal(data)
+
+	sent := make(chan Message, 1)
+
+	peer.mu.Lock()
+	if peer.closed {
+		peer.mu.Unlock()
+		return nil, ErrPeerClosed
+	}
+	peer.nextId++
+	id := peer.nextId
+	peer.sents[id] = sent
+	peer.mu.Unlock()
+
+	if err := peer.write(Message{Request: true, Id: id, Method: method, Data: rawData}); err != nil {
+		peer.mu.Lock()
+		delete(peer.sents, id)
+		peer.mu.Unlock()
+
+		return nil, err
+	}
+
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case rsp, ok := <-sent:
+		if !ok {
+			return nil, ErrPeerClosed
+		}
+		if !rsp.Ok {
+			return nil, mediasoup.NewTypeError("%s request failed: %s", method, rsp.ErrorReason)
+		}
+		return rsp.Data, nil
+
+	case <-timer.C:
+		peer.mu.Lock()
+		delete(peer.sents, id)
+		peer.mu.Unlock()
+
+		return nil, mediasoup.NewTimeoutError("%s request timeout", method)
+	}
+}
+
+func (peer *Peer) respond(request Message, result interface{}, err error) error {
+	rsp := Message{Response: true, Id: request.Id}
+
+	if err != nil {
+		peer.room.logger.Warnf("request failed [peerId:%s, method:%s]: %s", peer.id, request.Method, err)
+
+		rsp.ErrorCode, rsp.ErrorReason = 500, err.Error()
+		if protooErr, ok := err.(protooError); ok {
+			rsp.ErrorCode = protooErr.code
+		}
+	} else {
+		if result == nil {
+			result = mediasoup.H{}
+		}
+		rsp.Ok = true
+		rsp.Data, _ = json.Marshal(result)
+	}
+
+	return peer.write(rsp)
+}
+
+func (peer *Peer) write(msg Message) error {
+	data, _ := json.Marshal(msg)
+
+	return peer.send(data)
+}
+
+func (peer *Peer) handleRequest(method string, data json.RawMessage) (result interface{}, err error) {
+	room := peer.room
+	router := room.options.Router
+
+	var params struct {
+		DisplayName     string                     `json:"displayName"`
+		Device          mediasoup.H                `json:"device"`
+		RtpCapabilities *mediasoup.RtpCapabilities `json:"rtpCapabilities"`
+		ForceTcp        bool                       `json:"forceTcp"`
+		Producing       bool                       `json:"producing"`
+		Consuming       bool                       `json:"consuming"`
+		TransportId     string                     `json:"transportId"`
+		DtlsParameters  *mediasoup.DtlsParameters  `json:"dtlsParameters"`
+		Kind            string                     `json:"kind"`
+		RtpParameters   mediasoup.RtpParameters    `json:"rtpParameters"`
+		AppData         mediasoup.H                `json:"appData"`
+		ProducerId      string                     `json:"producerId"`
+		ConsumerId      string                     `json:"consumerId"`
+		SpatialLayer    uint8                      `json:"spatialLayer"`
+		TemporalLayer   uint8                      `json:"temporalLayer"`
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &params); err != nil {
+			return nil, protooError{400, mediasoup.NewTypeError("invalid request data: %s", err)}
+		}
+	}
+
+	switch method {
+	case "getRouterRtpCapabilities":
+		return router.RtpCapabilities(), nil
+
+	case "join":
+		return peer.join(params.DisplayName, params.Device, params.RtpCapabilities)
+
+	case "createWebRtcTransport":
+		transportParams := room.options.WebRtcTransportParams
+		transportParams.AppData = mediasoup.H{"producing": params.Producing, "consuming": params.Consuming}
+
+		if params.ForceTcp {
+			transportParams.EnableUdp = false
+			transportParams.EnableTcp = true
+		}
+
+		transport, err := router.CreateWebRtcTransport(transportParams)
+		if err != nil {
+			return nil, err
+		}
+
+		peer.mu.Lock()
+		peer.transports[transport.Id()] = transport
+		peer.mu.Unlock()
+
+		transport.Observer().On("close", func() {
+			peer.mu.Lock()
+			defer peer.mu.Unlock()
+
+			delete(peer.transports, transport.Id())
+		})
+
+		return mediasoup.H{
+			"id":             transport.Id(),
+			"iceParameters":  transport.IceParameters(),
+			"iceCandidates":  transport.IceCandidates(),
+			"dtlsParameters": transport.DtlsParameters(),
+			"sctpParameters": nil,
+		}, nil
+
+	case "connectWebRtcTransport":
+		transport, err := peer.getTransport(params.TransportId)
+		if err != nil {
+			return nil, err
+		}
+		return nil, transport.Connect(mediasoup.TransportConnectParams{DtlsParameters: params.DtlsParameters})
+
+	case "restartIce":
+		transport, err := peer.getTransport(params.TransportId)
+		if err != nil {
+			return nil, err
+		}
+		return transport.RestartIce()
+
+	case "produce":
+		return peer.produce(params.TransportId, params.Kind, params.RtpParameters, params.AppData)
+
+	case "closeProducer", "pauseProducer", "resumeProducer", "getProducerStats":
+		producer, err := peer.getProducer(params.ProducerId)
+		if err != nil {
+			return nil, err
+		}
+
+		switch method {
+		case "closeProducer":
+			return nil, producer.Close()
+		case "pauseProducer":
+			return nil, producer.Pause()
+		case "resumeProducer":
+			return nil, producer.Resume()
+		default:
+			return rawStats(producer.GetStats())
+		}
+
+	case "pauseConsumer", "resumeConsumer", "setConsumerPreferredLayers", "requestConsumerKeyFrame", "getConsumerStats":
+		consumer, err := peer.getConsumer(params.ConsumerId)
+		if err != nil {
+			return nil, err
+		}
+
+		switch method {
+		case "pauseConsumer":
+			return nil, consumer.Pause()
+		case "resumeConsumer":
+			return nil, consumer.Resume()
+		case "setConsumerPreferredLayers":
+			return nil, consumer.SetPreferredLayers(params.SpatialLayer, params.TemporalLayer)
+		case "requestConsumerKeyFrame":
+			return nil, consumer.RequestKeyFrame()
+		default:
+			return rawStats(consumer.GetStats())
+		}
+
+	case "getTransportStats":
+		transport, err := peer.getTransport(params.TransportId)
+		if err != nil {
+			return nil, err
+		}
+		return transport.GetStats()
+
+	case "changeDisplayName":
+		peer.mu.Lock()
+		oldDisplayName := peer.displayName
+		peer.displayName = params.DisplayName
+		peer.mu.Unlock()
+
+		for _, other := range room.joinedPeers(peer) {
+			other.Notify("peerDisplayNameChanged", mediasoup.H{
+				"peerId":         peer.id,
+				"displayName":    params.DisplayName,
+				"oldDisplayName": oldDisplayName,
+			})
+		}
+
+		return nil, nil
+	}
+
+	return nil, protooError{400, mediasoup.NewTypeError(`unknown request.method "%s"`, method)}
+}
+
+func (peer *Peer) join(displayName string, device mediasoup.H, rtpCapabilities *mediasoup.RtpCapabilities) (result interface{}, err error) {
+	peer.mu.Lock()
+	if peer.joined {
+		peer.mu.Unlock()
+		return nil, protooError{400, mediasoup.NewTypeError("peer already joined")}
+	}
+	peer.joined = true
+	peer.displayName = displayName
+	peer.device = device
+	peer.rtpCapabilities = rtpCapabilities
+	peer.mu.Unlock()
+
+	others := peer.room.joinedPeers(peer)
+	peerInfos := []peerInfo{}
+
+	for _, other := range others {
+		peerInfos = append(peerInfos, other.info())
+	}
+
+	for _, other := range others {
+		for _, producer := range other.getProducers() {
+			peer.consume(other, producer)
+		}
+
+		other.Notify("newPeer", peer.info())
+	}
+
+	return mediasoup.H{"peers": peerInfos}, nil
+}
+
+func (peer *Peer) produce(transportId, kind string, rtpParameters mediasoup.RtpParameters, appData mediasoup.H) (result interface{}, err error) {
+	if !peer.Joined() {
+		return nil, protooError{400, mediasoup.NewTypeError("peer not yet joined")}
+	}
+
+	transport, err := peer.getTransport(transportId)
+	if err != nil {
+		return
+	}
+
+	if appData == nil {
+		appData = mediasoup.H{}
+	}
+	appData["peerId"] = peer.id
+
+	producer, err := transport.Produce(mediasoup.TransportProduceParams{
+		Kind:          kind,
+		RtpParameters: rtpParameters,
+		AppData:       appData,
+	})
+	if err != nil {
+		return
+	}
+
+	peer.mu.Lock()
+	peer.producers[producer.Id()] = producer
+	peer.mu.Unlock()
+
+	producer.Observer().On("close", func() {
+		peer.mu.Lock()
+		defer peer.mu.Unlock()
+
+		delete(peer.producers, producer.Id())
+	})
+
+	producer.On("score", func(score []mediasoup.ProducerScore) {
+		peer.Notify("producerScore", mediasoup.H{"producerId": producer.Id(), "score": score})
+	})
+
+	for _, other := range peer.room.joinedPeers(peer) {
+		other.consume(peer, producer)
+	}
+
+	return mediasoup.H{"id": producer.Id()}, nil
+}
+
+// consume creates a Consumer of the producer of another peer, paused until
+// the peer accepted it through the newConsumer request.
+func (peer *Peer) consume(producerPeer *Peer, producer *mediasoup.Producer) {
+	router := peer.room.options.Router
+
+	peer.mu.Lock()
+	rtpCapabilities := peer.rtpCapabilities
+
+	var transport *mediasoup.WebRtcTransport
+	for _, t := range peer.transports {
+		if appData, ok := t.AppData().(mediasoup.H); ok && appData["consuming"] == true {
+			transport = t
+			break
+		}
+	}
+	peer.mu.Unlock()
+
+	if rtpCapabilities == nil || !router.CanConsume(producer.Id(), *rtpCapabilities) {
+		return
+	}
+	if transport == nil {
+		peer.room.logger.Warnf("consume() | transport for consuming not found [peerId:%s]", peer.id)
+		return
+	}
+
+	consumer, err := transport.Consume(mediasoup.TransportConsumeParams{
+		ProducerId:      producer.Id(),
+		RtpCapabilities: *rtpCapabilities,
+		Paused:          true,
+	})
+	if err != nil {
+		peer.room.logger.Warnf("consume() | transport.consume() failed [peerId:%s]: %s", peer.id, err)
+		return
+	}
+
+	peer.mu.Lock()
+	peer.consumers[consumer.Id()] = consumer
+	peer.mu.Unlock()
+
+	consumer.Observer().On("close", func() {
+		peer.mu.Lock()
+		defer peer.mu.Unlock()
+
+		delete(peer.consumers, consumer.Id())
+	})
+
+	closed := func() {
+		peer.Notify("consumerClosed", mediasoup.H{"consumerId": consumer.Id()})
+	}
+	consumer.On("transportclose", closed)
+	consumer.On("producerclose", closed)
+	consumer.On("producerpause", func() {
+		peer.Notify("consumerPaused", mediasoup.H{"consumerId": consumer.Id()})
+	})
+	consumer.On("producerresume", func() {
+		peer.Notify("consumerResumed", mediasoup.H{"consumerId": consumer.Id()})
+	})
+	consumer.On("score", func(score mediasoup.ConsumerScore) {
+		peer.Notify("consumerScore", mediasoup.H{"consumerId": consumer.Id(), "score": score})
+	})
+	consumer.On("layerschange", func() {
+		layers := mediasoup.H{"consumerId": consumer.Id(), "spatialLayer": nil, "temporalLayer": nil}
+		if current := consumer.CurrentLayers(); current != nil {
+			layers["spatialLayer"] = current.SpatialLayer
+		}
+		peer.Notify("consumerLayersChanged", layers)
+	})
+
+	// Not blocking the request of the producing or joining peer.
+	go func() {
+		_, err := peer.Request("newConsumer", mediasoup.H{
+			"peerId":         producerPeer.id,
+			"producerId":     producer.Id(),
+			"id":             consumer.Id(),
+			"kind":           consumer.Kind(),
+			"rtpParameters":  consumer.RtpParameters(),
+			"type":           consumer.Type(),
+			"appData":        producer.AppData(),
+			"producerPaused": consumer.ProducerPaused(),
+		})
+		if err != nil {
+			peer.room.logger.Warnf("consume() | newConsumer request failed [peerId:%s]: %s", peer.id, err)
+			return
+		}
+
+		if err = consumer.Resume(); err != nil {
+			peer.room.logger.Warnf("consume() | consumer.resume() failed [peerId:%s]: %s", peer.id, err)
+			return
+		}
+
+		if score := consumer.Score(); score != nil {
+			peer.Notify("consumerScore", mediasoup.H{"consumerId": consumer.Id(), "score": score})
+		}
+	}()
+}
+
+func (peer *Peer) info() peerInfo {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	return peerInfo{Id: peer.id, DisplayName: peer.displayName, Device: peer.device}
+}
+
+func (peer *Peer) getProducers() []*mediasoup.Producer {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	producers := make([]*mediasoup.Producer, 0, len(peer.producers))
+	for _, producer := range peer.producers {
+		producers = append(producers, producer)
+	}
+
+	return producers
+}
+
+func (peer *Peer) getTransport(transportId string) (*mediasoup.WebRtcTransport, error) {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	if transport := peer.transports[transportId]; transport != nil {
+		return transport, nil
+	}
+
+	return nil, protooError{404, mediasoup.NewTypeError(`transport with id "%s" not found`, transportId)}
+}
+
+func (peer *Peer) getProducer(producerId string) (*mediasoup.Producer, error) {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	if producer := peer.producers[producerId]; producer != nil {
+		return producer, nil
+	}
+
+	return nil, protooError{404, mediasoup.NewTypeError(`producer with id "%s" not found`, producerId)}
+}
+
+func (peer *Peer) getConsumer(consumerId string) (*mediasoup.Consumer, error) {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	if consumer := peer.consumers[consumerId]; consumer != nil {
+		return consumer, nil
+	}
+
+	return nil, protooError{404, mediasoup.NewTypeError(`consumer with id "%s" not found`, consumerId)}
+}
+
+func rawStats(rsp mediasoup.Response) (interface{}, error) {
+	if err := rsp.Err(); err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(rsp.Data()), nil
+}
@@ -0,0 +1,224 @@
+package protoo
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	if len(os.Getenv("MEDIASOUP_WORKER_BIN")) == 0 {
+		os.Setenv("MEDIASOUP_WORKER_BIN", "../../mediasoup-worker")
+	}
+}
+
+var (
+	testMediaCodecs = []mediasoup.RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+		{Kind: "video", MimeType: "video/VP8", ClockRate: 90000},
+	}
+	testAudioRtpParameters = mediasoup.RtpParameters{
+		Mid: "AUDIO",
+		Codecs: []mediasoup.RtpCodecCapability{
+			{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+		},
+		Encodings: []mediasoup.RtpEncoding{{Ssrc: 11111111}},
+		Rtcp:      mediasoup.RtcpConfiguation{Cname: "FOOBAR"},
+	}
+	testDeviceCapabilities = mediasoup.RtpCapabilities{
+		Codecs: []mediasoup.RtpCodecCapability{
+			{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2, PreferredPayloadType: 100},
+		},
+	}
+)
+
+// testClient is a protoo client of a Room, accepting the
+// newConsumer requests.
+type testClient struct {
+	t             *testing.T
+	peer          *Peer
+	nextId        uint32
+	responses     chan Message
+	notifications chan Message
+	newConsumers  chan Message
+}
+
+func newTestClient(t *testing.T, room *Room, peerId string) *testClient {
+	client := &testClient{
+		t:             t,
+		responses:     make(chan Message, 10),
+		notifications: make(chan Message, 10),
+		newConsumers:  make(chan Message, 10),
+	}
+
+	peer, err := room.Join(peerId, func(data []byte) error {
+		var msg Message
+		require.NoError(t, json.Unmarshal(data, &msg))
+
+		switch {
+		case msg.Response:
+			client.responses <- msg
+		case msg.Notification:
+			client.notifications <- msg
+		case msg.Request:
+			client.newConsumers <- msg
+
+			rsp, _ := json.Marshal(Message{Response: true, Id: msg.Id, Ok: true})
+			go client.peer.HandleMessage(rsp)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	client.peer = peer
+
+	return client
+}
+
+func (c *testClient) request(method string, data interface{}, result interface{}) Message {
+	c.nextId++
+
+	rawData, _ := json.Marshal(data)
+	req, _ := json.Marshal(Message{Request: true, Id: c.nextId, Method: method, Data: rawData})
+
+	require.NoError(c.t, c.peer.HandleMessage(req))
+
+	rsp := receiveMessage(c.t, c.responses)
+	assert.Equal(c.t, c.nextId, rsp.Id)
+
+	if result != nil && rsp.Ok {
+		require.NoError(c.t, json.Unmarshal(rsp.Data, result))
+	}
+
+	return rsp
+}
+
+func receiveMessage(t *testing.T, ch chan Message) Message {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		require.FailNow(t, "timeout")
+		return Message{}
+	}
+}
+
+func TestRoom_ProduceAndConsume(t *testing.T) {
+	worker, err := mediasoup.CreateWorker("", mediasoup.WithLogLevel("warn"))
+	require.NoError(t, err)
+	defer worker.Close()
+
+	router, err := worker.CreateRouter(testMediaCodecs)
+	require.NoError(t, err)
+
+	room := NewRoom(RoomOptions{
+		Router: router,
+		WebRtcTransportParams: mediasoup.CreateWebRtcTransportParams{
+			ListenIps: []mediasoup.ListenIp{{Ip: "127.0.0.1"}},
+			EnableUdp: true,
+		},
+	})
+	defer room.Close()
+
+	alice := newTestClient(t, room, "alice")
+
+	var rtpCapabilities mediasoup.RtpCapabilities
+	assert.True(t, alice.request("getRouterRtpCapabilities", nil, &rtpCapabilities).Ok)
+	require.Len(t, rtpCapabilities.Codecs, len(router.RtpCapabilities().Codecs))
+	assert.Equal(t, "audio/opus", rtpCapabilities.Codecs[0].MimeType)
+
+	var sendTransport struct{ Id string }
+	assert.True(t, alice.request("createWebRtcTransport", mediasoup.H{"producing": true}, &sendTransport).Ok)
+	assert.NotEmpty(t, sendTransport.Id)
+
+	rsp := alice.request("produce", mediasoup.H{
+		"transportId":   sendTransport.Id,
+		"kind":          "audio",
+		"rtpParameters": testAudioRtpParameters,
+	}, nil)
+	assert.False(t, rsp.Ok)
+	assert.Equal(t, 400, rsp.ErrorCode)
+
+	var joined struct{ Peers []peerInfo }
+	assert.True(t, alice.request("join", mediasoup.H{
+		"displayName": "Alice",
+		"device":      mediasoup.H{"name": "chrome"},
+	}, &joined).Ok)
+	assert.Empty(t, joined.Peers)
+
+	var producer struct{ Id string }
+	assert.True(t, alice.request("produce", mediasoup.H{
+		"transportId":   sendTransport.Id,
+		"kind":          "audio",
+		"rtpParameters": testAudioRtpParameters,
+		"appData":       mediasoup.H{"source": "mic"},
+	}, &producer).Ok)
+	assert.NotEmpty(t, producer.Id)
+
+	// Bob consumes the producer of Alice once joined.
+	bob := newTestClient(t, room, "bob")
+
+	var recvTransport struct{ Id string }
+	assert.True(t, bob.request("createWebRtcTransport", mediasoup.H{"consuming": true}, &recvTransport).Ok)
+
+	assert.True(t, bob.request("join", mediasoup.H{
+		"displayName":     "Bob",
+		"device":          mediasoup.H{"name": "firefox"},
+		"rtpCapabilities": testDeviceCapabilities,
+	}, &joined).Ok)
+	require.Len(t, joined.Peers, 1)
+	assert.Equal(t, "alice", joined.Peers[0].Id)
+	assert.Equal(t, "Alice", joined.Peers[0].DisplayName)
+
+	newPeer := receiveMessage(t, alice.notifications)
+	assert.Equal(t, "newPeer", newPeer.Method)
+	assert.JSONEq(t, `{"id":"bob","displayName":"Bob","device":{"name":"firefox"}}`, string(newPeer.Data))
+
+	newConsumer := receiveMessage(t, bob.newConsumers)
+	assert.Equal(t, "newConsumer", newConsumer.Method)
+
+	var consumer struct {
+		PeerId     string
+		ProducerId string
+		Id         string
+		Kind       string
+		AppData    mediasoup.H
+	}
+	require.NoError(t, json.Unmarshal(newConsumer.Data, &consumer))
+	assert.Equal(t, "alice", consumer.PeerId)
+	assert.Equal(t, producer.Id, consumer.ProducerId)
+	assert.Equal(t, "audio", consumer.Kind)
+	assert.Equal(t, mediasoup.H{"source": "mic", "peerId": "alice"}, consumer.AppData)
+
+	// Resumed once accepted.
+	resumed := false
+	for i := 0; i < 100 && !resumed; i++ {
+		if consumer, err := bob.peer.getConsumer(consumer.Id); err == nil {
+			resumed = !consumer.Paused()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, resumed)
+
+	assert.True(t, bob.request("getConsumerStats", mediasoup.H{"consumerId": consumer.Id}, nil).Ok)
+	rsp = bob.request("pauseConsumer", mediasoup.H{"consumerId": "unknown"}, nil)
+	assert.False(t, rsp.Ok)
+	assert.Equal(t, 404, rsp.ErrorCode)
+
+	rsp = bob.request("chicken", nil, nil)
+	assert.False(t, rsp.Ok)
+	assert.Contains(t, rsp.ErrorReason, "chicken")
+
+	// Alice leaves.
+	alice.peer.Close()
+
+	peerClosed := receiveMessage(t, bob.notifications)
+	assert.Equal(t, "peerClosed", peerClosed.Method)
+	assert.JSONEq(t, `{"peerId":"alice"}`, string(peerClosed.Data))
+	assert.Len(t, room.Peers(), 1)
+}
@@ -0,0 +1,238 @@
+package mediasoup
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metrics of the quality alert rules, evaluated per Transport.
+const (
+	// Lowest score of the Producers and Consumers of the Transport (0-10).
+	QualityMetricScore = "score"
+	// Highest fraction of lost packets of the Producers and Consumers of the
+	// Transport (0-1).
+	QualityMetricLoss = "loss"
+)
+
+// QualityAlertRule raises an alert when the metric of a Transport is beyond
+// Threshold during For, and recovers it when the metric is back beyond
+// Recovery during RecoverFor, so that alerts don't flap around the threshold.
+type QualityAlertRule struct {
+	Name string
+	// QualityMetricScore (alerting below Threshold) or QualityMetricLoss
+	// (alerting above Threshold).
+	Metric    string
+	Threshold float64
+	// Threshold of recovery, Threshold if zero.
+	Recovery   float64
+	For        time.Duration
+	RecoverFor time.Duration
+}
+
+// DefaultQualityAlertRules alert on a score below 5 for 10s, and on a loss
+// above 5% for 30s.
+var DefaultQualityAlertRules = []QualityAlertRule{
+	{
+		Name:      "lowScore",
+		Metric:    QualityMetricScore,
+		Threshold: 5,
+		Recovery:  7,
+		For:       10 * time.Second,
+	},
+	{
+		Name:      "highLoss",
+		Metric:    QualityMetricLoss,
+		Threshold: 0.05,
+		Recovery:  0.02,
+		For:       30 * time.Second,
+	},
+}
+
+// QualityAlert is emitted with "qualityalert" and "qualityrecovered".
+type QualityAlert struct {
+	Rule        string  `json:"rule"`
+	Metric      string  `json:"metric"`
+	Threshold   float64 `json:"threshold"`
+	Value       float64 `json:"value"`
+	RouterId    string  `json:"routerId"`
+	TransportId string  `json:"transportId"`
+	// Since when the metric is beyond the threshold (or the recovery one).
+	Since time.Time `json:"since"`
+	// Labels of the Transport, if watched by the StatsExporter.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// WithQualityAlerts evaluates the given rules (DefaultQualityAlertRules if
+// none) at every sample.
+func WithQualityAlerts(rules ...QualityAlertRule) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		if len(rules) == 0 {
+			rules = DefaultQualityAlertRules
+		}
+		o.QualityAlertRules = rules
+	}
+}
+
+func validateQualityAlertRules(rules []QualityAlertRule) error {
+	names := make(map[string]bool, len(rules))
+
+	for i, rule := range rules {
+		field := fmt.Sprintf("StatsExporterOptions.QualityAlertRules[%d]", i)
+
+		if len(rule.Name) == 0 {
+			return NewValidationError(field+".Name", "missing name")
+		}
+		if names[rule.Name] {
+			return NewValidationError(field+".Name", `duplicated name "%s"`, rule.Name)
+		}
+		if rule.Metric != QualityMetricScore && rule.Metric != QualityMetricLoss {
+			return NewValidationError(field+".Metric", `unknown metric "%s"`, rule.Metric)
+		}
+		if rule.For < 0 || rule.RecoverFor < 0 {
+			return NewValidationError(field+".For", "must not be negative")
+		}
+		if rule.Recovery != 0 && rule.breached(rule.Recovery) {
+			return NewValidationError(field+".Recovery", "must be beyond Threshold (%v)", rule.Threshold)
+		}
+
+		names[rule.Name] = true
+	}
+
+	return nil
+}
+
+func (rule QualityAlertRule) breached(value float64) bool {
+	if rule.Metric == QualityMetricScore {
+		return value < rule.Threshold
+	}
+
+	return value > rule.Threshold
+}
+
+func (rule QualityAlertRule) recovered(value float64) bool {
+	recovery := rule.Recovery
+	if recovery == 0 {
+		recovery = rule.Threshold
+	}
+
+	if rule.Metric == QualityMetricScore {
+		return value >= recovery
+	}
+
+	return value <= recovery
+}
+
+// qualityMetrics are the metrics of a Transport at a sample.
+type qualityMetrics struct {
+	routerId string
+	labels   map[string]string
+	values   map[string]float64
+}
+
+func (m *qualityMetrics) add(metric string, value float64, worse func(a, b float64) bool) {
+	if current, ok := m.values[metric]; !ok || worse(value, current) {
+		m.values[metric] = value
+	}
+}
+
+// addStat adds the score and loss of the stats entry of a Producer (its
+// "inbound-rtp" entries) or of a Consumer (its "outbound-rtp" ones).
+func (m *qualityMetrics) addStat(kind string, stat map[string]interface{}) {
+	if (kind == "producer" && stat["type"] != "inbound-rtp") ||
+		(kind == "consumer" && stat["type"] != "outbound-rtp") {
+		return
+	}
+
+	if score, ok := stat["score"].(float64); ok {
+		m.add(QualityMetricScore, score, func(a, b float64) bool { return a < b })
+	}
+	// fractionLost is the RTCP one, in 1/256.
+	if fractionLost, ok := stat["fractionLost"].(float64); ok {
+		m.add(QualityMetricLoss, fractionLost/256, func(a, b float64) bool { return a > b })
+	}
+}
+
+// qualityAlertState is the state of a rule for a Transport.
+type qualityAlertState struct {
+	firing bool
+	// Since when the metric is beyond the threshold to change the state.
+	pendingSince time.Time
+}
+
+// evaluateQualityAlerts evaluates the rules with the metrics of the given
+// sample, by Transport id.
+func (e *StatsExporter) evaluateQualityAlerts(now time.Time, metrics map[string]*qualityMetrics) {
+	type event struct {
+		name  string
+		alert QualityAlert
+	}
+
+	var events []event
+
+	e.mu.Lock()
+
+	for transportId, m := range metrics {
+		for _, rule := range e.options.QualityAlertRules {
+			value, ok := m.values[rule.Metric]
+
+			key := transportId + "/" + rule.Name
+			state, exists := e.alerts[key]
+			if !exists {
+				state = &qualityAlertState{}
+				e.alerts[key] = state
+			}
+
+			changing, duration, name := rule.breached(value), rule.For, "qualityalert"
+			if state.firing {
+				changing, duration, name = rule.recovered(value), rule.RecoverFor, "qualityrecovered"
+			}
+			if !ok || !changing {
+				state.pendingSince = time.Time{}
+				continue
+			}
+			if state.pendingSince.IsZero() {
+				state.pendingSince = now
+			}
+			if now.Sub(state.pendingSince) < duration {
+				continue
+			}
+
+			events = append(events, event{
+				name: name,
+				alert: QualityAlert{
+					Rule:        rule.Name,
+					Metric:      rule.Metric,
+					Threshold:   rule.Threshold,
+					Value:       value,
+					RouterId:    m.routerId,
+					TransportId: transportId,
+					Since:       state.pendingSince,
+					Labels:      m.labels,
+				},
+			})
+
+			state.firing = !state.firing
+			state.pendingSince = time.Time{}
+		}
+	}
+
+	e.mu.Unlock()
+
+	for _, event := range events {
+		if event.name == "qualityalert" {
+			e.logger.Warnf("quality alert %q on transport %s: %s is %v",
+				event.alert.Rule, event.alert.TransportId, event.alert.Metric, event.alert.Value)
+		}
+		e.SafeEmit(event.name, event.alert)
+	}
+}
+
+// removeQualityAlerts forgets the alerts of a closed Transport.
+func (e *StatsExporter) removeQualityAlerts(transportId string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, rule := range e.options.QualityAlertRules {
+		delete(e.alerts, transportId+"/"+rule.Name)
+	}
+}
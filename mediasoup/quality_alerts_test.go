@@ -0,0 +1,108 @@
+package mediasoup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsExporter_QualityAlerts(t *testing.T) {
+	_, err := NewStatsExporter(WithQualityAlerts(QualityAlertRule{Name: "jitter", Metric: "jitter"}))
+	assertValidationError(t, "StatsExporterOptions.QualityAlertRules[0].Metric", err)
+
+	_, err = NewStatsExporter(WithQualityAlerts(QualityAlertRule{
+		Name: "lowScore", Metric: QualityMetricScore, Threshold: 5, Recovery: 3,
+	}))
+	assertValidationError(t, "StatsExporterOptions.QualityAlertRules[0].Recovery", err)
+
+	exporter, err := NewStatsExporter(WithQualityAlerts())
+	require.NoError(t, err)
+
+	var alerts, recoveries []QualityAlert
+	exporter.On("qualityalert", func(alert QualityAlert) {
+		alerts = append(alerts, alert)
+	})
+	exporter.On("qualityrecovered", func(alert QualityAlert) {
+		recoveries = append(recoveries, alert)
+	})
+
+	score, fractionLost := 10.0, 0.0
+
+	transportObserver := NewEventEmitter(AppLogger())
+	exporter.addSource("t1", transportObserver, &statsSource{
+		kind:   "transport",
+		labels: map[string]string{"routerId": "r1", "transportId": "t1", "peer": "alice"},
+		getStats: func() ([]map[string]interface{}, error) {
+			return []map[string]interface{}{{"type": "webrtc-transport"}}, nil
+		},
+	})
+	exporter.addSource("c1", NewEventEmitter(AppLogger()), &statsSource{
+		kind:   "consumer",
+		labels: map[string]string{"routerId": "r1", "transportId": "t1", "consumerId": "c1"},
+		getStats: func() ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"type": "outbound-rtp", "score": score, "fractionLost": fractionLost},
+				// The stream of the Producer, on another Transport.
+				{"type": "inbound-rtp", "score": float64(0), "fractionLost": float64(255)},
+			}, nil
+		},
+	})
+
+	start := time.Unix(1700000000, 0)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	exporter.sample(at(0))
+	assert.Empty(t, alerts)
+
+	// The score must stay below 5 for 10s.
+	score = 3
+	exporter.sample(at(10))
+	score = 6
+	exporter.sample(at(20))
+	score = 3
+	exporter.sample(at(30))
+	assert.Empty(t, alerts)
+	exporter.sample(at(40))
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, QualityAlert{
+		Rule:        "lowScore",
+		Metric:      QualityMetricScore,
+		Threshold:   5,
+		Value:       3,
+		RouterId:    "r1",
+		TransportId: "t1",
+		Since:       at(30),
+		Labels:      map[string]string{"routerId": "r1", "transportId": "t1", "peer": "alice"},
+	}, alerts[0])
+
+	// Hysteresis: back above the threshold but below the recovery one.
+	score = 6
+	exporter.sample(at(50))
+	exporter.sample(at(60))
+	assert.Empty(t, recoveries)
+
+	score = 8
+	exporter.sample(at(70))
+	require.Len(t, recoveries, 1)
+	assert.Equal(t, "lowScore", recoveries[0].Rule)
+	assert.Equal(t, at(70), recoveries[0].Since)
+	assert.Len(t, alerts, 1)
+
+	// Loss above 5% for 30s.
+	fractionLost = 26
+	for i := 8; i <= 11; i++ {
+		exporter.sample(at(i * 10))
+	}
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "highLoss", alerts[1].Rule)
+	assert.InDelta(t, 26.0/256, alerts[1].Value, 1e-9)
+
+	// The alerts of closed Transports are forgotten.
+	transportObserver.Emit("close")
+	assert.Empty(t, exporter.alerts["t1/highLoss"])
+}
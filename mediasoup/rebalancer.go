@@ -0,0 +1,497 @@
+package mediasoup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkerLoad is the load of a Worker sampled by the Rebalancer.
+type WorkerLoad struct {
+	Worker *Worker
+	// CPU usage of the worker process since the previous sample (100 for a
+	// whole core), negative if unknown (first sample, or not on Linux with
+	// workers not reporting their resource usage).
+	CpuPercent float64
+	// Sum of the load of its Routers.
+	Load    float64
+	Routers []RouterLoad
+}
+
+// RouterLoad is the load of a Router sampled by the Rebalancer.
+type RouterLoad struct {
+	Router     *Router
+	Transports int
+	Producers  int
+	Consumers  int
+	Load       float64
+}
+
+// RebalanceRecommendation advises to move a Router to another Worker.
+type RebalanceRecommendation struct {
+	Router *Router
+	From   *Worker
+	To     *Worker
+	Reason string
+}
+
+func (r RebalanceRecommendation) String() string {
+	return fmt.Sprintf("move router %s from worker %d to worker %d: %s",
+		r.Router.Id(), r.From.Pid(), r.To.Pid(), r.Reason)
+}
+
+// RebalancerOptions to analyze the Workers.
+type RebalancerOptions struct {
+	// Interval of the periodic analysis started by Start().
+	Interval time.Duration
+	// Load above which a Worker is saturating.
+	MaxWorkerLoad float64
+	// CPU usage above which a Worker is saturating, ignored if not positive.
+	MaxCpuPercent float64
+	// Load of the entities of a Router.
+	ProducerLoad float64
+	ConsumerLoad float64
+	// Whether to execute the recommendations.
+	AutoExecute bool
+	// Moves a Router to another Worker, MigrateRouter by default.
+	Migrate MigrateRouterFunc
+}
+
+// MigrateRouterFunc moves a Router to another Worker, returning the new Router
+// and the ids of the new Consumers by old Consumer id.
+type MigrateRouterFunc func(router *Router, worker *Worker) (*Router, map[string]string, error)
+
+type RebalancerOption func(o *RebalancerOptions)
+
+func WithRebalanceInterval(interval time.Duration) RebalancerOption {
+	return func(o *RebalancerOptions) {
+		o.Interval = interval
+	}
+}
+
+func WithRebalanceThresholds(maxWorkerLoad, maxCpuPercent float64) RebalancerOption {
+	return func(o *RebalancerOptions) {
+		o.MaxWorkerLoad = maxWorkerLoad
+		o.MaxCpuPercent = maxCpuPercent
+	}
+}
+
+func WithRebalanceEntityLoads(producerLoad, consumerLoad float64) RebalancerOption {
+	return func(o *RebalancerOptions) {
+		o.ProducerLoad = producerLoad
+		o.ConsumerLoad = consumerLoad
+	}
+}
+
+// WithRebalanceAutoExecute executes the recommendations with the given
+// migrate function, MigrateRouter if nil.
+func WithRebalanceAutoExecute(migrate MigrateRouterFunc) RebalancerOption {
+	return func(o *RebalancerOptions) {
+		o.AutoExecute = true
+		if migrate != nil {
+			o.Migrate = migrate
+		}
+	}
+}
+
+// Rebalancer analyzes the load of Workers and of their Routers, and advises
+// to move Routers away from the saturating Workers before they are saturated.
+type Rebalancer struct {
+	EventEmitter
+	mu      sync.Mutex
+	logger  logrus.FieldLogger
+	options RebalancerOptions
+	workers []*Worker
+	cpu     *cpuSampler
+	stopCh  chan struct{}
+}
+
+// cpuSampler samples the CPU usage of the worker processes.
+type cpuSampler struct {
+	mu sync.Mutex
+	// Last CPU time sample of the worker processes.
+	samples map[*Worker]cpuSample
+}
+
+type cpuSample struct {
+	at      time.Time
+	cpuTime time.Duration
+}
+
+func newCpuSampler() *cpuSampler {
+	return &cpuSampler{
+		samples: make(map[*Worker]cpuSample),
+	}
+}
+
+// cpuPercent returns the CPU usage of the worker process since the previous
+// sample (100 for a whole core), negative if unknown.
+func (s *cpuSampler) cpuPercent(worker *Worker) float64 {
+	cpuTime, err := workerCpuTime(worker)
+	if err != nil {
+		return -1
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	previous, ok := s.samples[worker]
+	s.samples[worker] = cpuSample{at: now, cpuTime: cpuTime}
+	s.mu.Unlock()
+
+	if !ok || !now.After(previous.at) {
+		return -1
+	}
+
+	return float64(cpuTime-previous.cpuTime) / float64(now.Sub(previous.at)) * 100
+}
+
+func (s *cpuSampler) forget(worker *Worker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.samples, worker)
+}
+
+/**
+ * NewRebalancer
+ *
+ * @emits {recommendation: RebalanceRecommendation} recommendation
+ * @emits {recommendation: RebalanceRecommendation, router: *Router, consumerIds: map[string]string} migrate
+ * @emits {recommendation: RebalanceRecommendation, error: error} migrationerror
+ */
+func NewRebalancer(options ...RebalancerOption) (*Rebalancer, error) {
+	logger := TypeLogger("Rebalancer")
+
+	logger.Debug("constructor()")
+
+	opts := RebalancerOptions{
+		Interval:      10 * time.Second,
+		MaxWorkerLoad: 500,
+		MaxCpuPercent: 80,
+		ProducerLoad:  2,
+		ConsumerLoad:  1,
+		Migrate:       MigrateRouter,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.Interval <= 0 {
+		return nil, NewValidationError("RebalancerOptions.Interval", "must be positive")
+	}
+	if opts.MaxWorkerLoad <= 0 {
+		return nil, NewValidationError("RebalancerOptions.MaxWorkerLoad", "must be positive")
+	}
+	if opts.ProducerLoad < 0 || opts.ConsumerLoad < 0 {
+		return nil, NewValidationError("RebalancerOptions.ProducerLoad", "entity loads must not be negative")
+	}
+
+	return &Rebalancer{
+		EventEmitter: NewEventEmitter(logger),
+		logger:       logger,
+		options:      opts,
+		cpu:          newCpuSampler(),
+	}, nil
+}
+
+// AddWorker adds the given Worker to the analyzed ones, until it is closed.
+func (r *Rebalancer) AddWorker(worker *Worker) {
+	r.mu.Lock()
+	r.workers = append(r.workers, worker)
+	r.mu.Unlock()
+
+	worker.Observer().On("close", func() {
+		r.mu.Lock()
+		for i, w := range r.workers {
+			if w == worker {
+				r.workers = append(r.workers[:i], r.workers[i+1:]...)
+				break
+			}
+		}
+		r.mu.Unlock()
+
+		r.cpu.forget(worker)
+	})
+}
+
+// Start analyzing the Workers periodically, emitting the recommendations
+// (and executing them if enabled).
+func (r *Rebalancer) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopCh != nil {
+		return
+	}
+
+	stopCh := make(chan struct{})
+	r.stopCh = stopCh
+
+	spawn("rebalancer", func() {
+		ticker := time.NewTicker(r.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				r.Run()
+			}
+		}
+	})
+}
+
+// Stop the periodic analysis.
+func (r *Rebalancer) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopCh != nil {
+		close(r.stopCh)
+		r.stopCh = nil
+	}
+}
+
+// Run analyzes the Workers once, emitting the recommendations and executing
+// them if enabled.
+func (r *Rebalancer) Run() []RebalanceRecommendation {
+	recommendations := r.Analyze()
+
+	for _, recommendation := range recommendations {
+		r.logger.Infof("recommendation: %s", recommendation)
+
+		r.SafeEmit("recommendation", recommendation)
+
+		if !r.options.AutoExecute {
+			continue
+		}
+
+		router, consumerIds, err := r.options.Migrate(recommendation.Router, recommendation.To)
+		if err != nil {
+			r.logger.Errorf("migration failed: %s", err)
+
+			r.SafeEmit("migrationerror", recommendation, err)
+			continue
+		}
+
+		r.SafeEmit("migrate", recommendation, router, consumerIds)
+	}
+
+	return recommendations
+}
+
+// Analyze returns the current recommendations without emitting nor executing
+// them.
+func (r *Rebalancer) Analyze() []RebalanceRecommendation {
+	return planRebalance(r.Loads(), r.options)
+}
+
+// Loads samples the load of the Workers.
+func (r *Rebalancer) Loads() []WorkerLoad {
+	r.mu.Lock()
+	workers := make([]*Worker, len(r.workers))
+	copy(workers, r.workers)
+	r.mu.Unlock()
+
+	loads := make([]WorkerLoad, 0, len(workers))
+
+	for _, worker := range workers {
+		loads = append(loads, sampleWorkerLoad(worker, r.cpu.cpuPercent(worker),
+			r.options.ProducerLoad, r.options.ConsumerLoad))
+	}
+
+	return loads
+}
+
+// sampleWorkerLoad counts the entities of the Routers of the given Worker,
+// weighted by the given loads.
+func sampleWorkerLoad(worker *Worker, cpuPercent, producerLoad, consumerLoad float64) WorkerLoad {
+	load := WorkerLoad{
+		Worker:     worker,
+		CpuPercent: cpuPercent,
+	}
+
+	worker.routersLocker.Lock()
+	routers := make([]*Router, 0, len(worker.routers))
+	for _, router := range worker.routers {
+		routers = append(routers, router)
+	}
+	worker.routersLocker.Unlock()
+
+	for _, router := range routers {
+		routerLoad := RouterLoad{Router: router}
+
+		for _, transport := range router.getTransports() {
+			routerLoad.Transports++
+
+			if base := baseTransportOf(transport); base != nil {
+				routerLoad.Producers += len(base.getProducers())
+				routerLoad.Consumers += len(base.getConsumers())
+			}
+		}
+
+		routerLoad.Load = float64(routerLoad.Producers)*producerLoad +
+			float64(routerLoad.Consumers)*consumerLoad
+
+		load.Routers = append(load.Routers, routerLoad)
+		load.Load += routerLoad.Load
+	}
+
+	return load
+}
+
+// planRebalance moves Routers from the saturating Workers to the least loaded
+// ones, as long as the target Workers don't become saturating.
+func planRebalance(loads []WorkerLoad, options RebalancerOptions) (recommendations []RebalanceRecommendation) {
+	if len(loads) < 2 {
+		return
+	}
+
+	// Projected loads, updated with the recommended moves.
+	projected := make([]float64, len(loads))
+	for i, load := range loads {
+		projected[i] = load.Load
+	}
+
+	// Returns the reason why the given Worker is saturating, if it is.
+	saturating := func(i int) (reason string, byCpu bool) {
+		if projected[i] > options.MaxWorkerLoad {
+			return fmt.Sprintf("load %.0f above %.0f", projected[i], options.MaxWorkerLoad), false
+		}
+		if options.MaxCpuPercent > 0 && loads[i].CpuPercent > options.MaxCpuPercent {
+			return fmt.Sprintf("cpu %.0f%% above %.0f%%", loads[i].CpuPercent, options.MaxCpuPercent), true
+		}
+		return "", false
+	}
+
+	order := make([]int, len(loads))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return projected[order[a]] > projected[order[b]]
+	})
+
+	// At most one move per saturating Worker and per analysis, the next one
+	// is made from fresh loads.
+	for _, from := range order {
+		reason, byCpu := saturating(from)
+		if len(reason) == 0 {
+			continue
+		}
+
+		// The least loaded other Worker.
+		to := -1
+		for i := range loads {
+			if i != from && (to == -1 || projected[i] < projected[to]) {
+				to = i
+			}
+		}
+		if toReason, _ := saturating(to); len(toReason) > 0 {
+			continue
+		}
+
+		// The smallest Router which fits in the target Worker and improves the
+		// load balance (or relieves the CPU), small Routers being cheaper to
+		// move.
+		routers := make([]RouterLoad, len(loads[from].Routers))
+		copy(routers, loads[from].Routers)
+		sort.SliceStable(routers, func(a, b int) bool {
+			return routers[a].Load < routers[b].Load
+		})
+
+		for _, router := range routers {
+			if router.Load <= 0 ||
+				projected[to]+router.Load > options.MaxWorkerLoad ||
+				(!byCpu && projected[to]+router.Load >= projected[from]) {
+				continue
+			}
+
+			projected[from] -= router.Load
+			projected[to] += router.Load
+
+			recommendations = append(recommendations, RebalanceRecommendation{
+				Router: router.Router,
+				From:   loads[from].Worker,
+				To:     loads[to].Worker,
+				Reason: reason,
+			})
+			break
+		}
+	}
+
+	return
+}
+
+// MigrateRouter moves the given Router to the given Worker through its
+// snapshot (see LoadRouterSnapshot), with its settings, RtpObservers and
+// Consumer layers. Before closing the Router, "migrate" is emitted on its
+// observer with the new Router and the ids of the new Consumers by old
+// Consumer id: the media is interrupted, clients must connect to the new
+// Transports and replace their Consumers.
+func MigrateRouter(router *Router, worker *Worker) (newRouter *Router, consumerIds map[string]string, err error) {
+	snapshot, err := router.Snapshot()
+	if err != nil {
+		return
+	}
+
+	if newRouter, consumerIds, err = loadRouterSnapshot(worker, snapshot); err != nil {
+		return
+	}
+
+	// Emit observer event.
+	router.observer.SafeEmit("migrate", newRouter, consumerIds)
+
+	router.Close()
+
+	return
+}
+
+// workerCpuTime returns the CPU time used by the worker process, from its
+// resource usage or, with older workers, from /proc.
+func workerCpuTime(worker *Worker) (time.Duration, error) {
+	if !workerSupports(worker.version, WorkerFeatureResourceUsage) {
+		return processCpuTime(worker.Pid())
+	}
+
+	usage, err := worker.GetResourceUsage()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration((usage.RuUtime + usage.RuStime) * float64(time.Millisecond)), nil
+}
+
+// processCpuTime returns the CPU time used by the given process, on Linux.
+func processCpuTime(pid int) (time.Duration, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name may contain spaces, the fields follow its ")".
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+
+	// utime and stime, 14th and 15th fields, in clock ticks (100 Hz).
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("invalid stat of process %d", pid)
+	}
+
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("invalid stat of process %d", pid)
+	}
+
+	return time.Duration(utime+stime) * 10 * time.Millisecond, nil
+}
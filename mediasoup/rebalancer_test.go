@@ -0,0 +1,155 @@
+package mediasoup
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanRebalance(t *testing.T) {
+	worker1, worker2, worker3 := &Worker{pid: 1}, &Worker{pid: 2}, &Worker{pid: 3}
+	small := &Router{internal: internalData{RouterId: "small"}}
+	large := &Router{internal: internalData{RouterId: "large"}}
+	other := &Router{internal: internalData{RouterId: "other"}}
+
+	options := RebalancerOptions{MaxWorkerLoad: 100, MaxCpuPercent: 80}
+
+	loads := []WorkerLoad{
+		{
+			Worker:     worker1,
+			CpuPercent: -1,
+			Load:       130,
+			Routers: []RouterLoad{
+				{Router: large, Load: 100},
+				{Router: small, Load: 30},
+			},
+		},
+		{Worker: worker2, CpuPercent: -1, Load: 60, Routers: []RouterLoad{{Router: other, Load: 60}}},
+		{Worker: worker3, CpuPercent: -1, Load: 0},
+	}
+
+	// The smallest Router moves to the least loaded Worker.
+	recommendations := planRebalance(loads, options)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, small, recommendations[0].Router)
+	assert.Equal(t, worker1, recommendations[0].From)
+	assert.Equal(t, worker3, recommendations[0].To)
+	assert.Equal(t, "load 130 above 100", recommendations[0].Reason)
+
+	// Saturating CPU.
+	loads[0].Load, loads[0].Routers = 80, loads[0].Routers[1:]
+	loads[1].CpuPercent = 95
+	recommendations = planRebalance(loads, options)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, other, recommendations[0].Router)
+	assert.Equal(t, worker3, recommendations[0].To)
+	assert.Equal(t, "cpu 95% above 80%", recommendations[0].Reason)
+
+	// No Worker able to take the load.
+	loads = loads[:2]
+	recommendations = planRebalance(loads, options)
+	assert.Empty(t, recommendations)
+
+	// Balanced.
+	loads[1].CpuPercent = 10
+	assert.Empty(t, planRebalance(loads, options))
+}
+
+func TestNewRebalancer_Validation(t *testing.T) {
+	_, err := NewRebalancer(WithRebalanceInterval(0))
+	assert.IsType(t, ValidationError{}, err)
+
+	_, err = NewRebalancer(WithRebalanceThresholds(0, 80))
+	assert.IsType(t, ValidationError{}, err)
+
+	_, err = NewRebalancer(WithRebalanceEntityLoads(-1, 1))
+	assert.IsType(t, ValidationError{}, err)
+
+	rebalancer, err := NewRebalancer()
+	assert.NoError(t, err)
+	assert.NotNil(t, rebalancer)
+}
+
+func TestProcessCpuTime(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+
+	cpuTime, err := processCpuTime(os.Getpid())
+	assert.NoError(t, err)
+	assert.True(t, cpuTime >= 0)
+
+	_, err = processCpuTime(-1)
+	assert.Error(t, err)
+}
+
+func TestMigrateRouter(t *testing.T) {
+	worker1 := CreateTestWorker()
+	defer worker1.Close()
+	worker2 := CreateTestWorker()
+	defer worker2.Close()
+
+	rebalancer, err := NewRebalancer(WithRebalanceThresholds(3, 0), WithRebalanceAutoExecute(nil))
+	require.NoError(t, err)
+	rebalancer.AddWorker(worker1)
+	rebalancer.AddWorker(worker2)
+
+	// Two Routers with a Producer each (load 2).
+	var (
+		routers   []*Router
+		producers []*Producer
+	)
+	for i := 0; i < 2; i++ {
+		router, err := worker1.CreateRouter(testPipeMediaCodecs)
+		require.NoError(t, err)
+		transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+			ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+		})
+		require.NoError(t, err)
+		producer, err := transport.Produce(audioProducerParameters)
+		require.NoError(t, err)
+
+		routers = append(routers, router)
+		producers = append(producers, producer)
+	}
+
+	var (
+		migrated    *Router
+		consumerIds map[string]string
+		observed    int
+	)
+	rebalancer.On("migrate", func(recommendation RebalanceRecommendation, newRouter *Router, ids map[string]string) {
+		migrated, consumerIds = newRouter, ids
+	})
+	for _, router := range routers {
+		router.Observer().On("migrate", func(newRouter *Router, ids map[string]string) {
+			observed++
+		})
+	}
+
+	recommendations := rebalancer.Run()
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, worker1, recommendations[0].From)
+	assert.Equal(t, worker2, recommendations[0].To)
+
+	require.NotNil(t, migrated)
+	assert.NotNil(t, consumerIds)
+	assert.Equal(t, 1, observed)
+	assert.True(t, recommendations[0].Router.Closed())
+
+	for i, router := range routers {
+		if router == recommendations[0].Router {
+			assert.NotNil(t, migrated.getProducer(producers[i].Id()))
+		}
+	}
+
+	loads := rebalancer.Loads()
+	require.Len(t, loads, 2)
+	assert.EqualValues(t, 2, loads[0].Load)
+	assert.EqualValues(t, 2, loads[1].Load)
+
+	// Balanced now.
+	assert.Empty(t, rebalancer.Run())
+}
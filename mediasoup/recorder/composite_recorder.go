@@ -0,0 +1,991 @@
+// Package recorder records the Producers of a mediasoup Router into composite
+// files through ffmpeg, along with the manifests describing the recordings.
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/sirupsen/logrus"
+)
+
+// Composite recording layouts.
+const (
+	// All the video streams in a grid of equal tiles.
+	RecordingLayoutGrid = "grid"
+	// The active speaker in a large tile, the other video streams in a strip
+	// below it.
+	RecordingLayoutSpeaker = "speaker"
+)
+
+// CompositeRecorderOptions to record the Producers of a Router into a single
+// composite file.
+type CompositeRecorderOptions struct {
+	// Listen IP of the PlainRtpTransports sending media to the encoder.
+	ListenIp mediasoup.ListenIp
+	// IP the encoder receives media on.
+	EncoderIp string
+	// Port range used to receive media on the encoder side.
+	EncoderMinPort uint16
+	EncoderMaxPort uint16
+	// RTP capabilities of the encoder, the Router ones (without RTX) if not set.
+	RtpCapabilities *mediasoup.RtpCapabilities
+	// Path of the output files. A segment number is added before the
+	// extension: the ffmpeg filter graph cannot be changed while running, so
+	// a new ffmpeg process, and segment, is started on every Producer added
+	// or removed, active speaker switch (in the "speaker" layout) and encoder
+	// restart. The RecordingManifest listing the segments is written with the
+	// ".json" extension instead.
+	OutputPath string
+	// Path of the ffmpeg binary.
+	FFmpegBin string
+	// Extra ffmpeg arguments set before the output path, codecs by default.
+	FFmpegOutputArgs []string
+	Width            int
+	Height           int
+	Layout           string
+	// appData key identifying the peer of each Producer, used to show the
+	// video of the active speaker.
+	PeerKey string
+	// Minimum interval between two active speaker switches, so between two
+	// segments started by them.
+	SpeakerSwitchInterval time.Duration
+	// Number of times the encoder is restarted after failing, before the
+	// recording is stopped.
+	MaxEncoderRestarts int
+	// Delay before requesting key frames once the encoder (re)started.
+	KeyFrameDelay time.Duration
+}
+
+// RecordingLayout is the current composition of the video streams.
+type RecordingLayout struct {
+	Layout string
+	Width  int
+	Height int
+	// Producer shown in the large tile of the "speaker" layout.
+	MainProducerId string
+}
+
+// CompositeRecorderStream is a Producer being recorded.
+type CompositeRecorderStream struct {
+	Producer    *mediasoup.Producer
+	Consumer    *mediasoup.Consumer
+	Transport   *mediasoup.PlainRtpTransport
+	EncoderPort uint16
+	ports       *rtpPortPair
+	// Listeners of the Producer events.
+	listeners []mediasoup.ListenerHandle
+}
+
+// CompositeRecorder records the given Producers into a single composite file
+// through ffmpeg. It handles the Consumers creation, the layout, the active
+// speaker switching and the encoder failures, and keeps the manifest of the
+// recording up to date on each segment (see CompositeRecorderOptions.OutputPath).
+//
+// @emits {segment: String} segmentstart
+// @emits {layout: RecordingLayout} layoutchange
+// @emits {restarts: Number, err: error} encoderrestart
+// @emits {err: error} failure
+// @emits stop
+type CompositeRecorder struct {
+	mediasoup.EventEmitter
+	logger             logrus.FieldLogger
+	router             *mediasoup.Router
+	options            CompositeRecorderOptions
+	rtpCapabilities    mediasoup.RtpCapabilities
+	mu                 sync.Mutex
+	streams            map[string]*CompositeRecorderStream
+	addingStreams      map[string]struct{}
+	layout             RecordingLayout
+	audioLevelObserver mediasoup.RtpObserver
+	lastSpeakerSwitch  time.Time
+	encoder            *recorderEncoder
+	sdpFile            string
+	segment            int
+	restarts           int
+	started            bool
+	manifest           *recordingManifestBuilder
+}
+
+func NewCompositeRecorder(router *mediasoup.Router, options CompositeRecorderOptions) *CompositeRecorder {
+	logger := mediasoup.TypeLogger("CompositeRecorder")
+
+	logger.Debug("constructor()")
+
+	if len(options.ListenIp.Ip) == 0 {
+		options.ListenIp.Ip = "127.0.0.1"
+	}
+	if len(options.EncoderIp) == 0 {
+		options.EncoderIp = "127.0.0.1"
+	}
+	if options.EncoderMinPort == 0 {
+		options.EncoderMinPort = 20000
+	}
+	if options.EncoderMaxPort == 0 {
+		options.EncoderMaxPort = 29999
+	}
+	if len(options.FFmpegBin) == 0 {
+		options.FFmpegBin = "ffmpeg"
+	}
+	if options.FFmpegOutputArgs == nil {
+		options.FFmpegOutputArgs = []string{
+			"-c:v", "libx264", "-preset", "veryfast", "-pix_fmt", "yuv420p", "-c:a", "aac",
+		}
+	}
+	if options.Width == 0 || options.Height == 0 {
+		options.Width, options.Height = 1280, 720
+	}
+	if len(options.Layout) == 0 {
+		options.Layout = RecordingLayoutGrid
+	}
+	if options.SpeakerSwitchInterval == 0 {
+		options.SpeakerSwitchInterval = 2 * time.Second
+	}
+	if options.MaxEncoderRestarts == 0 {
+		options.MaxEncoderRestarts = 3
+	}
+	if options.KeyFrameDelay == 0 {
+		options.KeyFrameDelay = time.Second
+	}
+
+	var rtpCapabilities mediasoup.RtpCapabilities
+
+	if options.RtpCapabilities != nil {
+		rtpCapabilities = *options.RtpCapabilities
+	} else {
+		rtpCapabilities = router.RtpCapabilities()
+		rtpCapabilities.Codecs = nil
+
+		// The encoder does not handle retransmissions.
+		for _, codec := range router.RtpCapabilities().Codecs {
+			if !strings.HasSuffix(strings.ToLower(codec.MimeType), "/rtx") {
+				rtpCapabilities.Codecs = append(rtpCapabilities.Codecs, codec)
+			}
+		}
+	}
+
+	return &CompositeRecorder{
+		EventEmitter:    mediasoup.NewEventEmitter(logger),
+		logger:          logger,
+		router:          router,
+		options:         options,
+		rtpCapabilities: rtpCapabilities,
+		streams:         make(map[string]*CompositeRecorderStream),
+		addingStreams:   make(map[string]struct{}),
+		layout: RecordingLayout{
+			Layout: options.Layout,
+			Width:  options.Width,
+			Height: options.Height,
+		},
+		manifest: newRecordingManifestBuilder(options.OutputPath),
+	}
+}
+
+// Layout returns the current layout.
+func (r *CompositeRecorder) Layout() RecordingLayout {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.layout
+}
+
+// Manifest returns the current manifest of the recording.
+func (r *CompositeRecorder) Manifest() RecordingManifest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.manifest.build()
+}
+
+// Streams returns the Producers being recorded.
+func (r *CompositeRecorder) Streams() (streams []CompositeRecorderStream) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stream := range r.streams {
+		streams = append(streams, *stream)
+	}
+
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].Producer.Id() < streams[j].Producer.Id()
+	})
+
+	return
+}
+
+// Start records the given Producers.
+func (r *CompositeRecorder) Start(producers ...*mediasoup.Producer) (err error) {
+	r.logger.Debug("start()")
+
+	if err = r.router.BlockE2eeFeature(mediasoup.E2eeFeatureRecording); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.started {
+		r.mu.Unlock()
+		return mediasoup.NewInvalidStateError("recording already started")
+	}
+	r.started = true
+	r.manifest = newRecordingManifestBuilder(r.options.OutputPath)
+	r.manifest.start()
+	r.mu.Unlock()
+
+	defer func() {
+		if err != nil {
+			r.Stop()
+		}
+	}()
+
+	// For the "speaker" layout and the speaker timeline of the manifest.
+	audioLevelObserver, err := r.router.CreateAudioLevelObserver(&mediasoup.CreateAudioLevelObserverParams{
+		MaxEntries: 1,
+		Threshold:  -70,
+		Interval:   500,
+	})
+	if err != nil {
+		return
+	}
+
+	audioLevelObserver.On("volumes", r.handleVolumes)
+
+	r.mu.Lock()
+	r.audioLevelObserver = audioLevelObserver
+	r.mu.Unlock()
+
+	for _, producer := range producers {
+		if _, err = r.addStream(producer); err != nil {
+			return
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.startEncoder()
+}
+
+// AddProducer adds a Producer to the recording, starting a new segment.
+func (r *CompositeRecorder) AddProducer(producer *mediasoup.Producer) (err error) {
+	r.logger.Debugf("addProducer() [producerId:%s]", producer.Id())
+
+	added, err := r.addStream(producer)
+	if err != nil || !added {
+		return
+	}
+
+	return r.restartEncoder()
+}
+
+// RemoveProducer removes a Producer from the recording, starting a new
+// segment.
+func (r *CompositeRecorder) RemoveProducer(producerId string) error {
+	r.logger.Debugf("removeProducer() [producerId:%s]", producerId)
+
+	if !r.removeStream(producerId) {
+		return nil
+	}
+
+	return r.restartEncoder()
+}
+
+// Stop the recording, closing the encoder and the Consumers.
+func (r *CompositeRecorder) Stop() {
+	r.mu.Lock()
+
+	if !r.started {
+		r.mu.Unlock()
+		return
+	}
+
+	r.logger.Debug("stop()")
+
+	r.started = false
+	encoder := r.encoder
+	r.encoder = nil
+	streams := r.streams
+	r.streams = make(map[string]*CompositeRecorderStream)
+	audioLevelObserver := r.audioLevelObserver
+	r.audioLevelObserver = nil
+
+	r.mu.Unlock()
+
+	stopEncoder(encoder)
+
+	if audioLevelObserver != nil {
+		audioLevelObserver.Close()
+	}
+
+	// Closing the Transport closes its Consumer.
+	for _, stream := range streams {
+		stream.close()
+	}
+
+	if len(r.sdpFile) > 0 {
+		os.Remove(r.sdpFile)
+	}
+
+	r.mu.Lock()
+	r.manifest.stop()
+	r.writeManifest()
+	r.mu.Unlock()
+
+	r.SafeEmit("stop")
+}
+
+func (r *CompositeRecorder) addStream(producer *mediasoup.Producer) (added bool, err error) {
+	// Reserve the stream, not to add the Producer twice concurrently.
+	r.mu.Lock()
+	_, exists := r.streams[producer.Id()]
+	_, adding := r.addingStreams[producer.Id()]
+	if !exists && !adding {
+		r.addingStreams[producer.Id()] = struct{}{}
+	}
+	r.mu.Unlock()
+
+	if exists || adding {
+		return
+	}
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.addingStreams, producer.Id())
+		r.mu.Unlock()
+	}()
+
+	ports, err := reserveRtpPortPair(
+		r.options.EncoderIp, r.options.EncoderMinPort, r.options.EncoderMaxPort)
+	if err != nil {
+		return
+	}
+
+	transport, err := r.router.CreatePlainRtpTransport(mediasoup.CreatePlainRtpTransportParams{
+		ListenIp: r.options.ListenIp,
+		RtcpMux:  false,
+		AppData:  mediasoup.H{"compositeRecorder": true},
+	})
+	if err != nil {
+		ports.release()
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			transport.Close()
+			ports.release()
+		}
+	}()
+
+	err = transport.Connect(mediasoup.TransportConnectParams{
+		Ip:       r.options.EncoderIp,
+		Port:     ports.port,
+		RtcpPort: ports.port + 1,
+	})
+	if err != nil {
+		return
+	}
+
+	// Start paused, it is resumed once the encoder listens.
+	consumer, err := transport.Consume(mediasoup.TransportConsumeParams{
+		ProducerId:      producer.Id(),
+		RtpCapabilities: r.rtpCapabilities,
+		Paused:          true,
+	})
+	if err != nil {
+		return
+	}
+
+	consumer.Observer().On("close", func() {
+		if r.removeStream(producer.Id()) {
+			r.restartEncoder()
+		}
+	})
+
+	listeners := []mediasoup.ListenerHandle{
+		producer.Observer().Listen("pause", func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.manifest.pauseProducer(producer.Id())
+		}),
+		producer.Observer().Listen("resume", func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.manifest.resumeProducer(producer.Id())
+		}),
+	}
+
+	r.mu.Lock()
+	audioLevelObserver := r.audioLevelObserver
+	r.mu.Unlock()
+
+	if audioLevelObserver != nil && producer.Kind() == "audio" {
+		audioLevelObserver.AddProducer(producer.Id())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.streams[producer.Id()] = &CompositeRecorderStream{
+		Producer:    producer,
+		Consumer:    consumer,
+		Transport:   transport,
+		EncoderPort: ports.port,
+		ports:       ports,
+		listeners:   listeners,
+	}
+
+	r.manifest.addProducer(producer.Id(), producer.Kind(), r.peerOf(producer),
+		consumer.RtpParameters().Codecs[0], producer.Paused())
+
+	return true, nil
+}
+
+func (r *CompositeRecorder) removeStream(producerId string) bool {
+	r.mu.Lock()
+	stream, ok := r.streams[producerId]
+	delete(r.streams, producerId)
+
+	if ok && r.layout.MainProducerId == producerId {
+		r.layout.MainProducerId = ""
+	}
+	if ok {
+		r.manifest.removeProducer(producerId)
+	}
+	r.mu.Unlock()
+
+	if ok {
+		stream.close()
+	}
+
+	return ok
+}
+
+// close closes the Transport of the stream, and removes its listeners.
+func (stream *CompositeRecorderStream) close() {
+	for _, listener := range stream.listeners {
+		listener.Remove()
+	}
+
+	stream.Transport.Close()
+	stream.ports.release()
+}
+
+// restartEncoder starts a new segment if the recording is running.
+func (r *CompositeRecorder) restartEncoder() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started {
+		return nil
+	}
+
+	encoder := r.encoder
+	r.encoder = nil
+
+	stopEncoder(encoder)
+
+	r.manifest.stopSegment()
+	defer r.writeManifest()
+
+	return r.startEncoder()
+}
+
+// startEncoder must be called with the lock held.
+func (r *CompositeRecorder) startEncoder() (err error) {
+	streams := r.sortedStreams()
+
+	if len(streams) == 0 {
+		return
+	}
+
+	sdp := buildCompositeRecorderSdp(r.options.EncoderIp, streams)
+
+	if len(r.sdpFile) == 0 {
+		f, err := ioutil.TempFile("", "mediasoup-recording-*.sdp")
+		if err != nil {
+			return err
+		}
+		f.Close()
+
+		r.sdpFile = f.Name()
+	}
+
+	if err = ioutil.WriteFile(r.sdpFile, []byte(sdp), 0644); err != nil {
+		return
+	}
+
+	segmentPath := compositeRecorderSegmentPath(r.options.OutputPath, r.segment)
+	r.segment++
+
+	args := []string{
+		"-loglevel", "error",
+		"-protocol_whitelist", "file,udp,rtp",
+		"-fflags", "+genpts",
+		"-i", r.sdpFile,
+	}
+	args = append(args, r.filterArgs(streams)...)
+	args = append(args, r.options.FFmpegOutputArgs...)
+	args = append(args, "-y", segmentPath)
+
+	r.logger.Debugf("starting encoder: %s %s", r.options.FFmpegBin, strings.Join(args, " "))
+
+	encoder := &recorderEncoder{
+		cmd:  exec.Command(r.options.FFmpegBin, args...),
+		done: make(chan struct{}),
+	}
+	encoder.cmd.Stderr = &encoder.stderr
+
+	// Freeing the reserved ports at the last moment for ffmpeg to bind them.
+	for _, stream := range streams {
+		stream.ports.unbind()
+	}
+
+	if err = encoder.cmd.Start(); err != nil {
+		return
+	}
+
+	r.encoder = encoder
+
+	producerIds := make([]string, 0, len(streams))
+	for _, stream := range streams {
+		producerIds = append(producerIds, stream.Producer.Id())
+	}
+	r.manifest.startSegment(segmentPath, r.layout, producerIds)
+	r.writeManifest()
+
+	mediasoup.Spawn("recorder.waitEncoder", func() {
+		r.waitEncoder(encoder)
+	})
+
+	// Resume the Consumers and ask for key frames once the encoder listens.
+	time.AfterFunc(r.options.KeyFrameDelay, func() {
+		for _, stream := range streams {
+			if stream.Consumer.Paused() {
+				stream.Consumer.Resume()
+			}
+			if stream.Consumer.Kind() == "video" {
+				stream.Consumer.RequestKeyFrame()
+			}
+		}
+	})
+
+	mediasoup.Spawn("recorder.emit", func() {
+		r.SafeEmit("segmentstart", segmentPath)
+	})
+
+	return
+}
+
+func (r *CompositeRecorder) waitEncoder(encoder *recorderEncoder) {
+	err := encoder.cmd.Wait()
+
+	close(encoder.done)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Stopped or replaced on purpose.
+	if r.encoder != encoder {
+		return
+	}
+
+	r.encoder = nil
+	r.manifest.stopSegment()
+
+	if err == nil {
+		err = fmt.Errorf("encoder exited")
+	}
+	err = fmt.Errorf("%s: %s", err, strings.TrimSpace(encoder.stderr.String()))
+
+	if r.restarts >= r.options.MaxEncoderRestarts {
+		r.logger.Errorf("encoder failed, stopping the recording: %s", err)
+
+		mediasoup.Spawn("recorder.failure", func() {
+			r.SafeEmit("failure", err)
+			r.Stop()
+		})
+
+		return
+	}
+
+	r.restarts++
+
+	r.logger.Warnf("encoder failed, restarting it [restarts:%d]: %s", r.restarts, err)
+
+	restarts := r.restarts
+	mediasoup.Spawn("recorder.emit", func() {
+		r.SafeEmit("encoderrestart", restarts, err)
+	})
+
+	if err := r.startEncoder(); err != nil {
+		mediasoup.Spawn("recorder.failure", func() {
+			r.SafeEmit("failure", err)
+			r.Stop()
+		})
+	}
+}
+
+func (r *CompositeRecorder) handleVolumes(volumes []mediasoup.VolumeInfo) {
+	if len(volumes) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	r.manifest.speaker(volumes[0].Producer.Id(), r.peerOf(volumes[0].Producer))
+	r.mu.Unlock()
+
+	if r.options.Layout != RecordingLayoutSpeaker || len(r.options.PeerKey) == 0 {
+		return
+	}
+
+	peer, ok := mediasoup.AppDataValue(volumes[0].Producer.AppData(), r.options.PeerKey)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+
+	if time.Since(r.lastSpeakerSwitch) < r.options.SpeakerSwitchInterval {
+		r.mu.Unlock()
+		return
+	}
+
+	mainProducerId := ""
+
+	for id, stream := range r.streams {
+		if stream.Producer.Kind() != "video" {
+			continue
+		}
+		if value, ok := mediasoup.AppDataValue(stream.Producer.AppData(), r.options.PeerKey); ok && value == peer {
+			mainProducerId = id
+			break
+		}
+	}
+
+	if len(mainProducerId) == 0 || mainProducerId == r.layout.MainProducerId {
+		r.mu.Unlock()
+		return
+	}
+
+	r.lastSpeakerSwitch = time.Now()
+	r.layout.MainProducerId = mainProducerId
+	layout := r.layout
+
+	r.mu.Unlock()
+
+	r.logger.Debugf("active speaker changed [producerId:%s]", mainProducerId)
+
+	r.SafeEmit("layoutchange", layout)
+
+	r.restartEncoder()
+}
+
+// peerOf returns the peer of the given Producer, if any.
+func (r *CompositeRecorder) peerOf(producer *mediasoup.Producer) string {
+	if len(r.options.PeerKey) == 0 {
+		return ""
+	}
+
+	if value, ok := mediasoup.AppDataValue(producer.AppData(), r.options.PeerKey); ok {
+		return fmt.Sprint(value)
+	}
+
+	return ""
+}
+
+// writeManifest must be called with the lock held.
+func (r *CompositeRecorder) writeManifest() {
+	path := compositeRecorderManifestPath(r.options.OutputPath)
+
+	if err := writeRecordingManifest(path, r.manifest.build()); err != nil {
+		r.logger.Warnf("writing manifest failed: %s", err)
+	}
+}
+
+// sortedStreams must be called with the lock held.
+func (r *CompositeRecorder) sortedStreams() (streams []*CompositeRecorderStream) {
+	for _, stream := range r.streams {
+		streams = append(streams, stream)
+	}
+
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].Producer.Id() < streams[j].Producer.Id()
+	})
+
+	return
+}
+
+// filterArgs must be called with the lock held.
+func (r *CompositeRecorder) filterArgs(streams []*CompositeRecorderStream) (args []string) {
+	videoCount, audioCount, mainIndex := 0, 0, -1
+
+	for _, stream := range streams {
+		if stream.Producer.Kind() == "video" {
+			if stream.Producer.Id() == r.layout.MainProducerId {
+				mainIndex = videoCount
+			}
+			videoCount++
+		} else {
+			audioCount++
+		}
+	}
+
+	filterGraph := buildCompositeFilterGraph(r.layout, videoCount, audioCount, mainIndex)
+
+	args = append(args, "-filter_complex", filterGraph)
+
+	if videoCount > 0 {
+		args = append(args, "-map", "[vout]")
+	}
+	if audioCount > 0 {
+		args = append(args, "-map", "[aout]")
+	}
+
+	return
+}
+
+type recorderEncoder struct {
+	cmd    *exec.Cmd
+	stderr bytes.Buffer
+	done   chan struct{}
+}
+
+type layoutRect struct {
+	X, Y, Width, Height int
+}
+
+// compositeLayoutRects returns the rectangle of each video stream. mainIndex
+// is the stream shown in the large tile of the "speaker" layout, if any.
+func compositeLayoutRects(layout RecordingLayout, count, mainIndex int) (rects []layoutRect) {
+	if count == 0 {
+		return
+	}
+
+	if layout.Layout == RecordingLayoutSpeaker && mainIndex >= 0 && count > 1 {
+		mainHeight := layout.Height * 3 / 4
+		thumbWidth := layout.Width / (count - 1)
+		thumb := 0
+
+		for i := 0; i < count; i++ {
+			if i == mainIndex {
+				rects = append(rects, layoutRect{0, 0, layout.Width, mainHeight})
+				continue
+			}
+
+			rects = append(rects, layoutRect{
+				thumb * thumbWidth, mainHeight, thumbWidth, layout.Height - mainHeight,
+			})
+			thumb++
+		}
+
+		return
+	}
+
+	cols := int(math.Ceil(math.Sqrt(float64(count))))
+	rows := (count + cols - 1) / cols
+	tileWidth, tileHeight := layout.Width/cols, layout.Height/rows
+
+	for i := 0; i < count; i++ {
+		rects = append(rects, layoutRect{
+			(i % cols) * tileWidth, (i / cols) * tileHeight, tileWidth, tileHeight,
+		})
+	}
+
+	return
+}
+
+// buildCompositeFilterGraph returns the ffmpeg filtergraph composing the
+// video streams into "[vout]" and mixing the audio streams into "[aout]".
+func buildCompositeFilterGraph(layout RecordingLayout, videoCount, audioCount, mainIndex int) string {
+	var filters []string
+
+	if videoCount > 0 {
+		filters = append(filters,
+			fmt.Sprintf("color=c=black:s=%dx%d:r=30[bg]", layout.Width, layout.Height))
+
+		previous := "bg"
+
+		for i, rect := range compositeLayoutRects(layout, videoCount, mainIndex) {
+			output := fmt.Sprintf("o%d", i)
+			if i == videoCount-1 {
+				output = "vout"
+			}
+
+			filters = append(filters,
+				fmt.Sprintf("[0:v:%d]scale=%d:%d[v%d]", i, rect.Width, rect.Height, i),
+				fmt.Sprintf("[%s][v%d]overlay=%d:%d[%s]", previous, i, rect.X, rect.Y, output),
+			)
+
+			previous = output
+		}
+	}
+
+	if audioCount == 1 {
+		filters = append(filters, "[0:a:0]anull[aout]")
+	} else if audioCount > 1 {
+		var inputs string
+
+		for i := 0; i < audioCount; i++ {
+			inputs += fmt.Sprintf("[0:a:%d]", i)
+		}
+
+		filters = append(filters, fmt.Sprintf("%samix=inputs=%d[aout]", inputs, audioCount))
+	}
+
+	return strings.Join(filters, ";")
+}
+
+// buildCompositeRecorderSdp returns the SDP describing the streams received
+// by the encoder, video ones first.
+func buildCompositeRecorderSdp(ip string, streams []*CompositeRecorderStream) string {
+	var sdp strings.Builder
+
+	fmt.Fprintf(&sdp, "v=0\r\no=- 0 0 IN IP4 %s\r\ns=mediasoup composite recording\r\n", ip)
+	fmt.Fprintf(&sdp, "c=IN IP4 %s\r\nt=0 0\r\n", ip)
+
+	for _, kind := range []string{"video", "audio"} {
+		for _, stream := range streams {
+			if stream.Consumer.Kind() != kind {
+				continue
+			}
+
+			codec := stream.Consumer.RtpParameters().Codecs[0]
+			codecName := codec.MimeType[strings.Index(codec.MimeType, "/")+1:]
+
+			fmt.Fprintf(&sdp, "m=%s %d RTP/AVP %d\r\n", kind, stream.EncoderPort, codec.PayloadType)
+
+			if codec.Channels > 1 {
+				fmt.Fprintf(&sdp, "a=rtpmap:%d %s/%d/%d\r\n",
+					codec.PayloadType, codecName, codec.ClockRate, codec.Channels)
+			} else {
+				fmt.Fprintf(&sdp, "a=rtpmap:%d %s/%d\r\n", codec.PayloadType, codecName, codec.ClockRate)
+			}
+
+			if params := codec.Parameters; params != nil && strings.EqualFold(codecName, "H264") {
+				fmt.Fprintf(&sdp, "a=fmtp:%d packetization-mode=%d", codec.PayloadType, params.PacketizationMode)
+				if len(params.ProfileLevelId) > 0 {
+					fmt.Fprintf(&sdp, ";profile-level-id=%s", params.ProfileLevelId)
+				}
+				sdp.WriteString("\r\n")
+			}
+
+			sdp.WriteString("a=recvonly\r\n")
+		}
+	}
+
+	return sdp.String()
+}
+
+func compositeRecorderSegmentPath(outputPath string, segment int) string {
+	ext := filepath.Ext(outputPath)
+
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(outputPath, ext), segment, ext)
+}
+
+// stopEncoder asks ffmpeg to finish the file and kills it if it does not exit
+// in time.
+func stopEncoder(encoder *recorderEncoder) {
+	if encoder == nil {
+		return
+	}
+
+	encoder.cmd.Process.Signal(syscall.SIGINT)
+
+	select {
+	case <-encoder.done:
+	case <-time.After(5 * time.Second):
+		encoder.cmd.Process.Kill()
+	}
+}
+
+// reservedRtpPorts are the "ip:port" of the RTP ports reserved for the
+// encoders, so that no two streams, whatever their recorder, get the same
+// ones.
+var reservedRtpPorts = struct {
+	sync.Mutex
+	ports map[string]bool
+}{
+	ports: make(map[string]bool),
+}
+
+// rtpPortPair is a pair of RTP and RTCP ports reserved for an encoder. The
+// ports are kept bound until unbind, so that no other process takes them
+// before the encoder binds them.
+type rtpPortPair struct {
+	mu    sync.Mutex
+	ip    string
+	port  uint16
+	conns []net.PacketConn
+}
+
+// reserveRtpPortPair reserves an even port, and the next one, in the given
+// range.
+func reserveRtpPortPair(ip string, minPort, maxPort uint16) (*rtpPortPair, error) {
+	reservedRtpPorts.Lock()
+	defer reservedRtpPorts.Unlock()
+
+	for p := int(minPort + minPort%2); p+1 <= int(maxPort); p += 2 {
+		key := net.JoinHostPort(ip, fmt.Sprint(p))
+
+		if reservedRtpPorts.ports[key] {
+			continue
+		}
+
+		rtpConn, err := net.ListenPacket("udp", key)
+		if err != nil {
+			continue
+		}
+		rtcpConn, err := net.ListenPacket("udp", net.JoinHostPort(ip, fmt.Sprint(p+1)))
+		if err != nil {
+			rtpConn.Close()
+			continue
+		}
+
+		reservedRtpPorts.ports[key] = true
+
+		return &rtpPortPair{
+			ip:    ip,
+			port:  uint16(p),
+			conns: []net.PacketConn{rtpConn, rtcpConn},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no free port pair in range %d-%d", minPort, maxPort)
+}
+
+// unbind frees the ports for the encoder to bind them, keeping them reserved
+// for the other streams.
+func (p *rtpPortPair) unbind() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+
+	p.conns = nil
+}
+
+// release unbinds the ports and ends their reservation.
+func (p *rtpPortPair) release() {
+	p.unbind()
+
+	reservedRtpPorts.Lock()
+	defer reservedRtpPorts.Unlock()
+
+	delete(reservedRtpPorts.ports, net.JoinHostPort(p.ip, fmt.Sprint(p.port)))
+}
@@ -0,0 +1,82 @@
+package recorder
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositeLayoutRects(t *testing.T) {
+	layout := RecordingLayout{Layout: RecordingLayoutGrid, Width: 1280, Height: 720}
+
+	assert.Equal(t, []layoutRect{
+		{0, 0, 640, 360},
+		{640, 0, 640, 360},
+		{0, 360, 640, 360},
+	}, compositeLayoutRects(layout, 3, -1))
+
+	layout.Layout = RecordingLayoutSpeaker
+
+	assert.Equal(t, []layoutRect{
+		{0, 540, 640, 180},
+		{0, 0, 1280, 540},
+		{640, 540, 640, 180},
+	}, compositeLayoutRects(layout, 3, 1))
+
+	// Without active speaker the grid is used.
+	assert.Len(t, compositeLayoutRects(layout, 4, -1), 4)
+	assert.Empty(t, compositeLayoutRects(layout, 0, -1))
+}
+
+func TestBuildCompositeFilterGraph(t *testing.T) {
+	layout := RecordingLayout{Layout: RecordingLayoutGrid, Width: 1280, Height: 720}
+
+	assert.Equal(t,
+		"color=c=black:s=1280x720:r=30[bg];"+
+			"[0:v:0]scale=640:720[v0];[bg][v0]overlay=0:0[o0];"+
+			"[0:v:1]scale=640:720[v1];[o0][v1]overlay=640:0[vout];"+
+			"[0:a:0][0:a:1]amix=inputs=2[aout]",
+		buildCompositeFilterGraph(layout, 2, 2, -1),
+	)
+
+	assert.Equal(t, "[0:a:0]anull[aout]", buildCompositeFilterGraph(layout, 0, 1, -1))
+}
+
+func TestCompositeRecorderSegmentPath(t *testing.T) {
+	assert.Equal(t, "/tmp/room-0.mkv", compositeRecorderSegmentPath("/tmp/room.mkv", 0))
+	assert.Equal(t, "/tmp/room-2", compositeRecorderSegmentPath("/tmp/room", 2))
+}
+
+func TestReserveRtpPortPair(t *testing.T) {
+	first, err := reserveRtpPortPair("127.0.0.1", 41001, 41999)
+	assert.NoError(t, err)
+	defer first.release()
+	assert.EqualValues(t, 0, first.port%2)
+
+	// Bound until unbound, and reserved until released.
+	_, err = net.ListenPacket("udp", net.JoinHostPort("127.0.0.1", fmt.Sprint(first.port)))
+	assert.Error(t, err)
+
+	first.unbind()
+
+	conn, err := net.ListenPacket("udp", net.JoinHostPort("127.0.0.1", fmt.Sprint(first.port)))
+	assert.NoError(t, err)
+	conn.Close()
+
+	second, err := reserveRtpPortPair("127.0.0.1", first.port, 41999)
+	assert.NoError(t, err)
+	defer second.release()
+	assert.NotEqual(t, first.port, second.port)
+
+	first.release()
+
+	third, err := reserveRtpPortPair("127.0.0.1", first.port, first.port+1)
+	assert.NoError(t, err)
+	defer third.release()
+	assert.Equal(t, first.port, third.port)
+
+	_, err = reserveRtpPortPair("127.0.0.1", first.port, first.port+1)
+	assert.Error(t, err)
+}
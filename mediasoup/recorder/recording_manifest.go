@@ -0,0 +1,276 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+)
+
+// RecordingManifest describes a composite recording for the post-processing
+// pipelines, so that they can align and edit the segments without querying
+// the application. It is written as JSON next to the segments (see
+// CompositeRecorder). Times are unix times in milliseconds.
+type RecordingManifest struct {
+	OutputPath   string                   `json:"outputPath"`
+	StartedAt    int64                    `json:"startedAt"`
+	StoppedAt    int64                    `json:"stoppedAt,omitempty"`
+	Segments     []RecordingSegment       `json:"segments"`
+	Participants []RecordingParticipant   `json:"participants"`
+	Producers    []RecordingProducer      `json:"producers"`
+	Speakers     []RecordingSpeakerChange `json:"speakers"`
+}
+
+// RecordingSegment is a file of the recording, a new one being started on
+// every layout change or encoder restart.
+type RecordingSegment struct {
+	Path           string   `json:"path"`
+	StartedAt      int64    `json:"startedAt"`
+	StoppedAt      int64    `json:"stoppedAt,omitempty"`
+	ProducerIds    []string `json:"producerIds"`
+	Layout         string   `json:"layout"`
+	MainProducerId string   `json:"mainProducerId,omitempty"`
+}
+
+// RecordingParticipant is a peer (see CompositeRecorderOptions.PeerKey) and
+// its recorded Producers.
+type RecordingParticipant struct {
+	Peer        string   `json:"peer"`
+	ProducerIds []string `json:"producerIds"`
+}
+
+// RecordingProducer is a recorded Producer. A Producer removed then added
+// again has an entry per period.
+type RecordingProducer struct {
+	ProducerId string           `json:"producerId"`
+	Kind       string           `json:"kind"`
+	Peer       string           `json:"peer,omitempty"`
+	MimeType   string           `json:"mimeType"`
+	ClockRate  int              `json:"clockRate"`
+	Channels   int              `json:"channels,omitempty"`
+	AddedAt    int64            `json:"addedAt"`
+	RemovedAt  int64            `json:"removedAt,omitempty"`
+	Pauses     []RecordingPause `json:"pauses,omitempty"`
+}
+
+type RecordingPause struct {
+	PausedAt  int64 `json:"pausedAt"`
+	ResumedAt int64 `json:"resumedAt,omitempty"`
+}
+
+// RecordingSpeakerChange is an entry of the speaker timeline, the loudest
+// audio Producer having changed.
+type RecordingSpeakerChange struct {
+	At         int64  `json:"at"`
+	ProducerId string `json:"producerId"`
+	Peer       string `json:"peer,omitempty"`
+}
+
+// recordingManifestBuilder tracks the recording events into a manifest.
+type recordingManifestBuilder struct {
+	manifest RecordingManifest
+	// Index of the current entry of the Producers in manifest.Producers.
+	producers map[string]int
+	now       func() time.Time
+}
+
+func newRecordingManifestBuilder(outputPath string) *recordingManifestBuilder {
+	b := &recordingManifestBuilder{
+		producers: make(map[string]int),
+		now:       time.Now,
+	}
+	b.manifest.OutputPath = outputPath
+
+	return b
+}
+
+func (b *recordingManifestBuilder) timestamp() int64 {
+	return b.now().UnixNano() / int64(time.Millisecond)
+}
+
+func (b *recordingManifestBuilder) start() {
+	b.manifest.StartedAt = b.timestamp()
+}
+
+func (b *recordingManifestBuilder) stop() {
+	b.stopSegment()
+
+	for producerId := range b.producers {
+		b.removeProducer(producerId)
+	}
+
+	b.manifest.StoppedAt = b.timestamp()
+}
+
+func (b *recordingManifestBuilder) addProducer(producerId, kind, peer string, codec mediasoup.RtpCodecCapability, paused bool) {
+	if _, ok := b.producers[producerId]; ok {
+		return
+	}
+
+	b.producers[producerId] = len(b.manifest.Producers)
+	b.manifest.Producers = append(b.manifest.Producers, RecordingProducer{
+		ProducerId: producerId,
+		Kind:       kind,
+		Peer:       peer,
+		MimeType:   codec.MimeType,
+		ClockRate:  codec.ClockRate,
+		Channels:   codec.Channels,
+		AddedAt:    b.timestamp(),
+	})
+
+	if paused {
+		b.pauseProducer(producerId)
+	}
+}
+
+func (b *recordingManifestBuilder) removeProducer(producerId string) {
+	i, ok := b.producers[producerId]
+	if !ok {
+		return
+	}
+
+	b.resumeProducer(producerId)
+	b.manifest.Producers[i].RemovedAt = b.timestamp()
+
+	delete(b.producers, producerId)
+}
+
+func (b *recordingManifestBuilder) pauseProducer(producerId string) {
+	i, ok := b.producers[producerId]
+	if !ok {
+		return
+	}
+
+	producer := &b.manifest.Producers[i]
+
+	if n := len(producer.Pauses); n > 0 && producer.Pauses[n-1].ResumedAt == 0 {
+		return
+	}
+
+	producer.Pauses = append(producer.Pauses, RecordingPause{PausedAt: b.timestamp()})
+}
+
+func (b *recordingManifestBuilder) resumeProducer(producerId string) {
+	i, ok := b.producers[producerId]
+	if !ok {
+		return
+	}
+
+	producer := &b.manifest.Producers[i]
+
+	if n := len(producer.Pauses); n > 0 && producer.Pauses[n-1].ResumedAt == 0 {
+		producer.Pauses[n-1].ResumedAt = b.timestamp()
+	}
+}
+
+func (b *recordingManifestBuilder) startSegment(path string, layout RecordingLayout, producerIds []string) {
+	b.stopSegment()
+
+	b.manifest.Segments = append(b.manifest.Segments, RecordingSegment{
+		Path:           path,
+		StartedAt:      b.timestamp(),
+		ProducerIds:    producerIds,
+		Layout:         layout.Layout,
+		MainProducerId: layout.MainProducerId,
+	})
+}
+
+func (b *recordingManifestBuilder) stopSegment() {
+	if n := len(b.manifest.Segments); n > 0 && b.manifest.Segments[n-1].StoppedAt == 0 {
+		b.manifest.Segments[n-1].StoppedAt = b.timestamp()
+	}
+}
+
+func (b *recordingManifestBuilder) speaker(producerId, peer string) {
+	speakers := b.manifest.Speakers
+
+	if n := len(speakers); n > 0 && speakers[n-1].ProducerId == producerId {
+		return
+	}
+
+	b.manifest.Speakers = append(speakers, RecordingSpeakerChange{
+		At:         b.timestamp(),
+		ProducerId: producerId,
+		Peer:       peer,
+	})
+}
+
+// build returns a copy of the manifest, with its participants.
+func (b *recordingManifestBuilder) build() RecordingManifest {
+	manifest := b.manifest
+
+	manifest.Segments = append([]RecordingSegment{}, b.manifest.Segments...)
+	manifest.Speakers = append([]RecordingSpeakerChange{}, b.manifest.Speakers...)
+	manifest.Producers = make([]RecordingProducer, len(b.manifest.Producers))
+	manifest.Participants = []RecordingParticipant{}
+
+	participants := make(map[string]int)
+
+	for i, producer := range b.manifest.Producers {
+		producer.Pauses = append([]RecordingPause(nil), producer.Pauses...)
+		manifest.Producers[i] = producer
+
+		if len(producer.Peer) == 0 {
+			continue
+		}
+
+		j, ok := participants[producer.Peer]
+		if !ok {
+			j = len(manifest.Participants)
+			participants[producer.Peer] = j
+			manifest.Participants = append(manifest.Participants, RecordingParticipant{Peer: producer.Peer})
+		}
+
+		if !containsString(manifest.Participants[j].ProducerIds, producer.ProducerId) {
+			manifest.Participants[j].ProducerIds = append(manifest.Participants[j].ProducerIds, producer.ProducerId)
+		}
+	}
+
+	sort.Slice(manifest.Participants, func(i, j int) bool {
+		return manifest.Participants[i].Peer < manifest.Participants[j].Peer
+	})
+
+	return manifest
+}
+
+// compositeRecorderManifestPath returns the path of the manifest of the
+// recording with the given output path ("room.mkv" -> "room.json").
+func compositeRecorderManifestPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".json"
+}
+
+// writeRecordingManifest writes the given manifest atomically, readers never
+// seeing a partial file.
+func writeRecordingManifest(path string, manifest RecordingManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+
+	if err = ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
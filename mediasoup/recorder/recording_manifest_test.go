@@ -0,0 +1,110 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingManifestBuilder(t *testing.T) {
+	builder := newRecordingManifestBuilder("/tmp/room.mkv")
+
+	var clock int64
+	builder.now = func() time.Time {
+		return time.Unix(clock, 0)
+	}
+	at := func(seconds int64) *recordingManifestBuilder {
+		clock = seconds
+		return builder
+	}
+
+	opus := mediasoup.RtpCodecCapability{MimeType: "audio/opus", ClockRate: 48000, Channels: 2}
+	vp8 := mediasoup.RtpCodecCapability{MimeType: "video/VP8", ClockRate: 90000}
+	layout := RecordingLayout{Layout: RecordingLayoutSpeaker, MainProducerId: "v1"}
+
+	at(1001).start()
+	at(1002).addProducer("a1", "audio", "alice", opus, false)
+	at(1003).addProducer("v1", "video", "alice", vp8, true)
+	at(1004).addProducer("a2", "audio", "bob", opus, false)
+	at(1005).startSegment("/tmp/room-0.mkv", layout, []string{"a1", "a2", "v1"})
+	at(1006).speaker("a1", "alice")
+	at(1007).speaker("a1", "alice")
+	at(1008).speaker("a2", "bob")
+	at(1009).resumeProducer("v1")
+	at(1010).removeProducer("a2")
+	at(1011).startSegment("/tmp/room-1.mkv", layout, []string{"a1", "v1"})
+	at(1012).pauseProducer("a1")
+	at(1013).stop()
+
+	manifest := builder.build()
+
+	assert.Equal(t, "/tmp/room.mkv", manifest.OutputPath)
+	assert.EqualValues(t, 1001000, manifest.StartedAt)
+	assert.EqualValues(t, 1013000, manifest.StoppedAt)
+
+	require.Len(t, manifest.Segments, 2)
+	assert.Equal(t, RecordingSegment{
+		Path:           "/tmp/room-0.mkv",
+		StartedAt:      1005000,
+		StoppedAt:      1011000,
+		ProducerIds:    []string{"a1", "a2", "v1"},
+		Layout:         RecordingLayoutSpeaker,
+		MainProducerId: "v1",
+	}, manifest.Segments[0])
+	assert.EqualValues(t, 1013000, manifest.Segments[1].StoppedAt)
+
+	assert.Equal(t, []RecordingParticipant{
+		{Peer: "alice", ProducerIds: []string{"a1", "v1"}},
+		{Peer: "bob", ProducerIds: []string{"a2"}},
+	}, manifest.Participants)
+
+	require.Len(t, manifest.Producers, 3)
+	assert.Equal(t, RecordingProducer{
+		ProducerId: "a2",
+		Kind:       "audio",
+		Peer:       "bob",
+		MimeType:   "audio/opus",
+		ClockRate:  48000,
+		Channels:   2,
+		AddedAt:    1004000,
+		RemovedAt:  1010000,
+	}, manifest.Producers[2])
+	assert.Equal(t, []RecordingPause{{PausedAt: 1003000, ResumedAt: 1009000}}, manifest.Producers[1].Pauses)
+	// Paused until the end.
+	assert.Equal(t, []RecordingPause{{PausedAt: 1012000, ResumedAt: 1013000}}, manifest.Producers[0].Pauses)
+	assert.EqualValues(t, 1013000, manifest.Producers[0].RemovedAt)
+
+	assert.Equal(t, []RecordingSpeakerChange{
+		{At: 1006000, ProducerId: "a1", Peer: "alice"},
+		{At: 1008000, ProducerId: "a2", Peer: "bob"},
+	}, manifest.Speakers)
+}
+
+func TestWriteRecordingManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := compositeRecorderManifestPath(filepath.Join(dir, "room.mkv"))
+	assert.Equal(t, filepath.Join(dir, "room.json"), path)
+
+	manifest := newRecordingManifestBuilder("room.mkv").build()
+	require.NoError(t, writeRecordingManifest(path, manifest))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var read RecordingManifest
+	require.NoError(t, json.Unmarshal(data, &read))
+	assert.Equal(t, manifest, read)
+
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
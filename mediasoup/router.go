@@ -1,32 +1,67 @@
 package mediasoup
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 )
 
 type Router struct {
 	EventEmitter
-	logger                  logrus.FieldLogger
-	internal                internalData
-	data                    routerData
-	channel                 *Channel
-	transports              map[string]Transport
+	logger     logrus.FieldLogger
+	appLogger  logrus.FieldLogger
+	internal   internalData
+	data       routerData
+	channel    *Channel
+	transports map[string]Transport
+	// Guards transports, producers and rtpObservers.
+	entitiesLocker          sync.Mutex
 	producers               map[string]*Producer
 	rtpObservers            map[string]RtpObserver
 	mapRouterPipeTransports map[*Router][]*PipeTransport
+	pipeTransportsLocker    sync.Mutex
+	pipeToRouterCalls       map[pipeToRouterKey]*pipeToRouterCall
+	pipeToRouterLocker      sync.Mutex
 	observer                EventEmitter
-	closed                  bool
+	closed                  closeFlag
+	listenIpResolver        ListenIpResolver
+	workerVersion           string
+	consumerLimiter         *consumerLimiter
+	authorizer              Authorizer
+	// Set once the worker is draining.
+	workerDraining *closeFlag
+	// SSRCs of the Producers being created in the PlainRtpTransports.
+	ssrcReservations *ssrcReservations
+}
+
+type pipeToRouterKey struct {
+	producerId string
+	router     *Router
+}
+
+type pipeToRouterCall struct {
+	done         chan struct{}
+	pipeConsumer *Consumer
+	pipeProducer *Producer
+	err          error
 }
 
 func NewRouter(internal internalData, data routerData, channel *Channel) *Router {
-	logger := TypeLogger("Router")
+	appLogger := data.Settings.appLogger()
+	logger := appLogger.WithField("type", "Router")
 
 	logger.Debug("constructor()")
 
+	closed, ctx := newCloseFlag()
+
 	return &Router{
-		EventEmitter:            NewEventEmitter(AppLogger()),
+		EventEmitter:            NewEventEmitter(appLogger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		closed:                  closed,
 		logger:                  logger,
+		appLogger:               appLogger,
 		internal:                internal,
 		data:                    data,
 		channel:                 channel,
@@ -34,7 +69,9 @@ func NewRouter(internal internalData, data routerData, channel *Channel) *Router
 		producers:               make(map[string]*Producer),
 		rtpObservers:            make(map[string]RtpObserver),
 		mapRouterPipeTransports: make(map[*Router][]*PipeTransport),
-		observer:                NewEventEmitter(AppLogger()),
+		pipeToRouterCalls:       make(map[pipeToRouterKey]*pipeToRouterCall),
+		observer:                NewEventEmitter(appLogger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		ssrcReservations:        newSsrcReservations(),
 	}
 }
 
@@ -45,7 +82,7 @@ func (router *Router) Id() string {
 
 // Whether the Router is closed.
 func (router *Router) Closed() bool {
-	return router.closed
+	return router.closed.isSet()
 }
 
 // RTC capabilities of the Router.
@@ -53,43 +90,34 @@ func (router *Router) RtpCapabilities() RtpCapabilities {
 	return router.data.RtpCapabilities
 }
 
+/**
+ * Observer.
+ *
+ * @emits close
+ * @emits {transport: Transport} newtransport
+ * @emits {rtpObserver: RtpObserver} newrtpobserver
+ * @emits {oldTransport: Transport, transport: Transport, consumerIds: []string} transportrecreate
+ * @emits {feature: string} e2eefeatureblocked
+ * @emits {router: Router, consumerIds: map[string]string} migrate
+ */
 func (router *Router) Observer() EventEmitter {
 	return router.observer
 }
 
 // Close the Router.
 func (router *Router) Close() (err error) {
-	if router.closed {
+	if !router.closed.set() {
 		return
 	}
+	defer router.closed.cancelContext()
 
 	router.logger.Debug("close()")
 
-	router.closed = true
-
 	resp := router.channel.Request("router.close", router.internal)
 
-	if err = resp.Err(); err != nil {
-		return
-	}
-
-	// Close every Transport.
-	for _, transport := range router.transports {
-		transport.routerClosed()
-	}
-	router.transports = make(map[string]Transport)
+	err = resp.Err()
 
-	// Clear the Producers map.
-	router.producers = make(map[string]*Producer)
-
-	// Close every RtpObserver.
-	for _, rtpObserver := range router.rtpObservers {
-		rtpObserver.routerClosed()
-	}
-	router.rtpObservers = make(map[string]RtpObserver)
-
-	// Clear map of Router/PipeTransports.
-	router.mapRouterPipeTransports = make(map[*Router][]*PipeTransport)
+	router.closeEntities()
 
 	router.Emit("@close")
 
@@ -101,50 +129,183 @@ func (router *Router) Close() (err error) {
 
 // Worker was closed.
 func (router *Router) workerClosed() {
-	if router.closed {
+	if !router.closed.set() {
 		return
 	}
+	defer router.closed.cancelContext()
 
 	router.logger.Debug("workerClosed()")
 
-	router.closed = true
+	router.closeEntities()
+
+	router.SafeEmit("workerclose")
+
+	// Emit observer event.
+	router.observer.SafeEmit("close")
+
+	return
+}
+
+// closeEntities closes the Transports and RtpObservers of the closed Router.
+// They are closed outside of the lock, their listeners may use the Router.
+func (router *Router) closeEntities() {
+	router.entitiesLocker.Lock()
+	transports, rtpObservers := router.transports, router.rtpObservers
+	router.transports = make(map[string]Transport)
+	router.producers = make(map[string]*Producer)
+	router.rtpObservers = make(map[string]RtpObserver)
+	router.entitiesLocker.Unlock()
 
 	// Close every Transport.
-	for _, transport := range router.transports {
+	for _, transport := range transports {
 		transport.routerClosed()
 	}
-	router.transports = make(map[string]Transport)
-
-	// Clear the Producers map.
-	router.producers = make(map[string]*Producer)
 
 	// Close every RtpObserver.
-	for _, rtpObserver := range router.rtpObservers {
+	for _, rtpObserver := range rtpObservers {
 		rtpObserver.routerClosed()
 	}
-	router.rtpObservers = make(map[string]RtpObserver)
 
 	// Clear map of Router/PipeTransports.
+	router.pipeTransportsLocker.Lock()
 	router.mapRouterPipeTransports = make(map[*Router][]*PipeTransport)
+	router.pipeTransportsLocker.Unlock()
+}
 
-	router.SafeEmit("workerclose")
+// addTransport registers the given new Transport, which is closed instead if
+// the Router was closed meanwhile.
+func (router *Router) addTransport(transport Transport) error {
+	router.entitiesLocker.Lock()
 
-	// Emit observer event.
-	router.observer.SafeEmit("close")
+	if router.closed.isSet() {
+		router.entitiesLocker.Unlock()
 
-	return
+		transport.routerClosed()
+
+		return NewInvalidStateError("Router closed")
+	}
+
+	router.transports[transport.Id()] = transport
+	router.entitiesLocker.Unlock()
+
+	removeTransport := func() {
+		router.entitiesLocker.Lock()
+		delete(router.transports, transport.Id())
+		router.entitiesLocker.Unlock()
+	}
+	transport.On("@close", removeTransport)
+	transport.On("@listenserverclose", removeTransport)
+	transport.On("@newproducer", func(producer *Producer) {
+		router.entitiesLocker.Lock()
+		router.producers[producer.Id()] = producer
+		router.entitiesLocker.Unlock()
+	})
+	transport.On("@producerclose", func(producer *Producer) {
+		router.entitiesLocker.Lock()
+		delete(router.producers, producer.Id())
+		router.entitiesLocker.Unlock()
+	})
+
+	return nil
+}
+
+// addRtpObserver registers the given new RtpObserver, which is closed instead
+// if the Router was closed meanwhile.
+func (router *Router) addRtpObserver(rtpObserver RtpObserver) error {
+	router.entitiesLocker.Lock()
+
+	if router.closed.isSet() {
+		router.entitiesLocker.Unlock()
+
+		rtpObserver.routerClosed()
+
+		return NewInvalidStateError("Router closed")
+	}
+
+	router.rtpObservers[rtpObserver.Id()] = rtpObserver
+	router.entitiesLocker.Unlock()
+
+	rtpObserver.On("@close", func() {
+		router.entitiesLocker.Lock()
+		delete(router.rtpObservers, rtpObserver.Id())
+		router.entitiesLocker.Unlock()
+	})
+
+	return nil
+}
+
+// getRtpObservers returns the RtpObservers of the Router.
+func (router *Router) getRtpObservers() []RtpObserver {
+	router.entitiesLocker.Lock()
+	defer router.entitiesLocker.Unlock()
+
+	rtpObservers := make([]RtpObserver, 0, len(router.rtpObservers))
+
+	for _, rtpObserver := range router.rtpObservers {
+		rtpObservers = append(rtpObservers, rtpObserver)
+	}
+
+	return rtpObservers
+}
+
+// getTransport returns the Transport with the given id, if any.
+func (router *Router) getTransport(id string) Transport {
+	router.entitiesLocker.Lock()
+	defer router.entitiesLocker.Unlock()
+
+	return router.transports[id]
+}
+
+// getTransports returns the Transports of the Router.
+func (router *Router) getTransports() []Transport {
+	router.entitiesLocker.Lock()
+	defer router.entitiesLocker.Unlock()
+
+	transports := make([]Transport, 0, len(router.transports))
+
+	for _, transport := range router.transports {
+		transports = append(transports, transport)
+	}
+
+	return transports
+}
+
+// getProducer returns the Producer with the given id, if any.
+func (router *Router) getProducer(id string) *Producer {
+	router.entitiesLocker.Lock()
+	defer router.entitiesLocker.Unlock()
+
+	return router.producers[id]
+}
+
+// getProducers returns the Producers of the Router.
+func (router *Router) getProducers() []*Producer {
+	router.entitiesLocker.Lock()
+	defer router.entitiesLocker.Unlock()
+
+	producers := make([]*Producer, 0, len(router.producers))
+
+	for _, producer := range router.producers {
+		producers = append(producers, producer)
+	}
+
+	return producers
 }
 
 // Dump Router.
-func (router *Router) Dump() Response {
+func (router *Router) Dump() (dump RouterDump, err error) {
 	router.logger.Debug("dump()")
 
-	return router.channel.Request("router.dump", router.internal)
+	err = router.channel.requestIdempotent(context.Background(), "router.dump", router.internal).Unmarshal(&dump)
+
+	return
 }
 
 /**
  * Create a WebRtcTransport.
  *
+ * @param {WebRtcServer} [webRtcServer] - WebRtcServer sharing its ports, in
+ *   place of listenIps.
  * @param {Array<String|Object>} listenIps - Listen IPs in order of preference.
  *   Each entry can be a IP string or an object with ip and optional
  *   announcedIp strings.
@@ -162,8 +323,31 @@ func (router *Router) CreateWebRtcTransport(
 	if params.AppData == nil {
 		params.AppData = H{}
 	}
-	if !isObject(params.AppData) {
-		err = NewTypeError("if given, appData must be an object")
+	if len(params.ListenIps) == 0 && params.WebRtcServer == nil {
+		params.ListenIps = router.data.Settings.ListenIps
+	}
+	if err = params.validate(); err != nil {
+		return
+	}
+	if params.WebRtcServer != nil && params.WebRtcServer.Closed() {
+		return nil, NewInvalidStateError("WebRtcServer closed")
+	}
+	if params.ListenIps, err = resolveListenIps(
+		router.listenIpResolver, "CreateWebRtcTransportParams.ListenIps", params.ListenIps...); err != nil {
+		return
+	}
+
+	if err = router.checkWorkerDraining(); err != nil {
+		return
+	}
+
+	if err = authorize(router.authorizer, AuthorizationRequest{
+		Action:        AuthorizeCreateTransport,
+		RouterId:      router.Id(),
+		TransportType: "webrtc",
+		Options:       params,
+		AppData:       params.AppData,
+	}); err != nil {
 		return
 	}
 
@@ -172,7 +356,14 @@ func (router *Router) CreateWebRtcTransport(
 	reqData := params
 	reqData.AppData = nil
 
-	resp := router.channel.Request("router.createWebRtcTransport", internal, reqData)
+	method, reqInternal := "router.createWebRtcTransport", internal
+
+	if params.WebRtcServer != nil {
+		method = "router.createWebRtcTransportWithServer"
+		reqInternal.WebRtcServerId = params.WebRtcServer.Id()
+	}
+
+	resp := router.channel.Request(method, reqInternal, reqData)
 
 	var data WebRtcTransportData
 	if err = resp.Unmarshal(&data); err != nil {
@@ -180,27 +371,31 @@ func (router *Router) CreateWebRtcTransport(
 	}
 
 	transport = NewWebRtcTransport(data, createTransportParams{
-		Internal: internal,
-		Channel:  router.channel,
-		AppData:  params.AppData,
+		Internal:  internal,
+		Channel:   router.channel,
+		Options:   reqData,
+		AppData:   params.AppData,
+		AppLogger: router.appLogger,
 		GetRouterRtpCapabilities: func() RtpCapabilities {
 			return router.data.RtpCapabilities
 		},
-		GetProducerById: func(producerId string) *Producer {
-			return router.producers[producerId]
-		},
+		GetProducerById:        router.getProducer,
+		WorkerVersion:          router.workerVersion,
+		ConsumerLimiter:        router.consumerLimiter,
+		Type:                   "webrtc",
+		Authorizer:             router.authorizer,
+		BlockE2eeFeature:       router.BlockE2eeFeature,
+		NotificationRateLimits: router.data.Settings.NotificationRateLimits,
 	})
 
-	router.transports[transport.Id()] = transport
-	transport.On("@close", func() {
-		delete(router.transports, transport.Id())
-	})
-	transport.On("@newproducer", func(producer *Producer) {
-		router.producers[producer.Id()] = producer
-	})
-	transport.On("@producerclose", func(producer *Producer) {
-		delete(router.producers, producer.Id())
-	})
+	if err = router.addTransport(transport); err != nil {
+		return nil, err
+	}
+	if params.WebRtcServer != nil {
+		if err = params.WebRtcServer.handleWebRtcTransport(transport); err != nil {
+			return nil, err
+		}
+	}
 
 	// Emit observer event.
 	router.observer.SafeEmit("newtransport", transport)
@@ -230,8 +425,28 @@ func (router *Router) CreatePlainRtpTransport(
 	if params.AppData == nil {
 		params.AppData = H{}
 	}
-	if !isObject(params.AppData) {
-		err = NewTypeError("if given, appData must be an object")
+	if len(params.ListenIp.Ip) == 0 {
+		params.ListenIp = router.data.Settings.listenIp()
+	}
+	if err = params.validate(); err != nil {
+		return
+	}
+	if params.ListenIp, err = resolveListenIp(
+		router.listenIpResolver, "CreatePlainRtpTransportParams.ListenIp", params.ListenIp); err != nil {
+		return
+	}
+
+	if err = router.checkWorkerDraining(); err != nil {
+		return
+	}
+
+	if err = authorize(router.authorizer, AuthorizationRequest{
+		Action:        AuthorizeCreateTransport,
+		RouterId:      router.Id(),
+		TransportType: "plain",
+		Options:       params,
+		AppData:       params.AppData,
+	}); err != nil {
 		return
 	}
 
@@ -248,27 +463,28 @@ func (router *Router) CreatePlainRtpTransport(
 	}
 
 	transport = NewPlainRtpTransport(data, createTransportParams{
-		Internal: internal,
-		Channel:  router.channel,
-		AppData:  params.AppData,
+		Internal:  internal,
+		Channel:   router.channel,
+		Options:   reqData,
+		AppData:   params.AppData,
+		AppLogger: router.appLogger,
 		GetRouterRtpCapabilities: func() RtpCapabilities {
 			return router.data.RtpCapabilities
 		},
-		GetProducerById: func(producerId string) *Producer {
-			return router.producers[producerId]
-		},
+		GetProducerById:        router.getProducer,
+		GetProducerBySsrc:      router.getProducerBySsrc,
+		SsrcReservations:       router.ssrcReservations,
+		WorkerVersion:          router.workerVersion,
+		ConsumerLimiter:        router.consumerLimiter,
+		Type:                   "plain",
+		Authorizer:             router.authorizer,
+		BlockE2eeFeature:       router.BlockE2eeFeature,
+		NotificationRateLimits: router.data.Settings.NotificationRateLimits,
 	})
 
-	router.transports[transport.Id()] = transport
-	transport.On("@close", func() {
-		delete(router.transports, transport.Id())
-	})
-	transport.On("@newproducer", func(producer *Producer) {
-		router.producers[producer.Id()] = producer
-	})
-	transport.On("@producerclose", func(producer *Producer) {
-		delete(router.producers, producer.Id())
-	})
+	if err = router.addTransport(transport); err != nil {
+		return nil, err
+	}
 
 	// Emit observer event.
 	router.observer.SafeEmit("newtransport", transport)
@@ -288,6 +504,31 @@ func (router *Router) CreatePipeTransport(
 ) (transport *PipeTransport, err error) {
 	router.logger.Debug("createPipeTransport()")
 
+	if len(params.ListenIp.Ip) == 0 {
+		params.ListenIp = router.data.Settings.listenIp()
+	}
+	if err = params.validate(); err != nil {
+		return
+	}
+	if params.ListenIp, err = resolveListenIp(
+		router.listenIpResolver, "CreatePipeTransportParams.ListenIp", params.ListenIp); err != nil {
+		return
+	}
+
+	if err = router.checkWorkerDraining(); err != nil {
+		return
+	}
+
+	if err = authorize(router.authorizer, AuthorizationRequest{
+		Action:        AuthorizeCreateTransport,
+		RouterId:      router.Id(),
+		TransportType: "pipe",
+		Options:       params,
+		AppData:       params.AppData,
+	}); err != nil {
+		return
+	}
+
 	internal := router.internal
 	internal.TransportId = uuid.NewV4().String()
 	reqData := params
@@ -301,27 +542,26 @@ func (router *Router) CreatePipeTransport(
 	}
 
 	transport = NewPipeTransport(data, createTransportParams{
-		Internal: internal,
-		Channel:  router.channel,
-		AppData:  params.AppData,
+		Internal:  internal,
+		Channel:   router.channel,
+		Options:   reqData,
+		AppData:   params.AppData,
+		AppLogger: router.appLogger,
 		GetRouterRtpCapabilities: func() RtpCapabilities {
 			return router.data.RtpCapabilities
 		},
-		GetProducerById: func(producerId string) *Producer {
-			return router.producers[producerId]
-		},
+		GetProducerById:        router.getProducer,
+		WorkerVersion:          router.workerVersion,
+		ConsumerLimiter:        router.consumerLimiter,
+		Type:                   "pipe",
+		Authorizer:             router.authorizer,
+		BlockE2eeFeature:       router.BlockE2eeFeature,
+		NotificationRateLimits: router.data.Settings.NotificationRateLimits,
 	})
 
-	router.transports[transport.Id()] = transport
-	transport.On("@close", func() {
-		delete(router.transports, transport.Id())
-	})
-	transport.On("@newproducer", func(producer *Producer) {
-		router.producers[producer.Id()] = producer
-	})
-	transport.On("@producerclose", func(producer *Producer) {
-		delete(router.producers, producer.Id())
-	})
+	if err = router.addTransport(transport); err != nil {
+		return nil, err
+	}
 
 	// Emit observer event.
 	router.observer.SafeEmit("newtransport", transport)
@@ -339,6 +579,9 @@ func (router *Router) CreatePipeTransport(
  *
  * @returns {Object} - Contains `pipeConsumer` {Consumer} created in the current
  *   Router and `pipeProducer` {Producer} created in the destination Router.
+ *
+ * Piping the same Producer into the same Router more than once, even from
+ * concurrent goroutines, returns the existing pipe Consumer and pipe Producer.
  */
 func (router *Router) PipeToRouter(
 	params PipeToRouterParams,
@@ -360,72 +603,61 @@ func (router *Router) PipeToRouter(
 		return
 	}
 
-	producer, ok := router.producers[params.ProducerId]
+	producer := router.getProducer(params.ProducerId)
 
-	if !ok {
+	if producer == nil {
 		err = NewTypeError("Producer not found")
 		return
 	}
 
-	var localPipeTransport, remotePipeTransport *PipeTransport
+	key := pipeToRouterKey{producerId: params.ProducerId, router: params.Router}
 
-	defer func() {
-		if err != nil {
-			if localPipeTransport != nil {
-				localPipeTransport.Close()
-			}
-			if remotePipeTransport != nil {
-				remotePipeTransport.Close()
-			}
-		}
-	}()
+	router.pipeToRouterLocker.Lock()
 
-	pipeTransportPair := router.mapRouterPipeTransports[params.Router]
+	// Piping the same Producer to the same Router again (or concurrently)
+	// returns the pipe Consumer and pipe Producer of the first call.
+	if call, ok := router.pipeToRouterCalls[key]; ok {
+		router.pipeToRouterLocker.Unlock()
 
-	if pipeTransportPair != nil {
-		localPipeTransport = pipeTransportPair[0]
-		remotePipeTransport = pipeTransportPair[1]
-	} else {
-		createPipeTransportParams := CreatePipeTransportParams{
-			ListenIp: params.ListenIp,
-		}
+		<-call.done
 
-		localPipeTransport, err = router.CreatePipeTransport(createPipeTransportParams)
-		if err != nil {
-			return
-		}
-		remotePipeTransport, err = params.Router.CreatePipeTransport(createPipeTransportParams)
-		if err != nil {
-			return
-		}
+		return call.pipeConsumer, call.pipeProducer, call.err
+	}
 
-		err = localPipeTransport.Connect(transportConnectParams{
-			Ip:   remotePipeTransport.Tuple().LocalIp,
-			Port: remotePipeTransport.Tuple().LocalPort,
-		})
-		if err != nil {
-			return
-		}
-		err = remotePipeTransport.Connect(transportConnectParams{
-			Ip:   localPipeTransport.Tuple().LocalIp,
-			Port: localPipeTransport.Tuple().LocalPort,
-		})
-		if err != nil {
-			return
-		}
+	call := &pipeToRouterCall{done: make(chan struct{})}
+	router.pipeToRouterCalls[key] = call
 
-		localPipeTransport.Observer().On("close", func() {
-			remotePipeTransport.Close()
-			delete(router.mapRouterPipeTransports, params.Router)
-		})
+	router.pipeToRouterLocker.Unlock()
+
+	call.pipeConsumer, call.pipeProducer, call.err = router.pipeProducerToRouter(producer, params)
+
+	if call.err != nil {
+		router.pipeToRouterLocker.Lock()
+		delete(router.pipeToRouterCalls, key)
+		router.pipeToRouterLocker.Unlock()
+	} else {
+		call.pipeConsumer.Observer().On("close", func() {
+			router.pipeToRouterLocker.Lock()
+			defer router.pipeToRouterLocker.Unlock()
 
-		remotePipeTransport.Observer().On("close", func() {
-			localPipeTransport.Close()
-			delete(router.mapRouterPipeTransports, params.Router)
+			if router.pipeToRouterCalls[key] == call {
+				delete(router.pipeToRouterCalls, key)
+			}
 		})
+	}
+
+	close(call.done)
+
+	return call.pipeConsumer, call.pipeProducer, call.err
+}
 
-		router.mapRouterPipeTransports[params.Router] =
-			[]*PipeTransport{localPipeTransport, remotePipeTransport}
+func (router *Router) pipeProducerToRouter(
+	producer *Producer,
+	params PipeToRouterParams,
+) (pipeConsumer *Consumer, pipeProducer *Producer, err error) {
+	localPipeTransport, remotePipeTransport, err := router.getPipeTransportPair(params)
+	if err != nil {
+		return
 	}
 
 	defer func() {
@@ -439,7 +671,7 @@ func (router *Router) PipeToRouter(
 		}
 	}()
 
-	pipeConsumer, err = localPipeTransport.Consume(transportConsumeParams{
+	pipeConsumer, err = localPipeTransport.Consume(TransportConsumeParams{
 		ProducerId: params.ProducerId,
 		Paused:     producer.Paused(),
 	})
@@ -447,7 +679,7 @@ func (router *Router) PipeToRouter(
 		return
 	}
 
-	pipeProducer, err = remotePipeTransport.Produce(transportProduceParams{
+	pipeProducer, err = remotePipeTransport.Produce(TransportProduceParams{
 		Id:            producer.Id(),
 		Kind:          pipeConsumer.Kind(),
 		RtpParameters: pipeConsumer.RtpParameters(),
@@ -469,6 +701,81 @@ func (router *Router) PipeToRouter(
 	return
 }
 
+// getPipeTransportPair returns the PipeTransport pair connecting this Router
+// with the destination Router, creating it if needed. Concurrent calls for the
+// same destination share a single pair.
+func (router *Router) getPipeTransportPair(
+	params PipeToRouterParams,
+) (localPipeTransport, remotePipeTransport *PipeTransport, err error) {
+	router.pipeTransportsLocker.Lock()
+	defer router.pipeTransportsLocker.Unlock()
+
+	if pipeTransportPair := router.mapRouterPipeTransports[params.Router]; pipeTransportPair != nil {
+		return pipeTransportPair[0], pipeTransportPair[1], nil
+	}
+
+	defer func() {
+		if err != nil {
+			if localPipeTransport != nil {
+				localPipeTransport.Close()
+			}
+			if remotePipeTransport != nil {
+				remotePipeTransport.Close()
+			}
+		}
+	}()
+
+	createPipeTransportParams := CreatePipeTransportParams{
+		ListenIp: params.ListenIp,
+	}
+
+	localPipeTransport, err = router.CreatePipeTransport(createPipeTransportParams)
+	if err != nil {
+		return
+	}
+	remotePipeTransport, err = params.Router.CreatePipeTransport(createPipeTransportParams)
+	if err != nil {
+		return
+	}
+
+	err = localPipeTransport.Connect(TransportConnectParams{
+		Ip:   remotePipeTransport.Tuple().LocalIp,
+		Port: remotePipeTransport.Tuple().LocalPort,
+	})
+	if err != nil {
+		return
+	}
+	err = remotePipeTransport.Connect(TransportConnectParams{
+		Ip:   localPipeTransport.Tuple().LocalIp,
+		Port: localPipeTransport.Tuple().LocalPort,
+	})
+	if err != nil {
+		return
+	}
+
+	localPipeTransport.Observer().On("close", func() {
+		remotePipeTransport.Close()
+		router.deletePipeTransportPair(params.Router)
+	})
+
+	remotePipeTransport.Observer().On("close", func() {
+		localPipeTransport.Close()
+		router.deletePipeTransportPair(params.Router)
+	})
+
+	router.mapRouterPipeTransports[params.Router] =
+		[]*PipeTransport{localPipeTransport, remotePipeTransport}
+
+	return
+}
+
+func (router *Router) deletePipeTransportPair(destRouter *Router) {
+	router.pipeTransportsLocker.Lock()
+	defer router.pipeTransportsLocker.Unlock()
+
+	delete(router.mapRouterPipeTransports, destRouter)
+}
+
 /**
  * Create an AudioLevelObserver.
  *
@@ -491,6 +798,9 @@ func (router *Router) CreateAudioLevelObserver(
 			Interval:   1000,
 		}
 	}
+	if err = params.validate(); err != nil {
+		return
+	}
 
 	internal := router.internal
 	internal.RtpObserverId = uuid.NewV4().String()
@@ -501,18 +811,22 @@ func (router *Router) CreateAudioLevelObserver(
 		return
 	}
 
-	rtpObserver = NewAudioLevelObserver(
+	audioLevelObserver := newAudioLevelObserver(
 		internal,
 		router.channel,
-		func(producerId string) *Producer {
-			return router.producers[producerId]
-		},
+		router.getProducer,
+		router.data.Settings.NotificationRateLimits,
+		router.appLogger,
 	)
+	audioLevelObserver.params = *params
+	rtpObserver = audioLevelObserver
 
-	router.rtpObservers[rtpObserver.Id()] = rtpObserver
-	rtpObserver.On("@close", func() {
-		delete(router.rtpObservers, rtpObserver.Id())
-	})
+	if err = router.addRtpObserver(rtpObserver); err != nil {
+		return nil, err
+	}
+
+	// Emit observer event.
+	router.observer.SafeEmit("newrtpobserver", rtpObserver)
 
 	return
 }
@@ -525,7 +839,7 @@ func (router *Router) CreateAudioLevelObserver(
  *
  */
 func (router *Router) CanConsume(producerId string, rtpCapabilities RtpCapabilities) bool {
-	producer := router.producers[producerId]
+	producer := router.getProducer(producerId)
 
 	if producer == nil {
 		router.logger.Errorf(`canConsume() | Producer with id "%s" not found`, producerId)
@@ -535,3 +849,95 @@ func (router *Router) CanConsume(producerId string, rtpCapabilities RtpCapabilit
 
 	return CanConsume(producer.ConsumableRtpParameters(), rtpCapabilities)
 }
+
+/**
+ * Recreate the given WebRtcTransport or PlainRtpTransport for a client
+ * reconnecting without rejoining: a new Transport is created with the same
+ * options and appData, the Producers consumed by the old one are consumed
+ * again (paused) by the new one, then the old Transport is closed. The new
+ * Consumers keep the MID, header extensions, Producer close hook, preferred
+ * layers and bitrate cap of the old ones. Producers of the old Transport are
+ * not recreated, the client must produce again.
+ *
+ * @param {Transport} oldTransport
+ *
+ * @returns {Transport, map[string]string} - The new Transport and the ids of
+ *   the new Consumers by old Consumer id, to give to the client.
+ *
+ * @emits {oldTransport: Transport, transport: Transport, consumerIds: map[string]string} transportrecreate
+ *   on the observer.
+ */
+func (router *Router) RecreateTransportFor(
+	oldTransport Transport,
+) (transport Transport, consumerIds map[string]string, err error) {
+	router.logger.Debug("recreateTransportFor()")
+
+	if router.getTransport(oldTransport.Id()) != oldTransport {
+		err = NewTypeError(`Transport with id "%s" not found`, oldTransport.Id())
+		return
+	}
+
+	var oldBase *baseTransport
+
+	switch t := oldTransport.(type) {
+	case *WebRtcTransport:
+		params, _ := t.options.(CreateWebRtcTransportParams)
+		params.AppData = t.AppData()
+		oldBase = t.baseTransport
+		transport, err = router.CreateWebRtcTransport(params)
+
+	case *PlainRtpTransport:
+		params, _ := t.options.(CreatePlainRtpTransportParams)
+		params.AppData = t.AppData()
+		oldBase = t.baseTransport
+		transport, err = router.CreatePlainRtpTransport(params)
+
+	default:
+		err = NewTypeError("only WebRtcTransports and PlainRtpTransports can be recreated")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	consumerIds = make(map[string]string)
+
+	for _, oldConsumer := range oldBase.getConsumers() {
+		consumer, err := recreateConsumer(transport, oldConsumer)
+		if err != nil {
+			transport.Close()
+
+			return nil, nil, fmt.Errorf(`cannot consume again Producer "%s": %s`, oldConsumer.ProducerId(), err)
+		}
+
+		consumerIds[oldConsumer.Id()] = consumer.Id()
+	}
+
+	oldTransport.Close()
+
+	// Emit observer event.
+	router.observer.SafeEmit("transportrecreate", oldTransport, transport, consumerIds)
+
+	return
+}
+
+// recreateConsumer consumes again (paused) the Producer of the given Consumer
+// with the given Transport, restoring its settings.
+func recreateConsumer(transport Transport, oldConsumer *Consumer) (*Consumer, error) {
+	snapshot := newConsumerSnapshot(oldConsumer)
+	snapshot.Paused = true
+
+	return consumeSnapshot(transport, snapshot, oldConsumer.onProducerClose)
+}
+
+// getProducerBySsrc returns the Producer announcing the given SSRC, if any.
+func (router *Router) getProducerBySsrc(ssrc uint32) *Producer {
+	for _, producer := range router.getProducers() {
+		for _, encoding := range producer.RtpParameters().Encodings {
+			if encoding.Ssrc == ssrc || (encoding.Rtx != nil && encoding.Rtx.Ssrc == ssrc) {
+				return producer
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package mediasoup
+
+import (
+	"sync"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup/h264profile"
+	"github.com/sirupsen/logrus"
+)
+
+// Built-in Router profiles.
+const (
+	RouterProfileAudioRoom = "audioroom"
+	RouterProfileWebinar   = "webinar"
+	RouterProfileCall      = "call"
+	// Call with the codecs offered by default by recent browsers, AV1 and
+	// VP9 profile 2 (HDR) included.
+	RouterProfileModernCall = "moderncall"
+)
+
+// RouterSettings override, for a single Router, behaviors of this library
+// otherwise shared by every Router. The settings of the worker process (e.g.
+// the log tags) apply to all its Routers and can't be overridden.
+type RouterSettings struct {
+	// Log level in this library of the Router and of its Transports,
+	// Producers, Consumers and RtpObservers ("debug", "info", "warn",
+	// "error"...), instead of the level of Logger().
+	LogLevel string
+	// Listen IPs of the Transports created without any. Plain and pipe
+	// Transports use the first one.
+	ListenIps []ListenIp
+	// Whether the media is end-to-end encrypted (e.g. SFrame with insertable
+	// streams): the payloads are forwarded untouched and the features
+	// depending on their contents are disabled. The endpoints must leave the
+	// codec payload headers (e.g. the VP8 payload descriptor) unencrypted, the
+	// worker detecting the key frames from them.
+	E2ee bool
+	// Rate limits of the "score" and "volumes" events.
+	NotificationRateLimits NotificationRateLimits
+}
+
+type RouterOption func(s *RouterSettings)
+
+func WithRouterLogLevel(logLevel string) RouterOption {
+	return func(s *RouterSettings) {
+		s.LogLevel = logLevel
+	}
+}
+
+func WithRouterE2ee() RouterOption {
+	return func(s *RouterSettings) {
+		s.E2ee = true
+	}
+}
+
+func WithRouterNotificationRateLimits(limits NotificationRateLimits) RouterOption {
+	return func(s *RouterSettings) {
+		s.NotificationRateLimits = limits
+	}
+}
+
+func WithRouterListenIps(listenIps ...ListenIp) RouterOption {
+	return func(s *RouterSettings) {
+		s.ListenIps = listenIps
+	}
+}
+
+// RouterProfile groups the options to create Routers for a use case, so they
+// can be selected by name.
+type RouterProfile struct {
+	MediaCodecs []RtpCodecCapability
+	Settings    RouterSettings
+}
+
+type registeredRouterProfile struct {
+	template *RouterTemplate
+	settings RouterSettings
+}
+
+var routerProfiles = struct {
+	sync.RWMutex
+	profiles map[string]registeredRouterProfile
+}{
+	profiles: make(map[string]registeredRouterProfile),
+}
+
+func init() {
+	opus := RtpCodecCapability{
+		Kind:      "audio",
+		MimeType:  "audio/opus",
+		ClockRate: 48000,
+		Channels:  2,
+		Parameters: &RtpCodecParameter{
+			Useinbandfec: 1,
+		},
+	}
+	vp8 := RtpCodecCapability{
+		Kind:      "video",
+		MimeType:  "video/VP8",
+		ClockRate: 90000,
+	}
+	vp9 := RtpCodecCapability{
+		Kind:      "video",
+		MimeType:  "video/VP9",
+		ClockRate: 90000,
+	}
+	vp9Profile2 := RtpCodecCapability{
+		Kind:      "video",
+		MimeType:  "video/VP9",
+		ClockRate: 90000,
+		Parameters: &RtpCodecParameter{
+			ProfileId: 2,
+		},
+	}
+	av1 := RtpCodecCapability{
+		Kind:      "video",
+		MimeType:  "video/AV1",
+		ClockRate: 90000,
+	}
+	h264 := RtpCodecCapability{
+		Kind:      "video",
+		MimeType:  "video/H264",
+		ClockRate: 90000,
+		Parameters: &RtpCodecParameter{
+			RtpH264Parameter: h264profile.RtpH264Parameter{
+				LevelAsymmetryAllowed: 1,
+				PacketizationMode:     1,
+				ProfileLevelId:        "42e01f",
+			},
+		},
+	}
+
+	for name, profile := range map[string]RouterProfile{
+		RouterProfileAudioRoom: {
+			MediaCodecs: []RtpCodecCapability{opus},
+		},
+		RouterProfileWebinar: {
+			MediaCodecs: []RtpCodecCapability{opus, vp8, h264},
+		},
+		RouterProfileCall: {
+			MediaCodecs: []RtpCodecCapability{opus, vp8, vp9, h264},
+		},
+		RouterProfileModernCall: {
+			MediaCodecs: []RtpCodecCapability{opus, vp8, vp9, vp9Profile2, av1, h264},
+		},
+	} {
+		if err := RegisterRouterProfile(name, profile); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterRouterProfile registers (or replaces) the named Router profile, to
+// be used with Worker.CreateRouterWithProfile().
+func RegisterRouterProfile(name string, profile RouterProfile) (err error) {
+	if len(name) == 0 {
+		return NewTypeError("missing profile name")
+	}
+	if err = profile.Settings.validate(); err != nil {
+		return
+	}
+
+	template, err := NewRouterTemplate(profile.MediaCodecs)
+	if err != nil {
+		return
+	}
+
+	routerProfiles.Lock()
+	defer routerProfiles.Unlock()
+
+	routerProfiles.profiles[name] = registeredRouterProfile{
+		template: template,
+		settings: profile.Settings,
+	}
+
+	return
+}
+
+// GetRouterProfile returns the named Router profile.
+func GetRouterProfile(name string) (profile RouterProfile, ok bool) {
+	routerProfiles.RLock()
+	defer routerProfiles.RUnlock()
+
+	registered, ok := routerProfiles.profiles[name]
+	if ok {
+		profile = RouterProfile{
+			MediaCodecs: registered.template.MediaCodecs(),
+			Settings:    registered.settings,
+		}
+	}
+
+	return
+}
+
+func newRouterSettings(settings RouterSettings, options ...RouterOption) (RouterSettings, error) {
+	for _, option := range options {
+		option(&settings)
+	}
+
+	return settings, settings.validate()
+}
+
+// appLogger returns the logger of the Router entities, with the overridden
+// level if any.
+func (s RouterSettings) appLogger() logrus.FieldLogger {
+	level, err := logrus.ParseLevel(s.LogLevel)
+	if len(s.LogLevel) == 0 || err != nil {
+		return AppLogger()
+	}
+
+	scoped := logrus.New()
+	scoped.Out = logger.Out
+	scoped.Formatter = logger.Formatter
+	scoped.Hooks = logger.Hooks
+	scoped.ReportCaller = logger.ReportCaller
+	scoped.Level = level
+
+	return scoped.WithField("app", "mediasoup")
+}
+
+func (s RouterSettings) listenIp() ListenIp {
+	if len(s.ListenIps) > 0 {
+		return s.ListenIps[0]
+	}
+
+	return ListenIp{}
+}
@@ -0,0 +1,38 @@
+package mediasoup
+
+// RouterTemplate holds the RTP capabilities computed from a set of media
+// codecs, so that many Routers (in any Worker) sharing the same codecs don't
+// validate and generate them again. Just the capabilities are shared: the
+// codecs of each Producer and Consumer are still matched against them when
+// created, depending on its own RTP parameters.
+type RouterTemplate struct {
+	data routerData
+}
+
+// NewRouterTemplate validates the given media codecs and generates the Router
+// RTP capabilities once.
+func NewRouterTemplate(mediaCodecs []RtpCodecCapability) (template *RouterTemplate, err error) {
+	rtpCapabilities, err := GenerateRouterRtpCapabilities(mediaCodecs)
+	if err != nil {
+		return
+	}
+
+	template = &RouterTemplate{
+		data: routerData{
+			MediaCodecs:     mediaCodecs,
+			RtpCapabilities: rtpCapabilities,
+		},
+	}
+
+	return
+}
+
+// MediaCodecs the template was created with.
+func (t *RouterTemplate) MediaCodecs() []RtpCodecCapability {
+	return t.data.MediaCodecs
+}
+
+// RtpCapabilities of the Routers created from the template.
+func (t *RouterTemplate) RtpCapabilities() RtpCapabilities {
+	return t.data.RtpCapabilities
+}
@@ -1,10 +1,13 @@
 package mediasoup
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/jiyeyuran/mediasoup-go/mediasoup/h264profile"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testRouterMediaCodecs = []RtpCodecCapability{
@@ -51,33 +54,14 @@ func TestCreateRouter_Succeeds(t *testing.T) {
 	assert.Equal(t, 1, called)
 	assert.False(t, router.Closed())
 
-	dump := worker.Dump()
-
-	type Result struct {
-		Pid       int
-		RouterIds []string
-	}
-	var result Result
-	assert.NoError(t, dump.Unmarshal(&result))
+	dump, err := worker.Dump()
+	assert.NoError(t, err)
+	assert.Equal(t, worker.Pid(), dump.Pid)
+	assert.Equal(t, []string{router.Id()}, dump.RouterIds)
 
-	result1 := Result{
-		Pid:       worker.Pid(),
-		RouterIds: []string{router.Id()},
-	}
-	assert.Equal(t, result, result1)
-
-	type RouterDumpResult struct {
-		Id                       string
-		TransportIds             []string
-		RtpObserverIds           []string
-		MapProducerIdConsumerIds map[string][]string
-		MapConsumerIdProducerId  map[string]string
-		MapProducerIdObserverIds map[string][]string
-	}
-	var routerDumpResult1 RouterDumpResult
-	dump = router.Dump()
-	assert.NoError(t, dump.Unmarshal(&routerDumpResult1))
-	routerDumpResult2 := RouterDumpResult{
+	routerDumpResult1, err := router.Dump()
+	assert.NoError(t, err)
+	routerDumpResult2 := RouterDump{
 		Id:                       router.Id(),
 		TransportIds:             []string{},
 		RtpObserverIds:           []string{},
@@ -109,6 +93,138 @@ func TestCreateRouter_InvalidStateError(t *testing.T) {
 	assert.Error(t, err, NewInvalidStateError(""))
 }
 
+func TestCreateRouterFromTemplate_Succeeds(t *testing.T) {
+	template, err := NewRouterTemplate(testRouterMediaCodecs)
+	assert.NoError(t, err)
+
+	worker1 := CreateTestWorker()
+	defer worker1.Close()
+	worker2 := CreateTestWorker()
+	defer worker2.Close()
+
+	router1, err := worker1.CreateRouterFromTemplate(template)
+	assert.NoError(t, err)
+	router2, err := worker2.CreateRouterFromTemplate(template)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, router1.Id(), router2.Id())
+	assert.Equal(t, template.RtpCapabilities(), router1.RtpCapabilities())
+	assert.Equal(t, template.RtpCapabilities(), router2.RtpCapabilities())
+}
+
+func TestNewRouterTemplate_TypeError(t *testing.T) {
+	_, err := NewRouterTemplate(nil)
+
+	assert.IsType(t, err, NewTypeError(""))
+}
+
+func TestCreateRouterWithProfile_Succeeds(t *testing.T) {
+	worker := CreateTestWorker()
+	defer worker.Close()
+
+	router, err := worker.CreateRouterWithProfile(RouterProfileAudioRoom,
+		WithRouterListenIps(ListenIp{Ip: "127.0.0.1"}))
+	assert.NoError(t, err)
+
+	profile, _ := GetRouterProfile(RouterProfileAudioRoom)
+	assert.Equal(t, profile.MediaCodecs, router.data.MediaCodecs)
+
+	for _, codec := range router.RtpCapabilities().Codecs {
+		assert.Equal(t, "audio", codec.Kind)
+	}
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{EnableUdp: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", transport.IceCandidates()[0].Ip)
+
+	_, err = worker.CreateRouterWithProfile("unknown")
+	assert.IsType(t, NewTypeError(""), err)
+
+	_, err = worker.CreateRouterWithProfile(RouterProfileCall, WithRouterLogLevel("verbose"))
+	assert.IsType(t, ValidationError{}, err)
+}
+
+func TestRouterLogLevel_AppliesToChildren(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+
+	router, err := worker.CreateRouter(testPipeMediaCodecs,
+		WithRouterLogLevel("error"), WithRouterListenIps(ListenIp{Ip: "127.0.0.1"}))
+	require.NoError(t, err)
+
+	transport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{})
+	require.NoError(t, err)
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	})
+	require.NoError(t, err)
+
+	consumer, err := transport.Consume(TransportConsumeParams{
+		ProducerId:      producer.Id(),
+		RtpCapabilities: router.RtpCapabilities(),
+	})
+	require.NoError(t, err)
+
+	rtpObserver, err := router.CreateAudioLevelObserver(nil)
+	require.NoError(t, err)
+
+	for _, logger := range []logrus.FieldLogger{
+		router.logger,
+		transport.logger,
+		producer.logger,
+		consumer.logger,
+		rtpObserver.(*AudioLevelObserver).logger,
+	} {
+		assert.Equal(t, logrus.ErrorLevel, logger.(*logrus.Entry).Logger.Level)
+	}
+}
+
+func TestCreateRouterWithProfile_ProducesAV1AndVP9Profile2(t *testing.T) {
+	skipUnlessWorkerSupports(t, WorkerFeatureAV1)
+
+	router, err := worker.CreateRouterWithProfile(RouterProfileModernCall,
+		WithRouterListenIps(ListenIp{Ip: "127.0.0.1"}))
+	require.NoError(t, err)
+	defer router.Close()
+
+	transport1, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{})
+	require.NoError(t, err)
+	transport2, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{})
+	require.NoError(t, err)
+
+	for i, codec := range []RtpCodecCapability{
+		{MimeType: "video/AV1", ClockRate: 90000, PayloadType: 45},
+		{MimeType: "video/VP9", ClockRate: 90000, PayloadType: 98, Parameters: &RtpCodecParameter{ProfileId: 2}},
+	} {
+		producer, err := transport1.Produce(TransportProduceParams{
+			Kind: "video",
+			RtpParameters: RtpParameters{
+				Mid:       fmt.Sprintf("VIDEO%d", i),
+				Codecs:    []RtpCodecCapability{codec},
+				Encodings: []RtpEncoding{{Ssrc: uint32(33333333 + i), ScalabilityMode: "L1T3"}},
+				Rtcp:      RtcpConfiguation{Cname: "hdr"},
+			},
+		})
+		require.NoError(t, err)
+
+		consumer, err := transport2.Consume(TransportConsumeParams{
+			ProducerId:      producer.Id(),
+			RtpCapabilities: router.RtpCapabilities(),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, codec.MimeType, consumer.RtpParameters().Codecs[0].MimeType)
+		assert.Equal(t, codec.Parameters, consumer.RtpParameters().Codecs[0].Parameters)
+		assert.Equal(t, "L1T3", consumer.RtpParameters().Encodings[0].ScalabilityMode)
+	}
+}
+
 func TestRouterClose_Succeeds(t *testing.T) {
 	worker := CreateTestWorker()
 	router, _ := worker.CreateRouter(testRouterMediaCodecs)
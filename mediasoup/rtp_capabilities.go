@@ -23,6 +23,8 @@ type RtpMappingParameters struct {
 	Codecs           []RtpMappingCodec     `json:"codecs,omitempty"`
 	HeaderExtensions []RtpMappingHeaderExt `json:"headerExtensions,omitempty"`
 	Encodings        []RtpMappingEncoding  `json:"encodings,omitempty"`
+	// Header extensions of the Producer dropped because their ids conflicted.
+	HeaderExtensionConflicts []RtpHeaderExtensionConflict `json:"-"`
 }
 
 type RtpMappingCodec struct {
@@ -68,6 +70,12 @@ type RtpCodecParameter struct {
 	XGoogleMinBitrate   uint32 `json:"x-google-min-bitrate,omitempty"`
 	XGoogleMaxBitrate   uint32 `json:"x-google-max-bitrate,omitempty"`
 	XGoogleStartBitrate uint32 `json:"x-google-start-bitrate,omitempty"`
+
+	ProfileId uint8 `json:"profile-id,omitempty"` // used by vp9 codec, 0 (default) or 2 (HDR)
+
+	Profile  uint8 `json:"profile,omitempty"`   // used by av1 codec
+	LevelIdx uint8 `json:"level-idx,omitempty"` // used by av1 codec
+	Tier     uint8 `json:"tier,omitempty"`      // used by av1 codec
 }
 
 type RtpHeaderExtension struct {
@@ -87,6 +95,18 @@ type RtpEncoding struct {
 	MaxBitrate       uint32       `json:"maxBitrate,omitempty"`
 	CodecPayloadType uint32       `json:"codecPayloadType,omitempty"`
 	Dtx              bool         `json:"dtx,omitempty"`
+	// Spatial and temporal layers ("L1T3", "L3T3_KEY", "S3T3"...), used by
+	// VP9 and AV1 SVC.
+	ScalabilityMode string `json:"scalabilityMode,omitempty"`
+	// Forward error correction of the encoding, if any.
+	Fec *RtpEncodingFec `json:"fec,omitempty"`
+}
+
+type RtpEncodingFec struct {
+	// "red", "red+ulpfec" or "flexfec".
+	Mechanism string `json:"mechanism"`
+	// SSRC of the FlexFEC stream, RED and ULPFEC using the media SSRC.
+	Ssrc uint32 `json:"ssrc,omitempty"`
 }
 
 type RtcpConfiguation struct {
@@ -217,6 +237,17 @@ var supportedRtpCapabilities = RtpCapabilities{
 				{Type: "goog-remb"},
 			},
 		},
+		{
+			Kind:      "video",
+			MimeType:  "video/AV1",
+			ClockRate: 90000,
+			RtcpFeedback: []RtcpFeedback{
+				{Type: "nack"},
+				{Type: "nack", Parameter: "pli"},
+				{Type: "ccm", Parameter: "fir"},
+				{Type: "goog-remb"},
+			},
+		},
 		{
 			Kind:      "video",
 			MimeType:  "video/H264",
@@ -0,0 +1,130 @@
+package mediasoup
+
+import "strings"
+
+// RtpCapabilitiesReport describes how the RTP capabilities of a client fall
+// short of the ones of a Router, for support tooling.
+type RtpCapabilitiesReport struct {
+	// Router codecs (but RTX) not supported by the client.
+	MissingCodecs []RtpCodecCapability `json:"missingCodecs,omitempty"`
+	// Router header extensions not supported by the client.
+	MissingHeaderExtensions []RtpHeaderExtension `json:"missingHeaderExtensions,omitempty"`
+	// The client doesn't support RTX, so lost video packets are not
+	// retransmitted.
+	NoRtx bool `json:"noRtx,omitempty"`
+	// The client doesn't support Opus in-band FEC while the Router enables it.
+	NoFec bool `json:"noFec,omitempty"`
+}
+
+// Degraded returns whether the client lacks anything the Router offers.
+func (r RtpCapabilitiesReport) Degraded() bool {
+	return len(r.MissingCodecs) > 0 || len(r.MissingHeaderExtensions) > 0 || r.NoRtx || r.NoFec
+}
+
+// ForKind returns the part of the report about the given media kind.
+func (r RtpCapabilitiesReport) ForKind(kind string) (report RtpCapabilitiesReport) {
+	for _, codec := range r.MissingCodecs {
+		if codec.Kind == kind {
+			report.MissingCodecs = append(report.MissingCodecs, codec)
+		}
+	}
+	for _, ext := range r.MissingHeaderExtensions {
+		if ext.Kind == kind || len(ext.Kind) == 0 {
+			report.MissingHeaderExtensions = append(report.MissingHeaderExtensions, ext)
+		}
+	}
+
+	report.NoRtx = r.NoRtx && kind == "video"
+	report.NoFec = r.NoFec && kind == "audio"
+
+	return
+}
+
+// CheckRtpCapabilities compares the given client RTP capabilities with the
+// Router ones.
+func (router *Router) CheckRtpCapabilities(rtpCapabilities RtpCapabilities) RtpCapabilitiesReport {
+	return CompareRtpCapabilities(router.data.RtpCapabilities, rtpCapabilities)
+}
+
+// CheckRtpCapabilities compares the given client RTP capabilities with the
+// consumable RTP parameters of the Producer, that is with what its Consumers
+// receive. Unlike Router.CheckRtpCapabilities, the Router codecs not used by
+// the Producer are not reported.
+func (producer *Producer) CheckRtpCapabilities(rtpCapabilities RtpCapabilities) RtpCapabilitiesReport {
+	var consumableCaps RtpCapabilities
+
+	params := producer.ConsumableRtpParameters()
+
+	for _, codec := range params.Codecs {
+		codec.Kind = producer.Kind()
+		consumableCaps.Codecs = append(consumableCaps.Codecs, codec)
+	}
+	for _, ext := range params.HeaderExtensions {
+		ext.Kind = producer.Kind()
+		consumableCaps.HeaderExtensions = append(consumableCaps.HeaderExtensions, ext)
+	}
+
+	return CompareRtpCapabilities(consumableCaps, rtpCapabilities).ForKind(producer.Kind())
+}
+
+// CompareRtpCapabilities compares the RTP capabilities of a client with the
+// ones of a Router.
+func CompareRtpCapabilities(routerCaps, clientCaps RtpCapabilities) (report RtpCapabilitiesReport) {
+	var routerRtx, clientRtx, clientVideo bool
+
+	for _, codec := range clientCaps.Codecs {
+		if isRtxMimeType(codec.MimeType) {
+			clientRtx = true
+		} else if codec.Kind == "video" {
+			clientVideo = true
+		}
+	}
+
+	for _, codec := range routerCaps.Codecs {
+		if isRtxMimeType(codec.MimeType) {
+			routerRtx = true
+			continue
+		}
+
+		clientCodec, matched := selectMatchedCodecs(&codec, clientCaps.Codecs, codecMatchStrict)
+		if !matched {
+			report.MissingCodecs = append(report.MissingCodecs, codec)
+			continue
+		}
+
+		if strings.ToLower(codec.MimeType) == "audio/opus" &&
+			codec.Parameters != nil && codec.Parameters.Useinbandfec == 1 &&
+			(clientCodec.Parameters == nil || clientCodec.Parameters.Useinbandfec != 1) {
+			report.NoFec = true
+		}
+	}
+
+	report.NoRtx = routerRtx && clientVideo && !clientRtx
+
+	for _, ext := range routerCaps.HeaderExtensions {
+		// Not used by Consumers.
+		if ext.Uri == "urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id" ||
+			ext.Uri == "urn:ietf:params:rtp-hdrext:sdes:repaired-rtp-stream-id" {
+			continue
+		}
+
+		supported := false
+
+		for _, clientExt := range clientCaps.HeaderExtensions {
+			if matchHeaderExtensions(ext, clientExt) {
+				supported = true
+				break
+			}
+		}
+
+		if !supported {
+			report.MissingHeaderExtensions = append(report.MissingHeaderExtensions, ext)
+		}
+	}
+
+	return
+}
+
+func isRtxMimeType(mimeType string) bool {
+	return strings.HasSuffix(strings.ToLower(mimeType), "/rtx")
+}
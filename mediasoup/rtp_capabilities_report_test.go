@@ -0,0 +1,116 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareRtpCapabilities(t *testing.T) {
+	routerCaps, err := GenerateRouterRtpCapabilities([]RtpCodecCapability{
+		{
+			Kind:      "audio",
+			MimeType:  "audio/opus",
+			ClockRate: 48000,
+			Channels:  2,
+			Parameters: &RtpCodecParameter{
+				Useinbandfec: 1,
+			},
+		},
+		{
+			Kind:      "video",
+			MimeType:  "video/VP8",
+			ClockRate: 90000,
+		},
+		{
+			Kind:      "video",
+			MimeType:  "video/VP9",
+			ClockRate: 90000,
+		},
+	})
+	require.NoError(t, err)
+
+	report := CompareRtpCapabilities(routerCaps, routerCaps)
+	assert.False(t, report.Degraded())
+
+	clientCaps := RtpCapabilities{
+		Codecs: []RtpCodecCapability{
+			{
+				Kind:      "audio",
+				MimeType:  "audio/opus",
+				ClockRate: 48000,
+				Channels:  2,
+			},
+			{
+				Kind:      "video",
+				MimeType:  "video/VP8",
+				ClockRate: 90000,
+			},
+		},
+	}
+	for _, ext := range routerCaps.HeaderExtensions {
+		if ext.Uri != "urn:3gpp:video-orientation" {
+			clientCaps.HeaderExtensions = append(clientCaps.HeaderExtensions, ext)
+		}
+	}
+
+	report = CompareRtpCapabilities(routerCaps, clientCaps)
+	assert.True(t, report.Degraded())
+	assert.True(t, report.NoRtx)
+	assert.True(t, report.NoFec)
+	require.Len(t, report.MissingCodecs, 1)
+	assert.Equal(t, "video/VP9", report.MissingCodecs[0].MimeType)
+	require.Len(t, report.MissingHeaderExtensions, 1)
+	assert.Equal(t, "urn:3gpp:video-orientation", report.MissingHeaderExtensions[0].Uri)
+
+	audioReport := report.ForKind("audio")
+	assert.True(t, audioReport.Degraded())
+	assert.True(t, audioReport.NoFec)
+	assert.False(t, audioReport.NoRtx)
+	assert.Empty(t, audioReport.MissingCodecs)
+	assert.Empty(t, audioReport.MissingHeaderExtensions)
+}
+
+func TestProducerCheckRtpCapabilities(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+
+	producer := NewProducer(
+		internalData{ProducerId: "producer"},
+		producerData{
+			Kind: "video",
+			ConsumableRtpParameters: RtpParameters{
+				Codecs: []RtpCodecCapability{
+					{MimeType: "video/VP8", PayloadType: 101, ClockRate: 90000},
+					{MimeType: "video/rtx", PayloadType: 102, ClockRate: 90000},
+				},
+				HeaderExtensions: []RtpHeaderExtension{
+					{Uri: "urn:3gpp:video-orientation", Id: 4},
+				},
+			},
+		},
+		worker.channel, nil, false,
+	)
+
+	// Router codecs and header extensions not used by the Producer are not
+	// reported.
+	clientCaps := RtpCapabilities{
+		Codecs: []RtpCodecCapability{
+			{Kind: "video", MimeType: "video/VP8", ClockRate: 90000},
+			{Kind: "video", MimeType: "video/rtx", ClockRate: 90000},
+		},
+		HeaderExtensions: []RtpHeaderExtension{
+			{Kind: "video", Uri: "urn:3gpp:video-orientation"},
+		},
+	}
+	assert.False(t, producer.CheckRtpCapabilities(clientCaps).Degraded())
+
+	clientCaps.Codecs = clientCaps.Codecs[:1]
+	clientCaps.HeaderExtensions = nil
+
+	report := producer.CheckRtpCapabilities(clientCaps)
+	assert.True(t, report.NoRtx)
+	assert.Empty(t, report.MissingCodecs)
+	require.Len(t, report.MissingHeaderExtensions, 1)
+	assert.Equal(t, "urn:3gpp:video-orientation", report.MissingHeaderExtensions[0].Uri)
+}
@@ -0,0 +1,138 @@
+package mediasoup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FEC mechanisms of the encodings, with the codecs they need.
+var fecMechanismCodecs = map[string][]string{
+	"red":        {"red"},
+	"red+ulpfec": {"red", "ulpfec"},
+	"flexfec":    {"flexfec-03"},
+}
+
+// validateProducerEncodings checks the RTX and FEC streams announced by the
+// encodings of a Producer against its codecs, since the worker would silently
+// ignore them otherwise (no retransmission, no error correction).
+func validateProducerEncodings(params RtpParameters) error {
+	for i, encoding := range params.Encodings {
+		field := fmt.Sprintf("RtpParameters.Encodings[%d]", i)
+
+		if encoding.CodecPayloadType == 0 && encoding.Rtx == nil && encoding.Fec == nil {
+			continue
+		}
+
+		mediaCodec, err := encodingMediaCodec(params, encoding)
+		if err != nil {
+			return NewValidationError(field+".CodecPayloadType", "%s", err)
+		}
+
+		if encoding.Rtx != nil {
+			if encoding.Rtx.Ssrc == 0 {
+				return NewValidationError(field+".Rtx.Ssrc", "missing RTX SSRC")
+			}
+			if encoding.Rtx.Ssrc == encoding.Ssrc {
+				return NewValidationError(field+".Rtx.Ssrc", "RTX SSRC %d is the media SSRC", encoding.Ssrc)
+			}
+			if !hasRtxCodec(params, mediaCodec.PayloadType) {
+				return NewValidationError(field+".Rtx",
+					"no RTX codec associated with media codec %s (payload type %d)",
+					mediaCodec.MimeType, mediaCodec.PayloadType)
+			}
+		}
+
+		if encoding.Fec != nil {
+			if err := validateEncodingFec(field+".Fec", params, encoding); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// encodingMediaCodec returns the media codec of the given encoding, the first
+// media codec if the encoding doesn't set CodecPayloadType.
+func encodingMediaCodec(params RtpParameters, encoding RtpEncoding) (codec RtpCodecCapability, err error) {
+	for _, codec := range params.Codecs {
+		if !isMediaCodec(codec) {
+			continue
+		}
+		if encoding.CodecPayloadType == 0 || uint32(codec.PayloadType) == encoding.CodecPayloadType {
+			return codec, nil
+		}
+	}
+
+	if encoding.CodecPayloadType == 0 {
+		return codec, fmt.Errorf("no media codec")
+	}
+
+	return codec, fmt.Errorf("no media codec with payload type %d", encoding.CodecPayloadType)
+}
+
+func validateEncodingFec(field string, params RtpParameters, encoding RtpEncoding) error {
+	fec := encoding.Fec
+
+	codecs, ok := fecMechanismCodecs[fec.Mechanism]
+	if !ok {
+		return NewValidationError(field+".Mechanism", `unknown mechanism "%s"`, fec.Mechanism)
+	}
+
+	for _, name := range codecs {
+		if !hasCodec(params, name) {
+			return NewValidationError(field+".Mechanism", `no %s codec for mechanism "%s"`, name, fec.Mechanism)
+		}
+	}
+
+	if fec.Mechanism == "flexfec" {
+		if fec.Ssrc == 0 {
+			return NewValidationError(field+".Ssrc", "missing FlexFEC SSRC")
+		}
+		if fec.Ssrc == encoding.Ssrc || (encoding.Rtx != nil && fec.Ssrc == encoding.Rtx.Ssrc) {
+			return NewValidationError(field+".Ssrc", "FlexFEC SSRC %d is already used by the encoding", fec.Ssrc)
+		}
+	} else if fec.Ssrc != 0 && fec.Ssrc != encoding.Ssrc {
+		return NewValidationError(field+".Ssrc", `mechanism "%s" uses the media SSRC, not %d`, fec.Mechanism, fec.Ssrc)
+	}
+
+	return nil
+}
+
+func hasRtxCodec(params RtpParameters, payloadType int) bool {
+	for _, codec := range params.Codecs {
+		if codecName(codec) == "rtx" && codec.Parameters != nil && codec.Parameters.Apt == payloadType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasCodec(params RtpParameters, name string) bool {
+	for _, codec := range params.Codecs {
+		if codecName(codec) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isMediaCodec returns whether the codec carries media, not retransmissions
+// or error correction.
+func isMediaCodec(codec RtpCodecCapability) bool {
+	switch codecName(codec) {
+	case "rtx", "red", "ulpfec", "flexfec-03":
+		return false
+	}
+
+	return true
+}
+
+// codecName returns the lowercase subtype of the codec mime type, e.g. "rtx".
+func codecName(codec RtpCodecCapability) string {
+	mimeType := strings.ToLower(codec.MimeType)
+
+	return mimeType[strings.Index(mimeType, "/")+1:]
+}
@@ -0,0 +1,108 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProducerEncodings(t *testing.T) {
+	codecs := []RtpCodecCapability{
+		{MimeType: "video/VP8", PayloadType: 96, ClockRate: 90000},
+		{MimeType: "video/rtx", PayloadType: 97, ClockRate: 90000, Parameters: &RtpCodecParameter{Apt: 96}},
+		{MimeType: "video/H264", PayloadType: 98, ClockRate: 90000},
+		{MimeType: "video/red", PayloadType: 100, ClockRate: 90000},
+		{MimeType: "video/ulpfec", PayloadType: 101, ClockRate: 90000},
+	}
+
+	testCases := []struct {
+		name     string
+		encoding RtpEncoding
+		field    string
+	}{
+		{
+			name:     "rtx",
+			encoding: RtpEncoding{Ssrc: 1, Rtx: &RtpEncoding{Ssrc: 2}},
+		},
+		{
+			name:     "red+ulpfec",
+			encoding: RtpEncoding{Ssrc: 1, Fec: &RtpEncodingFec{Mechanism: "red+ulpfec"}},
+		},
+		{
+			name:     "unknown codec payload type",
+			encoding: RtpEncoding{Ssrc: 1, CodecPayloadType: 99},
+			field:    "RtpParameters.Encodings[0].CodecPayloadType",
+		},
+		{
+			name:     "rtx codec payload type",
+			encoding: RtpEncoding{Ssrc: 1, CodecPayloadType: 97},
+			field:    "RtpParameters.Encodings[0].CodecPayloadType",
+		},
+		{
+			name:     "missing rtx ssrc",
+			encoding: RtpEncoding{Ssrc: 1, Rtx: &RtpEncoding{}},
+			field:    "RtpParameters.Encodings[0].Rtx.Ssrc",
+		},
+		{
+			name:     "rtx ssrc is the media ssrc",
+			encoding: RtpEncoding{Ssrc: 1, Rtx: &RtpEncoding{Ssrc: 1}},
+			field:    "RtpParameters.Encodings[0].Rtx.Ssrc",
+		},
+		{
+			name:     "no rtx codec for the encoding codec",
+			encoding: RtpEncoding{Ssrc: 1, CodecPayloadType: 98, Rtx: &RtpEncoding{Ssrc: 2}},
+			field:    "RtpParameters.Encodings[0].Rtx",
+		},
+		{
+			name:     "unknown fec mechanism",
+			encoding: RtpEncoding{Ssrc: 1, Fec: &RtpEncodingFec{Mechanism: "raptor"}},
+			field:    "RtpParameters.Encodings[0].Fec.Mechanism",
+		},
+		{
+			name:     "no flexfec codec",
+			encoding: RtpEncoding{Ssrc: 1, Fec: &RtpEncodingFec{Mechanism: "flexfec", Ssrc: 3}},
+			field:    "RtpParameters.Encodings[0].Fec.Mechanism",
+		},
+		{
+			name:     "ulpfec with another ssrc",
+			encoding: RtpEncoding{Ssrc: 1, Fec: &RtpEncodingFec{Mechanism: "red+ulpfec", Ssrc: 3}},
+			field:    "RtpParameters.Encodings[0].Fec.Ssrc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateProducerEncodings(RtpParameters{
+				Codecs:    codecs,
+				Encodings: []RtpEncoding{tc.encoding},
+			})
+
+			if len(tc.field) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.IsType(t, ValidationError{}, err) {
+				assert.Equal(t, tc.field, err.(ValidationError).Field)
+			}
+		})
+	}
+}
+
+func TestValidateProducerEncodings_FlexFec(t *testing.T) {
+	params := RtpParameters{
+		Codecs: []RtpCodecCapability{
+			{MimeType: "video/VP8", PayloadType: 96, ClockRate: 90000},
+			{MimeType: "video/flexfec-03", PayloadType: 102, ClockRate: 90000},
+		},
+		Encodings: []RtpEncoding{{Ssrc: 1, Fec: &RtpEncodingFec{Mechanism: "flexfec", Ssrc: 3}}},
+	}
+	assert.NoError(t, validateProducerEncodings(params))
+
+	params.Encodings[0].Fec.Ssrc = 0
+	assert.EqualError(t, validateProducerEncodings(params),
+		"RtpParameters.Encodings[0].Fec.Ssrc: missing FlexFEC SSRC")
+
+	params.Encodings[0].Fec.Ssrc = 1
+	assert.EqualError(t, validateProducerEncodings(params),
+		"RtpParameters.Encodings[0].Fec.Ssrc: FlexFEC SSRC 1 is already used by the encoding")
+}
@@ -1,6 +1,9 @@
 package mediasoup
 
 import (
+	"sort"
+	"sync"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,6 +13,7 @@ type RtpObserver interface {
 	Id() string
 	Closed() bool
 	Paused() bool
+	Observer() EventEmitter
 	Close()
 	routerClosed()
 	Pause()
@@ -23,66 +27,103 @@ type baseRtpObserver struct {
 	logger   logrus.FieldLogger
 	internal internalData
 	channel  *Channel
-	closed   bool
+	closed   closeFlag
 	paused   bool
+	observer EventEmitter
+	// Producers of the "addproducer" and "removeproducer" observer events.
+	getProducerById fetchProducerFunc
+	// Route of the worker notifications.
+	subscription notificationSubscription
+	// Producers added to the RtpObserver, by id.
+	producerIds       map[string]struct{}
+	producerIdsLocker sync.Mutex
 }
 
-func newRtpObserver(internal internalData, channel *Channel) *baseRtpObserver {
-	logger := TypeLogger("RtpObserver")
+func newRtpObserver(
+	internal internalData,
+	channel *Channel,
+	getProducerById fetchProducerFunc,
+	appLogger logrus.FieldLogger,
+) *baseRtpObserver {
+	appLogger = entityAppLogger(appLogger)
+	logger := appLogger.WithField("type", "RtpObserver")
 
 	logger.Debug("constructor()")
 
+	closed, ctx := newCloseFlag()
+
 	return &baseRtpObserver{
-		EventEmitter: NewEventEmitter(logger),
+		EventEmitter: NewEventEmitter(logger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		closed:       closed,
 		logger:       logger,
 		// - .RouterId
 		// - .RtpObserverId
-		internal: internal,
-		channel:  channel,
+		internal:        internal,
+		channel:         channel,
+		observer:        NewEventEmitter(appLogger, WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		getProducerById: getProducerById,
+		producerIds:     make(map[string]struct{}),
 	}
 }
 
-func (rtpObserver baseRtpObserver) Id() string {
+func (rtpObserver *baseRtpObserver) Id() string {
 	return rtpObserver.internal.RtpObserverId
 }
 
-func (rtpObserver baseRtpObserver) Closed() bool {
-	return rtpObserver.closed
+func (rtpObserver *baseRtpObserver) Closed() bool {
+	return rtpObserver.closed.isSet()
 }
 
-func (rtpObserver baseRtpObserver) Paused() bool {
+func (rtpObserver *baseRtpObserver) Paused() bool {
 	return rtpObserver.paused
 }
 
+/**
+ * Observer.
+ *
+ * @emits close
+ * @emits pause
+ * @emits resume
+ * @emits {producer: Producer} addproducer
+ * @emits {producer: Producer} removeproducer
+ */
+func (rtpObserver *baseRtpObserver) Observer() EventEmitter {
+	return rtpObserver.observer
+}
+
 func (rtpObserver *baseRtpObserver) Close() {
-	if rtpObserver.closed {
+	if !rtpObserver.closed.set() {
 		return
 	}
-
-	rtpObserver.closed = true
+	defer rtpObserver.closed.cancelContext()
 
 	// Remove notification subscriptions.
-	rtpObserver.channel.RemoveAllListeners(rtpObserver.internal.RtpObserverId)
+	rtpObserver.channel.unsubscribe(rtpObserver.subscription)
 
 	rtpObserver.channel.Request("rtpObserver.close", rtpObserver.internal, nil)
 
 	rtpObserver.Emit("@close")
+
+	// Emit observer event.
+	rtpObserver.observer.SafeEmit("close")
 }
 
 // Router was closed.
 func (rtpObserver *baseRtpObserver) routerClosed() {
-	if rtpObserver.closed {
+	if !rtpObserver.closed.set() {
 		return
 	}
+	defer rtpObserver.closed.cancelContext()
 
 	rtpObserver.logger.Debug("routerClosed()")
 
-	rtpObserver.closed = true
-
 	// Remove notification subscriptions.
-	rtpObserver.channel.RemoveAllListeners(rtpObserver.internal.RtpObserverId)
+	rtpObserver.channel.unsubscribe(rtpObserver.subscription)
 
 	rtpObserver.SafeEmit("routerclose")
+
+	// Emit observer event.
+	rtpObserver.observer.SafeEmit("close")
 }
 
 // Pause the RtpObserver.
@@ -96,6 +137,9 @@ func (rtpObserver *baseRtpObserver) Pause() {
 	rtpObserver.channel.Request("rtpObserver.pause", rtpObserver.internal, nil)
 
 	rtpObserver.paused = true
+
+	// Emit observer event.
+	rtpObserver.observer.SafeEmit("pause")
 }
 
 // Resume the RtpObserver.
@@ -109,6 +153,9 @@ func (rtpObserver *baseRtpObserver) Resume() {
 	rtpObserver.channel.Request("rtpObserver.resume", rtpObserver.internal, nil)
 
 	rtpObserver.paused = false
+
+	// Emit observer event.
+	rtpObserver.observer.SafeEmit("resume")
 }
 
 // Add a Producer to the RtpObserver.
@@ -118,7 +165,16 @@ func (rtpObserver *baseRtpObserver) AddProducer(producerId string) {
 	internal := rtpObserver.internal
 	internal.ProducerId = producerId
 
-	rtpObserver.channel.Request("rtpObserver.addProducer", internal, nil)
+	if rtpObserver.channel.Request("rtpObserver.addProducer", internal, nil).Err() == nil {
+		rtpObserver.producerIdsLocker.Lock()
+		rtpObserver.producerIds[producerId] = struct{}{}
+		rtpObserver.producerIdsLocker.Unlock()
+	}
+
+	// Emit observer event.
+	if producer := rtpObserver.producer(producerId); producer != nil {
+		rtpObserver.observer.SafeEmit("addproducer", producer)
+	}
 }
 
 // Remove a Producer from the RtpObserver.
@@ -129,4 +185,43 @@ func (rtpObserver *baseRtpObserver) RemoveProducer(producerId string) {
 	internal.ProducerId = producerId
 
 	rtpObserver.channel.Request("rtpObserver.removeProducer", internal, nil)
+
+	rtpObserver.producerIdsLocker.Lock()
+	delete(rtpObserver.producerIds, producerId)
+	rtpObserver.producerIdsLocker.Unlock()
+
+	// Emit observer event.
+	if producer := rtpObserver.producer(producerId); producer != nil {
+		rtpObserver.observer.SafeEmit("removeproducer", producer)
+	}
+}
+
+// getProducerIds returns the ids of the Producers added to the RtpObserver and
+// not closed since, forgetting the closed ones.
+func (rtpObserver *baseRtpObserver) getProducerIds() []string {
+	rtpObserver.producerIdsLocker.Lock()
+	defer rtpObserver.producerIdsLocker.Unlock()
+
+	producerIds := make([]string, 0, len(rtpObserver.producerIds))
+
+	for producerId := range rtpObserver.producerIds {
+		if producer := rtpObserver.producer(producerId); producer == nil || producer.Closed() {
+			delete(rtpObserver.producerIds, producerId)
+			continue
+		}
+
+		producerIds = append(producerIds, producerId)
+	}
+
+	sort.Strings(producerIds)
+
+	return producerIds
+}
+
+func (rtpObserver *baseRtpObserver) producer(producerId string) *Producer {
+	if rtpObserver.getProducerById == nil {
+		return nil
+	}
+
+	return rtpObserver.getProducerById(producerId)
 }
@@ -0,0 +1,152 @@
+package mediasoup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRoleForbidden is returned (wrapped) by the Authorizer of
+// RtpRoleAuthorizer for the Producers and Consumers not allowed by the role.
+var ErrRoleForbidden = errors.New("forbidden by role")
+
+// RtpRole is what the participants of a role may send and receive.
+type RtpRole struct {
+	Name string
+	// Kinds ("audio", "video") the participants may produce and consume.
+	SendKinds []string
+	RecvKinds []string
+	// Codecs (e.g. "audio/opus") the participants may use, all if empty.
+	MimeTypes []string
+}
+
+// Usual roles.
+var (
+	RtpRolePublisher = RtpRole{
+		Name:      "publisher",
+		SendKinds: []string{"audio", "video"},
+		RecvKinds: []string{"audio", "video"},
+	}
+	RtpRoleViewer = RtpRole{
+		Name:      "viewer",
+		RecvKinds: []string{"audio", "video"},
+	}
+	RtpRoleAudioOnly = RtpRole{
+		Name:      "audioonly",
+		SendKinds: []string{"audio"},
+		RecvKinds: []string{"audio"},
+	}
+)
+
+func (role RtpRole) canSend(kind string) bool {
+	return containsString(role.SendKinds, kind)
+}
+
+func (role RtpRole) canRecv(kind string) bool {
+	return containsString(role.RecvKinds, kind)
+}
+
+func (role RtpRole) allowsCodec(codec RtpCodecCapability) bool {
+	if len(role.MimeTypes) == 0 {
+		return true
+	}
+
+	for _, mimeType := range role.MimeTypes {
+		if strings.EqualFold(mimeType, codec.MimeType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SubsetRtpCapabilities returns the given capabilities reduced to the kinds
+// and codecs of the role, to be given to its participants. RTP capabilities
+// are the same in both directions, so the kinds the role may either send or
+// receive are kept, the direction being enforced by RtpRoleAuthorizer.
+func SubsetRtpCapabilities(rtpCapabilities RtpCapabilities, role RtpRole) (subset RtpCapabilities) {
+	for _, codec := range rtpCapabilities.Codecs {
+		if isRtxMimeType(codec.MimeType) || !role.allowsCodec(codec) ||
+			(!role.canSend(codec.Kind) && !role.canRecv(codec.Kind)) {
+			continue
+		}
+
+		subset.Codecs = append(subset.Codecs, codec)
+
+		// Followed by its RTX codec, as in the Router capabilities.
+		for _, rtxCodec := range rtpCapabilities.Codecs {
+			if isRtxMimeType(rtxCodec.MimeType) && rtxCodec.Parameters != nil &&
+				rtxCodec.Parameters.Apt == codec.PreferredPayloadType {
+				subset.Codecs = append(subset.Codecs, rtxCodec)
+			}
+		}
+	}
+
+	for _, ext := range rtpCapabilities.HeaderExtensions {
+		if role.canSend(ext.Kind) || role.canRecv(ext.Kind) {
+			subset.HeaderExtensions = append(subset.HeaderExtensions, ext)
+		}
+	}
+
+	return
+}
+
+// RoleRtpCapabilities returns the RTP capabilities of the Router reduced to
+// the given role, see SubsetRtpCapabilities.
+func (router *Router) RoleRtpCapabilities(role RtpRole) RtpCapabilities {
+	return SubsetRtpCapabilities(router.RtpCapabilities(), role)
+}
+
+// RtpRoleAuthorizer returns an Authorizer enforcing the roles of the
+// participants, named by the given key of the appData (H) of their Producers
+// and Consumers. The entities without a role are authorized, as the
+// Transports.
+func RtpRoleAuthorizer(roles []RtpRole, appDataKey string) Authorizer {
+	rolesByName := make(map[string]RtpRole, len(roles))
+
+	for _, role := range roles {
+		rolesByName[role.Name] = role
+	}
+
+	return func(request AuthorizationRequest) error {
+		if request.Action == AuthorizeCreateTransport {
+			return nil
+		}
+
+		appData, _ := request.AppData.(H)
+
+		name, ok := appData[appDataKey].(string)
+		if !ok {
+			return nil
+		}
+
+		role, ok := rolesByName[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown role %q", ErrRoleForbidden, name)
+		}
+
+		return role.authorize(request)
+	}
+}
+
+func (role RtpRole) authorize(request AuthorizationRequest) error {
+	switch request.Action {
+	case AuthorizeProduce:
+		if !role.canSend(request.Kind) {
+			return fmt.Errorf("%w: role %q cannot send %s", ErrRoleForbidden, role.Name, request.Kind)
+		}
+
+		for _, codec := range request.RtpParameters.Codecs {
+			if !isRtxMimeType(codec.MimeType) && !role.allowsCodec(codec) {
+				return fmt.Errorf("%w: role %q cannot send %s", ErrRoleForbidden, role.Name, codec.MimeType)
+			}
+		}
+
+	case AuthorizeConsume:
+		if !role.canRecv(request.Kind) {
+			return fmt.Errorf("%w: role %q cannot receive %s", ErrRoleForbidden, role.Name, request.Kind)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package mediasoup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsetRtpCapabilities(t *testing.T) {
+	template, err := NewRouterTemplate([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+		{Kind: "video", MimeType: "video/VP8", ClockRate: 90000},
+		{Kind: "video", MimeType: "video/H264", ClockRate: 90000},
+	})
+	require.NoError(t, err)
+
+	rtpCapabilities := template.data.RtpCapabilities
+
+	subset := SubsetRtpCapabilities(rtpCapabilities, RtpRolePublisher)
+	assert.Equal(t, rtpCapabilities, subset)
+
+	// RTP capabilities are not directional.
+	assert.Equal(t, rtpCapabilities, SubsetRtpCapabilities(rtpCapabilities, RtpRoleViewer))
+
+	subset = SubsetRtpCapabilities(rtpCapabilities, RtpRoleAudioOnly)
+	if assert.Len(t, subset.Codecs, 1) {
+		assert.Equal(t, "audio/opus", subset.Codecs[0].MimeType)
+	}
+	for _, ext := range subset.HeaderExtensions {
+		assert.Equal(t, "audio", ext.Kind)
+	}
+	assert.NotEmpty(t, subset.HeaderExtensions)
+
+	subset = SubsetRtpCapabilities(rtpCapabilities, RtpRole{
+		SendKinds: []string{"audio", "video"},
+		MimeTypes: []string{"audio/opus", "video/vp8"},
+	})
+
+	var mimeTypes []string
+	for _, codec := range subset.Codecs {
+		mimeTypes = append(mimeTypes, codec.MimeType)
+	}
+	assert.Equal(t, []string{"audio/opus", "video/VP8", "video/rtx"}, mimeTypes)
+	assert.Equal(t, subset.Codecs[1].PreferredPayloadType, subset.Codecs[2].Parameters.Apt)
+}
+
+func TestRtpRoleAuthorizer(t *testing.T) {
+	authorizer := RtpRoleAuthorizer([]RtpRole{RtpRoleViewer, RtpRoleAudioOnly}, "role")
+
+	assert.NoError(t, authorizer(AuthorizationRequest{
+		Action:  AuthorizeCreateTransport,
+		AppData: H{"role": "viewer"},
+	}))
+	assert.NoError(t, authorizer(AuthorizationRequest{
+		Action:  AuthorizeProduce,
+		Kind:    "video",
+		AppData: H{},
+	}))
+
+	err := authorizer(AuthorizationRequest{
+		Action:  AuthorizeProduce,
+		Kind:    "audio",
+		AppData: H{"role": "viewer"},
+	})
+	assert.True(t, errors.Is(err, ErrRoleForbidden))
+
+	assert.NoError(t, authorizer(AuthorizationRequest{
+		Action:  AuthorizeConsume,
+		Kind:    "video",
+		AppData: H{"role": "viewer"},
+	}))
+
+	err = authorizer(AuthorizationRequest{
+		Action:  AuthorizeConsume,
+		Kind:    "video",
+		AppData: H{"role": "audioonly"},
+	})
+	assert.True(t, errors.Is(err, ErrRoleForbidden))
+
+	err = authorizer(AuthorizationRequest{
+		Action:  AuthorizeConsume,
+		Kind:    "audio",
+		AppData: H{"role": "admin"},
+	})
+	assert.True(t, errors.Is(err, ErrRoleForbidden))
+
+	authorizer = RtpRoleAuthorizer([]RtpRole{{
+		Name:      "opus",
+		SendKinds: []string{"audio"},
+		MimeTypes: []string{"audio/opus"},
+	}}, "role")
+
+	err = authorizer(AuthorizationRequest{
+		Action: AuthorizeProduce,
+		Kind:   "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{{MimeType: "audio/PCMU"}},
+		},
+		AppData: H{"role": "opus"},
+	})
+	assert.True(t, errors.Is(err, ErrRoleForbidden))
+}
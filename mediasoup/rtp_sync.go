@@ -0,0 +1,105 @@
+package mediasoup
+
+// RtpStreamSync is the RTP position of a stream, as reported by the worker
+// in the dumps, so that recorders stitching several Consumers can align them.
+type RtpStreamSync struct {
+	Ssrc      uint32 `json:"ssrc"`
+	Rid       string `json:"rid,omitempty"`
+	ClockRate uint32 `json:"clockRate"`
+	// Offsets applied by the worker to the sequence numbers and timestamps
+	// of the Producer stream forwarded by a Consumer (sent = received +
+	// offset, wrapping around), zero for a Producer.
+	SeqOffset       uint16 `json:"seqOffset,omitempty"`
+	TimestampOffset uint32 `json:"timestampOffset,omitempty"`
+	// Highest RTP timestamp of the stream, and the time (ms) it was
+	// sent or received at.
+	MaxPacketTs uint32 `json:"maxPacketTs"`
+	MaxPacketMs uint64 `json:"maxPacketMs"`
+}
+
+// ProducerSeq returns the Producer sequence number of a sequence number sent
+// by the Consumer.
+func (s RtpStreamSync) ProducerSeq(seq uint16) uint16 {
+	return seq - s.SeqOffset
+}
+
+// ProducerTimestamp returns the Producer RTP timestamp of a timestamp sent by
+// the Consumer.
+func (s RtpStreamSync) ProducerTimestamp(timestamp uint32) uint32 {
+	return timestamp - s.TimestampOffset
+}
+
+// rtpStreamSyncDump is an RTP stream of a dump, the sync fields being nil if
+// not reported by the worker.
+type rtpStreamSyncDump struct {
+	Params struct {
+		Ssrc      uint32
+		Rid       string
+		ClockRate uint32
+	}
+	SeqOffset   *uint16
+	TsOffset    *uint32
+	MaxPacketTs *uint32
+	MaxPacketMs *uint64
+}
+
+func (dump rtpStreamSyncDump) sync() (sync RtpStreamSync, ok bool) {
+	if dump.SeqOffset == nil && dump.TsOffset == nil && dump.MaxPacketTs == nil {
+		return
+	}
+
+	sync = RtpStreamSync{
+		Ssrc:      dump.Params.Ssrc,
+		Rid:       dump.Params.Rid,
+		ClockRate: dump.Params.ClockRate,
+	}
+	if dump.SeqOffset != nil {
+		sync.SeqOffset = *dump.SeqOffset
+	}
+	if dump.TsOffset != nil {
+		sync.TimestampOffset = *dump.TsOffset
+	}
+	if dump.MaxPacketTs != nil {
+		sync.MaxPacketTs = *dump.MaxPacketTs
+	}
+	if dump.MaxPacketMs != nil {
+		sync.MaxPacketMs = *dump.MaxPacketMs
+	}
+
+	return sync, true
+}
+
+func rtpStreamSyncs(response Response) (syncs []RtpStreamSync, err error) {
+	var dump struct {
+		RtpStreams []rtpStreamSyncDump
+	}
+	if err = response.Unmarshal(&dump); err != nil {
+		return
+	}
+
+	for _, rtpStream := range dump.RtpStreams {
+		if sync, ok := rtpStream.sync(); ok {
+			syncs = append(syncs, sync)
+		}
+	}
+
+	return
+}
+
+// GetRtpSync returns the RTP positions and offsets of the streams sent by the
+// Consumer, taken from its dump. It is empty if the worker doesn't report
+// them.
+func (consumer *Consumer) GetRtpSync() ([]RtpStreamSync, error) {
+	consumer.logger.Debug("getRtpSync()")
+
+	return rtpStreamSyncs(consumer.Dump())
+}
+
+// GetRtpSync returns the RTP positions of the streams received by the
+// Producer, taken from its dump. It is empty if the worker doesn't report
+// them.
+func (producer *Producer) GetRtpSync() ([]RtpStreamSync, error) {
+	producer.logger.Debug("getRtpSync()")
+
+	return rtpStreamSyncs(producer.Dump())
+}
@@ -0,0 +1,65 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRtpSync(t *testing.T) {
+	fake := newFakeWorker(t)
+	channel := fake.channel
+
+	dumps := map[string]string{
+		"consumer.dump": `{"rtpStreams":[` +
+			`{"params":{"ssrc":1111,"clockRate":90000},"seqOffset":65526,"tsOffset":4294967200,` +
+			`"maxPacketTs":3000,"maxPacketMs":1700000000000},` +
+			`{"params":{"ssrc":2222,"clockRate":90000}}]}`,
+		"producer.dump": `{"rtpStreams":[` +
+			`{"params":{"ssrc":10,"rid":"r0","clockRate":90000},"maxPacketTs":3096,"maxPacketMs":1699999999990}]}`,
+	}
+
+	fake.reply("*", func(request fakeWorkerRequest) (interface{}, error) {
+		if dump, ok := dumps[request.Method]; ok {
+			return json.RawMessage(dump), nil
+		}
+		return H{}, nil
+	})
+
+	consumer := NewConsumer(
+		internalData{ConsumerId: "consumer", ProducerId: "producer"},
+		consumerData{Kind: "video", Type: "simple"},
+		channel, nil, false, false, nil,
+	)
+
+	syncs, err := consumer.GetRtpSync()
+	require.NoError(t, err)
+	require.Equal(t, []RtpStreamSync{
+		{
+			Ssrc:            1111,
+			ClockRate:       90000,
+			SeqOffset:       65526,
+			TimestampOffset: 4294967200,
+			MaxPacketTs:     3000,
+			MaxPacketMs:     1700000000000,
+		},
+	}, syncs)
+
+	// The offsets wrap around.
+	assert.EqualValues(t, 10, syncs[0].ProducerSeq(0))
+	assert.EqualValues(t, 3096, syncs[0].ProducerTimestamp(3000))
+
+	producer := NewProducer(
+		internalData{ProducerId: "producer"},
+		producerData{Kind: "video", Type: "simulcast"},
+		channel, nil, false,
+	)
+
+	syncs, err = producer.GetRtpSync()
+	require.NoError(t, err)
+	assert.Equal(t, []RtpStreamSync{
+		{Ssrc: 10, Rid: "r0", ClockRate: 90000, MaxPacketTs: 3096, MaxPacketMs: 1699999999990},
+	}, syncs)
+}
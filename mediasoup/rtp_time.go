@@ -0,0 +1,140 @@
+package mediasoup
+
+import (
+	"time"
+)
+
+// Seconds between the NTP epoch (1900) and the unix one (1970).
+const ntpEpochOffset = 2208988800
+
+// RtpTimestampDiff returns a - b, in ticks, the timestamps being at most 2^31
+// ticks apart, wrapping around included.
+func RtpTimestampDiff(a, b uint32) int32 {
+	return int32(a - b)
+}
+
+// IsRtpTimestampNewer returns whether a is after b, wrapping around included.
+func IsRtpTimestampNewer(a, b uint32) bool {
+	return RtpTimestampDiff(a, b) > 0
+}
+
+// RtpTicksToDuration returns the duration of the given ticks at the given
+// clock rate.
+func RtpTicksToDuration(ticks int64, clockRate uint32) time.Duration {
+	return time.Duration(rescaleTicks(ticks, clockRate, uint32(time.Second/time.Nanosecond)))
+}
+
+// DurationToRtpTicks returns the ticks of the given duration at the given
+// clock rate, rounded to the nearest.
+func DurationToRtpTicks(d time.Duration, clockRate uint32) int64 {
+	return rescaleTicks(int64(d), uint32(time.Second/time.Nanosecond), clockRate)
+}
+
+// RescaleRtpTimestamp converts an extended (unwrapped, see
+// RtpTimestampUnwrapper) timestamp from a clock rate to another one, rounded
+// to the nearest tick.
+func RescaleRtpTimestamp(timestamp int64, fromClockRate, toClockRate uint32) int64 {
+	return rescaleTicks(timestamp, fromClockRate, toClockRate)
+}
+
+// rescaleTicks returns ticks * to / from rounded to the nearest, without
+// overflowing for the extended timestamps.
+func rescaleTicks(ticks int64, from, to uint32) int64 {
+	if from == to || from == 0 {
+		return ticks
+	}
+
+	negative := ticks < 0
+	if negative {
+		ticks = -ticks
+	}
+
+	q, r := ticks/int64(from), ticks%int64(from)
+	result := q*int64(to) + (r*int64(to)+int64(from)/2)/int64(from)
+
+	if negative {
+		return -result
+	}
+
+	return result
+}
+
+// RtpTimestampUnwrapper extends the 32 bits RTP timestamps of a stream to 64
+// bits, counting their wraparounds, the packets being possibly reordered.
+// The first timestamp is extended to itself.
+type RtpTimestampUnwrapper struct {
+	last    int64
+	started bool
+}
+
+// Unwrap returns the extended timestamp of the given one.
+func (u *RtpTimestampUnwrapper) Unwrap(timestamp uint32) int64 {
+	if !u.started {
+		u.started = true
+		u.last = int64(timestamp)
+
+		return u.last
+	}
+
+	extended := u.last + int64(RtpTimestampDiff(timestamp, uint32(u.last)))
+
+	// Only move forward, reordered packets being extended relative to the
+	// latest one.
+	if extended > u.last {
+		u.last = extended
+	}
+
+	return extended
+}
+
+// NtpToTime returns the time of a 64 bits NTP timestamp, as found in the
+// RTCP Sender Reports (seconds since 1900 in the high 32 bits, fraction of
+// second in the low ones).
+func NtpToTime(ntp uint64) time.Time {
+	seconds := int64(ntp>>32) - ntpEpochOffset
+	nanos := (int64(ntp&0xffffffff)*int64(time.Second) + 1<<31) >> 32
+
+	return time.Unix(seconds, nanos)
+}
+
+// TimeToNtp returns the 64 bits NTP timestamp of a time.
+func TimeToNtp(t time.Time) uint64 {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := (uint64(t.Nanosecond())<<32 + uint64(time.Second)/2) / uint64(time.Second)
+
+	return seconds<<32 | fraction
+}
+
+// RtpWallclockMapping maps the RTP timestamps of a stream to wallclock time,
+// from the NTP and RTP timestamps of an RTCP Sender Report of the stream.
+// Recorders use it to align the streams of a participant (lip sync) or of
+// several ones.
+type RtpWallclockMapping struct {
+	// Time of the Sender Report, and the RTP timestamp matching it.
+	NtpTime      time.Time
+	RtpTimestamp uint32
+	ClockRate    uint32
+}
+
+// NewRtpWallclockMapping returns the mapping of a Sender Report having the
+// given NTP timestamp (seconds and fraction) and RTP timestamp.
+func NewRtpWallclockMapping(ntpSec, ntpFrac, rtpTimestamp, clockRate uint32) RtpWallclockMapping {
+	return RtpWallclockMapping{
+		NtpTime:      NtpToTime(uint64(ntpSec)<<32 | uint64(ntpFrac)),
+		RtpTimestamp: rtpTimestamp,
+		ClockRate:    clockRate,
+	}
+}
+
+// Wallclock returns the time of the given RTP timestamp, at most 2^31 ticks
+// away from the one of the Sender Report.
+func (m RtpWallclockMapping) Wallclock(timestamp uint32) time.Time {
+	ticks := int64(RtpTimestampDiff(timestamp, m.RtpTimestamp))
+
+	return m.NtpTime.Add(RtpTicksToDuration(ticks, m.ClockRate))
+}
+
+// Timestamp returns the RTP timestamp of the given time.
+func (m RtpWallclockMapping) Timestamp(t time.Time) uint32 {
+	return m.RtpTimestamp + uint32(DurationToRtpTicks(t.Sub(m.NtpTime), m.ClockRate))
+}
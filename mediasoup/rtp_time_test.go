@@ -0,0 +1,77 @@
+package mediasoup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRtpTimestampDiff(t *testing.T) {
+	assert.Equal(t, int32(10), RtpTimestampDiff(100, 90))
+	assert.Equal(t, int32(-10), RtpTimestampDiff(90, 100))
+	assert.Equal(t, int32(20), RtpTimestampDiff(10, 4294967286))
+
+	assert.True(t, IsRtpTimestampNewer(10, 4294967286))
+	assert.False(t, IsRtpTimestampNewer(4294967286, 10))
+	assert.False(t, IsRtpTimestampNewer(10, 10))
+}
+
+func TestRtpTicksToDuration(t *testing.T) {
+	assert.Equal(t, time.Second, RtpTicksToDuration(90000, 90000))
+	assert.Equal(t, 20*time.Millisecond, RtpTicksToDuration(960, 48000))
+	assert.Equal(t, -time.Second, RtpTicksToDuration(-48000, 48000))
+
+	assert.Equal(t, int64(960), DurationToRtpTicks(20*time.Millisecond, 48000))
+	assert.Equal(t, int64(3000), DurationToRtpTicks(time.Second/30, 90000))
+}
+
+func TestRescaleRtpTimestamp(t *testing.T) {
+	assert.Equal(t, int64(90000), RescaleRtpTimestamp(48000, 48000, 90000))
+	assert.Equal(t, int64(2), RescaleRtpTimestamp(1, 48000, 90000))
+	assert.Equal(t, int64(-2), RescaleRtpTimestamp(-1, 48000, 90000))
+
+	// No overflow with large extended timestamps.
+	extended := int64(1)<<40 + 48000
+	assert.Equal(t, (int64(1)<<40)/48000*90000+(int64(1)<<40)%48000*90000/48000+90000,
+		RescaleRtpTimestamp(extended, 48000, 90000))
+}
+
+func TestRtpTimestampUnwrapper(t *testing.T) {
+	var unwrapper RtpTimestampUnwrapper
+
+	assert.Equal(t, int64(4294967000), unwrapper.Unwrap(4294967000))
+	assert.Equal(t, int64(4294967200), unwrapper.Unwrap(4294967200))
+	// Wrapped around.
+	assert.Equal(t, int64(1<<32+100), unwrapper.Unwrap(100))
+	// Reordered, before the wraparound.
+	assert.Equal(t, int64(4294967250), unwrapper.Unwrap(4294967250))
+	assert.Equal(t, int64(1<<32+200), unwrapper.Unwrap(200))
+}
+
+func TestNtpTime(t *testing.T) {
+	now := time.Unix(1700000000, 500000000)
+
+	ntp := TimeToNtp(now)
+	assert.Equal(t, uint64(1700000000+ntpEpochOffset), ntp>>32)
+	assert.Equal(t, uint64(1)<<31, ntp&0xffffffff)
+	assert.True(t, now.Equal(NtpToTime(ntp)))
+
+	now = time.Unix(1700000000, 123456789)
+	assert.InDelta(t, 0, NtpToTime(TimeToNtp(now)).Sub(now), 1)
+}
+
+func TestRtpWallclockMapping(t *testing.T) {
+	srTime := time.Unix(1700000000, 0)
+	ntp := TimeToNtp(srTime)
+
+	srTimestamp := uint32(4294960000)
+	mapping := NewRtpWallclockMapping(uint32(ntp>>32), uint32(ntp), srTimestamp, 90000)
+
+	assert.True(t, srTime.Equal(mapping.Wallclock(srTimestamp)))
+	// After the wraparound.
+	assert.True(t, srTime.Add(time.Second).Equal(mapping.Wallclock(srTimestamp+90000)))
+	assert.True(t, srTime.Add(-time.Second).Equal(mapping.Wallclock(srTimestamp-90000)))
+
+	assert.Equal(t, uint32(82704), mapping.Timestamp(srTime.Add(time.Second)))
+}
@@ -0,0 +1,496 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// RouterSnapshot is a serializable description of a Router and all its
+// entities, used to share and reproduce a topology without live media.
+type RouterSnapshot struct {
+	Id          string               `json:"id"`
+	MediaCodecs []RtpCodecCapability `json:"mediaCodecs"`
+	// Settings of the Router, its profile and options applied.
+	Settings     RouterSettings        `json:"settings"`
+	Transports   []TransportSnapshot   `json:"transports"`
+	RtpObservers []RtpObserverSnapshot `json:"rtpObservers"`
+}
+
+// TransportSnapshot describes a Transport with its Producers and Consumers.
+type TransportSnapshot struct {
+	Id   string `json:"id"`
+	Type string `json:"type"` // "webrtc", "plain" or "pipe"
+	// Options given at creation (CreateWebRtcTransportParams,
+	// CreatePlainRtpTransportParams or CreatePipeTransportParams).
+	Options json.RawMessage `json:"options,omitempty"`
+	// Listen infos of the WebRtcServer of a WebRtcTransport created on one,
+	// for the Transport to be loaded on a WebRtcServer too.
+	WebRtcServerListenInfos []WebRtcServerListenInfo `json:"webRtcServerListenInfos,omitempty"`
+	// State of the Transport (WebRtcTransportData, PlainTransportData or
+	// PipeTransportData), just informative.
+	Data      interface{}        `json:"data,omitempty"`
+	AppData   interface{}        `json:"appData,omitempty"`
+	Producers []ProducerSnapshot `json:"producers"`
+	Consumers []ConsumerSnapshot `json:"consumers"`
+}
+
+// ProducerSnapshot describes a Producer.
+type ProducerSnapshot struct {
+	Id            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	Type          string          `json:"type"`
+	RtpParameters RtpParameters   `json:"rtpParameters"`
+	Paused        bool            `json:"paused"`
+	Score         []ProducerScore `json:"score,omitempty"`
+	AppData       interface{}     `json:"appData,omitempty"`
+}
+
+// ConsumerSnapshot describes a Consumer.
+type ConsumerSnapshot struct {
+	Id             string         `json:"id"`
+	ProducerId     string         `json:"producerId"`
+	Kind           string         `json:"kind"`
+	Type           string         `json:"type"`
+	RtpParameters  RtpParameters  `json:"rtpParameters"`
+	Paused         bool           `json:"paused"`
+	ProducerPaused bool           `json:"producerPaused"`
+	Score          *ConsumerScore `json:"score,omitempty"`
+	AppData        interface{}    `json:"appData,omitempty"`
+	// Layers given to SetPreferredLayers, nil if none.
+	PreferredLayers *ConsumerPreferredLayers `json:"preferredLayers,omitempty"`
+	// Bitrate cap given to SetMaxBitrate, zero if none.
+	MaxBitrate uint32 `json:"maxBitrate,omitempty"`
+}
+
+// ConsumerPreferredLayers are the layers given to Consumer.SetPreferredLayers.
+type ConsumerPreferredLayers struct {
+	SpatialLayer  uint8 `json:"spatialLayer"`
+	TemporalLayer uint8 `json:"temporalLayer"`
+}
+
+// RtpObserverSnapshot describes an RtpObserver.
+type RtpObserverSnapshot struct {
+	Id   string `json:"id"`
+	Type string `json:"type"` // "audiolevel"
+	// Options given at creation (CreateAudioLevelObserverParams).
+	Options     json.RawMessage `json:"options,omitempty"`
+	Paused      bool            `json:"paused"`
+	ProducerIds []string        `json:"producerIds"`
+}
+
+// Snapshot returns a serializable description of the Router entities, their
+// options and their current states. The appData are kept as is so that the
+// snapshot can be loaded back, use Redacted before exposing it.
+func (router *Router) Snapshot() (snapshot RouterSnapshot, err error) {
+	router.logger.Debug("snapshot()")
+
+	snapshot.Id = router.Id()
+	snapshot.MediaCodecs = router.data.MediaCodecs
+	snapshot.Settings = router.data.Settings
+	snapshot.Transports = []TransportSnapshot{}
+	snapshot.RtpObservers = []RtpObserverSnapshot{}
+
+	for _, transport := range router.getTransports() {
+		var (
+			base          *baseTransport
+			transportType string
+			data          interface{}
+			listenInfos   []WebRtcServerListenInfo
+		)
+
+		switch t := transport.(type) {
+		case *WebRtcTransport:
+			base, transportType, data = t.baseTransport, "webrtc", t.data
+
+			if params, ok := base.options.(CreateWebRtcTransportParams); ok && params.WebRtcServer != nil {
+				listenInfos = params.WebRtcServer.listenInfos
+			}
+		case *PlainRtpTransport:
+			base, transportType, data = t.baseTransport, "plain", t.data
+		case *PipeTransport:
+			base, transportType, data = t.baseTransport, "pipe", t.data
+		default:
+			continue
+		}
+
+		options, err := json.Marshal(base.options)
+		if err != nil {
+			return snapshot, fmt.Errorf(`cannot snapshot Transport "%s": %s`, base.Id(), err)
+		}
+
+		transportSnapshot := TransportSnapshot{
+			Id:                      base.Id(),
+			Type:                    transportType,
+			Options:                 options,
+			WebRtcServerListenInfos: listenInfos,
+			Data:                    data,
+			AppData:                 base.AppData(),
+			Producers:               []ProducerSnapshot{},
+			Consumers:               []ConsumerSnapshot{},
+		}
+
+		for _, producer := range base.getProducers() {
+			transportSnapshot.Producers = append(transportSnapshot.Producers, ProducerSnapshot{
+				Id:            producer.Id(),
+				Kind:          producer.Kind(),
+				Type:          producer.Type(),
+				RtpParameters: producer.RtpParameters(),
+				Paused:        producer.Paused(),
+				Score:         producer.Score(),
+				AppData:       producer.AppData(),
+			})
+		}
+
+		for _, consumer := range base.getConsumers() {
+			transportSnapshot.Consumers = append(transportSnapshot.Consumers, newConsumerSnapshot(consumer))
+		}
+
+		sort.Slice(transportSnapshot.Producers, func(i, j int) bool {
+			return transportSnapshot.Producers[i].Id < transportSnapshot.Producers[j].Id
+		})
+		sort.Slice(transportSnapshot.Consumers, func(i, j int) bool {
+			return transportSnapshot.Consumers[i].Id < transportSnapshot.Consumers[j].Id
+		})
+
+		snapshot.Transports = append(snapshot.Transports, transportSnapshot)
+	}
+
+	sort.Slice(snapshot.Transports, func(i, j int) bool {
+		return snapshot.Transports[i].Id < snapshot.Transports[j].Id
+	})
+
+	for _, rtpObserver := range router.getRtpObservers() {
+		audioLevelObserver, ok := rtpObserver.(*AudioLevelObserver)
+		if !ok {
+			continue
+		}
+
+		options, err := json.Marshal(audioLevelObserver.params)
+		if err != nil {
+			return snapshot, fmt.Errorf(`cannot snapshot RtpObserver "%s": %s`, rtpObserver.Id(), err)
+		}
+
+		snapshot.RtpObservers = append(snapshot.RtpObservers, RtpObserverSnapshot{
+			Id:          rtpObserver.Id(),
+			Type:        "audiolevel",
+			Options:     options,
+			Paused:      rtpObserver.Paused(),
+			ProducerIds: audioLevelObserver.getProducerIds(),
+		})
+	}
+
+	sort.Slice(snapshot.RtpObservers, func(i, j int) bool {
+		return snapshot.RtpObservers[i].Id < snapshot.RtpObservers[j].Id
+	})
+
+	return
+}
+
+func newConsumerSnapshot(consumer *Consumer) ConsumerSnapshot {
+	preferredLayers, maxBitrate := consumer.requestedLayersAndCap()
+
+	return ConsumerSnapshot{
+		Id:              consumer.Id(),
+		ProducerId:      consumer.ProducerId(),
+		Kind:            consumer.Kind(),
+		Type:            consumer.Type(),
+		RtpParameters:   consumer.RtpParameters(),
+		Paused:          consumer.Paused(),
+		ProducerPaused:  consumer.ProducerPaused(),
+		Score:           consumer.Score(),
+		AppData:         consumer.AppData(),
+		PreferredLayers: preferredLayers,
+		MaxBitrate:      maxBitrate,
+	}
+}
+
+// Redacted returns a copy of the snapshot with the sensitive appData values
+// redacted or encrypted by the given redactor.
+func (snapshot RouterSnapshot) Redacted(redactor *AppDataRedactor) RouterSnapshot {
+	transports := make([]TransportSnapshot, 0, len(snapshot.Transports))
+
+	for _, transport := range snapshot.Transports {
+		producers := make([]ProducerSnapshot, 0, len(transport.Producers))
+		consumers := make([]ConsumerSnapshot, 0, len(transport.Consumers))
+
+		for _, producer := range transport.Producers {
+			producer.AppData = redactor.Redact(producer.AppData)
+			producers = append(producers, producer)
+		}
+		for _, consumer := range transport.Consumers {
+			consumer.AppData = redactor.Redact(consumer.AppData)
+			consumers = append(consumers, consumer)
+		}
+
+		transport.AppData = redactor.Redact(transport.AppData)
+		transport.Producers = producers
+		transport.Consumers = consumers
+		transports = append(transports, transport)
+	}
+
+	snapshot.Transports = transports
+
+	return snapshot
+}
+
+// LoadRouterSnapshot creates in the given Worker a Router equivalent to the
+// one described by the snapshot, with the same settings. Transports are not
+// connected, so no media flows. Producers keep their ids, while Transports,
+// Consumers and RtpObservers get new ones. WebRtcTransports created on a
+// WebRtcServer are loaded on the WebRtcServer of the Worker listening on the
+// same IPs and protocols, else on a new one listening on them on free ports.
+func LoadRouterSnapshot(worker *Worker, snapshot RouterSnapshot) (router *Router, err error) {
+	router, _, err = loadRouterSnapshot(worker, snapshot)
+
+	return
+}
+
+// loadRouterSnapshot loads the snapshot as LoadRouterSnapshot, also returning
+// the ids of the new Consumers by snapshot Consumer id.
+func loadRouterSnapshot(
+	worker *Worker, snapshot RouterSnapshot,
+) (router *Router, consumerIds map[string]string, err error) {
+	template, err := NewRouterTemplate(snapshot.MediaCodecs)
+	if err != nil {
+		return
+	}
+
+	router, err = worker.createRouter(template, snapshot.Settings, nil)
+	if err != nil {
+		return
+	}
+
+	transports := make([]Transport, len(snapshot.Transports))
+	webRtcServers := &snapshotWebRtcServers{worker: worker}
+
+	defer func() {
+		if err != nil {
+			router.Close()
+			router, consumerIds = nil, nil
+			webRtcServers.closeCreated()
+		}
+	}()
+
+	for i, transportSnapshot := range snapshot.Transports {
+		if transports[i], err = loadTransportSnapshot(router, webRtcServers, transportSnapshot); err != nil {
+			return
+		}
+
+		for _, producerSnapshot := range transportSnapshot.Producers {
+			_, err = transports[i].Produce(TransportProduceParams{
+				Id:            producerSnapshot.Id,
+				Kind:          producerSnapshot.Kind,
+				RtpParameters: producerSnapshot.RtpParameters,
+				Paused:        producerSnapshot.Paused,
+				AppData:       producerSnapshot.AppData,
+			})
+			if err != nil {
+				err = fmt.Errorf(`cannot load Producer "%s": %s`, producerSnapshot.Id, err)
+				return
+			}
+		}
+	}
+
+	// Consumers and RtpObservers are created once every Producer exists.
+	consumerIds = make(map[string]string)
+
+	for i, transportSnapshot := range snapshot.Transports {
+		for _, consumerSnapshot := range transportSnapshot.Consumers {
+			consumer, err := consumeSnapshot(transports[i], consumerSnapshot, nil)
+			if err != nil {
+				return nil, nil, fmt.Errorf(`cannot load Consumer "%s": %s`, consumerSnapshot.Id, err)
+			}
+
+			consumerIds[consumerSnapshot.Id] = consumer.Id()
+		}
+	}
+
+	for _, rtpObserverSnapshot := range snapshot.RtpObservers {
+		if err = loadRtpObserverSnapshot(router, rtpObserverSnapshot); err != nil {
+			err = fmt.Errorf(`cannot load RtpObserver "%s": %s`, rtpObserverSnapshot.Id, err)
+			return
+		}
+	}
+
+	return
+}
+
+// consumeSnapshot creates with the given Transport a Consumer equivalent to the
+// one described by the snapshot.
+func consumeSnapshot(
+	transport Transport, snapshot ConsumerSnapshot, onProducerClose ProducerCloseHook,
+) (consumer *Consumer, err error) {
+	headerExtensions := make([]string, 0, len(snapshot.RtpParameters.HeaderExtensions))
+
+	for _, ext := range snapshot.RtpParameters.HeaderExtensions {
+		headerExtensions = append(headerExtensions, ext.Uri)
+	}
+
+	consumer, err = transport.Consume(TransportConsumeParams{
+		ProducerId:              snapshot.ProducerId,
+		RtpCapabilities:         rtpCapabilitiesFromParameters(snapshot.Kind, snapshot.RtpParameters),
+		Paused:                  snapshot.Paused,
+		AppData:                 snapshot.AppData,
+		Mid:                     snapshot.RtpParameters.Mid,
+		EnabledHeaderExtensions: headerExtensions,
+		OnProducerClose:         onProducerClose,
+	})
+	if err != nil {
+		return
+	}
+
+	if layers := snapshot.PreferredLayers; layers != nil {
+		err = consumer.SetPreferredLayers(layers.SpatialLayer, layers.TemporalLayer)
+	}
+	if err == nil && snapshot.MaxBitrate > 0 {
+		_, err = consumer.SetMaxBitrate(snapshot.MaxBitrate)
+	}
+	if err != nil {
+		consumer.Close()
+		consumer = nil
+	}
+
+	return
+}
+
+func loadRtpObserverSnapshot(router *Router, snapshot RtpObserverSnapshot) (err error) {
+	if snapshot.Type != "audiolevel" {
+		return NewTypeError(`invalid RtpObserver type "%s"`, snapshot.Type)
+	}
+
+	var params CreateAudioLevelObserverParams
+	if err = json.Unmarshal(snapshot.Options, &params); err != nil {
+		return
+	}
+
+	rtpObserver, err := router.CreateAudioLevelObserver(&params)
+	if err != nil {
+		return
+	}
+
+	for _, producerId := range snapshot.ProducerIds {
+		rtpObserver.AddProducer(producerId)
+	}
+
+	if snapshot.Paused {
+		rtpObserver.Pause()
+	}
+
+	return
+}
+
+func loadTransportSnapshot(router *Router, webRtcServers *snapshotWebRtcServers, snapshot TransportSnapshot) (transport Transport, err error) {
+	switch snapshot.Type {
+	case "webrtc":
+		var params CreateWebRtcTransportParams
+		if err = json.Unmarshal(snapshot.Options, &params); err != nil {
+			return
+		}
+		params.AppData = snapshot.AppData
+
+		if len(snapshot.WebRtcServerListenInfos) > 0 {
+			if params.WebRtcServer, err = webRtcServers.get(snapshot.WebRtcServerListenInfos); err != nil {
+				return
+			}
+		}
+
+		return router.CreateWebRtcTransport(params)
+
+	case "plain":
+		var params CreatePlainRtpTransportParams
+		if err = json.Unmarshal(snapshot.Options, &params); err != nil {
+			return
+		}
+		params.AppData = snapshot.AppData
+
+		return router.CreatePlainRtpTransport(params)
+
+	case "pipe":
+		var params CreatePipeTransportParams
+		if err = json.Unmarshal(snapshot.Options, &params); err != nil {
+			return
+		}
+		params.AppData = snapshot.AppData
+
+		return router.CreatePipeTransport(params)
+
+	default:
+		err = NewTypeError(`invalid transport type "%s"`, snapshot.Type)
+		return
+	}
+}
+
+// snapshotWebRtcServers finds the WebRtcServers of the Worker a snapshot is
+// loaded in, creating the missing ones.
+type snapshotWebRtcServers struct {
+	worker  *Worker
+	created []*WebRtcServer
+}
+
+// get returns the WebRtcServer listening on the same IPs and protocols as the
+// given listen infos, whatever the ports.
+func (s *snapshotWebRtcServers) get(listenInfos []WebRtcServerListenInfo) (server *WebRtcServer, err error) {
+	for _, server := range append(s.worker.getWebRtcServers(), s.created...) {
+		if !server.Closed() && sameListenInfosButPorts(server.listenInfos, listenInfos) {
+			return server, nil
+		}
+	}
+
+	freeListenInfos := make([]WebRtcServerListenInfo, len(listenInfos))
+
+	for i, listenInfo := range listenInfos {
+		listenInfo.Port = 0
+		freeListenInfos[i] = listenInfo
+	}
+
+	if server, err = s.worker.CreateWebRtcServer(CreateWebRtcServerParams{ListenInfos: freeListenInfos}); err != nil {
+		return
+	}
+
+	s.created = append(s.created, server)
+
+	return
+}
+
+func (s *snapshotWebRtcServers) closeCreated() {
+	for _, server := range s.created {
+		server.Close()
+	}
+}
+
+func sameListenInfosButPorts(a, b []WebRtcServerListenInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Protocol != b[i].Protocol || a[i].Ip != b[i].Ip || a[i].AnnouncedIp != b[i].AnnouncedIp {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rtpCapabilitiesFromParameters returns the minimal RTP capabilities which
+// produce the given Consumer RTP parameters.
+func rtpCapabilitiesFromParameters(kind string, params RtpParameters) (caps RtpCapabilities) {
+	for _, codec := range params.Codecs {
+		codec.Kind = kind
+		codec.PreferredPayloadType = codec.PayloadType
+		codec.PayloadType = 0
+
+		caps.Codecs = append(caps.Codecs, codec)
+	}
+
+	for _, ext := range params.HeaderExtensions {
+		caps.HeaderExtensions = append(caps.HeaderExtensions, RtpHeaderExtension{
+			Kind:        kind,
+			Uri:         ext.Uri,
+			PreferredId: ext.Id,
+		})
+	}
+
+	return
+}
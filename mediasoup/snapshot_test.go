@@ -0,0 +1,224 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterSnapshot_LoadIntoFreshWorker(t *testing.T) {
+	ns := setupPipeTest(t)
+
+	consumer, err := ns.transport1.Consume(TransportConsumeParams{
+		ProducerId:      ns.audioProducer.Id(),
+		RtpCapabilities: consumerDeviceCapabilities,
+		Paused:          true,
+	})
+	assert.NoError(t, err)
+
+	snapshot, err := ns.router1.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, ns.router1.Id(), snapshot.Id)
+	assert.Len(t, snapshot.Transports, 1)
+	assert.Len(t, snapshot.Transports[0].Producers, 2)
+	assert.Len(t, snapshot.Transports[0].Consumers, 1)
+	assert.Equal(t, consumer.Id(), snapshot.Transports[0].Consumers[0].Id)
+
+	data, err := json.Marshal(snapshot)
+	assert.NoError(t, err)
+
+	var loadedSnapshot RouterSnapshot
+	assert.NoError(t, json.Unmarshal(data, &loadedSnapshot))
+
+	freshWorker := CreateTestWorker()
+	defer freshWorker.Close()
+
+	router, err := LoadRouterSnapshot(freshWorker, loadedSnapshot)
+	assert.NoError(t, err)
+
+	reloadedSnapshot, err := router.Snapshot()
+	assert.NoError(t, err)
+	assert.Len(t, reloadedSnapshot.Transports, 1)
+	assert.Equal(t, "webrtc", reloadedSnapshot.Transports[0].Type)
+	assert.Len(t, reloadedSnapshot.Transports[0].Producers, 2)
+	assert.Len(t, reloadedSnapshot.Transports[0].Consumers, 1)
+
+	for i, producer := range snapshot.Transports[0].Producers {
+		reloadedProducer := reloadedSnapshot.Transports[0].Producers[i]
+
+		assert.Equal(t, producer.Id, reloadedProducer.Id)
+		assert.Equal(t, producer.Paused, reloadedProducer.Paused)
+	}
+
+	reloadedConsumer := reloadedSnapshot.Transports[0].Consumers[0]
+	assert.Equal(t, ns.audioProducer.Id(), reloadedConsumer.ProducerId)
+	assert.True(t, reloadedConsumer.Paused)
+	assert.Equal(t, consumer.RtpParameters().Codecs, reloadedConsumer.RtpParameters.Codecs)
+}
+
+func TestRouterSnapshot_WebRtcServer(t *testing.T) {
+	newWorker := func() *Worker {
+		return &Worker{
+			logger:        TypeLogger("Worker"),
+			channel:       newFakeWorker(t).channel,
+			observer:      NewEventEmitter(AppLogger()),
+			routers:       make(map[string]*Router),
+			webRtcServers: make(map[string]*WebRtcServer),
+		}
+	}
+	listenInfos := func(port uint16) []WebRtcServerListenInfo {
+		return []WebRtcServerListenInfo{{Protocol: "udp", ListenIp: ListenIp{Ip: "127.0.0.1"}, Port: port}}
+	}
+
+	worker := newWorker()
+	server, err := worker.CreateWebRtcServer(CreateWebRtcServerParams{ListenInfos: listenInfos(44444)})
+	require.NoError(t, err)
+	router, err := worker.CreateRouter(testRouterMediaCodecs)
+	require.NoError(t, err)
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{WebRtcServer: server, EnableUdp: true})
+	require.NoError(t, err)
+
+	snapshot, err := router.Snapshot()
+	require.NoError(t, err)
+	require.Len(t, snapshot.Transports, 1)
+	assert.Equal(t, listenInfos(44444), snapshot.Transports[0].WebRtcServerListenInfos)
+
+	// Loaded on the WebRtcServer of the Worker listening on the same IPs.
+	otherWorker := newWorker()
+	otherServer, err := otherWorker.CreateWebRtcServer(CreateWebRtcServerParams{ListenInfos: listenInfos(44445)})
+	require.NoError(t, err)
+
+	loaded, err := LoadRouterSnapshot(otherWorker, snapshot)
+	require.NoError(t, err)
+	require.Len(t, loaded.getTransports(), 1)
+	assert.True(t, otherServer.hasWebRtcTransport(loaded.getTransports()[0].Id()))
+	assert.Len(t, otherWorker.getWebRtcServers(), 1)
+
+	// Else on a new one listening on free ports.
+	freshWorker := newWorker()
+
+	loaded, err = LoadRouterSnapshot(freshWorker, snapshot)
+	require.NoError(t, err)
+	require.Len(t, freshWorker.getWebRtcServers(), 1)
+
+	freshServer := freshWorker.getWebRtcServers()[0]
+	assert.Equal(t, listenInfos(0), freshServer.listenInfos)
+	assert.True(t, freshServer.hasWebRtcTransport(loaded.getTransports()[0].Id()))
+}
+
+func TestRouterSnapshot_SettingsObserversAndLayers(t *testing.T) {
+	newWorker := func() (*Worker, *fakeWorker) {
+		fake := newFakeWorker(t)
+		fake.reply("transport.produce", func(request fakeWorkerRequest) (interface{}, error) {
+			return H{"type": "simple"}, nil
+		})
+
+		return &Worker{
+			logger:        TypeLogger("Worker"),
+			channel:       fake.channel,
+			observer:      NewEventEmitter(AppLogger()),
+			routers:       make(map[string]*Router),
+			webRtcServers: make(map[string]*WebRtcServer),
+		}, fake
+	}
+
+	worker, _ := newWorker()
+	router, err := worker.CreateRouter(testRouterMediaCodecs, WithRouterE2ee())
+	require.NoError(t, err)
+
+	transport, err := router.CreatePlainRtpTransport(CreatePlainRtpTransportParams{
+		ListenIp: ListenIp{Ip: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	producer, err := transport.Produce(TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	})
+	require.NoError(t, err)
+
+	consumer, err := transport.Consume(TransportConsumeParams{
+		ProducerId:      producer.Id(),
+		RtpCapabilities: router.RtpCapabilities(),
+		Mid:             "audio1",
+	})
+	require.NoError(t, err)
+	require.NoError(t, consumer.SetPreferredLayers(1, 0))
+
+	rtpObserver, err := router.CreateAudioLevelObserver(&CreateAudioLevelObserverParams{
+		MaxEntries: 2, Threshold: -70, Interval: 500,
+	})
+	require.NoError(t, err)
+	rtpObserver.AddProducer(producer.Id())
+	rtpObserver.Pause()
+
+	snapshot, err := router.Snapshot()
+	require.NoError(t, err)
+	assert.True(t, snapshot.Settings.E2ee)
+	require.Len(t, snapshot.RtpObservers, 1)
+	assert.Equal(t, []string{producer.Id()}, snapshot.RtpObservers[0].ProducerIds)
+	assert.True(t, snapshot.RtpObservers[0].Paused)
+	require.Len(t, snapshot.Transports[0].Consumers, 1)
+	assert.Equal(t, &ConsumerPreferredLayers{SpatialLayer: 1}, snapshot.Transports[0].Consumers[0].PreferredLayers)
+
+	otherWorker, fake := newWorker()
+
+	loaded, consumerIds, err := loadRouterSnapshot(otherWorker, snapshot)
+	require.NoError(t, err)
+	assert.True(t, loaded.data.Settings.E2ee)
+	require.Contains(t, consumerIds, consumer.Id())
+
+	loadedConsumer := loaded.getTransports()[0].(*PlainRtpTransport).consumers[consumerIds[consumer.Id()]]
+	require.NotNil(t, loadedConsumer)
+	assert.Equal(t, "audio1", loadedConsumer.RtpParameters().Mid)
+	assert.Equal(t, &VideoLayer{SpatialLayer: 1}, loadedConsumer.PreferredLayers())
+
+	require.Len(t, loaded.getRtpObservers(), 1)
+	loadedObserver := loaded.getRtpObservers()[0].(*AudioLevelObserver)
+	assert.True(t, loadedObserver.Paused())
+	assert.Equal(t, []string{producer.Id()}, loadedObserver.getProducerIds())
+
+	var params CreateAudioLevelObserverParams
+	for _, request := range fake.requested() {
+		if request.Method == "router.createAudioLevelObserver" {
+			require.NoError(t, json.Unmarshal(request.Data, &params))
+		}
+	}
+	assert.Equal(t, CreateAudioLevelObserverParams{MaxEntries: 2, Threshold: -70, Interval: 500}, params)
+}
+
+func TestRouterSnapshot_Redacted(t *testing.T) {
+	redactor, err := NewAppDataRedactor(WithSensitiveAppDataKeys("userId"))
+	assert.NoError(t, err)
+
+	snapshot := RouterSnapshot{
+		Id: "router",
+		Transports: []TransportSnapshot{
+			{
+				Id:        "transport",
+				AppData:   H{"userId": "u1"},
+				Producers: []ProducerSnapshot{{Id: "producer", AppData: H{"userId": "u2"}}},
+				Consumers: []ConsumerSnapshot{{Id: "consumer", AppData: H{"userId": "u3"}}},
+			},
+		},
+	}
+
+	redacted := snapshot.Redacted(redactor)
+
+	redactedAppData := map[string]interface{}{"userId": RedactedAppDataValue}
+	assert.Equal(t, redactedAppData, redacted.Transports[0].AppData)
+	assert.Equal(t, redactedAppData, redacted.Transports[0].Producers[0].AppData)
+	assert.Equal(t, redactedAppData, redacted.Transports[0].Consumers[0].AppData)
+
+	// The snapshot itself, to be loaded back, is not modified.
+	assert.Equal(t, H{"userId": "u1"}, snapshot.Transports[0].AppData)
+	assert.Equal(t, H{"userId": "u2"}, snapshot.Transports[0].Producers[0].AppData)
+	assert.Equal(t, H{"userId": "u3"}, snapshot.Transports[0].Consumers[0].AppData)
+}
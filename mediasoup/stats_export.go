@@ -0,0 +1,838 @@
+package mediasoup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Formats of the batches of a StatsExporter.
+const (
+	// OpenMetrics text format, for Prometheus compatible storages.
+	StatsFormatOpenMetrics = "openmetrics"
+	// InfluxDB line protocol.
+	StatsFormatLineProtocol = "lineprotocol"
+)
+
+// Aggregation levels of the StatsExporter, from the most detailed one.
+const (
+	// A series per stream of each Transport, Producer and Consumer.
+	StatsAggregationEntity = "entity"
+	// A series per Transport, its Producers and Consumers being aggregated.
+	StatsAggregationTransport = "transport"
+	// A series per Router.
+	StatsAggregationRouter = "router"
+	// A series per Worker (with WatchWorker).
+	StatsAggregationWorker = "worker"
+)
+
+// Labels dropped by the aggregation levels, each level dropping the labels of
+// the more detailed ones too.
+var statsAggregationLabels = []struct {
+	level  string
+	labels []string
+}{
+	{StatsAggregationTransport, []string{"producerId", "consumerId", "rid", "ssrc"}},
+	{StatsAggregationRouter, []string{"transportId"}},
+	{StatsAggregationWorker, []string{"routerId"}},
+}
+
+// StatsExporterOptions to export the stats.
+type StatsExporterOptions struct {
+	// StatsFormatOpenMetrics or StatsFormatLineProtocol.
+	Format string
+	// The stats are sampled every SampleInterval, and downsampled into a
+	// batch every Resolution: the counters (packetCount, bytesSent...) with
+	// their last value, the other values with their mean.
+	SampleInterval time.Duration
+	Resolution     time.Duration
+	// Labels (tags) of every metric.
+	Labels map[string]string
+	// Top level appData keys of the entities exported as labels, the values
+	// being formatted with fmt.
+	AppDataLabels []string
+	// Redactor of the appData labels. The sensitive ones are exported as
+	// RedactedAppDataValue, never encrypted, not to multiply the series.
+	AppDataRedactor *AppDataRedactor
+	// Writer of the batches, if any. The batches are emitted anyway.
+	Writer io.Writer
+	// Aggregation level (StatsAggregationEntity by default), limiting the
+	// cardinality of the series: the additive values (counters, bitrates)
+	// of the aggregated entities are summed, the others averaged. The
+	// counters sum the increases of the entities, not to decrease when some
+	// of them close.
+	Aggregation string
+	// Labels kept, all if empty.
+	LabelAllowList []string
+	// Rules of the quality alerts, evaluated at every sample.
+	QualityAlertRules []QualityAlertRule
+}
+
+type StatsExporterOption func(o *StatsExporterOptions)
+
+func WithStatsFormat(format string) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.Format = format
+	}
+}
+
+func WithStatsResolution(sampleInterval, resolution time.Duration) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.SampleInterval = sampleInterval
+		o.Resolution = resolution
+	}
+}
+
+func WithStatsLabels(labels map[string]string, appDataLabels ...string) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.Labels = labels
+		o.AppDataLabels = appDataLabels
+	}
+}
+
+func WithStatsAppDataRedactor(redactor *AppDataRedactor) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.AppDataRedactor = redactor
+	}
+}
+
+func WithStatsAggregation(level string) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.Aggregation = level
+	}
+}
+
+func WithStatsLabelAllowList(labels ...string) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.LabelAllowList = labels
+	}
+}
+
+func WithStatsWriter(writer io.Writer) StatsExporterOption {
+	return func(o *StatsExporterOptions) {
+		o.Writer = writer
+	}
+}
+
+// StatsExporter samples the stats of the watched Transports, Producers and
+// Consumers, and exports them downsampled, so that long-term call quality
+// storages don't need custom adapters.
+type StatsExporter struct {
+	EventEmitter
+	logger  logrus.FieldLogger
+	options StatsExporterOptions
+	// Labels dropped by the aggregation level, and kept by the allow-list.
+	droppedLabels map[string]bool
+	allowedLabels map[string]bool
+	mu            sync.Mutex
+	sources       map[string]*statsSource
+	// Aggregates of the current batch, by series key.
+	series map[string]*statsSeries
+	// States of the quality alerts, by Transport id and rule name.
+	alerts map[string]*qualityAlertState
+	stopCh chan struct{}
+	// Totals of the counters by series key and field, accumulating the
+	// increases of the entities so that they don't decrease when aggregated
+	// entities close.
+	counterTotals map[string]map[string]float64
+}
+
+// statsSource is a watched entity.
+type statsSource struct {
+	// "transport", "producer" or "consumer".
+	kind     string
+	labels   map[string]string
+	getStats func() ([]map[string]interface{}, error)
+	// Last values of the counters, by stats entry and field.
+	counters map[string]float64
+}
+
+// statsSeries aggregates the values of an entity (and of a stats entry of
+// it, e.g. an encoding of a Producer) over the batch.
+type statsSeries struct {
+	kind   string
+	labels map[string]string
+	values map[string]*statsAggregate
+}
+
+type statsAggregate struct {
+	counter bool
+	sum     float64
+	count   int
+	last    float64
+}
+
+func (a *statsAggregate) value() float64 {
+	if a.counter {
+		return a.last
+	}
+
+	return a.sum / float64(a.count)
+}
+
+/**
+ * NewStatsExporter
+ *
+ * @emits {batch: []byte} batch
+ * @emits {alert: QualityAlert} qualityalert
+ * @emits {alert: QualityAlert} qualityrecovered
+ */
+func NewStatsExporter(options ...StatsExporterOption) (*StatsExporter, error) {
+	logger := TypeLogger("StatsExporter")
+
+	logger.Debug("constructor()")
+
+	opts := StatsExporterOptions{
+		Format:         StatsFormatOpenMetrics,
+		SampleInterval: 10 * time.Second,
+		Resolution:     time.Minute,
+		Aggregation:    StatsAggregationEntity,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.Format != StatsFormatOpenMetrics && opts.Format != StatsFormatLineProtocol {
+		return nil, NewValidationError("StatsExporterOptions.Format", `unknown format "%s"`, opts.Format)
+	}
+	if opts.SampleInterval <= 0 {
+		return nil, NewValidationError("StatsExporterOptions.SampleInterval", "must be positive")
+	}
+	if opts.Resolution < opts.SampleInterval {
+		return nil, NewValidationError("StatsExporterOptions.Resolution", "must not be less than SampleInterval (%s)", opts.SampleInterval)
+	}
+	if err := validateQualityAlertRules(opts.QualityAlertRules); err != nil {
+		return nil, err
+	}
+
+	droppedLabels := make(map[string]bool)
+
+	if opts.Aggregation != StatsAggregationEntity {
+		known := false
+
+		for _, level := range statsAggregationLabels {
+			for _, label := range level.labels {
+				droppedLabels[label] = true
+			}
+			if level.level == opts.Aggregation {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, NewValidationError("StatsExporterOptions.Aggregation", `unknown level "%s"`, opts.Aggregation)
+		}
+	}
+
+	var allowedLabels map[string]bool
+
+	if len(opts.LabelAllowList) > 0 {
+		allowedLabels = make(map[string]bool, len(opts.LabelAllowList))
+
+		for _, label := range opts.LabelAllowList {
+			allowedLabels[label] = true
+		}
+	}
+
+	return &StatsExporter{
+		EventEmitter:  NewEventEmitter(logger),
+		logger:        logger,
+		options:       opts,
+		droppedLabels: droppedLabels,
+		allowedLabels: allowedLabels,
+		sources:       make(map[string]*statsSource),
+		series:        make(map[string]*statsSeries),
+		alerts:        make(map[string]*qualityAlertState),
+		counterTotals: make(map[string]map[string]float64),
+	}, nil
+}
+
+// WatchWorker exports the stats of the entities of the given Worker, labeled
+// with its "workerPid".
+func (e *StatsExporter) WatchWorker(worker *Worker) {
+	e.visitor(map[string]string{"workerPid": strconv.Itoa(worker.Pid())}).WalkWorker(worker)
+}
+
+// WatchRouter exports the stats of the entities of the given Router.
+func (e *StatsExporter) WatchRouter(router *Router) {
+	e.visitor(nil).WalkRouter(router)
+}
+
+// WatchTransport exports the stats of the given Transport and of its
+// Producers and Consumers.
+func (e *StatsExporter) WatchTransport(routerId string, transport Transport) {
+	e.visitor(map[string]string{"routerId": routerId}).WalkTransport(nil, transport)
+}
+
+// visitor returns the visitor adding the sources of the entities, labeled
+// with the given parent labels.
+func (e *StatsExporter) visitor(parentLabels map[string]string) EntityVisitor {
+	entityLabels := func(router *Router, labels map[string]string) map[string]string {
+		for name, value := range parentLabels {
+			labels[name] = value
+		}
+		if router != nil {
+			labels["routerId"] = router.Id()
+		}
+		return labels
+	}
+
+	return EntityVisitor{
+		Transport: func(router *Router, transport Transport) {
+			e.addSource(transport.Id(), transport.Observer(), &statsSource{
+				kind: "transport",
+				labels: e.labels(transport.AppData(), entityLabels(router, map[string]string{
+					"transportId": transport.Id(),
+				})),
+				getStats: func() ([]map[string]interface{}, error) {
+					stats, err := transport.GetStats()
+					if err != nil {
+						return nil, err
+					}
+					return statsMaps(stats)
+				},
+			})
+		},
+		Producer: func(router *Router, transport Transport, producer *Producer) {
+			e.addSource(producer.Id(), producer.Observer(), &statsSource{
+				kind: "producer",
+				labels: e.labels(producer.AppData(), entityLabels(router, map[string]string{
+					"transportId": transport.Id(),
+					"producerId":  producer.Id(),
+				})),
+				getStats: func() (stats []map[string]interface{}, err error) {
+					err = producer.GetStats().Unmarshal(&stats)
+					return
+				},
+			})
+		},
+		Consumer: func(router *Router, transport Transport, consumer *Consumer) {
+			e.addSource(consumer.Id(), consumer.Observer(), &statsSource{
+				kind: "consumer",
+				labels: e.labels(consumer.AppData(), entityLabels(router, map[string]string{
+					"transportId": transport.Id(),
+					"consumerId":  consumer.Id(),
+					"producerId":  consumer.ProducerId(),
+				})),
+				getStats: func() (stats []map[string]interface{}, err error) {
+					err = consumer.GetStats().Unmarshal(&stats)
+					return
+				},
+			})
+		},
+	}
+}
+
+// labels returns the labels of an entity, with the static and appData ones.
+func (e *StatsExporter) labels(appData interface{}, labels map[string]string) map[string]string {
+	for name, value := range e.options.Labels {
+		labels[name] = value
+	}
+	for _, key := range e.options.AppDataLabels {
+		if value, ok := AppDataValue(appData, key); ok && value != nil {
+			if e.options.AppDataRedactor.IsSensitive(key) {
+				labels[key] = RedactedAppDataValue
+			} else {
+				labels[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	return labels
+}
+
+// addSource watches the given source until the close of its entity.
+func (e *StatsExporter) addSource(id string, observer EventEmitter, source *statsSource) {
+	e.mu.Lock()
+	e.sources[id] = source
+	e.mu.Unlock()
+
+	observer.On("close", func() {
+		e.mu.Lock()
+		delete(e.sources, id)
+		e.mu.Unlock()
+
+		if source.kind == "transport" {
+			e.removeQualityAlerts(id)
+		}
+	})
+}
+
+// Start sampling the stats, and exporting them every Resolution.
+func (e *StatsExporter) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stopCh != nil {
+		return
+	}
+
+	stopCh := make(chan struct{})
+	e.stopCh = stopCh
+
+	spawn("statsExporter", func() {
+		ticker := time.NewTicker(e.options.SampleInterval)
+		defer ticker.Stop()
+
+		batchStart := time.Now()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				e.sample(now)
+
+				if now.Sub(batchStart) >= e.options.Resolution {
+					e.flush(now)
+					batchStart = now
+				}
+			}
+		}
+	})
+}
+
+// Stop sampling the stats, the pending batch being exported.
+func (e *StatsExporter) Stop() {
+	e.mu.Lock()
+	stopCh := e.stopCh
+	e.stopCh = nil
+	e.mu.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+
+	close(stopCh)
+
+	e.flush(time.Now())
+}
+
+// sample adds the current stats of the sources to the batch, and evaluates
+// the quality alerts.
+func (e *StatsExporter) sample(now time.Time) {
+	e.mu.Lock()
+	sources := make([]*statsSource, 0, len(e.sources))
+	for _, source := range e.sources {
+		sources = append(sources, source)
+	}
+	e.mu.Unlock()
+
+	metrics := make(map[string]*qualityMetrics)
+	sampled := make(statsSample)
+
+	for _, source := range sources {
+		stats, err := source.getStats()
+		if err != nil {
+			e.logger.Debugf("getStats() failed: %s", err)
+			continue
+		}
+
+		transportId := source.labels["transportId"]
+
+		m, ok := metrics[transportId]
+		if !ok {
+			m = &qualityMetrics{
+				routerId: source.labels["routerId"],
+				values:   make(map[string]float64),
+			}
+			metrics[transportId] = m
+		}
+		if source.kind == "transport" {
+			m.labels = source.labels
+		}
+
+		for _, stat := range stats {
+			e.add(sampled, source, stat)
+			m.addStat(source.kind, stat)
+		}
+	}
+
+	e.commit(sampled)
+
+	if len(e.options.QualityAlertRules) > 0 {
+		e.evaluateQualityAlerts(now, metrics)
+	}
+}
+
+// Fields of the stats exported as labels, to tell the entries apart.
+var statsLabelFields = []string{"type", "kind", "mimeType", "rid", "ssrc"}
+
+// statsSample accumulates the stats of a sample by series key, the values of
+// the entities aggregated in a series being combined.
+type statsSample map[string]*sampledSeries
+
+type sampledSeries struct {
+	kind   string
+	labels map[string]string
+	values map[string]*sampledValue
+}
+
+type sampledValue struct {
+	sum   float64
+	count int
+	// Increase of the counters since the previous sample.
+	delta float64
+}
+
+// add adds the stat of the source to the sample, with the labels kept by the
+// aggregation level and the allow-list.
+func (e *StatsExporter) add(sample statsSample, source *statsSource, stat map[string]interface{}) {
+	labels := make(map[string]string, len(source.labels)+len(statsLabelFields))
+
+	for name, value := range source.labels {
+		labels[name] = value
+	}
+	for _, field := range statsLabelFields {
+		if value, ok := stat[field]; ok && value != nil {
+			labels[field] = fmt.Sprint(value)
+		}
+	}
+
+	// The stats entry of the source, before the aggregation.
+	entryKey := statsLabelsKey(labels)
+
+	for name := range labels {
+		if e.droppedLabels[name] || (e.allowedLabels != nil && !e.allowedLabels[name]) {
+			delete(labels, name)
+		}
+	}
+
+	key := source.kind + statsLabelsKey(labels)
+
+	series, ok := sample[key]
+	if !ok {
+		series = &sampledSeries{
+			kind:   source.kind,
+			labels: labels,
+			values: make(map[string]*sampledValue),
+		}
+		sample[key] = series
+	}
+
+	for field, value := range stat {
+		number, ok := value.(float64)
+		if !ok || field == "timestamp" || field == "ssrc" || field == "rtxSsrc" {
+			continue
+		}
+
+		sampled, ok := series.values[field]
+		if !ok {
+			sampled = &sampledValue{}
+			series.values[field] = sampled
+		}
+
+		sampled.sum += number
+		sampled.count++
+
+		if isStatsCounter(field) {
+			if source.counters == nil {
+				source.counters = make(map[string]float64)
+			}
+
+			counterKey := entryKey + "/" + field
+			delta := number - source.counters[counterKey]
+
+			// The counters of an entity only decrease when reset.
+			if delta < 0 {
+				delta = number
+			}
+			source.counters[counterKey] = number
+			sampled.delta += delta
+		}
+	}
+}
+
+// commit adds the sample to the batch.
+func (e *StatsExporter) commit(sample statsSample) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, sampled := range sample {
+		totals, ok := e.counterTotals[key]
+		if !ok {
+			totals = make(map[string]float64)
+			e.counterTotals[key] = totals
+		}
+
+		series, ok := e.series[key]
+		if !ok {
+			series = &statsSeries{
+				kind:   sampled.kind,
+				labels: sampled.labels,
+				values: make(map[string]*statsAggregate),
+			}
+			e.series[key] = series
+		}
+
+		for field, value := range sampled.values {
+			number := value.sum
+			if isStatsCounter(field) {
+				totals[field] += value.delta
+				number = totals[field]
+			} else if !isStatsAdditive(field) {
+				number /= float64(value.count)
+			}
+
+			aggregate, ok := series.values[field]
+			if !ok {
+				aggregate = &statsAggregate{counter: isStatsCounter(field)}
+				series.values[field] = aggregate
+			}
+
+			aggregate.sum += number
+			aggregate.count++
+			aggregate.last = number
+		}
+	}
+}
+
+// flush exports the batch, stamped with the given time.
+func (e *StatsExporter) flush(now time.Time) {
+	e.mu.Lock()
+	series := e.series
+	e.series = make(map[string]*statsSeries)
+
+	// Forget the counters of the series not sampled during the batch.
+	for key := range e.counterTotals {
+		if _, ok := series[key]; !ok {
+			delete(e.counterTotals, key)
+		}
+	}
+	e.mu.Unlock()
+
+	if len(series) == 0 {
+		return
+	}
+
+	var batch []byte
+
+	if e.options.Format == StatsFormatLineProtocol {
+		batch = formatLineProtocol(series, now)
+	} else {
+		batch = formatOpenMetrics(series, now)
+	}
+
+	if e.options.Writer != nil {
+		if _, err := e.options.Writer.Write(batch); err != nil {
+			e.logger.Errorf("write batch failed: %s", err)
+		}
+	}
+
+	e.SafeEmit("batch", batch)
+}
+
+// isStatsCounter returns whether the given stats field is monotonic.
+func isStatsCounter(field string) bool {
+	return strings.HasSuffix(field, "Count") ||
+		strings.HasPrefix(field, "packets") ||
+		strings.HasPrefix(field, "bytes") ||
+		strings.Contains(field, "Bytes")
+}
+
+// isStatsAdditive returns whether the values of the given stats field are
+// summed when aggregating entities (counters, bitrates), or averaged.
+func isStatsAdditive(field string) bool {
+	return isStatsCounter(field) || strings.Contains(strings.ToLower(field), "bitrate")
+}
+
+func statsMaps(stats interface{}) (maps []map[string]interface{}, err error) {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &maps)
+
+	return
+}
+
+func statsLabelsKey(labels map[string]string) string {
+	var key strings.Builder
+
+	for _, name := range sortedStatsLabels(labels) {
+		fmt.Fprintf(&key, ",%s=%s", name, labels[name])
+	}
+
+	return key.String()
+}
+
+func sortedStatsLabels(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedStatsSeries(series map[string]*statsSeries) []*statsSeries {
+	keys := make([]string, 0, len(series))
+
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*statsSeries, 0, len(keys))
+
+	for _, key := range keys {
+		sorted = append(sorted, series[key])
+	}
+
+	return sorted
+}
+
+// statsMetricName returns the snake case metric name of a field, e.g.
+// "mediasoup_consumer_packet_count".
+func statsMetricName(kind, field string) string {
+	var name strings.Builder
+
+	name.WriteString("mediasoup_" + kind + "_")
+
+	for i, r := range field {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+
+	return name.String()
+}
+
+func formatStatsValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatOpenMetrics(series map[string]*statsSeries, now time.Time) []byte {
+	type sample struct {
+		labels string
+		value  float64
+	}
+	type family struct {
+		counter bool
+		samples []sample
+	}
+
+	families := make(map[string]*family)
+
+	for _, s := range sortedStatsSeries(series) {
+		var labels []string
+
+		for _, name := range sortedStatsLabels(s.labels) {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, name, openMetricsEscaper.Replace(s.labels[name])))
+		}
+
+		for field, aggregate := range s.values {
+			name := statsMetricName(s.kind, field)
+
+			f, ok := families[name]
+			if !ok {
+				f = &family{counter: aggregate.counter}
+				families[name] = f
+			}
+			f.samples = append(f.samples, sample{
+				labels: "{" + strings.Join(labels, ",") + "}",
+				value:  aggregate.value(),
+			})
+		}
+	}
+
+	names := make([]string, 0, len(families))
+
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	timestamp := strconv.FormatFloat(float64(now.UnixNano())/float64(time.Second), 'f', 3, 64)
+
+	var buf bytes.Buffer
+
+	for _, name := range names {
+		f := families[name]
+		metricType, suffix := "gauge", ""
+
+		if f.counter {
+			metricType, suffix = "counter", "_total"
+		}
+
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, metricType)
+
+		for _, sample := range f.samples {
+			fmt.Fprintf(&buf, "%s%s%s %s %s\n", name, suffix, sample.labels, formatStatsValue(sample.value), timestamp)
+		}
+	}
+
+	buf.WriteString("# EOF\n")
+
+	return buf.Bytes()
+}
+
+var (
+	lineProtocolMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	lineProtocolTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func formatLineProtocol(series map[string]*statsSeries, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	for _, s := range sortedStatsSeries(series) {
+		if len(s.values) == 0 {
+			continue
+		}
+
+		buf.WriteString(lineProtocolMeasurementEscaper.Replace("mediasoup_" + s.kind))
+
+		for _, name := range sortedStatsLabels(s.labels) {
+			if value := s.labels[name]; len(value) > 0 {
+				fmt.Fprintf(&buf, ",%s=%s",
+					lineProtocolTagEscaper.Replace(name), lineProtocolTagEscaper.Replace(value))
+			}
+		}
+
+		fields := make([]string, 0, len(s.values))
+
+		for field := range s.values {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for i, field := range fields {
+			separator := ","
+			if i == 0 {
+				separator = " "
+			}
+
+			aggregate := s.values[field]
+			value := aggregate.value()
+
+			if aggregate.counter && value == math.Trunc(value) {
+				fmt.Fprintf(&buf, "%s%s=%di", separator, lineProtocolTagEscaper.Replace(field), int64(value))
+			} else {
+				fmt.Fprintf(&buf, "%s%s=%s", separator, lineProtocolTagEscaper.Replace(field), formatStatsValue(value))
+			}
+		}
+
+		fmt.Fprintf(&buf, " %d\n", now.UnixNano())
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,257 @@
+package mediasoup
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsExporter(t *testing.T) {
+	_, err := NewStatsExporter(WithStatsFormat("csv"))
+	assertValidationError(t, "StatsExporterOptions.Format", err)
+
+	_, err = NewStatsExporter(WithStatsResolution(time.Minute, time.Second))
+	assertValidationError(t, "StatsExporterOptions.Resolution", err)
+
+	var buf bytes.Buffer
+
+	exporter, err := NewStatsExporter(
+		WithStatsLabels(map[string]string{"region": "eu"}, "roomId", "missing"),
+		WithStatsWriter(&buf),
+	)
+	require.NoError(t, err)
+
+	var batches [][]byte
+	exporter.On("batch", func(batch []byte) {
+		batches = append(batches, batch)
+	})
+
+	bitrates := []float64{1000, 3000}
+	sample := 0
+
+	observer := NewEventEmitter(AppLogger())
+	exporter.addSource("consumer", observer, &statsSource{
+		kind: "consumer",
+		labels: exporter.labels(H{"roomId": "room 1"}, map[string]string{
+			"consumerId": "c1",
+		}),
+		getStats: func() ([]map[string]interface{}, error) {
+			defer func() { sample++ }()
+
+			return []map[string]interface{}{
+				{
+					"type":        "outbound-rtp",
+					"ssrc":        float64(1111),
+					"timestamp":   float64(12345),
+					"mimeType":    "video/VP8",
+					"packetCount": float64(10 * (sample + 1)),
+					"bitrate":     bitrates[sample],
+				},
+			}, nil
+		},
+	})
+
+	exporter.sample(time.Now())
+	exporter.sample(time.Now())
+	exporter.flush(time.Unix(1700000000, 0))
+
+	expected := `# TYPE mediasoup_consumer_bitrate gauge
+mediasoup_consumer_bitrate{consumerId="c1",mimeType="video/VP8",region="eu",roomId="room 1",ssrc="1111",type="outbound-rtp"} 2000 1700000000.000
+# TYPE mediasoup_consumer_packet_count counter
+mediasoup_consumer_packet_count_total{consumerId="c1",mimeType="video/VP8",region="eu",roomId="room 1",ssrc="1111",type="outbound-rtp"} 20 1700000000.000
+# EOF
+`
+	require.Len(t, batches, 1)
+	assert.Equal(t, expected, string(batches[0]))
+	assert.Equal(t, expected, buf.String())
+
+	// Nothing sampled.
+	exporter.flush(time.Now())
+	assert.Len(t, batches, 1)
+
+	// Closed entities are not sampled anymore.
+	observer.Emit("close")
+	exporter.sample(time.Now())
+	assert.Empty(t, exporter.series)
+}
+
+func TestStatsExporter_LineProtocol(t *testing.T) {
+	exporter, err := NewStatsExporter(WithStatsFormat(StatsFormatLineProtocol))
+	require.NoError(t, err)
+
+	source := &statsSource{
+		kind:   "transport",
+		labels: map[string]string{"transportId": "t1", "room": "a,b c", "empty": ""},
+	}
+	sample := make(statsSample)
+	exporter.add(sample, source, map[string]interface{}{
+		"type":                     "webrtc-transport",
+		"bytesReceived":            float64(100),
+		"availableOutgoingBitrate": float64(300000),
+		"iceState":                 "completed",
+	})
+	exporter.commit(sample)
+
+	sample = make(statsSample)
+	exporter.add(sample, source, map[string]interface{}{
+		"type":                     "webrtc-transport",
+		"bytesReceived":            float64(200),
+		"availableOutgoingBitrate": float64(200001),
+	})
+	exporter.commit(sample)
+
+	var batch []byte
+	exporter.On("batch", func(b []byte) { batch = b })
+	exporter.flush(time.Unix(1700000000, 0))
+
+	assert.Equal(t,
+		`mediasoup_transport,room=a\,b\ c,transportId=t1,type=webrtc-transport `+
+			"availableOutgoingBitrate=250000.5,bytesReceived=200i 1700000000000000000\n",
+		string(batch))
+}
+
+func TestStatsExporter_Aggregation(t *testing.T) {
+	exporter, err := NewStatsExporter(
+		WithStatsFormat(StatsFormatLineProtocol),
+		WithStatsAggregation(StatsAggregationRouter),
+	)
+	require.NoError(t, err)
+
+	sample := make(statsSample)
+
+	for _, transportId := range []string{"t1", "t2"} {
+		exporter.add(sample, &statsSource{
+			kind:   "consumer",
+			labels: map[string]string{"routerId": "r1", "transportId": transportId, "consumerId": "c-" + transportId},
+		}, map[string]interface{}{
+			"type":      "outbound-rtp",
+			"kind":      "video",
+			"ssrc":      float64(1234),
+			"byteCount": float64(100),
+			"bitrate":   float64(1000),
+			"score":     float64(10),
+		})
+	}
+	exporter.add(sample, &statsSource{
+		kind:   "consumer",
+		labels: map[string]string{"routerId": "r1", "transportId": "t3", "consumerId": "c-t3"},
+	}, map[string]interface{}{
+		"type":      "outbound-rtp",
+		"kind":      "video",
+		"ssrc":      float64(5678),
+		"byteCount": float64(50),
+		"bitrate":   float64(500),
+		"score":     float64(4),
+	})
+	exporter.commit(sample)
+
+	var batch []byte
+	exporter.On("batch", func(b []byte) { batch = b })
+	exporter.flush(time.Unix(1700000000, 0))
+
+	assert.Equal(t,
+		"mediasoup_consumer,kind=video,routerId=r1,type=outbound-rtp "+
+			"bitrate=2500,byteCount=250i,score=8 1700000000000000000\n",
+		string(batch))
+}
+
+func TestStatsExporter_AggregatedCountersMonotonic(t *testing.T) {
+	exporter, err := NewStatsExporter(
+		WithStatsFormat(StatsFormatLineProtocol),
+		WithStatsAggregation(StatsAggregationRouter),
+	)
+	require.NoError(t, err)
+
+	var batch []byte
+	exporter.On("batch", func(b []byte) { batch = b })
+
+	newSource := func(transportId string) *statsSource {
+		return &statsSource{
+			kind:   "transport",
+			labels: map[string]string{"routerId": "r1", "transportId": transportId},
+		}
+	}
+	t1, t2 := newSource("t1"), newSource("t2")
+
+	sample := func(bytes map[*statsSource]float64) string {
+		sampled := make(statsSample)
+		for _, source := range []*statsSource{t1, t2} {
+			if value, ok := bytes[source]; ok {
+				exporter.add(sampled, source, map[string]interface{}{"bytesReceived": value})
+			}
+		}
+		exporter.commit(sampled)
+		exporter.flush(time.Unix(1700000000, 0))
+		return string(batch)
+	}
+
+	assert.Equal(t, "mediasoup_transport,routerId=r1 bytesReceived=300i 1700000000000000000\n",
+		sample(map[*statsSource]float64{t1: 100, t2: 200}))
+	assert.Equal(t, "mediasoup_transport,routerId=r1 bytesReceived=400i 1700000000000000000\n",
+		sample(map[*statsSource]float64{t1: 150, t2: 250}))
+
+	// t2 closed: the total keeps its bytes.
+	assert.Equal(t, "mediasoup_transport,routerId=r1 bytesReceived=420i 1700000000000000000\n",
+		sample(map[*statsSource]float64{t1: 170}))
+}
+
+func TestStatsExporter_LabelAllowList(t *testing.T) {
+	exporter, err := NewStatsExporter(
+		WithStatsFormat(StatsFormatLineProtocol),
+		WithStatsLabelAllowList("routerId", "kind"),
+	)
+	require.NoError(t, err)
+
+	sample := make(statsSample)
+	exporter.add(sample, &statsSource{
+		kind:   "producer",
+		labels: map[string]string{"routerId": "r1", "transportId": "t1", "producerId": "p1", "room": "a"},
+	}, map[string]interface{}{
+		"type":      "inbound-rtp",
+		"kind":      "audio",
+		"ssrc":      float64(1234),
+		"byteCount": float64(100),
+	})
+	exporter.commit(sample)
+
+	var batch []byte
+	exporter.On("batch", func(b []byte) { batch = b })
+	exporter.flush(time.Unix(1700000000, 0))
+
+	assert.Equal(t,
+		"mediasoup_producer,kind=audio,routerId=r1 byteCount=100i 1700000000000000000\n",
+		string(batch))
+}
+
+func TestStatsExporter_AppDataRedactor(t *testing.T) {
+	redactor, err := NewAppDataRedactor(WithSensitiveAppDataKeys("userId"))
+	require.NoError(t, err)
+
+	exporter, err := NewStatsExporter(
+		WithStatsLabels(nil, "room", "userId"),
+		WithStatsAppDataRedactor(redactor),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]string{"room": "a", "userId": RedactedAppDataValue},
+		exporter.labels(H{"room": "a", "userId": "u1"}, map[string]string{}))
+}
+
+func TestStatsExporter_InvalidAggregation(t *testing.T) {
+	_, err := NewStatsExporter(WithStatsAggregation("room"))
+	assert.IsType(t, ValidationError{}, err)
+}
+
+func TestStatsMetricName(t *testing.T) {
+	assert.Equal(t, "mediasoup_producer_nack_packet_count", statsMetricName("producer", "nackPacketCount"))
+	assert.Equal(t, "mediasoup_transport_bytes_received", statsMetricName("transport", "bytesReceived"))
+	assert.True(t, isStatsCounter("bytesReceived"))
+	assert.True(t, isStatsCounter("rtpBytesSent"))
+	assert.True(t, isStatsCounter("packetsLost"))
+	assert.False(t, isStatsCounter("fractionLost"))
+	assert.False(t, isStatsCounter("sendBitrate"))
+}
@@ -0,0 +1,144 @@
+package mediasoup
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	timerWheelTick  = 10 * time.Millisecond
+	timerWheelSlots = 512
+)
+
+// timerWheel is a hashed timer wheel shared by the entities of a worker, so
+// that their timers (stats polling, timeouts...) don't need a runtime timer,
+// nor a goroutine, each. It ticks only while timers are pending, and has the
+// resolution of its tick.
+type timerWheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	slots   []map[*wheelTimer]struct{}
+	pos     int
+	count   int
+	running bool
+}
+
+// wheelTimer is a timer of a timerWheel, like time.Timer.
+type wheelTimer struct {
+	wheel *timerWheel
+	f     func()
+	slot  int
+	// Remaining turns of the wheel before firing.
+	rounds    int
+	scheduled bool
+}
+
+func newTimerWheel(tick time.Duration, slots int) *timerWheel {
+	wheel := &timerWheel{
+		tick:  tick,
+		slots: make([]map[*wheelTimer]struct{}, slots),
+	}
+
+	for i := range wheel.slots {
+		wheel.slots[i] = make(map[*wheelTimer]struct{})
+	}
+
+	return wheel
+}
+
+// AfterFunc calls f in its own goroutine after the given duration, like
+// time.AfterFunc.
+func (w *timerWheel) AfterFunc(d time.Duration, f func()) *wheelTimer {
+	timer := &wheelTimer{wheel: w, f: f}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.schedule(timer, d)
+
+	return timer
+}
+
+// Stop prevents the timer from firing, returning false if it already fired
+// or was stopped.
+func (t *wheelTimer) Stop() bool {
+	t.wheel.mu.Lock()
+	defer t.wheel.mu.Unlock()
+
+	return t.wheel.unschedule(t)
+}
+
+// Reset changes the timer to fire after the given duration, returning
+// whether it was pending.
+func (t *wheelTimer) Reset(d time.Duration) bool {
+	t.wheel.mu.Lock()
+	defer t.wheel.mu.Unlock()
+
+	pending := t.wheel.unschedule(t)
+	t.wheel.schedule(t, d)
+
+	return pending
+}
+
+func (w *timerWheel) schedule(timer *wheelTimer, d time.Duration) {
+	ticks := int((d + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	timer.slot = (w.pos + ticks) % len(w.slots)
+	timer.rounds = (ticks - 1) / len(w.slots)
+	timer.scheduled = true
+
+	w.slots[timer.slot][timer] = struct{}{}
+	w.count++
+
+	if !w.running {
+		w.running = true
+
+		spawn("timerwheel", w.run)
+	}
+}
+
+func (w *timerWheel) unschedule(timer *wheelTimer) bool {
+	if !timer.scheduled {
+		return false
+	}
+
+	delete(w.slots[timer.slot], timer)
+	timer.scheduled = false
+	w.count--
+
+	return true
+}
+
+func (w *timerWheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		w.mu.Lock()
+
+		w.pos = (w.pos + 1) % len(w.slots)
+
+		for timer := range w.slots[w.pos] {
+			if timer.rounds > 0 {
+				timer.rounds--
+				continue
+			}
+
+			w.unschedule(timer)
+
+			spawn("timerwheel.func", timer.f)
+		}
+
+		// Idle, the next timer starts it again.
+		if w.count == 0 {
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+
+		w.mu.Unlock()
+	}
+}
@@ -0,0 +1,70 @@
+package mediasoup
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimerWheel(t *testing.T) {
+	wheel := newTimerWheel(time.Millisecond, 8)
+
+	var (
+		mu    sync.Mutex
+		fired []string
+	)
+	record := func(name string) func() {
+		return func() {
+			mu.Lock()
+			fired = append(fired, name)
+			mu.Unlock()
+		}
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+
+	// Longer than a turn of the wheel.
+	wheel.AfterFunc(20*time.Millisecond, func() {
+		record("long")()
+		close(done)
+	})
+	wheel.AfterFunc(2*time.Millisecond, record("short"))
+	stopped := wheel.AfterFunc(5*time.Millisecond, record("stopped"))
+	reset := wheel.AfterFunc(time.Millisecond, record("reset"))
+
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+	assert.True(t, reset.Reset(10*time.Millisecond))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timer not fired")
+	}
+
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []string{"short", "reset", "long"}, fired)
+	mu.Unlock()
+
+	// The wheel stops ticking once idle.
+	time.Sleep(5 * time.Millisecond)
+	wheel.mu.Lock()
+	assert.False(t, wheel.running)
+	assert.Zero(t, wheel.count)
+	wheel.mu.Unlock()
+
+	// And starts again.
+	fired2 := make(chan struct{})
+	wheel.AfterFunc(0, func() { close(fired2) })
+
+	select {
+	case <-fired2:
+	case <-time.After(time.Second):
+		t.Fatal("timer not fired")
+	}
+}
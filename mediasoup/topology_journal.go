@@ -0,0 +1,304 @@
+package mediasoup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TopologyJournalOptions configure a TopologyJournal.
+type TopologyJournalOptions struct {
+	// Path of the journal file, the rotated ones having a ".1", ".2"...
+	// suffix.
+	Path string
+	// Size in bytes over which the file is rotated, 10MB if not positive.
+	MaxSize int64
+	// Number of rotated files kept, 3 if not positive.
+	MaxFiles int
+	// Sync the file after each event, for the journal to survive machine
+	// crashes and not just process ones.
+	Sync bool
+}
+
+// topologyJournalLine is a line of the journal: a checkpoint of the whole
+// topology (the first line of each file) or an event.
+type topologyJournalLine struct {
+	State *TopologyState `json:"state,omitempty"`
+	Event *TopologyEvent `json:"event,omitempty"`
+}
+
+// TopologyJournal appends the events of a TopologyStore to a local file, so
+// that after a crash of the process the topology it had can be read back with
+// ReadTopologyJournal (e.g. to recreate the rooms). Each file starts with a
+// checkpoint of the topology, so the last one is enough to read it.
+type TopologyJournal struct {
+	logger  logrus.FieldLogger
+	store   *TopologyStore
+	options TopologyJournalOptions
+	// Replica of the topology, for the checkpoints.
+	mirror *TopologyStore
+	file   *os.File
+	size   int64
+	mu     sync.Mutex
+	cancel func()
+	closed bool
+	doneCh chan struct{}
+}
+
+// NewTopologyJournal starts journaling the events of the given store.
+func NewTopologyJournal(store *TopologyStore, options TopologyJournalOptions) (journal *TopologyJournal, err error) {
+	logger := TypeLogger("TopologyJournal")
+
+	logger.Debug("constructor()")
+
+	if len(options.Path) == 0 {
+		return nil, NewValidationError("TopologyJournalOptions.Path", "must not be empty")
+	}
+	if options.MaxSize <= 0 {
+		options.MaxSize = 10 << 20
+	}
+	if options.MaxFiles <= 0 {
+		options.MaxFiles = 3
+	}
+
+	journal = &TopologyJournal{
+		logger:  logger,
+		store:   store,
+		options: options,
+		doneCh:  make(chan struct{}),
+	}
+
+	events, err := journal.subscribe(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	spawn("topologyjournal.run", func() {
+		journal.run(events)
+	})
+
+	return journal, nil
+}
+
+// Close stops journaling.
+func (j *TopologyJournal) Close() error {
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return nil
+	}
+	j.closed = true
+	cancel := j.cancel
+	j.mu.Unlock()
+
+	j.logger.Debug("close()")
+
+	cancel()
+	<-j.doneCh
+
+	if j.file == nil {
+		return nil
+	}
+
+	return j.file.Close()
+}
+
+// subscribe starts a new file with the current topology and subscribes to the
+// following events. The topology is taken from the mirror when possible,
+// else from the store (at start or after missed events).
+func (j *TopologyJournal) subscribe(events <-chan TopologyEvent) (<-chan TopologyEvent, error) {
+	var err error
+
+	for {
+		if j.mirror != nil && events != nil {
+			// The subscriber was too slow, resume from the last event.
+			var cancel func()
+			if events, cancel, err = j.store.Subscribe(j.mirror.Seq(), 1000); err == nil {
+				j.setCancel(cancel)
+				return events, nil
+			}
+			j.logger.Warnf("events missed, starting a new file: %s", err)
+		}
+
+		state := j.store.State()
+
+		j.mirror = NewTopologyStore(1)
+		j.mirror.seq = state.Seq
+		for i := range state.Entities {
+			entity := state.Entities[i].clone()
+			j.mirror.entities[entity.Id] = &entity
+		}
+
+		if err = j.rotate(); err != nil {
+			return nil, err
+		}
+
+		var cancel func()
+		events, cancel, err = j.store.Subscribe(state.Seq, 1000)
+		if err == nil {
+			j.setCancel(cancel)
+			return events, nil
+		}
+	}
+}
+
+func (j *TopologyJournal) setCancel(cancel func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.cancel = cancel
+	if j.closed {
+		cancel()
+	}
+}
+
+func (j *TopologyJournal) run(events <-chan TopologyEvent) {
+	defer close(j.doneCh)
+
+	for {
+		event, ok := <-events
+		if !ok {
+			j.mu.Lock()
+			closed := j.closed
+			j.mu.Unlock()
+
+			if closed {
+				return
+			}
+
+			var err error
+			if events, err = j.subscribe(events); err != nil {
+				j.logger.Errorf("journaling stopped: %s", err)
+				return
+			}
+			continue
+		}
+
+		j.mirror.seq = event.Seq
+		j.mirror.apply(event)
+
+		if err := j.write(topologyJournalLine{Event: &event}); err != nil {
+			j.logger.Errorf("write event failed [seq:%d]: %s", event.Seq, err)
+		}
+
+		if j.size > j.options.MaxSize {
+			if err := j.rotate(); err != nil {
+				j.logger.Errorf("rotate failed: %s", err)
+			}
+		}
+	}
+}
+
+// rotate moves the current file (if any) to its ".1" suffix, shifting the
+// rotated ones, and starts a new one with a checkpoint of the topology.
+func (j *TopologyJournal) rotate() (err error) {
+	path := j.options.Path
+
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+
+		os.Remove(fmt.Sprintf("%s.%d", path, j.options.MaxFiles))
+
+		for i := j.options.MaxFiles - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		}
+		if err = os.Rename(path, path+".1"); err != nil {
+			return
+		}
+	}
+
+	if j.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644); err != nil {
+		return
+	}
+	j.size = 0
+
+	state := j.mirror.State()
+
+	return j.write(topologyJournalLine{State: &state})
+}
+
+func (j *TopologyJournal) write(line topologyJournalLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	n, err := j.file.Write(append(data, '\n'))
+	j.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if j.options.Sync {
+		return j.file.Sync()
+	}
+
+	return nil
+}
+
+// ReadTopologyJournal returns the topology recorded in the given journal file
+// (see TopologyJournal). A last line truncated by a crash is ignored.
+func ReadTopologyJournal(path string) (state TopologyState, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var replica *TopologyStore
+
+	reader := bufio.NewReader(file)
+
+	for {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return state, readErr
+		}
+
+		// Incomplete last line.
+		if readErr == io.EOF && len(bytes.TrimSpace(data)) > 0 {
+			break
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			var line topologyJournalLine
+			if err = json.Unmarshal(data, &line); err != nil {
+				return
+			}
+
+			switch {
+			case line.State != nil:
+				replica = NewTopologyStore(1)
+				replica.seq = line.State.Seq
+				for i := range line.State.Entities {
+					entity := line.State.Entities[i]
+					if entity.Data == nil {
+						entity.Data = H{}
+					}
+					replica.entities[entity.Id] = &entity
+				}
+
+			case line.Event != nil && replica != nil:
+				replica.seq = line.Event.Seq
+				replica.apply(*line.Event)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if replica == nil {
+		return state, NewTypeError("no checkpoint in journal %s", path)
+	}
+
+	return replica.State(), nil
+}
@@ -0,0 +1,82 @@
+package mediasoup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "topology.log")
+
+	store := NewTopologyStore(0)
+	store.record("worker", "create", "1", "", H{"pid": 1})
+	store.record("router", "create", "r1", "1", nil)
+
+	journal, err := NewTopologyJournal(store, TopologyJournalOptions{Path: path, MaxSize: 500, MaxFiles: 2})
+	require.NoError(t, err)
+
+	store.record("transport", "create", "t1", "r1", H{"type": "webrtc"})
+	store.record("producer", "create", "p1", "t1", H{"kind": "audio", "paused": false})
+	store.record("producer", "pause", "p1", "", H{"paused": true})
+
+	for i := 0; i < 20; i++ {
+		store.record("router", "create", "tmp", "1", nil)
+		store.record("router", "close", "tmp", "", nil)
+	}
+
+	waitJournal := func() {
+		for i := 0; i < 100; i++ {
+			state, err := ReadTopologyJournal(path)
+			if err == nil && state.Seq == store.Seq() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("journal not written")
+	}
+	waitJournal()
+
+	// Rotated, the last file being enough.
+	_, err = os.Stat(path + ".1")
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	state, err := ReadTopologyJournal(path)
+	require.NoError(t, err)
+
+	expected := store.State()
+	assert.Equal(t, expected.Seq, state.Seq)
+	require.Len(t, state.Entities, 4)
+	assert.Equal(t, "p1", state.Entities[1].Id)
+	assert.Equal(t, true, state.Entities[1].Data["paused"])
+	assert.Equal(t, "t1", state.Entities[1].ParentId)
+
+	require.NoError(t, journal.Close())
+
+	// Events after the close are not journaled.
+	store.record("router", "create", "r2", "1", nil)
+	state, err = ReadTopologyJournal(path)
+	require.NoError(t, err)
+	assert.Len(t, state.Entities, 4)
+
+	// A line truncated by a crash is ignored.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	file.WriteString(`{"event":{"seq":1000,"kind":"rou`)
+	file.Close()
+
+	state, err = ReadTopologyJournal(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Seq, state.Seq)
+}
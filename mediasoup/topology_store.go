@@ -0,0 +1,397 @@
+package mediasoup
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TopologyEvent is a change of the media topology: an entity ("worker",
+// "router", "transport", "producer" or "consumer") created, closed or whose
+// state changed.
+type TopologyEvent struct {
+	Seq       uint64 `json:"seq"`
+	Timestamp int64  `json:"timestamp"` // unix time in milliseconds
+	Kind      string `json:"kind"`
+	// "create", "close" or the state change ("pause", "resume",
+	// "icestatechange", "dtlsstatechange").
+	Action   string `json:"action"`
+	Id       string `json:"id"`
+	ParentId string `json:"parentId,omitempty"`
+	Data     H      `json:"data,omitempty"`
+}
+
+// TopologyEntity is the current state of an entity, resulting from its
+// events.
+type TopologyEntity struct {
+	Kind     string `json:"kind"`
+	Id       string `json:"id"`
+	ParentId string `json:"parentId,omitempty"`
+	Data     H      `json:"data,omitempty"`
+}
+
+// TopologyState is the whole topology as of the event with sequence number
+// Seq.
+type TopologyState struct {
+	Seq      uint64           `json:"seq"`
+	Entities []TopologyEntity `json:"entities"`
+}
+
+// TopologyStore maintains an event-sourced model of the topology of the
+// watched Workers, so that external controllers can mirror it: load State()
+// once, then apply the events following its sequence number, resuming with
+// EventsSince() or Subscribe() after reconnecting.
+type TopologyStore struct {
+	mu          sync.Mutex
+	logger      logrus.FieldLogger
+	redactor    *AppDataRedactor
+	maxEvents   int
+	seq         uint64
+	events      []TopologyEvent
+	entities    map[string]*TopologyEntity
+	subscribers map[chan TopologyEvent]struct{}
+}
+
+type TopologyStoreOption func(s *TopologyStore)
+
+// WithTopologyAppDataRedactor makes the store redact the appData of the
+// recorded entities.
+func WithTopologyAppDataRedactor(redactor *AppDataRedactor) TopologyStoreOption {
+	return func(s *TopologyStore) {
+		s.redactor = redactor
+	}
+}
+
+// NewTopologyStore creates a store retaining the last maxEvents events
+// (10000 if not positive).
+func NewTopologyStore(maxEvents int, options ...TopologyStoreOption) *TopologyStore {
+	logger := TypeLogger("TopologyStore")
+
+	logger.Debug("constructor()")
+
+	if maxEvents <= 0 {
+		maxEvents = 10000
+	}
+
+	s := &TopologyStore{
+		logger:      logger,
+		maxEvents:   maxEvents,
+		entities:    make(map[string]*TopologyEntity),
+		subscribers: make(map[chan TopologyEvent]struct{}),
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// Seq returns the sequence number of the last event.
+func (s *TopologyStore) Seq() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.seq
+}
+
+// State returns the current topology.
+func (s *TopologyStore) State() TopologyState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := TopologyState{
+		Seq:      s.seq,
+		Entities: make([]TopologyEntity, 0, len(s.entities)),
+	}
+
+	for _, entity := range s.entities {
+		state.Entities = append(state.Entities, entity.clone())
+	}
+
+	sort.Slice(state.Entities, func(i, j int) bool {
+		return state.Entities[i].Id < state.Entities[j].Id
+	})
+
+	return state
+}
+
+// Entity returns the current state of the given entity.
+func (s *TopologyStore) Entity(id string) (entity TopologyEntity, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, found := s.entities[id]; found {
+		return e.clone(), true
+	}
+
+	return
+}
+
+// Children returns the entities whose parent is the given entity.
+func (s *TopologyStore) Children(id string) (children []TopologyEntity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entity := range s.entities {
+		if entity.ParentId == id {
+			children = append(children, entity.clone())
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Id < children[j].Id
+	})
+
+	return
+}
+
+// EventsSince returns the events following the given sequence number. An
+// InvalidStateError is returned if some of them are not retained anymore, in
+// which case State() must be loaded again.
+func (s *TopologyStore) EventsSince(seq uint64) (events []TopologyEvent, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.eventsSince(seq)
+}
+
+// Subscribe streams the events following the given sequence number, the
+// retained ones first. The channel is closed by cancel() or when the
+// subscriber doesn't keep up (more than bufferSize pending events), in which
+// case it should subscribe again from the last received sequence number.
+func (s *TopologyStore) Subscribe(
+	seq uint64, bufferSize int,
+) (events <-chan TopologyEvent, cancel func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending, err := s.eventsSince(seq)
+	if err != nil {
+		return
+	}
+
+	ch := make(chan TopologyEvent, bufferSize+len(pending))
+
+	for _, event := range pending {
+		ch <- event
+	}
+
+	s.subscribers[ch] = struct{}{}
+
+	cancel = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.unsubscribe(ch)
+	}
+
+	return ch, cancel, nil
+}
+
+// WatchWorker records the events of the given Worker and of its entities.
+func (s *TopologyStore) WatchWorker(worker *Worker) {
+	workerId := strconv.Itoa(worker.Pid())
+
+	s.record("worker", "create", workerId, "", H{"pid": worker.Pid()})
+
+	worker.Observer().On("close", func() {
+		s.record("worker", "close", workerId, "", nil)
+	})
+
+	EntityVisitor{
+		Router: func(router *Router) {
+			s.watchRouter(workerId, router)
+		},
+		Transport: s.watchTransport,
+		Producer: func(router *Router, transport Transport, producer *Producer) {
+			s.watchProducer(transport, producer)
+		},
+		Consumer: func(router *Router, transport Transport, consumer *Consumer) {
+			s.watchConsumer(transport, consumer)
+		},
+	}.WalkWorker(worker)
+}
+
+func (s *TopologyStore) watchRouter(workerId string, router *Router) {
+	s.record("router", "create", router.Id(), workerId, nil)
+
+	router.Observer().On("close", func() {
+		s.record("router", "close", router.Id(), "", nil)
+	})
+}
+
+func (s *TopologyStore) watchTransport(router *Router, transport Transport) {
+	data := H{"appData": s.redactor.Redact(transport.AppData())}
+
+	switch t := transport.(type) {
+	case *WebRtcTransport:
+		data["type"] = "webrtc"
+		data["iceState"] = t.IceState()
+		data["dtlsState"] = t.DtlsState()
+	case *PlainRtpTransport:
+		data["type"] = "plain"
+	case *PipeTransport:
+		data["type"] = "pipe"
+	}
+
+	s.record("transport", "create", transport.Id(), router.Id(), data)
+
+	transport.Observer().On("close", func() {
+		s.record("transport", "close", transport.Id(), "", nil)
+	})
+	transport.Observer().On("icestatechange", func(iceState string) {
+		s.record("transport", "icestatechange", transport.Id(), "", H{"iceState": iceState})
+	})
+	transport.Observer().On("dtlsstatechange", func(dtlsState string) {
+		s.record("transport", "dtlsstatechange", transport.Id(), "", H{"dtlsState": dtlsState})
+	})
+}
+
+func (s *TopologyStore) watchProducer(transport Transport, producer *Producer) {
+	s.record("producer", "create", producer.Id(), transport.Id(), H{
+		"kind":    producer.Kind(),
+		"type":    producer.Type(),
+		"paused":  producer.Paused(),
+		"appData": s.redactor.Redact(producer.AppData()),
+	})
+
+	producer.Observer().On("close", func() {
+		s.record("producer", "close", producer.Id(), "", nil)
+	})
+	producer.Observer().On("pause", func() {
+		s.record("producer", "pause", producer.Id(), "", H{"paused": true})
+	})
+	producer.Observer().On("resume", func() {
+		s.record("producer", "resume", producer.Id(), "", H{"paused": false})
+	})
+}
+
+func (s *TopologyStore) watchConsumer(transport Transport, consumer *Consumer) {
+	s.record("consumer", "create", consumer.Id(), transport.Id(), H{
+		"producerId": consumer.ProducerId(),
+		"kind":       consumer.Kind(),
+		"type":       consumer.Type(),
+		"paused":     consumer.Paused(),
+		"appData":    s.redactor.Redact(consumer.AppData()),
+	})
+
+	consumer.Observer().On("close", func() {
+		s.record("consumer", "close", consumer.Id(), "", nil)
+	})
+	consumer.Observer().On("pause", func() {
+		s.record("consumer", "pause", consumer.Id(), "", H{"paused": true})
+	})
+	consumer.Observer().On("resume", func() {
+		s.record("consumer", "resume", consumer.Id(), "", H{"paused": false})
+	})
+}
+
+func (s *TopologyStore) record(kind, action, id, parentId string, data H) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+
+	event := TopologyEvent{
+		Seq:       s.seq,
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+		Kind:      kind,
+		Action:    action,
+		Id:        id,
+		ParentId:  parentId,
+		Data:      data,
+	}
+
+	s.apply(event)
+
+	s.events = append(s.events, event)
+
+	if len(s.events) > s.maxEvents {
+		s.events = s.events[len(s.events)-s.maxEvents:]
+	}
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			s.logger.Warnf("subscriber too slow, closing it [seq:%d]", event.Seq)
+			s.unsubscribe(ch)
+		}
+	}
+}
+
+func (s *TopologyStore) apply(event TopologyEvent) {
+	switch event.Action {
+	case "create":
+		entity := &TopologyEntity{
+			Kind:     event.Kind,
+			Id:       event.Id,
+			ParentId: event.ParentId,
+			Data:     H{},
+		}
+		for key, value := range event.Data {
+			entity.Data[key] = value
+		}
+		s.entities[event.Id] = entity
+
+	case "close":
+		s.removeEntity(event.Id)
+
+	default:
+		if entity, ok := s.entities[event.Id]; ok {
+			for key, value := range event.Data {
+				entity.Data[key] = value
+			}
+		}
+	}
+}
+
+// removeEntity removes the given entity and its descendants, whose close
+// events may come later or not at all.
+func (s *TopologyStore) removeEntity(id string) {
+	delete(s.entities, id)
+
+	for childId, entity := range s.entities {
+		if entity.ParentId == id {
+			s.removeEntity(childId)
+		}
+	}
+}
+
+func (s *TopologyStore) eventsSince(seq uint64) (events []TopologyEvent, err error) {
+	if seq >= s.seq {
+		return
+	}
+	if len(s.events) == 0 || s.events[0].Seq > seq+1 {
+		err = NewInvalidStateError("events following %d are not retained anymore", seq)
+		return
+	}
+
+	retained := s.events[seq+1-s.events[0].Seq:]
+	events = make([]TopologyEvent, len(retained))
+	copy(events, retained)
+
+	return
+}
+
+func (s *TopologyStore) unsubscribe(ch chan TopologyEvent) {
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (e *TopologyEntity) clone() TopologyEntity {
+	entity := *e
+	entity.Data = make(H, len(e.Data))
+
+	for key, value := range e.Data {
+		entity.Data[key] = value
+	}
+
+	return entity
+}
@@ -0,0 +1,111 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopologyStore_MirrorsTopology(t *testing.T) {
+	worker := CreateTestWorker()
+	defer worker.Close()
+
+	store := NewTopologyStore(0)
+	store.WatchWorker(worker)
+
+	router, err := worker.CreateRouter(testPipeMediaCodecs)
+	assert.NoError(t, err)
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{
+			{Ip: "127.0.0.1"},
+		},
+	})
+	assert.NoError(t, err)
+
+	seq := store.Seq()
+	events, cancel, err := store.Subscribe(seq, 10)
+	assert.NoError(t, err)
+	defer cancel()
+
+	producer, err := transport.Produce(audioProducerParameters)
+	assert.NoError(t, err)
+	assert.NoError(t, producer.Pause())
+
+	event := <-events
+	assert.Equal(t, seq+1, event.Seq)
+	assert.Equal(t, "producer", event.Kind)
+	assert.Equal(t, "create", event.Action)
+	assert.Equal(t, transport.Id(), event.ParentId)
+
+	event = <-events
+	assert.Equal(t, seq+2, event.Seq)
+	assert.Equal(t, "pause", event.Action)
+
+	entity, ok := store.Entity(producer.Id())
+	assert.True(t, ok)
+	assert.Equal(t, true, entity.Data["paused"])
+	assert.Equal(t, "audio", entity.Data["kind"])
+
+	state := store.State()
+	assert.Equal(t, seq+2, state.Seq)
+	assert.Len(t, state.Entities, 4)
+	assert.Len(t, store.Children(router.Id()), 1)
+
+	pending, err := store.EventsSince(seq)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	transport.Close()
+
+	_, ok = store.Entity(transport.Id())
+	assert.False(t, ok)
+	_, ok = store.Entity(producer.Id())
+	assert.False(t, ok)
+}
+
+func TestTopologyStore_EventsNotRetained(t *testing.T) {
+	store := NewTopologyStore(2)
+
+	store.record("router", "create", "r1", "", nil)
+	store.record("router", "create", "r2", "", nil)
+	store.record("router", "close", "r1", "", nil)
+
+	_, err := store.EventsSince(0)
+	assert.Error(t, err)
+
+	_, _, err = store.Subscribe(0, 10)
+	assert.Error(t, err)
+
+	events, err := store.EventsSince(1)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	state := store.State()
+	assert.Equal(t, uint64(3), state.Seq)
+	assert.Equal(t, []TopologyEntity{{Kind: "router", Id: "r2", Data: H{}}}, state.Entities)
+}
+
+func TestTopologyStore_AppDataRedactor(t *testing.T) {
+	redactor, err := NewAppDataRedactor(WithSensitiveAppDataKeys("userId"))
+	assert.NoError(t, err)
+
+	fake := newFakeWorker(t)
+	store := NewTopologyStore(0, WithTopologyAppDataRedactor(redactor))
+
+	transport := &PipeTransport{baseTransport: &baseTransport{internal: internalData{TransportId: "transport"}}}
+	producer := NewProducer(
+		internalData{TransportId: "transport", ProducerId: "producer"},
+		producerData{Kind: "audio", Type: "simple"},
+		fake.channel, H{"userId": "u1", "role": "speaker"}, false,
+	)
+
+	store.watchProducer(transport, producer)
+
+	entity, ok := store.Entity(producer.Id())
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{
+		"userId": RedactedAppDataValue,
+		"role":   "speaker",
+	}, entity.Data["appData"])
+}
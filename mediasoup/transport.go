@@ -1,10 +1,10 @@
 package mediasoup
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"runtime"
-	"strings"
+	"sync"
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
@@ -19,54 +19,98 @@ type Transport interface {
 	Observer() EventEmitter
 	Close() error
 	routerClosed()
-	Dump() Response
+	Dump() (TransportDump, error)
 	GetStats() ([]TransportStat, error)
-	Connect(transportConnectParams) error
-	Produce(transportProduceParams) (*Producer, error)
-	Consume(transportConsumeParams) (*Consumer, error)
+	Connect(TransportConnectParams) error
+	Produce(TransportProduceParams) (*Producer, error)
+	Consume(TransportConsumeParams) (*Consumer, error)
+	ProduceDryRun(TransportProduceParams) (ProduceDryRunResult, error)
+	ConsumeDryRun(TransportConsumeParams) (RtpParameters, error)
+	EnableTraceEvent(types ...string) error
+	BweHistory() []BweSample
 }
 
 type baseTransport struct {
 	EventEmitter
 	logger                   logrus.FieldLogger
+	appLogger                logrus.FieldLogger
 	internal                 internalData
 	channel                  *Channel
+	options                  interface{}
 	appData                  interface{}
-	closed                   bool
+	closed                   closeFlag
 	getRouterRtpCapabilities fetchRouterRtpCapabilitiesFunc
 	getProducerById          fetchProducerFunc
-	producers                map[string]*Producer
-	consumers                map[string]*Consumer
-	cnameForProducers        string
-	observer                 EventEmitter
+	getProducerBySsrc        fetchProducerBySsrcFunc
+	ssrcReservations         *ssrcReservations
+	workerVersion            string
+	consumerLimiter          *consumerLimiter
+	transportType            string
+	authorizer               Authorizer
+	blockE2eeFeature         func(feature string) error
+	notificationRateLimits   NotificationRateLimits
+	// Guards producers and consumers.
+	entitiesLocker    sync.Mutex
+	producers         map[string]*Producer
+	consumers         map[string]*Consumer
+	cnameForProducers string
+	observer          EventEmitter
+	// Guards bweHistory, a ring buffer starting at bweHistoryStart.
+	traceLocker     sync.Mutex
+	bweHistory      []BweSample
+	bweHistoryStart int
+	// Route of the worker notifications, set by the Transport types.
+	subscription notificationSubscription
 }
 
 /**
  * new transport
  *
  * @emits routerclose
+ * @emits listenserverclose
  * @emits @close
+ * @emits @listenserverclose
  * @emits @newproducer
  * @emits @producerclose
+ * @emits {consumer: Consumer, report: RtpCapabilitiesReport} degradedconsumer
+ * @emits {trace: TransportTraceEventData} trace
  */
 func newTransport(params createTransportParams) *baseTransport {
-	logger := TypeLogger("Transport")
+	appLogger := entityAppLogger(params.AppLogger)
+	logger := appLogger.WithField("type", "Transport")
 
 	logger.Debug("constructor()")
 
+	closed, ctx := newCloseFlag()
+
 	transport := &baseTransport{
-		EventEmitter: NewEventEmitter(logger),
+		EventEmitter: NewEventEmitter(logger, WithEmitterLogFields(params.Internal.logFields()), WithEmitterContext(ctx)),
+		closed:       closed,
 		logger:       logger,
+		appLogger:    appLogger,
 		// - .routerId
 		// - .transportId
 		internal:                 params.Internal,
 		channel:                  params.Channel,
+		options:                  params.Options,
 		appData:                  params.AppData,
 		getRouterRtpCapabilities: params.GetRouterRtpCapabilities,
 		getProducerById:          params.GetProducerById,
+		getProducerBySsrc:        params.GetProducerBySsrc,
+		ssrcReservations:         params.SsrcReservations,
+		workerVersion:            params.WorkerVersion,
+		consumerLimiter:          params.ConsumerLimiter,
+		transportType:            params.Type,
+		authorizer:               params.Authorizer,
+		blockE2eeFeature:         params.BlockE2eeFeature,
+		notificationRateLimits:   params.NotificationRateLimits,
 		producers:                make(map[string]*Producer),
 		consumers:                make(map[string]*Consumer),
-		observer:                 NewEventEmitter(AppLogger()),
+		observer:                 NewEventEmitter(appLogger, WithEmitterLogFields(params.Internal.logFields()), WithEmitterContext(ctx)),
+	}
+
+	if transport.ssrcReservations == nil {
+		transport.ssrcReservations = newSsrcReservations()
 	}
 
 	return transport
@@ -79,7 +123,7 @@ func (transport *baseTransport) Id() string {
 
 // Whether the Transport is closed.
 func (transport *baseTransport) Closed() bool {
-	return transport.closed
+	return transport.closed.isSet()
 }
 
 //App custom data.
@@ -93,6 +137,8 @@ func (transport *baseTransport) AppData() interface{} {
  * @emits close
  * @emits {producer: Producer} newproducer
  * @emits {consumer: Consumer} newconsumer
+ * @emits {consumer: Consumer, report: RtpCapabilitiesReport} degradedconsumer
+ * @emits {trace: TransportTraceEventData} trace
  */
 func (transport *baseTransport) Observer() EventEmitter {
 	return transport.observer
@@ -100,33 +146,30 @@ func (transport *baseTransport) Observer() EventEmitter {
 
 // Close the Transport.
 func (transport *baseTransport) Close() (err error) {
-	if transport.closed {
+	return transport.close(nil)
+}
+
+// close closes the Transport, calling onClosed first if closed by this call.
+func (transport *baseTransport) close(onClosed func()) (err error) {
+	if !transport.closed.set() {
 		return
 	}
+	defer transport.closed.cancelContext()
 
 	transport.logger.Debug("close()")
 
-	transport.closed = true
+	if onClosed != nil {
+		onClosed()
+	}
 
-	transport.RemoveAllListeners(transport.internal.TransportId)
+	// Remove notification subscriptions.
+	transport.channel.unsubscribe(transport.subscription)
 
 	response := transport.channel.Request("transport.close", transport.internal, nil)
 
-	if err = response.Err(); err != nil {
-		return
-	}
+	err = response.Err()
 
-	for _, producer := range transport.producers {
-		producer.TransportClosed()
-
-		transport.Emit("@producerclose", producer)
-	}
-	transport.producers = make(map[string]*Producer)
-
-	for _, consumer := range transport.consumers {
-		consumer.TransportClosed()
-	}
-	transport.consumers = make(map[string]*Consumer)
+	transport.closeEntities()
 
 	transport.Emit("@close")
 
@@ -136,60 +179,209 @@ func (transport *baseTransport) Close() (err error) {
 	return
 }
 
+// authorize calls the Authorizer with the given request of a Producer or
+// Consumer of the Transport.
+func (transport *baseTransport) authorize(request AuthorizationRequest) error {
+	request.RouterId = transport.internal.RouterId
+	request.TransportId = transport.internal.TransportId
+	request.TransportType = transport.transportType
+
+	return authorize(transport.authorizer, request)
+}
+
 /**
  * Router was closed.
  *
  * @virtual
  */
 func (transport *baseTransport) routerClosed() {
-	if transport.closed {
+	transport.handleRouterClosed(nil)
+}
+
+// handleRouterClosed closes the Transport because its Router was closed,
+// calling onClosed first if closed by this call.
+func (transport *baseTransport) handleRouterClosed(onClosed func()) {
+	if !transport.closed.set() {
 		return
 	}
+	defer transport.closed.cancelContext()
 
 	transport.logger.Debug("routerClosed()")
 
-	transport.closed = true
+	if onClosed != nil {
+		onClosed()
+	}
 
 	// Remove notification subscriptions.
-	transport.channel.RemoveAllListeners(transport.internal.TransportId)
+	transport.channel.unsubscribe(transport.subscription)
 
-	for _, producer := range transport.producers {
+	transport.closeEntities()
+
+	transport.SafeEmit("routerclose")
+
+	// Emit observer event.
+	transport.observer.SafeEmit("close")
+}
+
+// handleListenServerClosed closes the Transport because its WebRtcServer was
+// closed, calling onClosed first if closed by this call.
+func (transport *baseTransport) handleListenServerClosed(onClosed func()) {
+	if !transport.closed.set() {
+		return
+	}
+	defer transport.closed.cancelContext()
+
+	transport.logger.Debug("listenServerClosed()")
+
+	if onClosed != nil {
+		onClosed()
+	}
+
+	// Remove notification subscriptions.
+	transport.channel.unsubscribe(transport.subscription)
+
+	transport.closeEntities()
+
+	transport.Emit("@listenserverclose")
+
+	transport.SafeEmit("listenserverclose")
+
+	// Emit observer event.
+	transport.observer.SafeEmit("close")
+}
+
+// closeEntities closes the Producers and Consumers of the closed Transport.
+// They are closed outside of the lock, their listeners may use the Transport.
+func (transport *baseTransport) closeEntities() {
+	transport.entitiesLocker.Lock()
+	producers, consumers := transport.producers, transport.consumers
+	transport.producers = make(map[string]*Producer)
+	transport.consumers = make(map[string]*Consumer)
+	transport.entitiesLocker.Unlock()
+
+	for _, producer := range producers {
 		producer.TransportClosed()
 
 		transport.Emit("@producerclose", producer)
 	}
-	transport.producers = make(map[string]*Producer)
 
-	for _, consumer := range transport.consumers {
+	for _, consumer := range consumers {
 		consumer.TransportClosed()
 	}
-	transport.consumers = make(map[string]*Consumer)
+}
 
-	transport.SafeEmit("routerclose")
+// addProducer registers the given new Producer, which is closed instead if
+// the Transport was closed meanwhile.
+func (transport *baseTransport) addProducer(producer *Producer) (err error) {
+	transport.entitiesLocker.Lock()
 
-	// Emit observer event.
-	transport.observer.SafeEmit("close")
+	if transport.closed.isSet() {
+		transport.entitiesLocker.Unlock()
+
+		producer.TransportClosed()
+
+		return NewInvalidStateError("Transport closed")
+	}
+
+	transport.producers[producer.Id()] = producer
+	transport.entitiesLocker.Unlock()
+
+	producer.On("@close", func() {
+		transport.entitiesLocker.Lock()
+		delete(transport.producers, producer.Id())
+		transport.entitiesLocker.Unlock()
+
+		transport.Emit("@producerclose", producer)
+	})
+
+	return
+}
+
+// addConsumer registers the given new Consumer, which is closed instead if
+// the Transport was closed meanwhile.
+func (transport *baseTransport) addConsumer(consumer *Consumer) (err error) {
+	transport.entitiesLocker.Lock()
+
+	if transport.closed.isSet() {
+		transport.entitiesLocker.Unlock()
+
+		consumer.TransportClosed()
+
+		return NewInvalidStateError("Transport closed")
+	}
+
+	transport.consumers[consumer.Id()] = consumer
+	transport.entitiesLocker.Unlock()
+
+	removeConsumer := func() {
+		transport.entitiesLocker.Lock()
+		delete(transport.consumers, consumer.Id())
+		transport.entitiesLocker.Unlock()
+	}
+
+	consumer.On("@close", removeConsumer)
+	consumer.On("@producerclose", removeConsumer)
+
+	return
+}
+
+// getProducer returns the Producer with the given id, if any.
+func (transport *baseTransport) getProducer(id string) *Producer {
+	transport.entitiesLocker.Lock()
+	defer transport.entitiesLocker.Unlock()
+
+	return transport.producers[id]
+}
+
+// getProducers returns the Producers of the Transport.
+func (transport *baseTransport) getProducers() []*Producer {
+	transport.entitiesLocker.Lock()
+	defer transport.entitiesLocker.Unlock()
+
+	producers := make([]*Producer, 0, len(transport.producers))
+
+	for _, producer := range transport.producers {
+		producers = append(producers, producer)
+	}
+
+	return producers
+}
+
+// getConsumers returns the Consumers of the Transport.
+func (transport *baseTransport) getConsumers() []*Consumer {
+	transport.entitiesLocker.Lock()
+	defer transport.entitiesLocker.Unlock()
+
+	consumers := make([]*Consumer, 0, len(transport.consumers))
+
+	for _, consumer := range transport.consumers {
+		consumers = append(consumers, consumer)
+	}
+
+	return consumers
 }
 
 // Dump Transport.
-func (transport *baseTransport) Dump() Response {
+func (transport *baseTransport) Dump() (dump TransportDump, err error) {
 	transport.logger.Debug("dump()")
 
-	return transport.channel.Request("transport.dump", transport.internal, nil)
+	err = transport.channel.requestIdempotent(context.Background(), "transport.dump", transport.internal, nil).Unmarshal(&dump)
+
+	return
 }
 
 // Get Transport stats.
 func (transport *baseTransport) GetStats() (stat []TransportStat, err error) {
 	transport.logger.Debug("getStats()")
 
-	resp := transport.channel.Request("transport.getStats", transport.internal)
+	resp := transport.channel.requestIdempotent(context.Background(), "transport.getStats", transport.internal)
 
 	err = resp.Unmarshal(&stat)
 
 	return
 }
 
-func (transport *baseTransport) Connect(transportConnectParams) error {
+func (transport *baseTransport) Connect(TransportConnectParams) error {
 	return errors.New("method not implemented in the subclass")
 }
 
@@ -202,13 +394,84 @@ func (transport *baseTransport) Connect(transportConnectParams) error {
  * @param [paused=false] - Whether the Consumer must start paused.
  * @param [appData={}] - Custom app data.
  */
-func (transport *baseTransport) Produce(params transportProduceParams) (producer *Producer, err error) {
+func (transport *baseTransport) Produce(params TransportProduceParams) (producer *Producer, err error) {
 	transport.logger.Debug("produce()")
 
+	plan, err := transport.prepareProduce(params, false)
+	if err != nil {
+		return
+	}
+
+	// Once the Producer is registered in the Router, or failed.
+	defer transport.ssrcReservations.release(plan.reservedSsrcs)
+	if err = transport.authorize(AuthorizationRequest{
+		Action:        AuthorizeProduce,
+		Kind:          params.Kind,
+		RtpParameters: plan.RtpParameters,
+		AppData:       plan.appData,
+	}); err != nil {
+		return
+	}
+
+	id, kind, paused := params.Id, params.Kind, params.Paused
+	rtpParameters, rtpMapping := plan.RtpParameters, plan.RtpMapping
+
+	internal := transport.internal
+	if len(id) > 0 {
+		internal.ProducerId = id
+	} else {
+		internal.ProducerId = uuid.NewV4().String()
+	}
+
+	reqData := H{
+		"kind":          kind,
+		"rtpParameters": rtpParameters,
+		"rtpMapping":    rtpMapping,
+		"paused":        paused,
+	}
+
+	resp := transport.channel.Request("transport.produce", internal, reqData)
+
+	var status struct {
+		Type string
+	}
+	if err = resp.Unmarshal(&status); err != nil {
+		return
+	}
+
+	producerData := producerData{
+		Kind:                    kind,
+		RtpParameters:           rtpParameters,
+		Type:                    status.Type,
+		ConsumableRtpParameters: plan.ConsumableRtpParameters,
+		ScoreInterval:           transport.notificationRateLimits.ScoreInterval,
+		AppLogger:               transport.appLogger,
+	}
+
+	producer = NewProducer(internal, producerData, transport.channel, plan.appData, paused)
+
+	if err = transport.addProducer(producer); err != nil {
+		return nil, err
+	}
+
+	transport.Emit("@newproducer", producer)
+
+	// Emit observer event.
+	transport.observer.SafeEmit("newproducer", producer)
+
+	return
+}
+
+// prepareProduce validates the given Producer parameters and computes their
+// mapping to the Router ones. In dry run, the transport is left unchanged.
+func (transport *baseTransport) prepareProduce(
+	params TransportProduceParams, dryRun bool,
+) (plan ProduceDryRunResult, err error) {
+	isPipeTransport := transport.transportType == "pipe"
+
 	id := params.Id
 	kind := params.Kind
 	rtpParameters := params.RtpParameters
-	paused := params.Paused
 	appData := params.AppData
 
 	if appData == nil {
@@ -219,7 +482,7 @@ func (transport *baseTransport) Produce(params transportProduceParams) (producer
 		return
 	}
 
-	if len(id) > 0 && transport.producers[id] != nil {
+	if len(id) > 0 && transport.getProducer(id) != nil {
 		err = NewTypeError(`a Producer with same id "%s" already exists`, id)
 		return
 	}
@@ -229,24 +492,74 @@ func (transport *baseTransport) Produce(params transportProduceParams) (producer
 		return
 	}
 
-	pc, _, _, ok := runtime.Caller(1)
+	if !workerSupports(transport.workerVersion, WorkerFeatureScalabilityMode) {
+		encodings := make([]RtpEncoding, len(rtpParameters.Encodings))
+		for i, encoding := range rtpParameters.Encodings {
+			encoding.ScalabilityMode = ""
+			encodings[i] = encoding
+		}
+		rtpParameters.Encodings = encodings
+	}
+
+	if err = validateProducerEncodings(rtpParameters); err != nil {
+		return
+	}
+
+	if transport.blockE2eeFeature != nil && isLayeredVideo(kind, rtpParameters) {
+		if err = transport.blockE2eeFeature(E2eeFeatureSimulcast); err != nil {
+			return
+		}
+	}
+
+	// Only set for the transports injecting media (PlainRtpTransports), whose
+	// SSRCs are chosen by senders unaware of the other ones of the Router. The
+	// SSRCs of WebRTC endpoints are only unique per transport.
+	if transport.getProducerBySsrc != nil {
+		var reservedSsrcs []uint32
+
+		if reservedSsrcs, err = transport.avoidSsrcCollisions(&rtpParameters, params.RemapSsrcOnCollision); err != nil {
+			return
+		}
+
+		defer func() {
+			if err != nil || dryRun {
+				transport.ssrcReservations.release(reservedSsrcs)
+			} else {
+				plan.reservedSsrcs = reservedSsrcs
+			}
+		}()
+	}
+
 	// Don"t do this in PipeTransports since there we must keep CNAME value in
 	// each Producer.
-	if details := runtime.FuncForPC(pc); ok && details != nil &&
-		!strings.Contains(details.Name(), "(*PipeTransport)") {
+	if !isPipeTransport {
+		cname := transport.cnameForProducers
+
 		// If CNAME is given and we don"t have yet a CNAME for Producers in this
 		// Transport, take it.
-		if len(transport.cnameForProducers) == 0 && len(rtpParameters.Rtcp.Cname) > 0 {
-			transport.cnameForProducers = rtpParameters.Rtcp.Cname
-		} else if len(transport.cnameForProducers) == 0 {
+		if len(cname) == 0 && len(rtpParameters.Rtcp.Cname) > 0 {
+			cname = rtpParameters.Rtcp.Cname
+		} else if len(cname) == 0 {
 			// Otherwise if we don"t have yet a CNAME for Producers and the RTP parameters
 			// do not include CNAME, create a random one.
-			transport.cnameForProducers = uuid.NewV4().String()[:8]
+			cname = uuid.NewV4().String()[:8]
+		}
+
+		if !dryRun {
+			transport.cnameForProducers = cname
 		}
 
 		// Override Producer"s CNAME.
-		rtpParameters.Rtcp.Cname = transport.cnameForProducers
+		rtpParameters.Rtcp.Cname = cname
+	}
+
+	// Drop the header extensions the worker couldn't tell apart.
+	headerExtensions, conflicts := resolveProducerHeaderExtensions(rtpParameters.HeaderExtensions)
+
+	for _, conflict := range conflicts {
+		transport.logger.Warnf("produce() | %s", conflict)
 	}
+	rtpParameters.HeaderExtensions = headerExtensions
 
 	routerRtpCapabilities := transport.getRouterRtpCapabilities()
 
@@ -255,6 +568,7 @@ func (transport *baseTransport) Produce(params transportProduceParams) (producer
 	if err != nil {
 		return
 	}
+	rtpMapping.HeaderExtensionConflicts = conflicts
 
 	consumableRtpParameters, err := GetConsumableRtpParameters(
 		kind, rtpParameters, routerRtpCapabilities, rtpMapping)
@@ -262,50 +576,135 @@ func (transport *baseTransport) Produce(params transportProduceParams) (producer
 		return
 	}
 
-	internal := transport.internal
-	if len(id) > 0 {
-		internal.ProducerId = id
-	} else {
-		internal.ProducerId = uuid.NewV4().String()
+	plan = ProduceDryRunResult{
+		RtpParameters:           rtpParameters,
+		RtpMapping:              rtpMapping,
+		ConsumableRtpParameters: consumableRtpParameters,
+		appData:                 appData,
 	}
 
-	reqData := H{
-		"kind":          kind,
-		"rtpParameters": rtpParameters,
-		"rtpMapping":    rtpMapping,
-		"paused":        paused,
+	return
+}
+
+// avoidSsrcCollisions checks that the SSRCs of the given RTP parameters are
+// not used by other Producers of the Router, replacing them if remap is set.
+// The SSRCs are reserved until released, so that the Producers being created
+// concurrently can't announce the same ones.
+func (transport *baseTransport) avoidSsrcCollisions(rtpParameters *RtpParameters, remap bool) (reserved []uint32, err error) {
+	defer func() {
+		if err != nil {
+			transport.ssrcReservations.release(reserved)
+			reserved = nil
+		}
+	}()
+
+	announced := make(map[uint32]bool)
+	encodings := make([]RtpEncoding, len(rtpParameters.Encodings))
+
+	copy(encodings, rtpParameters.Encodings)
+
+	for i := range encodings {
+		ssrcs := []*uint32{&encodings[i].Ssrc}
+
+		if encodings[i].Rtx != nil {
+			rtx := *encodings[i].Rtx
+			encodings[i].Rtx = &rtx
+			ssrcs = append(ssrcs, &rtx.Ssrc)
+		}
+		if encodings[i].Fec != nil {
+			fec := *encodings[i].Fec
+			encodings[i].Fec = &fec
+			ssrcs = append(ssrcs, &fec.Ssrc)
+		}
+
+		for _, ssrc := range ssrcs {
+			if *ssrc == 0 {
+				continue
+			}
+
+			var producer *Producer
+
+			if !announced[*ssrc] {
+				var ok bool
+
+				if producer, ok = transport.ssrcReservations.reserve(*ssrc, transport.getProducerBySsrc); ok {
+					announced[*ssrc] = true
+					reserved = append(reserved, *ssrc)
+					continue
+				}
+			}
+
+			if !remap {
+				collision := SsrcCollisionError{Ssrc: *ssrc}
+				if producer != nil {
+					collision.ProducerId = producer.Id()
+				}
+				return reserved, collision
+			}
+
+			var newSsrc uint32
+
+			for {
+				newSsrc = generateRandomNumber()
+
+				if !announced[newSsrc] {
+					if _, ok := transport.ssrcReservations.reserve(newSsrc, transport.getProducerBySsrc); ok {
+						break
+					}
+				}
+			}
+
+			transport.logger.Warnf("produce() | ssrc %d collides, remapped to %d", *ssrc, newSsrc)
+
+			*ssrc = newSsrc
+			announced[newSsrc] = true
+			reserved = append(reserved, newSsrc)
+		}
 	}
 
-	resp := transport.channel.Request("transport.produce", internal, reqData)
+	rtpParameters.Encodings = encodings
 
-	var status struct {
-		Type string
+	return
+}
+
+// ssrcReservations are the SSRCs of the Producers being created in a Router,
+// reserved from their collision check until the Producers are registered.
+type ssrcReservations struct {
+	mu    sync.Mutex
+	ssrcs map[uint32]struct{}
+}
+
+func newSsrcReservations() *ssrcReservations {
+	return &ssrcReservations{
+		ssrcs: make(map[uint32]struct{}),
 	}
-	if err = resp.Unmarshal(&status); err != nil {
+}
+
+// reserve reserves the given SSRC, unless reserved or announced by the
+// returned Producer.
+func (r *ssrcReservations) reserve(ssrc uint32, getProducerBySsrc fetchProducerBySsrcFunc) (producer *Producer, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, reserved := r.ssrcs[ssrc]; reserved {
 		return
 	}
-
-	producerData := producerData{
-		Kind:                    kind,
-		RtpParameters:           rtpParameters,
-		Type:                    status.Type,
-		ConsumableRtpParameters: consumableRtpParameters,
+	if producer = getProducerBySsrc(ssrc); producer != nil {
+		return
 	}
 
-	producer = NewProducer(internal, producerData, transport.channel, appData, paused)
-
-	transport.producers[producer.Id()] = producer
-	producer.On("@close", func() {
-		delete(transport.producers, producer.Id())
-		transport.Emit("@producerclose", producer)
-	})
+	r.ssrcs[ssrc] = struct{}{}
 
-	transport.Emit("@newproducer", producer)
+	return nil, true
+}
 
-	// Emit observer event.
-	transport.observer.SafeEmit("newproducer", producer)
+func (r *ssrcReservations) release(ssrcs []uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	return
+	for _, ssrc := range ssrcs {
+		delete(r.ssrcs, ssrc)
+	}
 }
 
 /**
@@ -315,35 +714,29 @@ func (transport *baseTransport) Produce(params transportProduceParams) (producer
  * @param rtpCapabilities - Remote RTP capabilities.
  * @param [paused=false] - Whether the Consumer must start paused.
  * @param [appData={}] - Custom app data.
+ * @param [mid] - MID of the Consumer RTP parameters.
+ * @param [enabledHeaderExtensions] - Header extension URIs to keep.
+ * @param [disabledHeaderExtensions] - Header extension URIs to remove.
  */
-func (transport *baseTransport) Consume(params transportConsumeParams) (consumer *Consumer, err error) {
+func (transport *baseTransport) Consume(params TransportConsumeParams) (consumer *Consumer, err error) {
 	transport.logger.Debug("consume()")
 
-	producerId := params.ProducerId
-	rtpCapabilities := params.RtpCapabilities
-	paused := params.Paused
-	appData := params.AppData
-
-	if appData == nil {
-		appData = H{}
-	}
-	if !isObject(appData) {
-		err = NewTypeError("if given, appData must be an object")
+	producer, rtpParameters, appData, err := transport.prepareConsume(params)
+	if err != nil {
 		return
 	}
-
-	producer := transport.getProducerById(producerId)
-
-	if producer == nil {
-		err = fmt.Errorf(`Producer with id "%s" not found`, producerId)
+	if err = transport.authorize(AuthorizationRequest{
+		Action:     AuthorizeConsume,
+		Kind:       producer.Kind(),
+		ProducerId: producer.Id(),
+		AppData:    appData,
+	}); err != nil {
 		return
 	}
 
-	rtpParameters, err := GetConsumerRtpParameters(
-		producer.ConsumableRtpParameters(), rtpCapabilities)
-	if err != nil {
-		return
-	}
+	producerId := params.ProducerId
+	rtpCapabilities := params.RtpCapabilities
+	paused := params.Paused
 
 	internal := transport.internal
 	internal.ConsumerId = uuid.NewV4().String()
@@ -357,6 +750,11 @@ func (transport *baseTransport) Consume(params transportConsumeParams) (consumer
 		"consumableRtpEncodings": producer.ConsumableRtpParameters().Encodings,
 	}
 
+	releaseConsumer, err := transport.consumerLimiter.acquire()
+	if err != nil {
+		return
+	}
+
 	resp := transport.channel.Request("transport.consume", internal, reqData)
 
 	var status struct {
@@ -365,6 +763,7 @@ func (transport *baseTransport) Consume(params transportConsumeParams) (consumer
 		Score          *ConsumerScore
 	}
 	if err = resp.Unmarshal(&status); err != nil {
+		releaseConsumer()
 		return
 	}
 
@@ -372,6 +771,8 @@ func (transport *baseTransport) Consume(params transportConsumeParams) (consumer
 		Kind:          producer.Kind(),
 		RtpParameters: rtpParameters,
 		Type:          producer.Type(),
+		ScoreInterval: transport.notificationRateLimits.ScoreInterval,
+		AppLogger:     transport.appLogger,
 	}
 
 	consumer = NewConsumer(
@@ -383,17 +784,66 @@ func (transport *baseTransport) Consume(params transportConsumeParams) (consumer
 		status.ProducerPaused,
 		status.Score,
 	)
+	consumer.onProducerClose = params.OnProducerClose
+	consumer.getProducerById = transport.getProducerById
+	consumer.observer.Once("close", releaseConsumer)
 
-	transport.consumers[consumer.Id()] = consumer
-	consumer.On("@close", func() {
-		delete(transport.consumers, consumer.Id())
-	})
-	consumer.On("@producerclose", func() {
-		delete(transport.consumers, consumer.Id())
-	})
+	if err = transport.addConsumer(consumer); err != nil {
+		return
+	}
+
+	producer.addConsumer(consumer)
 
 	// Emit observer event.
 	transport.observer.SafeEmit("newconsumer", consumer)
 
+	// Warn about clients missing capabilities used by the Producer.
+	report := producer.CheckRtpCapabilities(rtpCapabilities)
+
+	if report.Degraded() {
+		transport.logger.Warnf("consumer created for a degraded client [consumerId:%s]", consumer.Id())
+
+		transport.SafeEmit("degradedconsumer", consumer, report)
+		transport.observer.SafeEmit("degradedconsumer", consumer, report)
+	}
+
+	return
+}
+
+// prepareConsume validates the given Consumer parameters and computes the
+// Consumer RTP parameters.
+func (transport *baseTransport) prepareConsume(params TransportConsumeParams) (
+	producer *Producer, rtpParameters RtpParameters, appData interface{}, err error,
+) {
+	appData = params.AppData
+
+	if appData == nil {
+		appData = H{}
+	}
+	if !isObject(appData) {
+		err = NewTypeError("if given, appData must be an object")
+		return
+	}
+
+	producer = transport.getProducerById(params.ProducerId)
+
+	if producer == nil {
+		err = fmt.Errorf(`Producer with id "%s" not found`, params.ProducerId)
+		return
+	}
+
+	rtpParameters, conflicts, err := getConsumerRtpParameters(
+		producer.ConsumableRtpParameters(), params.RtpCapabilities)
+	if err != nil {
+		return
+	}
+
+	for _, conflict := range conflicts {
+		transport.logger.Warnf("consume() | %s", conflict)
+	}
+
+	err = ApplyConsumerRtpParametersOverrides(&rtpParameters,
+		params.Mid, params.EnabledHeaderExtensions, params.DisabledHeaderExtensions)
+
 	return
 }
@@ -0,0 +1,43 @@
+package mediasoup
+
+// ProduceDryRunResult is what Produce would do with the given parameters.
+type ProduceDryRunResult struct {
+	// RTP parameters of the Producer (with the remapped SSRCs and CNAME). If
+	// the transport has no CNAME yet, the one of Produce will differ.
+	RtpParameters RtpParameters
+	// Mapping of the RTP parameters to the Router ones.
+	RtpMapping RtpMappingParameters
+	// RTP parameters the Consumers of the Producer are created from.
+	ConsumableRtpParameters RtpParameters
+	appData                 interface{}
+	// SSRCs reserved until the Producer is created.
+	reservedSsrcs []uint32
+}
+
+/**
+ * Validate the given Producer parameters and compute their ORTC mapping as
+ * Produce does, without creating the Producer in the worker, e.g. to reject a
+ * client intent early.
+ *
+ * @param params - Same as Produce.
+ */
+func (transport *baseTransport) ProduceDryRun(params TransportProduceParams) (ProduceDryRunResult, error) {
+	transport.logger.Debug("produceDryRun()")
+
+	return transport.prepareProduce(params, true)
+}
+
+/**
+ * Validate the given Consumer parameters and compute the RTP parameters of
+ * the Consumer as Consume does, without creating it in the worker, e.g. to
+ * know whether a client can consume a Producer and how.
+ *
+ * @param params - Same as Consume.
+ */
+func (transport *baseTransport) ConsumeDryRun(params TransportConsumeParams) (RtpParameters, error) {
+	transport.logger.Debug("consumeDryRun()")
+
+	_, rtpParameters, _, err := transport.prepareConsume(params)
+
+	return rtpParameters, err
+}
@@ -0,0 +1,82 @@
+package mediasoup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportDryRun(t *testing.T) {
+	routerRtpCapabilities, err := GenerateRouterRtpCapabilities(testRouterMediaCodecs)
+	require.NoError(t, err)
+
+	var producer *Producer
+
+	transport := newTransport(createTransportParams{
+		GetRouterRtpCapabilities: func() RtpCapabilities {
+			return routerRtpCapabilities
+		},
+		GetProducerById: func(producerId string) *Producer {
+			if producer != nil && producerId == producer.Id() {
+				return producer
+			}
+			return nil
+		},
+		GetProducerBySsrc: func(ssrc uint32) *Producer {
+			return nil
+		},
+	})
+
+	rtpParameters := RtpParameters{
+		Mid: "AUDIO",
+		Codecs: []RtpCodecCapability{
+			{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+		},
+		Encodings: []RtpEncoding{{Ssrc: 11111111}},
+	}
+
+	_, err = transport.ProduceDryRun(TransportProduceParams{Kind: "data", RtpParameters: rtpParameters})
+	assert.Error(t, err)
+
+	result, err := transport.ProduceDryRun(TransportProduceParams{Kind: "audio", RtpParameters: rtpParameters})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.RtpParameters.Rtcp.Cname)
+	assert.Empty(t, transport.cnameForProducers)
+	require.Len(t, result.RtpMapping.Codecs, 1)
+	assert.Equal(t, 111, result.RtpMapping.Codecs[0].PayloadType)
+	require.Len(t, result.ConsumableRtpParameters.Codecs, 1)
+	assert.Equal(t, "audio/opus", result.ConsumableRtpParameters.Codecs[0].MimeType)
+
+	_, err = transport.ConsumeDryRun(TransportConsumeParams{ProducerId: "unknown"})
+	assert.Error(t, err)
+
+	producer = &Producer{
+		internal: internalData{ProducerId: "producer"},
+		data: producerData{
+			Kind:                    "audio",
+			RtpParameters:           result.RtpParameters,
+			Type:                    "simple",
+			ConsumableRtpParameters: result.ConsumableRtpParameters,
+		},
+	}
+
+	consumerRtpParameters, err := transport.ConsumeDryRun(TransportConsumeParams{
+		ProducerId:      "producer",
+		RtpCapabilities: routerRtpCapabilities,
+	})
+	require.NoError(t, err)
+	require.Len(t, consumerRtpParameters.Codecs, 1)
+	assert.Equal(t, "audio/opus", consumerRtpParameters.Codecs[0].MimeType)
+	require.Len(t, consumerRtpParameters.Encodings, 1)
+
+	_, err = transport.ConsumeDryRun(TransportConsumeParams{
+		ProducerId: "producer",
+		RtpCapabilities: RtpCapabilities{
+			Codecs: []RtpCodecCapability{
+				{Kind: "audio", MimeType: "audio/PCMU", PreferredPayloadType: 0, ClockRate: 8000},
+			},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,219 @@
+package mediasoup
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DTLS roles.
+const (
+	DtlsRoleAuto   = "auto"
+	DtlsRoleClient = "client"
+	DtlsRoleServer = "server"
+)
+
+// DTLS fingerprint algorithms, with the length of their digest.
+const (
+	DtlsFingerprintSha1   = "sha-1"
+	DtlsFingerprintSha224 = "sha-224"
+	DtlsFingerprintSha256 = "sha-256"
+	DtlsFingerprintSha384 = "sha-384"
+	DtlsFingerprintSha512 = "sha-512"
+	DtlsFingerprintMd5    = "md5"
+	DtlsFingerprintMd2    = "md2"
+)
+
+var dtlsFingerprintLengths = map[string]int{
+	DtlsFingerprintSha1:   20,
+	DtlsFingerprintSha224: 28,
+	DtlsFingerprintSha256: 32,
+	DtlsFingerprintSha384: 48,
+	DtlsFingerprintSha512: 64,
+	DtlsFingerprintMd5:    16,
+	DtlsFingerprintMd2:    16,
+}
+
+// ICE candidate types and TCP types.
+const (
+	IceCandidateTypeHost  = "host"
+	IceCandidateTypeSrflx = "srflx"
+	IceCandidateTypePrflx = "prflx"
+	IceCandidateTypeRelay = "relay"
+
+	IceCandidateTcpTypePassive = "passive"
+	IceCandidateTcpTypeActive  = "active"
+	IceCandidateTcpTypeSo      = "so"
+)
+
+// SctpParameters of the SCTP association of a transport, as exchanged with
+// mediasoup-client. OS and MIS are the numbers of outgoing and incoming
+// streams.
+type SctpParameters struct {
+	Port           uint16 `json:"port"`
+	OS             uint16 `json:"OS"`
+	MIS            uint16 `json:"MIS"`
+	MaxMessageSize uint32 `json:"maxMessageSize"`
+}
+
+// The parameters exchanged with mediasoup-client are parsed strictly: unknown
+// (e.g. misnamed) fields are rejected instead of being silently dropped, and
+// the values are validated.
+
+// ParseDtlsParameters parses and validates the DTLS parameters of a client.
+func ParseDtlsParameters(data []byte) (params DtlsParameters, err error) {
+	if err = decodeStrictJSON("DtlsParameters", data, &params); err != nil {
+		return
+	}
+
+	err = params.Validate()
+
+	return
+}
+
+// ParseIceParameters parses and validates ICE parameters.
+func ParseIceParameters(data []byte) (params IceParameters, err error) {
+	if err = decodeStrictJSON("IceParameters", data, &params); err != nil {
+		return
+	}
+
+	err = params.Validate()
+
+	return
+}
+
+// ParseIceCandidates parses and validates a list of ICE candidates.
+func ParseIceCandidates(data []byte) (candidates []IceCandidate, err error) {
+	if err = decodeStrictJSON("IceCandidates", data, &candidates); err != nil {
+		return
+	}
+
+	for i, candidate := range candidates {
+		if err = candidate.validate(fmt.Sprintf("IceCandidates[%d]", i)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ParseSctpParameters parses and validates SCTP parameters.
+func ParseSctpParameters(data []byte) (params SctpParameters, err error) {
+	if err = decodeStrictJSON("SctpParameters", data, &params); err != nil {
+		return
+	}
+
+	err = params.Validate()
+
+	return
+}
+
+func decodeStrictJSON(field string, data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return NewValidationError(field, "%s", err)
+	}
+
+	return nil
+}
+
+// Validate checks the role and the fingerprints, whose values must be colon
+// separated hex bytes of the digest length of their algorithm.
+func (p DtlsParameters) Validate() error {
+	switch p.Role {
+	case "", DtlsRoleAuto, DtlsRoleClient, DtlsRoleServer:
+	default:
+		return NewValidationError("DtlsParameters.Role", `invalid role "%s"`, p.Role)
+	}
+
+	if len(p.Fingerprints) == 0 {
+		return NewValidationError("DtlsParameters.Fingerprints", "must not be empty")
+	}
+
+	for i, fingerprint := range p.Fingerprints {
+		field := fmt.Sprintf("DtlsParameters.Fingerprints[%d]", i)
+
+		length, ok := dtlsFingerprintLengths[fingerprint.Algorithm]
+		if !ok {
+			return NewValidationError(field+".Algorithm", `invalid algorithm "%s"`, fingerprint.Algorithm)
+		}
+
+		digest, err := hex.DecodeString(strings.Replace(fingerprint.Value, ":", "", -1))
+		if err != nil || len(digest) != length || len(fingerprint.Value) != 3*length-1 {
+			return NewValidationError(field+".Value", "must be %d colon separated hex bytes", length)
+		}
+	}
+
+	return nil
+}
+
+func (p IceParameters) Validate() error {
+	if len(p.UsernameFragment) == 0 {
+		return NewValidationError("IceParameters.UsernameFragment", "must not be empty")
+	}
+	if len(p.Password) == 0 {
+		return NewValidationError("IceParameters.Password", "must not be empty")
+	}
+
+	return nil
+}
+
+func (c IceCandidate) Validate() error {
+	return c.validate("IceCandidate")
+}
+
+func (c IceCandidate) validate(field string) error {
+	if len(c.Foundation) == 0 {
+		return NewValidationError(field+".Foundation", "must not be empty")
+	}
+	if len(c.Ip) == 0 {
+		return NewValidationError(field+".Ip", "must not be empty")
+	}
+	if c.Port == 0 {
+		return NewValidationError(field+".Port", "must not be 0")
+	}
+
+	switch c.Type {
+	case IceCandidateTypeHost, IceCandidateTypeSrflx, IceCandidateTypePrflx, IceCandidateTypeRelay:
+	default:
+		return NewValidationError(field+".Type", `invalid type "%s"`, c.Type)
+	}
+
+	switch c.Protocol {
+	case "udp":
+		if len(c.TcpType) > 0 {
+			return NewValidationError(field+".TcpType", "must be empty for udp")
+		}
+	case "tcp":
+		switch c.TcpType {
+		case IceCandidateTcpTypePassive, IceCandidateTcpTypeActive, IceCandidateTcpTypeSo:
+		default:
+			return NewValidationError(field+".TcpType", `invalid tcp type "%s"`, c.TcpType)
+		}
+	default:
+		return NewValidationError(field+".Protocol", `invalid protocol "%s"`, c.Protocol)
+	}
+
+	return nil
+}
+
+func (p SctpParameters) Validate() error {
+	if p.Port == 0 {
+		return NewValidationError("SctpParameters.Port", "must not be 0")
+	}
+	if p.OS == 0 {
+		return NewValidationError("SctpParameters.OS", "must not be 0")
+	}
+	if p.MIS == 0 {
+		return NewValidationError("SctpParameters.MIS", "must not be 0")
+	}
+	if p.MaxMessageSize == 0 {
+		return NewValidationError("SctpParameters.MaxMessageSize", "must not be 0")
+	}
+
+	return nil
+}
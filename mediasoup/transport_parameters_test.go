@@ -0,0 +1,95 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDtlsParameters(t *testing.T) {
+	params, err := ParseDtlsParameters([]byte(`{
+		"role": "client",
+		"fingerprints": [{
+			"algorithm": "sha-256",
+			"value": "82:5A:68:3D:36:C3:0A:DE:AF:E7:32:43:D2:88:83:57:AC:2D:65:E5:80:C4:B6:FB:AF:1A:A0:21:9F:6D:0C:AD"
+		}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, DtlsRoleClient, params.Role)
+	assert.Equal(t, DtlsFingerprintSha256, params.Fingerprints[0].Algorithm)
+
+	// Misnamed field.
+	_, err = ParseDtlsParameters([]byte(`{"role": "client", "fingerprints": [{"hash": "sha-256", "value": "00"}]}`))
+	assert.IsType(t, ValidationError{}, err)
+
+	invalid := []struct {
+		field  string
+		params DtlsParameters
+	}{
+		{"DtlsParameters.Role", DtlsParameters{Role: "chicken"}},
+		{"DtlsParameters.Fingerprints", DtlsParameters{}},
+		{"DtlsParameters.Fingerprints[0].Algorithm", DtlsParameters{
+			Fingerprints: []DtlsFingerprint{{Algorithm: "sha-256000", Value: "00"}},
+		}},
+		{"DtlsParameters.Fingerprints[0].Value", DtlsParameters{
+			Fingerprints: []DtlsFingerprint{{Algorithm: "sha-1", Value: "82:5A:68"}},
+		}},
+		{"DtlsParameters.Fingerprints[0].Value", DtlsParameters{
+			Fingerprints: []DtlsFingerprint{{Algorithm: "md5", Value: "825A683D36C30ADEAFE73243D2888357"}},
+		}},
+	}
+
+	for _, test := range invalid {
+		err := test.params.Validate()
+		if assert.IsType(t, ValidationError{}, err) {
+			assert.Equal(t, test.field, err.(ValidationError).Field)
+		}
+	}
+}
+
+func TestParseIceParameters(t *testing.T) {
+	params, err := ParseIceParameters([]byte(`{"usernameFragment": "u", "password": "p", "iceLite": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, IceParameters{UsernameFragment: "u", Password: "p", IceLite: true}, params)
+
+	_, err = ParseIceParameters([]byte(`{"ufrag": "u", "password": "p"}`))
+	assert.Error(t, err)
+
+	_, err = ParseIceParameters([]byte(`{"usernameFragment": "u"}`))
+	assert.Equal(t, NewValidationError("IceParameters.Password", "must not be empty"), err)
+
+	// Not dropped when false.
+	data, _ := json.Marshal(IceParameters{UsernameFragment: "u", Password: "p"})
+	assert.JSONEq(t, `{"usernameFragment": "u", "password": "p", "iceLite": false}`, string(data))
+}
+
+func TestParseIceCandidates(t *testing.T) {
+	candidates, err := ParseIceCandidates([]byte(`[
+		{"foundation": "udpcandidate", "priority": 1076302079, "ip": "1.2.3.4", "port": 40000, "type": "host", "protocol": "udp"},
+		{"foundation": "tcpcandidate", "priority": 1076276479, "ip": "1.2.3.4", "port": 40001, "type": "host", "protocol": "tcp", "tcpType": "passive"}
+	]`))
+	require.NoError(t, err)
+	assert.Len(t, candidates, 2)
+
+	_, err = ParseIceCandidates([]byte(`[
+		{"foundation": "tcpcandidate", "priority": 1, "ip": "1.2.3.4", "port": 40001, "type": "host", "protocol": "tcp"}
+	]`))
+	assert.Equal(t, NewValidationError("IceCandidates[0].TcpType", `invalid tcp type ""`), err)
+
+	err = IceCandidate{Foundation: "f", Ip: "1.2.3.4", Port: 1, Type: "local", Protocol: "udp"}.Validate()
+	assert.Equal(t, NewValidationError("IceCandidate.Type", `invalid type "local"`), err)
+}
+
+func TestParseSctpParameters(t *testing.T) {
+	params, err := ParseSctpParameters([]byte(`{"port": 5000, "OS": 1024, "MIS": 1024, "maxMessageSize": 262144}`))
+	require.NoError(t, err)
+	assert.Equal(t, SctpParameters{Port: 5000, OS: 1024, MIS: 1024, MaxMessageSize: 262144}, params)
+
+	_, err = ParseSctpParameters([]byte(`{"port": 5000, "OS": 1024, "MIS": 1024, "maxMessageSize": 262144, "numStreams": 1024}`))
+	assert.Error(t, err)
+
+	_, err = ParseSctpParameters([]byte(`{"port": 5000, "OS": 1024, "MIS": 1024}`))
+	assert.Equal(t, NewValidationError("SctpParameters.MaxMessageSize", "must not be 0"), err)
+}
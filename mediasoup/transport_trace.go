@@ -0,0 +1,101 @@
+package mediasoup
+
+import (
+	"encoding/json"
+)
+
+// Number of BweSamples kept by a Transport.
+const bweHistoryCapacity = 300
+
+// TransportTraceEventData is the data of the "trace" event of Transports.
+type TransportTraceEventData struct {
+	// "probation" or "bwe".
+	Type string `json:"type,omitempty"`
+	// Worker time in ms.
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// "in" or "out".
+	Direction string          `json:"direction,omitempty"`
+	Info      json.RawMessage `json:"info,omitempty"`
+}
+
+// BweSample is a bandwidth estimation of a Transport, from a "bwe" trace event.
+type BweSample struct {
+	// Worker time in ms.
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// "transport-cc" or "remb".
+	Type                    string `json:"type,omitempty"`
+	DesiredBitrate          uint32 `json:"desiredBitrate,omitempty"`
+	EffectiveDesiredBitrate uint32 `json:"effectiveDesiredBitrate,omitempty"`
+	MinBitrate              uint32 `json:"minBitrate,omitempty"`
+	MaxBitrate              uint32 `json:"maxBitrate,omitempty"`
+	StartBitrate            uint32 `json:"startBitrate,omitempty"`
+	MaxPaddingBitrate       uint32 `json:"maxPaddingBitrate,omitempty"`
+	AvailableBitrate        uint32 `json:"availableBitrate,omitempty"`
+	// RTT of the transport-cc feedback in ms, 0 if not reported by the worker.
+	Rtt float64 `json:"rtt,omitempty"`
+}
+
+/**
+ * Enable "trace" events.
+ *
+ * @param {[]String} types - "probation" and/or "bwe", none to disable them.
+ */
+func (transport *baseTransport) EnableTraceEvent(types ...string) error {
+	transport.logger.Debug("enableTraceEvent()")
+
+	if types == nil {
+		types = []string{}
+	}
+
+	resp := transport.channel.Request("transport.enableTraceEvent", transport.internal, H{"types": types})
+
+	return resp.Err()
+}
+
+// BweHistory returns the last bandwidth estimations of the Transport (oldest
+// first), recorded while "bwe" trace events are enabled.
+func (transport *baseTransport) BweHistory() []BweSample {
+	transport.traceLocker.Lock()
+	defer transport.traceLocker.Unlock()
+
+	history := make([]BweSample, 0, len(transport.bweHistory))
+	history = append(history, transport.bweHistory[transport.bweHistoryStart:]...)
+	history = append(history, transport.bweHistory[:transport.bweHistoryStart]...)
+
+	return history
+}
+
+// handleTrace handles the "trace" notification of the worker.
+func (transport *baseTransport) handleTrace(rawData json.RawMessage) {
+	var trace TransportTraceEventData
+
+	if err := json.Unmarshal([]byte(rawData), &trace); err != nil {
+		transport.logger.Errorf("invalid trace event: %s", err)
+		return
+	}
+
+	if trace.Type == "bwe" {
+		sample := BweSample{Timestamp: trace.Timestamp}
+		json.Unmarshal([]byte(trace.Info), &sample)
+
+		transport.recordBweSample(sample)
+	}
+
+	transport.SafeEmit("trace", trace)
+
+	// Emit observer event.
+	transport.observer.SafeEmit("trace", trace)
+}
+
+func (transport *baseTransport) recordBweSample(sample BweSample) {
+	transport.traceLocker.Lock()
+	defer transport.traceLocker.Unlock()
+
+	if len(transport.bweHistory) < bweHistoryCapacity {
+		transport.bweHistory = append(transport.bweHistory, sample)
+		return
+	}
+
+	transport.bweHistory[transport.bweHistoryStart] = sample
+	transport.bweHistoryStart = (transport.bweHistoryStart + 1) % bweHistoryCapacity
+}
@@ -0,0 +1,66 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportHandleTrace(t *testing.T) {
+	transport := newTransport(createTransportParams{})
+
+	var traces []TransportTraceEventData
+	transport.Observer().On("trace", func(trace TransportTraceEventData) {
+		traces = append(traces, trace)
+	})
+
+	transport.handleTrace(json.RawMessage(`{
+		"type": "probation",
+		"timestamp": 1000,
+		"direction": "out",
+		"info": {"sequenceNumber": 1}
+	}`))
+
+	for i := 0; i < bweHistoryCapacity+2; i++ {
+		transport.handleTrace(json.RawMessage(fmt.Sprintf(`{
+			"type": "bwe",
+			"timestamp": %d,
+			"direction": "out",
+			"info": {
+				"type": "transport-cc",
+				"desiredBitrate": 1000000,
+				"availableBitrate": %d,
+				"rtt": 25.5
+			}
+		}`, 2000+i, 300000+i)))
+	}
+
+	require.Len(t, traces, bweHistoryCapacity+3)
+	assert.Equal(t, "probation", traces[0].Type)
+	assert.Equal(t, "out", traces[0].Direction)
+	assert.JSONEq(t, `{"sequenceNumber": 1}`, string(traces[0].Info))
+
+	// The oldest samples are dropped.
+	history := transport.BweHistory()
+	require.Len(t, history, bweHistoryCapacity)
+	assert.Equal(t, BweSample{
+		Timestamp:        2002,
+		Type:             "transport-cc",
+		DesiredBitrate:   1000000,
+		AvailableBitrate: 300002,
+		Rtt:              25.5,
+	}, history[0])
+	assert.EqualValues(t, 2000+bweHistoryCapacity+1, history[bweHistoryCapacity-1].Timestamp)
+}
+
+func TestTransportEnableTraceEvent(t *testing.T) {
+	router, transport := setupWebRtcTest(t)
+	defer router.Close()
+
+	assert.NoError(t, transport.EnableTraceEvent("probation", "bwe"))
+	assert.NoError(t, transport.EnableTraceEvent())
+	assert.Empty(t, transport.BweHistory())
+}
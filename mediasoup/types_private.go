@@ -1,15 +1,50 @@
 package mediasoup
 
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
 type internalData struct {
-	RouterId      string `json:"routerId,omitempty"`
-	TransportId   string `json:"transportId,omitempty"`
-	ProducerId    string `json:"producerId,omitempty"`
-	ConsumerId    string `json:"consumerId,omitempty"`
-	RtpObserverId string `json:"rtpObserverId,omitempty"`
+	RouterId       string `json:"routerId,omitempty"`
+	TransportId    string `json:"transportId,omitempty"`
+	ProducerId     string `json:"producerId,omitempty"`
+	ConsumerId     string `json:"consumerId,omitempty"`
+	RtpObserverId  string `json:"rtpObserverId,omitempty"`
+	WebRtcServerId string `json:"webRtcServerId,omitempty"`
+}
+
+// logFields returns the non-empty ids as log fields of the entity emitters.
+func (d internalData) logFields() logrus.Fields {
+	fields := logrus.Fields{}
+
+	if len(d.RouterId) > 0 {
+		fields["routerId"] = d.RouterId
+	}
+	if len(d.TransportId) > 0 {
+		fields["transportId"] = d.TransportId
+	}
+	if len(d.ProducerId) > 0 {
+		fields["producerId"] = d.ProducerId
+	}
+	if len(d.ConsumerId) > 0 {
+		fields["consumerId"] = d.ConsumerId
+	}
+	if len(d.RtpObserverId) > 0 {
+		fields["rtpObserverId"] = d.RtpObserverId
+	}
+	if len(d.WebRtcServerId) > 0 {
+		fields["webRtcServerId"] = d.WebRtcServerId
+	}
+
+	return fields
 }
 
 type routerData struct {
+	MediaCodecs     []RtpCodecCapability
 	RtpCapabilities RtpCapabilities
+	Settings        RouterSettings
 }
 
 type producerData struct {
@@ -17,47 +52,45 @@ type producerData struct {
 	Type                    string
 	RtpParameters           RtpParameters
 	ConsumableRtpParameters RtpParameters
+	// Minimum interval between two "score" events.
+	ScoreInterval time.Duration
+	// Logger of the Router, AppLogger() if nil.
+	AppLogger logrus.FieldLogger
 }
 
 type consumerData struct {
 	Kind          string
 	Type          string
 	RtpParameters RtpParameters
-}
-
-type transportProduceParams struct {
-	Id            string                `json:"id,omitempty"`
-	Kind          string                `json:"kind,omitempty"`
-	RtpParameters RtpParameters `json:"rtpParameters,omitempty"`
-	Paused        bool                  `json:"paused,omitempty"`
-	AppData       interface{}           `json:"appData,omitempty"`
-}
-
-type transportConsumeParams struct {
-	ProducerId      string          `json:"producerId,omitempty"`
-	RtpCapabilities RtpCapabilities `json:"rtpCapabilities,omitempty"`
-	Paused          bool            `json:"paused,omitempty"`
-	AppData         interface{}     `json:"appData,omitempty"`
+	// Minimum interval between two "score" events.
+	ScoreInterval time.Duration
+	// Logger of the Router, AppLogger() if nil.
+	AppLogger logrus.FieldLogger
 }
 
 type createTransportParams struct {
 	Internal                 internalData
 	Channel                  *Channel
+	Options                  interface{}
 	AppData                  interface{}
+	AppLogger                logrus.FieldLogger
 	GetRouterRtpCapabilities fetchRouterRtpCapabilitiesFunc
 	GetProducerById          fetchProducerFunc
-}
-
-type transportConnectParams struct {
-	// pipe and plain transport
-	Ip   string `json:"ip,omitempty"`
-	Port uint16 `json:"port,omitempty"`
-	// plain transport
-	RtcpPort uint16 `json:"rtcpPort,omitempty"`
-	// webrtc transport
-	DtlsParameters *DtlsParameters `json:"dtlsParameters,omitempty"`
+	GetProducerBySsrc        fetchProducerBySsrcFunc
+	SsrcReservations         *ssrcReservations
+	WorkerVersion            string
+	ConsumerLimiter          *consumerLimiter
+	// "webrtc", "plain" or "pipe".
+	Type       string
+	Authorizer Authorizer
+	// Fails if the Router is end-to-end encrypted.
+	BlockE2eeFeature func(feature string) error
+	// Rate limits of the events of the Producers and Consumers.
+	NotificationRateLimits NotificationRateLimits
 }
 
 type fetchProducerFunc func(producerId string) *Producer
 
+type fetchProducerBySsrcFunc func(ssrc uint32) *Producer
+
 type fetchRouterRtpCapabilitiesFunc func() RtpCapabilities
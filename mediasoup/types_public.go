@@ -46,7 +46,21 @@ type VideoOrientation struct {
 type ProducerScore struct {
 	Score uint8  `json:"score"`
 	Ssrc  uint32 `json:"ssrc"`
-	Rid   uint32 `json:"rid,omitempty"`
+	Rid   string `json:"rid,omitempty"`
+}
+
+// SimulcastMismatch is the parameter of event "simulcastmismatch" emitted by
+// Producer, when announced simulcast encodings have no received stream for a
+// while, e.g. only one layer works.
+type SimulcastMismatch struct {
+	Reason string `json:"reason"`
+	// Announced encodings the worker scored a stream for.
+	MappedEncodings []RtpEncoding `json:"mappedEncodings"`
+	// Announced encodings without a scored stream.
+	MissingEncodings []RtpEncoding `json:"missingEncodings"`
+	// Whether the RTP stream id header extension is negotiated, needed by
+	// encodings announced by rid.
+	RidNegotiated bool `json:"ridNegotiated"`
 }
 
 type ConsumerScore struct {
@@ -85,39 +99,61 @@ type TransportTuple struct {
 	Protocol   string `json:"protocol,omitempty"`
 }
 
+// IceParameters, IceCandidate and DtlsParameters are exchanged with
+// mediasoup-client, their fields are always serialized except the optional ones
+// (see ParseDtlsParameters for the strict parsing).
+
 type IceParameters struct {
-	UsernameFragment string `json:"usernameFragment,omitempty"`
-	Password         string `json:"password,omitempty"`
-	IceLite          bool   `json:"iceLite,omitempty"`
+	UsernameFragment string `json:"usernameFragment"`
+	Password         string `json:"password"`
+	IceLite          bool   `json:"iceLite"`
 }
 
 type IceCandidate struct {
-	Foundation string `json:"foundation,omitempty"`
-	Priority   uint32 `json:"priority,omitempty"`
-	Ip         string `json:"ip,omitempty"`
-	Port       uint16 `json:"port,omitempty"`
-	Type       string `json:"type,omitempty"`
-	Protocol   string `json:"protocol,omitempty"`
-	TcpType    string `json:"tcpType,omitempty"`
+	Foundation string `json:"foundation"`
+	Priority   uint32 `json:"priority"`
+	Ip         string `json:"ip"`
+	Port       uint16 `json:"port"`
+	Type       string `json:"type"`
+	Protocol   string `json:"protocol"`
+	// Just for tcp candidates.
+	TcpType string `json:"tcpType,omitempty"`
 }
 
 type DtlsParameters struct {
+	// "auto" if empty.
 	Role         string            `json:"role,omitempty"`
-	Fingerprints []DtlsFingerprint `json:"fingerprints,omitempty"`
+	Fingerprints []DtlsFingerprint `json:"fingerprints"`
 }
 
 type DtlsFingerprint struct {
-	Algorithm string `json:"algorithm,omitempty"`
-	Value     string `json:"value,omitempty"`
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
 }
 
 type CreateWebRtcTransportParams struct {
-	ListenIps []ListenIp  `json:"listenIps,omitempty"`
-	EnableUdp bool        `json:"enableUdp,omitempty"`
-	EnableTcp bool        `json:"enableTcp,omitempty"`
-	PreferUdp bool        `json:"preferUdp,omitempty"`
-	PreferTcp bool        `json:"preferTcp,omitempty"`
-	AppData   interface{} `json:"appData,omitempty"`
+	// WebRtcServer sharing its ports with the transport, instead of the
+	// transport listening on its own ports of ListenIps.
+	WebRtcServer *WebRtcServer `json:"-"`
+	ListenIps    []ListenIp    `json:"listenIps,omitempty"`
+	EnableUdp    bool          `json:"enableUdp,omitempty"`
+	EnableTcp    bool          `json:"enableTcp,omitempty"`
+	PreferUdp    bool          `json:"preferUdp,omitempty"`
+	PreferTcp    bool          `json:"preferTcp,omitempty"`
+	AppData      interface{}   `json:"appData,omitempty"`
+}
+
+type CreateWebRtcServerParams struct {
+	ListenInfos []WebRtcServerListenInfo `json:"listenInfos"`
+	AppData     interface{}              `json:"appData,omitempty"`
+}
+
+type WebRtcServerListenInfo struct {
+	// "udp" or "tcp".
+	Protocol string `json:"protocol"`
+	ListenIp
+	// Port shared by the WebRtcTransports, a free port if zero.
+	Port uint16 `json:"port,omitempty"`
 }
 
 type CreatePlainRtpTransportParams struct {
@@ -140,8 +176,16 @@ type PipeToRouterParams struct {
 }
 
 type ListenIp struct {
+	// IP, network interface name or hostname, converted into an IP by the
+	// ListenIpResolver of the worker at router or transport creation.
 	Ip          string `json:"ip,omitempty"`
 	AnnouncedIp string `json:"announcedIp,omitempty"`
+	// Send and receive buffer sizes in bytes of the sockets (SO_SNDBUF and
+	// SO_RCVBUF), for high bitrate transports dropping packets with the
+	// kernel defaults. Zero keeps the defaults. Ignored by workers not
+	// supporting them.
+	SendBufferSize uint32 `json:"sendBufferSize,omitempty"`
+	RecvBufferSize uint32 `json:"recvBufferSize,omitempty"`
 }
 
 type CreateAudioLevelObserverParams struct {
@@ -170,3 +214,74 @@ type TransportStat struct {
 	Tuple     *TransportTuple `json:"tuple,omitempty"`
 	RtcpTuple *TransportTuple `json:"rtcpTuple,omitempty"`
 }
+
+// WorkerResourceUsage of the worker process, as reported by getrusage(2).
+type WorkerResourceUsage struct {
+	// User and system CPU time used, in ms.
+	RuUtime float64 `json:"ru_utime"`
+	RuStime float64 `json:"ru_stime"`
+	// Maximum resident set size, in KB.
+	RuMaxrss int64 `json:"ru_maxrss"`
+	// Integral shared, unshared data and unshared stack sizes.
+	RuIxrss int64 `json:"ru_ixrss"`
+	RuIdrss int64 `json:"ru_idrss"`
+	RuIsrss int64 `json:"ru_isrss"`
+	// Page reclaims (soft page faults) and page faults (hard page faults).
+	RuMinflt int64 `json:"ru_minflt"`
+	RuMajflt int64 `json:"ru_majflt"`
+	// Swaps.
+	RuNswap int64 `json:"ru_nswap"`
+	// Block input and output operations.
+	RuInblock int64 `json:"ru_inblock"`
+	RuOublock int64 `json:"ru_oublock"`
+	// IPC messages sent and received.
+	RuMsgsnd int64 `json:"ru_msgsnd"`
+	RuMsgrcv int64 `json:"ru_msgrcv"`
+	// Signals received.
+	RuNsignals int64 `json:"ru_nsignals"`
+	// Voluntary and involuntary context switches.
+	RuNvcsw  int64 `json:"ru_nvcsw"`
+	RuNivcsw int64 `json:"ru_nivcsw"`
+}
+
+// TransportProduceParams are the parameters of Transport.Produce.
+type TransportProduceParams struct {
+	Id            string        `json:"id,omitempty"`
+	Kind          string        `json:"kind,omitempty"`
+	RtpParameters RtpParameters `json:"rtpParameters,omitempty"`
+	Paused        bool          `json:"paused,omitempty"`
+	AppData       interface{}   `json:"appData,omitempty"`
+	// Replace the SSRCs already used by other Producers in the Router with
+	// random ones instead of failing with SsrcCollisionError (just checked by
+	// PlainRtpTransports). The sender must then use the SSRCs of the Producer
+	// RTP parameters.
+	RemapSsrcOnCollision bool `json:"-"`
+}
+
+// TransportConsumeParams are the parameters of Transport.Consume.
+type TransportConsumeParams struct {
+	ProducerId      string          `json:"producerId,omitempty"`
+	RtpCapabilities RtpCapabilities `json:"rtpCapabilities,omitempty"`
+	Paused          bool            `json:"paused,omitempty"`
+	AppData         interface{}     `json:"appData,omitempty"`
+	// MID of the Consumer RTP parameters.
+	Mid string `json:"mid,omitempty"`
+	// If given, just these header extension URIs are kept.
+	EnabledHeaderExtensions []string `json:"enabledHeaderExtensions,omitempty"`
+	// Header extension URIs to remove (e.g. abs-send-time).
+	DisabledHeaderExtensions []string `json:"disabledHeaderExtensions,omitempty"`
+	// Decides whether the Consumer is closed when its Producer closes, which
+	// it is if nil. Not supported by PipeTransports.
+	OnProducerClose ProducerCloseHook `json:"-"`
+}
+
+// TransportConnectParams are the parameters of Transport.Connect.
+type TransportConnectParams struct {
+	// pipe and plain transport
+	Ip   string `json:"ip,omitempty"`
+	Port uint16 `json:"port,omitempty"`
+	// plain transport
+	RtcpPort uint16 `json:"rtcpPort,omitempty"`
+	// webrtc transport
+	DtlsParameters *DtlsParameters `json:"dtlsParameters,omitempty"`
+}
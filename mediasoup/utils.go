@@ -1,8 +1,10 @@
 package mediasoup
 
 import (
+	"context"
 	"math/rand"
 	"reflect"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,6 +12,47 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
+// closeFlag is the closed state of an entity. An entity may be closed
+// concurrently by the application, by its parent being closed (Worker,
+// Router, Transport) or by the worker process dying: the first close wins and
+// is the only one emitting the close events, the others being no-ops. It
+// cancels the context of the entity once they are emitted. The
+// close events are emitted even if the worker could not be told (the error
+// being returned), e.g. because it died meanwhile. Parents close their
+// children without holding their own locks, and children created while their
+// parent is closing are closed right away, so that no entity survives its
+// parent.
+type closeFlag struct {
+	value uint32
+	// Cancels the context of the entity, if any.
+	cancel context.CancelFunc
+}
+
+// newCloseFlag returns a flag canceling the returned context with
+// cancelContext.
+func newCloseFlag() (closeFlag, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return closeFlag{cancel: cancel}, ctx
+}
+
+// set returns whether the caller is the one closing the entity.
+func (f *closeFlag) set() bool {
+	return atomic.CompareAndSwapUint32(&f.value, 0, 1)
+}
+
+// cancelContext cancels the context of the entity, by the one closing it
+// after emitting the close events, so that the listeners bound to it get them.
+func (f *closeFlag) cancelContext() {
+	if f.cancel != nil {
+		f.cancel()
+	}
+}
+
+func (f *closeFlag) isSet() bool {
+	return atomic.LoadUint32(&f.value) == 1
+}
+
 func generateRandomNumber() uint32 {
 	return uint32(rand.Int63n(900000000)) + 100000000
 }
@@ -23,3 +66,13 @@ func isObject(appData interface{}) bool {
 
 	return appDataKind == reflect.Struct || appDataKind == reflect.Map
 }
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
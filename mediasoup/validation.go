@@ -0,0 +1,226 @@
+package mediasoup
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Options are validated before being used, the errors being ValidationErrors
+// naming the invalid field. Listen IPs are validated by their resolution (see
+// resolveListenIps).
+
+func (o *Options) validate() error {
+	if err := validateLogSettings(o.LogLevel, o.LogTags); err != nil {
+		return err
+	}
+
+	if o.RTCMinPort > o.RTCMaxPort {
+		return NewValidationError("Options.RTCMinPort", "must not exceed RTCMaxPort (%d)", o.RTCMaxPort)
+	}
+
+	if o.MaxConsumersSoft < 0 {
+		return NewValidationError("Options.MaxConsumersSoft", "must not be negative")
+	}
+	if o.MaxConsumersHard < 0 {
+		return NewValidationError("Options.MaxConsumersHard", "must not be negative")
+	}
+	if o.MaxConsumersHard > 0 && o.MaxConsumersSoft > o.MaxConsumersHard {
+		return NewValidationError("Options.MaxConsumersSoft", "must not exceed MaxConsumersHard (%d)", o.MaxConsumersHard)
+	}
+
+	for i, env := range o.Env {
+		if strings.IndexByte(env, '=') <= 0 {
+			return NewValidationError(fmt.Sprintf("Options.Env[%d]", i), `invalid environment variable "%s"`, env)
+		}
+		if strings.HasPrefix(env, "MEDIASOUP_VERSION=") {
+			return NewValidationError(fmt.Sprintf("Options.Env[%d]", i), "MEDIASOUP_VERSION is given by Version")
+		}
+	}
+
+	if o.StartupTimeout < 0 {
+		return NewValidationError("Options.StartupTimeout", "must not be negative")
+	}
+	if o.StartupRetries < 0 {
+		return NewValidationError("Options.StartupRetries", "must not be negative")
+	}
+
+	if err := o.validateVersion(); err != nil {
+		return err
+	}
+
+	cpus := make(map[int]bool)
+
+	for i, cpu := range o.CPUAffinity {
+		if cpu < 0 {
+			return NewValidationError(fmt.Sprintf("Options.CPUAffinity[%d]", i), "must not be negative")
+		}
+		if cpus[cpu] {
+			return NewValidationError(fmt.Sprintf("Options.CPUAffinity[%d]", i), "duplicate core %d", cpu)
+		}
+		cpus[cpu] = true
+	}
+
+	if len(o.DTLSCertificateFile) > 0 && len(o.DTLSPrivateKeyFile) == 0 {
+		return NewValidationError("Options.DTLSPrivateKeyFile", "required with DTLSCertificateFile")
+	}
+	if len(o.DTLSPrivateKeyFile) > 0 && len(o.DTLSCertificateFile) == 0 {
+		return NewValidationError("Options.DTLSCertificateFile", "required with DTLSPrivateKeyFile")
+	}
+
+	return nil
+}
+
+// validateLogSettings checks the log settings of the worker, an empty log
+// level being the current or default one.
+func validateLogSettings(logLevel string, logTags []string) error {
+	switch logLevel {
+	case "", "debug", "warn", "error", "none":
+	default:
+		return NewValidationError("Options.LogLevel", `invalid log level "%s"`, logLevel)
+	}
+
+	for i, logTag := range logTags {
+		if len(logTag) > 0 && !isWorkerLogTag(logTag) {
+			return NewValidationError(fmt.Sprintf("Options.LogTags[%d]", i), `unknown log tag "%s"`, logTag)
+		}
+	}
+
+	return nil
+}
+
+// validateVersion checks Version against MinVersion and MaxVersion.
+func (o *Options) validateVersion() error {
+	if len(o.MinVersion) == 0 && len(o.MaxVersion) == 0 {
+		return nil
+	}
+
+	min, ok := parseWorkerVersion(o.MinVersion)
+	if !ok && len(o.MinVersion) > 0 {
+		return NewValidationError("Options.MinVersion", `invalid version "%s"`, o.MinVersion)
+	}
+	max, ok := parseWorkerVersion(o.MaxVersion)
+	if !ok && len(o.MaxVersion) > 0 {
+		return NewValidationError("Options.MaxVersion", `invalid version "%s"`, o.MaxVersion)
+	}
+
+	version, ok := parseWorkerVersion(o.Version)
+	if !ok {
+		return NewValidationError("Options.Version",
+			`worker version "%s" cannot be checked against [%s, %s]`, o.Version, o.MinVersion, o.MaxVersion)
+	}
+
+	if (len(o.MinVersion) > 0 && compareWorkerVersions(version, min) < 0) ||
+		(len(o.MaxVersion) > 0 && compareWorkerVersions(version, max) > 0) {
+		return NewValidationError("Options.Version",
+			`worker version "%s" not in [%s, %s]`, o.Version, o.MinVersion, o.MaxVersion)
+	}
+
+	return nil
+}
+
+func (s RouterSettings) validate() error {
+	if len(s.LogLevel) > 0 {
+		if _, err := logrus.ParseLevel(s.LogLevel); err != nil {
+			return NewValidationError("RouterSettings.LogLevel", "%s", err)
+		}
+	}
+
+	for i, listenIp := range s.ListenIps {
+		if err := validateListenIp(fmt.Sprintf("RouterSettings.ListenIps[%d]", i), listenIp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (params CreateWebRtcTransportParams) validate() error {
+	if params.AppData != nil && !isObject(params.AppData) {
+		return NewValidationError("CreateWebRtcTransportParams.AppData", "must be an object")
+	}
+	if params.WebRtcServer != nil {
+		if len(params.ListenIps) > 0 {
+			return NewValidationError("CreateWebRtcTransportParams.ListenIps", "cannot be set with WebRtcServer")
+		}
+	} else if len(params.ListenIps) == 0 {
+		return NewValidationError("CreateWebRtcTransportParams.ListenIps", "missing listen IPs")
+	}
+
+	for i, listenIp := range params.ListenIps {
+		if err := validateListenIp(fmt.Sprintf("CreateWebRtcTransportParams.ListenIps[%d]", i), listenIp); err != nil {
+			return err
+		}
+	}
+
+	if params.PreferUdp && params.PreferTcp {
+		return NewValidationError("CreateWebRtcTransportParams.PreferTcp", "cannot be set with PreferUdp")
+	}
+
+	return nil
+}
+
+func (params CreateWebRtcServerParams) validate() error {
+	if params.AppData != nil && !isObject(params.AppData) {
+		return NewValidationError("CreateWebRtcServerParams.AppData", "must be an object")
+	}
+	if len(params.ListenInfos) == 0 {
+		return NewValidationError("CreateWebRtcServerParams.ListenInfos", "missing listen infos")
+	}
+
+	for i, listenInfo := range params.ListenInfos {
+		field := fmt.Sprintf("CreateWebRtcServerParams.ListenInfos[%d]", i)
+
+		if listenInfo.Protocol != "udp" && listenInfo.Protocol != "tcp" {
+			return NewValidationError(field+".Protocol", `must be "udp" or "tcp"`)
+		}
+		if err := validateListenIp(field, listenInfo.ListenIp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (params CreatePlainRtpTransportParams) validate() error {
+	if params.AppData != nil && !isObject(params.AppData) {
+		return NewValidationError("CreatePlainRtpTransportParams.AppData", "must be an object")
+	}
+
+	return validateListenIp("CreatePlainRtpTransportParams.ListenIp", params.ListenIp)
+}
+
+func (params CreatePipeTransportParams) validate() error {
+	if params.AppData != nil && !isObject(params.AppData) {
+		return NewValidationError("CreatePipeTransportParams.AppData", "must be an object")
+	}
+
+	return validateListenIp("CreatePipeTransportParams.ListenIp", params.ListenIp)
+}
+
+func (params CreateAudioLevelObserverParams) validate() error {
+	if params.MaxEntries == 0 {
+		return NewValidationError("CreateAudioLevelObserverParams.MaxEntries", "must be positive")
+	}
+	if params.Threshold < -127 || params.Threshold > 0 {
+		return NewValidationError("CreateAudioLevelObserverParams.Threshold", "must be between -127 and 0 dBvo")
+	}
+	return nil
+}
+
+// validateListenIp validates the given listen IP, named field in the errors.
+func validateListenIp(field string, listenIp ListenIp) error {
+	if len(listenIp.Ip) == 0 {
+		return NewValidationError(field+".Ip", "missing address")
+	}
+	if listenIp.SendBufferSize > math.MaxInt32 {
+		return NewValidationError(field+".SendBufferSize", "must not exceed %d", math.MaxInt32)
+	}
+	if listenIp.RecvBufferSize > math.MaxInt32 {
+		return NewValidationError(field+".RecvBufferSize", "must not exceed %d", math.MaxInt32)
+	}
+
+	return nil
+}
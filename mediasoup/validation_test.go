@@ -0,0 +1,143 @@
+package mediasoup
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertValidationError(t *testing.T, field string, err error) {
+	require.IsType(t, ValidationError{}, err)
+	assert.Equal(t, field, err.(ValidationError).Field)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, NewOptions().validate())
+
+	options := NewOptions()
+	options.LogLevel = "chicken"
+	assertValidationError(t, "Options.LogLevel", options.validate())
+
+	options = NewOptions()
+	options.LogTags = []string{WorkerLogTagIce, "chicken"}
+	assertValidationError(t, "Options.LogTags[1]", options.validate())
+
+	options = NewOptions()
+	options.RTCMinPort, options.RTCMaxPort = 1000, 999
+	assert.EqualError(t, options.validate(), "Options.RTCMinPort: must not exceed RTCMaxPort (999)")
+
+	options = NewOptions()
+	options.DTLSCertificateFile = "dtls-cert.pem"
+	assertValidationError(t, "Options.DTLSPrivateKeyFile", options.validate())
+
+	options = NewOptions()
+	WithConsumerLimits(100, 0)(options)
+	assert.NoError(t, options.validate())
+	WithConsumerLimits(100, 50)(options)
+	assertValidationError(t, "Options.MaxConsumersSoft", options.validate())
+	WithConsumerLimits(0, -1)(options)
+	assertValidationError(t, "Options.MaxConsumersHard", options.validate())
+
+	options = NewOptions()
+	WithEnv("DEBUG=mediasoup*")(options)
+	assert.NoError(t, options.validate())
+	WithEnv("DEBUG")(options)
+	assertValidationError(t, "Options.Env[1]", options.validate())
+
+	options = NewOptions()
+	WithEnv("MEDIASOUP_VERSION=3.10.0")(options)
+	assertValidationError(t, "Options.Env[0]", options.validate())
+
+	options = NewOptions()
+	WithCPUAffinity(0, 2)(options)
+	assert.NoError(t, options.validate())
+	WithCPUAffinity(0, -1)(options)
+	assertValidationError(t, "Options.CPUAffinity[1]", options.validate())
+	WithCPUAffinity(2, 3, 2)(options)
+	assertValidationError(t, "Options.CPUAffinity[2]", options.validate())
+
+	options = NewOptions()
+	WithStartupTimeout(time.Second, 2)(options)
+	assert.NoError(t, options.validate())
+	WithStartupTimeout(-time.Second, 0)(options)
+	assertValidationError(t, "Options.StartupTimeout", options.validate())
+	WithStartupTimeout(time.Second, -1)(options)
+	assertValidationError(t, "Options.StartupRetries", options.validate())
+}
+
+func TestOptionsValidateVersion(t *testing.T) {
+	options := NewOptions()
+	WithVersion("3.10.5")(options)
+
+	WithVersionRange("3.10.0", "3.11.0")(options)
+	assert.NoError(t, options.validate())
+	WithVersionRange("3.10.5", "")(options)
+	assert.NoError(t, options.validate())
+	WithVersionRange("", "3.10.5")(options)
+	assert.NoError(t, options.validate())
+
+	WithVersionRange("3.11.0", "")(options)
+	assert.EqualError(t, options.validate(), `Options.Version: worker version "3.10.5" not in [3.11.0, ]`)
+	WithVersionRange("", "3.9.0")(options)
+	assertValidationError(t, "Options.Version", options.validate())
+	WithVersionRange("3.x", "")(options)
+	assertValidationError(t, "Options.MinVersion", options.validate())
+	WithVersionRange("", "4")(options)
+	assertValidationError(t, "Options.MaxVersion", options.validate())
+
+	WithVersion("latest")(options)
+	WithVersionRange("3.10.0", "")(options)
+	assertValidationError(t, "Options.Version", options.validate())
+	WithVersionRange("", "")(options)
+	assert.NoError(t, options.validate())
+}
+
+func TestParamsValidate(t *testing.T) {
+	assertValidationError(t, "RouterSettings.LogLevel", RouterSettings{LogLevel: "verbose"}.validate())
+	assertValidationError(t, "RouterSettings.ListenIps[0].Ip", RouterSettings{ListenIps: []ListenIp{{}}}.validate())
+
+	listenIps := []ListenIp{{Ip: "127.0.0.1"}}
+
+	assert.NoError(t, CreateWebRtcTransportParams{ListenIps: listenIps}.validate())
+	assertValidationError(t, "CreateWebRtcTransportParams.ListenIps",
+		CreateWebRtcTransportParams{}.validate())
+	assertValidationError(t, "CreateWebRtcTransportParams.AppData",
+		CreateWebRtcTransportParams{ListenIps: listenIps, AppData: "app"}.validate())
+	assertValidationError(t, "CreateWebRtcTransportParams.ListenIps[1].SendBufferSize",
+		CreateWebRtcTransportParams{
+			ListenIps: append(listenIps, ListenIp{Ip: "::1", SendBufferSize: math.MaxUint32}),
+		}.validate())
+	assertValidationError(t, "CreateWebRtcTransportParams.PreferTcp",
+		CreateWebRtcTransportParams{ListenIps: listenIps, PreferUdp: true, PreferTcp: true}.validate())
+
+	assertValidationError(t, "CreatePlainRtpTransportParams.ListenIp.Ip",
+		CreatePlainRtpTransportParams{}.validate())
+	assertValidationError(t, "CreatePipeTransportParams.ListenIp.RecvBufferSize",
+		CreatePipeTransportParams{ListenIp: ListenIp{Ip: "127.0.0.1", RecvBufferSize: math.MaxUint32}}.validate())
+
+	assert.NoError(t, CreateAudioLevelObserverParams{MaxEntries: 1, Threshold: -80}.validate())
+	assertValidationError(t, "CreateAudioLevelObserverParams.MaxEntries",
+		CreateAudioLevelObserverParams{Threshold: -80}.validate())
+	assertValidationError(t, "CreateAudioLevelObserverParams.Threshold",
+		CreateAudioLevelObserverParams{MaxEntries: 1, Threshold: 10}.validate())
+}
+
+func TestResolveListenIp_InvalidAddress(t *testing.T) {
+	resolver := func(host string) (string, error) {
+		if host == "media0" {
+			return "not-an-ip", nil
+		}
+		return "", errors.New("unknown host")
+	}
+
+	_, err := resolveListenIp(resolver, "CreatePipeTransportParams.ListenIp", ListenIp{Ip: "media0"})
+	assert.EqualError(t, err,
+		`CreatePipeTransportParams.ListenIp.Ip: invalid address "not-an-ip" resolved from "media0"`)
+
+	_, err = resolveListenIp(resolver, "CreatePipeTransportParams.ListenIp", ListenIp{Ip: "media1"})
+	assert.EqualError(t, err, "CreatePipeTransportParams.ListenIp.Ip: invalid address: unknown host")
+}
@@ -0,0 +1,337 @@
+// Package webhook posts JSON webhooks on the operationally significant events
+// of the watched mediasoup Workers: worker died, consumer limits reached,
+// transport failed and sustained consumer packet loss.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/sirupsen/logrus"
+)
+
+// Webhook events.
+const (
+	EventWorkerDied         = "worker.died"
+	EventTransportFailed    = "transport.failed"
+	EventConsumerPacketLoss = "consumer.packetloss"
+	EventQuotaExceeded      = "quota.exceeded"
+)
+
+// SignatureHeader holds the hex encoded HMAC-SHA256 of the request
+// body, computed with the configured secret.
+const SignatureHeader = "X-Mediasoup-Signature"
+
+// Event is the JSON body posted to the webhook url.
+type Event struct {
+	Event     string      `json:"event"`
+	Timestamp int64       `json:"timestamp"` // unix time in milliseconds
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// Options to deliver webhooks.
+type Options struct {
+	Url    string
+	Secret string
+	// Number of retries after a failed delivery.
+	MaxRetries int
+	// Delay before the first retry, doubled on each further retry.
+	RetryInterval time.Duration
+	// Backoff of the retries, overriding MaxRetries and RetryInterval.
+	Backoff *mediasoup.Backoff
+	// Number of events waiting for delivery before new ones are dropped.
+	QueueSize int
+	// Consumer score (from 0 to 10) below which packet loss is considered
+	// significant. Consumer scores are computed by the worker from packet loss.
+	PacketLossScoreThreshold uint8
+	// How long the Consumer score must stay below the threshold.
+	PacketLossDuration time.Duration
+	HttpClient         *http.Client
+}
+
+type Option func(o *Options)
+
+func WithSecret(secret string) Option {
+	return func(o *Options) {
+		o.Secret = secret
+	}
+}
+
+func WithRetries(maxRetries int, retryInterval time.Duration) Option {
+	return func(o *Options) {
+		o.MaxRetries = maxRetries
+		o.RetryInterval = retryInterval
+	}
+}
+
+func WithBackoff(backoff mediasoup.Backoff) Option {
+	return func(o *Options) {
+		o.Backoff = &backoff
+	}
+}
+
+func WithQueueSize(queueSize int) Option {
+	return func(o *Options) {
+		o.QueueSize = queueSize
+	}
+}
+
+func WithPacketLoss(scoreThreshold uint8, duration time.Duration) Option {
+	return func(o *Options) {
+		o.PacketLossScoreThreshold = scoreThreshold
+		o.PacketLossDuration = duration
+	}
+}
+
+func WithHttpClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.HttpClient = client
+	}
+}
+
+// Notifier posts JSON webhooks on operationally significant events.
+type Notifier struct {
+	logger    logrus.FieldLogger
+	options   Options
+	queue     chan Event
+	backoff   mediasoup.Backoff
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewNotifier(url string, options ...Option) *Notifier {
+	logger := mediasoup.TypeLogger("Notifier")
+
+	logger.Debug("constructor()")
+
+	opts := Options{
+		Url:                      url,
+		MaxRetries:               3,
+		RetryInterval:            time.Second,
+		QueueSize:                100,
+		PacketLossScoreThreshold: 5,
+		PacketLossDuration:       10 * time.Second,
+		HttpClient:               &http.Client{Timeout: 5 * time.Second},
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	n := &Notifier{
+		logger:  logger,
+		options: opts,
+		queue:   make(chan Event, opts.QueueSize),
+		backoff: mediasoup.Backoff{
+			Initial:    opts.RetryInterval,
+			Multiplier: 2,
+			MaxRetries: opts.MaxRetries,
+		},
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+
+	if opts.Backoff != nil {
+		n.backoff = *opts.Backoff
+	}
+
+	n.wg.Add(1)
+	go n.run()
+
+	return n
+}
+
+// Notify queues the given event for delivery. It never blocks, the event is
+// dropped if the queue is full or the notifier is closed.
+func (n *Notifier) Notify(event string, data interface{}) {
+	if n.ctx.Err() != nil {
+		return
+	}
+
+	evt := Event{
+		Event:     event,
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+		Data:      data,
+	}
+
+	select {
+	case n.queue <- evt:
+	default:
+		n.logger.Warnf(`queue is full, dropping event "%s"`, event)
+	}
+}
+
+// WatchWorker sends webhooks for the given Worker and its entities: worker
+// died, consumer limits reached (see mediasoup.WithConsumerLimits), transport
+// failed and sustained consumer packet loss.
+func (n *Notifier) WatchWorker(worker *mediasoup.Worker) {
+	worker.On("died", func(err error) {
+		n.Notify(EventWorkerDied, mediasoup.H{
+			"pid":   worker.Pid(),
+			"error": fmt.Sprint(err),
+		})
+	})
+
+	worker.On("consumersoftlimit", func(count int) {
+		n.Notify(EventQuotaExceeded, mediasoup.H{
+			"pid":       worker.Pid(),
+			"limit":     "soft",
+			"consumers": count,
+		})
+	})
+
+	worker.On("consumerhardlimit", func(count int) {
+		n.Notify(EventQuotaExceeded, mediasoup.H{
+			"pid":       worker.Pid(),
+			"limit":     "hard",
+			"consumers": count,
+		})
+	})
+
+	mediasoup.EntityVisitor{
+		Transport: n.watchTransport,
+		Consumer:  n.watchConsumer,
+	}.WalkWorker(worker)
+}
+
+// Close stops delivering events. Events still queued are discarded.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() {
+		n.logger.Debug("close()")
+
+		n.cancel()
+	})
+
+	n.wg.Wait()
+}
+
+func (n *Notifier) watchTransport(router *mediasoup.Router, transport mediasoup.Transport) {
+	transport.Observer().On("dtlsstatechange", func(dtlsState string) {
+		if dtlsState == "failed" {
+			n.Notify(EventTransportFailed, mediasoup.H{
+				"routerId":    router.Id(),
+				"transportId": transport.Id(),
+				"dtlsState":   dtlsState,
+			})
+		}
+	})
+}
+
+func (n *Notifier) watchConsumer(router *mediasoup.Router, transport mediasoup.Transport, consumer *mediasoup.Consumer) {
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+
+	stopTimer := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+	}
+
+	consumer.Observer().On("score", func(score mediasoup.ConsumerScore) {
+		if score.Consumer >= n.options.PacketLossScoreThreshold {
+			stopTimer()
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			return
+		}
+
+		timer = time.AfterFunc(n.options.PacketLossDuration, func() {
+			n.Notify(EventConsumerPacketLoss, mediasoup.H{
+				"routerId":    router.Id(),
+				"transportId": transport.Id(),
+				"consumerId":  consumer.Id(),
+				"score":       consumer.Score(),
+			})
+		})
+	})
+
+	consumer.Observer().On("close", stopTimer)
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case evt := <-n.queue:
+			n.deliver(evt)
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *Notifier) deliver(evt Event) {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		n.logger.Errorf(`cannot marshal event "%s": %s`, evt.Event, err)
+		return
+	}
+
+	backoff := n.backoff
+	backoff.OnRetry = func(attempt int, delay time.Duration, err error) {
+		n.logger.Warnf(`delivery of event "%s" failed, retrying in %s: %s`, evt.Event, delay, err)
+	}
+
+	err = backoff.Retry(n.ctx, func(attempt int) error {
+		return n.post(body)
+	})
+	if err != nil && n.ctx.Err() == nil {
+		n.logger.Errorf(`delivery of event "%s" failed: %s`, evt.Event, err)
+	}
+}
+
+func (n *Notifier) post(body []byte) (err error) {
+	req, err := http.NewRequest(http.MethodPost, n.options.Url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(n.options.Secret) > 0 {
+		req.Header.Set(SignatureHeader, SignPayload(n.options.Secret, body))
+	}
+
+	rsp, err := n.options.HttpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		err = fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+	}
+
+	return
+}
+
+// SignPayload returns the signature of a webhook body, as sent in the
+// SignatureHeader header.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier_DeliversSignedEventWithRetries(t *testing.T) {
+	var attempts int32
+	received := make(chan Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		assert.Equal(t, SignPayload("secret", body), r.Header.Get(SignatureHeader))
+
+		var evt Event
+		json.Unmarshal(body, &evt)
+
+		received <- evt
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL,
+		WithSecret("secret"),
+		WithRetries(2, 10*time.Millisecond),
+	)
+	defer notifier.Close()
+
+	notifier.Notify(EventQuotaExceeded, mediasoup.H{"roomId": "room1"})
+
+	select {
+	case evt := <-received:
+		assert.Equal(t, EventQuotaExceeded, evt.Event)
+		assert.Equal(t, map[string]interface{}{"roomId": "room1"}, evt.Data)
+		assert.NotZero(t, evt.Timestamp)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+}
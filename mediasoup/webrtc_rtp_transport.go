@@ -15,7 +15,7 @@ type WebRtcTransport struct {
 }
 
 func NewWebRtcTransport(data WebRtcTransportData, params createTransportParams) *WebRtcTransport {
-	logger := TypeLogger("WebRtcTransportData")
+	logger := entityAppLogger(params.AppLogger).WithField("type", "WebRtcTransportData")
 
 	logger.Debug("constructor()")
 
@@ -73,7 +73,10 @@ func (t *WebRtcTransport) DtlsRemoteCert() string {
  * @emits {consumer: Consumer} newconsumer
  * @emits {iceState: String} icestatechange
  * @emits {iceSelectedTuple: Object} iceselectedtuplechange
+ * @emits {lostTuple: TransportTuple} iceconsentexpired
+ * @emits {lostTuple: TransportTuple} iceselectedtupleloss
  * @emits {dtlsState: String} dtlsstatechange
+ * @emits {trace: TransportTraceEventData} trace
  */
 func (t *WebRtcTransport) Observer() EventEmitter {
 	return t.observer
@@ -85,15 +88,7 @@ func (t *WebRtcTransport) Observer() EventEmitter {
  * @override
  */
 func (t *WebRtcTransport) Close() (err error) {
-	if t.closed {
-		return
-	}
-
-	t.data.IceState = "closed"
-	t.data.IceSelectedTuple = nil
-	t.data.DtlsState = "closed"
-
-	return t.baseTransport.Close()
+	return t.baseTransport.close(t.setClosedStates)
 }
 
 /**
@@ -103,15 +98,18 @@ func (t *WebRtcTransport) Close() (err error) {
  * @override
  */
 func (t *WebRtcTransport) routerClosed() {
-	if t.closed {
-		return
-	}
+	t.baseTransport.handleRouterClosed(t.setClosedStates)
+}
+
+// WebRtcServer was closed.
+func (t *WebRtcTransport) listenServerClosed() {
+	t.baseTransport.handleListenServerClosed(t.setClosedStates)
+}
 
+func (t *WebRtcTransport) setClosedStates() {
 	t.data.IceState = "closed"
 	t.data.IceSelectedTuple = nil
 	t.data.DtlsState = "closed"
-
-	t.baseTransport.routerClosed()
 }
 
 /**
@@ -121,7 +119,7 @@ func (t *WebRtcTransport) routerClosed() {
  *
  * @override
  */
-func (t *WebRtcTransport) Connect(params transportConnectParams) (err error) {
+func (t *WebRtcTransport) Connect(params TransportConnectParams) (err error) {
 	t.logger.Debug("connect()")
 
 	resp := t.channel.Request("transport.connect", t.internal, params)
@@ -158,6 +156,24 @@ func (t *WebRtcTransport) SetMaxIncomingBitrate(bitrate int) error {
 	return resp.Err()
 }
 
+/**
+ * Set maximum outgoing bitrate for sending media.
+ *
+ * @param {Number} bitrate - In bps.
+ */
+func (t *WebRtcTransport) SetMaxOutgoingBitrate(bitrate int) error {
+	t.logger.Debugf(`setMaxOutgoingBitrate() [bitrate:%d]`, bitrate)
+
+	reqData := map[string]int{
+		"bitrate": bitrate,
+	}
+
+	resp := t.channel.Request(
+		"transport.setMaxOutgoingBitrate", t.internal, reqData)
+
+	return resp.Err()
+}
+
 /**
  * Restart ICE.
  *
@@ -184,13 +200,13 @@ func (t *WebRtcTransport) RestartIce() (iceParameters IceParameters, err error)
  * @private
  */
 func (t *WebRtcTransport) handleWorkerNotifications() {
-	t.channel.On(t.internal.TransportId, func(event string, rawData json.RawMessage) {
+	t.subscription = t.channel.subscribe(t.internal.TransportId, t.channel.profiledListener(t.internal.RouterId, func(event string, rawData json.RawMessage) {
 		var data WebRtcTransportData
 		json.Unmarshal([]byte(rawData), &data)
 
 		switch event {
 		case "icestatechange":
-			iceState := data.IceState
+			iceState, previousIceState := data.IceState, t.data.IceState
 
 			t.data.IceState = iceState
 
@@ -199,8 +215,19 @@ func (t *WebRtcTransport) handleWorkerNotifications() {
 			// Emit observer event.
 			t.observer.SafeEmit("icestatechange", iceState)
 
+			// ICE consent (STUN keepalive) was not refreshed by the client in time,
+			// or its last tuple was closed (e.g. TCP): the client is gone, or at
+			// least unreachable until it restarts ICE.
+			if lostTuple := t.data.IceSelectedTuple; lostTuple != nil && iceState == "disconnected" &&
+				(previousIceState == "connected" || previousIceState == "completed") {
+				t.SafeEmit("iceconsentexpired", *lostTuple)
+
+				// Emit observer event.
+				t.observer.SafeEmit("iceconsentexpired", *lostTuple)
+			}
+
 		case "iceselectedtuplechange":
-			iceSelectedTuple := *data.IceSelectedTuple
+			iceSelectedTuple, previousTuple := *data.IceSelectedTuple, t.data.IceSelectedTuple
 
 			t.data.IceSelectedTuple = &iceSelectedTuple
 
@@ -209,6 +236,16 @@ func (t *WebRtcTransport) handleWorkerNotifications() {
 			// Emit observer event.
 			t.observer.SafeEmit("iceselectedtuplechange", iceSelectedTuple)
 
+			// The worker replaced the selected tuple (e.g. its socket failed, or
+			// the client moved behind a NAT rebinding), the client being still
+			// reachable through the new one.
+			if previousTuple != nil && *previousTuple != iceSelectedTuple {
+				t.SafeEmit("iceselectedtupleloss", *previousTuple)
+
+				// Emit observer event.
+				t.observer.SafeEmit("iceselectedtupleloss", *previousTuple)
+			}
+
 		case "dtlsstatechange":
 			dtlsState, dtlsRemoteCert := data.DtlsState, data.DtlsRemoteCert
 
@@ -223,8 +260,11 @@ func (t *WebRtcTransport) handleWorkerNotifications() {
 			// Emit observer event.
 			t.observer.SafeEmit("dtlsstatechange", dtlsState)
 
+		case "trace":
+			t.handleTrace(rawData)
+
 		default:
 			t.logger.Errorf(`ignoring unknown event "%s"`, event)
 		}
-	})
+	}))
 }
@@ -2,11 +2,14 @@ package mediasoup
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"sync"
 	"testing"
 
 	"github.com/jiyeyuran/mediasoup-go/mediasoup/h264profile"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testWebRtcMediaCodecs = []RtpCodecCapability{
@@ -55,10 +58,7 @@ func setupWebRtcTest(t *testing.T) (router *Router, transport *WebRtcTransport)
 func TestRouterCreateWebRtcTransport_Succeeds(t *testing.T) {
 	router, transport := setupWebRtcTest(t)
 
-	var dump struct {
-		TransportIds []string
-	}
-	router.Dump().Unmarshal(&dump)
+	dump, _ := router.Dump()
 
 	assert.Equal(t, dump.TransportIds, []string{transport.Id()})
 
@@ -132,22 +132,7 @@ func TestRouterCreateWebRtcTransport_Succeeds(t *testing.T) {
 	assert.Equal(t, transport.DtlsState(), "new")
 	assert.Empty(t, transport.DtlsRemoteCert())
 
-	var data1 struct {
-		Id                  string
-		ProducerIds         []string
-		ConsumerIds         []string
-		IceRole             string
-		IceParameters       IceParameters
-		IceCandidates       []IceCandidate
-		IceState            string
-		IceSelectedTuple    *TransportTuple
-		DtlsParameters      DtlsParameters
-		DtlsState           string
-		RtpHeaderExtensions interface{}
-		RtpListener         interface{}
-	}
-
-	transport1.Dump().Unmarshal(&data1)
+	data1, _ := transport1.Dump()
 
 	assert.Equal(t, data1.Id, transport1.Id())
 	assert.Empty(t, data1.ProducerIds)
@@ -177,7 +162,7 @@ func TestRouterCreateWebRtcTransport_TypeError(t *testing.T) {
 	router, _ := setupWebRtcTest(t)
 
 	_, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{})
-	assert.IsType(t, err, NewTypeError(""))
+	assert.Equal(t, NewValidationError("CreateWebRtcTransportParams.ListenIps", "missing listen IPs"), err)
 
 	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{
 		ListenIps: []ListenIp{
@@ -185,7 +170,25 @@ func TestRouterCreateWebRtcTransport_TypeError(t *testing.T) {
 		},
 		AppData: "NOT-AN-OBJECT",
 	})
-	assert.IsType(t, err, NewTypeError(""))
+	assert.IsType(t, ValidationError{}, err)
+	assert.Equal(t, "CreateWebRtcTransportParams.AppData", err.(ValidationError).Field)
+
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{
+			{Ip: "127.0.0.1"},
+			{Ip: "127.0.0.1", RecvBufferSize: math.MaxUint32},
+		},
+	})
+	assert.IsType(t, ValidationError{}, err)
+	assert.Equal(t, "CreateWebRtcTransportParams.ListenIps[1].RecvBufferSize", err.(ValidationError).Field)
+
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{
+			{Ip: "unknown.invalid"},
+		},
+	})
+	assert.IsType(t, ValidationError{}, err)
+	assert.Equal(t, "CreateWebRtcTransportParams.ListenIps[0].Ip", err.(ValidationError).Field)
 }
 
 func TestRouterCreateWebRtcTransport_WithNonBindableIPRejectsWithError(t *testing.T) {
@@ -233,12 +236,12 @@ func TestWebRtcTransportConnect_Succeeds(t *testing.T) {
 		Role: "client",
 	}
 
-	err := transport.Connect(transportConnectParams{
+	err := transport.Connect(TransportConnectParams{
 		DtlsParameters: &dtlsRemoteParameters,
 	})
 	assert.NoError(t, err)
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		DtlsParameters: &dtlsRemoteParameters,
 	})
 	assert.Error(t, err)
@@ -249,7 +252,7 @@ func TestWebRtcTransportConnect_Succeeds(t *testing.T) {
 func TestWebRtcTransportConnect_TypeError(t *testing.T) {
 	_, transport := setupWebRtcTest(t)
 
-	err := transport.Connect(transportConnectParams{})
+	err := transport.Connect(TransportConnectParams{})
 	assert.IsType(t, err, NewTypeError(""))
 
 	dtlsRemoteParameters := DtlsParameters{
@@ -262,7 +265,7 @@ func TestWebRtcTransportConnect_TypeError(t *testing.T) {
 		Role: "client",
 	}
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		DtlsParameters: &dtlsRemoteParameters,
 	})
 	assert.IsType(t, err, NewTypeError(""))
@@ -277,12 +280,12 @@ func TestWebRtcTransportConnect_TypeError(t *testing.T) {
 		Role: "chicken",
 	}
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		DtlsParameters: &dtlsRemoteParameters,
 	})
 	assert.IsType(t, err, NewTypeError(""))
 
-	err = transport.Connect(transportConnectParams{
+	err = transport.Connect(TransportConnectParams{
 		DtlsParameters: &dtlsRemoteParameters,
 	})
 	assert.IsType(t, err, NewTypeError(""))
@@ -330,7 +333,7 @@ func TestWebRtcTransportEvents_Succeeds(t *testing.T) {
 
 	data, _ := json.Marshal(H{"iceState": "completed"})
 
-	channel.Emit(transport.Id(), "icestatechange", data)
+	channel.dispatch(transport.Id(), "icestatechange", data)
 
 	assert.Equal(t, called, 1)
 	assert.Equal(t, iceState, "completed")
@@ -349,7 +352,7 @@ func TestWebRtcTransportEvents_Succeeds(t *testing.T) {
 		called++
 		assert.Equal(t, iceSelectedTuple, tuple)
 	})
-	channel.Emit(transport.Id(), "iceselectedtuplechange", data)
+	channel.dispatch(transport.Id(), "iceselectedtuplechange", data)
 
 	assert.Equal(t, called, 1)
 
@@ -359,14 +362,14 @@ func TestWebRtcTransportEvents_Succeeds(t *testing.T) {
 		dtlsState = state
 	})
 	data, _ = json.Marshal(H{"dtlsState": "connecting"})
-	channel.Emit(transport.Id(), "dtlsstatechange", data)
+	channel.dispatch(transport.Id(), "dtlsstatechange", data)
 
 	assert.Equal(t, called, 1)
 	assert.Equal(t, dtlsState, "connecting")
 	assert.Equal(t, transport.DtlsState(), "connecting")
 
 	data, _ = json.Marshal(H{"dtlsState": "connected", "dtlsRemoteCert": "ABCD"})
-	channel.Emit(transport.Id(), "dtlsstatechange", data)
+	channel.dispatch(transport.Id(), "dtlsstatechange", data)
 
 	assert.Equal(t, called, 2)
 	assert.Equal(t, dtlsState, "connected")
@@ -374,6 +377,63 @@ func TestWebRtcTransportEvents_Succeeds(t *testing.T) {
 	assert.Equal(t, transport.DtlsRemoteCert(), "ABCD")
 }
 
+func TestWebRtcTransportEmitsConsentExpiredAndSelectedTupleLoss(t *testing.T) {
+	_, transport := setupWebRtcTest(t)
+
+	// Private API.
+	channel := transport.channel
+
+	iceSelectedTuple := TransportTuple{
+		LocalIp:    "1.1.1.1",
+		LocalPort:  1111,
+		RemoteIp:   "2.2.2.2",
+		RemotePort: 2222,
+		Protocol:   "udp",
+	}
+
+	data, _ := json.Marshal(H{"iceState": "completed"})
+	channel.dispatch(transport.Id(), "icestatechange", data)
+	data, _ = json.Marshal(H{"iceSelectedTuple": iceSelectedTuple})
+	channel.dispatch(transport.Id(), "iceselectedtuplechange", data)
+
+	lossCalled := 0
+	transport.On("iceselectedtupleloss", func(tuple TransportTuple) {
+		lossCalled++
+		assert.Equal(t, iceSelectedTuple, tuple)
+	})
+
+	// The same tuple selected again is not lost.
+	channel.dispatch(transport.Id(), "iceselectedtuplechange", data)
+
+	assert.Equal(t, 0, lossCalled)
+
+	// The client moved to another tuple (e.g. NAT rebinding).
+	newTuple := iceSelectedTuple
+	newTuple.RemotePort = 3333
+
+	data, _ = json.Marshal(H{"iceSelectedTuple": newTuple})
+	channel.dispatch(transport.Id(), "iceselectedtuplechange", data)
+
+	assert.Equal(t, 1, lossCalled)
+	assert.Equal(t, &newTuple, transport.IceSelectedTuple())
+
+	consentCalled := 0
+	transport.Observer().On("iceconsentexpired", func(tuple TransportTuple) {
+		consentCalled++
+		assert.Equal(t, newTuple, tuple)
+	})
+
+	data, _ = json.Marshal(H{"iceState": "disconnected"})
+	channel.dispatch(transport.Id(), "icestatechange", data)
+
+	assert.Equal(t, 1, consentCalled)
+
+	// No consent expiration if ICE was not connected.
+	channel.dispatch(transport.Id(), "icestatechange", data)
+
+	assert.Equal(t, 1, consentCalled)
+}
+
 func TestWebRtcTransport_MethodsRejectIfClosed(t *testing.T) {
 	_, transport := setupWebRtcTest(t)
 
@@ -387,12 +447,13 @@ func TestWebRtcTransport_MethodsRejectIfClosed(t *testing.T) {
 	assert.Empty(t, transport.IceSelectedTuple())
 	assert.Equal(t, transport.DtlsState(), "closed")
 
-	assert.Error(t, transport.Dump().Err())
+	_, err := transport.Dump()
+	assert.Error(t, err)
 
-	_, err := transport.GetStats()
+	_, err = transport.GetStats()
 	assert.Error(t, err)
 
-	err = transport.Connect(transportConnectParams{})
+	err = transport.Connect(TransportConnectParams{})
 	assert.Error(t, err)
 
 	err = transport.SetMaxIncomingBitrate(0)
@@ -455,3 +516,142 @@ func TestWebRtcTransport_EmitsIfWorkerIsClosed(t *testing.T) {
 	assert.Empty(t, transport.IceSelectedTuple())
 	assert.Equal(t, transport.DtlsState(), "closed")
 }
+
+func TestRouterRecreateTransportFor_Succeeds(t *testing.T) {
+	ns := setupPipeTest(t)
+
+	oldTransport, err := ns.router1.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{
+			{Ip: "127.0.0.1"},
+		},
+		AppData: H{"peerId": "alice"},
+	})
+	assert.NoError(t, err)
+
+	var hookCalled bool
+	oldConsumer, err := oldTransport.Consume(TransportConsumeParams{
+		ProducerId:               ns.audioProducer.Id(),
+		RtpCapabilities:          consumerDeviceCapabilities,
+		Mid:                      "audio1",
+		DisabledHeaderExtensions: []string{"http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"},
+		OnProducerClose: func(consumer *Consumer) ProducerCloseAction {
+			hookCalled = true
+			return ProducerCloseActionKeep
+		},
+	})
+	assert.NoError(t, err)
+
+	onRecreate := NewMockFunc(t)
+	ns.router1.Observer().Once("transportrecreate", onRecreate.Fn())
+
+	transport, consumerIds, err := ns.router1.RecreateTransportFor(oldTransport)
+	assert.NoError(t, err)
+	assert.True(t, oldTransport.Closed())
+	assert.NotEqual(t, oldTransport.Id(), transport.Id())
+	assert.Equal(t, oldTransport.AppData(), transport.AppData())
+	assert.Len(t, consumerIds, 1)
+	onRecreate.ExpectCalledWith(oldTransport, transport, consumerIds)
+
+	consumer := transport.(*WebRtcTransport).consumers[consumerIds[oldConsumer.Id()]]
+	assert.NotNil(t, consumer)
+	assert.True(t, consumer.Paused())
+	assert.Equal(t, ns.audioProducer.Id(), consumer.ProducerId())
+	assert.Equal(t, "audio1", consumer.RtpParameters().Mid)
+	assert.Equal(t, oldConsumer.RtpParameters().HeaderExtensions, consumer.RtpParameters().HeaderExtensions)
+
+	require.NotNil(t, consumer.onProducerClose)
+	consumer.onProducerClose(consumer)
+	assert.True(t, hookCalled)
+
+	_, _, err = ns.router1.RecreateTransportFor(oldTransport)
+	assert.IsType(t, NewTypeError(""), err)
+}
+
+func TestRecreateConsumer_RestoresPreferredLayers(t *testing.T) {
+	fake := newFakeWorker(t)
+
+	routerRtpCapabilities, err := GenerateRouterRtpCapabilities(testRouterMediaCodecs)
+	require.NoError(t, err)
+
+	var producer *Producer
+
+	transport := newTransport(createTransportParams{
+		Channel: fake.channel,
+		GetRouterRtpCapabilities: func() RtpCapabilities {
+			return routerRtpCapabilities
+		},
+		GetProducerById: func(producerId string) *Producer {
+			return producer
+		},
+		GetProducerBySsrc: func(ssrc uint32) *Producer {
+			return nil
+		},
+	})
+
+	result, err := transport.ProduceDryRun(TransportProduceParams{
+		Kind: "audio",
+		RtpParameters: RtpParameters{
+			Codecs: []RtpCodecCapability{
+				{MimeType: "audio/opus", PayloadType: 111, ClockRate: 48000, Channels: 2},
+			},
+			Encodings: []RtpEncoding{{Ssrc: 11111111}},
+		},
+	})
+	require.NoError(t, err)
+
+	producer = &Producer{
+		internal: internalData{ProducerId: "producer"},
+		data: producerData{
+			Kind:                    "audio",
+			RtpParameters:           result.RtpParameters,
+			Type:                    "simple",
+			ConsumableRtpParameters: result.ConsumableRtpParameters,
+		},
+	}
+
+	oldConsumer, err := transport.Consume(TransportConsumeParams{
+		ProducerId:      "producer",
+		RtpCapabilities: routerRtpCapabilities,
+		Mid:             "audio1",
+	})
+	require.NoError(t, err)
+	require.NoError(t, oldConsumer.SetPreferredLayers(1, 2))
+	fake.takeRequests()
+
+	consumer, err := recreateConsumer(transport, oldConsumer)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldConsumer.Id(), consumer.Id())
+	assert.Equal(t, "audio1", consumer.RtpParameters().Mid)
+	assert.Equal(t, &VideoLayer{SpatialLayer: 1}, consumer.PreferredLayers())
+
+	requests := fake.takeRequests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "transport.consume", requests[0].Method)
+	assert.Equal(t, "consumer.setPreferredLayers", requests[1].Method)
+	assert.JSONEq(t, `{"spatialLayer":1,"temporalLayer":2}`, string(requests[1].Data))
+}
+
+func TestCreateWebRtcTransport_ResolvesListenHosts(t *testing.T) {
+	worker := CreateTestWorker(WithListenIpResolver(func(host string) (string, error) {
+		if host != "media0" {
+			return "", fmt.Errorf("unknown host %s", host)
+		}
+		return "127.0.0.1", nil
+	}))
+	defer worker.Close()
+
+	router, err := worker.CreateRouter(testRouterMediaCodecs)
+	require.NoError(t, err)
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{
+			{Ip: "media0"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, transport.IceCandidates())
+	for _, candidate := range transport.IceCandidates() {
+		assert.Equal(t, "127.0.0.1", candidate.Ip)
+	}
+}
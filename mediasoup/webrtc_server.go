@@ -0,0 +1,184 @@
+package mediasoup
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WebRtcServer listens on a few UDP/TCP ports shared by the WebRtcTransports
+// created with it, so that deployments behind restrictive firewalls don't
+// need a port range per transport. It needs worker 3.10.0 or later.
+type WebRtcServer struct {
+	EventEmitter
+	logger   logrus.FieldLogger
+	internal internalData
+	channel  *Channel
+	appData  interface{}
+	closed   closeFlag
+	observer EventEmitter
+	// WebRtcTransports using the server, by id.
+	webRtcTransports       map[string]*WebRtcTransport
+	webRtcTransportsLocker sync.Mutex
+	// Resolved listen infos, the ports possibly zero.
+	listenInfos []WebRtcServerListenInfo
+}
+
+/**
+ * new WebRtcServer
+ *
+ * @emits workerclose
+ * @emits @close
+ */
+func NewWebRtcServer(internal internalData, channel *Channel, appData interface{}) *WebRtcServer {
+	logger := TypeLogger("WebRtcServer")
+
+	logger.Debug("constructor()")
+
+	closed, ctx := newCloseFlag()
+
+	return &WebRtcServer{
+		EventEmitter: NewEventEmitter(AppLogger(), WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		closed:       closed,
+		logger:       logger,
+		// - .WebRtcServerId
+		internal:         internal,
+		channel:          channel,
+		appData:          appData,
+		observer:         NewEventEmitter(AppLogger(), WithEmitterLogFields(internal.logFields()), WithEmitterContext(ctx)),
+		webRtcTransports: make(map[string]*WebRtcTransport),
+	}
+}
+
+// WebRtcServer id
+func (server *WebRtcServer) Id() string {
+	return server.internal.WebRtcServerId
+}
+
+// Whether the WebRtcServer is closed.
+func (server *WebRtcServer) Closed() bool {
+	return server.closed.isSet()
+}
+
+// App custom data.
+func (server *WebRtcServer) AppData() interface{} {
+	return server.appData
+}
+
+/**
+ * Observer.
+ *
+ * @emits close
+ * @emits {webRtcTransport: *WebRtcTransport} webrtctransporthandled
+ * @emits {webRtcTransport: *WebRtcTransport} webrtctransportunhandled
+ */
+func (server *WebRtcServer) Observer() EventEmitter {
+	return server.observer
+}
+
+// Close the WebRtcServer, closing the WebRtcTransports using it.
+func (server *WebRtcServer) Close() (err error) {
+	if !server.closed.set() {
+		return
+	}
+	defer server.closed.cancelContext()
+
+	server.logger.Debug("close()")
+
+	err = server.channel.Request("worker.closeWebRtcServer", server.internal, nil).Err()
+
+	// Close every WebRtcTransport, outside of the lock since their listeners
+	// may use the WebRtcServer.
+	for _, transport := range server.takeWebRtcTransports() {
+		transport.listenServerClosed()
+
+		// Emit observer event.
+		server.observer.SafeEmit("webrtctransportunhandled", transport)
+	}
+
+	server.Emit("@close")
+
+	// Emit observer event.
+	server.observer.SafeEmit("close")
+
+	return
+}
+
+// Worker was closed, its Routers closing the WebRtcTransports.
+func (server *WebRtcServer) workerClosed() {
+	if !server.closed.set() {
+		return
+	}
+	defer server.closed.cancelContext()
+
+	server.logger.Debug("workerClosed()")
+
+	server.takeWebRtcTransports()
+
+	server.SafeEmit("workerclose")
+
+	// Emit observer event.
+	server.observer.SafeEmit("close")
+}
+
+// Dump WebRtcServer.
+func (server *WebRtcServer) Dump() Response {
+	server.logger.Debug("dump()")
+
+	return server.channel.requestIdempotent(context.Background(), "webRtcServer.dump", server.internal, nil)
+}
+
+// handleWebRtcTransport registers the given new WebRtcTransport, which is
+// closed instead if the WebRtcServer was closed meanwhile.
+func (server *WebRtcServer) handleWebRtcTransport(transport *WebRtcTransport) error {
+	server.webRtcTransportsLocker.Lock()
+
+	if server.closed.isSet() {
+		server.webRtcTransportsLocker.Unlock()
+
+		transport.listenServerClosed()
+
+		return NewInvalidStateError("WebRtcServer closed")
+	}
+
+	server.webRtcTransports[transport.Id()] = transport
+	server.webRtcTransportsLocker.Unlock()
+
+	// Emit observer event.
+	server.observer.SafeEmit("webrtctransporthandled", transport)
+
+	transport.Observer().On("close", func() {
+		server.webRtcTransportsLocker.Lock()
+		_, ok := server.webRtcTransports[transport.Id()]
+		delete(server.webRtcTransports, transport.Id())
+		server.webRtcTransportsLocker.Unlock()
+
+		if ok {
+			// Emit observer event.
+			server.observer.SafeEmit("webrtctransportunhandled", transport)
+		}
+	})
+
+	return nil
+}
+
+// hasWebRtcTransport tells whether the given WebRtcTransport uses the server.
+func (server *WebRtcServer) hasWebRtcTransport(transportId string) bool {
+	server.webRtcTransportsLocker.Lock()
+	defer server.webRtcTransportsLocker.Unlock()
+
+	_, ok := server.webRtcTransports[transportId]
+
+	return ok
+}
+
+func (server *WebRtcServer) takeWebRtcTransports() map[string]*WebRtcTransport {
+	server.webRtcTransportsLocker.Lock()
+	defer server.webRtcTransportsLocker.Unlock()
+
+	transports := server.webRtcTransports
+	server.webRtcTransports = make(map[string]*WebRtcTransport)
+
+	return transports
+}
@@ -0,0 +1,97 @@
+package mediasoup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebRtcServer(t *testing.T) {
+	fake := newFakeWorker(t)
+	channel := fake.channel
+
+	worker := &Worker{
+		logger:        TypeLogger("Worker"),
+		channel:       channel,
+		observer:      NewEventEmitter(AppLogger()),
+		routers:       make(map[string]*Router),
+		webRtcServers: make(map[string]*WebRtcServer),
+	}
+
+	server, err := worker.CreateWebRtcServer(CreateWebRtcServerParams{
+		ListenInfos: []WebRtcServerListenInfo{
+			{Protocol: "udp", ListenIp: ListenIp{Ip: "127.0.0.1"}, Port: 44444},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, worker.webRtcServers, server.Id())
+	assert.Equal(t, H{}, server.AppData())
+
+	router := NewRouter(internalData{RouterId: "router"}, routerData{}, channel)
+
+	var handled, unhandled []string
+	server.Observer().On("webrtctransporthandled", func(transport *WebRtcTransport) {
+		handled = append(handled, transport.Id())
+	})
+	server.Observer().On("webrtctransportunhandled", func(transport *WebRtcTransport) {
+		unhandled = append(unhandled, transport.Id())
+	})
+
+	transport, err := router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		WebRtcServer: server,
+		EnableUdp:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{transport.Id()}, handled)
+
+	listenServerClosed := false
+	transport.On("listenserverclose", func() { listenServerClosed = true })
+
+	require.NoError(t, server.Close())
+	assert.True(t, server.Closed())
+	assert.True(t, transport.Closed())
+	assert.True(t, listenServerClosed)
+	assert.Equal(t, "closed", transport.IceState())
+	assert.Equal(t, []string{transport.Id()}, unhandled)
+	assert.NotContains(t, worker.webRtcServers, server.Id())
+	assert.Empty(t, router.transports)
+
+	var requests []string
+	for _, request := range fake.requested() {
+		requests = append(requests, fmt.Sprintf("%s %s %s",
+			request.Method, request.Internal.WebRtcServerId, request.Data))
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf(`worker.createWebRtcServer %s {"listenInfos":[{"protocol":"udp","ip":"127.0.0.1","port":44444}]}`, server.Id()),
+		fmt.Sprintf(`router.createWebRtcTransportWithServer %s {"enableUdp":true}`, server.Id()),
+		fmt.Sprintf(`worker.closeWebRtcServer %s null`, server.Id()),
+	}, requests)
+
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{WebRtcServer: server})
+	assert.IsType(t, NewInvalidStateError(""), err)
+}
+
+func TestWebRtcServer_Validation(t *testing.T) {
+	worker := &Worker{logger: TypeLogger("Worker"), version: "3.9.0"}
+
+	_, err := worker.CreateWebRtcServer(CreateWebRtcServerParams{})
+	assert.Equal(t, ErrUnsupportedByWorkerVersion{Feature: WorkerFeatureWebRtcServer, Version: "3.9.0"}, err)
+
+	worker.version = "3.10.0"
+
+	_, err = worker.CreateWebRtcServer(CreateWebRtcServerParams{})
+	assert.IsType(t, ValidationError{}, err)
+
+	_, err = worker.CreateWebRtcServer(CreateWebRtcServerParams{
+		ListenInfos: []WebRtcServerListenInfo{{Protocol: "sctp", ListenIp: ListenIp{Ip: "127.0.0.1"}}},
+	})
+	assert.IsType(t, ValidationError{}, err)
+
+	err = CreateWebRtcTransportParams{
+		WebRtcServer: &WebRtcServer{},
+		ListenIps:    []ListenIp{{Ip: "127.0.0.1"}},
+	}.validate()
+	assert.IsType(t, ValidationError{}, err)
+}
@@ -2,12 +2,15 @@ package mediasoup
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 
 	uuid "github.com/satori/go.uuid"
@@ -16,28 +19,68 @@ import (
 
 type Worker struct {
 	EventEmitter
-	pid          int
-	closed       bool
-	channel      *Channel
-	observer     EventEmitter
-	logger       logrus.FieldLogger
-	workerLogger logrus.FieldLogger
-	child        *exec.Cmd
-	spawnDone    bool
-	routers      map[string]*Router
+	pid           int
+	closed        closeFlag
+	channel       *Channel
+	observer      EventEmitter
+	logger        logrus.FieldLogger
+	child         *exec.Cmd
+	spawnDone     bool
+	routers       map[string]*Router
+	routersLocker sync.Mutex
+	// WebRtcServers of the worker, guarded by routersLocker.
+	webRtcServers map[string]*WebRtcServer
+	// Resolver of the listen hosts of the Routers.
+	listenIpResolver ListenIpResolver
+	// Version of the worker, as given in the options.
+	version string
+	// Current log settings of the worker process.
+	logLevel          string
+	logTags           []string
+	logSettingsLocker sync.Mutex
+	// Counts the Consumers against their limits.
+	consumerLimiter *consumerLimiter
+	// Authorizes the creation of entities.
+	authorizer Authorizer
+	// Set once draining, see Drain.
+	draining closeFlag
+	// Cores the worker process is pinned to.
+	cpuAffinity []int
+	// Last lines of the worker stderr.
+	stderrTail *lineTail
+	// OOM kills of the cgroup when spawned, -1 if unknown.
+	oomKills int64
+	// Port range of the transports not using a WebRtcServer.
+	rtcMinPort uint16
+	rtcMaxPort uint16
 }
 
 func newWorker(workerBin string, options ...Option) (worker *Worker, err error) {
-	if len(workerBin) == 0 {
-		workerBin = os.Getenv("MEDIASOUP_WORKER_BIN")
-	}
-
 	opts := NewOptions()
 
 	for _, option := range options {
 		option(opts)
 	}
 
+	if err = opts.validate(); err != nil {
+		return
+	}
+
+	if len(workerBin) == 0 {
+		workerBin = opts.WorkerBin
+	}
+	if len(workerBin) == 0 {
+		workerBin = os.Getenv("MEDIASOUP_WORKER_BIN")
+	}
+	if _, err = exec.LookPath(workerBin); err != nil {
+		return nil, NewValidationError("Options.WorkerBin", `invalid worker binary "%s": %s`, workerBin, err)
+	}
+
+	name, args, err := cpuAffinityCommand(workerBin, opts.WorkerArgs(), opts.CPUAffinity)
+	if err != nil {
+		return
+	}
+
 	logger := TypeLogger("Worker")
 
 	logger.Debug("constructor()")
@@ -53,12 +96,11 @@ func newWorker(workerBin string, options ...Option) (worker *Worker, err error)
 		return
 	}
 
-	logger.Debugf(
-		"spawning worker process: %s %s", workerBin, strings.Join(opts.WorkerArgs(), " "))
+	logger.Debugf("spawning worker process: %s %s", name, strings.Join(args, " "))
 
-	child := exec.Command(workerBin, opts.WorkerArgs()...)
+	child := exec.Command(name, args...)
 	child.ExtraFiles = []*os.File{os.NewFile(uintptr(fd2), "")}
-	child.Env = []string{"MEDIASOUP_VERSION=" + opts.Version}
+	child.Env = append([]string{"MEDIASOUP_VERSION=" + opts.Version}, opts.Env...)
 
 	stderr, err := child.StderrPipe()
 	if err != nil {
@@ -70,50 +112,82 @@ func newWorker(workerBin string, options ...Option) (worker *Worker, err error)
 		return
 	}
 
+	oomKills := oomKillCount()
+
 	if err = child.Start(); err != nil {
 		return
 	}
 
 	pid := child.Process.Pid
 
-	channel := NewChannel(socket, pid)
+	workerLogs := newWorkerLogForwarder(pid, opts.WorkerLogger, opts.WorkerLogFilter)
+
+	channel := newChannel(socket, pid, workerLogs)
+	channel.requestBackoff = opts.RequestBackoff
+
+	stderrTail := newLineTail(workerStderrTailLines)
 
-	workerLogger := TypeLogger(fmt.Sprintf(`worker[pid:%d]`, pid))
+	spawn("worker.stderr", func() {
+		defer close(stderrTail.done)
 
-	go func() {
 		r := bufio.NewReader(stderr)
 		for {
 			line, _, err := r.ReadLine()
 			if err != nil {
 				break
 			}
-			workerLogger.Errorf(`(stderr) %s`, line)
+			stderrTail.add(string(line))
+			workerLogs.forward(WorkerLogStreamStderr, "error", string(line))
 		}
-	}()
+	})
 
-	go func() {
+	spawn("worker.stdout", func() {
 		r := bufio.NewReader(stdout)
 		for {
 			line, _, err := r.ReadLine()
 			if err != nil {
 				break
 			}
-			workerLogger.Debugf(`(stdout) %s`, line)
+			workerLogs.forward(WorkerLogStreamStdout, "debug", string(line))
 		}
-	}()
+	})
+
+	closed, ctx := newCloseFlag()
 
 	worker = &Worker{
-		EventEmitter: NewEventEmitter(logger),
-		pid:          pid,
-		channel:      channel,
-		observer:     NewEventEmitter(AppLogger()),
-		logger:       logger,
-		workerLogger: workerLogger,
-		child:        child,
-		routers:      make(map[string]*Router),
+		EventEmitter:  NewEventEmitter(logger, WithEmitterContext(ctx)),
+		closed:        closed,
+		pid:           pid,
+		channel:       channel,
+		observer:      NewEventEmitter(AppLogger(), WithEmitterContext(ctx)),
+		logger:        logger,
+		child:         child,
+		stderrTail:    stderrTail,
+		oomKills:      oomKills,
+		routers:       make(map[string]*Router),
+		webRtcServers: make(map[string]*WebRtcServer),
+
+		version:          opts.Version,
+		listenIpResolver: opts.ListenIpResolver,
+		authorizer:       opts.Authorizer,
+		logLevel:         opts.LogLevel,
+		logTags:          opts.LogTags,
+		cpuAffinity:      opts.CPUAffinity,
+		rtcMinPort:       opts.RTCMinPort,
+		rtcMaxPort:       opts.RTCMaxPort,
 	}
 
-	channel.Once(strconv.Itoa(pid), func(event string) {
+	worker.consumerLimiter = newConsumerLimiter(opts.MaxConsumersSoft, opts.MaxConsumersHard, func(count int) {
+		logger.Warnf("soft limit of consumers reached [pid:%d, consumers:%d]", pid, count)
+
+		worker.SafeEmit("consumersoftlimit", count)
+	}, func(count int) {
+		logger.Warnf("hard limit of consumers reached [pid:%d, consumers:%d]", pid, count)
+
+		worker.SafeEmit("consumerhardlimit", count)
+	})
+
+	channel.subscribeOnce(strconv.Itoa(pid), func(event string, data json.RawMessage) {
 		if !worker.spawnDone && event == "running" {
 			worker.spawnDone = true
 
@@ -123,7 +197,9 @@ func newWorker(workerBin string, options ...Option) (worker *Worker, err error)
 		}
 	})
 
-	go worker.wait(child)
+	spawn("worker.wait", func() {
+		worker.wait(child)
+	})
 
 	return
 }
@@ -133,22 +209,28 @@ func (w *Worker) Pid() int {
 }
 
 func (w *Worker) Closed() bool {
-	return w.closed
+	return w.closed.isSet()
 }
 
-func (w Worker) Observer() EventEmitter {
+/**
+ * Observer.
+ *
+ * @emits close
+ * @emits {router: Router} newrouter
+ * @emits {webRtcServer: WebRtcServer} newwebrtcserver
+ */
+func (w *Worker) Observer() EventEmitter {
 	return w.observer
 }
 
 func (w *Worker) Close() {
-	if w.closed {
+	if !w.closed.set() {
 		return
 	}
+	defer w.closed.cancelContext()
 
 	w.logger.Debugln("close()")
 
-	w.closed = true
-
 	// Kill the worker process.
 	if w.child != nil {
 		w.child.Process.Signal(syscall.SIGTERM)
@@ -158,34 +240,201 @@ func (w *Worker) Close() {
 	// Close the Channel instance.
 	w.channel.Close()
 
-	// Close every Router.
-	for _, router := range w.routers {
+	// Close every Router and WebRtcServer, outside of the lock since their
+	// listeners may use the Worker.
+	w.routersLocker.Lock()
+	routers := w.routers
+	w.routers = make(map[string]*Router)
+	webRtcServers := w.webRtcServers
+	w.webRtcServers = make(map[string]*WebRtcServer)
+	w.routersLocker.Unlock()
+
+	for _, router := range routers {
 		router.workerClosed()
 	}
-	w.routers = make(map[string]*Router)
+	for _, webRtcServer := range webRtcServers {
+		webRtcServer.workerClosed()
+	}
 
 	// Emit observer event.
 	w.observer.SafeEmit("close")
 }
 
 // Dump Worker.
-func (w *Worker) Dump() Response {
+func (w *Worker) Dump() (dump WorkerDump, err error) {
 	w.logger.Debugln("dump()")
 
-	return w.channel.Request("worker.dump", nil, nil)
+	err = w.channel.requestIdempotent(context.Background(), "worker.dump", nil, nil).Unmarshal(&dump)
+
+	return
 }
 
-// UpdateSettings Update settings.
+// GetResourceUsage returns the resource usage of the worker process.
+func (w *Worker) GetResourceUsage() (usage WorkerResourceUsage, err error) {
+	w.logger.Debugln("getResourceUsage()")
+
+	if err = checkWorkerSupports(w.version, WorkerFeatureResourceUsage); err != nil {
+		return
+	}
+
+	err = w.channel.requestIdempotent(context.Background(), "worker.getResourceUsage", nil, nil).Unmarshal(&usage)
+
+	return
+}
+
+// UpdateSettings changes the log level and the log tags of the running
+// worker, the empty ones being left unchanged (see DisableLogTags to remove
+// log tags).
 func (w *Worker) UpdateSettings(options Options) Response {
 	w.logger.Debugln("updateSettings()")
 
-	return w.channel.Request("worker.updateSettings", nil, options)
+	if err := validateLogSettings(options.LogLevel, options.LogTags); err != nil {
+		return Response{err: err}
+	}
+
+	w.logSettingsLocker.Lock()
+	defer w.logSettingsLocker.Unlock()
+
+	logLevel, logTags := w.logLevel, w.logTags
+
+	if len(options.LogLevel) > 0 {
+		logLevel = options.LogLevel
+	}
+	if len(options.LogTags) > 0 {
+		logTags = options.LogTags
+	}
+
+	return Response{err: w.setLogSettings(logLevel, logTags)}
+}
+
+// CreateWebRtcServer creates a WebRtcServer, whose ports are shared by the
+// WebRtcTransports created with CreateWebRtcTransportParams.WebRtcServer.
+func (w *Worker) CreateWebRtcServer(params CreateWebRtcServerParams) (webRtcServer *WebRtcServer, err error) {
+	w.logger.Debug("createWebRtcServer()")
+
+	if w.Draining() {
+		return nil, ErrWorkerDraining
+	}
+	if err = checkWorkerSupports(w.version, WorkerFeatureWebRtcServer); err != nil {
+		return
+	}
+	if params.AppData == nil {
+		params.AppData = H{}
+	}
+	if err = params.validate(); err != nil {
+		return
+	}
+
+	listenInfos := make([]WebRtcServerListenInfo, len(params.ListenInfos))
+
+	for i, listenInfo := range params.ListenInfos {
+		if listenInfo.ListenIp, err = resolveListenIp(w.listenIpResolver,
+			fmt.Sprintf("CreateWebRtcServerParams.ListenInfos[%d]", i), listenInfo.ListenIp); err != nil {
+			return
+		}
+		listenInfos[i] = listenInfo
+	}
+
+	internal := internalData{WebRtcServerId: uuid.NewV4().String()}
+	reqData := H{"listenInfos": listenInfos}
+
+	if err = w.channel.Request("worker.createWebRtcServer", internal, reqData).Err(); err != nil {
+		return
+	}
+
+	webRtcServer = NewWebRtcServer(internal, w.channel, params.AppData)
+	webRtcServer.listenInfos = listenInfos
+
+	w.routersLocker.Lock()
+
+	if w.closed.isSet() {
+		w.routersLocker.Unlock()
+
+		webRtcServer.workerClosed()
+
+		return nil, NewInvalidStateError("Worker closed")
+	}
+
+	w.webRtcServers[internal.WebRtcServerId] = webRtcServer
+	w.routersLocker.Unlock()
+
+	webRtcServer.On("@close", func() {
+		w.routersLocker.Lock()
+		delete(w.webRtcServers, internal.WebRtcServerId)
+		w.routersLocker.Unlock()
+	})
+
+	// Emit observer event.
+	w.observer.SafeEmit("newwebrtcserver", webRtcServer)
+
+	return
 }
 
 // CreateRouter creates a router.
-func (w *Worker) CreateRouter(mediaCodecs []RtpCodecCapability) (router *Router, err error) {
+func (w *Worker) CreateRouter(
+	mediaCodecs []RtpCodecCapability, options ...RouterOption,
+) (router *Router, err error) {
 	w.logger.Debug("createRouter()")
 
+	template, err := NewRouterTemplate(mediaCodecs)
+	if err != nil {
+		return
+	}
+
+	return w.createRouter(template, RouterSettings{}, options)
+}
+
+// CreateRouterFromTemplate creates a router with the media codecs and the RTP
+// capabilities of the given template.
+func (w *Worker) CreateRouterFromTemplate(
+	template *RouterTemplate, options ...RouterOption,
+) (router *Router, err error) {
+	w.logger.Debug("createRouterFromTemplate()")
+
+	return w.createRouter(template, RouterSettings{}, options)
+}
+
+// CreateRouterWithProfile creates a router with the named profile (see
+// RegisterRouterProfile), the given options overriding the profile settings.
+func (w *Worker) CreateRouterWithProfile(
+	name string, options ...RouterOption,
+) (router *Router, err error) {
+	w.logger.Debug("createRouterWithProfile()")
+
+	routerProfiles.RLock()
+	profile, ok := routerProfiles.profiles[name]
+	routerProfiles.RUnlock()
+
+	if !ok {
+		err = NewTypeError(`router profile "%s" not found`, name)
+		return
+	}
+
+	return w.createRouter(profile.template, profile.settings, options)
+}
+
+func (w *Worker) createRouter(
+	template *RouterTemplate, settings RouterSettings, options []RouterOption,
+) (router *Router, err error) {
+	if w.Draining() {
+		return nil, ErrWorkerDraining
+	}
+	if settings, err = newRouterSettings(settings, options...); err != nil {
+		return
+	}
+	if settings.ListenIps, err = resolveListenIps(
+		w.listenIpResolver, "RouterSettings.ListenIps", settings.ListenIps...); err != nil {
+		return
+	}
+
+	for _, codec := range template.data.RtpCapabilities.Codecs {
+		if strings.EqualFold(codec.MimeType, "video/AV1") {
+			if err = checkWorkerSupports(w.version, WorkerFeatureAV1); err != nil {
+				return
+			}
+		}
+	}
+
 	internal := internalData{RouterId: uuid.NewV4().String()}
 
 	rsp := w.channel.Request("worker.createRouter", internal, nil)
@@ -193,17 +442,33 @@ func (w *Worker) CreateRouter(mediaCodecs []RtpCodecCapability) (router *Router,
 		return
 	}
 
-	rtpCapabilities, err := GenerateRouterRtpCapabilities(mediaCodecs)
-	if err != nil {
-		return
-	}
-	data := routerData{RtpCapabilities: rtpCapabilities}
+	data := template.data
+	data.Settings = settings
 
 	router = NewRouter(internal, data, w.channel)
+	router.listenIpResolver = w.listenIpResolver
+	router.workerVersion = w.version
+	router.consumerLimiter = w.consumerLimiter
+	router.authorizer = w.authorizer
+	router.workerDraining = &w.draining
+
+	w.routersLocker.Lock()
+
+	if w.closed.isSet() {
+		w.routersLocker.Unlock()
+
+		router.workerClosed()
+
+		return nil, NewInvalidStateError("Worker closed")
+	}
 
 	w.routers[internal.RouterId] = router
+	w.routersLocker.Unlock()
+
 	router.On("@close", func() {
+		w.routersLocker.Lock()
 		delete(w.routers, internal.RouterId)
+		w.routersLocker.Unlock()
 	})
 
 	// Emit observer event.
@@ -212,10 +477,46 @@ func (w *Worker) CreateRouter(mediaCodecs []RtpCodecCapability) (router *Router,
 	return
 }
 
+// getRouters returns the Routers of the Worker.
+func (w *Worker) getRouters() []*Router {
+	w.routersLocker.Lock()
+	defer w.routersLocker.Unlock()
+
+	routers := make([]*Router, 0, len(w.routers))
+
+	for _, router := range w.routers {
+		routers = append(routers, router)
+	}
+
+	return routers
+}
+
+// getWebRtcServers returns the WebRtcServers of the Worker.
+func (w *Worker) getWebRtcServers() []*WebRtcServer {
+	w.routersLocker.Lock()
+	defer w.routersLocker.Unlock()
+
+	webRtcServers := make([]*WebRtcServer, 0, len(w.webRtcServers))
+
+	for _, webRtcServer := range w.webRtcServers {
+		webRtcServers = append(webRtcServers, webRtcServer)
+	}
+
+	return webRtcServers
+}
+
 func (w *Worker) wait(child *exec.Cmd) {
+	// Wait closes the pipes, so the last lines must be read before.
+	<-w.stderrTail.done
+
 	err := child.Wait()
 
-	w.child = nil
+	// Let the private listeners see the entities of the died worker before
+	// they are closed (e.g. to snapshot them).
+	if w.spawnDone && !w.closed.isSet() {
+		w.Emit("@exit")
+	}
+
 	w.Close()
 
 	code, signal := 0, ""
@@ -233,6 +534,17 @@ func (w *Worker) wait(child *exec.Cmd) {
 		}
 	}
 
+	stderrTail := w.stderrTail.get()
+	oomKilled := w.oomKills >= 0 && oomKillCount() > w.oomKills
+
+	info := WorkerDiedInfo{
+		Pid:        w.pid,
+		ExitCode:   code,
+		Signal:     signal,
+		Reason:     classifyWorkerExit(code, signal, stderrTail, oomKilled),
+		StderrTail: stderrTail,
+	}
+
 	if !w.spawnDone {
 		w.spawnDone = true
 
@@ -241,15 +553,16 @@ func (w *Worker) wait(child *exec.Cmd) {
 
 			w.Emit("@failure", NewTypeError("wrong settings"))
 		} else {
-			w.logger.Errorf("worker process failed unexpectedly [pid:%d, code:%d, signal:%s]",
-				w.pid, code, signal)
+			w.logger.Errorf("worker process failed unexpectedly [pid:%d, code:%d, signal:%s, reason:%s]",
+				w.pid, code, signal, info.Reason)
 
-			w.Emit("@failure", fmt.Errorf(`[pid:%d, code:%d, signal:%s]`, w.pid, code, signal))
+			w.Emit("@failure", info)
 		}
 	} else {
-		w.logger.Errorf("worker process died unexpectedly [pid:%d, code:%d, signal:%s]", w.pid, code, signal)
+		w.logger.Errorf("worker process died unexpectedly [pid:%d, code:%d, signal:%s, reason:%s]",
+			w.pid, code, signal, info.Reason)
 
-		w.SafeEmit("died", fmt.Errorf("[pid:%d, code:%d, signal:%s]", w.pid, code, signal))
+		w.SafeEmit("died", info)
 	}
 }
 
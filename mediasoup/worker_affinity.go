@@ -0,0 +1,48 @@
+package mediasoup
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Binary pinning the worker process to its cores.
+var tasksetBin = "taskset"
+
+// cpuAffinityCommand returns the command spawning the worker pinned to the
+// given cores, through taskset so that all the threads of the worker inherit
+// the affinity, the worker binary itself if none.
+func cpuAffinityCommand(workerBin string, args []string, cpus []int) (name string, cmdArgs []string, err error) {
+	if len(cpus) == 0 {
+		return workerBin, args, nil
+	}
+
+	if runtime.GOOS != "linux" {
+		return "", nil, NewValidationError("Options.CPUAffinity", "not supported on %s", runtime.GOOS)
+	}
+
+	if name, err = exec.LookPath(tasksetBin); err != nil {
+		return "", nil, NewValidationError("Options.CPUAffinity", "%s not found: %s", tasksetBin, err)
+	}
+	// The worker environment has no PATH.
+	if workerBin, err = exec.LookPath(workerBin); err != nil {
+		return "", nil, NewValidationError("Options.WorkerBin", `invalid worker binary "%s": %s`, workerBin, err)
+	}
+
+	list := make([]string, len(cpus))
+
+	for i, cpu := range cpus {
+		list[i] = strconv.Itoa(cpu)
+	}
+
+	cmdArgs = append([]string{"-c", strings.Join(list, ","), workerBin}, args...)
+
+	return
+}
+
+// CPUAffinity returns the cores the worker process is pinned to, empty if not
+// pinned.
+func (w *Worker) CPUAffinity() []int {
+	return append([]int{}, w.cpuAffinity...)
+}
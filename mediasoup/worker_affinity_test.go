@@ -0,0 +1,40 @@
+package mediasoup
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCpuAffinityCommand(t *testing.T) {
+	name, args, err := cpuAffinityCommand("mediasoup-worker", []string{"--logLevel=warn"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "mediasoup-worker", name)
+	assert.Equal(t, []string{"--logLevel=warn"}, args)
+
+	if runtime.GOOS != "linux" {
+		_, _, err = cpuAffinityCommand("sh", nil, []int{0})
+		assertValidationError(t, "Options.CPUAffinity", err)
+		return
+	}
+	if _, err := exec.LookPath(tasksetBin); err != nil {
+		t.Skip("taskset not installed")
+	}
+
+	shPath, err := exec.LookPath("sh")
+	require.NoError(t, err)
+
+	name, args, err = cpuAffinityCommand("sh", []string{"--logLevel=warn"}, []int{1, 3})
+	require.NoError(t, err)
+	assert.Contains(t, name, tasksetBin)
+	assert.Equal(t, []string{"-c", "1,3", shPath, "--logLevel=warn"}, args)
+
+	tasksetBin = "notfound-taskset"
+	defer func() { tasksetBin = "taskset" }()
+
+	_, _, err = cpuAffinityCommand("sh", nil, []int{0})
+	assertValidationError(t, "Options.CPUAffinity", err)
+}
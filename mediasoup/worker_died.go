@@ -0,0 +1,67 @@
+package mediasoup
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Number of the last lines of the worker stderr kept for WorkerDiedInfo.
+const workerStderrTailLines = 20
+
+// WorkerDiedInfo describes the unexpected exit of a worker process, given as
+// the error of the "died" event:
+//
+//	worker.On("died", func(err error) {
+//		if info, ok := err.(WorkerDiedInfo); ok {
+//			log.Printf("worker died (%s): %s\n%s", info.Reason, info, strings.Join(info.StderrTail, "\n"))
+//		}
+//	})
+type WorkerDiedInfo struct {
+	Pid int
+	// Exit code of the process, -1 if killed by a signal.
+	ExitCode int
+	// Signal that killed or stopped the process, empty if none.
+	Signal string
+	// Classification of the exit, see WorkerExitReason.
+	Reason WorkerExitReason
+	// Last lines written by the process to stderr, oldest first.
+	StderrTail []string
+}
+
+func (info WorkerDiedInfo) Error() string {
+	return fmt.Sprintf("[pid:%d, code:%d, signal:%s]", info.Pid, info.ExitCode, info.Signal)
+}
+
+// lineTail keeps the last lines written to a stream.
+type lineTail struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+	// Closed once the stream is fully read.
+	done chan struct{}
+}
+
+func newLineTail(max int) *lineTail {
+	return &lineTail{
+		max:  max,
+		done: make(chan struct{}),
+	}
+}
+
+func (t *lineTail) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lines = append(t.lines, line)
+
+	if len(t.lines) > t.max {
+		t.lines = append([]string(nil), t.lines[len(t.lines)-t.max:]...)
+	}
+}
+
+func (t *lineTail) get() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.lines...)
+}
@@ -0,0 +1,70 @@
+package mediasoup
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineTail(t *testing.T) {
+	tail := newLineTail(3)
+
+	tail.add("1")
+	tail.add("2")
+	assert.Equal(t, []string{"1", "2"}, tail.get())
+
+	tail.add("3")
+	tail.add("4")
+	tail.add("5")
+	assert.Equal(t, []string{"3", "4", "5"}, tail.get())
+}
+
+func TestWorkerDiedInfo(t *testing.T) {
+	info := WorkerDiedInfo{Pid: 10, ExitCode: -1, Signal: "killed", Reason: WorkerExitSignal, StderrTail: []string{"abort"}}
+
+	var err error = info
+	assert.Equal(t, "[pid:10, code:-1, signal:killed]", err.Error())
+
+	var target WorkerDiedInfo
+	assert.True(t, errors.As(err, &target))
+	assert.Equal(t, []string{"abort"}, target.StderrTail)
+
+	// Listened to as an error or as a WorkerDiedInfo.
+	emitter := NewEventEmitter(AppLogger())
+
+	var (
+		gotErr  error
+		gotInfo WorkerDiedInfo
+	)
+	EventWorkerDied.On(emitter, func(err error) { gotErr = err })
+	EventWorkerDiedInfo.On(emitter, func(info WorkerDiedInfo) { gotInfo = info })
+
+	emitter.SafeEmit("died", info)
+	assert.Equal(t, info, gotErr)
+	assert.Equal(t, info, gotInfo)
+}
+
+func TestWorkerEmitsDied_Info(t *testing.T) {
+	worker := CreateTestWorker(WithLogLevel("warn"))
+
+	infoCh := make(chan WorkerDiedInfo, 1)
+	EventWorkerDiedInfo.On(worker, func(info WorkerDiedInfo) { infoCh <- info })
+
+	process, err := os.FindProcess(worker.Pid())
+	require.NoError(t, err)
+	process.Signal(syscall.SIGKILL)
+
+	select {
+	case info := <-infoCh:
+		assert.Equal(t, worker.Pid(), info.Pid)
+		assert.Equal(t, syscall.SIGKILL.String(), info.Signal)
+		assert.Equal(t, -1, info.ExitCode)
+	case <-time.After(time.Second):
+		assert.FailNow(t, "timeout")
+	}
+}
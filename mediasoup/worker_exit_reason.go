@@ -0,0 +1,134 @@
+package mediasoup
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// WorkerExitReason classifies the unexpected exit of a worker process.
+type WorkerExitReason string
+
+const (
+	// Exited with the code 0.
+	WorkerExitNormal WorkerExitReason = "normal"
+	// Exited with a non-zero code.
+	WorkerExitCode WorkerExitReason = "exitcode"
+	// Killed or stopped by a signal.
+	WorkerExitSignal WorkerExitReason = "signal"
+	// Killed by the OOM killer, or aborted on a failed allocation.
+	WorkerExitOOMKilled WorkerExitReason = "oomkilled"
+	// Aborted, the stderr tail holding the abort or assertion message.
+	WorkerExitPanic WorkerExitReason = "panic"
+)
+
+// Patterns of the stderr lines written on a failed allocation.
+var workerOOMPatterns = []string{
+	"std::bad_alloc",
+	"out of memory",
+	"cannot allocate memory",
+}
+
+// Patterns of the stderr lines written on abort (MS_ABORT, MS_ASSERT, uncaught
+// C++ exceptions, libuv assertions).
+var workerPanicPatterns = []string{
+	"(abort)",
+	"failed assertion",
+	"assertion failed",
+	"assertion `",
+	"terminate called",
+	"panic",
+}
+
+// classifyWorkerExit classifies the exit of a worker process given its exit
+// code, signal, last stderr lines and whether the OOM killer killed a process
+// of its cgroup meanwhile.
+func classifyWorkerExit(code int, signal string, stderrTail []string, oomKilled bool) WorkerExitReason {
+	if matchesAnyLine(stderrTail, workerOOMPatterns) {
+		return WorkerExitOOMKilled
+	}
+	if oomKilled && signal == syscall.SIGKILL.String() {
+		return WorkerExitOOMKilled
+	}
+	if matchesAnyLine(stderrTail, workerPanicPatterns) {
+		return WorkerExitPanic
+	}
+	if len(signal) > 0 {
+		return WorkerExitSignal
+	}
+	if code != 0 {
+		return WorkerExitCode
+	}
+
+	return WorkerExitNormal
+}
+
+func matchesAnyLine(lines []string, patterns []string) bool {
+	for _, line := range lines {
+		line = strings.ToLower(line)
+
+		for _, pattern := range patterns {
+			if strings.Contains(line, pattern) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Root of the cgroup v2 hierarchy, a var for tests.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// oomKillCount returns the number of processes of the current cgroup killed by
+// the OOM killer, -1 if unknown (e.g. not on Linux or cgroup v1).
+func oomKillCount() int64 {
+	data, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return -1
+	}
+
+	var path string
+
+	// The cgroup v2 line is "0::<path>".
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "0::") {
+			path = strings.TrimPrefix(line, "0::")
+			break
+		}
+	}
+	if len(path) == 0 {
+		return -1
+	}
+
+	return readOomKillCount(filepath.Join(cgroupRoot, path, "memory.events"))
+}
+
+// readOomKillCount returns the "oom_kill" counter of a cgroup v2
+// memory.events file, -1 if unknown.
+func readOomKillCount(file string) int64 {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return -1
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return -1
+			}
+			return count
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,49 @@
+package mediasoup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyWorkerExit(t *testing.T) {
+	sigkill, sigabrt := syscall.SIGKILL.String(), syscall.SIGABRT.String()
+
+	assert.Equal(t, WorkerExitNormal, classifyWorkerExit(0, "", nil, false))
+	assert.Equal(t, WorkerExitCode, classifyWorkerExit(1, "", []string{"bye"}, false))
+	assert.Equal(t, WorkerExitSignal, classifyWorkerExit(-1, sigkill, nil, false))
+	assert.Equal(t, WorkerExitOOMKilled, classifyWorkerExit(-1, sigkill, nil, true))
+	// Only SIGKILL is sent by the OOM killer.
+	assert.Equal(t, WorkerExitSignal, classifyWorkerExit(-1, "terminated", nil, true))
+
+	assert.Equal(t, WorkerExitOOMKilled, classifyWorkerExit(-1, sigabrt, []string{
+		"terminate called after throwing an instance of 'std::bad_alloc'",
+	}, false))
+	assert.Equal(t, WorkerExitPanic, classifyWorkerExit(-1, sigabrt, []string{
+		"(ABORT) RTC::Transport::Foo() | failed assertion `ptr != nullptr'",
+	}, false))
+	assert.Equal(t, WorkerExitPanic, classifyWorkerExit(1, "", []string{
+		"terminate called after throwing an instance of 'MediaSoupError'",
+	}, false))
+}
+
+func TestReadOomKillCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "memory.events")
+
+	assert.EqualValues(t, -1, readOomKillCount(file))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("low 0\nhigh 0\nmax 3\noom 2\noom_kill 2\n"), 0644))
+	assert.EqualValues(t, 2, readOomKillCount(file))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("low 0\n"), 0644))
+	assert.EqualValues(t, -1, readOomKillCount(file))
+}
@@ -0,0 +1,168 @@
+package mediasoup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WorkerFeature is a feature of the library depending on the worker version.
+type WorkerFeature string
+
+const (
+	// AV1 codec in the Router media codecs.
+	WorkerFeatureAV1 WorkerFeature = "av1"
+	// ScalabilityMode of the Producer encodings (VP9 and AV1 SVC).
+	WorkerFeatureScalabilityMode WorkerFeature = "scalabilityMode"
+	// "bwe", "svc", "sctp" and "message" log tags.
+	WorkerFeatureExtendedLogTags WorkerFeature = "extendedLogTags"
+	// WebRtcServers sharing their ports with WebRtcTransports.
+	WorkerFeatureWebRtcServer WorkerFeature = "webRtcServer"
+	// Resource usage of the worker process.
+	WorkerFeatureResourceUsage WorkerFeature = "resourceUsage"
+)
+
+// What the library does when the worker doesn't support a feature.
+const (
+	// The feature is left out, the API working without it.
+	WorkerFeatureFallback = "fallback"
+	// The API fails with ErrUnsupportedByWorkerVersion.
+	WorkerFeatureError = "error"
+)
+
+// WorkerFeatureSupport is an entry of the WorkerFeatureMatrix.
+type WorkerFeatureSupport struct {
+	Feature WorkerFeature `json:"feature"`
+	// First worker version supporting the feature.
+	MinVersion string `json:"minVersion"`
+	// WorkerFeatureFallback or WorkerFeatureError.
+	Unsupported string `json:"unsupported"`
+	Description string `json:"description"`
+}
+
+// WorkerFeatureMatrix lists the features depending on the worker version,
+// which is the one given with WithVersion ("latest" supporting everything).
+var WorkerFeatureMatrix = []WorkerFeatureSupport{
+	{
+		Feature:     WorkerFeatureAV1,
+		MinVersion:  "3.10.0",
+		Unsupported: WorkerFeatureError,
+		Description: "AV1 codec in the Router media codecs",
+	},
+	{
+		Feature:     WorkerFeatureScalabilityMode,
+		MinVersion:  "3.4.0",
+		Unsupported: WorkerFeatureFallback,
+		Description: "scalabilityMode of the Producer encodings, dropped (single layer)",
+	},
+	{
+		Feature:     WorkerFeatureExtendedLogTags,
+		MinVersion:  "3.3.0",
+		Unsupported: WorkerFeatureFallback,
+		Description: `"bwe", "svc", "sctp" and "message" log tags, dropped`,
+	},
+	{
+		Feature:     WorkerFeatureWebRtcServer,
+		MinVersion:  "3.10.0",
+		Unsupported: WorkerFeatureError,
+		Description: "WebRtcServers, Worker.CreateWebRtcServer failing",
+	},
+	{
+		Feature:     WorkerFeatureResourceUsage,
+		MinVersion:  "3.6.0",
+		Unsupported: WorkerFeatureError,
+		Description: "resource usage of the worker process, Worker.GetResourceUsage failing",
+	},
+}
+
+// ErrUnsupportedByWorkerVersion produced when calling an API needing a more
+// recent worker.
+type ErrUnsupportedByWorkerVersion struct {
+	Feature WorkerFeature
+	Version string
+}
+
+func (e ErrUnsupportedByWorkerVersion) Error() string {
+	return fmt.Sprintf(`UnsupportedByWorkerVersion:feature "%s" is not supported by worker %s`,
+		e.Feature, e.Version)
+}
+
+// workerSupports returns whether the worker of the given version supports the
+// given feature. Versions which are not semver (e.g. "latest") support every
+// known feature.
+func workerSupports(version string, feature WorkerFeature) bool {
+	for _, support := range WorkerFeatureMatrix {
+		if support.Feature != feature {
+			continue
+		}
+
+		current, ok := parseWorkerVersion(version)
+		if !ok {
+			return true
+		}
+		min, _ := parseWorkerVersion(support.MinVersion)
+
+		return compareWorkerVersions(current, min) >= 0
+	}
+
+	return false
+}
+
+// compareWorkerVersions returns -1, 0 or 1 if a is older, equal or newer
+// than b.
+func compareWorkerVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// checkWorkerSupports returns ErrUnsupportedByWorkerVersion if the worker of
+// the given version doesn't support the given feature.
+func checkWorkerSupports(version string, feature WorkerFeature) error {
+	if !workerSupports(version, feature) {
+		return ErrUnsupportedByWorkerVersion{Feature: feature, Version: version}
+	}
+
+	return nil
+}
+
+// parseWorkerVersion parses "[v]major.minor.patch[-pre]" versions.
+func parseWorkerVersion(version string) (parsed [3]int, ok bool) {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return
+		}
+		parsed[i] = n
+	}
+
+	return parsed, true
+}
+
+// Version of the worker, as given with WithVersion.
+func (w *Worker) Version() string {
+	return w.version
+}
+
+// Supports returns whether the worker supports the given feature, according
+// to the WorkerFeatureMatrix.
+func (w *Worker) Supports(feature WorkerFeature) bool {
+	return workerSupports(w.version, feature)
+}
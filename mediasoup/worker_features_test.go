@@ -0,0 +1,72 @@
+package mediasoup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerSupports(t *testing.T) {
+	assert.True(t, workerSupports("latest", WorkerFeatureAV1))
+	assert.True(t, workerSupports("", WorkerFeatureAV1))
+	assert.True(t, workerSupports("3.10.0", WorkerFeatureAV1))
+	assert.True(t, workerSupports("v3.11.2", WorkerFeatureAV1))
+	assert.True(t, workerSupports("4.0.0-beta.1", WorkerFeatureAV1))
+	assert.False(t, workerSupports("3.9.17", WorkerFeatureAV1))
+	assert.False(t, workerSupports("2.6.0", WorkerFeatureAV1))
+	assert.False(t, workerSupports("latest", WorkerFeature("unknown")))
+
+	assert.NoError(t, checkWorkerSupports("3.10.0", WorkerFeatureAV1))
+	assert.Equal(t,
+		ErrUnsupportedByWorkerVersion{Feature: WorkerFeatureAV1, Version: "3.9.0"},
+		checkWorkerSupports("3.9.0", WorkerFeatureAV1))
+}
+
+func TestWorkerFeatureMatrix(t *testing.T) {
+	for _, support := range WorkerFeatureMatrix {
+		_, ok := parseWorkerVersion(support.MinVersion)
+		assert.True(t, ok, support.Feature)
+		assert.Contains(t, []string{WorkerFeatureFallback, WorkerFeatureError}, support.Unsupported)
+	}
+
+	data, err := json.Marshal(WorkerFeatureMatrix[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"feature": "av1",
+		"minVersion": "3.10.0",
+		"unsupported": "error",
+		"description": "AV1 codec in the Router media codecs"
+	}`, string(data))
+}
+
+func TestSupportedLogTags(t *testing.T) {
+	tags := []string{WorkerLogTagIce, WorkerLogTagBwe, WorkerLogTagRtp, WorkerLogTagSctp}
+
+	assert.Equal(t, tags, supportedLogTags("latest", tags))
+	assert.Equal(t, []string{WorkerLogTagIce, WorkerLogTagRtp}, supportedLogTags("3.2.0", tags))
+
+	options := Options{Version: "3.2.0", LogTags: tags}
+	assert.Contains(t, options.WorkerArgs(), "--logTags=ice")
+	assert.NotContains(t, options.WorkerArgs(), "--logTags=bwe")
+}
+
+func TestWorkerVersion_CreateRouterWithUnsupportedCodec(t *testing.T) {
+	worker := CreateTestWorker(WithVersion("3.9.0"))
+	defer worker.Close()
+
+	assert.Equal(t, "3.9.0", worker.Version())
+	assert.False(t, worker.Supports(WorkerFeatureAV1))
+	assert.True(t, worker.Supports(WorkerFeatureScalabilityMode))
+
+	_, err := worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "video", MimeType: "video/AV1", ClockRate: 90000},
+	})
+	assert.Equal(t, ErrUnsupportedByWorkerVersion{Feature: WorkerFeatureAV1, Version: "3.9.0"}, err)
+
+	_, err = worker.CreateRouter([]RtpCodecCapability{
+		{Kind: "video", MimeType: "video/VP8", ClockRate: 90000},
+	})
+	assert.NoError(t, err)
+}
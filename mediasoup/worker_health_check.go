@@ -0,0 +1,200 @@
+package mediasoup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Ping sends a cheap request to the worker, returning the time it took to
+// answer, e.g. for liveness probes.
+func (w *Worker) Ping(ctx context.Context) (latency time.Duration, err error) {
+	w.logger.Debugln("ping()")
+
+	if w.Closed() {
+		return 0, NewInvalidStateError("Worker closed")
+	}
+
+	// The dumps have a low priority, waiting behind the other ones.
+	method := "worker.dump"
+
+	if workerSupports(w.version, WorkerFeatureResourceUsage) {
+		method = "worker.getResourceUsage"
+	}
+
+	start := time.Now()
+	rspCh := make(chan Response, 1)
+
+	spawn("worker.ping", func() {
+		rspCh <- w.channel.requestIdempotent(ctx, method, nil, nil)
+	})
+
+	select {
+	case rsp := <-rspCh:
+		err = rsp.Err()
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return time.Since(start), err
+}
+
+// WorkerHealthCheckOptions to check the health of a Worker.
+type WorkerHealthCheckOptions struct {
+	// The worker is pinged every Interval, each ping failing after Timeout.
+	Interval time.Duration
+	Timeout  time.Duration
+	// Number of consecutive failed pings after which the worker is unhealthy.
+	FailureThreshold int
+}
+
+type WorkerHealthCheckOption func(o *WorkerHealthCheckOptions)
+
+func WithWorkerHealthCheckInterval(interval, timeout time.Duration) WorkerHealthCheckOption {
+	return func(o *WorkerHealthCheckOptions) {
+		o.Interval = interval
+		o.Timeout = timeout
+	}
+}
+
+func WithWorkerHealthCheckFailureThreshold(failureThreshold int) WorkerHealthCheckOption {
+	return func(o *WorkerHealthCheckOptions) {
+		o.FailureThreshold = failureThreshold
+	}
+}
+
+// WorkerHealthChecker pings a Worker in the background until stopped or the
+// worker is closed, the worker being unhealthy after FailureThreshold
+// consecutive failed pings and healthy again after a successful one.
+//
+// @emits {failures: Number, err: error} unhealthy
+// @emits healthy
+type WorkerHealthChecker struct {
+	EventEmitter
+	logger  logrus.FieldLogger
+	worker  *Worker
+	options WorkerHealthCheckOptions
+	mu      sync.Mutex
+	// Consecutive failed pings.
+	failures  int
+	unhealthy bool
+	latency   time.Duration
+	cancel    context.CancelFunc
+}
+
+func NewWorkerHealthChecker(worker *Worker, options ...WorkerHealthCheckOption) *WorkerHealthChecker {
+	logger := TypeLogger("WorkerHealthChecker")
+
+	logger.Debug("constructor()")
+
+	o := WorkerHealthCheckOptions{
+		Interval:         10 * time.Second,
+		Timeout:          5 * time.Second,
+		FailureThreshold: 3,
+	}
+	for _, option := range options {
+		option(&o)
+	}
+
+	ctx, cancel := context.WithCancel(worker.Context())
+
+	checker := &WorkerHealthChecker{
+		EventEmitter: NewEventEmitter(logger),
+		logger:       logger,
+		worker:       worker,
+		options:      o,
+		cancel:       cancel,
+	}
+
+	spawn("worker.healthCheck", func() {
+		checker.run(ctx)
+	})
+
+	return checker
+}
+
+// Healthy returns false once the worker failed FailureThreshold consecutive
+// pings, until the next successful one.
+func (c *WorkerHealthChecker) Healthy() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.unhealthy
+}
+
+// Latency returns the latency of the last successful ping.
+func (c *WorkerHealthChecker) Latency() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.latency
+}
+
+// Stop stops the health checks.
+func (c *WorkerHealthChecker) Stop() {
+	c.logger.Debug("stop()")
+
+	c.cancel()
+}
+
+func (c *WorkerHealthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(c.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.check(ctx)
+	}
+}
+
+func (c *WorkerHealthChecker) check(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	latency, err := c.worker.Ping(pingCtx)
+
+	// Stopped or the worker closed meanwhile.
+	if ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+
+	if err == nil {
+		c.failures = 0
+		c.latency = latency
+
+		recovered := c.unhealthy
+		c.unhealthy = false
+		c.mu.Unlock()
+
+		if recovered {
+			c.logger.Infof("worker healthy again [pid:%d]", c.worker.Pid())
+
+			c.SafeEmit("healthy")
+		}
+		return
+	}
+
+	c.failures++
+	failures := c.failures
+
+	becameUnhealthy := !c.unhealthy && failures >= c.options.FailureThreshold
+	if becameUnhealthy {
+		c.unhealthy = true
+	}
+	c.mu.Unlock()
+
+	c.logger.Warnf("ping failed [pid:%d, failures:%d]: %s", c.worker.Pid(), failures, err)
+
+	if becameUnhealthy {
+		c.SafeEmit("unhealthy", failures, err)
+	}
+}
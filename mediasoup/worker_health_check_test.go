@@ -0,0 +1,101 @@
+package mediasoup
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHealthCheckTestWorker returns a Worker whose channel answers the requests
+// while responding is set.
+func newHealthCheckTestWorker(t *testing.T, responding *int32) *Worker {
+	fake := newFakeWorker(t)
+	fake.reply("*", func(request fakeWorkerRequest) (interface{}, error) {
+		if atomic.LoadInt32(responding) == 1 {
+			return H{}, nil
+		}
+		return nil, errFakeWorkerNoResponse
+	})
+
+	closed, ctx := newCloseFlag()
+
+	return &Worker{
+		EventEmitter: NewEventEmitter(AppLogger(), WithEmitterContext(ctx)),
+		closed:       closed,
+		pid:          1,
+		logger:       TypeLogger("Worker"),
+		channel:      fake.channel,
+		observer:     NewEventEmitter(AppLogger(), WithEmitterContext(ctx)),
+		routers:      make(map[string]*Router),
+	}
+}
+
+func TestWorkerPing(t *testing.T) {
+	responding := int32(1)
+	worker := newHealthCheckTestWorker(t, &responding)
+
+	latency, err := worker.Ping(context.Background())
+	require.NoError(t, err)
+	assert.True(t, latency > 0)
+
+	atomic.StoreInt32(&responding, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = worker.Ping(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	worker.Close()
+
+	_, err = worker.Ping(context.Background())
+	assert.IsType(t, NewInvalidStateError(""), err)
+}
+
+func TestWorkerHealthChecker(t *testing.T) {
+	responding := int32(1)
+	worker := newHealthCheckTestWorker(t, &responding)
+
+	unhealthyCh := make(chan int, 1)
+	healthyCh := make(chan struct{}, 1)
+
+	checker := NewWorkerHealthChecker(worker,
+		WithWorkerHealthCheckInterval(10*time.Millisecond, 10*time.Millisecond),
+		WithWorkerHealthCheckFailureThreshold(2),
+	)
+	defer checker.Stop()
+
+	checker.On("unhealthy", func(failures int, err error) {
+		unhealthyCh <- failures
+	})
+	checker.On("healthy", func() {
+		healthyCh <- struct{}{}
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, checker.Healthy())
+	assert.True(t, checker.Latency() > 0)
+
+	atomic.StoreInt32(&responding, 0)
+
+	select {
+	case failures := <-unhealthyCh:
+		assert.Equal(t, 2, failures)
+	case <-time.After(time.Second):
+		assert.FailNow(t, "timeout")
+	}
+	assert.False(t, checker.Healthy())
+
+	atomic.StoreInt32(&responding, 1)
+
+	select {
+	case <-healthyCh:
+	case <-time.After(time.Second):
+		assert.FailNow(t, "timeout")
+	}
+	assert.True(t, checker.Healthy())
+}
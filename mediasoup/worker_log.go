@@ -0,0 +1,169 @@
+package mediasoup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Streams of the worker logs.
+const (
+	WorkerLogStreamChannel = "channel"
+	WorkerLogStreamStdout  = "stdout"
+	WorkerLogStreamStderr  = "stderr"
+)
+
+// WorkerLog is a log line of the worker process, e.g. for
+// "D(ice) RTC::IceServer::ProcessStunPacket() | processing STUN packet":
+//
+//	WorkerLog{
+//		Level:   "debug",
+//		Tag:     "ice",
+//		Source:  "RTC::IceServer::ProcessStunPacket()",
+//		Message: "processing STUN packet",
+//	}
+type WorkerLog struct {
+	Pid int
+	// WorkerLogStream* the line was read from.
+	Stream string
+	// "debug", "warn" or "error".
+	Level string
+	// WorkerLogTag* of the line, empty if not tagged.
+	Tag string
+	// Function of the worker logging the line, empty if unknown.
+	Source  string
+	Message string
+}
+
+// WorkerLogger handles the logs of the workers.
+type WorkerLogger interface {
+	Log(log WorkerLog)
+}
+
+// WorkerLoggerFunc is a function handling the logs of the workers.
+type WorkerLoggerFunc func(log WorkerLog)
+
+func (fn WorkerLoggerFunc) Log(log WorkerLog) {
+	fn(log)
+}
+
+// logrusWorkerLogger logs the worker logs through logrus, the default.
+type logrusWorkerLogger struct {
+	logger logrus.FieldLogger
+}
+
+func (l logrusWorkerLogger) Log(log WorkerLog) {
+	logger := l.logger
+
+	if len(log.Tag) > 0 {
+		logger = logger.WithField("tag", log.Tag)
+	}
+
+	message := log.Message
+
+	if len(log.Source) > 0 {
+		message = log.Source + " | " + message
+	}
+	if log.Stream != WorkerLogStreamChannel {
+		message = fmt.Sprintf("(%s) %s", log.Stream, message)
+	}
+
+	switch log.Level {
+	case "debug":
+		logger.Debug(message)
+	case "warn":
+		logger.Warn(message)
+	default:
+		logger.Error(message)
+	}
+}
+
+// workerLogForwarder parses the log lines of a worker and forwards them to
+// its WorkerLogger.
+type workerLogForwarder struct {
+	pid    int
+	logger WorkerLogger
+	// Tags of the forwarded debug logs, all if nil.
+	tags map[string]bool
+}
+
+func newWorkerLogForwarder(pid int, logger WorkerLogger, tags []string) *workerLogForwarder {
+	if logger == nil {
+		logger = logrusWorkerLogger{logger: TypeLogger(fmt.Sprintf("worker[pid:%d]", pid))}
+	}
+
+	forwarder := &workerLogForwarder{
+		pid:    pid,
+		logger: logger,
+	}
+
+	if len(tags) > 0 {
+		forwarder.tags = make(map[string]bool)
+
+		for _, tag := range expandLogTags(tags) {
+			forwarder.tags[tag] = true
+		}
+	}
+
+	return forwarder
+}
+
+// forward forwards the given line, of the given level if it doesn't start with
+// one (the lines of stdout and stderr).
+func (f *workerLogForwarder) forward(stream, level, line string) {
+	log := parseWorkerLog(level, line)
+	log.Pid = f.pid
+	log.Stream = stream
+
+	// Warnings and errors are never filtered.
+	if f.tags != nil && log.Level == "debug" && len(log.Tag) > 0 && !f.tags[log.Tag] {
+		return
+	}
+
+	f.logger.Log(log)
+}
+
+// forwardPayload forwards a log received through the channel, returning false
+// if it isn't one.
+func (f *workerLogForwarder) forwardPayload(payload []byte) bool {
+	var level string
+
+	switch payload[0] {
+	case 'D':
+		level = "debug"
+	case 'W':
+		level = "warn"
+	case 'E':
+		level = "error"
+	default:
+		return false
+	}
+
+	f.forward(WorkerLogStreamChannel, level, string(payload[1:]))
+
+	return true
+}
+
+// parseWorkerLog parses a log line formatted as "(tag) source() | message",
+// the tag and the source being optional.
+func parseWorkerLog(level, line string) (log WorkerLog) {
+	log.Level = level
+	line = strings.TrimLeft(line, " ")
+
+	if strings.HasPrefix(line, "(") {
+		if end := strings.IndexByte(line, ')'); end > 0 && isWorkerLogTag(line[1:end]) {
+			log.Tag = line[1:end]
+			line = strings.TrimLeft(line[end+1:], " ")
+		}
+	}
+
+	if idx := strings.Index(line, "() | "); idx > 0 && !strings.Contains(line[:idx], " ") {
+		log.Source = line[:idx+2]
+		line = line[idx+5:]
+	}
+
+	log.Message = line
+
+	return
+}
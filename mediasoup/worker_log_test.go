@@ -0,0 +1,91 @@
+package mediasoup
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkerLog(t *testing.T) {
+	assert.Equal(t, WorkerLog{
+		Level:   "debug",
+		Tag:     "ice",
+		Source:  "RTC::IceServer::ProcessStunPacket()",
+		Message: "processing STUN packet",
+	}, parseWorkerLog("debug", "(ice) RTC::IceServer::ProcessStunPacket() | processing STUN packet"))
+
+	assert.Equal(t, WorkerLog{
+		Level:   "warn",
+		Source:  "RTC::Router::HandleRequest()",
+		Message: "unknown method",
+	}, parseWorkerLog("warn", "RTC::Router::HandleRequest() | unknown method"))
+
+	// Not a worker log tag.
+	assert.Equal(t, WorkerLog{
+		Level:   "error",
+		Message: "(ABORT) failure",
+	}, parseWorkerLog("error", "(ABORT) failure"))
+
+	assert.Equal(t, WorkerLog{
+		Level:   "debug",
+		Message: "see Foo() | bar",
+	}, parseWorkerLog("debug", "see Foo() | bar"))
+}
+
+func TestWorkerLogForwarder_Filter(t *testing.T) {
+	var logs []WorkerLog
+
+	forwarder := newWorkerLogForwarder(10, WorkerLoggerFunc(func(log WorkerLog) {
+		logs = append(logs, log)
+	}), []string{WorkerLogTagDtls, "media"})
+
+	assert.True(t, forwarder.forwardPayload([]byte("D(ice) ice")))
+	assert.True(t, forwarder.forwardPayload([]byte("D(rtp) rtp")))
+	assert.True(t, forwarder.forwardPayload([]byte("D(dtls) dtls")))
+	assert.True(t, forwarder.forwardPayload([]byte("W(ice) warning")))
+	assert.True(t, forwarder.forwardPayload([]byte("D untagged")))
+	assert.False(t, forwarder.forwardPayload([]byte("X unexpected")))
+	forwarder.forward(WorkerLogStreamStdout, "debug", "(ice) stdout")
+
+	var messages []string
+	for _, log := range logs {
+		assert.Equal(t, 10, log.Pid)
+		messages = append(messages, log.Message)
+	}
+	assert.Equal(t, []string{"rtp", "dtls", "warning", "untagged"}, messages)
+	assert.Equal(t, WorkerLogStreamChannel, logs[0].Stream)
+}
+
+func TestChannel_ForwardsWorkerLogs(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		logs []WorkerLog
+	)
+
+	fake := newFakeWorker(t, withFakeWorkerChannel(func(conn net.Conn) *Channel {
+		return newChannel(conn, 1, newWorkerLogForwarder(1, WorkerLoggerFunc(func(log WorkerLog) {
+			mu.Lock()
+			defer mu.Unlock()
+			logs = append(logs, log)
+		}), nil))
+	}))
+
+	fake.send([]byte("E(dtls) RTC::DtlsTransport::Run() | failed"))
+
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []WorkerLog{{
+		Pid:     1,
+		Stream:  WorkerLogStreamChannel,
+		Level:   "error",
+		Tag:     "dtls",
+		Source:  "RTC::DtlsTransport::Run()",
+		Message: "failed",
+	}}, logs)
+}
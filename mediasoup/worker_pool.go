@@ -0,0 +1,350 @@
+package mediasoup
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkerPoolOptions to spawn and balance the Workers.
+type WorkerPoolOptions struct {
+	// Number of Workers, runtime.NumCPU() by default.
+	Size int
+	// Options of the spawned Workers.
+	WorkerOptions []Option
+	// Load of a Router and of the entities of the Routers of a Worker.
+	RouterLoad   float64
+	ProducerLoad float64
+	ConsumerLoad float64
+	// Load of each CPU percent of the worker process (100 for a whole core),
+	// CPU usage being ignored if not positive.
+	CpuLoad float64
+	// Interval of the CPU usage samples.
+	CpuInterval time.Duration
+	// Backoff respawning the died Workers, not respawned if nil.
+	Respawn *Backoff
+	// Selects the Worker of the created Routers, LeastLoadedPlacement if nil.
+	Placement PlacementStrategy
+}
+
+type WorkerPoolOption func(o *WorkerPoolOptions)
+
+func WithWorkerPoolSize(size int) WorkerPoolOption {
+	return func(o *WorkerPoolOptions) {
+		o.Size = size
+	}
+}
+
+func WithWorkerPoolWorkerOptions(options ...Option) WorkerPoolOption {
+	return func(o *WorkerPoolOptions) {
+		o.WorkerOptions = options
+	}
+}
+
+func WithWorkerPoolEntityLoads(routerLoad, producerLoad, consumerLoad float64) WorkerPoolOption {
+	return func(o *WorkerPoolOptions) {
+		o.RouterLoad = routerLoad
+		o.ProducerLoad = producerLoad
+		o.ConsumerLoad = consumerLoad
+	}
+}
+
+func WithWorkerPoolCpuLoad(cpuLoad float64, interval time.Duration) WorkerPoolOption {
+	return func(o *WorkerPoolOptions) {
+		o.CpuLoad = cpuLoad
+		o.CpuInterval = interval
+	}
+}
+
+func WithWorkerPoolRespawn(backoff Backoff) WorkerPoolOption {
+	return func(o *WorkerPoolOptions) {
+		o.Respawn = &backoff
+	}
+}
+
+func WithWorkerPoolPlacement(placement PlacementStrategy) WorkerPoolOption {
+	return func(o *WorkerPoolOptions) {
+		o.Placement = placement
+	}
+}
+
+// WorkerPool spawns Workers and creates the Routers on the least loaded one,
+// the load of a Worker being the weighted sum of its Routers, Producers,
+// Consumers and CPU usage.
+type WorkerPool struct {
+	EventEmitter
+	logger    logrus.FieldLogger
+	options   WorkerPoolOptions
+	workerBin string
+	mu        sync.Mutex
+	workers   []*Worker
+	// Last CPU usage of the workers, sampled every CpuInterval.
+	cpu         *cpuSampler
+	cpuPercents map[*Worker]float64
+	// Serializes the Router creations, so that each one sees the previous.
+	createLocker sync.Mutex
+	ctx          context.Context
+	cancel       context.CancelFunc
+	closed       closeFlag
+}
+
+/**
+ * NewWorkerPool spawns the Workers of the pool, closing them if one fails.
+ *
+ * @emits {worker: *Worker} newworker
+ * @emits {worker: *Worker, error: error} workerdied
+ * @emits {error: error} respawnerror
+ */
+func NewWorkerPool(workerBin string, options ...WorkerPoolOption) (pool *WorkerPool, err error) {
+	pool = newWorkerPool(workerBin, options...)
+
+	if pool.options.Size <= 0 {
+		pool.Close()
+		return nil, NewValidationError("WorkerPoolOptions.Size", "must be positive")
+	}
+	if pool.options.CpuLoad > 0 && pool.options.CpuInterval <= 0 {
+		pool.Close()
+		return nil, NewValidationError("WorkerPoolOptions.CpuInterval", "must be positive")
+	}
+
+	for i := 0; i < pool.options.Size; i++ {
+		var worker *Worker
+
+		if worker, err = CreateWorker(workerBin, pool.options.WorkerOptions...); err != nil {
+			pool.Close()
+			return nil, err
+		}
+
+		pool.addWorker(worker)
+	}
+
+	if pool.options.CpuLoad > 0 {
+		spawn("workerpool.cpu", pool.sampleCpu)
+	}
+
+	return pool, nil
+}
+
+func newWorkerPool(workerBin string, options ...WorkerPoolOption) *WorkerPool {
+	logger := TypeLogger("WorkerPool")
+
+	logger.Debug("constructor()")
+
+	opts := WorkerPoolOptions{
+		Size:         runtime.NumCPU(),
+		RouterLoad:   5,
+		ProducerLoad: 2,
+		ConsumerLoad: 1,
+		CpuLoad:      1,
+		CpuInterval:  2 * time.Second,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	pool := &WorkerPool{
+		EventEmitter: NewEventEmitter(logger),
+		logger:       logger,
+		options:      opts,
+		workerBin:    workerBin,
+		cpu:          newCpuSampler(),
+		cpuPercents:  make(map[*Worker]float64),
+	}
+	pool.ctx, pool.cancel = context.WithCancel(context.Background())
+
+	return pool
+}
+
+// Workers returns the alive Workers of the pool.
+func (pool *WorkerPool) Workers() []*Worker {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	workers := make([]*Worker, len(pool.workers))
+	copy(workers, pool.workers)
+
+	return workers
+}
+
+// Loads returns the load of the Workers, CpuPercent being the last sample.
+func (pool *WorkerPool) Loads() []WorkerLoad {
+	workers := pool.Workers()
+	loads := make([]WorkerLoad, 0, len(workers))
+
+	for _, worker := range workers {
+		pool.mu.Lock()
+		cpuPercent, ok := pool.cpuPercents[worker]
+		pool.mu.Unlock()
+
+		if !ok {
+			cpuPercent = -1
+		}
+
+		load := sampleWorkerLoad(worker, cpuPercent, pool.options.ProducerLoad, pool.options.ConsumerLoad)
+		load.Load += float64(len(load.Routers)) * pool.options.RouterLoad
+
+		if pool.options.CpuLoad > 0 && cpuPercent > 0 {
+			load.Load += cpuPercent * pool.options.CpuLoad
+		}
+
+		loads = append(loads, load)
+	}
+
+	return loads
+}
+
+// GetLeastLoadedWorker returns the least loaded Worker not draining, the
+// first one of the pool on equal loads.
+func (pool *WorkerPool) GetLeastLoadedWorker() (*Worker, error) {
+	return pool.selectWorker(LeastLoadedPlacement())
+}
+
+// SelectWorker returns the Worker not draining selected by the placement
+// strategy of the pool, the one CreateRouter creates the Router on.
+func (pool *WorkerPool) SelectWorker() (*Worker, error) {
+	placement := pool.options.Placement
+
+	if placement == nil {
+		placement = LeastLoadedPlacement()
+	}
+
+	return pool.selectWorker(placement)
+}
+
+func (pool *WorkerPool) selectWorker(placement PlacementStrategy) (*Worker, error) {
+	loads := []WorkerLoad{}
+
+	for _, load := range pool.Loads() {
+		if !load.Worker.Draining() {
+			loads = append(loads, load)
+		}
+	}
+
+	if len(loads) == 0 {
+		return nil, NewInvalidStateError("no Worker in the pool")
+	}
+
+	worker := placement.SelectWorker(loads)
+	if worker == nil {
+		return nil, NewInvalidStateError("no Worker selected")
+	}
+
+	return worker, nil
+}
+
+// CreateRouter creates a Router on the Worker selected by the placement
+// strategy, the least loaded one by default.
+func (pool *WorkerPool) CreateRouter(mediaCodecs []RtpCodecCapability, options ...RouterOption) (*Router, error) {
+	pool.logger.Debug("createRouter()")
+
+	pool.createLocker.Lock()
+	defer pool.createLocker.Unlock()
+
+	worker, err := pool.SelectWorker()
+	if err != nil {
+		return nil, err
+	}
+
+	return worker.CreateRouter(mediaCodecs, options...)
+}
+
+// Close the pool and its Workers.
+func (pool *WorkerPool) Close() {
+	if !pool.closed.set() {
+		return
+	}
+
+	pool.logger.Debug("close()")
+
+	pool.cancel()
+
+	// Workers() takes the lock, so a concurrent respawn either lands before
+	// this snapshot or sees the pool closed.
+	for _, worker := range pool.Workers() {
+		worker.Close()
+	}
+}
+
+// addWorker adds the worker to the pool, unless the pool is closed, in which
+// case it returns false.
+func (pool *WorkerPool) addWorker(worker *Worker) bool {
+	pool.mu.Lock()
+	if pool.closed.isSet() {
+		pool.mu.Unlock()
+		return false
+	}
+	pool.workers = append(pool.workers, worker)
+	pool.mu.Unlock()
+
+	worker.Observer().On("close", func() {
+		pool.mu.Lock()
+		for i, w := range pool.workers {
+			if w == worker {
+				pool.workers = append(pool.workers[:i], pool.workers[i+1:]...)
+				break
+			}
+		}
+		delete(pool.cpuPercents, worker)
+		pool.mu.Unlock()
+
+		pool.cpu.forget(worker)
+	})
+
+	worker.On("died", func(err error) {
+		pool.logger.Errorf("worker %d died: %s", worker.Pid(), err)
+
+		pool.SafeEmit("workerdied", worker, err)
+
+		if pool.options.Respawn != nil && !pool.closed.isSet() {
+			spawn("workerpool.respawn", pool.respawn)
+		}
+	})
+
+	pool.SafeEmit("newworker", worker)
+
+	return true
+}
+
+func (pool *WorkerPool) respawn() {
+	worker, err := CreateWorkerWithRetry(pool.ctx, *pool.options.Respawn, pool.workerBin, pool.options.WorkerOptions...)
+	if err != nil {
+		if pool.ctx.Err() == nil {
+			pool.logger.Errorf("respawn failed: %s", err)
+
+			pool.SafeEmit("respawnerror", err)
+		}
+		return
+	}
+
+	// The pool may have been closed meanwhile.
+	if !pool.addWorker(worker) {
+		worker.Close()
+	}
+}
+
+func (pool *WorkerPool) sampleCpu() {
+	ticker := time.NewTicker(pool.options.CpuInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pool.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, worker := range pool.Workers() {
+			cpuPercent := pool.cpu.cpuPercent(worker)
+
+			pool.mu.Lock()
+			if !worker.Closed() {
+				pool.cpuPercents[worker] = cpuPercent
+			}
+			pool.mu.Unlock()
+		}
+	}
+}
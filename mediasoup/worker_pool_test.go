@@ -0,0 +1,97 @@
+package mediasoup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_CreateRouter(t *testing.T) {
+	pool := newWorkerPool("", WithWorkerPoolCpuLoad(0, 0))
+
+	worker1, worker2 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2)
+	pool.addWorker(worker1)
+	pool.addWorker(worker2)
+
+	var pids []int
+
+	for i := 0; i < 4; i++ {
+		router, err := pool.CreateRouter([]RtpCodecCapability{
+			{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+		})
+		require.NoError(t, err)
+
+		for _, worker := range pool.Workers() {
+			if _, ok := worker.routers[router.Id()]; ok {
+				pids = append(pids, worker.Pid())
+			}
+		}
+	}
+	assert.Equal(t, []int{1, 2, 1, 2}, pids)
+
+	loads := pool.Loads()
+	require.Len(t, loads, 2)
+	assert.EqualValues(t, 10, loads[0].Load)
+	assert.Len(t, loads[0].Routers, 2)
+}
+
+func TestWorkerPool_GetLeastLoadedWorker(t *testing.T) {
+	pool := newWorkerPool("")
+
+	_, err := pool.GetLeastLoadedWorker()
+	assert.IsType(t, NewInvalidStateError(""), err)
+
+	worker1, worker2 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2)
+	pool.addWorker(worker1)
+	pool.addWorker(worker2)
+
+	pool.cpuPercents[worker1] = 50
+	pool.cpuPercents[worker2] = 20
+
+	worker, err := pool.GetLeastLoadedWorker()
+	require.NoError(t, err)
+	assert.Equal(t, worker2, worker)
+
+	worker2.Close()
+	assert.Equal(t, []*Worker{worker1}, pool.Workers())
+
+	worker, err = pool.GetLeastLoadedWorker()
+	require.NoError(t, err)
+	assert.Equal(t, worker1, worker)
+}
+
+func TestWorkerPool_WorkerDied(t *testing.T) {
+	pool := newWorkerPool("")
+
+	worker := newPoolTestWorker(t, 1)
+	pool.addWorker(worker)
+
+	var died []*Worker
+	pool.On("workerdied", func(worker *Worker, err error) {
+		died = append(died, worker)
+	})
+
+	worker.Close()
+	worker.SafeEmit("died", fmt.Errorf("killed"))
+
+	assert.Equal(t, []*Worker{worker}, died)
+	assert.Empty(t, pool.Workers())
+}
+
+func TestWorkerPool_Validation(t *testing.T) {
+	_, err := NewWorkerPool("", WithWorkerPoolSize(0))
+	assert.IsType(t, ValidationError{}, err)
+
+	_, err = NewWorkerPool("", WithWorkerPoolSize(1), WithWorkerPoolCpuLoad(1, 0))
+	assert.IsType(t, ValidationError{}, err)
+}
+
+func TestWorkerPool_AddWorkerAfterClose(t *testing.T) {
+	pool := newWorkerPool("")
+	pool.Close()
+
+	assert.False(t, pool.addWorker(newPoolTestWorker(t, 1)))
+	assert.Empty(t, pool.Workers())
+}
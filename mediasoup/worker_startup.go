@@ -0,0 +1,58 @@
+package mediasoup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWorkerStartupTimeout is returned by CreateWorker when the worker process
+// did not notify it is running within Options.StartupTimeout.
+var ErrWorkerStartupTimeout = errors.New("worker startup timeout")
+
+// startWorker spawns a worker process and waits for it to be running, killing
+// it if not running within timeout (if positive).
+func startWorker(workerBin string, timeout time.Duration, options ...Option) (*Worker, error) {
+	worker, err := newWorker(workerBin, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(chan error, 1)
+
+	worker.Once("@failure", func(err error) {
+		result <- err
+	})
+	worker.Once("@success", func() {
+		result <- nil
+	})
+
+	var timeoutCh <-chan time.Time
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timeoutCh = timer.C
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return nil, err
+		}
+		return worker, nil
+
+	case <-timeoutCh:
+		worker.logger.Errorf("worker process not running after %s, killing it [pid:%d]", timeout, worker.Pid())
+
+		// The Worker is closed once the process exits, resetting its child.
+		child := worker.child
+
+		child.Process.Kill()
+		worker.Close()
+
+		return nil, fmt.Errorf("%w: not running after %s [pid:%d, workerBin:%s]",
+			ErrWorkerStartupTimeout, timeout, worker.Pid(), child.Path)
+	}
+}
@@ -0,0 +1,55 @@
+package mediasoup
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStartupTestWorkerBin returns a fake worker binary running the given shell
+// command, and the function returning the number of times it was spawned.
+func newStartupTestWorkerBin(t *testing.T, command string) (string, func() int) {
+	dir, err := ioutil.TempDir("", "worker")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	bin, spawns := filepath.Join(dir, "mediasoup-worker"), filepath.Join(dir, "spawns")
+
+	script := "#!/bin/sh\necho >> " + spawns + "\n" + command + "\n"
+	require.NoError(t, ioutil.WriteFile(bin, []byte(script), 0755))
+
+	return bin, func() int {
+		data, _ := ioutil.ReadFile(spawns)
+		return strings.Count(string(data), "\n")
+	}
+}
+
+func TestCreateWorker_StartupTimeout(t *testing.T) {
+	bin, spawns := newStartupTestWorkerBin(t, "exec sleep 10")
+
+	start := time.Now()
+
+	worker, err := CreateWorker(bin, WithStartupTimeout(100*time.Millisecond, 2))
+	assert.Nil(t, worker)
+	assert.True(t, errors.Is(err, ErrWorkerStartupTimeout), err)
+	assert.Contains(t, err.Error(), bin)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, 3, spawns())
+}
+
+func TestCreateWorker_StartupFailureNotRetried(t *testing.T) {
+	bin, spawns := newStartupTestWorkerBin(t, "exit 1")
+
+	worker, err := CreateWorker(bin, WithStartupTimeout(time.Second, 2))
+	assert.Nil(t, worker)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrWorkerStartupTimeout))
+	assert.Equal(t, 1, spawns())
+}
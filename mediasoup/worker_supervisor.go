@@ -0,0 +1,253 @@
+package mediasoup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkerRespawn describes a Worker respawned by a WorkerSupervisor.
+type WorkerRespawn struct {
+	// The new Worker.
+	Worker *Worker
+	// The died Worker, and why it died.
+	Previous *Worker
+	Err      error
+	// Snapshots of the Routers of the died Worker, taken when it died.
+	Snapshots []RouterSnapshot
+	// Routers restored in the new Worker (with WithSupervisorRestoreRouters),
+	// by id of the Router of the died Worker.
+	Routers map[string]*Router
+}
+
+// WorkerSupervisorOptions to respawn the Worker.
+type WorkerSupervisorOptions struct {
+	// Options of the spawned Workers.
+	WorkerOptions []Option
+	// Backoff of the spawns of a new Worker.
+	Backoff Backoff
+	// Whether to restore the Routers of the died Worker from their snapshots
+	// (see LoadRouterSnapshot), before calling the Rebuild callbacks.
+	RestoreRouters bool
+	// Called in order once the Worker is respawned, to recreate what the
+	// application needs in it.
+	Rebuild []func(respawn WorkerRespawn) error
+}
+
+type WorkerSupervisorOption func(o *WorkerSupervisorOptions)
+
+func WithSupervisorWorkerOptions(options ...Option) WorkerSupervisorOption {
+	return func(o *WorkerSupervisorOptions) {
+		o.WorkerOptions = options
+	}
+}
+
+func WithSupervisorBackoff(backoff Backoff) WorkerSupervisorOption {
+	return func(o *WorkerSupervisorOptions) {
+		o.Backoff = backoff
+	}
+}
+
+func WithSupervisorRestoreRouters() WorkerSupervisorOption {
+	return func(o *WorkerSupervisorOptions) {
+		o.RestoreRouters = true
+	}
+}
+
+func WithSupervisorRebuild(rebuild func(respawn WorkerRespawn) error) WorkerSupervisorOption {
+	return func(o *WorkerSupervisorOptions) {
+		o.Rebuild = append(o.Rebuild, rebuild)
+	}
+}
+
+// WorkerSupervisor respawns its Worker when the worker process dies, then
+// restores its Routers and calls the rebuild callbacks, so that the
+// applications don't need to handle the "died" event themselves.
+type WorkerSupervisor struct {
+	EventEmitter
+	logger  logrus.FieldLogger
+	options WorkerSupervisorOptions
+	mu      sync.Mutex
+	worker  *Worker
+	// Spawns a Worker, retrying with the backoff.
+	createWorker func(ctx context.Context) (*Worker, error)
+	ctx          context.Context
+	cancel       context.CancelFunc
+	closed       closeFlag
+}
+
+/**
+ * NewWorkerSupervisor spawns the supervised Worker.
+ *
+ * @emits {worker: *Worker, error: error} died
+ * @emits {respawn: WorkerRespawn} respawn
+ * @emits {snapshot: RouterSnapshot, error: error} restoreerror
+ * @emits {respawn: WorkerRespawn, error: error} rebuilderror
+ * @emits {error: error} respawnerror
+ */
+func NewWorkerSupervisor(workerBin string, options ...WorkerSupervisorOption) (s *WorkerSupervisor, err error) {
+	s = newWorkerSupervisor(options...)
+	s.createWorker = func(ctx context.Context) (*Worker, error) {
+		return CreateWorkerWithRetry(ctx, s.options.Backoff, workerBin, s.options.WorkerOptions...)
+	}
+
+	worker, err := CreateWorker(workerBin, s.options.WorkerOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.supervise(worker)
+
+	return s, nil
+}
+
+func newWorkerSupervisor(options ...WorkerSupervisorOption) *WorkerSupervisor {
+	logger := TypeLogger("WorkerSupervisor")
+
+	logger.Debug("constructor()")
+
+	opts := WorkerSupervisorOptions{
+		Backoff: Backoff{
+			Initial:    time.Second,
+			Max:        30 * time.Second,
+			Multiplier: 2,
+			Jitter:     0.2,
+			MaxRetries: -1,
+		},
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	s := &WorkerSupervisor{
+		EventEmitter: NewEventEmitter(logger),
+		logger:       logger,
+		options:      opts,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	return s
+}
+
+// Worker returns the current Worker, the died one while respawning.
+func (s *WorkerSupervisor) Worker() *Worker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.worker
+}
+
+// Close stops supervising and closes the Worker.
+func (s *WorkerSupervisor) Close() {
+	if !s.closed.set() {
+		return
+	}
+
+	s.logger.Debug("close()")
+
+	s.cancel()
+
+	if worker := s.Worker(); worker != nil {
+		worker.Close()
+	}
+}
+
+// supervise makes the worker the supervised one, unless the supervisor is
+// closed, in which case it returns false.
+func (s *WorkerSupervisor) supervise(worker *Worker) bool {
+	s.mu.Lock()
+	if s.closed.isSet() {
+		s.mu.Unlock()
+		return false
+	}
+	s.worker = worker
+	s.mu.Unlock()
+
+	var snapshots []RouterSnapshot
+
+	worker.On("@exit", func() {
+		worker.routersLocker.Lock()
+		routers := make([]*Router, 0, len(worker.routers))
+		for _, router := range worker.routers {
+			routers = append(routers, router)
+		}
+		worker.routersLocker.Unlock()
+
+		for _, router := range routers {
+			snapshot, err := router.Snapshot()
+			if err != nil {
+				s.logger.Errorf("router %s not respawned: %s", router.Id(), err)
+				continue
+			}
+
+			snapshots = append(snapshots, snapshot)
+		}
+	})
+
+	worker.On("died", func(err error) {
+		s.logger.Errorf("worker %d died: %s", worker.Pid(), err)
+
+		s.SafeEmit("died", worker, err)
+
+		if s.closed.isSet() {
+			return
+		}
+
+		spawn("supervisor.respawn", func() {
+			s.respawn(WorkerRespawn{Previous: worker, Err: err, Snapshots: snapshots})
+		})
+	})
+
+	return true
+}
+
+func (s *WorkerSupervisor) respawn(respawn WorkerRespawn) {
+	worker, err := s.createWorker(s.ctx)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.logger.Errorf("respawn failed: %s", err)
+
+			s.SafeEmit("respawnerror", err)
+		}
+		return
+	}
+
+	// The supervisor may have been closed meanwhile.
+	if !s.supervise(worker) {
+		worker.Close()
+		return
+	}
+
+	s.logger.Infof("worker %d respawned as worker %d", respawn.Previous.Pid(), worker.Pid())
+
+	respawn.Worker = worker
+
+	if s.options.RestoreRouters {
+		respawn.Routers = make(map[string]*Router)
+
+		for _, snapshot := range respawn.Snapshots {
+			router, err := LoadRouterSnapshot(worker, snapshot)
+			if err != nil {
+				s.logger.Errorf(`cannot restore router "%s": %s`, snapshot.Id, err)
+
+				s.SafeEmit("restoreerror", snapshot, err)
+				continue
+			}
+
+			respawn.Routers[snapshot.Id] = router
+		}
+	}
+
+	for _, rebuild := range s.options.Rebuild {
+		if err := rebuild(respawn); err != nil {
+			s.logger.Errorf("rebuild failed: %s", err)
+
+			s.SafeEmit("rebuilderror", respawn, err)
+		}
+	}
+
+	s.SafeEmit("respawn", respawn)
+}
@@ -0,0 +1,121 @@
+package mediasoup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerSupervisor(t *testing.T) {
+	var rebuilt []WorkerRespawn
+
+	supervisor := newWorkerSupervisor(
+		WithSupervisorRestoreRouters(),
+		WithSupervisorRebuild(func(respawn WorkerRespawn) error {
+			rebuilt = append(rebuilt, respawn)
+			return nil
+		}),
+	)
+	defer supervisor.Close()
+
+	worker1, worker2 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2)
+
+	supervisor.createWorker = func(ctx context.Context) (*Worker, error) {
+		return worker2, nil
+	}
+	supervisor.supervise(worker1)
+
+	router, err := worker1.CreateRouter([]RtpCodecCapability{
+		{Kind: "audio", MimeType: "audio/opus", ClockRate: 48000, Channels: 2},
+	})
+	require.NoError(t, err)
+
+	_, err = router.CreateWebRtcTransport(CreateWebRtcTransportParams{
+		ListenIps: []ListenIp{{Ip: "127.0.0.1"}},
+		EnableUdp: true,
+	})
+	require.NoError(t, err)
+
+	respawnCh := make(chan WorkerRespawn, 1)
+	supervisor.On("respawn", func(respawn WorkerRespawn) {
+		respawnCh <- respawn
+	})
+
+	died := errors.New("killed")
+
+	// What Worker.wait does when the worker process dies.
+	worker1.Emit("@exit")
+	worker1.Close()
+	worker1.SafeEmit("died", died)
+
+	var respawn WorkerRespawn
+
+	select {
+	case respawn = <-respawnCh:
+	case <-time.After(time.Second):
+		t.Fatal("worker not respawned")
+	}
+
+	assert.Equal(t, worker2, respawn.Worker)
+	assert.Equal(t, worker1, respawn.Previous)
+	assert.Equal(t, died, respawn.Err)
+	assert.Equal(t, worker2, supervisor.Worker())
+
+	require.Len(t, respawn.Snapshots, 1)
+	assert.Equal(t, router.Id(), respawn.Snapshots[0].Id)
+	assert.Len(t, respawn.Snapshots[0].Transports, 1)
+
+	restored := respawn.Routers[router.Id()]
+	require.NotNil(t, restored)
+	assert.Contains(t, worker2.routers, restored.Id())
+	assert.Len(t, restored.getTransports(), 1)
+
+	require.Len(t, rebuilt, 1)
+	assert.Equal(t, worker2, rebuilt[0].Worker)
+}
+
+func TestWorkerSupervisor_Closed(t *testing.T) {
+	supervisor := newWorkerSupervisor()
+
+	worker := newPoolTestWorker(t, 1)
+
+	supervisor.createWorker = func(ctx context.Context) (*Worker, error) {
+		t.Error("worker respawned after close")
+		return nil, ctx.Err()
+	}
+	supervisor.supervise(worker)
+
+	diedCh := make(chan error, 1)
+	supervisor.On("died", func(worker *Worker, err error) {
+		diedCh <- err
+	})
+
+	supervisor.Close()
+	assert.True(t, worker.Closed())
+
+	worker.SafeEmit("died", errors.New("killed"))
+	assert.EqualError(t, <-diedCh, "killed")
+
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestWorkerSupervisor_RespawnAfterClose(t *testing.T) {
+	supervisor := newWorkerSupervisor()
+
+	worker1, worker2 := newPoolTestWorker(t, 1), newPoolTestWorker(t, 2)
+	supervisor.supervise(worker1)
+
+	supervisor.createWorker = func(ctx context.Context) (*Worker, error) {
+		// Close the supervisor while the worker is being created.
+		supervisor.Close()
+		return worker2, nil
+	}
+	supervisor.respawn(WorkerRespawn{Previous: worker1})
+
+	assert.True(t, worker2.Closed())
+	assert.Equal(t, worker1, supervisor.Worker())
+}
@@ -1,13 +1,13 @@
 package mediasoup
 
 import (
-	"fmt"
 	"os"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var worker *Worker
@@ -28,6 +28,14 @@ func TestMain(m *testing.M) {
 	worker.Close()
 }
 
+// skipUnlessWorkerSupports skips the test if the worker under test (see
+// MEDIASOUP_WORKER_VERSION) doesn't support the given feature.
+func skipUnlessWorkerSupports(t *testing.T, feature WorkerFeature) {
+	if version := NewOptions().Version; !workerSupports(version, feature) {
+		t.Skipf(`feature "%s" not supported by worker %s`, feature, version)
+	}
+}
+
 func CreateTestWorker(options ...Option) *Worker {
 	options = append([]Option{WithLogLevel("debug"), WithLogTags([]string{"info"})}, options...)
 
@@ -63,10 +71,10 @@ func TestCreateWorker_Succeeds(t *testing.T) {
 
 func TestCreateWorker_TypeError(t *testing.T) {
 	_, err := CreateWorker("", WithLogLevel("chicken"))
-	assert.IsType(t, err, NewTypeError(""))
+	assert.IsType(t, ValidationError{}, err)
 
 	_, err = CreateWorker("", WithRTCMinPort(1000), WithRTCMaxPort(999))
-	assert.IsType(t, err, NewTypeError(""))
+	assert.Equal(t, NewValidationError("Options.RTCMinPort", "must not exceed RTCMaxPort (999)"), err)
 
 	_, err = CreateWorker("", WithDTLSCert("notfuond/dtls-cert.pem", "testdata/dtls-key.pem"))
 	assert.IsType(t, err, NewTypeError(""))
@@ -75,6 +83,22 @@ func TestCreateWorker_TypeError(t *testing.T) {
 	assert.IsType(t, err, NewTypeError(""))
 }
 
+func TestCreateWorker_InvalidWorkerBin(t *testing.T) {
+	_, err := CreateWorker("notfound/mediasoup-worker")
+	assertValidationError(t, "Options.WorkerBin", err)
+
+	_, err = CreateWorker("", WithWorkerBin("notfound/mediasoup-worker"))
+	assertValidationError(t, "Options.WorkerBin", err)
+}
+
+func TestCreateWorker_CPUAffinity(t *testing.T) {
+	worker := CreateTestWorker(WithCPUAffinity(0))
+	defer worker.Close()
+
+	assert.Equal(t, []int{0}, worker.CPUAffinity())
+	assert.Greater(t, worker.Pid(), 0)
+}
+
 func TestWorkerUpdateSettings_Succeeds(t *testing.T) {
 	worker := CreateTestWorker()
 	resp := worker.UpdateSettings(Options{LogLevel: "debug", LogTags: []string{"ice"}})
@@ -88,7 +112,7 @@ func TestWorkerUpdateSettings_TypeError(t *testing.T) {
 	worker := CreateTestWorker()
 	resp := worker.UpdateSettings(Options{LogLevel: "chicken"})
 
-	assert.IsType(t, resp.Err(), NewTypeError(""))
+	assert.IsType(t, ValidationError{}, resp.Err())
 
 	worker.Close()
 }
@@ -107,13 +131,11 @@ func TestWorkerUpdateSettings_InvalidStateError(t *testing.T) {
 func TestWorkerDump(t *testing.T) {
 	worker := CreateTestWorker()
 
-	resp := worker.Dump()
+	dump, err := worker.Dump()
+	require.NoError(t, err)
 
-	assert.JSONEq(
-		t,
-		string(resp.Data()),
-		fmt.Sprintf(`{ "pid": %d, "routerIds": [] }`, worker.Pid()),
-	)
+	assert.Equal(t, worker.Pid(), dump.Pid)
+	assert.Empty(t, dump.RouterIds)
 
 	worker.Close()
 }
@@ -122,12 +144,32 @@ func TestWorkerDump_InvalidStateError(t *testing.T) {
 	worker := CreateTestWorker()
 	worker.Close()
 
-	resp := worker.Dump()
-	assert.IsType(t, resp.Err(), NewInvalidStateError(""))
+	_, err := worker.Dump()
+	assert.IsType(t, err, NewInvalidStateError(""))
 
 	worker.Close()
 }
 
+func TestWorkerGetResourceUsage(t *testing.T) {
+	skipUnlessWorkerSupports(t, WorkerFeatureResourceUsage)
+
+	worker := CreateTestWorker()
+	defer worker.Close()
+
+	usage, err := worker.GetResourceUsage()
+	require.NoError(t, err)
+	assert.True(t, usage.RuMaxrss > 0)
+	assert.True(t, usage.RuUtime >= 0)
+}
+
+func TestWorkerGetResourceUsage_Unsupported(t *testing.T) {
+	worker := newPoolTestWorker(t, 1)
+	worker.version = "3.5.0"
+
+	_, err := worker.GetResourceUsage()
+	assert.IsType(t, ErrUnsupportedByWorkerVersion{}, err)
+}
+
 func TestWorkerClose_Succeeds(t *testing.T) {
 	worker := CreateTestWorker(WithLogLevel("warn"))
 
@@ -0,0 +1,347 @@
+// Package workerbin acquires the prebuilt mediasoup-worker binaries of the
+// mediasoup releases, like the install step of the mediasoup Node.js package.
+package workerbin
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+)
+
+// ErrChecksum is returned by Acquire when the downloaded archive does not
+// match the expected checksum.
+var ErrChecksum = errors.New("worker binary checksum mismatch")
+
+// Versions of the mediasoup releases.
+var versionRegexp = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// Name of the worker binary in the release archives.
+const binaryName = "mediasoup-worker"
+
+// Options to acquire the prebuilt worker binary.
+type Options struct {
+	// Directory caching the binaries, "mediasoup-go" of the user cache
+	// directory if empty.
+	CacheDir string
+	// Url of the releases, the archives being downloaded from
+	// "<BaseUrl>/<version>/<archive>".
+	BaseUrl string
+	// SHA-256 (hex) of the archive, required: the cached archive is verified
+	// as well before using its binary.
+	Checksum string
+	// Platform ("linux", "darwin" or "win32") and architecture ("x64" or
+	// "arm64") of the binary, the current ones if empty.
+	Platform string
+	Arch     string
+	// Major version of the Linux kernel the binary is built for, the current
+	// one if zero.
+	KernelMajor int
+	HttpClient  *http.Client
+	// Backoff of the downloads, not retried by default. The missing
+	// archives and checksum mismatches are not retried.
+	Backoff mediasoup.Backoff
+}
+
+type Option func(o *Options)
+
+func WithCacheDir(cacheDir string) Option {
+	return func(o *Options) {
+		o.CacheDir = cacheDir
+	}
+}
+
+func WithBaseUrl(baseUrl string) Option {
+	return func(o *Options) {
+		o.BaseUrl = baseUrl
+	}
+}
+
+func WithChecksum(checksum string) Option {
+	return func(o *Options) {
+		o.Checksum = checksum
+	}
+}
+
+func WithPlatform(platform, arch string, kernelMajor int) Option {
+	return func(o *Options) {
+		o.Platform = platform
+		o.Arch = arch
+		o.KernelMajor = kernelMajor
+	}
+}
+
+func WithBackoff(backoff mediasoup.Backoff) Option {
+	return func(o *Options) {
+		o.Backoff = backoff
+	}
+}
+
+func WithHttpClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.HttpClient = client
+	}
+}
+
+// Acquire returns the path of the worker binary of the given mediasoup
+// version: MEDIASOUP_WORKER_BIN if set, else the binary of the prebuilt
+// archive of the mediasoup releases, downloaded and cached if missing, the
+// archive being verified against the checksum in any case.
+//
+//	workerBin, err := workerbin.Acquire(ctx, "3.12.16", workerbin.WithChecksum(sum))
+//	if err != nil {
+//		return err
+//	}
+//	worker, err := mediasoup.CreateWorker(workerBin, mediasoup.WithVersion("3.12.16"))
+func Acquire(ctx context.Context, version string, options ...Option) (workerBin string, err error) {
+	logger := mediasoup.TypeLogger("WorkerBinary")
+
+	if workerBin = os.Getenv("MEDIASOUP_WORKER_BIN"); len(workerBin) > 0 {
+		return
+	}
+
+	if !versionRegexp.MatchString(version) {
+		return "", mediasoup.NewValidationError("version", `invalid version "%s"`, version)
+	}
+
+	o := Options{
+		BaseUrl:    "https://github.com/versatica/mediasoup/releases/download",
+		HttpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+	for _, option := range options {
+		option(&o)
+	}
+
+	if len(o.CacheDir) == 0 {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		o.CacheDir = filepath.Join(cacheDir, "mediasoup-go")
+	}
+
+	archive, err := o.archiveName(version)
+	if err != nil {
+		return
+	}
+
+	if len(o.Checksum) == 0 {
+		return "", mediasoup.NewValidationError("Options.Checksum", "required to verify %s", archive)
+	}
+
+	archivePath := filepath.Join(o.CacheDir, version, archive)
+	workerBin = filepath.Join(o.CacheDir, version, strings.TrimSuffix(archive, ".tgz"), binaryName)
+
+	if strings.Contains(archive, "-win32-") {
+		workerBin += ".exe"
+	}
+
+	// The cached archive, unless tampered with or corrupted.
+	if data, err := ioutil.ReadFile(archivePath); err == nil {
+		if err = verifyArchive(archive, data, o.Checksum); err == nil {
+			logger.Debugf("using cached worker binary [path:%s]", workerBin)
+
+			return workerBin, installBinary(data, workerBin)
+		}
+
+		logger.Warnf("downloading the worker binary again: %s", err)
+	}
+
+	url := strings.TrimSuffix(o.BaseUrl, "/") + "/" + path.Join(version, archive)
+
+	var data []byte
+
+	err = o.Backoff.Retry(ctx, func(attempt int) (err error) {
+		logger.Infof("downloading worker binary [url:%s, attempt:%d]", url, attempt+1)
+
+		if data, err = o.download(ctx, url); err != nil {
+			return
+		}
+
+		return mediasoup.Permanent(verifyArchive(archive, data, o.Checksum))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = writeFileAtomic(archivePath, data, 0644); err != nil {
+		return "", err
+	}
+
+	if err = installBinary(data, workerBin); err != nil {
+		return "", err
+	}
+
+	return workerBin, nil
+}
+
+// verifyArchive checks the SHA-256 of the given archive.
+func verifyArchive(name string, data []byte, checksum string) error {
+	sum := sha256.Sum256(data)
+
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, checksum) {
+		return fmt.Errorf("%w: %s is %s, expected %s", ErrChecksum, name, actual, checksum)
+	}
+
+	return nil
+}
+
+// archiveName returns the name of the release archive of the worker, e.g.
+// "mediasoup-worker-3.12.16-linux-x64-kernel6.tgz".
+func (o Options) archiveName(version string) (string, error) {
+	platform, arch := o.Platform, o.Arch
+
+	if len(platform) == 0 {
+		switch runtime.GOOS {
+		case "linux", "darwin":
+			platform = runtime.GOOS
+		case "windows":
+			platform = "win32"
+		default:
+			return "", mediasoup.NewUnsupportedError("no prebuilt worker binary for %s", runtime.GOOS)
+		}
+	}
+	if len(arch) == 0 {
+		switch runtime.GOARCH {
+		case "amd64":
+			arch = "x64"
+		case "arm64":
+			arch = "arm64"
+		default:
+			return "", mediasoup.NewUnsupportedError("no prebuilt worker binary for %s", runtime.GOARCH)
+		}
+	}
+
+	name := fmt.Sprintf("%s-%s-%s-%s", binaryName, version, platform, arch)
+
+	if platform == "linux" {
+		kernelMajor := o.KernelMajor
+
+		if kernelMajor == 0 {
+			data, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+			if err == nil {
+				fmt.Sscanf(string(data), "%d.", &kernelMajor)
+			}
+			if kernelMajor == 0 {
+				return "", mediasoup.NewUnsupportedError("unknown Linux kernel version")
+			}
+		}
+
+		name += fmt.Sprintf("-kernel%d", kernelMajor)
+	}
+
+	return name + ".tgz", nil
+}
+
+func (o Options) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := o.HttpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		err = mediasoup.NewTypeError("download of %s failed: %s", url, rsp.Status)
+
+		// Only the server errors are worth retrying.
+		if rsp.StatusCode < http.StatusInternalServerError {
+			err = mediasoup.Permanent(err)
+		}
+
+		return nil, err
+	}
+
+	return ioutil.ReadAll(rsp.Body)
+}
+
+// installBinary writes the worker binary of the gzipped tar archive to
+// workerBin, unless already there, atomically so that concurrent acquisitions
+// never see it partly written.
+func installBinary(archive []byte, workerBin string) error {
+	binary, err := readBinary(archive)
+	if err != nil {
+		return err
+	}
+
+	if installed, err := ioutil.ReadFile(workerBin); err == nil && bytes.Equal(installed, binary) {
+		return nil
+	}
+
+	return writeFileAtomic(workerBin, binary, 0755)
+}
+
+// readBinary returns the worker binary of the gzipped tar archive.
+func readBinary(archive []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, mediasoup.NewTypeError("invalid worker archive: %s", err)
+	}
+	defer gz.Close()
+
+	r := tar.NewReader(gz)
+
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return nil, mediasoup.NewTypeError("no %s in the worker archive", binaryName)
+		}
+		if err != nil {
+			return nil, mediasoup.NewTypeError("invalid worker archive: %s", err)
+		}
+
+		name := path.Base(header.Name)
+
+		if header.Typeflag == tar.TypeReg &&
+			(name == binaryName || name == binaryName+".exe") {
+			return ioutil.ReadAll(r)
+		}
+	}
+}
+
+// writeFileAtomic writes the file through a temporary one renamed once
+// complete.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
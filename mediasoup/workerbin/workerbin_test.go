@@ -0,0 +1,189 @@
+package workerbin
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jiyeyuran/mediasoup-go/mediasoup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWorkerArchive(t *testing.T, content string) ([]byte, string) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+
+	require.NoError(t, w.WriteHeader(&tar.Header{
+		Name:     "bin/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+	}))
+	require.NoError(t, w.WriteHeader(&tar.Header{
+		Name:     "bin/mediasoup-worker",
+		Typeflag: tar.TypeReg,
+		Mode:     0755,
+		Size:     int64(len(content)),
+	}))
+	w.Write([]byte(content))
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+
+	sum := sha256.Sum256(buf.Bytes())
+
+	return buf.Bytes(), hex.EncodeToString(sum[:])
+}
+
+func TestAcquire(t *testing.T) {
+	if workerBin, ok := os.LookupEnv("MEDIASOUP_WORKER_BIN"); ok {
+		defer os.Setenv("MEDIASOUP_WORKER_BIN", workerBin)
+	}
+	os.Unsetenv("MEDIASOUP_WORKER_BIN")
+
+	archive, checksum := newWorkerArchive(t, "#!/bin/sh\n")
+
+	var downloads int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/3.12.16/mediasoup-worker-3.12.16-linux-x64-kernel6.tgz" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&downloads, 1)
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	cacheDir, err := ioutil.TempDir("", "mediasoup-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	options := []Option{
+		WithCacheDir(cacheDir),
+		WithBaseUrl(server.URL),
+		WithPlatform("linux", "x64", 6),
+	}
+
+	_, err = Acquire(context.Background(), "3.12.16", options...)
+	require.IsType(t, mediasoup.ValidationError{}, err)
+	assert.Equal(t, "Options.Checksum", err.(mediasoup.ValidationError).Field)
+
+	_, err = Acquire(context.Background(), "3.12.16",
+		append(options, WithChecksum("00"+checksum[2:]))...)
+	assert.True(t, errors.Is(err, ErrChecksum), err)
+
+	options = append(options, WithChecksum(checksum))
+
+	workerBin, err := Acquire(context.Background(), "3.12.16", options...)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "3.12.16", "mediasoup-worker-3.12.16-linux-x64-kernel6", "mediasoup-worker"), workerBin)
+
+	info, err := os.Stat(workerBin)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0755, info.Mode().Perm())
+
+	// Cached.
+	cached, err := Acquire(context.Background(), "3.12.16", options...)
+	require.NoError(t, err)
+	assert.Equal(t, workerBin, cached)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&downloads))
+
+	// A tampered binary is restored from the cached archive.
+	require.NoError(t, ioutil.WriteFile(workerBin, []byte("tampered"), 0755))
+
+	cached, err = Acquire(context.Background(), "3.12.16", options...)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&downloads))
+
+	data, err := ioutil.ReadFile(cached)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(data))
+
+	// A cached archive not matching the checksum is downloaded again.
+	archivePath := filepath.Join(cacheDir, "3.12.16", "mediasoup-worker-3.12.16-linux-x64-kernel6.tgz")
+	require.NoError(t, ioutil.WriteFile(archivePath, []byte("corrupted"), 0644))
+
+	_, err = Acquire(context.Background(), "3.12.16", options...)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&downloads))
+
+	_, err = Acquire(context.Background(), "3.13.0", options...)
+	assert.Error(t, err)
+
+	// Located through the environment.
+	os.Setenv("MEDIASOUP_WORKER_BIN", "/usr/bin/mediasoup-worker")
+
+	workerBin, err = Acquire(context.Background(), "3.12.16")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/mediasoup-worker", workerBin)
+
+	os.Unsetenv("MEDIASOUP_WORKER_BIN")
+}
+
+func TestAcquire_Retries(t *testing.T) {
+	if workerBin, ok := os.LookupEnv("MEDIASOUP_WORKER_BIN"); ok {
+		defer os.Setenv("MEDIASOUP_WORKER_BIN", workerBin)
+	}
+	os.Unsetenv("MEDIASOUP_WORKER_BIN")
+
+	archive, checksum := newWorkerArchive(t, "#!/bin/sh\n")
+
+	var downloads int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&downloads, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	cacheDir, err := ioutil.TempDir("", "mediasoup-go")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	options := []Option{
+		WithCacheDir(cacheDir),
+		WithBaseUrl(server.URL),
+		WithPlatform("darwin", "arm64", 0),
+		WithBackoff(mediasoup.Backoff{Initial: time.Millisecond, MaxRetries: 2}),
+	}
+
+	// Checksum mismatches are not retried.
+	_, err = Acquire(context.Background(), "3.12.16",
+		append(options, WithChecksum("00"+checksum[2:]))...)
+	assert.True(t, errors.Is(err, ErrChecksum), err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&downloads))
+
+	atomic.StoreInt32(&downloads, 0)
+
+	_, err = Acquire(context.Background(), "3.12.16",
+		append(options, WithChecksum(checksum))...)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&downloads))
+}
+
+func TestWorkerBinaryArchiveName(t *testing.T) {
+	name, err := Options{Platform: "darwin", Arch: "arm64"}.archiveName("3.12.16")
+	require.NoError(t, err)
+	assert.Equal(t, "mediasoup-worker-3.12.16-darwin-arm64.tgz", name)
+
+	name, err = Options{Platform: "linux", Arch: "x64", KernelMajor: 5}.archiveName("3.12.16")
+	require.NoError(t, err)
+	assert.Equal(t, "mediasoup-worker-3.12.16-linux-x64-kernel5.tgz", name)
+}